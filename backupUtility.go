@@ -0,0 +1,221 @@
+package main
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"go-image-board/config"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"go-image-board/routers"
+	"go-image-board/storage"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//backupFormatVersion is incremented whenever the archive layout or manifest changes in a way older importers cannot read
+const backupFormatVersion = 2
+
+//backupManifestName is the first entry of every archive, media files follow it under images/ and thumbs/
+const backupManifestName = "manifest.json"
+
+//backupManifest describes an archive and holds all of the board's database rows
+type backupManifest struct {
+	FormatVersion      int
+	ApplicationVersion string
+	Created            time.Time
+	//MissingFiles lists images whose file was not in the blob store when the archive was made
+	MissingFiles []string
+	Data         interfaces.BackupData
+}
+
+//exportArchive writes the database and every image and thumbnail to a tar archive at ArchivePath.
+//The archive is written next to ArchivePath and only renamed into place once complete, so a failed export never leaves a partial archive behind
+func exportArchive(ctx context.Context, ArchivePath string) error {
+	Data, err := database.DBInterface.ExportBackup(ctx)
+	if err != nil {
+		return err
+	}
+	Manifest := backupManifest{FormatVersion: backupFormatVersion, ApplicationVersion: config.ApplicationVersion, Created: time.Now().UTC(), Data: Data}
+	for _, Image := range Data.Images {
+		if _, err := storage.BlobStore.Stat(ctx, Image.Location); err != nil {
+			logging.WriteLog(logging.LogLevelWarning, "backupUtility/exportArchive", "0", logging.ResultFailure, []string{"Image file is missing and will not be in the archive", Image.Location, err.Error()})
+			Manifest.MissingFiles = append(Manifest.MissingFiles, Image.Location)
+		}
+	}
+
+	archiveFile, err := os.CreateTemp(filepath.Dir(ArchivePath), filepath.Base(ArchivePath)+".partial-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archiveFile.Name()) //Does nothing once renamed
+	defer archiveFile.Close()
+	archive := tar.NewWriter(archiveFile)
+	ManifestJSON, err := json.MarshalIndent(Manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := archive.WriteHeader(&tar.Header{Name: backupManifestName, Mode: 0640, Size: int64(len(ManifestJSON)), ModTime: Manifest.Created}); err != nil {
+		return err
+	}
+	if _, err := archive.Write(ManifestJSON); err != nil {
+		return err
+	}
+	missing := make(map[string]bool, len(Manifest.MissingFiles))
+	for _, Location := range Manifest.MissingFiles {
+		missing[Location] = true
+	}
+	for Index, Image := range Data.Images {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if missing[Image.Location] == false {
+			if err := addFileToArchive(ctx, archive, Image.Location, "images/"+Image.Location); err != nil {
+				return err
+			}
+		}
+		//Thumbnails can be regenerated with -thumbsonly, so a missing one is not worth a warning
+		if err := addFileToArchive(ctx, archive, routers.ThumbnailName(Image.Location), routers.ThumbnailName(Image.Location)); err != nil && errors.Is(err, fs.ErrNotExist) == false {
+			return err
+		}
+		if (Index+1)%1000 == 0 {
+			logging.WriteLog(logging.LogLevelInfo, "backupUtility/exportArchive", "0", logging.ResultInfo, []string{"Archived", strconv.Itoa(Index + 1), "of", strconv.Itoa(len(Data.Images)), "images"})
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	if err := archiveFile.Sync(); err != nil {
+		return err
+	}
+	if err := archiveFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(archiveFile.Name(), ArchivePath); err != nil {
+		return err
+	}
+	logging.WriteLog(logging.LogLevelInfo, "backupUtility/exportArchive", "0", logging.ResultSuccess, []string{"Exported", strconv.Itoa(len(Data.Users)), "users,", strconv.Itoa(len(Data.Images)), "images,", strconv.Itoa(len(Data.Tags)), "tags and", strconv.Itoa(len(Data.Collections)), "collections to", ArchivePath})
+	return nil
+}
+
+//addFileToArchive copies the blob stored under BlobName into archive as Name
+func addFileToArchive(ctx context.Context, archive *tar.Writer, BlobName string, Name string) error {
+	info, err := storage.BlobStore.Stat(ctx, BlobName)
+	if err != nil {
+		return err
+	}
+	file, err := storage.BlobStore.Open(ctx, BlobName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := archive.WriteHeader(&tar.Header{Name: Name, Mode: 0640, Size: info.Size, ModTime: info.ModTime}); err != nil {
+		return err
+	}
+	_, err = io.Copy(archive, file)
+	return err
+}
+
+//upgradeBackupAuditLogs converts audit log entries from format version 1 archives, which only had a type and free text, to typed events.
+//The type becomes the action and the text is kept under Info in the details, as the database migration does
+func upgradeBackupAuditLogs(AuditLogs []interfaces.BackupAuditLog) {
+	for Index := range AuditLogs {
+		Log := &AuditLogs[Index]
+		if Log.Action == "" {
+			Log.Action = interfaces.AuditAction(Log.Type)
+			Log.Details = json.RawMessage("{}")
+			if Log.Info != "" {
+				Log.Details, _ = json.Marshal(interfaces.AuditDetails{"Info": Log.Info})
+			}
+		}
+		Log.Type = ""
+		Log.Info = ""
+	}
+}
+
+//importArchive restores an archive made by exportArchive into a freshly installed database and an empty blob store.
+//Rows are imported in one transaction, which is only committed once every file has been stored. On failure the stored files are removed again
+func importArchive(ctx context.Context, ArchivePath string) error {
+	archiveFile, err := os.Open(ArchivePath)
+	if err != nil {
+		return err
+	}
+	defer archiveFile.Close()
+	archive := tar.NewReader(archiveFile)
+	header, err := archive.Next()
+	if err != nil {
+		return errors.New("failed to read archive: " + err.Error())
+	}
+	if header.Name != backupManifestName {
+		return errors.New("archive does not start with " + backupManifestName + ", it was not made by -export")
+	}
+	var Manifest backupManifest
+	if err := json.NewDecoder(archive).Decode(&Manifest); err != nil {
+		return errors.New("failed to parse " + backupManifestName + ": " + err.Error())
+	}
+	if Manifest.FormatVersion == 1 {
+		upgradeBackupAuditLogs(Manifest.Data.AuditLogs)
+	} else if Manifest.FormatVersion != backupFormatVersion {
+		return errors.New("archive format version " + strconv.Itoa(Manifest.FormatVersion) + " is not supported by this version of gib")
+	}
+	for _, Location := range Manifest.MissingFiles {
+		logging.WriteLog(logging.LogLevelWarning, "backupUtility/importArchive", "0", logging.ResultInfo, []string{"Image file was missing when the archive was made and will still be missing", Location})
+	}
+
+	//Only files belonging to an image in the manifest are extracted
+	expected := make(map[string]bool)
+	for _, Image := range Manifest.Data.Images {
+		expected["images/"+Image.Location] = true
+		expected["thumbs/"+Image.Location+".png"] = true
+	}
+	for _, Location := range Manifest.MissingFiles {
+		delete(expected, "images/"+Location)
+	}
+	var writtenFiles []string
+	err = database.DBInterface.RunInTransaction(ctx, func(Tx interfaces.DBInterface) error {
+		if err := Tx.ImportBackup(ctx, Manifest.Data); err != nil {
+			return err
+		}
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return errors.New("failed to read archive: " + err.Error())
+			}
+			if header.Typeflag != tar.TypeReg || expected[header.Name] == false || header.Name != path.Clean(header.Name) {
+				return errors.New("archive has an unexpected entry " + header.Name)
+			}
+			delete(expected, header.Name)
+			//Images are stored under their location, thumbnails keep their thumbs/ prefix
+			BlobName := strings.TrimPrefix(header.Name, "images/")
+			if err := storage.BlobStore.Create(ctx, BlobName, archive, header.Size); err != nil {
+				return err
+			}
+			writtenFiles = append(writtenFiles, BlobName)
+		}
+		for Name := range expected {
+			if strings.HasPrefix(Name, "images/") {
+				return errors.New("archive is missing " + Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, BlobName := range writtenFiles {
+			storage.BlobStore.Delete(context.WithoutCancel(ctx), BlobName)
+		}
+		return err
+	}
+	logging.WriteLog(logging.LogLevelInfo, "backupUtility/importArchive", "0", logging.ResultSuccess, []string{"Imported", strconv.Itoa(len(Manifest.Data.Users)), "users,", strconv.Itoa(len(Manifest.Data.Images)), "images,", strconv.Itoa(len(Manifest.Data.Tags)), "tags and", strconv.Itoa(len(Manifest.Data.Collections)), "collections from", ArchivePath})
+	return nil
+}
@@ -13,6 +13,12 @@ import (
 
 //ConfigurationSettings contains the structure of all the settings that will be loaded at runtime.
 type ConfigurationSettings struct {
+	//DBType which database plugin to use, mariadb (default), sqlite, postgres or memory
+	DBType string
+	//DBFile path to the database file, only used by the sqlite plugin
+	DBFile string
+	//DBSSLMode sslmode passed to the postgres plugin, disable by default
+	DBSSLMode string
 	//DBName is the name of the db used for this instance
 	DBName string
 	//DBUser is the user name used to auth to the db
@@ -23,8 +29,26 @@ type ConfigurationSettings struct {
 	DBPort string
 	//DBHost hostname of the database server
 	DBHost string
-	//ImageDirectory path to where images are stored
+	//ImageDirectory path to where images are stored, when BlobStore is local
 	ImageDirectory string
+	//BlobStore where images and thumbnails are stored, local (default) for ImageDirectory or s3 for an S3 compatible bucket
+	BlobStore string
+	//S3Endpoint URL of the S3 compatible service, such as https://s3.us-east-1.amazonaws.com
+	S3Endpoint string
+	//S3Region region of the bucket, us-east-1 by default
+	S3Region string
+	//S3Bucket name of the bucket media files are stored in
+	S3Bucket string
+	//S3Prefix is prepended to the name of every media file in the bucket, so a bucket can be shared
+	S3Prefix string
+	//S3AccessKey access key ID used to sign requests to the bucket
+	S3AccessKey string
+	//S3SecretKey secret access key used to sign requests to the bucket
+	S3SecretKey string
+	//S3PathStyle if set, the bucket is addressed as part of the path instead of the host name, needed by most self hosted services
+	S3PathStyle bool
+	//S3PresignSeconds if above 0, images and thumbnails are served by redirecting to a presigned URL valid this long, instead of passing through gib
+	S3PresignSeconds int64
 	//Address hostname/port that this server should listen on
 	Address string
 	//ReadTimeout timeout allowed for reads
@@ -57,6 +81,8 @@ type ConfigurationSettings struct {
 	UsersControlOwnObjects bool
 	//FFMPEGPath Path to the FFMPEG application
 	FFMPEGPath string
+	//FFProbePath Path to the ffprobe application, used to read the size and length of videos and audio. Defaults to ffprobe next to FFMPEGPath
+	FFProbePath string
 	//UseFFMPEG If set, when joined with FFMPEGPath, videos that are uploaded will have a thumbnail generated using FFMPEG
 	UseFFMPEG bool
 	//PageStride How many images to show on one page
@@ -69,6 +95,18 @@ type ConfigurationSettings struct {
 	TLSCertPath string
 	//TLSKeyPath The path to the TLS/SSL key file for the cert
 	TLSKeyPath string
+	//TrashRetentionDays How many days deleted images stay in the recycle bin before they are purged. Negative values keep them until purged by hand.
+	//0 counts as unset and becomes the default of 30, so the shortest retention is 1
+	TrashRetentionDays int64
+	//AuditRetentionDays How many days entries stay in the audit log before they are deleted. Negative values keep them forever.
+	//0 counts as unset and becomes the default of 30
+	AuditRetentionDays int64
+	//AuditArchiveDirectory if set, expired audit log entries are written here as gzip compressed JSON lines files before they are deleted
+	AuditArchiveDirectory string
+	//JobWorkers How many background jobs, such as generating thumbnails and dHashes, run at the same time
+	JobWorkers uint64
+	//JobMaxAttempts How many times a background job is tried before it is marked failed
+	JobMaxAttempts uint64
 	//ShowSimilarOnImages If enabled, shows similar count and link when viewing an image
 	ShowSimilarOnImages bool
 	//TargetLogLevel increase or decrease log verbosity
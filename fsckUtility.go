@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"go-image-board/routers"
+	"go-image-board/storage"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//fsckRepairs are the repairs -repair accepts, all makes every one of them
+var fsckRepairs = []string{"missing", "thumbs", "dhashes", "members"}
+
+//parseFsckRepairs reads the comma separated list given to -repair
+func parseFsckRepairs(Repairs string) (map[string]bool, error) {
+	ToReturn := make(map[string]bool)
+	for _, Repair := range strings.Split(Repairs, ",") {
+		Repair = strings.ToLower(strings.TrimSpace(Repair))
+		switch {
+		case Repair == "":
+		case Repair == "all":
+			for _, Repair := range fsckRepairs {
+				ToReturn[Repair] = true
+			}
+		case slices.Contains(fsckRepairs, Repair):
+			ToReturn[Repair] = true
+		default:
+			return nil, errors.New("unknown repair " + Repair + ", expected " + strings.Join(fsckRepairs, ", ") + " or all")
+		}
+	}
+	return ToReturn, nil
+}
+
+//fsckCounts is how many of one kind of problem checkLibrary found, and how many of those it repaired
+type fsckCounts struct {
+	Found    int
+	Repaired int
+}
+
+//checkLibrary checks that the database and blob store agree, printing each problem it finds and making the requested repairs.
+//It returns how many problems are left unrepaired
+func checkLibrary(Repairs map[string]bool) (int, error) {
+	ctx := context.Background()
+	images, err := getAllImages(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var Missing, Mismatched, Thumbnails, DHashes, Members, Unchecked fsckCounts
+	//report prints one problem and counts it, along with whether it was repaired
+	report := func(Counts *fsckCounts, Repair string, Problem string, Fix func() error) {
+		Counts.Found++
+		if Repairs[Repair] == false || Fix == nil {
+			fmt.Println(Problem)
+			return
+		}
+		if err := Fix(); err != nil {
+			fmt.Println(Problem, "- repair failed:", err.Error())
+			return
+		}
+		Counts.Repaired++
+		fmt.Println(Problem, "- repaired")
+	}
+
+	for index, imageInfo := range images {
+		if index > 0 && index%1000 == 0 {
+			logging.WriteLog(logging.LogLevelInfo, "fsckUtility/checkLibrary", "0", logging.ResultInfo, []string{"Checked images", strconv.Itoa(index), "of", strconv.Itoa(len(images))})
+		}
+		imageName := "Image " + strconv.FormatUint(imageInfo.ID, 10) + " (" + imageInfo.Location + ")"
+
+		//The file must exist, and be named after its content
+		fileStream, err := storage.BlobStore.Open(ctx, imageInfo.Location)
+		if errors.Is(err, fs.ErrNotExist) {
+			var Fix func() error
+			if imageInfo.DeletedTime.IsZero() == false {
+				imageName += " in the recycle bin"
+			} else {
+				Fix = func() error {
+					if err := database.DBInterface.TrashImage(ctx, imageInfo.ID, 0); err != nil {
+						return err
+					}
+					routers.WriteAuditLog(ctx, 0, interfaces.AuditImageDelete, interfaces.AuditTargetImage, imageInfo.ID, interfaces.AuditDetails{"Name": imageInfo.Name, "Location": imageInfo.Location, "Reason": "file missing"})
+					return nil
+				}
+			}
+			report(&Missing, "missing", imageName+": file is missing", Fix)
+			continue
+		} else if err != nil {
+			report(&Unchecked, "", imageName+": could not open file: "+err.Error(), nil)
+			continue
+		}
+		expectedName, err := routers.GetNewImageName(imageInfo.Location, fileStream)
+		fileStream.Close()
+		if err != nil {
+			report(&Unchecked, "", imageName+": could not hash file: "+err.Error(), nil)
+		} else if expectedName != imageInfo.Location && expectedName == routers.ShardedImageName(imageInfo.Location) {
+			report(&Mismatched, "", imageName+": file is still in the flat layout, run -shardonly to move it", nil)
+		} else if expectedName != imageInfo.Location {
+			report(&Mismatched, "", imageName+": content does not match its name, expected "+expectedName, nil)
+		}
+
+		if routers.CanGenerateThumbnail(imageInfo.Location) {
+			if _, err := storage.BlobStore.Stat(ctx, routers.ThumbnailName(imageInfo.Location)); errors.Is(err, fs.ErrNotExist) {
+				report(&Thumbnails, "thumbs", imageName+": thumbnail is missing", func() error {
+					return routers.GenerateThumbnail(imageInfo.Location)
+				})
+			} else if err != nil {
+				report(&Unchecked, "", imageName+": could not stat thumbnail: "+err.Error(), nil)
+			}
+		}
+
+		if routers.CanGeneratedHash(imageInfo.Location) {
+			if _, _, err := database.DBInterface.GetImagedHash(ctx, imageInfo.ID); err == sql.ErrNoRows {
+				report(&DHashes, "dhashes", imageName+": dHash is missing", func() error {
+					return routers.GeneratedHash(imageInfo.Location, imageInfo.ID)
+				})
+			} else if err != nil {
+				report(&Unchecked, "", imageName+": could not get dHash: "+err.Error(), nil)
+			}
+		}
+	}
+
+	orphans, err := database.DBInterface.GetOrphanedCollectionMembers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, Member := range orphans {
+		report(&Members, "members", "Collection "+strconv.FormatUint(Member.CollectionID, 10)+": member image "+strconv.FormatUint(Member.ImageID, 10)+" does not exist", func() error {
+			return database.DBInterface.RemoveCollectionMember(ctx, Member.CollectionID, Member.ImageID)
+		})
+	}
+
+	fmt.Println()
+	fmt.Println("Checked", len(images), "images")
+	Remaining := 0
+	for _, Line := range []struct {
+		Name   string
+		Counts fsckCounts
+	}{
+		{"Missing files", Missing},
+		{"Files not matching their name", Mismatched},
+		{"Missing thumbnails", Thumbnails},
+		{"Missing dHashes", DHashes},
+		{"Orphaned collection members", Members},
+		{"Could not be checked", Unchecked},
+	} {
+		fmt.Println(Line.Name+":", Line.Counts.Found, "found,", Line.Counts.Repaired, "repaired")
+		Remaining += Line.Counts.Found - Line.Counts.Repaired
+	}
+	return Remaining, nil
+}
@@ -3,22 +3,28 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"flag"
+	"fmt"
 	"go-image-board/config"
 	"go-image-board/database"
 	"go-image-board/interfaces"
 	"go-image-board/logging"
 	"go-image-board/plugins"
+	"go-image-board/plugins/localblobplugin"
 	"go-image-board/plugins/mariadbplugin"
+	"go-image-board/plugins/memoryplugin"
+	"go-image-board/plugins/postgresplugin"
+	"go-image-board/plugins/s3blobplugin"
+	"go-image-board/plugins/sqliteplugin"
 	"go-image-board/routers"
 	"go-image-board/routers/api"
 	"go-image-board/routers/templatecache"
-	"io/ioutil"
+	"go-image-board/storage"
 	"net/http"
 	"os"
-	"path"
 	"path/filepath"
-	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,8 +37,12 @@ func main() {
 	//Commands
 	generateThumbsOnly := flag.Bool("thumbsonly", false, "Regenerates all thumbnails. You should run this if you change your thumbnail size or enable ffmpeg.")
 	generatedHashesOnly := flag.Bool("dhashonly", false, "Regenerates all dhashes. You should run this if you change hash method, or after updating past 1.0.3.8")
-	missingOnly := flag.Bool("missingonly", false, "When used with dhashonly or thumbsonly, prevents deleting pre-existing entries.")
+	generateMetadataOnly := flag.Bool("metadataonly", false, "Reads the metadata of all images. You should run this after updating, or after enabling ffmpeg to read the length of videos.")
+	missingOnly := flag.Bool("missingonly", false, "When used with dhashonly, thumbsonly or metadataonly, only generates entries that are missing.")
 	renameFilesOnly := flag.Bool("renameonly", false, "Renames all posts and corrects the names in the database. Use if changing naming convention of files.")
+	shardFilesOnly := flag.Bool("shardonly", false, "Moves images and thumbnails stored in the old flat layout into hash prefix subdirectories and corrects their locations in the database.")
+	fsck := flag.Bool("fsck", false, "Checks every image for a missing file, content that does not match its name and a missing thumbnail or dHash, and every collection for members whose image no longer exists, prints a report, then exits.")
+	repairs := flag.String("repair", "", "With -fsck, a comma separated list of repairs to make: missing moves images whose file is missing to the recycle bin, thumbs and dhashes generate missing thumbnails and dHashes, members removes collection members whose image no longer exists. all makes every repair.")
 	removeOrphanFiles := flag.Bool("removeorphanfiles", false, "Removes images and thumbnails that do not have an associated database entry.")
 	username := flag.String("username", "", "username for user edits (add/change password)")
 	email := flag.String("email", "", "email for user insertion")
@@ -41,6 +51,10 @@ func main() {
 	addUser := flag.Bool("adduser", false, "Add new user")
 	changePassword := flag.Bool("changepassword", false, "Modify user password")
 	removeUser := flag.Bool("removeuser", false, "Remove existing user")
+	migrateStatus := flag.Bool("migrate-status", false, "Prints the database schema version, the pending migrations and any differences between the schema and a fresh install, then exits.")
+	migrateDryRun := flag.Bool("migrate-dry-run", false, "Prints the SQL of the pending migrations without running them, then exits.")
+	exportPath := flag.String("export", "", "Writes the database, images and thumbnails to this archive file, then exits.")
+	importPath := flag.String("import", "", "Restores an archive made with -export into a freshly installed database and empty blob store, then exits.")
 	flag.Parse()
 
 	//Load succeeded
@@ -59,43 +73,25 @@ func main() {
 	//Init logging
 	logging.LogInterface.Init(config.Configuration.TargetLogLevel, config.Configuration.LoggingWhiteList, config.Configuration.LoggingBlackList)
 
-	if *generateThumbsOnly {
-		logging.WriteLog(logging.LogLevelInfo, "main/main", "0", logging.ResultInfo, []string{"Generate thumbnails flag detected. Server will not start and instead just generate thumbnails. This may take some time."})
-		//We need wait group so that we don't end the application before goroutines
-		var wg sync.WaitGroup
-		//list files
-		files, err := ioutil.ReadDir(config.Configuration.ImageDirectory)
-		if err != nil {
-			logging.WriteLog(logging.LogLevelError, "main/main", "0", logging.ResultFailure, []string{"failed to get files to generate new thumbnails", err.Error()})
-			return
-		}
-		//for each image
-		generatedThumbnails := uint64(0)
-		for _, file := range files {
-			if file.IsDir() {
-				continue
-			}
-			//Delete thumbnail
-			thumbNailPath := config.Configuration.ImageDirectory + string(filepath.Separator) + "thumbs" + string(filepath.Separator) + file.Name() + ".png"
-			if _, err := os.Stat(thumbNailPath); *missingOnly == false || (err != nil && os.IsNotExist(err)) {
-				os.Remove(thumbNailPath)
-				//Goroutine generate a new one
-				generatedThumbnails++
-				wg.Add(1) //This magic thing will prevent program from closing before goroutines finish
-				go func(fileName string) {
-					defer wg.Done()
-					routers.GenerateThumbnail(fileName)
-				}(file.Name())
-			}
-			if generatedThumbnails%config.Configuration.PageStride == 0 {
-				wg.Wait() //Throttle how fast we generate thumbnails
-			}
+	if *migrateStatus || *migrateDryRun {
+		if err := printMigrationPlan(*migrateStatus, *migrateDryRun); err != nil {
+			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Failed to plan migrations", err.Error()})
+			os.Exit(1)
 		}
-		wg.Wait() //This will wait for all goroutines to finish
-		logging.WriteLog(logging.LogLevelInfo, "main/main", "0", logging.ResultSuccess, []string{"Finished generating " + strconv.FormatUint(generatedThumbnails, 10) + " new thumbnails."})
 		return //We do not want to start server if used in cli
 	}
 
+	//Start the blob store that holds images and thumbnails
+	blobStore, err := getBlobStore()
+	if err == nil {
+		err = blobStore.Init(context.Background())
+	}
+	if err != nil {
+		logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Failed to start the blob store", err.Error()})
+		os.Exit(1)
+	}
+	storage.BlobStore = blobStore
+
 	//Resave config file
 	config.SaveConfiguration(configPath)
 
@@ -106,11 +102,12 @@ func main() {
 	api.Throttle.Init()
 
 	//If we can, start the database
-	if config.Configuration.DBName == "" || config.Configuration.DBPassword == "" || config.Configuration.DBUser == "" || config.Configuration.DBHost == "" {
-		logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Missing database information. (Instance, User, Password?)"})
+	dbPlugin, pluginErr := getDatabasePlugin()
+	if pluginErr != nil {
+		logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{pluginErr.Error()})
 	} else {
 		//Initialize DB Connection
-		database.DBInterface = &mariadbplugin.MariaDBPlugin{}
+		database.DBInterface = dbPlugin
 		err = database.DBInterface.InitDatabase()
 		if err != nil {
 			logging.WriteLog(logging.LogLevelError, "main/main", "0", logging.ResultFailure, []string{"Failed to connect to database. Will keep trying. ", err.Error()})
@@ -147,6 +144,22 @@ func main() {
 		configConfirmed = true
 	}
 
+	if *exportPath != "" {
+		if err := exportArchive(context.Background(), *exportPath); err != nil {
+			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Failed to export archive", err.Error()})
+			os.Exit(1)
+		}
+		return //We do not want to start server if used in cli
+	}
+
+	if *importPath != "" {
+		if err := importArchive(context.Background(), *importPath); err != nil {
+			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Failed to import archive, nothing was restored", err.Error()})
+			os.Exit(1)
+		}
+		return //We do not want to start server if used in cli
+	}
+
 	if *addUser {
 		if *username == "" {
 			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultInfo, []string{"Missing new username"})
@@ -167,7 +180,7 @@ func main() {
 
 		logging.WriteLog(logging.LogLevelInfo, "main/main", "0", logging.ResultInfo, []string{"Adding new user: ", *username, *email})
 
-		err := database.DBInterface.CreateUser(*username, []byte(*password), *email, uint64(*permits))
+		err := database.DBInterface.CreateUser(context.Background(), *username, []byte(*password), *email, uint64(*permits))
 		if err != nil {
 			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultInfo, []string{"error creating new user: ", err.Error()})
 		}
@@ -180,7 +193,7 @@ func main() {
 			return
 		}
 
-		err := database.DBInterface.RemoveUser(*username)
+		err := database.DBInterface.RemoveUser(context.Background(), *username)
 		if err != nil {
 			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultInfo, []string{"error removing user: ", err.Error()})
 		}
@@ -198,104 +211,60 @@ func main() {
 			logging.WriteLog(logging.LogLevelInfo, "main/main", "0", logging.ResultInfo, []string{"Generated new password '" + *password + "'"})
 		}
 
-		err := database.DBInterface.SetUserPassword(*username, nil, []byte(*password), nil, nil, nil, true)
+		err := database.DBInterface.SetUserPassword(context.Background(), *username, nil, []byte(*password), nil, nil, nil, true)
 		if err != nil {
 			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultInfo, []string{"error changing user password: ", err.Error()})
 		}
 		return
 	}
 
+	if *generateThumbsOnly {
+		logging.WriteLog(logging.LogLevelInfo, "main/main", "0", logging.ResultInfo, []string{"Generate thumbnails flag detected. Server will not start and instead just generate thumbnails. This may take some time."})
+		if err := regenerateAll(interfaces.JobThumbnail, *missingOnly); err != nil {
+			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Failed to generate thumbnails", err.Error()})
+			os.Exit(1)
+		}
+		return //We do not want to start server if used in cli
+	}
 	if *generatedHashesOnly {
 		logging.WriteLog(logging.LogLevelInfo, "main/main", "0", logging.ResultInfo, []string{"Generate dHashes flag detected. Server will not start and instead just generate dHashes. This will take some time."})
-		//We need wait group so that we don't end the application before goroutines
-		var wg sync.WaitGroup
-		//for each image in the database
-		page := uint64(0)
-		processedImages := uint64(0)
-		for true {
-			images, maxCount, err := database.DBInterface.SearchImages([]interfaces.TagInformation{}, page, config.Configuration.PageStride)
-			page += config.Configuration.PageStride
-			if err != nil {
-				logging.WriteLog(logging.LogLevelError, "main/main", "0", logging.ResultFailure, []string{"Error processing hashes.", err.Error()})
-				break
-			}
-			if len(images) <= 0 {
-				logging.WriteLog(logging.LogLevelInfo, "main/main", "0", logging.ResultInfo, []string{"Finished queing images"})
-				break
-			}
-			logging.WriteLog(logging.LogLevelInfo, "main/main", "0", logging.ResultInfo, []string{"Queing", strconv.FormatUint(page, 10), "of", strconv.FormatUint(maxCount, 10)})
-			for _, nextImage := range images {
-				var dhashExists error
-				if *missingOnly {
-					_, _, dhashExists = database.DBInterface.GetImagedHash(nextImage.ID)
-				}
-				if *missingOnly == false || dhashExists != nil {
-					processedImages++
-					wg.Add(1) //This magic thing will prevent program from closing before goroutines finish
-					go func(fileName string, imageID uint64) {
-						defer wg.Done()
-						routers.GeneratedHash(fileName, imageID)
-					}(nextImage.Location, nextImage.ID)
-				}
-			}
-			wg.Wait() //This will wait for all goroutines to finish
+		if err := regenerateAll(interfaces.JobdHash, *missingOnly); err != nil {
+			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Failed to generate dHashes", err.Error()})
+			os.Exit(1)
 		}
-		logging.WriteLog(logging.LogLevelInfo, "main/main", "0", logging.ResultInfo, []string{"Waiting for images to finish processing"})
-		wg.Wait() //This will wait for all goroutines to finish
-		logging.WriteLog(logging.LogLevelInfo, "main/main", "0", logging.ResultSuccess, []string{"Finished generating " + strconv.FormatUint(processedImages, 10) + " new dHashes."})
-
 		return //We do not want to start server if used in cli
 	}
-	if *removeOrphanFiles {
-		//Scan image directory
-		files, err := ioutil.ReadDir(config.Configuration.ImageDirectory)
-		if err != nil {
-			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Failed to get images from directory", err.Error()})
-			return
+	if *generateMetadataOnly {
+		logging.WriteLog(logging.LogLevelInfo, "main/main", "0", logging.ResultInfo, []string{"Generate metadata flag detected. Server will not start and instead just read the metadata of images. This may take some time."})
+		if err := regenerateAll(interfaces.JobMetadata, *missingOnly); err != nil {
+			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Failed to read metadata", err.Error()})
+			os.Exit(1)
 		}
-		for _, file := range files {
-			if file.IsDir() {
-				continue
+		return //We do not want to start server if used in cli
+	}
+	if *removeOrphanFiles {
+		//Scan every image and thumbnail in the blob store
+		err := storage.BlobStore.List(context.Background(), "", func(file interfaces.BlobInfo) error {
+			//Thumbnails are matched on the name of their image
+			imageName := file.Name
+			if strings.HasPrefix(imageName, "thumbs/") {
+				imageName = strings.TrimSuffix(strings.TrimPrefix(imageName, "thumbs/"), ".png")
 			}
 			//Search database for matching image entry
-			_, err := database.DBInterface.GetImageByFileName(file.Name())
+			_, err := database.DBInterface.GetImageByFileName(context.Background(), imageName)
 			if err != nil && err == sql.ErrNoRows {
-				logging.WriteLog(logging.LogLevelWarning, "main/main", "0", logging.ResultInfo, []string{"Failed to get image from database, it will be deleted", file.Name()})
+				logging.WriteLog(logging.LogLevelWarning, "main/main", "0", logging.ResultInfo, []string{"Failed to get image from database, it will be deleted", file.Name})
 				//If database entry does not exist, delete the image
-				err = os.Remove(path.Join(config.Configuration.ImageDirectory, file.Name()))
-				if err != nil {
-					logging.WriteLog(logging.LogLevelError, "main/main", "0", logging.ResultFailure, []string{"Failed to delete image", file.Name(), err.Error()})
+				if err := storage.BlobStore.Delete(context.Background(), file.Name); err != nil {
+					logging.WriteLog(logging.LogLevelError, "main/main", "0", logging.ResultFailure, []string{"Failed to delete file", file.Name, err.Error()})
 				}
 			} else if err != nil {
-				logging.WriteLog(logging.LogLevelError, "main/main", "0", logging.ResultFailure, []string{"Failed to get image from database due to an unexpected db error, it will be skipped", file.Name(), err.Error()})
+				logging.WriteLog(logging.LogLevelError, "main/main", "0", logging.ResultFailure, []string{"Failed to get image from database due to an unexpected db error, it will be skipped", file.Name, err.Error()})
 			}
-		}
-		//Rinse&repeat with the thumbnails
-		files, err = ioutil.ReadDir(path.Join(config.Configuration.ImageDirectory, "thumbs"))
+			return nil
+		})
 		if err != nil {
-			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Failed to get images from directory", err.Error()})
-			return
-		}
-		for _, file := range files {
-			if file.IsDir() {
-				continue
-			}
-			//Search database for matching image entry
-			imageName := file.Name()
-			if len(imageName) > 4 { //Strip .png to get original name
-				imageName = imageName[:len(imageName)-4]
-			}
-			_, err := database.DBInterface.GetImageByFileName(imageName)
-			if err != nil && err == sql.ErrNoRows {
-				logging.WriteLog(logging.LogLevelWarning, "main/main", "0", logging.ResultInfo, []string{"Failed to get image from database, it will be deleted", file.Name()})
-				//If database entry does not exist, delete the image
-				err = os.Remove(path.Join(path.Join(config.Configuration.ImageDirectory, "thumbs"), file.Name()))
-				if err != nil {
-					logging.WriteLog(logging.LogLevelError, "main/main", "0", logging.ResultFailure, []string{"Failed to delete thumbnail", file.Name(), err.Error()})
-				}
-			} else if err != nil {
-				logging.WriteLog(logging.LogLevelError, "main/main", "0", logging.ResultFailure, []string{"Failed to get image from database due to an unexpected db error, it will be skipped", file.Name(), err.Error()})
-			}
+			logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Failed to get images from the blob store", err.Error()})
 		}
 
 		return //We do not want to start server if used in cli
@@ -320,17 +289,44 @@ func main() {
 			renameAllImages()
 			return //We only wanted to rename
 		}
+		if *fsck {
+			fsckRepairs, err := parseFsckRepairs(*repairs)
+			if err != nil {
+				logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Invalid -repair", err.Error()})
+				os.Exit(2)
+			}
+			remaining, err := checkLibrary(fsckRepairs)
+			if err != nil {
+				logging.WriteLog(logging.LogLevelCritical, "main/main", "0", logging.ResultFailure, []string{"Failed to check library", err.Error()})
+				os.Exit(2)
+			}
+			if remaining > 0 {
+				os.Exit(1)
+			}
+			return //We only wanted to check the library
+		}
+		if *shardFilesOnly {
+			shardAllImages()
+			return //We only wanted to move files
+		}
+		//Purge images that have outlived the recycle bin retention period
+		routers.StartTrashPurge()
+		//Expire, and optionally archive, audit log entries that have outlived their retention period
+		routers.StartAuditRetention()
+		//Generate thumbnails and dHashes in the background
+		routers.StartJobWorkers()
 		//Web routers
 		requestRouter.HandleFunc("/resources/{file}", routers.ResourceRouter).Methods("GET")
 		requestRouter.HandleFunc("/", routers.AccountRequiredMiddleWare(routers.RootRouter)).Methods("GET")
 		requestRouter.HandleFunc("/images", routers.AccountRequiredMiddleWare(routers.ImageQueryRouter)).Methods("GET")
+		requestRouter.HandleFunc("/calendar", routers.AccountRequiredMiddleWare(routers.CalendarRouter)).Methods("GET")
 		requestRouter.HandleFunc("/collectionorder", routers.AccountRequiredMiddleWare(routers.CollectionImageOrderGetRouter)).Methods("GET")
 		requestRouter.HandleFunc("/collectionorder", routers.AccountRequiredMiddleWare(routers.CollectionImageOrderPostRouter)).Methods("POST")
 		requestRouter.HandleFunc("/collection", routers.AccountRequiredMiddleWare(routers.CollectionGetRouter)).Methods("GET")
 		requestRouter.HandleFunc("/collection", routers.AccountRequiredMiddleWare(routers.CollectionPostRouter)).Methods("POST")
 		requestRouter.HandleFunc("/collections", routers.AccountRequiredMiddleWare(routers.CollectionsRouter)).Methods("GET")
-		requestRouter.HandleFunc("/images/{file}", routers.AccountRequiredMiddleWare(routers.ResourceImageRouter)).Methods("GET")
-		requestRouter.HandleFunc("/thumbs/{file}", routers.AccountRequiredMiddleWare(routers.ThumbnailRouter)).Methods("GET")
+		requestRouter.HandleFunc("/images/{file:.+}", routers.AccountRequiredMiddleWare(routers.ResourceImageRouter)).Methods("GET")
+		requestRouter.HandleFunc("/thumbs/{file:.+}", routers.AccountRequiredMiddleWare(routers.ThumbnailRouter)).Methods("GET")
 		requestRouter.HandleFunc("/image", routers.AccountRequiredMiddleWare(routers.ImageGetRouter)).Methods("GET")
 		requestRouter.HandleFunc("/image", routers.AccountRequiredMiddleWare(routers.ImagePostRouter)).Methods("POST")
 		requestRouter.HandleFunc("/uploadImage", routers.AccountRequiredMiddleWare(routers.UploadFormRouter)).Methods("GET")
@@ -344,6 +340,11 @@ func main() {
 		requestRouter.HandleFunc("/mod", routers.AccountRequiredMiddleWare(routers.ModRouter)).Methods("GET")
 		requestRouter.HandleFunc("/mod/user", routers.AccountRequiredMiddleWare(routers.ModUserGetRouter)).Methods("GET")
 		requestRouter.HandleFunc("/mod/user", routers.AccountRequiredMiddleWare(routers.ModUserPostRouter)).Methods("POST")
+		requestRouter.HandleFunc("/mod/trash", routers.AccountRequiredMiddleWare(routers.ModTrashGetRouter)).Methods("GET")
+		requestRouter.HandleFunc("/mod/trash", routers.AccountRequiredMiddleWare(routers.ModTrashPostRouter)).Methods("POST")
+		requestRouter.HandleFunc("/mod/audit", routers.AccountRequiredMiddleWare(routers.ModAuditGetRouter)).Methods("GET")
+		requestRouter.HandleFunc("/mod/jobs", routers.AccountRequiredMiddleWare(routers.ModJobsGetRouter)).Methods("GET")
+		requestRouter.HandleFunc("/mod/jobs", routers.AccountRequiredMiddleWare(routers.ModJobsPostRouter)).Methods("POST")
 
 		//API routers
 		requestRouter.HandleFunc("/api/Collection/{CollectionID}", api.CollectionGetAPIRouter).Methods("GET")
@@ -362,6 +363,10 @@ func main() {
 		requestRouter.HandleFunc("/api/Logon", api.LogonAPIRouter).Methods("POST")
 		requestRouter.HandleFunc("/api/Logout", api.LogoutAPIRouter).Methods("POST")
 		requestRouter.HandleFunc("/api/Users", api.UsersAPIRouter).Methods("GET")
+		requestRouter.HandleFunc("/api/AuditLogs", api.AuditLogsGetAPIRouter).Methods("GET")
+		requestRouter.HandleFunc("/api/SavedSearches", api.SavedSearchesGetAPIRouter).Methods("GET")
+		requestRouter.HandleFunc("/api/SavedSearches", api.SavedSearchesPostAPIRouter).Methods("POST")
+		requestRouter.HandleFunc("/api/SavedSearch/{Name}", api.SavedSearchDeleteAPIRouter).Methods("DELETE")
 		//Autocomplete helpers
 		requestRouter.HandleFunc("/api/TagName", api.TagNameAPIRouter).Methods("GET")
 		requestRouter.HandleFunc("/api/CollectionName", api.CollectionNameAPIRouter).Methods("GET")
@@ -399,16 +404,113 @@ func main() {
 	}
 }
 
+//getDatabasePlugin returns the database plugin selected by DBType, or an error if the settings it needs are missing
+//printMigrationPlan prints what InitDatabase would do to the configured database without changing it.
+//Status includes the comparison against a fresh install, DryRun includes the SQL of each pending migration
+func printMigrationPlan(Status bool, DryRun bool) error {
+	dbPlugin, err := getDatabasePlugin()
+	if err != nil {
+		return err
+	}
+	Plan, err := dbPlugin.PlanMigrations(context.Background(), Status)
+	if err != nil {
+		return err
+	}
+	if Plan.CurrentVersion < 0 {
+		fmt.Println("Database is not installed, schema version", Plan.TargetVersion, "will be installed")
+	} else {
+		fmt.Println("Database schema version", Plan.CurrentVersion, "of", Plan.TargetVersion)
+	}
+	if len(Plan.Pending) == 0 {
+		fmt.Println("No pending migrations")
+	}
+	for _, Migration := range Plan.Pending {
+		Mode := "in a transaction"
+		if Migration.Transactional == false {
+			Mode = "without a transaction"
+		}
+		fmt.Println("Pending migration", Migration.Version, "("+Mode+"):", Migration.Description)
+		if DryRun {
+			for _, Statement := range Migration.Statements {
+				fmt.Println(Statement)
+			}
+			fmt.Println()
+		}
+	}
+	if Status && Plan.CurrentVersion >= 0 {
+		if Plan.SchemaCheckError != "" {
+			fmt.Println("Could not compare the schema with a fresh install:", Plan.SchemaCheckError)
+		} else if len(Plan.SchemaDifferences) == 0 {
+			fmt.Println("Schema matches a fresh install of version", Plan.CurrentVersion)
+		} else {
+			fmt.Println("Schema differs from a fresh install of version", Plan.CurrentVersion)
+			for _, Difference := range Plan.SchemaDifferences {
+				fmt.Println("  " + Difference)
+			}
+		}
+	}
+	return nil
+}
+
+//getBlobStore returns the blob store plugin selected by BlobStore
+func getBlobStore() (interfaces.BlobStore, error) {
+	switch config.Configuration.BlobStore {
+	case "local":
+		return &localblobplugin.LocalBlobPlugin{}, nil
+	case "s3":
+		return &s3blobplugin.S3BlobPlugin{}, nil
+	}
+	return nil, errors.New("Unknown BlobStore " + config.Configuration.BlobStore + ", expected local or s3")
+}
+
+func getDatabasePlugin() (interfaces.DBInterface, error) {
+	switch config.Configuration.DBType {
+	case "mariadb":
+		if config.Configuration.DBName == "" || config.Configuration.DBPassword == "" || config.Configuration.DBUser == "" || config.Configuration.DBHost == "" {
+			return nil, errors.New("Missing database information. (Instance, User, Password?)")
+		}
+		return &mariadbplugin.MariaDBPlugin{}, nil
+	case "sqlite":
+		if config.Configuration.DBFile == "" {
+			return nil, errors.New("Missing database information. (DBFile?)")
+		}
+		return &sqliteplugin.SQLitePlugin{}, nil
+	case "postgres":
+		if config.Configuration.DBName == "" || config.Configuration.DBPassword == "" || config.Configuration.DBUser == "" || config.Configuration.DBHost == "" {
+			return nil, errors.New("Missing database information. (Instance, User, Password?)")
+		}
+		return &postgresplugin.PostgresPlugin{}, nil
+	case "memory":
+		return &memoryplugin.MemoryPlugin{}, nil
+	}
+	return nil, errors.New("Unknown DBType " + config.Configuration.DBType + ", expected mariadb, sqlite, postgres or memory")
+}
+
 func fixMissingConfigs() {
+	if config.Configuration.DBType == "" {
+		config.Configuration.DBType = "mariadb"
+	}
+	if config.Configuration.DBFile == "" {
+		config.Configuration.DBFile = "." + string(filepath.Separator) + "configuration" + string(filepath.Separator) + "gib.db"
+	}
+	if config.Configuration.DBSSLMode == "" {
+		config.Configuration.DBSSLMode = "disable"
+	}
 	if config.Configuration.Address == "" {
 		config.Configuration.Address = ":8080"
 	}
 	if config.Configuration.ImageDirectory == "" {
 		config.Configuration.ImageDirectory = "." + string(filepath.Separator) + "images"
 	}
+	if config.Configuration.BlobStore == "" {
+		config.Configuration.BlobStore = "local"
+	}
 	if config.Configuration.HTTPRoot == "" {
 		config.Configuration.HTTPRoot = "." + string(filepath.Separator) + "http"
 	}
+	if config.Configuration.FFProbePath == "" {
+		config.Configuration.FFProbePath = strings.TrimSuffix(config.Configuration.FFMPEGPath, filepath.Base(config.Configuration.FFMPEGPath)) + "ffprobe" + filepath.Ext(config.Configuration.FFMPEGPath)
+	}
 	if config.Configuration.MaxUploadBytes <= 0 {
 		config.Configuration.MaxUploadBytes = 100 << 20
 	}
@@ -430,6 +532,18 @@ func fixMissingConfigs() {
 	if config.Configuration.PageStride <= 0 {
 		config.Configuration.PageStride = 30
 	}
+	if config.Configuration.JobWorkers == 0 {
+		config.Configuration.JobWorkers = 4
+	}
+	if config.Configuration.JobMaxAttempts == 0 {
+		config.Configuration.JobMaxAttempts = 5
+	}
+	if config.Configuration.TrashRetentionDays == 0 {
+		config.Configuration.TrashRetentionDays = 30
+	}
+	if config.Configuration.AuditRetentionDays == 0 {
+		config.Configuration.AuditRetentionDays = 30
+	}
 	config.CreateSessionStore()
 }
 
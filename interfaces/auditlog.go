@@ -0,0 +1,132 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"time"
+)
+
+//AuditAction is what a user did in an audit event
+type AuditAction string
+
+const (
+	//AuditLogon a user logged on, or failed to
+	AuditLogon AuditAction = "LOGON"
+	//AuditLogout a user logged out
+	AuditLogout AuditAction = "LOGOUT"
+	//AuditAccountCreate a user created their account
+	AuditAccountCreate AuditAction = "ACCOUNT-CREATED"
+	//AuditPasswordReset a user reset their password with their security questions
+	AuditPasswordReset AuditAction = "PASSWORD-RESET"
+	//AuditPasswordSet a user changed their password
+	AuditPasswordSet AuditAction = "PASSWORD-SET"
+	//AuditQuestionSet a user changed their security questions
+	AuditQuestionSet AuditAction = "QUESTION-SET"
+	//AuditFilterSet a user changed their search filter
+	AuditFilterSet AuditAction = "FILTER-SET"
+	//AuditSavedSearchSet a user saved a search, or changed the query of one
+	AuditSavedSearchSet AuditAction = "SAVED-SEARCH-SET"
+	//AuditSavedSearchDelete a user deleted one of their saved searches
+	AuditSavedSearchDelete AuditAction = "SAVED-SEARCH-DELETE"
+	//AuditAPIAccess a user was refused API access
+	AuditAPIAccess AuditAction = "API"
+	//AuditImageUpload a user uploaded an image
+	AuditImageUpload AuditAction = "IMAGE-UPLOAD"
+	//AuditImageDelete a user moved an image to the recycle bin
+	AuditImageDelete AuditAction = "DELETE-IMAGE"
+	//AuditImageRestore a user restored an image from the recycle bin
+	AuditImageRestore AuditAction = "RESTORE-IMAGE"
+	//AuditImagePurge an image was permanently deleted
+	AuditImagePurge AuditAction = "PURGE-IMAGE"
+	//AuditImageScore a user changed an image's score
+	AuditImageScore AuditAction = "IMAGE-SCORE"
+	//AuditImageSource a user changed an image's source
+	AuditImageSource AuditAction = "IMAGE-SOURCE"
+	//AuditImageName a user changed an image's name or description
+	AuditImageName AuditAction = "IMAGE-NAME"
+	//AuditImageRating a user changed an image's rating
+	AuditImageRating AuditAction = "ADD-IMAGERATING"
+	//AuditImageRevert a user reverted an image to an earlier revision
+	AuditImageRevert AuditAction = "REVERT-IMAGE"
+	//AuditImageTagAdd a user added tags to an image
+	AuditImageTagAdd AuditAction = "ADD-IMAGETAG"
+	//AuditImageTagRemove a user removed a tag from an image
+	AuditImageTagRemove AuditAction = "REMOVE-IMAGETAG"
+	//AuditTagCreate a user created a tag
+	AuditTagCreate AuditAction = "CREATE-TAG"
+	//AuditTagModify a user changed a tag
+	AuditTagModify AuditAction = "MODIFY-TAG"
+	//AuditTagDelete a user deleted a tag
+	AuditTagDelete AuditAction = "DELETE-TAG"
+	//AuditTagBulkAdd a user added a tag to every image with another
+	AuditTagBulkAdd AuditAction = "ADD-BULKIMAGETAG"
+	//AuditTagBulkReplace a user replaced a tag with another on every image
+	AuditTagBulkReplace AuditAction = "REPLACE-BULKIMAGETAG"
+	//AuditCollectionCreate a user created a collection
+	AuditCollectionCreate AuditAction = "CREATE-COLLECTION"
+	//AuditCollectionModify a user changed a collection
+	AuditCollectionModify AuditAction = "MODIFY-COLLECTION"
+	//AuditCollectionDelete a user deleted a collection
+	AuditCollectionDelete AuditAction = "DELETE-COLLECTION"
+	//AuditCollectionMemberAdd a user added an image to a collection
+	AuditCollectionMemberAdd AuditAction = "ADD-COLLECTIONMEMBER"
+	//AuditCollectionMemberRemove a user removed an image from a collection
+	AuditCollectionMemberRemove AuditAction = "REMOVE-COLLECTIONMEMBER"
+	//AuditCollectionOrder a user reordered a collection
+	AuditCollectionOrder AuditAction = "MODIFY-COLLECTIONMEMBER"
+	//AuditUserPermissions a user changed another user's permissions
+	AuditUserPermissions AuditAction = "EDIT-USERPERMISSIONS"
+	//AuditUserDisable a user disabled or enabled another user
+	AuditUserDisable AuditAction = "DISABLE-USER"
+	//AuditUserRevert a user reverted another user's changes to images
+	AuditUserRevert AuditAction = "REVERT-USER"
+	//AuditJobRetry a user retried failed background jobs
+	AuditJobRetry AuditAction = "RETRY-JOB"
+)
+
+//AuditTargetType is the kind of object an audit event acted on
+type AuditTargetType string
+
+const (
+	//AuditTargetNone the event did not act on an object, or it is not known
+	AuditTargetNone AuditTargetType = ""
+	//AuditTargetImage the target is an image ID
+	AuditTargetImage AuditTargetType = "Image"
+	//AuditTargetTag the target is a tag ID
+	AuditTargetTag AuditTargetType = "Tag"
+	//AuditTargetCollection the target is a collection ID
+	AuditTargetCollection AuditTargetType = "Collection"
+	//AuditTargetUser the target is a user ID
+	AuditTargetUser AuditTargetType = "User"
+)
+
+//AuditDetails is extra information about an audit event, it is stored as a JSON object.
+//Events that failed have an Error key saying why
+type AuditDetails map[string]interface{}
+
+//AuditDenied is the Error of events refused for lack of permissions
+const AuditDenied = "insufficient permissions"
+
+//AuditLog is one entry of the audit log
+type AuditLog struct {
+	ID uint64
+	//UserID is the user who acted, 0 for the board itself
+	UserID     uint64
+	UserName   string
+	Action     AuditAction
+	TargetType AuditTargetType
+	TargetID   uint64
+	//Details is a JSON object. Entries from before events were typed keep their text under Info
+	Details json.RawMessage
+	LogTime time.Time
+}
+
+//AuditLogFilter narrows an audit log search, fields left at their zero value match every entry
+type AuditLogFilter struct {
+	UserID     uint64
+	Action     AuditAction
+	TargetType AuditTargetType
+	TargetID   uint64
+	//Since and Until limit entries to those logged from Since up to but not including Until
+	Since time.Time
+	Until time.Time
+}
@@ -0,0 +1,144 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"time"
+)
+
+//BackupData holds every row needed to restore a board, in a form that does not depend on the database plugin.
+//Derived rows, such as the tags collections inherit from their members, are left out and rebuilt on import.
+//Login tokens are also left out, so users have to sign in again after a restore
+type BackupData struct {
+	Users             []BackupUser
+	SavedSearches     []BackupSavedSearch
+	Tags              []BackupTag
+	Images            []BackupImage
+	ImagedHashes      []BackupImagedHash
+	ImageTags         []BackupImageTag
+	Votes             []BackupVote
+	Collections       []BackupCollection
+	CollectionMembers []BackupCollectionMember
+	AuditLogs         []BackupAuditLog
+	ImageRevisions    []BackupImageRevision
+}
+
+//BackupUser is a user account, the SYSTEM user is not included as every install has one.
+//Security questions and answers that were never set are empty
+type BackupUser struct {
+	ID           uint64
+	Name         string
+	EMail        string
+	PasswordHash string
+	SecQuestions [3]string
+	SecAnswers   [3]string
+	CreationTime time.Time
+	Disabled     bool
+	Permissions  uint64
+	SearchFilter string
+}
+
+//BackupSavedSearch is a search query a user saved under a name
+type BackupSavedSearch struct {
+	ID     uint64
+	UserID uint64
+	Name   string
+	Query  string
+}
+
+//BackupTag is a tag or alias
+type BackupTag struct {
+	ID          uint64
+	Name        string
+	Description string
+	UploaderID  uint64
+	UploadTime  time.Time
+	AliasedID   uint64
+	IsAlias     bool
+}
+
+//BackupImage is an image's row, Location is the file's name in the archive and in the blob store
+type BackupImage struct {
+	ID           uint64
+	UploaderID   uint64
+	Name         string
+	Description  string
+	Rating       string
+	ScoreTotal   int64
+	ScoreAverage int64
+	ScoreVoters  int64
+	Location     string
+	Source       string
+	UploadTime   time.Time
+	//DeletedTime is zero unless the image was in the recycle bin
+	DeletedTime time.Time
+	DeletedBy   uint64
+	//ImageMetadata is empty in archives from before it was recorded
+	ImageMetadata
+}
+
+//BackupImagedHash is the dHash of one image
+type BackupImagedHash struct {
+	ImageID uint64
+	HHash   uint64
+	VHash   uint64
+}
+
+//BackupImageTag links a tag to an image
+type BackupImageTag struct {
+	ImageID  uint64
+	TagID    uint64
+	LinkerID uint64
+	LinkTime time.Time
+}
+
+//BackupVote is one user's score on an image
+type BackupVote struct {
+	UserID       uint64
+	ImageID      uint64
+	Score        int64
+	CreationTime time.Time
+}
+
+//BackupCollection is a collection, its members are listed separately
+type BackupCollection struct {
+	ID          uint64
+	Name        string
+	Description string
+	UploaderID  uint64
+	UploadTime  time.Time
+}
+
+//BackupCollectionMember places an image in a collection
+type BackupCollectionMember struct {
+	CollectionID uint64
+	ImageID      uint64
+	LinkerID     uint64
+	LinkTime     time.Time
+	OrderWeight  uint64
+}
+
+//BackupAuditLog is one audit log entry
+type BackupAuditLog struct {
+	UserID     uint64
+	Action     AuditAction
+	TargetType AuditTargetType
+	TargetID   uint64
+	Details    json.RawMessage
+	LogTime    time.Time
+	//Type and Info are only set by format version 1 archives, from before audit events were typed. They are converted before the archive is imported
+	Type string `json:",omitempty"`
+	Info string `json:",omitempty"`
+}
+
+//BackupImageRevision is one change in an image's history, it keeps its ID so revisions stay in order
+type BackupImageRevision struct {
+	ID           uint64
+	ImageID      uint64
+	UserID       uint64
+	ChangeTime   time.Time
+	Field        string
+	TagID        uint64
+	CollectionID uint64
+	OldValue     string
+	NewValue     string
+}
@@ -0,0 +1,46 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+//BlobInfo describes a stored media file
+type BlobInfo struct {
+	//Name is the blob's slash separated name, images are stored under their Location and thumbnails under thumbs/<Location>.png
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+//BlobStore stores the board's media files, images and their thumbnails.
+//Errors for names that are not stored match fs.ErrNotExist with errors.Is
+type BlobStore interface {
+	//Init prepares the store, such as creating its directory or checking that its bucket can be reached
+	Init(ctx context.Context) error
+	//Create stores Content under Name, it fails with an error matching fs.ErrExist if Name is already stored. Size is -1 when it is not known
+	Create(ctx context.Context, Name string, Content io.Reader, Size int64) error
+	//Put stores Content under Name, replacing what was stored there before. Size is -1 when it is not known
+	Put(ctx context.Context, Name string, Content io.Reader, Size int64) error
+	//Open returns the content stored under Name, the caller must close it
+	Open(ctx context.Context, Name string) (io.ReadSeekCloser, error)
+	//Stat returns information about the blob stored under Name
+	Stat(ctx context.Context, Name string) (BlobInfo, error)
+	//Delete removes Name from the store, removing a name that is not stored is not an error
+	Delete(ctx context.Context, Name string) error
+	//Rename moves the blob stored under OldName to NewName, replacing anything stored there
+	Rename(ctx context.Context, OldName string, NewName string) error
+	//List calls Visit for every blob whose name starts with Prefix, and stops at the first error Visit returns
+	List(ctx context.Context, Prefix string, Visit func(Blob BlobInfo) error) error
+	//ServeBlob replies to request with the blob stored under Name, either directly or by redirecting to where it can be downloaded
+	ServeBlob(responseWriter http.ResponseWriter, request *http.Request, Name string)
+}
+
+//LocalBlobStore is a BlobStore that keeps its blobs as files, so tools that need a path, like FFMPEG, can read them in place
+type LocalBlobStore interface {
+	BlobStore
+	//LocalPath returns the path of the file Name is stored in
+	LocalPath(Name string) string
+}
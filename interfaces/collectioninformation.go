@@ -24,3 +24,9 @@ type CollectionInformation struct {
 	//Location When in a collection list, this should be set to the name/location of a preview image (Same as imageinformation)
 	Location string
 }
+
+//CollectionMember is one image's place in a collection
+type CollectionMember struct {
+	CollectionID uint64
+	ImageID      uint64
+}
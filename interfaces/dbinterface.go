@@ -1,143 +1,223 @@
 package interfaces
 
+import (
+	"context"
+	"time"
+)
+
 //DBInterface is a generic interface to allow swappable databases
+//Methods that touch the database take a context, plugins should stop work and return once it is cancelled
 type DBInterface interface {
 	////Account operations
 	//CreateUser is used to create and add a user to the AuthN database (return nil on success)
-	CreateUser(userName string, password []byte, email string, permissions uint64) error
+	CreateUser(ctx context.Context, userName string, password []byte, email string, permissions uint64) error
 	//ValidateUser Validate a user's password (return nil if valid)
-	ValidateUser(userName string, password []byte) error
+	ValidateUser(ctx context.Context, userName string, password []byte) error
 	//SetUserPassword Update a user's password, validation of user provided by either old password, or security answers, force flag skips validation, for root use only. (nil on success)
-	SetUserPassword(userName string, password []byte, newPassword []byte, answerOne []byte, answerTwo []byte, answerThree []byte, force bool) error
+	SetUserPassword(ctx context.Context, userName string, password []byte, newPassword []byte, answerOne []byte, answerTwo []byte, answerThree []byte, force bool) error
 	//ValidateToken Validate a cookie token (true if valid cookie, false otherwise, error for reason or nil)
-	ValidateToken(userName string, tokenID string, ip string) error
+	ValidateToken(ctx context.Context, userName string, tokenID string, ip string) error
 	//GenerateToken Generate a cookie token (string token, or error)
-	GenerateToken(userName string, ip string) (string, error)
+	GenerateToken(ctx context.Context, userName string, ip string) (string, error)
 	//RevokeToken Revokes a token (nil on success)
-	RevokeToken(userName string) error
+	RevokeToken(ctx context.Context, userName string) error
 	//RemoveUser Removes a user from the AuthN database (nil on success)
-	RemoveUser(userName string) error
+	RemoveUser(ctx context.Context, userName string) error
 	//SetSecurityQuestions changes a user's security questions (nil if success)
-	SetSecurityQuestions(userName string, questionOne string, questionTwo string, questionThree string, answerOne []byte, answerTwo []byte, answerThree []byte, challengeAnswer []byte) error
+	SetSecurityQuestions(ctx context.Context, userName string, questionOne string, questionTwo string, questionThree string, answerOne []byte, answerTwo []byte, answerThree []byte, challengeAnswer []byte) error
 	//ValidateSecurityQuestions Validates answers against a user's security questions (nil on success)
-	ValidateSecurityQuestions(userName string, answerOne []byte, answerTwo []byte, answerThree []byte) error
+	ValidateSecurityQuestions(ctx context.Context, userName string, answerOne []byte, answerTwo []byte, answerThree []byte) error
 	//GetSecurityQuestions returns the three questions, first, second, third, and an error if an issue occured
-	GetSecurityQuestions(userName string) (string, string, string, error)
+	GetSecurityQuestions(ctx context.Context, userName string) (string, string, string, error)
 	//GetUserPermissionSet returns a UserPermission object representing a user's intended access
-	GetUserPermissionSet(userName string) (UserPermission, error)
+	GetUserPermissionSet(ctx context.Context, userName string) (UserPermission, error)
 	//SetUserPermissionSet sets a user's permission in the database
-	SetUserPermissionSet(userID uint64, permissions uint64) error
+	SetUserPermissionSet(ctx context.Context, userID uint64, permissions uint64) error
 	//SetUserDisableState disables or enables a user account
-	SetUserDisableState(userID uint64, isDisabled bool) error
+	SetUserDisableState(ctx context.Context, userID uint64, isDisabled bool) error
 	//ValidatePasswordStrength Returns an error if there is an issue with the password describing the issue. Else nil
 	ValidatePasswordStrength(password string) error
 	//GetUserID returns a user's DBID for association with other db elements
-	GetUserID(userName string) (uint64, error)
+	GetUserID(ctx context.Context, userName string) (uint64, error)
 	//GetImage returns an ImageInformation object given an ID
-	GetImage(ID uint64) (ImageInformation, error)
+	GetImage(ctx context.Context, ID uint64) (ImageInformation, error)
 	//GetImageByFileName returns an ImageInformation object given a ImageName
-	GetImageByFileName(imageName string) (ImageInformation, error)
+	GetImageByFileName(ctx context.Context, imageName string) (ImageInformation, error)
 	//ValidateProposedUsername returns whether a username is in a valid format
 	ValidateProposedUsername(UserName string) error
 	//SetImageRating changes a given image's rating
-	SetImageRating(ID uint64, Rating string) error
+	SetImageRating(ctx context.Context, ID uint64, Rating string) error
 	//SetImageSource changes a given image's source
-	SetImageSource(ID uint64, Source string) error
+	SetImageSource(ctx context.Context, ID uint64, Source string) error
+	//SetImageMetadata stores what was read from an image's file
+	SetImageMetadata(ctx context.Context, ID uint64, Metadata ImageMetadata) error
 	//SetImagedHash changes a given image's dHash
-	SetImagedHash(ID uint64, hHash uint64, vHash uint64) error
+	SetImagedHash(ctx context.Context, ID uint64, hHash uint64, vHash uint64) error
 	//GetImagedHash changes a given image's dHash
-	GetImagedHash(ID uint64) (uint64, uint64, error)
+	GetImagedHash(ctx context.Context, ID uint64) (uint64, uint64, error)
 	//GetUserFilter returns the raw string of the user's filter
-	GetUserFilter(UserID uint64) (string, error)
+	GetUserFilter(ctx context.Context, UserID uint64) (string, error)
 	//SearchUsers performs a search for users (Returns a list of UserInfos, or error)
-	SearchUsers(searchString string, PageStart uint64, PageStride uint64) ([]UserInformation, uint64, error)
+	SearchUsers(ctx context.Context, searchString string, PageStart uint64, PageStride uint64) ([]UserInformation, uint64, error)
 	//GetUser returns a UserInformation object for the user with the specified ID
-	GetUser(UserID uint64) (UserInformation, error)
+	GetUser(ctx context.Context, UserID uint64) (UserInformation, error)
 
 	//Image operations
 	//NewImage adds an image with the provided information and returns the id, or error
-	NewImage(ImageName string, ImageFileName string, OwnerID uint64, Source string) (uint64, error)
+	NewImage(ctx context.Context, ImageName string, ImageFileName string, OwnerID uint64, Source string) (uint64, error)
 	//UpdateImage updates properties of an image
-	UpdateImage(ImageID uint64, ImageName interface{}, ImageDescription interface{}, OwnerID interface{}, Rating interface{}, Source interface{}, Location interface{}) error
-	//DeleteImage removes an image from the db
-	DeleteImage(ImageID uint64) error
+	UpdateImage(ctx context.Context, ImageID uint64, ImageName interface{}, ImageDescription interface{}, OwnerID interface{}, Rating interface{}, Source interface{}, Location interface{}) error
+	//TrashImage moves an image to the recycle bin, hiding it from searches and collections until it is restored or purged
+	TrashImage(ctx context.Context, ImageID uint64, DeletedBy uint64) error
+	//RestoreImage takes an image back out of the recycle bin
+	RestoreImage(ctx context.Context, ImageID uint64) error
+	//IsImageFileTrashed returns whether the image stored under Location is in the recycle bin, false if no image is stored there
+	IsImageFileTrashed(ctx context.Context, Location string) (bool, error)
+	//GetTrashedImages returns images in the recycle bin, most recently deleted first, and the total number of trashed images
+	GetTrashedImages(ctx context.Context, PageStart uint64, PageStride uint64) ([]ImageInformation, uint64, error)
+	//GetImagesTrashedBefore returns up to Limit images that were moved to the recycle bin before the given time
+	GetImagesTrashedBefore(ctx context.Context, Before time.Time, Limit uint64) ([]ImageInformation, error)
+	//DeleteImage permanently removes an image from the db
+	DeleteImage(ctx context.Context, ImageID uint64) error
+	//AddImageRevision records a change made to an image, ID, UserName and ChangeTime are filled in by the database
+	AddImageRevision(ctx context.Context, Revision ImageRevision) error
+	//GetImageRevision returns one recorded change to an image
+	GetImageRevision(ctx context.Context, RevisionID uint64) (ImageRevision, error)
+	//GetImageRevisions returns the changes made to an image, newest first, and how many there are in total
+	GetImageRevisions(ctx context.Context, ImageID uint64, PageStart uint64, PageStride uint64) ([]ImageRevision, uint64, error)
+	//GetImageRevisionsSince returns the changes made to an image from RevisionID on, including RevisionID itself, oldest first
+	GetImageRevisionsSince(ctx context.Context, ImageID uint64, RevisionID uint64) ([]ImageRevision, error)
+	//GetUserImageRevisions returns the changes a user made to any image from Since up to but not including Until, oldest first
+	GetUserImageRevisions(ctx context.Context, UserID uint64, Since time.Time, Until time.Time) ([]ImageRevision, error)
 	//SearchImages performs a search for images (Returns a list of imageIDs, or error)
-	SearchImages(Tags []TagInformation, PageStart uint64, PageStride uint64) ([]ImageInformation, uint64, error)
+	SearchImages(ctx context.Context, Tags []TagInformation, PageStart uint64, PageStride uint64) ([]ImageInformation, uint64, error)
+	//SearchImagesPage performs a search for a page of images, continuing after Cursor when it is set or starting at PageStart otherwise
+	//Returns the images, the cursor for the next page (blank on the last page), the result count if CountResults is set, and an error/nil
+	SearchImagesPage(ctx context.Context, Tags []TagInformation, Cursor string, PageStart uint64, PageStride uint64, CountResults bool) ([]ImageInformation, string, uint64, error)
 	//GetPrevNexImages performs a search for images (Returns a list of ImageInformations (Up to 2) and an error/nil)
-	GetPrevNexImages(Tags []TagInformation, TargetID uint64) ([]ImageInformation, error)
+	GetPrevNexImages(ctx context.Context, Tags []TagInformation, TargetID uint64) ([]ImageInformation, error)
 	//GetRandomImage returns a random image (Returns a ImageInformation, number of matches to the query, and an error/nil)
-	GetRandomImage(Tags []TagInformation) (ImageInformation, uint64, error)
+	GetRandomImage(ctx context.Context, Tags []TagInformation) (ImageInformation, uint64, error)
+	//GetImageUploadCounts returns how many images not in the recycle bin were uploaded in each day (ByDay) or month from Since up to but not including Until, oldest first
+	//Periods without uploads are left out, a zero Since or Until leaves that side open
+	GetImageUploadCounts(ctx context.Context, Since time.Time, Until time.Time, ByDay bool) ([]UploadCount, error)
 
 	//GetQueryTags returns a slice of tags based on a query
-	GetQueryTags(UserQuery string, CollectionContext bool) ([]TagInformation, error)
+	GetQueryTags(ctx context.Context, UserQuery string, CollectionContext bool) ([]TagInformation, error)
 	//GetUserFilterTags returns a slice of tags based on a user's custom filter
-	GetUserFilterTags(UserID uint64, CollectionContext bool) ([]TagInformation, error)
+	GetUserFilterTags(ctx context.Context, UserID uint64, CollectionContext bool) ([]TagInformation, error)
 	//SetUserQueryTags sets a user's global filter
-	SetUserQueryTags(UserID uint64, Filter string) error
+	SetUserQueryTags(ctx context.Context, UserID uint64, Filter string) error
+	//GetSavedSearches returns a user's saved searches, sorted by name
+	GetSavedSearches(ctx context.Context, UserID uint64) ([]SavedSearch, error)
+	//SetSavedSearch saves Query for a user under Name, replacing the query of their saved search by that name if they have one
+	SetSavedSearch(ctx context.Context, UserID uint64, Name string, Query string) error
+	//DeleteSavedSearch removes a user's saved search, returns sql.ErrNoRows if they have none by that name
+	DeleteSavedSearch(ctx context.Context, UserID uint64, Name string) error
 	//GetImageTags returns a list of TagInformation for all tags that apply to the given image
-	GetImageTags(ImageID uint64) ([]TagInformation, error)
+	GetImageTags(ctx context.Context, ImageID uint64) ([]TagInformation, error)
 	//GetAllTags returns a list of all tags
-	GetAllTags() ([]TagInformation, error)
+	GetAllTags(ctx context.Context) ([]TagInformation, error)
 	//GetTag returns detailed information on one tag
-	GetTag(ID uint64, IncludeCount bool) (TagInformation, error)
+	GetTag(ctx context.Context, ID uint64, IncludeCount bool) (TagInformation, error)
 	//GetTagByName returns detailed information on one tag
-	GetTagByName(Name string) (TagInformation, error)
+	GetTagByName(ctx context.Context, Name string) (TagInformation, error)
 	//Tag Operations
 	//NewTag adds a tag with the provided information
-	NewTag(Name string, Description string, UploaderID uint64) (uint64, error)
+	NewTag(ctx context.Context, Name string, Description string, UploaderID uint64) (uint64, error)
 	//DeleteTag removes a tag
-	DeleteTag(TagID uint64) error
+	DeleteTag(ctx context.Context, TagID uint64) error
 	//AddTag adds an association of a tag to image into the association table
-	AddTag(TagID []uint64, ImageID uint64, LinkerID uint64) error
+	AddTag(ctx context.Context, TagID []uint64, ImageID uint64, LinkerID uint64) error
 	//RemoveTag remove a tag association
-	RemoveTag(TagID uint64, ImageID uint64) error
+	RemoveTag(ctx context.Context, TagID uint64, ImageID uint64) error
 	//UpdateTag updates a pre-existing tag
-	UpdateTag(TagID uint64, Name string, Description string, AliasedID uint64, IsAlias bool, UploadID uint64) error
+	UpdateTag(ctx context.Context, TagID uint64, Name string, Description string, AliasedID uint64, IsAlias bool, UploadID uint64) error
 	//BulkAddTag Adds tags to images that already have another tag
-	BulkAddTag(TagID uint64, OldTagID uint64, LinkerID uint64) error
+	BulkAddTag(ctx context.Context, TagID uint64, OldTagID uint64, LinkerID uint64) error
+	//GetTagImageIDs returns the IDs of every image with a tag, including images in the recycle bin
+	GetTagImageIDs(ctx context.Context, TagID uint64) ([]uint64, error)
 	//ReplaceImageTags Replaces an old tag, with the new tag
-	ReplaceImageTags(OldTagID uint64, NewTagID uint64, LinkerID uint64) error
+	ReplaceImageTags(ctx context.Context, OldTagID uint64, NewTagID uint64, LinkerID uint64) error
 	//SearchTags returns a list of tags like the provided name, but only the ID, Name, Description, and IsAlias
-	SearchTags(name string, PageStart uint64, PageStride uint64, WildcardForwardOnly bool, SortByUsage bool) ([]TagInformation, uint64, error)
+	SearchTags(ctx context.Context, name string, PageStart uint64, PageStride uint64, WildcardForwardOnly bool, SortByUsage bool) ([]TagInformation, uint64, error)
 
 	//UpdateUserVoteScore Either creates or changes a user's vote on an image
-	UpdateUserVoteScore(UserID uint64, ImageID uint64, Score int64) error
+	UpdateUserVoteScore(ctx context.Context, UserID uint64, ImageID uint64, Score int64) error
 	//UpdateScoreOnImage update ScoreTotal, ScoreAverage, and ScoreVoters on an image
-	UpdateScoreOnImage(ImageID uint64) error
+	UpdateScoreOnImage(ctx context.Context, ImageID uint64) error
 	//GetUserVoteScore Returns a user's vote on an image
-	GetUserVoteScore(UserID uint64, ImageID uint64) (int64, error)
+	GetUserVoteScore(ctx context.Context, UserID uint64, ImageID uint64) (int64, error)
 
 	//Maitenance
 	//InitDatabase connects to a database, and if needed, creates and or updates tables
 	InitDatabase() error
-	//AddAuditLog adds a new audit log to the db
-	AddAuditLog(UserID uint64, Type string, Info string) error
+	//PlanMigrations connects if needed and reports the schema version and the migrations InitDatabase would run, without changing anything.
+	//If CompareSchema is set, the database is also compared with a fresh install of the same version made in a scratch database
+	PlanMigrations(ctx context.Context, CompareSchema bool) (MigrationPlan, error)
+	//AddAuditLog adds an event to the audit log at the current time, its ID, UserName and LogTime are ignored
+	AddAuditLog(ctx context.Context, Log AuditLog) error
+	//SearchAuditLogs returns the audit log entries matching Filter, newest first, and how many match in total
+	SearchAuditLogs(ctx context.Context, Filter AuditLogFilter, PageStart uint64, PageStride uint64) ([]AuditLog, uint64, error)
+	//GetAuditLogsBefore returns up to Limit audit log entries logged before the given time, oldest first
+	GetAuditLogsBefore(ctx context.Context, Before time.Time, Limit uint64) ([]AuditLog, error)
+	//DeleteAuditLogsBefore deletes the audit log entries logged before the given time and returns how many were deleted.
+	//If UpToID is not 0, only entries with an ID up to and including it are deleted, so entries that were just archived can be removed without touching any logged since
+	DeleteAuditLogsBefore(ctx context.Context, Before time.Time, UpToID uint64) (uint64, error)
+	//RunInTransaction calls Work with a DBInterface whose changes are only kept if Work returns nil, otherwise they are all rolled back and Work's error is returned.
+	//Work must make its changes through Tx, not the DBInterface it was called on, and must not keep Tx after returning. Calling RunInTransaction on Tx joins the outer transaction
+	RunInTransaction(ctx context.Context, Work func(Tx DBInterface) error) error
+	//ExportBackup reads every user, saved search, tag, image, image revision, collection, vote and audit log from one consistent snapshot of the database
+	ExportBackup(ctx context.Context) (BackupData, error)
+	//ImportBackup restores Data into a freshly installed database, keeping all IDs.
+	//It refuses to import into a database that already has users, images, tags or collections, and imports nothing if any row fails
+	ImportBackup(ctx context.Context, Data BackupData) error
+
+	//Jobs
+	//EnqueueJob queues a job to run at RunAfter. A pending or failed job of the same type for the same image is reset to pending with no attempts instead, a running one is marked to rerun
+	EnqueueJob(ctx context.Context, Type JobType, ImageID uint64, RunAfter time.Time) error
+	//ClaimJob marks the pending job that has been due the longest as running and counts the attempt, returns sql.ErrNoRows if no job is due at Now
+	ClaimJob(ctx context.Context, Now time.Time) (Job, error)
+	//FinishJob removes a job that succeeded, or sets it back to pending with no attempts if it was marked to rerun
+	FinishJob(ctx context.Context, JobID uint64) error
+	//FailJob records why an attempt failed. The job is set back to pending to retry at RetryAt, or marked failed if RetryAt is zero. Jobs marked to rerun are set back to pending with no attempts instead
+	FailJob(ctx context.Context, JobID uint64, Error string, RetryAt time.Time) error
+	//RetryFailedJobs sets a failed job back to pending with no attempts, to run at RunAfter. JobID 0 retries every failed job. Returns how many were retried
+	RetryFailedJobs(ctx context.Context, JobID uint64, RunAfter time.Time) (uint64, error)
+	//RequeueRunningJobs sets running jobs that have not been updated for Lease back to pending to run at RunAfter, for jobs whose worker stopped before finishing them. Returns how many were requeued
+	RequeueRunningJobs(ctx context.Context, RunAfter time.Time, Lease time.Duration) (uint64, error)
+	//GetJobs returns jobs with the given status, most recently updated first, and the total number of them
+	GetJobs(ctx context.Context, Status JobStatus, PageStart uint64, PageStride uint64) ([]Job, uint64, error)
+	//GetJobCounts returns how many jobs have each status, statuses without jobs are left out
+	GetJobCounts(ctx context.Context) (map[JobStatus]uint64, error)
 
 	//Collections
 	//NewCollection adds a collection with the provided information, returns collection ID and/or error
-	NewCollection(Name string, Description string, UploaderID uint64) (uint64, error)
+	NewCollection(ctx context.Context, Name string, Description string, UploaderID uint64) (uint64, error)
 	//UpdateCollection changes a basic property of a collection
-	UpdateCollection(CollectionID uint64, Name string, Description string) error
+	UpdateCollection(ctx context.Context, CollectionID uint64, Name string, Description string) error
 	//AddCollectionMember adds an image to a collection
-	AddCollectionMember(CollectionID uint64, ImageID []uint64, LinkerID uint64) error
+	AddCollectionMember(ctx context.Context, CollectionID uint64, ImageID []uint64, LinkerID uint64) error
 	//UpdateCollectionMember updates an image's properties in a collection
-	UpdateCollectionMember(CollectionID uint64, ImageID uint64, Order uint64) error
+	UpdateCollectionMember(ctx context.Context, CollectionID uint64, ImageID uint64, Order uint64) error
 	//RemoveCollectionMember removes an image from collection
-	RemoveCollectionMember(CollectionID uint64, ImageID uint64) error
+	RemoveCollectionMember(ctx context.Context, CollectionID uint64, ImageID uint64) error
 	//DeleteCollection removes a collection
-	DeleteCollection(CollectionID uint64) error
+	DeleteCollection(ctx context.Context, CollectionID uint64) error
 	//GetCollections returns a list of Collections
-	GetCollections(PageStart uint64, PageStride uint64) ([]CollectionInformation, uint64, error)
+	GetCollections(ctx context.Context, PageStart uint64, PageStride uint64) ([]CollectionInformation, uint64, error)
 	//GetTag return detailed information on one tag
-	GetCollection(ID uint64) (CollectionInformation, error)
+	GetCollection(ctx context.Context, ID uint64) (CollectionInformation, error)
 	//GetCollectionByName returns detailed information on one collection
-	GetCollectionByName(Name string) (CollectionInformation, error)
+	GetCollectionByName(ctx context.Context, Name string) (CollectionInformation, error)
 	//GetCollectionMembers gets a list of images in a collection (Returns a list of imageIDs, the count of the total members, and or error)
-	GetCollectionMembers(CollectionID uint64, PageStart uint64, PageStride uint64) ([]ImageInformation, uint64, error)
+	GetCollectionMembers(ctx context.Context, CollectionID uint64, PageStart uint64, PageStride uint64) ([]ImageInformation, uint64, error)
 	//GetCollectionsWithImage returns a slice of collections with a specific image
-	GetCollectionsWithImage(ImageID uint64) ([]CollectionInformation, error)
+	GetCollectionsWithImage(ctx context.Context, ImageID uint64) ([]CollectionInformation, error)
+	//GetOrphanedCollectionMembers returns collection members whose image no longer exists, sorted by collection then image. Members in the recycle bin are not orphaned
+	GetOrphanedCollectionMembers(ctx context.Context) ([]CollectionMember, error)
 	//SearchCollections performs a search for collections (Returns a list of CollectionInformation a result count and an error/nil)
-	SearchCollections(Tags []TagInformation, PageStart uint64, PageStride uint64) ([]CollectionInformation, uint64, error)
+	SearchCollections(ctx context.Context, Tags []TagInformation, PageStart uint64, PageStride uint64) ([]CollectionInformation, uint64, error)
 	//GetCollectionTags returns a list of TagInformation for all tags that apply to the given collection
-	GetCollectionTags(CollectionID uint64) ([]TagInformation, error)
+	GetCollectionTags(ctx context.Context, CollectionID uint64) ([]TagInformation, error)
 }
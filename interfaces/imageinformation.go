@@ -1,9 +1,16 @@
 package interfaces
 
 import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 )
 
+//ErrInvalidCursor is returned by SearchImagesPage for cursors it did not hand out
+var ErrInvalidCursor = errors.New("invalid search cursor")
+
 //ImageInformation contains information for a specific image. This is used to output search results
 type ImageInformation struct {
 	ID              uint64
@@ -20,14 +27,89 @@ type ImageInformation struct {
 	UsersVotedScore int64
 	Source          string
 	SourceIsURL     bool
+	//DeletedTime is zero unless the image is in the recycle bin
+	DeletedTime   time.Time
+	DeletedByID   uint64
+	DeletedByName string
+	//ImageMetadata is only filled in by GetImage and GetImageByFileName
+	ImageMetadata
 	//Special for collections
 	OrderInCollection uint64                  //Should be used in overview of a single collection
 	MemberCollections []CollectionInformation //Should be used in view of single image (For navigation of collections it's a member of)
 }
 
+//ImageMetadata describes an image's media, it is read from the file after upload. MIMEType is empty until it has been read
+type ImageMetadata struct {
+	//Width and Height are in pixels, 0 if not known or if the file has no picture
+	Width    uint64
+	Height   uint64
+	FileSize uint64
+	MIMEType string
+	//Duration is the length of audio and video in seconds
+	Duration float64
+	//Codec is the format of stills, such as png, or the codec of the first video stream, or of the audio if there is no video
+	Codec string
+}
+
 //ImagedHash conveniently contains the vertical and horizontal dHashes of an image
 type ImagedHash struct {
 	ImagehHash          uint64
 	ImagevHash          uint64
 	SimilarityThreshold uint64
 }
+
+//UploadCount is how many images were uploaded in a day or month, Period is formatted as 2006-01-02 or 2006-01 in UTC
+type UploadCount struct {
+	Period string
+	Count  uint64
+}
+
+//ImageRatio is an aspect ratio requested by a ratio MetaTag, for example 16:9
+type ImageRatio struct {
+	Width     uint64
+	Height    uint64
+	Tolerance uint64 //How far Width may be off, out of Height, for ratios typed as decimals
+}
+
+//EncodeSearchCursor returns the cursor for the page of search results after the image with ImageID, whose sort key in Order was SortKey.
+//The sort key is kept so the next page does not depend on that image still existing, and the random order's seed so the next page comes from the same shuffle.
+//Sort keys are whole numbers, upload times are kept as Unix nanoseconds
+func EncodeSearchCursor(ImageID uint64, SortKey int64, Order SearchOrder) string {
+	Cursor := "image:" + strconv.FormatUint(ImageID, 10)
+	if Order.Name == "random" {
+		Cursor += ":seed:" + strconv.FormatInt(Order.Seed, 10)
+	}
+	if Order.Name != "" {
+		Cursor += ":key:" + strconv.FormatInt(SortKey, 10)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(Cursor))
+}
+
+//DecodeSearchCursor returns the ID and sort key of the image a cursor continues after, and Order with the random order's seed from the cursor
+func DecodeSearchCursor(Cursor string, Order SearchOrder) (uint64, int64, SearchOrder, error) {
+	Decoded, err := base64.RawURLEncoding.DecodeString(Cursor)
+	if err != nil {
+		return 0, 0, Order, ErrInvalidCursor
+	}
+	IDString, IsImage := strings.CutPrefix(string(Decoded), "image:")
+	IDString, KeyString, HasKey := strings.Cut(IDString, ":key:")
+	IDString, SeedString, HasSeed := strings.Cut(IDString, ":seed:")
+	ImageID, err := strconv.ParseUint(IDString, 10, 64)
+	if IsImage == false || err != nil || ImageID == 0 || HasKey != (Order.Name != "") {
+		return 0, 0, Order, ErrInvalidCursor
+	}
+	var SortKey int64
+	if HasKey {
+		SortKey, err = strconv.ParseInt(KeyString, 10, 64)
+		if err != nil {
+			return 0, 0, Order, ErrInvalidCursor
+		}
+	}
+	if HasSeed && Order.Name == "random" {
+		Order.Seed, err = strconv.ParseInt(SeedString, 10, 64)
+		if err != nil {
+			return 0, 0, Order, ErrInvalidCursor
+		}
+	}
+	return ImageID, SortKey, Order, nil
+}
@@ -0,0 +1,40 @@
+package interfaces
+
+import (
+	"time"
+)
+
+//Fields an ImageRevision can record a change to
+const (
+	//RevisionName is a change to an image's name
+	RevisionName = "Name"
+	//RevisionDescription is a change to an image's description
+	RevisionDescription = "Description"
+	//RevisionSource is a change to an image's source
+	RevisionSource = "Source"
+	//RevisionRating is a change to an image's rating
+	RevisionRating = "Rating"
+	//RevisionTag is a tag being added to or removed from an image
+	RevisionTag = "Tag"
+	//RevisionCollection is an image being added to or removed from a collection
+	RevisionCollection = "Collection"
+)
+
+//ImageRevision is one recorded change to an image, used to show an image's history and to revert it
+type ImageRevision struct {
+	ID         uint64
+	ImageID    uint64
+	UserID     uint64
+	UserName   string
+	ChangeTime time.Time
+	//Field is which property changed, one of the Revision constants
+	Field string
+	//TagID is the tag that was added or removed, when Field is RevisionTag
+	TagID uint64
+	//CollectionID is the collection the image was added to or removed from, when Field is RevisionCollection
+	CollectionID uint64
+	//OldValue and NewValue are the field before and after the change.
+	//For tags and collections they are the tag or collection name while the image has it, and empty while it does not
+	OldValue string
+	NewValue string
+}
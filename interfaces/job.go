@@ -0,0 +1,47 @@
+package interfaces
+
+import (
+	"time"
+)
+
+//JobType is the kind of background work a job does
+type JobType string
+
+const (
+	//JobThumbnail generates an image's thumbnail
+	JobThumbnail JobType = "THUMBNAIL"
+	//JobdHash generates an image's dHash
+	JobdHash JobType = "DHASH"
+	//JobMetadata reads an image's metadata from its file
+	JobMetadata JobType = "METADATA"
+)
+
+//JobStatus is where a job is in the queue
+type JobStatus string
+
+const (
+	//JobPending the job is waiting to be run, once RunAfter has passed
+	JobPending JobStatus = "PENDING"
+	//JobRunning a worker has claimed the job
+	JobRunning JobStatus = "RUNNING"
+	//JobFailed the job used up its attempts, it stays in the queue until a mod runs it again
+	JobFailed JobStatus = "FAILED"
+)
+
+//Job is background work on one image. Jobs are removed from the queue once they succeed
+type Job struct {
+	ID      uint64
+	Type    JobType
+	ImageID uint64
+	Status  JobStatus
+	//Attempts is how many times the job has been started since it was queued
+	Attempts uint64
+	//LastError is why the last attempt failed
+	LastError string
+	//RunAfter is when a pending job may next be started
+	RunAfter time.Time
+	//Rerun is set when the job is queued again while it runs, so it runs once more after this run ends
+	Rerun       bool
+	CreatedTime time.Time
+	UpdatedTime time.Time
+}
@@ -0,0 +1,24 @@
+package interfaces
+
+//MigrationPlan describes the state of a database schema and what InitDatabase would do to bring it up to date
+type MigrationPlan struct {
+	//CurrentVersion is the schema version of the database, -1 if nothing is installed yet
+	CurrentVersion int64
+	//TargetVersion is the version the plugin's latest migration brings the schema to
+	TargetVersion int64
+	//Pending are the migrations InitDatabase would run, in order
+	Pending []PendingMigration
+	//SchemaDifferences lists where the database differs from a fresh install of the same version, empty if they match
+	SchemaDifferences []string
+	//SchemaCheckError is set when the comparison against a fresh install could not be made, for example due to missing privileges
+	SchemaCheckError string
+}
+
+//PendingMigration is one migration that has not been applied yet
+type PendingMigration struct {
+	Version     int64
+	Description string
+	//Transactional is false for migrations that the backend cannot roll back if they fail partway through
+	Transactional bool
+	Statements    []string
+}
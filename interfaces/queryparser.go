@@ -0,0 +1,369 @@
+package interfaces
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//MaxWildcardTags is the most tags a wildcard in a query can match, broader wildcards are rejected
+const MaxWildcardTags = 250
+
+//ErrWildcardTooBroad is returned by GetQueryTags when a wildcard matches more than MaxWildcardTags tags
+var ErrWildcardTooBroad = errors.New("wildcard matches too many tags")
+
+//ErrOrderInGroup is returned by GetQueryTags for an order tag inside parentheses, as orders sort a whole search
+var ErrOrderInGroup = errors.New("order tags cannot be used inside a group")
+
+//QueryTerm is one piece of a parsed user query. It is either a single tag, as the user typed it, or an OR group of tag lists
+type QueryTerm struct {
+	//Tag is the tag or metatag as typed, with quoted phrases joined by underscores
+	Tag string
+	//Exclude is set for -tag and -(group)
+	Exclude bool
+	//Alternatives is set for a group, which matches when every term in any one alternative matches
+	Alternatives [][]QueryTerm
+}
+
+//QueryGroup is the value of a group MetaTag, the tag information version of a QueryTerm with Alternatives
+type QueryGroup struct {
+	Alternatives [][]TagInformation
+	//Pattern is set when the group holds the tags matching a wildcard, and is shown in place of them
+	Pattern string
+}
+
+//String formats the group the way a user would type it, for display
+func (Group QueryGroup) String() string {
+	if Group.Pattern != "" {
+		return Group.Pattern
+	}
+	var Alternatives []string
+	for _, Alternative := range Group.Alternatives {
+		var Tags []string
+		for _, Tag := range Alternative {
+			if Tag.IsAlias {
+				continue //The aliased tag is listed alongside it
+			}
+			Name := Tag.Name
+			if Tag.IsMeta {
+				if SubGroup, IsGroup := Tag.MetaValue.(QueryGroup); IsGroup {
+					Name = SubGroup.String()
+				}
+			}
+			if Tag.Exclude {
+				Name = "-" + Name
+			}
+			Tags = append(Tags, Name)
+		}
+		Alternatives = append(Alternatives, strings.Join(Tags, " "))
+	}
+	return "(" + strings.Join(Alternatives, " OR ") + ")"
+}
+
+//TagInformation returns the group as a complex MetaTag, so it can be passed around with the other tags of a query
+func (Group QueryGroup) TagInformation(Exclude bool) TagInformation {
+	return TagInformation{
+		Name:          Group.String(),
+		Description:   "Matches when any one of the groups of tags match",
+		Exists:        true,
+		Exclude:       Exclude,
+		IsMeta:        true,
+		IsComplexMeta: true,
+		Comparator:    "=",
+		MetaValue:     Group}
+}
+
+const (
+	queryTokenWord = iota
+	queryTokenOpen
+	queryTokenClose
+	queryTokenOr
+)
+
+//queryToken is a word, a parenthesis or OR from a user query
+type queryToken struct {
+	Kind   int
+	Text   string
+	Negate bool
+	//Quote is the quote character a phrase was typed in, 0 for other words
+	Quote rune
+}
+
+//IsWildcardTag returns true for query tags containing a *, which match every tag fitting the pattern. Metatags are never wildcards
+func IsWildcardTag(Tag string) bool {
+	return strings.Contains(Tag, "*") && strings.Contains(Tag, ":") == false
+}
+
+//WildcardLikePattern converts a wildcard tag into a pattern for SQL LIKE, with ! as the escape character
+func WildcardLikePattern(Pattern string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_", "*", "%").Replace(Pattern)
+}
+
+//WildcardMatch returns whether a tag name fits a wildcard tag, where each * matches any number of characters
+func WildcardMatch(Pattern string, Name string) bool {
+	Parts := strings.Split(Pattern, "*")
+	if len(Parts) == 1 {
+		return Pattern == Name
+	}
+	if strings.HasPrefix(Name, Parts[0]) == false {
+		return false
+	}
+	Name = Name[len(Parts[0]):]
+	for _, Part := range Parts[1 : len(Parts)-1] {
+		Index := strings.Index(Name, Part)
+		if Index < 0 {
+			return false
+		}
+		Name = Name[Index+len(Part):]
+	}
+	return strings.HasSuffix(Name, Parts[len(Parts)-1])
+}
+
+//WildcardTagInformation returns a wildcard as a group MetaTag matching any one of the tags it expanded to.
+//Aliases are left out, as the tags they alias are looked up alongside them. A wildcard that matches nothing is returned as a tag that does not exist
+func WildcardTagInformation(Pattern string, Tags []TagInformation, Exclude bool) TagInformation {
+	Group := QueryGroup{Pattern: Pattern}
+	Added := make(map[uint64]bool)
+	for _, Tag := range Tags {
+		if Tag.Exists == false || Tag.IsAlias || Tag.IsMeta || Added[Tag.ID] {
+			continue
+		}
+		Added[Tag.ID] = true
+		Group.Alternatives = append(Group.Alternatives, []TagInformation{Tag})
+	}
+	if len(Group.Alternatives) == 0 {
+		return TagInformation{Name: Pattern, Exists: false, Exclude: Exclude}
+	}
+	ToReturn := Group.TagInformation(Exclude)
+	ToReturn.Description = "Matches any tag fitting the pattern"
+	return ToReturn
+}
+
+//ParseQuery splits a user query into terms that must all match.
+//Terms can be tags, "quoted phrases", -excluded tags, and (groups OR of OR tags), which may be excluded and nested.
+//OR binds looser than the implied AND between tags, so "a b OR c" is (a b) OR c. A query is never rejected,
+//unbalanced parentheses are closed at the end of the query and stray ones are ignored.
+func ParseQuery(UserQuery string) []QueryTerm {
+	Tokens := tokenizeQuery(UserQuery)
+	Position := 0
+	Alternatives := parseQueryAlternatives(Tokens, &Position, 0)
+	switch len(Alternatives) {
+	case 0:
+		return nil
+	case 1:
+		return Alternatives[0]
+	}
+	return []QueryTerm{{Alternatives: Alternatives}}
+}
+
+//tokenizeQuery splits a user query into words, parentheses and ORs. A leading - negates the word or group after it
+func tokenizeQuery(UserQuery string) []queryToken {
+	var Tokens []queryToken
+	Runes := []rune(UserQuery)
+	Negate := false
+	for Index := 0; Index < len(Runes); {
+		Rune := Runes[Index]
+		switch {
+		case unicode.IsSpace(Rune):
+			Negate = false //A dash on its own does not negate anything
+			Index++
+		case Rune == '(':
+			Tokens = append(Tokens, queryToken{Kind: queryTokenOpen, Negate: Negate})
+			Negate = false
+			Index++
+		case Rune == ')':
+			Tokens = append(Tokens, queryToken{Kind: queryTokenClose})
+			Negate = false
+			Index++
+		case Rune == '-' && Negate == false:
+			Negate = true
+			Index++
+		case Rune == '+' && Negate == false:
+			Index++ //+tag includes a tag, the same as tag
+		case Rune == '"' || Rune == '\'':
+			//Quoted phrases become one tag, up to the matching quote or the end of the query
+			End := Index + 1
+			for End < len(Runes) && Runes[End] != Rune {
+				End++
+			}
+			Phrase := strings.Join(strings.Fields(string(Runes[Index+1:End])), "_")
+			if Phrase != "" {
+				Tokens = append(Tokens, queryToken{Kind: queryTokenWord, Text: Phrase, Negate: Negate, Quote: Rune})
+			}
+			Negate = false
+			Index = End + 1
+		default:
+			End := Index
+			Depth := 0 //Parentheses inside a word, as in name_(artist), are part of the word
+			for End < len(Runes) && unicode.IsSpace(Runes[End]) == false {
+				if Runes[End] == '(' {
+					Depth++
+				} else if Runes[End] == ')' {
+					if Depth == 0 {
+						break
+					}
+					Depth--
+				}
+				End++
+			}
+			Word := string(Runes[Index:End])
+			if (Word == "OR" || Word == "|") && Negate == false {
+				Tokens = append(Tokens, queryToken{Kind: queryTokenOr})
+			} else {
+				Tokens = append(Tokens, queryToken{Kind: queryTokenWord, Text: Word, Negate: Negate})
+			}
+			Negate = false
+			Index = End
+		}
+	}
+	return Tokens
+}
+
+//ExpandSavedSearches replaces each saved:name in a user query with that saved search's query in parentheses, so -saved:name leaves out all of it.
+//Saved searches used inside a saved search are not expanded again, and names without a saved search are left for GetQueryTags to ignore
+func ExpandSavedSearches(UserQuery string, Saved []SavedSearch) string {
+	if len(Saved) == 0 || strings.Contains(strings.ToLower(UserQuery), "saved:") == false {
+		return UserQuery
+	}
+	Queries := make(map[string]string, len(Saved))
+	for _, Search := range Saved {
+		Queries[Search.Name] = Search.Query
+	}
+	var Expanded []string
+	for _, Token := range tokenizeQuery(UserQuery) {
+		Word := strings.ToLower(Token.Text)
+		Query, IsSaved := Queries[strings.TrimPrefix(Word, "saved:")]
+		if Token.Kind != queryTokenWord || Token.Quote != 0 || strings.HasPrefix(Word, "saved:") == false || IsSaved == false {
+			Expanded = append(Expanded, formatQueryTokens([]queryToken{Token}, false)...)
+			continue
+		}
+		Group := "("
+		if Token.Negate {
+			Group = "-("
+		}
+		//The saved query is balanced, so its parentheses cannot close or leave open the group it is put in
+		Expanded = append(Expanded, Group)
+		Expanded = append(Expanded, formatQueryTokens(tokenizeQuery(Query), true)...)
+		Expanded = append(Expanded, ")")
+	}
+	return strings.Join(Expanded, " ")
+}
+
+//PinRandomOrder writes the seed of a search's random order into the query as order:random:Seed, given the tags GetQueryTags returned for it.
+//Links to more of the results are built from the query, so they stay in the same shuffle after the day's one changes. Other queries are returned as they are
+func PinRandomOrder(UserQuery string, Tags []TagInformation) string {
+	var Order SearchOrder
+	for _, Tag := range Tags {
+		if TagOrder, IsOrder := Tag.MetaValue.(SearchOrder); IsOrder && Tag.Exists {
+			Order = TagOrder
+		}
+	}
+	if Order.Name != "random" {
+		return UserQuery
+	}
+	Pinned := "order:random:" + strconv.FormatInt(Order.Seed, 10)
+	Tokens := tokenizeQuery(UserQuery)
+	Found := false
+	for Index, Token := range Tokens {
+		Word := strings.ToLower(Token.Text)
+		if Token.Kind == queryTokenWord && Token.Quote == 0 && (Word == "order:random" || strings.HasPrefix(Word, "order:random:")) {
+			Tokens[Index].Text = Pinned
+			Found = true
+		}
+	}
+	if Found == false {
+		return UserQuery + " " + Pinned //The order came from a saved search, this one is read after it and wins
+	}
+	return strings.Join(formatQueryTokens(Tokens, false), " ")
+}
+
+//formatQueryTokens writes tokens back out the way they tokenize again. With Balance set, stray closing parentheses are left out and open groups are closed
+func formatQueryTokens(Tokens []queryToken, Balance bool) []string {
+	var ToReturn []string
+	Depth := 0
+	for _, Token := range Tokens {
+		Negate := ""
+		if Token.Negate {
+			Negate = "-"
+		}
+		switch Token.Kind {
+		case queryTokenOpen:
+			Depth++
+			ToReturn = append(ToReturn, Negate+"(")
+		case queryTokenClose:
+			if Balance && Depth == 0 {
+				continue
+			}
+			Depth--
+			ToReturn = append(ToReturn, ")")
+		case queryTokenOr:
+			ToReturn = append(ToReturn, "OR")
+		case queryTokenWord:
+			if Token.Quote != 0 {
+				ToReturn = append(ToReturn, Negate+string(Token.Quote)+Token.Text+string(Token.Quote))
+			} else {
+				ToReturn = append(ToReturn, Negate+Token.Text)
+			}
+		}
+	}
+	for Balance && Depth > 0 {
+		ToReturn = append(ToReturn, ")")
+		Depth--
+	}
+	return ToReturn
+}
+
+//parseQueryAlternatives reads OR separated lists of terms until the closing parenthesis of the current group, or the end of the query
+func parseQueryAlternatives(Tokens []queryToken, Position *int, Depth int) [][]QueryTerm {
+	Alternatives := [][]QueryTerm{nil}
+	for *Position < len(Tokens) {
+		Token := Tokens[*Position]
+		*Position++
+		Last := len(Alternatives) - 1
+		switch Token.Kind {
+		case queryTokenOr:
+			Alternatives = append(Alternatives, nil)
+		case queryTokenClose:
+			if Depth > 0 {
+				return removeEmptyAlternatives(Alternatives)
+			}
+			//Stray closing parenthesis, ignore it
+		case queryTokenOpen:
+			Inner := parseQueryAlternatives(Tokens, Position, Depth+1)
+			Alternatives[Last] = append(Alternatives[Last], queryGroupTerms(Inner, Token.Negate)...)
+		case queryTokenWord:
+			Alternatives[Last] = append(Alternatives[Last], QueryTerm{Tag: Token.Text, Exclude: Token.Negate})
+		}
+	}
+	return removeEmptyAlternatives(Alternatives)
+}
+
+//queryGroupTerms returns the terms a parenthesised group adds to the list it is in. Groups without an OR are only kept when they are excluded
+func queryGroupTerms(Alternatives [][]QueryTerm, Exclude bool) []QueryTerm {
+	if len(Alternatives) == 0 {
+		return nil
+	}
+	if len(Alternatives) == 1 {
+		if Exclude == false {
+			return Alternatives[0]
+		}
+		if len(Alternatives[0]) == 1 {
+			Term := Alternatives[0][0]
+			Term.Exclude = !Term.Exclude
+			return []QueryTerm{Term}
+		}
+	}
+	return []QueryTerm{{Exclude: Exclude, Alternatives: Alternatives}}
+}
+
+//removeEmptyAlternatives drops the empty alternatives left by a leading, trailing or doubled OR
+func removeEmptyAlternatives(Alternatives [][]QueryTerm) [][]QueryTerm {
+	var ToReturn [][]QueryTerm
+	for _, Alternative := range Alternatives {
+		if len(Alternative) > 0 {
+			ToReturn = append(ToReturn, Alternative)
+		}
+	}
+	return ToReturn
+}
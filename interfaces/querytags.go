@@ -0,0 +1,540 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var regexTagName = regexp.MustCompile("[^a-zA-Z0-9_-]") //Used to cleanup tag names
+var regexWhiteSpace = regexp.MustCompile("\\s{2,}")     //Matches 2 or more consecutive whitespace
+var regexTagValue = regexp.MustCompile("[^a-zA-Z0-9_\\-\\.]")
+var regexQueryTagValue = regexp.MustCompile("[^a-zA-Z0-9_\\-\\.:/+]") //Meta tag values in queries can also hold ratios (16:9) and MIME types (image/svg+xml)
+
+//PrepareTagName cleans up a tag name before it is saved or looked up
+func PrepareTagName(Name string) string {
+	//Lowercase Name -> Trimmed front and end of whitespace -> any inner whitespace reduced and underscored
+	Name = regexWhiteSpace.ReplaceAllString(strings.TrimSpace(strings.ToLower(Name)), "_") //Replace all whitespace with _
+	//Case of metatag
+	if strings.Count(Name, ":") == 1 {
+		//Assume a metatag
+		NameValue := strings.Split(Name, ":")
+		value, comparator := getTagComparator(NameValue[1]) //Strip comparator, so it does not get replaced by a _
+		Name = regexTagName.ReplaceAllString(NameValue[0], "_") + ":" + comparator + regexTagValue.ReplaceAllString(value, "_")
+	} else {
+		//Then any special characters replaced with _
+		Name = regexTagName.ReplaceAllString(Name, "_")
+	}
+	return Name
+}
+
+//prepareQueryTagName cleans up a tag typed in a search. Unlike PrepareTagName, which tag names are saved with, meta tag values keep :, / and +
+func prepareQueryTagName(Name string) string {
+	Name = regexWhiteSpace.ReplaceAllString(strings.TrimSpace(strings.ToLower(Name)), "_") //Replace all whitespace with _
+	if strings.Contains(Name, ":") {
+		//Assume a metatag, only the first colon separates the name from the value (ratio:16:9)
+		NameValue := strings.SplitN(Name, ":", 2)
+		value, comparator := getTagComparator(NameValue[1]) //Strip comparator, so it does not get replaced by a _
+		return regexTagName.ReplaceAllString(NameValue[0], "_") + ":" + comparator + regexQueryTagValue.ReplaceAllString(value, "_")
+	}
+	return regexTagName.ReplaceAllString(Name, "_")
+}
+
+//prepareWildcardTagName cleans each part of a wildcard tag the same way as a tag name, keeping the *s between them
+func prepareWildcardTagName(Name string) string {
+	Parts := strings.Split(Name, "*")
+	for Index, Part := range Parts {
+		Parts[Index] = PrepareTagName(Part)
+	}
+	return strings.Join(Parts, "*")
+}
+
+//getTagComparator returns the tagvalue and the comparator, or the original TagValue and an empty string if one does not exist
+func getTagComparator(TagValue string) (string, string) {
+	tagRunes := []rune(TagValue)
+	toReturn := ""
+	if len(tagRunes) == 0 { //Edge case if someone searched "tagname:"
+		return "", ""
+	}
+	if tagRunes[0] == '>' || tagRunes[0] == '<' {
+		toReturn += string(tagRunes[0])
+		tagRunes = tagRunes[1:]
+	}
+	if tagRunes[0] == '=' {
+		toReturn += string(tagRunes[0])
+		tagRunes = tagRunes[1:]
+	}
+	return string(tagRunes), toReturn
+}
+
+//QueryTagSource is what GetQueryTags needs from a database to look up the tags of a query. Only the lookups differ between database plugins
+type QueryTagSource interface {
+	//GetTagsByName returns the tags with the given names, names without a tag are left out
+	GetTagsByName(ctx context.Context, Names []string) ([]TagInformation, error)
+	//GetTagsByID returns the tags with the given IDs, IDs without a tag are left out
+	GetTagsByID(ctx context.Context, IDs []uint64) ([]TagInformation, error)
+	//GetTagNamesMatching returns the names of up to Limit tags that match a wildcard pattern, see WildcardMatch
+	GetTagNamesMatching(ctx context.Context, Pattern string, Limit int) ([]string, error)
+	//GetUserID returns a user's DBID, for the uploader meta tag
+	GetUserID(ctx context.Context, userName string) (uint64, error)
+	//GetImagedHash returns an image's dHash, for the similar meta tag
+	GetImagedHash(ctx context.Context, ID uint64) (uint64, uint64, error)
+}
+
+//GetQueryTags returns a slice of tags based on a query string, CollectionContext should be true if these tags are being parsed for a collection.
+//This is shared by all database plugins, which only supply the lookups in Source
+func GetQueryTags(ctx context.Context, Source QueryTagSource, UserQuery string, CollectionContext bool) ([]TagInformation, error) {
+	//Split the query into tags and OR groups, see ParseQuery for the syntax
+	Terms := ParseQuery(UserQuery)
+	if len(Terms) == 0 {
+		return nil, nil
+	}
+	return getQueryTermsInfo(ctx, Source, Terms, CollectionContext)
+}
+
+//getQueryTermsInfo looks up the tags for a list of query terms that must all match. Each alternative of an OR group is looked up the same way, and the group is returned as a MetaTag.
+func getQueryTermsInfo(ctx context.Context, Source QueryTagSource, Terms []QueryTerm, CollectionContext bool) ([]TagInformation, error) {
+	//What we want to return
+	var ToReturn []TagInformation
+	//These are passed to the getTagsInfo function to query SQL
+	var IncludeQueryTags []string
+	var ExcludeQueryTags []string
+	for _, Term := range Terms {
+		if len(Term.Alternatives) > 0 {
+			var Group QueryGroup
+			for _, Alternative := range Term.Alternatives {
+				AlternativeTags, err := getQueryTermsInfo(ctx, Source, Alternative, CollectionContext)
+				if err != nil {
+					return ToReturn, err
+				}
+				for _, Tag := range AlternativeTags {
+					if _, IsOrder := Tag.MetaValue.(SearchOrder); IsOrder {
+						return ToReturn, ErrOrderInGroup
+					}
+				}
+				Group.Alternatives = append(Group.Alternatives, AlternativeTags)
+			}
+			ToReturn = append(ToReturn, Group.TagInformation(Term.Exclude))
+			continue
+		}
+		if IsWildcardTag(Term.Tag) {
+			WildcardTag, err := getWildcardTagInfo(ctx, Source, Term.Tag, Term.Exclude, CollectionContext)
+			if err != nil {
+				return ToReturn, err
+			}
+			ToReturn = append(ToReturn, WildcardTag)
+			continue
+		}
+		Tag := strings.ToLower(prepareQueryTagName(Term.Tag)) //Cleanup
+		if Tag == "" {
+			continue
+		}
+		if Term.Exclude {
+			ExcludeQueryTags = append(ExcludeQueryTags, Tag)
+		} else {
+			IncludeQueryTags = append(IncludeQueryTags, Tag)
+		}
+	}
+
+	//This stores our pre-toReturn result, so a tag that is both included and excluded is only used once
+	queryMap := make(map[string]TagInformation)
+	//If we have exclude tags
+	if len(ExcludeQueryTags) > 0 {
+		//Get more info on them and update querymap with new info
+		returnedTags, err := getTagsInfo(ctx, Source, ExcludeQueryTags, true, CollectionContext)
+		if err != nil {
+			return ToReturn, err
+		}
+		for _, tag := range returnedTags {
+			queryMap[tag.Name] = tag
+		}
+	}
+	//If we have include tags
+	if len(IncludeQueryTags) > 0 {
+		//Get more info on them and add them to the map
+		returnedTags, err := getTagsInfo(ctx, Source, IncludeQueryTags, false, CollectionContext)
+		if err != nil {
+			return ToReturn, err
+		}
+		for _, tag := range returnedTags {
+			queryMap[tag.Name] = tag
+		}
+	}
+
+	//Now query map contains all the data we need. Now we just need to convert it to a slice
+	for _, TagInfo := range queryMap {
+		ToReturn = append(ToReturn, TagInfo)
+	}
+	return ToReturn, nil
+}
+
+//getWildcardTagInfo expands a wildcard query tag into a group of the tags it matches
+func getWildcardTagInfo(ctx context.Context, Source QueryTagSource, Tag string, Exclude bool, CollectionContext bool) (TagInformation, error) {
+	Pattern := prepareWildcardTagName(Tag)
+	//One more than the limit is read, to tell when there are too many
+	Names, err := Source.GetTagNamesMatching(ctx, Pattern, MaxWildcardTags+1)
+	if err != nil {
+		return TagInformation{}, err
+	}
+	if len(Names) > MaxWildcardTags {
+		return TagInformation{}, fmt.Errorf("%w: %s", ErrWildcardTooBroad, Pattern)
+	}
+	Tags, err := getTagsInfo(ctx, Source, Names, false, CollectionContext)
+	if err != nil {
+		return TagInformation{}, err
+	}
+	return WildcardTagInformation(Pattern, Tags, Exclude), nil
+}
+
+//getTagsInfo is a helper function to get more details on a set of tags by name, note that the names should be cleaned up before passing to this function.
+//This function will also parse Alias mapping and return those, as well as parse meta tags
+func getTagsInfo(ctx context.Context, Source QueryTagSource, Tags []string, Exclude bool, CollectionContext bool) ([]TagInformation, error) {
+	//What we will return
+	var ToReturn []TagInformation
+	if len(Tags) == 0 {
+		return ToReturn, nil
+	}
+
+	//First we handle meta tags
+	var NonMetaTags []string //Tags will be set to this and used later on in code
+	for _, value := range Tags {
+		if strings.Contains(value, ":") {
+			MetaValue, Comparator := getTagComparator(strings.SplitN(value, ":", 2)[1])
+			if Comparator == "" {
+				Comparator = "="
+			}
+			ToAdd := TagInformation{
+				Name:       strings.Split(value, ":")[0],
+				MetaValue:  MetaValue,
+				Comparator: Comparator,
+				Exclude:    Exclude,
+				IsMeta:     true}
+			ToReturn = append(ToReturn, ToAdd)
+		} else {
+			NonMetaTags = append(NonMetaTags, value)
+		}
+	}
+	//Parse meta tags further
+	//Need to ensure column names are correct, and values too
+	if len(ToReturn) > 0 {
+		ToReturn, _ = parseMetaTags(ctx, Source, ToReturn, CollectionContext)
+	}
+
+	Tags = NonMetaTags
+	if len(Tags) <= 0 {
+		return ToReturn, nil
+	}
+
+	Found, err := Source.GetTagsByName(ctx, Tags)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range Found {
+		tag.Exists = true
+		tag.Exclude = Exclude
+		ToReturn = append(ToReturn, tag)
+	}
+
+	//Add back in non-existant tags
+	for _, tag := range Tags {
+		if tagsContainName(tag, Found) == false {
+			ToReturn = append(ToReturn, TagInformation{
+				Name:    tag,
+				Exists:  false,
+				Exclude: Exclude})
+		}
+	}
+
+	//Parse alaises
+	var AliasedIDs []uint64
+	for index := 0; index < len(ToReturn); index++ {
+		if ToReturn[index].IsAlias && tagsContainID(ToReturn[index].AliasedID, ToReturn) == false {
+			AliasedIDs = append(AliasedIDs, ToReturn[index].AliasedID)
+		}
+	}
+	if len(AliasedIDs) > 0 {
+		Aliased, err := Source.GetTagsByID(ctx, AliasedIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range Aliased {
+			tag.Exists = true
+			tag.Exclude = Exclude
+			ToReturn = append(ToReturn, tag)
+		}
+	}
+
+	//Pass output
+	return ToReturn, nil
+}
+
+//parseMetaTags fills in additional information for MetaTags and vets out non-MetaTags
+func parseMetaTags(ctx context.Context, Source QueryTagSource, MetaTags []TagInformation, CollectionContext bool) ([]TagInformation, []error) {
+	var ToReturn []TagInformation
+	var ErrorList []error
+	for _, tag := range MetaTags {
+		ToAdd := tag
+		switch {
+		//TODO: Add additional metatags here
+		case ToAdd.Name == "uploader":
+			ToAdd.Name = "UploaderID"
+			ToAdd.Description = "The uploaded of the image"
+			//Get uploader ID and set that to value
+			name, isString := ToAdd.MetaValue.(string)
+			if isString {
+				value, err := Source.GetUserID(ctx, name)
+				if err != nil {
+					ErrorList = append(ErrorList, err)
+				} else {
+					ToAdd.MetaValue = value
+					ToAdd.Exists = true
+				}
+				ToAdd.Comparator = "=" //Clobber any other comparator requested. This one will only support equals
+			} else {
+				ErrorList = append(ErrorList, errors.New("Could not convert metatag value to string as expected"))
+			}
+		case ToAdd.Name == "rating" && CollectionContext == false:
+			ToAdd.Name = "Rating"
+			ToAdd.Description = "The rating of the image"
+			ToAdd.Exists = true
+			ToAdd.Comparator = "=" //Clobber any other comparator requested. This one will only support equals
+			//Since rating is a string, no futher processing needed!
+		case ToAdd.Name == "score" && CollectionContext == false:
+			ToAdd.Name = "ScoreAverage"
+			ToAdd.Description = "The average voted score of the image"
+			sscore, isString := ToAdd.MetaValue.(string)
+			if isString {
+				score, err := strconv.ParseInt(sscore, 10, 64)
+				if err == nil {
+					ToAdd.MetaValue = score
+				}
+			}
+			//Must be an int64
+			_, isInt := ToAdd.MetaValue.(int64)
+			if isInt {
+				ToAdd.Exists = true
+			} else {
+				ErrorList = append(ErrorList, errors.New("could not parse requested score, ensure it is a number"))
+			}
+			//All comparators valid
+		case ToAdd.Name == "averagescore" && CollectionContext == false:
+			ToAdd.Name = "ScoreAverage"
+			ToAdd.Description = "The average voted score of the image"
+			sscore, isString := ToAdd.MetaValue.(string)
+			if isString {
+				score, err := strconv.ParseInt(sscore, 10, 64)
+				if err == nil {
+					ToAdd.MetaValue = score
+				}
+			}
+			//Must be an int64
+			_, isInt := ToAdd.MetaValue.(int64)
+			if isInt {
+				ToAdd.Exists = true
+			} else {
+				ErrorList = append(ErrorList, errors.New("could not parse requested score, ensure it is a number"))
+			}
+			//All comparators valid
+		case ToAdd.Name == "totalscore" && CollectionContext == false:
+			ToAdd.Name = "ScoreTotal"
+			ToAdd.Description = "The total sum of all voted scores for the image"
+			sscore, isString := ToAdd.MetaValue.(string)
+			if isString {
+				score, err := strconv.ParseInt(sscore, 10, 64)
+				if err == nil {
+					ToAdd.MetaValue = score
+				}
+			}
+			//Must be an int64
+			_, isInt := ToAdd.MetaValue.(int64)
+			if isInt {
+				ToAdd.Exists = true
+			} else {
+				ErrorList = append(ErrorList, errors.New("could not parse requested score, ensure it is a number"))
+			}
+			//All comparators valid
+		case ToAdd.Name == "scorevoters" && CollectionContext == false:
+			ToAdd.Name = "ScoreVoters"
+			ToAdd.Description = "The count of all users that voted on the image"
+			sscore, isString := ToAdd.MetaValue.(string)
+			if isString {
+				score, err := strconv.ParseInt(sscore, 10, 64)
+				if err == nil {
+					ToAdd.MetaValue = score
+				}
+			}
+			//Must be an int64
+			_, isInt := ToAdd.MetaValue.(int64)
+			if isInt {
+				ToAdd.Exists = true
+			} else {
+				ErrorList = append(ErrorList, errors.New("could not parse requested score, ensure it is a number"))
+			}
+			//All comparators valid
+		case ToAdd.Name == "incollection" && CollectionContext == false:
+			ToAdd.Name = "InCollection"
+			ToAdd.Description = "Whether the image is in a collection or not"
+			ToAdd.IsComplexMeta = true
+			inCollOption, isString := ToAdd.MetaValue.(string)
+			if isString {
+				if inCollOption == "Y" || inCollOption == "y" || inCollOption == "true" {
+					ToAdd.MetaValue = true
+					ToAdd.Exists = true
+				} else if inCollOption == "N" || inCollOption == "n" || inCollOption == "false" {
+					ToAdd.MetaValue = false
+					ToAdd.Exists = true
+				} else {
+					ErrorList = append(ErrorList, errors.New("could not parse incollection tag"))
+				}
+			} else {
+				ErrorList = append(ErrorList, errors.New("could not parse incollection tag"))
+			}
+			ToAdd.Comparator = "=" //Clobber any other comparator requested. This one will only support equals
+		case ToAdd.Name == "tagcount" && CollectionContext == false:
+			ToAdd.Name = "TagCount"
+			ToAdd.Description = "Number of tags an image has"
+			ToAdd.IsComplexMeta = true
+			stringValue, isString := ToAdd.MetaValue.(string)
+			if isString {
+				countValue, err := strconv.ParseInt(stringValue, 10, 64)
+				if err == nil {
+					ToAdd.Exists = true
+					ToAdd.MetaValue = strconv.FormatInt(countValue, 10)
+				}
+			} else {
+				ErrorList = append(ErrorList, errors.New("could not parse tagcount tag"))
+			}
+		case ToAdd.Name == "similar" && CollectionContext == false:
+			ToAdd.Name = "Similar"
+			ToAdd.Description = "Show images similar to the id specified"
+			ToAdd.IsComplexMeta = true
+			stringValue, isString := ToAdd.MetaValue.(string)
+			ToAdd.Comparator = "<=" //Only return results less than or equal to threshold
+			if isString {
+				//First handle similarity if needed
+				SimilarityThreshold := uint64(26) //At 128 bits, 26 is 20%...ish
+				stringComponents := strings.Split(stringValue, "-")
+				if len(stringComponents) == 2 {
+					newSimilarity, err := strconv.ParseUint(stringComponents[0], 10, 64)
+					if err != nil {
+						ErrorList = append(ErrorList, errors.New("error parsing similarity threshold for similarity tag"))
+						break
+					}
+					stringValue = stringComponents[1]
+					SimilarityThreshold = newSimilarity
+				} else if len(stringComponents) != 1 {
+					ErrorList = append(ErrorList, errors.New("could not parse similar tag"))
+					break
+				}
+				//Then id value
+				idValue, err := strconv.ParseUint(stringValue, 10, 64)
+				if err == nil {
+					hHash, vHash, err := Source.GetImagedHash(ctx, idValue)
+					if err == nil {
+						ToAdd.Exists = true
+						ToAdd.MetaValue = ImagedHash{ImagehHash: hHash, ImagevHash: vHash, SimilarityThreshold: SimilarityThreshold}
+					} else {
+						ErrorList = append(ErrorList, errors.New("internal error occured querying database for similar"))
+					}
+				} else {
+					ErrorList = append(ErrorList, errors.New("could not find requested image for similar tag"))
+				}
+			} else {
+				ErrorList = append(ErrorList, errors.New("could not parse similar tag"))
+			}
+		case ToAdd.Name == "name":
+			ToAdd.Name = "Name"
+			ToAdd.Description = "Name of the item"
+			ToAdd.IsComplexMeta = false
+			inCollOption, isString := ToAdd.MetaValue.(string)
+			if isString {
+
+				//This chunk is ugly, but allows us to escape spaces //TODO: This is stupid and needs fixing, and a dedicated function to do so
+				inCollOption = strings.Replace(inCollOption, "--", "#", -1) //Placeholder for dash
+				inCollOption = strings.Replace(inCollOption, "-_", "$", -1) //Placeholder for underscore
+				inCollOption = strings.Replace(inCollOption, "__", " ", -1)
+				inCollOption = strings.Replace(inCollOption, "#", "-", -1)
+				inCollOption = strings.Replace(inCollOption, "_", "$", -1)
+				inCollOption = strings.Replace(inCollOption, "$", "\\_", -1)
+				if len(inCollOption) > 3 {
+					ToAdd.MetaValue = "%" + inCollOption + "%"
+					ToAdd.Exists = true
+				} else {
+					ErrorList = append(ErrorList, errors.New("could not parse name tag, please lengthen your query"))
+				}
+			} else {
+				ErrorList = append(ErrorList, errors.New("could not parse name tag"))
+			}
+			ToAdd.Comparator = "LIKE" //Clobber any other comparator requested. This one will only support LIKE
+		case ToAdd.Name == "location" && CollectionContext == false:
+			ToAdd.Name = "Location"
+			ToAdd.Description = "The item's file location/name"
+			ToAdd.IsComplexMeta = false
+			inCollOption, isString := ToAdd.MetaValue.(string)
+			if isString {
+				//This chunk is ugly, but allows us to escape spaces
+				inCollOption = strings.Replace(inCollOption, "--", "#", -1) //Placeholder for dash
+				inCollOption = strings.Replace(inCollOption, "-_", "$", -1) //Placeholder for underscore
+				inCollOption = strings.Replace(inCollOption, "__", " ", -1)
+				inCollOption = strings.Replace(inCollOption, "#", "-", -1)
+				inCollOption = strings.Replace(inCollOption, "_", "$", -1)
+				inCollOption = strings.Replace(inCollOption, "$", "\\_", -1)
+				if len(inCollOption) > 3 {
+					ToAdd.MetaValue = "%" + inCollOption + "%"
+					ToAdd.Exists = true
+				} else {
+					ErrorList = append(ErrorList, errors.New("could not parse filename tag, please lengthen your query"))
+				}
+			} else {
+				ErrorList = append(ErrorList, errors.New("could not parse filename tag"))
+			}
+			ToAdd.Comparator = "LIKE" //Clobber any other comparator requested. This one will only support LIKE
+		case ToAdd.Name == "order":
+			var err error
+			ToAdd, err = ParseOrderMetaTag(ToAdd, time.Now(), CollectionContext)
+			if err != nil {
+				ErrorList = append(ErrorList, err)
+			}
+		case ToAdd.Name == "uploaded" && CollectionContext == false:
+			var err error
+			ToAdd, err = ParseUploadedMetaTag(ToAdd, time.Now())
+			if err != nil {
+				ErrorList = append(ErrorList, err)
+			}
+		case IsMediaMetaTag(ToAdd.Name) && CollectionContext == false:
+			var err error
+			ToAdd, err = ParseMediaMetaTag(ToAdd)
+			if err != nil {
+				ErrorList = append(ErrorList, err)
+			}
+		default:
+			ErrorList = append(ErrorList, errors.New("MetaTag does not exist"))
+		}
+		ToReturn = append(ToReturn, ToAdd)
+	}
+	return ToReturn, ErrorList
+}
+
+//tagsContainID is a helper function to check if a TagInformation slice contains a specified ID
+func tagsContainID(ID uint64, Tags []TagInformation) bool {
+	for _, tag := range Tags {
+		if tag.ID == ID {
+			return true
+		}
+	}
+	return false
+}
+
+//tagsContainName is a helper function to check if a TagInformation slice contains a specified Name
+func tagsContainName(Name string, Tags []TagInformation) bool {
+	for _, tag := range Tags {
+		if strings.EqualFold(tag.Name, Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package interfaces
+
+import (
+	"errors"
+)
+
+//MaxSavedSearches is the most saved searches one user can keep
+const MaxSavedSearches = 50
+
+//MaxSavedSearchQueryLength is the longest query that can be saved, in bytes
+const MaxSavedSearchQueryLength = 1000
+
+//maxSavedSearchNameLength is the longest name a saved search can have, matching the database column
+const maxSavedSearchNameLength = 40
+
+//SavedSearch is a named search query kept for a user, they can use it in other searches as saved:Name
+type SavedSearch struct {
+	ID     uint64
+	UserID uint64
+	Name   string
+	Query  string
+}
+
+//ValidateSavedSearchName returns an error unless Name can be used as saved:Name. Names are lowercase letters, digits, - and _, as queries are lowercased
+func ValidateSavedSearchName(Name string) error {
+	if Name == "" || len(Name) > maxSavedSearchNameLength {
+		return errors.New("saved search names must be between 1 and 40 characters")
+	}
+	for _, Character := range Name {
+		if (Character < 'a' || Character > 'z') && (Character < '0' || Character > '9') && Character != '-' && Character != '_' {
+			return errors.New("saved search names can only contain lowercase letters, numbers, - and _")
+		}
+	}
+	return nil
+}
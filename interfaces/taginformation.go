@@ -1,6 +1,11 @@
 package interfaces
 
 import (
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -108,3 +113,309 @@ func MergeTagSlices(Original []TagInformation, ToAdd []TagInformation) []TagInfo
 	//Now we can fall back to RemoveDuplicateTags to cleanup any other issues
 	return RemoveDuplicateTags(append(Original, ToAdd...))
 }
+
+var regexRelativeTime = regexp.MustCompile("^([0-9]+)(h|d|w|m|y)$")
+var regexFileSize = regexp.MustCompile("^([0-9]+(?:\\.[0-9]+)?)(b|kb|mb|gb|tb)?$")
+var regexMIMEType = regexp.MustCompile("^[a-z0-9.+-]+(/[a-z0-9.+-]+)?$")
+var regexExtension = regexp.MustCompile("^[a-z0-9]+$")
+
+//fileSizeUnits maps the units accepted by the filesize MetaTag to their size in bytes
+var fileSizeUnits = map[string]float64{
+	"":   1,
+	"b":  1,
+	"kb": 1 << 10,
+	"mb": 1 << 20,
+	"gb": 1 << 30,
+	"tb": 1 << 40,
+}
+
+//IsMediaMetaTag returns true if the MetaTag name is one that ParseMediaMetaTag handles
+func IsMediaMetaTag(Name string) bool {
+	switch Name {
+	case "width", "height", "ratio", "filesize", "duration", "type", "ext":
+		return true
+	}
+	return false
+}
+
+//ParseMediaMetaTag fills in the column, value and comparator for MetaTags that search the stored image metadata.
+//These are shared by all database plugins, as the values are parsed the same way regardless of backend.
+func ParseMediaMetaTag(ToAdd TagInformation) (TagInformation, error) {
+	stringValue, isString := ToAdd.MetaValue.(string)
+	if isString == false {
+		return ToAdd, errors.New("Could not convert metatag value to string as expected")
+	}
+	switch ToAdd.Name {
+	case "width", "height":
+		ToAdd.Description = "The " + ToAdd.Name + " of the image in pixels"
+		if ToAdd.Name == "width" {
+			ToAdd.Name = "Width"
+		} else {
+			ToAdd.Name = "Height"
+		}
+		value, err := strconv.ParseInt(stringValue, 10, 64)
+		if err != nil {
+			return ToAdd, errors.New("could not parse requested " + strings.ToLower(ToAdd.Name) + ", ensure it is a number")
+		}
+		ToAdd.MetaValue = value
+		//All comparators valid
+	case "ratio":
+		ToAdd.Name = "Ratio"
+		ToAdd.Description = "The aspect ratio of the image"
+		ToAdd.IsComplexMeta = true
+		value, err := ParseImageRatio(stringValue)
+		if err != nil {
+			return ToAdd, err
+		}
+		ToAdd.MetaValue = value
+		//All comparators valid
+	case "filesize":
+		ToAdd.Name = "FileSize"
+		ToAdd.Description = "The size of the file in bytes"
+		value, err := ParseFileSize(stringValue)
+		if err != nil {
+			return ToAdd, err
+		}
+		ToAdd.MetaValue = value
+		//All comparators valid
+	case "duration":
+		ToAdd.Name = "Duration"
+		ToAdd.Description = "The length of a video or audio file in seconds"
+		value, err := ParseDuration(stringValue)
+		if err != nil {
+			return ToAdd, err
+		}
+		ToAdd.MetaValue = value
+		//All comparators valid
+	case "type":
+		ToAdd.Name = "MIMEType"
+		ToAdd.Description = "The type of the file"
+		if regexMIMEType.MatchString(stringValue) == false {
+			return ToAdd, errors.New("could not parse type tag, use a type such as video or a full type such as image/png")
+		}
+		if strings.Contains(stringValue, "/") {
+			ToAdd.MetaValue = stringValue
+			ToAdd.Comparator = "=" //Clobber any other comparator requested. This one will only support equals
+		} else {
+			ToAdd.MetaValue = stringValue + "/%"
+			ToAdd.Comparator = "LIKE" //Clobber any other comparator requested. This one will only support LIKE
+		}
+	case "ext":
+		ToAdd.Name = "Location"
+		ToAdd.Description = "The file extension of the item"
+		stringValue = strings.TrimPrefix(stringValue, ".")
+		if regexExtension.MatchString(stringValue) == false {
+			return ToAdd, errors.New("could not parse ext tag, use an extension such as gif")
+		}
+		ToAdd.MetaValue = "%." + stringValue
+		ToAdd.Comparator = "LIKE" //Clobber any other comparator requested. This one will only support LIKE
+	default:
+		return ToAdd, errors.New("MetaTag does not exist")
+	}
+	ToAdd.Exists = true
+	return ToAdd, nil
+}
+
+//ParseImageRatio parses a ratio written as width:height (16:9) or as a decimal (1.78)
+func ParseImageRatio(Value string) (ImageRatio, error) {
+	Parts := strings.Split(Value, ":")
+	if len(Parts) == 2 {
+		Width, err := strconv.ParseUint(Parts[0], 10, 64)
+		if err != nil {
+			return ImageRatio{}, errors.New("could not parse ratio tag, use a ratio such as 16:9 or 1.78")
+		}
+		Height, err := strconv.ParseUint(Parts[1], 10, 64)
+		if err != nil || Width == 0 || Height == 0 || Width > math.MaxUint32 || Height > math.MaxUint32 {
+			return ImageRatio{}, errors.New("could not parse ratio tag, use a ratio such as 16:9 or 1.78")
+		}
+		return ImageRatio{Width: Width, Height: Height}, nil
+	} else if len(Parts) == 1 {
+		Decimal, err := strconv.ParseFloat(Parts[0], 64)
+		if err != nil || Decimal <= 0 || Decimal > 1000 || math.IsNaN(Decimal) {
+			return ImageRatio{}, errors.New("could not parse ratio tag, use a ratio such as 16:9 or 1.78")
+		}
+		//Keep three decimal places, and match anything within 0.005 so a rounded ratio such as 1.78 still finds 1920x1080
+		return ImageRatio{Width: uint64(math.Round(Decimal * 1000)), Height: 1000, Tolerance: 5}, nil
+	}
+	return ImageRatio{}, errors.New("could not parse ratio tag, use a ratio such as 16:9 or 1.78")
+}
+
+//ParseFileSize parses a file size such as 500kb or 1.5gb into bytes. Units are 1024 based, and no unit means bytes
+func ParseFileSize(Value string) (int64, error) {
+	Parts := regexFileSize.FindStringSubmatch(Value)
+	if Parts == nil {
+		return 0, errors.New("could not parse filesize tag, use a size such as 5mb")
+	}
+	Size, err := strconv.ParseFloat(Parts[1], 64)
+	if err != nil {
+		return 0, errors.New("could not parse filesize tag, use a size such as 5mb")
+	}
+	Size = Size * fileSizeUnits[Parts[2]]
+	if Size > math.MaxInt64/2 {
+		return 0, errors.New("could not parse filesize tag, requested size is too large")
+	}
+	return int64(math.Round(Size)), nil
+}
+
+//ParseDuration parses a duration in seconds (90), or in minutes and seconds (1:30 or 1:02:30 with hours)
+func ParseDuration(Value string) (float64, error) {
+	Parts := strings.Split(Value, ":")
+	if len(Parts) > 3 {
+		return 0, errors.New("could not parse duration tag, use seconds such as 90 or minutes such as 1:30")
+	}
+	var Duration float64
+	for Index, Part := range Parts {
+		var PartValue float64
+		var err error
+		if Index == len(Parts)-1 {
+			PartValue, err = strconv.ParseFloat(Part, 64)
+		} else {
+			var IntValue uint64
+			IntValue, err = strconv.ParseUint(Part, 10, 32)
+			PartValue = float64(IntValue)
+		}
+		if err != nil || PartValue < 0 || math.IsNaN(PartValue) || math.IsInf(PartValue, 0) {
+			return 0, errors.New("could not parse duration tag, use seconds such as 90 or minutes such as 1:30")
+		}
+		Duration = Duration*60 + PartValue
+	}
+	return Duration, nil
+}
+
+//TimeRange is the span of time an uploaded MetaTag's date covers, from Start up to but not including End
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+//Bounds returns the times matching "time Comparator range" must fall between, from From up to but not including To.
+//A zero From or To is open on that side. For != the bounds of the range itself are returned, and matches are outside of them
+func (Range TimeRange) Bounds(Comparator string) (time.Time, time.Time) {
+	switch Comparator {
+	case ">":
+		return Range.End, time.Time{}
+	case ">=":
+		return Range.Start, time.Time{}
+	case "<":
+		return time.Time{}, Range.Start
+	case "<=":
+		return time.Time{}, Range.End
+	}
+	return Range.Start, Range.End
+}
+
+//ParseUploadedMetaTag fills in an uploaded MetaTag. Dates cover a whole year (2024), month (2024-05) or day (2024-05-01) in UTC
+//Relative values (7d) are an age, counted back from Now in hours, days, weeks, months or years, so uploaded:<7d is the last week
+func ParseUploadedMetaTag(ToAdd TagInformation, Now time.Time) (TagInformation, error) {
+	ToAdd.Name = "UploadTime"
+	ToAdd.Description = "When the image was uploaded"
+	ToAdd.IsComplexMeta = true
+	stringValue, isString := ToAdd.MetaValue.(string)
+	if isString == false {
+		return ToAdd, errors.New("Could not convert metatag value to string as expected")
+	}
+	if Parts := regexRelativeTime.FindStringSubmatch(stringValue); Parts != nil {
+		Amount, err := strconv.Atoi(Parts[1])
+		if err != nil || Amount > 100000 {
+			return ToAdd, errors.New("could not parse uploaded tag, requested age is too large")
+		}
+		Since := Now.UTC()
+		switch Parts[2] {
+		case "h":
+			Since = Since.Add(-time.Duration(Amount) * time.Hour)
+		case "d":
+			Since = Since.AddDate(0, 0, -Amount)
+		case "w":
+			Since = Since.AddDate(0, 0, -7*Amount)
+		case "m":
+			Since = Since.AddDate(0, -Amount, 0)
+		case "y":
+			Since = Since.AddDate(-Amount, 0, 0)
+		}
+		//An age is the opposite direction of a time, younger than 7 days is uploaded after 7 days ago
+		if ToAdd.Comparator == ">" || ToAdd.Comparator == ">=" {
+			ToAdd.Comparator = "<"
+		} else {
+			ToAdd.Comparator = ">="
+		}
+		ToAdd.MetaValue = TimeRange{Start: Since, End: Since}
+		ToAdd.Exists = true
+		return ToAdd, nil
+	}
+	for _, Layout := range []struct {
+		Format string
+		Years  int
+		Months int
+		Days   int
+	}{{"2006-01-02", 0, 0, 1}, {"2006-01", 0, 1, 0}, {"2006", 1, 0, 0}} {
+		Start, err := time.ParseInLocation(Layout.Format, stringValue, time.UTC)
+		if err == nil {
+			ToAdd.MetaValue = TimeRange{Start: Start, End: Start.AddDate(Layout.Years, Layout.Months, Layout.Days)}
+			ToAdd.Exists = true
+			//All comparators valid
+			return ToAdd, nil
+		}
+	}
+	return ToAdd, errors.New("could not parse uploaded tag, use a date such as 2024-05-01 or 2024-05, or an age such as 7d")
+}
+
+//SearchOrder is the value of an order MetaTag, which sorts search results instead of filtering them
+type SearchOrder struct {
+	//Name is the order as typed after order:, without _asc, such as score or tagcount
+	Name string
+	//Ascending lists the smallest values first, set by adding _asc to the order
+	Ascending bool
+	//Seed picks the shuffle used by the random order, so every page of results and the previous and next links agree
+	Seed int64
+}
+
+//RandomOrderMultiplier and RandomOrderModulus scramble IDs for the random order, the same way in every database
+const (
+	RandomOrderMultiplier = 1103515245
+	RandomOrderModulus    = 2147483647
+)
+
+//RandomOrderKey returns the sort key of an item in the random order. This matches the SQL used by the database plugins
+func (Order SearchOrder) RandomOrderKey(ID uint64) int64 {
+	Scrambled := (int64(ID)*RandomOrderMultiplier + Order.Seed) % RandomOrderModulus
+	return Scrambled * Scrambled % RandomOrderModulus
+}
+
+//ParseOrderMetaTag fills in an order MetaTag. Orders other than random can end in _asc to reverse them, and orders cannot be excluded.
+//order:random:Seed picks a shuffle, plain order:random uses the day's, see PinRandomOrder
+func ParseOrderMetaTag(ToAdd TagInformation, Now time.Time, CollectionContext bool) (TagInformation, error) {
+	ToAdd.Name = "Order"
+	ToAdd.Description = "Sorts the results"
+	ToAdd.IsComplexMeta = true
+	stringValue, isString := ToAdd.MetaValue.(string)
+	if isString == false {
+		return ToAdd, errors.New("Could not convert metatag value to string as expected")
+	}
+	if ToAdd.Exclude {
+		return ToAdd, errors.New("could not parse order tag, orders cannot be excluded")
+	}
+	stringValue, SeedString, HasSeed := strings.Cut(stringValue, ":")
+	Order := SearchOrder{Name: strings.TrimSuffix(stringValue, "_asc"), Ascending: strings.HasSuffix(stringValue, "_asc")}
+	Valid := map[string]bool{"uploaded": true, "random": true, "tagcount": true}
+	if CollectionContext == false {
+		Valid["score"] = true
+		Valid["votes"] = true
+		Valid["filesize"] = true
+	}
+	if Valid[Order.Name] == false || (Order.Name == "random" && Order.Ascending) {
+		return ToAdd, errors.New("could not parse order tag, " + stringValue + " is not a supported order")
+	}
+	if HasSeed {
+		Seed, err := strconv.ParseInt(SeedString, 10, 64)
+		if Order.Name != "random" || err != nil || Seed < 0 || Seed >= RandomOrderModulus {
+			return ToAdd, errors.New("could not parse order tag, only the random order takes a seed between 0 and " + strconv.Itoa(RandomOrderModulus-1))
+		}
+		Order.Seed = Seed
+	} else if Order.Name == "random" {
+		Order.Seed = (Now.UTC().Unix() / 86400 * 2654435761) % RandomOrderModulus
+	}
+	ToAdd.MetaValue = Order
+	ToAdd.Comparator = "=" //Orders are not compared
+	ToAdd.Exists = true
+	return ToAdd, nil
+}
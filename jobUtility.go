@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"go-image-board/routers"
+	"go-image-board/storage"
+	"io/fs"
+	"strconv"
+)
+
+//regenerateAll queues a job of type Type for every image it applies to, or only those missing the result when MissingOnly is set, then runs the queue until it is empty
+func regenerateAll(Type interfaces.JobType, MissingOnly bool) error {
+	ctx := context.Background()
+	images, err := getAllImages(ctx)
+	if err != nil {
+		return err
+	}
+	queued := uint64(0)
+	for _, imageInfo := range images {
+		var missing bool
+		switch Type {
+		case interfaces.JobThumbnail:
+			if routers.CanGenerateThumbnail(imageInfo.Location) == false {
+				continue
+			}
+			_, err = storage.BlobStore.Stat(ctx, routers.ThumbnailName(imageInfo.Location))
+			missing = errors.Is(err, fs.ErrNotExist)
+		case interfaces.JobdHash:
+			if routers.CanGeneratedHash(imageInfo.Location) == false {
+				continue
+			}
+			_, _, err = database.DBInterface.GetImagedHash(ctx, imageInfo.ID)
+			missing = err == sql.ErrNoRows
+		case interfaces.JobMetadata:
+			//Search results do not include metadata
+			fullInfo, err := database.DBInterface.GetImage(ctx, imageInfo.ID)
+			if err != nil {
+				return err
+			}
+			missing = fullInfo.MIMEType == ""
+		}
+		if MissingOnly && missing == false {
+			continue
+		}
+		if err := routers.QueueJob(ctx, database.DBInterface, Type, imageInfo.ID); err != nil {
+			return err
+		}
+		queued++
+	}
+	logging.WriteLog(logging.LogLevelInfo, "jobUtility/regenerateAll", "0", logging.ResultInfo, []string{"Queued", strconv.FormatUint(queued, 10), string(Type), "jobs"})
+
+	failed, err := routers.RunQueuedJobs(ctx)
+	if err != nil {
+		return err
+	}
+	logging.WriteLog(logging.LogLevelInfo, "jobUtility/regenerateAll", "0", logging.ResultSuccess, []string{"Finished running jobs,", strconv.FormatUint(failed, 10), "have failed, see /mod/jobs"})
+	return nil
+}
@@ -0,0 +1,165 @@
+//Package blobconformance is a set of checks every BlobStore plugin is expected to pass.
+//Plugins call RunSuite from their own tests, so a backend only needs a test file that sets up a store.
+package blobconformance
+
+import (
+	"bytes"
+	"errors"
+	"go-image-board/interfaces"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+//RunSuite runs all conformance checks against Store. Init must already have been called on Store.
+//Every name the suite uses starts with a prefix unique to the run, so it is safe to point it at a store that is in use, and it removes what it created
+func RunSuite(t *testing.T, Store interfaces.BlobStore) {
+	ctx := t.Context()
+	Prefix := "ct" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	Image, Nested, Thumb := Prefix+"-image.png", Prefix+"/ab/nested.png", "thumbs/"+Prefix+"-image.png.png"
+	Content := []byte("conformance image content")
+	defer func() {
+		for _, Name := range []string{Image, Nested, Thumb, Prefix + "-renamed.png"} {
+			Store.Delete(ctx, Name)
+		}
+	}()
+
+	//Create refuses to replace, Put does
+	if err := Store.Create(ctx, Image, bytes.NewReader(Content), int64(len(Content))); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := Store.Create(ctx, Image, strings.NewReader("replaced"), 8); errors.Is(err, fs.ErrExist) == false {
+		t.Errorf("Create of a stored name = %v, want fs.ErrExist", err)
+	}
+	checkContent(t, Store, Image, Content)
+	if info, err := Store.Stat(ctx, Image); err != nil || info.Name != Image || info.Size != int64(len(Content)) || info.ModTime.IsZero() {
+		t.Errorf("Stat = %+v, %v, want %s of %d bytes", info, err, Image, len(Content))
+	}
+	if err := Store.Create(ctx, Nested, bytes.NewReader(Content), int64(len(Content))); err != nil {
+		t.Errorf("Create of a nested name failed: %v", err)
+	}
+	Thumbnail := []byte("conformance thumbnail")
+	if err := Store.Put(ctx, Thumb, strings.NewReader("first"), 5); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := Store.Put(ctx, Thumb, bytes.NewReader(Thumbnail), -1); err != nil {
+		t.Fatalf("Put over a stored name failed: %v", err)
+	}
+	checkContent(t, Store, Thumb, Thumbnail)
+
+	//Missing names
+	if _, err := Store.Open(ctx, Prefix+"-missing.png"); errors.Is(err, fs.ErrNotExist) == false {
+		t.Errorf("Open of a missing name = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := Store.Stat(ctx, Prefix+"-missing.png"); errors.Is(err, fs.ErrNotExist) == false {
+		t.Errorf("Stat of a missing name = %v, want fs.ErrNotExist", err)
+	}
+	if err := Store.Delete(ctx, Prefix+"-missing.png"); err != nil {
+		t.Errorf("Delete of a missing name = %v, want nil", err)
+	}
+
+	//Open can seek, so blobs can be served with range requests
+	if Reader, err := Store.Open(ctx, Image); err != nil {
+		t.Errorf("Open failed: %v", err)
+	} else {
+		Part := make([]byte, 5)
+		if _, err := Reader.Seek(12, io.SeekStart); err != nil {
+			t.Errorf("Seek failed: %v", err)
+		} else if _, err := io.ReadFull(Reader, Part); err != nil || string(Part) != string(Content[12:17]) {
+			t.Errorf("Read after Seek = %q, %v, want %q", Part, err, Content[12:17])
+		}
+		Reader.Close()
+	}
+
+	//List only visits names under the prefix, and stops at the first error
+	if Names := listNames(t, Store, Prefix); slices.Equal(Names, []string{Image, Nested}) == false {
+		t.Errorf("List(%s) = %q, want %q", Prefix, Names, []string{Image, Nested})
+	}
+	if Names := listNames(t, Store, "thumbs/"+Prefix); slices.Equal(Names, []string{Thumb}) == false {
+		t.Errorf("List(thumbs/%s) = %q, want %q", Prefix, Names, []string{Thumb})
+	}
+	Stop := errors.New("stop")
+	Visited := 0
+	if err := Store.List(ctx, Prefix, func(Blob interfaces.BlobInfo) error { Visited++; return Stop }); err != Stop || Visited != 1 {
+		t.Errorf("List with a failing Visit = %v after %d calls, want the error after 1", err, Visited)
+	}
+
+	//Rename
+	if err := Store.Rename(ctx, Image, Prefix+"-renamed.png"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if _, err := Store.Stat(ctx, Image); errors.Is(err, fs.ErrNotExist) == false {
+		t.Errorf("Stat of the old name after Rename = %v, want fs.ErrNotExist", err)
+	}
+	checkContent(t, Store, Prefix+"-renamed.png", Content)
+
+	checkServe(t, Store, Prefix+"-renamed.png", Content)
+
+	if err := Store.Delete(ctx, Prefix+"-renamed.png"); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+	if _, err := Store.Stat(ctx, Prefix+"-renamed.png"); errors.Is(err, fs.ErrNotExist) == false {
+		t.Errorf("Stat after Delete = %v, want fs.ErrNotExist", err)
+	}
+}
+
+//checkContent fails the test if Name does not hold Want
+func checkContent(t *testing.T, Store interfaces.BlobStore, Name string, Want []byte) {
+	t.Helper()
+	Reader, err := Store.Open(t.Context(), Name)
+	if err != nil {
+		t.Errorf("Open(%s) failed: %v", Name, err)
+		return
+	}
+	defer Reader.Close()
+	if Got, err := io.ReadAll(Reader); err != nil || bytes.Equal(Got, Want) == false {
+		t.Errorf("Open(%s) read %q, %v, want %q", Name, Got, err, Want)
+	}
+}
+
+//listNames returns the sorted names List visits for Prefix
+func listNames(t *testing.T, Store interfaces.BlobStore, Prefix string) []string {
+	t.Helper()
+	var Names []string
+	if err := Store.List(t.Context(), Prefix, func(Blob interfaces.BlobInfo) error {
+		Names = append(Names, Blob.Name)
+		return nil
+	}); err != nil {
+		t.Errorf("List(%s) failed: %v", Prefix, err)
+	}
+	slices.Sort(Names)
+	return Names
+}
+
+//checkServe makes sure ServeBlob replies with Name's content, whole or in part, either directly or through a redirect
+func checkServe(t *testing.T, Store interfaces.BlobStore, Name string, Want []byte) {
+	t.Helper()
+	Request := httptest.NewRequest("GET", "/images/"+Name, nil)
+	Request.Header.Set("Range", "bytes=0-9")
+	Recorder := httptest.NewRecorder()
+	Store.ServeBlob(Recorder, Request, Name)
+	Response := Recorder.Result()
+	if Response.StatusCode == http.StatusFound {
+		Redirected, err := http.NewRequestWithContext(t.Context(), "GET", Response.Header.Get("Location"), nil)
+		if err != nil {
+			t.Errorf("ServeBlob redirected to %q: %v", Response.Header.Get("Location"), err)
+			return
+		}
+		Redirected.Header.Set("Range", "bytes=0-9")
+		if Response, err = http.DefaultClient.Do(Redirected); err != nil {
+			t.Errorf("Following the ServeBlob redirect failed: %v", err)
+			return
+		}
+	}
+	defer Response.Body.Close()
+	Got, err := io.ReadAll(Response.Body)
+	if err != nil || Response.StatusCode != http.StatusPartialContent || bytes.Equal(Got, Want[:10]) == false {
+		t.Errorf("ServeBlob with a range = %d %q, %v, want 206 %q", Response.StatusCode, Got, err, Want[:10])
+	}
+}
@@ -0,0 +1,181 @@
+package dbconformance
+
+import (
+	"database/sql"
+	"go-image-board/interfaces"
+	"testing"
+)
+
+//checkUsers covers account creation, lookup, permissions, filters and removal
+func (state *suiteState) checkUsers(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	if err := DB.ValidateProposedUsername("not valid!"); err == nil {
+		t.Error("ValidateProposedUsername accepted a name with spaces and symbols")
+	}
+	if err := DB.ValidateProposedUsername(state.prefix + "v"); err != nil {
+		t.Errorf("ValidateProposedUsername rejected %s: %v", state.prefix+"v", err)
+	}
+	if err := DB.ValidatePasswordStrength("a"); err == nil {
+		t.Error("ValidatePasswordStrength accepted a one character password")
+	}
+
+	//Names and emails are unique
+	if err := DB.CreateUser(ctx, state.userName, []byte(suitePassword), state.prefix+"other@example.com", 0); err == nil {
+		t.Error("CreateUser allowed a duplicate name")
+	}
+	if err := DB.CreateUser(ctx, state.prefix+"dup", []byte(suitePassword), state.userName+"@example.com", 0); err == nil {
+		t.Error("CreateUser allowed a duplicate email")
+	}
+
+	if err := DB.ValidateUser(ctx, state.userName, []byte(suitePassword)); err != nil {
+		t.Errorf("ValidateUser rejected the right password: %v", err)
+	}
+	if err := DB.ValidateUser(ctx, state.userName, []byte("Wrong-Pass1")); err == nil {
+		t.Error("ValidateUser accepted the wrong password")
+	}
+
+	User, err := DB.GetUser(ctx, state.userID)
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	if User.Name != state.userName || User.ID != state.userID {
+		t.Errorf("GetUser returned %+v for %s/%d", User, state.userName, state.userID)
+	}
+
+	//Permissions
+	Permissions, err := DB.GetUserPermissionSet(ctx, state.userName)
+	if err != nil || Permissions != interfaces.UploadImage {
+		t.Errorf("GetUserPermissionSet = %d, %v, want %d", Permissions, err, interfaces.UploadImage)
+	}
+	NewPermissions := uint64(interfaces.UploadImage | interfaces.ModifyImageTags | interfaces.AddTags)
+	if err := DB.SetUserPermissionSet(ctx, state.userID, NewPermissions); err != nil {
+		t.Errorf("SetUserPermissionSet failed: %v", err)
+	}
+	if Permissions, err = DB.GetUserPermissionSet(ctx, state.userName); err != nil || uint64(Permissions) != NewPermissions {
+		t.Errorf("GetUserPermissionSet after update = %d, %v, want %d", Permissions, err, NewPermissions)
+	}
+
+	//Filters
+	if err := DB.SetUserQueryTags(ctx, state.userID, "rating:safe"); err != nil {
+		t.Errorf("SetUserQueryTags failed: %v", err)
+	}
+	if Filter, err := DB.GetUserFilter(ctx, state.userID); err != nil || Filter != "rating:safe" {
+		t.Errorf("GetUserFilter = %q, %v, want %q", Filter, err, "rating:safe")
+	}
+	FilterTags, err := DB.GetUserFilterTags(ctx, state.userID, false)
+	if err != nil || len(FilterTags) != 1 || FilterTags[0].FromUserFilter == false || FilterTags[0].Name != "Rating" {
+		t.Errorf("GetUserFilterTags = %+v, %v, want a single Rating tag from the user filter", FilterTags, err)
+	}
+	if err := DB.SetUserQueryTags(ctx, state.userID, ""); err != nil {
+		t.Errorf("SetUserQueryTags failed to clear the filter: %v", err)
+	}
+
+	//Saved searches, saving a name again replaces its query
+	for _, Saved := range [][2]string{{"wallpapers", "landscape rating:safe"}, {"cats", "cat"}, {"wallpapers", "landscape -rating:explicit"}} {
+		if err := DB.SetSavedSearch(ctx, state.userID, Saved[0], Saved[1]); err != nil {
+			t.Errorf("SetSavedSearch(%s) failed: %v", Saved[0], err)
+		}
+	}
+	Searches, err := DB.GetSavedSearches(ctx, state.userID)
+	if err != nil || len(Searches) != 2 || Searches[0].Name != "cats" || Searches[1].Name != "wallpapers" || Searches[1].Query != "landscape -rating:explicit" || Searches[1].UserID != state.userID {
+		t.Errorf("GetSavedSearches = %+v, %v, want cats then the replaced wallpapers", Searches, err)
+	}
+	if Searches, err := DB.GetSavedSearches(ctx, 0); err != nil || len(Searches) != 0 {
+		t.Errorf("GetSavedSearches(SYSTEM) = %+v, %v, want another user's searches left out", Searches, err)
+	}
+	if err := DB.DeleteSavedSearch(ctx, state.userID, "cats"); err != nil {
+		t.Errorf("DeleteSavedSearch failed: %v", err)
+	}
+	if err := DB.DeleteSavedSearch(ctx, state.userID, "cats"); err != sql.ErrNoRows {
+		t.Errorf("DeleteSavedSearch of a deleted search = %v, want sql.ErrNoRows", err)
+	}
+	if Searches, err := DB.GetSavedSearches(ctx, state.userID); err != nil || len(Searches) != 1 || Searches[0].Name != "wallpapers" {
+		t.Errorf("GetSavedSearches after delete = %+v, %v, want only wallpapers", Searches, err)
+	}
+
+	//Search
+	Users, Count, err := DB.SearchUsers(ctx, state.prefix, 0, 10)
+	if err != nil || Count < 1 || len(Users) < 1 {
+		t.Errorf("SearchUsers(%s) = %v, %d, %v, expected the suite user", state.prefix, Users, Count, err)
+	}
+	Found := false
+	for _, User := range Users {
+		Found = Found || User.ID == state.userID
+	}
+	if Found == false {
+		t.Errorf("SearchUsers(%s) did not return the suite user", state.prefix)
+	}
+
+	//Removal
+	RemovedName := state.prefix + "r"
+	if err := DB.CreateUser(ctx, RemovedName, []byte(suitePassword), RemovedName+"@example.com", 0); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	RemovedID, err := DB.GetUserID(ctx, RemovedName)
+	if err != nil {
+		t.Fatalf("GetUserID failed: %v", err)
+	}
+	if err := DB.SetSavedSearch(ctx, RemovedID, "mine", "cat"); err != nil {
+		t.Errorf("SetSavedSearch failed: %v", err)
+	}
+	if err := DB.RemoveUser(ctx, RemovedName); err != nil {
+		t.Errorf("RemoveUser failed: %v", err)
+	}
+	if Searches, err := DB.GetSavedSearches(ctx, RemovedID); err != nil || len(Searches) != 0 {
+		t.Errorf("GetSavedSearches of a removed user = %+v, %v, want them removed with the user", Searches, err)
+	}
+	if _, err := DB.GetUserID(ctx, RemovedName); err == nil {
+		t.Error("GetUserID found a removed user")
+	}
+}
+
+//checkTokens covers session tokens and disabling an account
+func (state *suiteState) checkTokens(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	Token, err := DB.GenerateToken(ctx, state.userName, "127.0.0.1")
+	if err != nil || Token == "" {
+		t.Fatalf("GenerateToken = %q, %v", Token, err)
+	}
+	if err := DB.ValidateToken(ctx, state.userName, Token, "127.0.0.1"); err != nil {
+		t.Errorf("ValidateToken rejected a fresh token: %v", err)
+	}
+	if err := DB.ValidateToken(ctx, state.userName, Token, "127.0.0.2"); err == nil {
+		t.Error("ValidateToken accepted a token from a different IP")
+	}
+	if err := DB.ValidateToken(ctx, state.userName, "", "127.0.0.1"); err == nil {
+		t.Error("ValidateToken accepted a blank token")
+	}
+	OtherToken, err := DB.GenerateToken(ctx, state.userName, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	if err := DB.ValidateToken(ctx, state.userName, Token, "127.0.0.1"); err == nil {
+		t.Error("ValidateToken accepted a token that was replaced")
+	}
+
+	//Disabled accounts never validate
+	if err := DB.SetUserDisableState(ctx, state.userID, true); err != nil {
+		t.Fatalf("SetUserDisableState failed: %v", err)
+	}
+	if err := DB.ValidateToken(ctx, state.userName, OtherToken, "127.0.0.1"); err == nil {
+		t.Error("ValidateToken accepted a token for a disabled account")
+	}
+	if User, err := DB.GetUser(ctx, state.userID); err != nil || User.Disabled == false {
+		t.Errorf("GetUser = %+v, %v, expected the account to be disabled", User, err)
+	}
+	if err := DB.SetUserDisableState(ctx, state.userID, false); err != nil {
+		t.Fatalf("SetUserDisableState failed: %v", err)
+	}
+	if err := DB.ValidateToken(ctx, state.userName, OtherToken, "127.0.0.1"); err != nil {
+		t.Errorf("ValidateToken rejected a token after the account was enabled again: %v", err)
+	}
+
+	if err := DB.RevokeToken(ctx, state.userName); err != nil {
+		t.Errorf("RevokeToken failed: %v", err)
+	}
+	if err := DB.ValidateToken(ctx, state.userName, OtherToken, "127.0.0.1"); err == nil {
+		t.Error("ValidateToken accepted a revoked token")
+	}
+}
@@ -0,0 +1,118 @@
+package dbconformance
+
+import (
+	"encoding/json"
+	"go-image-board/interfaces"
+	"strings"
+	"testing"
+	"time"
+)
+
+//checkAuditLogs covers searching the audit log, entries come back newest first and every filter narrows them
+func (state *suiteState) checkAuditLogs(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	ActionA, ActionB := interfaces.AuditAction(state.prefix+"-A"), interfaces.AuditAction(state.prefix+"-B")
+	for _, Entry := range []interfaces.AuditLog{
+		{UserID: state.userID, Action: ActionA, TargetType: interfaces.AuditTargetImage, TargetID: 987654321, Details: json.RawMessage(`{"Tags":[5]}`)},
+		{UserID: state.userID, Action: ActionB, TargetType: interfaces.AuditTargetTag, TargetID: 987654321},
+		{UserID: state.userID, Action: ActionA, TargetType: interfaces.AuditTargetImage, TargetID: 987654321, Details: json.RawMessage(`{"Error":"insufficient permissions"}`)},
+	} {
+		if err := DB.AddAuditLog(ctx, Entry); err != nil {
+			t.Fatalf("AddAuditLog failed: %v", err)
+		}
+	}
+
+	Logs, Count, err := DB.SearchAuditLogs(ctx, interfaces.AuditLogFilter{Action: ActionA}, 0, 100)
+	if err != nil || Count != 2 || len(Logs) != 2 {
+		t.Fatalf("SearchAuditLogs(action) = %+v, %d, %v, want 2 entries", Logs, Count, err)
+	}
+	if string(Logs[0].Details) != `{"Error":"insufficient permissions"}` || string(Logs[1].Details) != `{"Tags":[5]}` || Logs[0].ID <= Logs[1].ID {
+		t.Errorf("SearchAuditLogs(action) = %+v, want newest first with details kept", Logs)
+	}
+	if Logs[0].UserID != state.userID || Logs[0].UserName != state.userName || Logs[0].Action != ActionA || Logs[0].TargetType != interfaces.AuditTargetImage || Logs[0].TargetID != 987654321 || Logs[0].LogTime.IsZero() {
+		t.Errorf("SearchAuditLogs(action)[0] = %+v, want user %s, the target and LogTime filled in", Logs[0], state.userName)
+	}
+	if Page, Count, err := DB.SearchAuditLogs(ctx, interfaces.AuditLogFilter{Action: ActionA}, 1, 1); err != nil || Count != 2 || len(Page) != 1 || Page[0].ID != Logs[1].ID {
+		t.Errorf("SearchAuditLogs(action, 1, 1) = %+v, %d, %v, want only %d", Page, Count, err, Logs[1].ID)
+	}
+
+	//Targets match on both type and ID, an event without details gets an empty object
+	if Logs, Count, err := DB.SearchAuditLogs(ctx, interfaces.AuditLogFilter{TargetType: interfaces.AuditTargetImage, TargetID: 987654321}, 0, 100); err != nil || Count != 2 || len(Logs) != 2 {
+		t.Errorf("SearchAuditLogs(image target) = %+v, %d, %v, want the 2 image entries", Logs, Count, err)
+	}
+	if Logs, Count, err := DB.SearchAuditLogs(ctx, interfaces.AuditLogFilter{TargetType: interfaces.AuditTargetTag, TargetID: 987654321}, 0, 100); err != nil || Count != 1 || len(Logs) != 1 || string(Logs[0].Details) != "{}" {
+		t.Errorf("SearchAuditLogs(tag target) = %+v, %d, %v, want 1 entry with empty details", Logs, Count, err)
+	}
+	if _, Count, err := DB.SearchAuditLogs(ctx, interfaces.AuditLogFilter{TargetID: 987654321}, 0, 100); err != nil || Count != 3 {
+		t.Errorf("SearchAuditLogs(target ID) = %d, %v, want 3", Count, err)
+	}
+	if err := DB.AddAuditLog(ctx, interfaces.AuditLog{UserID: state.userID, Action: interfaces.AuditAction(strings.Repeat("A", 41))}); err == nil {
+		t.Errorf("AddAuditLog with a 41 character action did not fail")
+	}
+
+	Now := time.Now()
+	if _, Count, err := DB.SearchAuditLogs(ctx, interfaces.AuditLogFilter{UserID: state.userID, Action: ActionB, Since: Now.Add(-time.Hour), Until: Now.Add(time.Hour)}, 0, 100); err != nil || Count != 1 {
+		t.Errorf("SearchAuditLogs(user, this hour) = %d, %v, want 1", Count, err)
+	}
+	if _, Count, err := DB.SearchAuditLogs(ctx, interfaces.AuditLogFilter{Action: ActionB, Until: Now.Add(-time.Hour)}, 0, 100); err != nil || Count != 0 {
+		t.Errorf("SearchAuditLogs(until an hour ago) = %d, %v, want none", Count, err)
+	}
+	if _, Count, err := DB.SearchAuditLogs(ctx, interfaces.AuditLogFilter{Action: ActionB, Since: Now.Add(time.Hour)}, 0, 100); err != nil || Count != 0 {
+		t.Errorf("SearchAuditLogs(since an hour from now) = %d, %v, want none", Count, err)
+	}
+	if _, Count, err := DB.SearchAuditLogs(ctx, interfaces.AuditLogFilter{UserID: state.userID + 100000, Action: ActionA}, 0, 100); err != nil || Count != 0 {
+		t.Errorf("SearchAuditLogs(other user) = %d, %v, want none", Count, err)
+	}
+}
+
+//checkAuditRetention covers expiring the audit log. Entries are fetched oldest first, and deleting can be limited to the ones fetched
+func (state *suiteState) checkAuditRetention(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	Action := interfaces.AuditAction(state.prefix + "-R")
+	for Index := 0; Index < 3; Index++ {
+		if err := DB.AddAuditLog(ctx, interfaces.AuditLog{UserID: state.userID, Action: Action}); err != nil {
+			t.Fatalf("AddAuditLog failed: %v", err)
+		}
+	}
+	for _, Log := range mustGetAuditLogsBefore(t, DB, time.Now().Add(-time.Hour), 1000) {
+		if Log.Action == Action {
+			t.Errorf("GetAuditLogsBefore(an hour ago) returned %+v, logged just now", Log)
+		}
+	}
+
+	Before := time.Now().Add(time.Hour)
+	Oldest := mustGetAuditLogsBefore(t, DB, Before, 2)
+	if len(Oldest) != 2 || Oldest[0].ID >= Oldest[1].ID || Oldest[0].LogTime.IsZero() {
+		t.Fatalf("GetAuditLogsBefore(limit 2) = %+v, want the 2 oldest entries, oldest first", Oldest)
+	}
+	if Deleted, err := DB.DeleteAuditLogsBefore(ctx, Before, Oldest[1].ID); err != nil || Deleted != 2 {
+		t.Errorf("DeleteAuditLogsBefore(up to %d) = %d, %v, want 2", Oldest[1].ID, Deleted, err)
+	}
+	if Remaining := mustGetAuditLogsBefore(t, DB, Before, 1); len(Remaining) != 1 || Remaining[0].ID <= Oldest[1].ID {
+		t.Errorf("GetAuditLogsBefore after deleting = %+v, want entries after %d", Remaining, Oldest[1].ID)
+	}
+	if Deleted, err := DB.DeleteAuditLogsBefore(ctx, time.Now().Add(-time.Hour), 0); err != nil {
+		t.Errorf("DeleteAuditLogsBefore(an hour ago) = %d, %v", Deleted, err)
+	}
+	if _, Count, err := DB.SearchAuditLogs(ctx, interfaces.AuditLogFilter{Action: Action}, 0, 100); err != nil || Count != 3 {
+		t.Errorf("SearchAuditLogs after deleting entries from an hour ago = %d, %v, want all 3 kept", Count, err)
+	}
+	if _, err := DB.DeleteAuditLogsBefore(ctx, Before, 0); err != nil {
+		t.Errorf("DeleteAuditLogsBefore(an hour from now) failed: %v", err)
+	}
+	if _, Count, err := DB.SearchAuditLogs(ctx, interfaces.AuditLogFilter{Action: Action}, 0, 100); err != nil || Count != 0 {
+		t.Errorf("SearchAuditLogs after deleting everything = %d, %v, want none", Count, err)
+	}
+}
+
+//mustGetAuditLogsBefore calls GetAuditLogsBefore and stops the test if it fails
+func mustGetAuditLogsBefore(t *testing.T, DB interfaces.DBInterface, Before time.Time, Limit uint64) []interfaces.AuditLog {
+	t.Helper()
+	Logs, err := DB.GetAuditLogsBefore(t.Context(), Before, Limit)
+	if err != nil {
+		t.Fatalf("GetAuditLogsBefore failed: %v", err)
+	}
+	return Logs
+}
@@ -0,0 +1,210 @@
+package dbconformance
+
+import (
+	"encoding/json"
+	"go-image-board/interfaces"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//checkBackup makes sure an export includes what the suite created, and that a backup is never imported over existing data
+func (state *suiteState) checkBackup(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	TagID := state.newTag(t, "backed_up")
+	ImageID := state.newImage(t, "backed_up")
+	if err := DB.AddTag(ctx, []uint64{TagID}, ImageID, state.userID); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	Data, err := DB.ExportBackup(ctx)
+	if err != nil {
+		t.Fatalf("ExportBackup failed: %v", err)
+	}
+	foundUser := false
+	for _, User := range Data.Users {
+		if User.ID == 0 {
+			t.Error("ExportBackup included the SYSTEM user")
+		}
+		foundUser = foundUser || (User.ID == state.userID && User.Name == state.userName && User.PasswordHash != "")
+	}
+	if foundUser == false {
+		t.Errorf("ExportBackup is missing the suite user %d", state.userID)
+	}
+	foundImage := false
+	for _, Image := range Data.Images {
+		foundImage = foundImage || (Image.ID == ImageID && Image.Location == state.prefix+"backed_up.png")
+	}
+	if foundImage == false {
+		t.Errorf("ExportBackup is missing image %d", ImageID)
+	}
+	foundLink := false
+	for _, Link := range Data.ImageTags {
+		foundLink = foundLink || (Link.ImageID == ImageID && Link.TagID == TagID && Link.LinkerID == state.userID)
+	}
+	if foundLink == false {
+		t.Errorf("ExportBackup is missing the link of tag %d to image %d", TagID, ImageID)
+	}
+
+	if err := DB.ImportBackup(ctx, interfaces.BackupData{}); err == nil {
+		t.Error("ImportBackup accepted a database that already has data")
+	}
+}
+
+//RunBackupRoundTrip fills Source with a small board, exports it, imports it into Fresh and checks Fresh exports the same data.
+//Both databases must be freshly installed and empty, which is why this is not part of RunSuite
+func RunBackupRoundTrip(t *testing.T, Source interfaces.DBInterface, Fresh interfaces.DBInterface) {
+	ctx := t.Context()
+	PrepareLogging()
+	state := &suiteState{DB: Source, prefix: "bk"}
+	state.userName = "bkuser"
+	if err := Source.CreateUser(ctx, state.userName, []byte(suitePassword), "backup@example.com", uint64(interfaces.UploadImage)); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	userID, err := Source.GetUserID(ctx, state.userName)
+	if err != nil {
+		t.Fatalf("GetUserID failed: %v", err)
+	}
+	state.userID = userID
+	if err := Source.SetSecurityQuestions(ctx, state.userName, "one", "two", "three", []byte("a1"), []byte("a2"), []byte("a3"), []byte(suitePassword)); err != nil {
+		t.Fatalf("SetSecurityQuestions failed: %v", err)
+	}
+	TagID := state.newTag(t, "tag")
+	AliasID := state.newTag(t, "alias")
+	if err := Source.UpdateTag(ctx, AliasID, state.prefix+"alias", "an alias", TagID, true, state.userID); err != nil {
+		t.Fatalf("UpdateTag failed: %v", err)
+	}
+	First := state.newImage(t, "first")
+	Second := state.newImage(t, "second")
+	for _, ImageID := range []uint64{First, Second} {
+		if err := Source.AddTag(ctx, []uint64{TagID}, ImageID, state.userID); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if err := Source.SetImagedHash(ctx, First, 0xF000000000000001, 0x0F); err != nil {
+		t.Fatalf("SetImagedHash failed: %v", err)
+	}
+	if err := Source.SetImageMetadata(ctx, First, interfaces.ImageMetadata{Width: 640, Height: 480, FileSize: 1234, MIMEType: "image/png", Codec: "png"}); err != nil {
+		t.Fatalf("SetImageMetadata failed: %v", err)
+	}
+	if err := Source.UpdateUserVoteScore(ctx, state.userID, First, 4); err != nil {
+		t.Fatalf("UpdateUserVoteScore failed: %v", err)
+	}
+	if err := Source.UpdateScoreOnImage(ctx, First); err != nil {
+		t.Fatalf("UpdateScoreOnImage failed: %v", err)
+	}
+	CollectionID, err := Source.NewCollection(ctx, state.prefix+"collection", "backed up", state.userID)
+	if err != nil {
+		t.Fatalf("NewCollection failed: %v", err)
+	}
+	if err := Source.AddCollectionMember(ctx, CollectionID, []uint64{Second, First}, state.userID); err != nil {
+		t.Fatalf("AddCollectionMember failed: %v", err)
+	}
+	Trashed := state.newImage(t, "trashed")
+	if err := Source.TrashImage(ctx, Trashed, state.userID); err != nil {
+		t.Fatalf("TrashImage failed: %v", err)
+	}
+	if err := Source.AddAuditLog(ctx, interfaces.AuditLog{UserID: state.userID, Action: "BACKUP-TEST", TargetType: interfaces.AuditTargetUser, TargetID: state.userID, Details: json.RawMessage(`{"Info":"round trip"}`)}); err != nil {
+		t.Fatalf("AddAuditLog failed: %v", err)
+	}
+	if err := Source.AddImageRevision(ctx, interfaces.ImageRevision{ImageID: First, UserID: state.userID, Field: interfaces.RevisionCollection, CollectionID: CollectionID, OldValue: "", NewValue: "after"}); err != nil {
+		t.Fatalf("AddImageRevision failed: %v", err)
+	}
+
+	if err := Source.SetSavedSearch(ctx, state.userID, "backup", "rating:safe"); err != nil {
+		t.Fatalf("SetSavedSearch failed: %v", err)
+	}
+
+	Exported, err := Source.ExportBackup(ctx)
+	if err != nil {
+		t.Fatalf("ExportBackup failed: %v", err)
+	}
+	if err := Fresh.ImportBackup(ctx, Exported); err != nil {
+		t.Fatalf("ImportBackup failed: %v", err)
+	}
+	Imported, err := Fresh.ExportBackup(ctx)
+	if err != nil {
+		t.Fatalf("ExportBackup after import failed: %v", err)
+	}
+	normalizeBackupTimes(&Exported)
+	normalizeBackupTimes(&Imported)
+	if reflect.DeepEqual(Exported, Imported) == false {
+		t.Errorf("backup changed on import\nexported %+v\nimported %+v", Exported, Imported)
+	}
+
+	//The restored board works like the original
+	if err := Fresh.ValidateUser(ctx, state.userName, []byte(suitePassword)); err != nil {
+		t.Errorf("ValidateUser after import failed: %v", err)
+	}
+	if err := Fresh.ValidateSecurityQuestions(ctx, state.userName, []byte("a1"), []byte("a2"), []byte("a3")); err != nil {
+		t.Errorf("ValidateSecurityQuestions after import failed: %v", err)
+	}
+	if Members, _, err := Fresh.GetCollectionMembers(ctx, CollectionID, 0, 0); err != nil || len(Members) != 2 || Members[0].ID != Second || Members[1].ID != First {
+		t.Errorf("GetCollectionMembers after import = %+v, %v, want %d then %d", Members, err, Second, First)
+	}
+	if Tags, err := Fresh.GetCollectionTags(ctx, CollectionID); err != nil || containsTag(Tags, TagID) == false {
+		t.Errorf("GetCollectionTags after import = %+v, %v, want tag %d", Tags, err, TagID)
+	}
+	if Image, err := Fresh.GetImage(ctx, First); err != nil || Image.ScoreTotal != 4 || Image.ScoreVoters != 1 {
+		t.Errorf("GetImage after import = %+v, %v, want a score of 4 from 1 voter", Image, err)
+	}
+	if Searches, err := Fresh.GetSavedSearches(ctx, state.userID); err != nil || len(Searches) != 1 || Searches[0].Name != "backup" || Searches[0].Query != "rating:safe" {
+		t.Errorf("GetSavedSearches after import = %+v, %v, want the backup search", Searches, err)
+	}
+	if Revisions, Count, err := Fresh.GetImageRevisions(ctx, First, 0, 10); err != nil || Count != 1 || Revisions[0].NewValue != "after" || Revisions[0].CollectionID != CollectionID || Revisions[0].UserName != state.userName {
+		t.Errorf("GetImageRevisions after import = %+v, %d, %v, want the collection revision", Revisions, Count, err)
+	}
+	if Image, err := Fresh.GetImage(ctx, Trashed); err != nil || Image.DeletedTime.IsZero() || Image.DeletedByID != state.userID {
+		t.Errorf("GetImage(trashed) after import = %+v, %v, want it still in the recycle bin", Image, err)
+	}
+	//New rows must not reuse imported IDs
+	if NewID, err := Fresh.NewImage(ctx, "after import", "after_import.png", state.userID, ""); err != nil || NewID <= Trashed {
+		t.Errorf("NewImage after import = %d, %v, want an ID after %d", NewID, err, Trashed)
+	}
+	if NewID, err := Fresh.NewTag(ctx, "after_import", "", state.userID); err != nil || NewID <= AliasID {
+		t.Errorf("NewTag after import = %d, %v, want an ID after %d", NewID, err, AliasID)
+	}
+	if err := Fresh.CreateUser(ctx, "afterimport", []byte(suitePassword), "after@example.com", 0); err != nil {
+		t.Errorf("CreateUser after import failed: %v", err)
+	} else if NewID, err := Fresh.GetUserID(ctx, "afterimport"); err != nil || NewID <= state.userID {
+		t.Errorf("GetUserID after import = %d, %v, want an ID after %d", NewID, err, state.userID)
+	}
+	if NewID, err := Fresh.NewCollection(ctx, "after import", "", state.userID); err != nil || NewID <= CollectionID {
+		t.Errorf("NewCollection after import = %d, %v, want an ID after %d", NewID, err, CollectionID)
+	}
+}
+
+//normalizeBackupTimes truncates every time to the second in UTC, as that is all some backends store
+func normalizeBackupTimes(Data *interfaces.BackupData) {
+	normalize := func(Time *time.Time) {
+		*Time = Time.UTC().Truncate(time.Second)
+	}
+	for Index := range Data.Users {
+		normalize(&Data.Users[Index].CreationTime)
+	}
+	for Index := range Data.Tags {
+		normalize(&Data.Tags[Index].UploadTime)
+	}
+	for Index := range Data.Images {
+		normalize(&Data.Images[Index].UploadTime)
+		normalize(&Data.Images[Index].DeletedTime)
+	}
+	for Index := range Data.ImageTags {
+		normalize(&Data.ImageTags[Index].LinkTime)
+	}
+	for Index := range Data.Votes {
+		normalize(&Data.Votes[Index].CreationTime)
+	}
+	for Index := range Data.Collections {
+		normalize(&Data.Collections[Index].UploadTime)
+	}
+	for Index := range Data.CollectionMembers {
+		normalize(&Data.CollectionMembers[Index].LinkTime)
+	}
+	for Index := range Data.AuditLogs {
+		normalize(&Data.AuditLogs[Index].LogTime)
+	}
+	for Index := range Data.ImageRevisions {
+		normalize(&Data.ImageRevisions[Index].ChangeTime)
+	}
+}
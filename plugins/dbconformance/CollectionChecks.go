@@ -0,0 +1,193 @@
+package dbconformance
+
+import (
+	"testing"
+)
+
+//checkCollections covers collection management, member ordering and collection search
+func (state *suiteState) checkCollections(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	TagID := state.newTag(t, "collected")
+	OtherTagID := state.newTag(t, "collected_other")
+	One := state.newImage(t, "member_one")
+	Two := state.newImage(t, "member_two")
+	Three := state.newImage(t, "member_three")
+	Outside := state.newImage(t, "not_a_member")
+	for _, ImageID := range []uint64{One, Two, Three, Outside} {
+		if err := DB.AddTag(ctx, []uint64{TagID}, ImageID, state.userID); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if err := DB.AddTag(ctx, []uint64{OtherTagID}, Two, state.userID); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	CollectionID, err := DB.NewCollection(ctx, state.prefix+"album", "a collection", state.userID)
+	if err != nil {
+		t.Fatalf("NewCollection failed: %v", err)
+	}
+	if _, err := DB.NewCollection(ctx, state.prefix+"album", "", state.userID); err == nil {
+		t.Error("NewCollection allowed a duplicate name")
+	}
+	if _, err := DB.NewCollection(ctx, "a", "", state.userID); err == nil {
+		t.Error("NewCollection allowed a name shorter than 3 characters")
+	}
+
+	//Members are appended in the order given
+	if err := DB.AddCollectionMember(ctx, CollectionID, []uint64{One, Two}, state.userID); err != nil {
+		t.Fatalf("AddCollectionMember failed: %v", err)
+	}
+	if err := DB.AddCollectionMember(ctx, CollectionID, []uint64{Three}, state.userID); err != nil {
+		t.Fatalf("AddCollectionMember failed: %v", err)
+	}
+	if err := DB.AddCollectionMember(ctx, CollectionID, []uint64{One}, state.userID); err == nil {
+		t.Error("AddCollectionMember added an image that is already a member")
+	}
+	state.expectMembers(t, "after add", CollectionID, One, Two, Three)
+
+	//Moving a member shifts the others around it
+	if err := DB.UpdateCollectionMember(ctx, CollectionID, Three, 0); err != nil {
+		t.Errorf("UpdateCollectionMember failed: %v", err)
+	}
+	state.expectMembers(t, "after moving three first", CollectionID, Three, One, Two)
+	if err := DB.UpdateCollectionMember(ctx, CollectionID, Three, 100); err != nil {
+		t.Errorf("UpdateCollectionMember failed: %v", err)
+	}
+	state.expectMembers(t, "after moving three past the end", CollectionID, One, Two, Three)
+	if err := DB.UpdateCollectionMember(ctx, CollectionID, One, 1); err != nil {
+		t.Errorf("UpdateCollectionMember failed: %v", err)
+	}
+	state.expectMembers(t, "after moving one to the middle", CollectionID, Two, One, Three)
+
+	//Paged members keep the total count
+	if Members, Count, err := DB.GetCollectionMembers(ctx, CollectionID, 1, 1); err != nil || Count != 3 || len(Members) != 1 || Members[0].ID != One {
+		t.Errorf("GetCollectionMembers(1, 1) = %+v, %d, %v, want %d of 3", Members, Count, err, One)
+	}
+
+	//An image knows its neighbours in the collection
+	Collections, err := DB.GetCollectionsWithImage(ctx, One)
+	if err != nil || len(Collections) != 1 {
+		t.Fatalf("GetCollectionsWithImage = %+v, %v, want one collection", Collections, err)
+	}
+	if Collection := Collections[0]; Collection.ID != CollectionID || Collection.OrderInCollection != 1 || Collection.PreviousMemberID != Two || Collection.NextMemberID != Three || Collection.Members != 3 {
+		t.Errorf("GetCollectionsWithImage = %+v, want order 1 between %d and %d", Collection, Two, Three)
+	}
+	if Collections, err := DB.GetCollectionsWithImage(ctx, Outside); err != nil || len(Collections) != 0 {
+		t.Errorf("GetCollectionsWithImage(outside) = %+v, %v, want none", Collections, err)
+	}
+
+	//Collections carry the tags of their members
+	CollectionTags, err := DB.GetCollectionTags(ctx, CollectionID)
+	if err != nil || len(CollectionTags) != 2 || containsTag(CollectionTags, TagID) == false || containsTag(CollectionTags, OtherTagID) == false {
+		t.Errorf("GetCollectionTags = %+v, %v, want collected and collected_other", CollectionTags, err)
+	}
+
+	//Lookups
+	Collection, err := DB.GetCollection(ctx, CollectionID)
+	if err != nil || Collection.Name != state.prefix+"album" || Collection.Description != "a collection" || Collection.UploaderID != state.userID || Collection.Members != 3 {
+		t.Errorf("GetCollection = %+v, %v", Collection, err)
+	}
+	if Collection, err := DB.GetCollectionByName(ctx, state.prefix+"album"); err != nil || Collection.ID != CollectionID {
+		t.Errorf("GetCollectionByName = %+v, %v, want ID %d", Collection, err, CollectionID)
+	}
+	if err := DB.UpdateCollection(ctx, CollectionID, state.prefix+"renamed", "renamed collection"); err != nil {
+		t.Errorf("UpdateCollection failed: %v", err)
+	}
+	if Collection, err := DB.GetCollectionByName(ctx, state.prefix+"renamed"); err != nil || Collection.ID != CollectionID || Collection.Description != "renamed collection" {
+		t.Errorf("GetCollectionByName after rename = %+v, %v", Collection, err)
+	}
+
+	//Search
+	Found, Count, err := DB.SearchCollections(ctx, state.queryTags(t, state.prefix+"collected_other", true), 0, 10)
+	if err != nil || Count != 1 || len(Found) != 1 || Found[0].ID != CollectionID || Found[0].Members != 3 {
+		t.Errorf("SearchCollections(collected_other) = %+v, %d, %v, want only %d", Found, Count, err, CollectionID)
+	} else if Image, err := DB.GetImage(ctx, Two); err != nil || Found[0].Location != Image.Location {
+		t.Errorf("SearchCollections preview = %q, want the first member %q", Found[0].Location, Image.Location)
+	}
+	if Found, _, err := DB.SearchCollections(ctx, state.queryTags(t, state.prefix+"collected -"+state.prefix+"collected_other", true), 0, 10); err != nil || len(Found) != 0 {
+		t.Errorf("SearchCollections with an excluded member tag = %+v, %v, want none", Found, err)
+	}
+	if Found, _, err := DB.SearchCollections(ctx, state.queryTags(t, "name:"+state.prefix+"renamed", true), 0, 10); err != nil || len(Found) != 1 || Found[0].ID != CollectionID {
+		t.Errorf("SearchCollections(name) = %+v, %v, want only %d", Found, err, CollectionID)
+	}
+	for _, Query := range []string{state.prefix + "collected_other order:uploaded_asc", state.prefix + "collected order:tagcount", "(" + state.prefix + "collected OR " + state.prefix + "missing) order:random"} {
+		if Found, _, err := DB.SearchCollections(ctx, state.queryTags(t, Query, true), 0, 10); err != nil || len(Found) != 1 || Found[0].ID != CollectionID {
+			t.Errorf("SearchCollections(%s) = %+v, %v, want only %d", Query, Found, err, CollectionID)
+		}
+	}
+	if Found, _, err := DB.SearchCollections(ctx, state.queryTags(t, "("+state.prefix+"missing OR name:"+state.prefix+"renamed)", true), 0, 10); err != nil || len(Found) != 1 || Found[0].ID != CollectionID {
+		t.Errorf("SearchCollections(missing OR name) = %+v, %v, want only %d", Found, err, CollectionID)
+	}
+	if Found, _, err := DB.SearchCollections(ctx, state.queryTags(t, state.prefix+"collected -("+state.prefix+"collected_other OR "+state.prefix+"missing)", true), 0, 10); err != nil || len(Found) != 0 {
+		t.Errorf("SearchCollections with an excluded group = %+v, %v, want none", Found, err)
+	}
+	if Listed, Count, err := DB.GetCollections(ctx, 0, 1000); err != nil || Count < 1 || uint64(len(Listed)) > Count {
+		t.Errorf("GetCollections = %d collections, count %d, %v", len(Listed), Count, err)
+	}
+
+	//InCollection meta tag on image search
+	expectIDs(t, "incollection", state.searchIDs(t, state.prefix+"collected incollection:y"), Three, Two, One)
+	expectIDs(t, "-incollection", state.searchIDs(t, state.prefix+"collected incollection:n"), Outside)
+
+	//Removing a member closes the gap it left
+	if err := DB.RemoveCollectionMember(ctx, CollectionID, Two); err != nil {
+		t.Errorf("RemoveCollectionMember failed: %v", err)
+	}
+	state.expectMembers(t, "after removing two", CollectionID, One, Three)
+	if err := DB.RemoveCollectionMember(ctx, CollectionID, Outside); err == nil {
+		t.Error("RemoveCollectionMember removed an image that is not a member")
+	}
+	//Deleting an image removes it from its collections
+	if err := DB.DeleteImage(ctx, Three); err != nil {
+		t.Errorf("DeleteImage failed: %v", err)
+	}
+	state.expectMembers(t, "after deleting three", CollectionID, One)
+	//Removing the last member removes the collection
+	if err := DB.RemoveCollectionMember(ctx, CollectionID, One); err != nil {
+		t.Errorf("RemoveCollectionMember failed: %v", err)
+	}
+	if _, err := DB.GetCollection(ctx, CollectionID); err == nil {
+		t.Error("GetCollection found a collection after its last member was removed")
+	}
+
+	//Deleting a collection leaves its images alone
+	OtherID, err := DB.NewCollection(ctx, state.prefix+"deleted", "", state.userID)
+	if err != nil {
+		t.Fatalf("NewCollection failed: %v", err)
+	}
+	if err := DB.AddCollectionMember(ctx, OtherID, []uint64{Outside}, state.userID); err != nil {
+		t.Fatalf("AddCollectionMember failed: %v", err)
+	}
+	if err := DB.DeleteCollection(ctx, OtherID); err != nil {
+		t.Errorf("DeleteCollection failed: %v", err)
+	}
+	if _, err := DB.GetCollection(ctx, OtherID); err == nil {
+		t.Error("GetCollection found a deleted collection")
+	}
+	if _, err := DB.GetImage(ctx, Outside); err != nil {
+		t.Errorf("GetImage failed for an image of a deleted collection: %v", err)
+	}
+}
+
+//expectMembers fails the test when a collection's members are not exactly Want, in order, with matching OrderInCollection
+func (state *suiteState) expectMembers(t *testing.T, What string, CollectionID uint64, Want ...uint64) {
+	t.Helper()
+	ctx := t.Context()
+	Members, Count, err := state.DB.GetCollectionMembers(ctx, CollectionID, 0, 0)
+	if err != nil {
+		t.Errorf("%s: GetCollectionMembers failed: %v", What, err)
+		return
+	}
+	var Got []uint64
+	for Index, Member := range Members {
+		Got = append(Got, Member.ID)
+		if Member.OrderInCollection != uint64(Index) {
+			t.Errorf("%s: member %d has order %d, want %d", What, Member.ID, Member.OrderInCollection, Index)
+		}
+	}
+	if Count != uint64(len(Want)) {
+		t.Errorf("%s: GetCollectionMembers count %d, want %d", What, Count, len(Want))
+	}
+	expectIDs(t, What, Got, Want...)
+}
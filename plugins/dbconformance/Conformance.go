@@ -0,0 +1,150 @@
+//Package dbconformance is a set of checks every DBInterface plugin is expected to pass.
+//Plugins call RunSuite from their own tests, so a backend only needs a test file that sets up a connection.
+package dbconformance
+
+import (
+	"context"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"go-image-board/plugins"
+	"strconv"
+	"testing"
+	"time"
+)
+
+//suiteState holds what the checks share. Every name is derived from prefix so the suite can run against a database that already has data in it
+type suiteState struct {
+	DB       interfaces.DBInterface
+	prefix   string
+	userName string
+	userID   uint64
+}
+
+//suitePassword is used for every account the suite creates
+const suitePassword = "Conformance-Pass1"
+
+//RunSuite runs all conformance checks against DB. InitDatabase must already have been called on DB.
+//The suite only reads back what it created itself, so it is safe to point it at a database that is in use, but it does leave its rows behind.
+func RunSuite(t *testing.T, DB interfaces.DBInterface) {
+	ctx := t.Context()
+	PrepareLogging()
+	state := &suiteState{DB: DB, prefix: "ct" + strconv.FormatInt(time.Now().UnixNano(), 36)}
+	state.userName = state.prefix + "u"
+	if err := DB.CreateUser(ctx, state.userName, []byte(suitePassword), state.userName+"@example.com", uint64(interfaces.UploadImage)); err != nil {
+		t.Fatalf("CreateUser failed for suite user: %v", err)
+	}
+	userID, err := DB.GetUserID(ctx, state.userName)
+	if err != nil {
+		t.Fatalf("GetUserID failed for suite user: %v", err)
+	}
+	state.userID = userID
+
+	t.Run("Users", state.checkUsers)
+	t.Run("Tokens", state.checkTokens)
+	t.Run("Tags", state.checkTags)
+	t.Run("Images", state.checkImages)
+	t.Run("Search", state.checkSearch)
+	t.Run("Collections", state.checkCollections)
+	t.Run("Trash", state.checkTrash)
+	t.Run("Revisions", state.checkRevisions)
+	t.Run("AuditLogs", state.checkAuditLogs)
+	t.Run("AuditRetention", state.checkAuditRetention)
+	t.Run("Jobs", state.checkJobs)
+	t.Run("Votes", state.checkVotes)
+	t.Run("Transactions", state.checkTransactions)
+	t.Run("Backup", state.checkBackup)
+	t.Run("Cancellation", state.checkCancellation)
+}
+
+//checkCancellation makes sure searches give up on a context that is already cancelled instead of returning results
+func (state *suiteState) checkCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, _, err := state.DB.SearchImages(ctx, nil, 0, 10); err == nil {
+		t.Error("SearchImages succeeded with a cancelled context")
+	}
+	if _, _, err := state.DB.SearchCollections(ctx, nil, 0, 10); err == nil {
+		t.Error("SearchCollections succeeded with a cancelled context")
+	}
+}
+
+//PrepareLogging sets up quiet console logging unless a test already set its own. Plugins log from InitDatabase, so call this before it
+func PrepareLogging() {
+	if logging.LogInterface == nil {
+		logging.LogInterface = &plugins.STDLog{}
+		logging.LogInterface.Init(logging.LogLevelCritical, "", "")
+	}
+}
+
+//newImage adds an image owned by the suite user and fails the test if that does not work
+func (state *suiteState) newImage(t *testing.T, Name string) uint64 {
+	t.Helper()
+	ctx := t.Context()
+	ImageID, err := state.DB.NewImage(ctx, state.prefix+Name, state.prefix+Name+".png", state.userID, "")
+	if err != nil {
+		t.Fatalf("NewImage(%s) failed: %v", Name, err)
+	}
+	return ImageID
+}
+
+//newTag adds a tag owned by the suite user and fails the test if that does not work
+func (state *suiteState) newTag(t *testing.T, Name string) uint64 {
+	t.Helper()
+	ctx := t.Context()
+	TagID, err := state.DB.NewTag(ctx, state.prefix+Name, "conformance tag", state.userID)
+	if err != nil {
+		t.Fatalf("NewTag(%s) failed: %v", Name, err)
+	}
+	return TagID
+}
+
+//queryTags runs GetQueryTags the way the routers do, deduplicating afterwards
+func (state *suiteState) queryTags(t *testing.T, Query string, CollectionContext bool) []interfaces.TagInformation {
+	t.Helper()
+	ctx := t.Context()
+	Tags, err := state.DB.GetQueryTags(ctx, Query, CollectionContext)
+	if err != nil {
+		t.Fatalf("GetQueryTags(%q) failed: %v", Query, err)
+	}
+	return interfaces.RemoveDuplicateTags(Tags)
+}
+
+//searchIDs runs a full image search for Query and returns the IDs in result order
+func (state *suiteState) searchIDs(t *testing.T, Query string) []uint64 {
+	t.Helper()
+	ctx := t.Context()
+	Images, _, err := state.DB.SearchImages(ctx, state.queryTags(t, Query, false), 0, 1000)
+	if err != nil {
+		t.Fatalf("SearchImages(%q) failed: %v", Query, err)
+	}
+	var ToReturn []uint64
+	for _, Image := range Images {
+		ToReturn = append(ToReturn, Image.ID)
+	}
+	return ToReturn
+}
+
+//expectIDs fails the test when Got is not exactly Want, in order
+func expectIDs(t *testing.T, What string, Got []uint64, Want ...uint64) {
+	t.Helper()
+	if len(Got) != len(Want) {
+		t.Errorf("%s: got %v, want %v", What, Got, Want)
+		return
+	}
+	for Index := range Got {
+		if Got[Index] != Want[Index] {
+			t.Errorf("%s: got %v, want %v", What, Got, Want)
+			return
+		}
+	}
+}
+
+//containsTag reports whether Tags has a tag with the given ID
+func containsTag(Tags []interfaces.TagInformation, TagID uint64) bool {
+	for _, Tag := range Tags {
+		if Tag.ID == TagID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package dbconformance
+
+import (
+	"go-image-board/interfaces"
+	"testing"
+)
+
+//checkImages covers adding, reading, updating and deleting images
+func (state *suiteState) checkImages(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	ImageID, err := DB.NewImage(ctx, state.prefix+"photo", state.prefix+"photo.png", state.userID, "https://example.com/photo")
+	if err != nil {
+		t.Fatalf("NewImage failed: %v", err)
+	}
+	if _, err := DB.NewImage(ctx, state.prefix+"again", state.prefix+"photo.png", state.userID, ""); err == nil {
+		t.Error("NewImage allowed a duplicate location")
+	}
+
+	Image, err := DB.GetImage(ctx, ImageID)
+	if err != nil {
+		t.Fatalf("GetImage failed: %v", err)
+	}
+	if Image.Name != state.prefix+"photo" || Image.Location != state.prefix+"photo.png" || Image.UploaderID != state.userID || Image.UploaderName != state.userName || Image.Source != "https://example.com/photo" {
+		t.Errorf("GetImage returned %+v", Image)
+	}
+	if Image, err := DB.GetImageByFileName(ctx, state.prefix+"photo.png"); err != nil || Image.ID != ImageID {
+		t.Errorf("GetImageByFileName = %+v, %v, want ID %d", Image, err, ImageID)
+	}
+	if _, err := DB.GetImage(ctx, ImageID+100000); err == nil {
+		t.Error("GetImage found an image that does not exist")
+	}
+
+	//Updates only touch the fields provided
+	if err := DB.UpdateImage(ctx, ImageID, state.prefix+"renamed", "a description", nil, nil, nil, nil); err != nil {
+		t.Errorf("UpdateImage failed: %v", err)
+	}
+	if err := DB.SetImageRating(ctx, ImageID, "questionable"); err != nil {
+		t.Errorf("SetImageRating failed: %v", err)
+	}
+	if err := DB.SetImageSource(ctx, ImageID, "https://example.com/other"); err != nil {
+		t.Errorf("SetImageSource failed: %v", err)
+	}
+	Image, err = DB.GetImage(ctx, ImageID)
+	if err != nil || Image.Name != state.prefix+"renamed" || Image.Description != "a description" || Image.Rating != "questionable" || Image.Source != "https://example.com/other" || Image.Location != state.prefix+"photo.png" {
+		t.Errorf("GetImage after update = %+v, %v", Image, err)
+	}
+	if err := DB.UpdateImage(ctx, ImageID, nil, nil, "not a uint64", nil, nil, nil); err == nil {
+		t.Error("UpdateImage accepted an OwnerID that is not a uint64")
+	}
+	if err := DB.UpdateImage(ctx, ImageID+100000, "missing", nil, nil, nil, nil, nil); err == nil {
+		t.Error("UpdateImage accepted an image that does not exist")
+	}
+
+	//dHashes
+	if _, _, err := DB.GetImagedHash(ctx, ImageID); err == nil {
+		t.Error("GetImagedHash returned a hash for an image that has none")
+	}
+	if err := DB.SetImagedHash(ctx, ImageID, 0xF0F0, 0x0F0F); err != nil {
+		t.Errorf("SetImagedHash failed: %v", err)
+	}
+	if err := DB.SetImagedHash(ctx, ImageID, 0xFF00, 0x00FF); err != nil {
+		t.Errorf("SetImagedHash failed to replace a hash: %v", err)
+	}
+	if hHash, vHash, err := DB.GetImagedHash(ctx, ImageID); err != nil || hHash != 0xFF00 || vHash != 0x00FF {
+		t.Errorf("GetImagedHash = %x, %x, %v, want ff00, ff", hHash, vHash, err)
+	}
+
+	//Metadata is empty until it is read from the file
+	if Image.ImageMetadata != (interfaces.ImageMetadata{}) {
+		t.Errorf("GetImage returned metadata %+v for a new image", Image.ImageMetadata)
+	}
+	Metadata := interfaces.ImageMetadata{Width: 1920, Height: 1080, FileSize: 5 << 30, MIMEType: "video/mp4", Duration: 12.5, Codec: "h264"}
+	if err := DB.SetImageMetadata(ctx, ImageID, Metadata); err != nil {
+		t.Errorf("SetImageMetadata failed: %v", err)
+	}
+	if Image, err := DB.GetImage(ctx, ImageID); err != nil || Image.ImageMetadata != Metadata {
+		t.Errorf("GetImage metadata = %+v, %v, want %+v", Image.ImageMetadata, err, Metadata)
+	}
+	if Image, err := DB.GetImageByFileName(ctx, state.prefix+"photo.png"); err != nil || Image.ImageMetadata != Metadata {
+		t.Errorf("GetImageByFileName metadata = %+v, %v, want %+v", Image.ImageMetadata, err, Metadata)
+	}
+
+	//Deleting an image also removes its tags
+	TagID := state.newTag(t, "deleted_image_tag")
+	if err := DB.AddTag(ctx, []uint64{TagID}, ImageID, state.userID); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := DB.DeleteImage(ctx, ImageID); err != nil {
+		t.Errorf("DeleteImage failed: %v", err)
+	}
+	if _, err := DB.GetImage(ctx, ImageID); err == nil {
+		t.Error("GetImage found a deleted image")
+	}
+	if Tag, err := DB.GetTag(ctx, TagID, true); err != nil || Tag.UseCount != 0 {
+		t.Errorf("GetTag after DeleteImage = %+v, %v, want UseCount 0", Tag, err)
+	}
+}
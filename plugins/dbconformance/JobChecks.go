@@ -0,0 +1,143 @@
+package dbconformance
+
+import (
+	"database/sql"
+	"go-image-board/interfaces"
+	"testing"
+	"time"
+)
+
+//checkJobs covers the background job queue, jobs are claimed once they are due, retried or failed, and removed when finished.
+//Jobs are queued far in the past so they are claimed ahead of any real jobs in the database
+func (state *suiteState) checkJobs(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	ImageID := state.newImage(t, "job_image")
+	Base := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := DB.EnqueueJob(ctx, interfaces.JobThumbnail, ImageID, Base); err != nil {
+		t.Fatalf("EnqueueJob failed: %v", err)
+	}
+	if err := DB.EnqueueJob(ctx, interfaces.JobdHash, ImageID, Base.Add(time.Hour)); err != nil {
+		t.Fatalf("EnqueueJob failed: %v", err)
+	}
+
+	//Only the due job is claimed, and only once
+	Job, err := DB.ClaimJob(ctx, Base)
+	if err != nil || Job.Type != interfaces.JobThumbnail || Job.ImageID != ImageID || Job.Status != interfaces.JobRunning || Job.Attempts != 1 {
+		t.Fatalf("ClaimJob = %+v, %v, want the running thumbnail job on its first attempt", Job, err)
+	}
+	if Claimed, err := DB.ClaimJob(ctx, Base); err != sql.ErrNoRows {
+		t.Errorf("ClaimJob with nothing due = %+v, %v, want sql.ErrNoRows", Claimed, err)
+	}
+	state.expectJob(t, "claimed", Job.ID, interfaces.JobRunning, 1, "")
+
+	//A failed attempt is retried once RetryAt passes
+	if err := DB.FailJob(ctx, Job.ID, "first failure", Base.Add(time.Minute)); err != nil {
+		t.Fatalf("FailJob failed: %v", err)
+	}
+	Retry := state.expectJob(t, "retrying", Job.ID, interfaces.JobPending, 1, "first failure")
+	if Retry.RunAfter.Equal(Base.Add(time.Minute)) == false {
+		t.Errorf("RunAfter of retrying job = %v, want %v", Retry.RunAfter, Base.Add(time.Minute))
+	}
+	if Claimed, err := DB.ClaimJob(ctx, Base); err != sql.ErrNoRows {
+		t.Errorf("ClaimJob before retry is due = %+v, %v, want sql.ErrNoRows", Claimed, err)
+	}
+	if Job, err = DB.ClaimJob(ctx, Base.Add(time.Minute)); err != nil || Job.Attempts != 2 {
+		t.Fatalf("ClaimJob of retry = %+v, %v, want the second attempt", Job, err)
+	}
+
+	//Giving up marks it failed until it is retried by hand
+	if err := DB.FailJob(ctx, Job.ID, "second failure", time.Time{}); err != nil {
+		t.Fatalf("FailJob failed: %v", err)
+	}
+	state.expectJob(t, "failed", Job.ID, interfaces.JobFailed, 2, "second failure")
+	if Counts, err := DB.GetJobCounts(ctx); err != nil || Counts[interfaces.JobFailed] < 1 || Counts[interfaces.JobPending] < 1 {
+		t.Errorf("GetJobCounts = %v, %v, want at least one failed and one pending job", Counts, err)
+	}
+	if Retried, err := DB.RetryFailedJobs(ctx, Job.ID, Base); err != nil || Retried != 1 {
+		t.Errorf("RetryFailedJobs = %d, %v, want 1", Retried, err)
+	}
+	state.expectJob(t, "retried by hand", Job.ID, interfaces.JobPending, 0, "second failure")
+
+	//Running jobs left by a stopped worker can be requeued once their lease runs out, keeping their attempts
+	if Job, err = DB.ClaimJob(ctx, Base); err != nil || Job.Attempts != 1 {
+		t.Fatalf("ClaimJob after retry = %+v, %v, want a first attempt", Job, err)
+	}
+	if _, err := DB.RequeueRunningJobs(ctx, Base, time.Hour); err != nil {
+		t.Errorf("RequeueRunningJobs within lease failed: %v", err)
+	}
+	state.expectJob(t, "running within its lease", Job.ID, interfaces.JobRunning, 1, "second failure")
+	if Requeued, err := DB.RequeueRunningJobs(ctx, Base, 0); err != nil || Requeued < 1 {
+		t.Errorf("RequeueRunningJobs = %d, %v, want at least 1", Requeued, err)
+	}
+	state.expectJob(t, "requeued", Job.ID, interfaces.JobPending, 1, "second failure")
+
+	//Finished jobs leave the queue, queuing one again starts it over
+	if err := DB.FinishJob(ctx, Job.ID); err != nil {
+		t.Fatalf("FinishJob failed: %v", err)
+	}
+	state.expectJob(t, "finished", Job.ID, "", 0, "")
+	if err := DB.EnqueueJob(ctx, interfaces.JobdHash, ImageID, Base); err != nil {
+		t.Fatalf("EnqueueJob failed: %v", err)
+	}
+	if Job, err = DB.ClaimJob(ctx, Base); err != nil || Job.Type != interfaces.JobdHash || Job.Attempts != 1 {
+		t.Fatalf("ClaimJob of queued again dHash job = %+v, %v, want its first attempt", Job, err)
+	}
+
+	//Queuing a running job again runs it once more after it finishes
+	if err := DB.EnqueueJob(ctx, interfaces.JobdHash, ImageID, Base); err != nil {
+		t.Fatalf("EnqueueJob failed: %v", err)
+	}
+	if Rerun := state.expectJob(t, "queued while running", Job.ID, interfaces.JobRunning, 1, ""); Rerun.Rerun == false {
+		t.Errorf("job queued while running = %+v, want it marked to rerun", Rerun)
+	}
+	if err := DB.FinishJob(ctx, Job.ID); err != nil {
+		t.Fatalf("FinishJob failed: %v", err)
+	}
+	if Rerun := state.expectJob(t, "finished with a rerun", Job.ID, interfaces.JobPending, 0, ""); Rerun.Rerun {
+		t.Errorf("job finished with a rerun = %+v, want the rerun cleared", Rerun)
+	}
+
+	//A rerun is not given up on when the running attempt fails
+	if Job, err = DB.ClaimJob(ctx, Base); err != nil || Job.Type != interfaces.JobdHash || Job.Attempts != 1 {
+		t.Fatalf("ClaimJob of rerun dHash job = %+v, %v, want its first attempt", Job, err)
+	}
+	if err := DB.EnqueueJob(ctx, interfaces.JobdHash, ImageID, Base); err != nil {
+		t.Fatalf("EnqueueJob failed: %v", err)
+	}
+	if err := DB.FailJob(ctx, Job.ID, "rerun failure", time.Time{}); err != nil {
+		t.Fatalf("FailJob failed: %v", err)
+	}
+	state.expectJob(t, "failed with a rerun", Job.ID, interfaces.JobPending, 0, "rerun failure")
+	if Job, err = DB.ClaimJob(ctx, Base); err != nil || Job.Type != interfaces.JobdHash || Job.Attempts != 1 {
+		t.Fatalf("ClaimJob of rerun dHash job = %+v, %v, want its first attempt", Job, err)
+	}
+	if err := DB.FinishJob(ctx, Job.ID); err != nil {
+		t.Fatalf("FinishJob failed: %v", err)
+	}
+	state.expectJob(t, "finished", Job.ID, "", 0, "")
+}
+
+//expectJob checks a job's status, attempts and last error through GetJobs and returns it. An empty Status expects the job to be gone
+func (state *suiteState) expectJob(t *testing.T, What string, JobID uint64, Status interfaces.JobStatus, Attempts uint64, LastError string) interfaces.Job {
+	t.Helper()
+	var Found interfaces.Job
+	for _, Listed := range []interfaces.JobStatus{interfaces.JobPending, interfaces.JobRunning, interfaces.JobFailed} {
+		Jobs, Count, err := state.DB.GetJobs(t.Context(), Listed, 0, 10000)
+		if err != nil || Count < uint64(len(Jobs)) {
+			t.Fatalf("GetJobs(%s) = %d jobs of %d, %v", Listed, len(Jobs), Count, err)
+		}
+		for _, Job := range Jobs {
+			if Job.ID == JobID {
+				if Found.ID != 0 || Job.Status != Listed {
+					t.Errorf("job %s listed as %s with status %s", What, Listed, Job.Status)
+				}
+				Found = Job
+			}
+		}
+	}
+	if Found.Status != Status || Found.Attempts != Attempts || Found.LastError != LastError {
+		t.Errorf("job %s = %+v, want status %q, %d attempts and error %q", What, Found, Status, Attempts, LastError)
+	}
+	return Found
+}
@@ -0,0 +1,102 @@
+package dbconformance
+
+import (
+	"go-image-board/interfaces"
+	"testing"
+	"time"
+)
+
+//checkRevisions covers the image history, revisions are listed newest first and removed with their image
+func (state *suiteState) checkRevisions(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	ImageID := state.newImage(t, "revision_image")
+	OtherID := state.newImage(t, "revision_other")
+	TagID := state.newTag(t, "revision_tag")
+
+	Recorded := []interfaces.ImageRevision{
+		{ImageID: ImageID, UserID: state.userID, Field: interfaces.RevisionName, OldValue: "old name", NewValue: "new name"},
+		{ImageID: OtherID, UserID: state.userID, Field: interfaces.RevisionRating, OldValue: "unrated", NewValue: "safe"},
+		{ImageID: ImageID, UserID: state.userID, Field: interfaces.RevisionTag, TagID: TagID, OldValue: "", NewValue: state.prefix + "revision_tag"},
+		{ImageID: ImageID, UserID: state.userID, Field: interfaces.RevisionSource, OldValue: "", NewValue: "http://example.com"},
+	}
+	for _, Revision := range Recorded {
+		if err := DB.AddImageRevision(ctx, Revision); err != nil {
+			t.Fatalf("AddImageRevision failed: %v", err)
+		}
+	}
+
+	Revisions, Count, err := DB.GetImageRevisions(ctx, ImageID, 0, 100)
+	if err != nil || Count != 3 || len(Revisions) != 3 {
+		t.Fatalf("GetImageRevisions = %+v, %d, %v, want 3 revisions", Revisions, Count, err)
+	}
+	//Newest first
+	for Index, Want := range []interfaces.ImageRevision{Recorded[3], Recorded[2], Recorded[0]} {
+		Got := Revisions[Index]
+		if Got.ImageID != Want.ImageID || Got.Field != Want.Field || Got.TagID != Want.TagID || Got.OldValue != Want.OldValue || Got.NewValue != Want.NewValue {
+			t.Errorf("GetImageRevisions[%d] = %+v, want %+v", Index, Got, Want)
+		}
+		if Got.ID == 0 || Got.UserName != state.userName || Got.ChangeTime.IsZero() {
+			t.Errorf("GetImageRevisions[%d] = %+v, want ID, UserName %s and ChangeTime filled in", Index, Got, state.userName)
+		}
+	}
+	if Revisions[0].ID <= Revisions[1].ID || Revisions[1].ID <= Revisions[2].ID {
+		t.Errorf("GetImageRevisions IDs = %d, %d, %d, want newest first", Revisions[0].ID, Revisions[1].ID, Revisions[2].ID)
+	}
+	if Page, Count, err := DB.GetImageRevisions(ctx, ImageID, 1, 1); err != nil || Count != 3 || len(Page) != 1 || Page[0].ID != Revisions[1].ID {
+		t.Errorf("GetImageRevisions(1, 1) = %+v, %d, %v, want only %d", Page, Count, err, Revisions[1].ID)
+	}
+
+	if Revision, err := DB.GetImageRevision(ctx, Revisions[1].ID); err != nil || Revision != Revisions[1] {
+		t.Errorf("GetImageRevision = %+v, %v, want %+v", Revision, err, Revisions[1])
+	}
+	if _, err := DB.GetImageRevision(ctx, Revisions[0].ID+100000); err == nil {
+		t.Error("GetImageRevision found a revision that does not exist")
+	}
+
+	//Oldest first, from the given revision on, and only for that image
+	Since, err := DB.GetImageRevisionsSince(ctx, ImageID, Revisions[1].ID)
+	if err != nil || len(Since) != 2 || Since[0].ID != Revisions[1].ID || Since[1].ID != Revisions[0].ID {
+		t.Errorf("GetImageRevisionsSince = %+v, %v, want %d then %d", Since, err, Revisions[1].ID, Revisions[0].ID)
+	}
+	if Since, err := DB.GetImageRevisionsSince(ctx, OtherID, Revisions[2].ID); err != nil || len(Since) != 1 || Since[0].Field != interfaces.RevisionRating {
+		t.Errorf("GetImageRevisionsSince(other image) = %+v, %v, want only its rating change", Since, err)
+	}
+
+	//A user's changes across images, oldest first, within a time window
+	Collected := interfaces.ImageRevision{ImageID: OtherID, UserID: state.userID, Field: interfaces.RevisionCollection, CollectionID: 7, OldValue: "", NewValue: "a collection"}
+	if err := DB.AddImageRevision(ctx, Collected); err != nil {
+		t.Fatalf("AddImageRevision failed: %v", err)
+	}
+	Now := time.Now()
+	UserRevisions, err := DB.GetUserImageRevisions(ctx, state.userID, Now.Add(-time.Hour), Now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetUserImageRevisions failed: %v", err)
+	}
+	var Mine []interfaces.ImageRevision
+	for _, Revision := range UserRevisions {
+		if Revision.ImageID == ImageID || Revision.ImageID == OtherID {
+			Mine = append(Mine, Revision)
+		}
+	}
+	if len(Mine) != 5 || Mine[0].Field != interfaces.RevisionName || Mine[1].ImageID != OtherID || Mine[4].CollectionID != 7 || Mine[4].NewValue != "a collection" || Mine[4].UserName != state.userName {
+		t.Errorf("GetUserImageRevisions = %+v, want the 5 recorded revisions oldest first", Mine)
+	}
+	if UserRevisions, err := DB.GetUserImageRevisions(ctx, state.userID, Now.Add(-2*time.Hour), Now.Add(-time.Hour)); err != nil || len(UserRevisions) != 0 {
+		t.Errorf("GetUserImageRevisions(an hour ago) = %+v, %v, want none", UserRevisions, err)
+	}
+	if UserRevisions, err := DB.GetUserImageRevisions(ctx, state.userID+100000, Now.Add(-time.Hour), Now.Add(time.Hour)); err != nil || len(UserRevisions) != 0 {
+		t.Errorf("GetUserImageRevisions(other user) = %+v, %v, want none", UserRevisions, err)
+	}
+
+	//History goes with the image
+	if err := DB.DeleteImage(ctx, ImageID); err != nil {
+		t.Fatalf("DeleteImage failed: %v", err)
+	}
+	if Revisions, Count, err := DB.GetImageRevisions(ctx, ImageID, 0, 100); err != nil || Count != 0 || len(Revisions) != 0 {
+		t.Errorf("GetImageRevisions after DeleteImage = %+v, %d, %v, want none", Revisions, Count, err)
+	}
+	if _, Count, err := DB.GetImageRevisions(ctx, OtherID, 0, 100); err != nil || Count != 2 {
+		t.Errorf("GetImageRevisions(other image) after DeleteImage = %d, %v, want 2", Count, err)
+	}
+}
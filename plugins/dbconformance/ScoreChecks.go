@@ -0,0 +1,49 @@
+package dbconformance
+
+import (
+	"testing"
+)
+
+//checkVotes covers user votes, the cached image scores and the score meta tags
+func (state *suiteState) checkVotes(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	TagID := state.newTag(t, "voted")
+	Voted := state.newImage(t, "voted")
+	Unvoted := state.newImage(t, "unvoted")
+	for _, ImageID := range []uint64{Voted, Unvoted} {
+		if err := DB.AddTag(ctx, []uint64{TagID}, ImageID, state.userID); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+
+	if Score, err := DB.GetUserVoteScore(ctx, state.userID, Voted); err != nil || Score != 0 {
+		t.Errorf("GetUserVoteScore before voting = %d, %v, want 0", Score, err)
+	}
+	if err := DB.UpdateUserVoteScore(ctx, state.userID, Voted, 5); err != nil {
+		t.Fatalf("UpdateUserVoteScore failed: %v", err)
+	}
+	//A second vote by the same user replaces the first
+	if err := DB.UpdateUserVoteScore(ctx, state.userID, Voted, 3); err != nil {
+		t.Fatalf("UpdateUserVoteScore failed: %v", err)
+	}
+	if Score, err := DB.GetUserVoteScore(ctx, state.userID, Voted); err != nil || Score != 3 {
+		t.Errorf("GetUserVoteScore = %d, %v, want 3", Score, err)
+	}
+
+	//Some plugins update the cached scores in the background, so update them directly before reading them back
+	if err := DB.UpdateScoreOnImage(ctx, Voted); err != nil {
+		t.Fatalf("UpdateScoreOnImage failed: %v", err)
+	}
+	Image, err := DB.GetImage(ctx, Voted)
+	if err != nil || Image.ScoreTotal != 3 || Image.ScoreAverage != 3 || Image.ScoreVoters != 1 {
+		t.Errorf("GetImage after voting = %+v, %v, want a total and average of 3 from 1 voter", Image, err)
+	}
+
+	Tag := state.prefix + "voted"
+	expectIDs(t, "score", state.searchIDs(t, Tag+" score:3"), Voted)
+	expectIDs(t, "score >", state.searchIDs(t, Tag+" score:>0"), Voted)
+	expectIDs(t, "-score", state.searchIDs(t, Tag+" -score:3"), Unvoted)
+	expectIDs(t, "totalscore", state.searchIDs(t, Tag+" totalscore:<=0"), Unvoted)
+	expectIDs(t, "scorevoters", state.searchIDs(t, Tag+" scorevoters:1"), Voted)
+}
@@ -0,0 +1,400 @@
+package dbconformance
+
+import (
+	"errors"
+	"go-image-board/interfaces"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+//checkSearch covers GetQueryTags and SearchImages together, the same way the image routers use them
+func (state *suiteState) checkSearch(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	//Every image in this check has the set tag, so queries never see images from other checks or other data in the database
+	SetID := state.newTag(t, "set")
+	AID := state.newTag(t, "tag_a")
+	BID := state.newTag(t, "tag_b")
+	ArtistID := state.newTag(t, "painter_(artist)")
+	AliasID := state.newTag(t, "tag_a_alias")
+	if err := DB.UpdateTag(ctx, AliasID, state.prefix+"tag_a_alias", "", AID, true, state.userID); err != nil {
+		t.Fatalf("UpdateTag failed to make an alias: %v", err)
+	}
+
+	First := state.newImage(t, "first")
+	Second := state.newImage(t, "second")
+	Third := state.newImage(t, "third")
+	Fourth := state.newImage(t, "fourth")
+	for ImageID, Tags := range map[uint64][]uint64{First: {SetID, AID}, Second: {SetID, AID, BID, ArtistID}, Third: {SetID, AID}, Fourth: {SetID}} {
+		if err := DB.AddTag(ctx, Tags, ImageID, state.userID); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if err := DB.SetImageRating(ctx, Third, "explicit"); err != nil {
+		t.Fatalf("SetImageRating failed: %v", err)
+	}
+
+	Set := state.prefix + "set"
+	A := state.prefix + "tag_a"
+	B := state.prefix + "tag_b"
+
+	//Tags, newest first
+	expectIDs(t, "set", state.searchIDs(t, Set), Fourth, Third, Second, First)
+	expectIDs(t, "set a", state.searchIDs(t, Set+" "+A), Third, Second, First)
+	expectIDs(t, "set a b", state.searchIDs(t, Set+" "+A+" "+B), Second)
+	expectIDs(t, "set a -b", state.searchIDs(t, Set+" "+A+" -"+B), Third, First)
+	expectIDs(t, "set +a", state.searchIDs(t, Set+" +"+A), Third, Second, First)
+	expectIDs(t, "set (+b OR +rating:explicit)", state.searchIDs(t, Set+" (+"+B+" OR +rating:explicit)"), Third, Second)
+	expectIDs(t, "set -a", state.searchIDs(t, Set+" -"+A), Fourth)
+	expectIDs(t, "set alias", state.searchIDs(t, Set+" "+state.prefix+"tag_a_alias"), Third, Second, First)
+	expectIDs(t, "set missing", state.searchIDs(t, Set+" "+state.prefix+"missing"), Fourth, Third, Second, First)
+
+	//Exclusion wins when the user's filter and their query disagree
+	//OR groups, top level ORs and excluded groups
+	expectIDs(t, "set (a OR b)", state.searchIDs(t, Set+" ("+A+" OR "+B+")"), Third, Second, First)
+	expectIDs(t, "set (b OR -a)", state.searchIDs(t, Set+" ("+B+" | -"+A+")"), Fourth, Second)
+	expectIDs(t, "set (a OR b) -b", state.searchIDs(t, Set+" ("+A+" OR "+B+") -"+B), Third, First)
+	expectIDs(t, "set -(a b)", state.searchIDs(t, Set+" -("+A+" "+B+")"), Fourth, Third, First)
+	expectIDs(t, "set -(a OR b)", state.searchIDs(t, Set+" -("+A+" OR "+B+")"), Fourth)
+	expectIDs(t, "set a b OR set -a", state.searchIDs(t, Set+" "+A+" "+B+" OR "+Set+" -"+A), Fourth, Second)
+	expectIDs(t, "nested groups", state.searchIDs(t, Set+" (("+B+" OR rating:explicit) OR -("+A+"))"), Fourth, Third, Second)
+	expectIDs(t, "group with alias", state.searchIDs(t, Set+" ("+state.prefix+"tag_a_alias OR "+B+")"), Third, Second, First)
+	expectIDs(t, "group with missing", state.searchIDs(t, Set+" ("+state.prefix+"missing OR "+B+")"), Second)
+	expectIDs(t, "group with only excluded missing", state.searchIDs(t, Set+" (-"+state.prefix+"missing OR "+B+")"), Fourth, Third, Second, First)
+	expectIDs(t, "group with unknown meta tag", state.searchIDs(t, Set+" (sideways:up OR "+B+")"), Second)
+	expectIDs(t, "group with excluded missing", state.searchIDs(t, Set+" (-"+state.prefix+"missing "+B+" OR rating:explicit)"), Third, Second)
+
+	//Wildcards match every tag fitting the pattern, following aliases to the tags they alias
+	expectIDs(t, "set tag_*", state.searchIDs(t, Set+" "+state.prefix+"tag_*"), Third, Second, First)
+	expectIDs(t, "set tag_b*", state.searchIDs(t, Set+" "+state.prefix+"tag_b*"), Second)
+	expectIDs(t, "set -tag_*", state.searchIDs(t, Set+" -"+state.prefix+"tag_*"), Fourth)
+	expectIDs(t, "set alias wildcard", state.searchIDs(t, Set+" "+state.prefix+"tag_a_al*"), Third, Second, First)
+	expectIDs(t, "set *_(artist)", state.searchIDs(t, Set+" "+state.prefix+"*_(artist)"), Second)
+	expectIDs(t, "set name_(artist)", state.searchIDs(t, Set+" "+state.prefix+"painter_(artist)"), Second)
+	expectIDs(t, "set (tag_b* OR -tag_a*)", state.searchIDs(t, Set+" ("+state.prefix+"tag_b* OR -"+state.prefix+"tag_a*)"), Fourth, Second)
+	expectIDs(t, "set missing wildcard", state.searchIDs(t, Set+" "+state.prefix+"missing_*"), Fourth, Third, Second, First)
+	expectIDs(t, "set (missing wildcard OR b)", state.searchIDs(t, Set+" ("+state.prefix+"missing_* OR "+B+")"), Second)
+	for Index := 0; Index <= interfaces.MaxWildcardTags; Index++ {
+		state.newTag(t, "many_"+strconv.Itoa(Index))
+	}
+	if Tags, err := DB.GetQueryTags(ctx, Set+" "+state.prefix+"many_*", false); errors.Is(err, interfaces.ErrWildcardTooBroad) == false {
+		t.Errorf("GetQueryTags(many_*) = %+v, %v, want ErrWildcardTooBroad", Tags, err)
+	}
+
+	if err := DB.SetUserQueryTags(ctx, state.userID, "-"+A); err != nil {
+		t.Fatalf("SetUserQueryTags failed: %v", err)
+	}
+	FilterTags, err := DB.GetUserFilterTags(ctx, state.userID, false)
+	if err != nil {
+		t.Fatalf("GetUserFilterTags failed: %v", err)
+	}
+	for _, Combined := range [][]interfaces.TagInformation{
+		interfaces.RemoveDuplicateTags(append(state.queryTags(t, Set+" "+A, false), FilterTags...)),
+		interfaces.RemoveDuplicateTags(append(append([]interfaces.TagInformation{}, FilterTags...), state.queryTags(t, Set+" "+A, false)...)),
+	} {
+		Images, _, err := DB.SearchImages(ctx, Combined, 0, 100)
+		if err != nil || len(Images) != 1 || Images[0].ID != Fourth {
+			t.Errorf("SearchImages with an excluding filter = %+v, %v, want only %d", Images, err, Fourth)
+		}
+	}
+	if err := DB.SetUserQueryTags(ctx, state.userID, ""); err != nil {
+		t.Fatalf("SetUserQueryTags failed: %v", err)
+	}
+
+	//Saved searches are expanded into groups before the query is parsed
+	if err := DB.SetSavedSearch(ctx, state.userID, "pair", A+" OR "+B); err != nil {
+		t.Fatalf("SetSavedSearch failed: %v", err)
+	}
+	if err := DB.SetSavedSearch(ctx, state.userID, "unbalanced", "-"+B+") ("+A); err != nil {
+		t.Fatalf("SetSavedSearch failed: %v", err)
+	}
+	Saved, err := DB.GetSavedSearches(ctx, state.userID)
+	if err != nil {
+		t.Fatalf("GetSavedSearches failed: %v", err)
+	}
+	expectIDs(t, "saved:pair", state.searchIDs(t, interfaces.ExpandSavedSearches(Set+" saved:pair -"+B, Saved)), Third, First)
+	expectIDs(t, "-saved:pair", state.searchIDs(t, interfaces.ExpandSavedSearches(Set+" -saved:pair", Saved)), Fourth)
+	expectIDs(t, "saved:unbalanced", state.searchIDs(t, interfaces.ExpandSavedSearches(Set+" saved:unbalanced", Saved)), Third, First)
+	expectIDs(t, "unknown saved search", state.searchIDs(t, interfaces.ExpandSavedSearches(Set+" saved:missing", Saved)), Fourth, Third, Second, First)
+
+	//Meta tags
+	expectIDs(t, "uploader", state.searchIDs(t, Set+" uploader:"+state.userName), Fourth, Third, Second, First)
+	expectIDs(t, "-uploader", state.searchIDs(t, Set+" -uploader:"+state.userName))
+	expectIDs(t, "rating", state.searchIDs(t, Set+" rating:explicit"), Third)
+	expectIDs(t, "-rating", state.searchIDs(t, Set+" -rating:explicit"), Fourth, Second, First)
+	expectIDs(t, "tagcount", state.searchIDs(t, Set+" tagcount:2"), Third, First)
+	expectIDs(t, "tagcount >", state.searchIDs(t, Set+" tagcount:>2"), Second)
+	expectIDs(t, "-tagcount", state.searchIDs(t, Set+" -tagcount:2"), Fourth, Second)
+	expectIDs(t, "name", state.searchIDs(t, Set+" name:"+state.prefix+"f"), Fourth, First)
+	expectIDs(t, "location", state.searchIDs(t, Set+" location:third.png"), Third)
+
+	//Media meta tags search the stored metadata, the fourth image has none read yet so its values are all zero
+	for ImageID, Metadata := range map[uint64]interfaces.ImageMetadata{
+		First:  {Width: 1920, Height: 1080, FileSize: 2 << 20, MIMEType: "image/png", Codec: "png"},
+		Second: {Width: 1280, Height: 1024, FileSize: 20 << 20, MIMEType: "video/mp4", Duration: 45.5, Codec: "h264"},
+		Third:  {Width: 1080, Height: 1920, FileSize: 500 << 10, MIMEType: "image/gif", Codec: "gif"},
+	} {
+		if err := DB.SetImageMetadata(ctx, ImageID, Metadata); err != nil {
+			t.Fatalf("SetImageMetadata failed: %v", err)
+		}
+	}
+	expectIDs(t, "width >", state.searchIDs(t, Set+" width:>1280"), First)
+	expectIDs(t, "width >=", state.searchIDs(t, Set+" width:>=1280"), Second, First)
+	expectIDs(t, "height", state.searchIDs(t, Set+" height:1920"), Third)
+	expectIDs(t, "ratio", state.searchIDs(t, Set+" ratio:16:9"), First)
+	expectIDs(t, "ratio decimal", state.searchIDs(t, Set+" ratio:<1"), Third)
+	expectIDs(t, "ratio rounded decimal", state.searchIDs(t, Set+" ratio:1.78"), First)
+	expectIDs(t, "-ratio rounded decimal", state.searchIDs(t, Set+" -ratio:1.78"), Fourth, Third, Second)
+	expectIDs(t, "ratio rounded decimal >", state.searchIDs(t, Set+" ratio:>1.78"))
+	expectIDs(t, "ratio rounded decimal >=", state.searchIDs(t, Set+" ratio:>=1.78"), First)
+	expectIDs(t, "-ratio", state.searchIDs(t, Set+" -ratio:16:9"), Fourth, Third, Second)
+	expectIDs(t, "filesize", state.searchIDs(t, Set+" filesize:>1mb"), Second, First)
+	expectIDs(t, "filesize <", state.searchIDs(t, Set+" filesize:<1.5mb"), Fourth, Third)
+	expectIDs(t, "duration", state.searchIDs(t, Set+" duration:>30"), Second)
+	expectIDs(t, "duration minutes", state.searchIDs(t, Set+" duration:>0:46"))
+	expectIDs(t, "type", state.searchIDs(t, Set+" type:video"), Second)
+	expectIDs(t, "type full", state.searchIDs(t, Set+" type:image/png"), First)
+	expectIDs(t, "-type", state.searchIDs(t, Set+" -type:video"), Fourth, Third, First)
+	expectIDs(t, "ext", state.searchIDs(t, Set+" ext:PNG"), Fourth, Third, Second, First)
+	expectIDs(t, "ext other", state.searchIDs(t, Set+" ext:gif"))
+
+	//Uploaded dates cover a span of time in UTC, and ages count back from now
+	FirstImage, err := DB.GetImage(ctx, First)
+	if err != nil {
+		t.Fatalf("GetImage failed: %v", err)
+	}
+	Uploaded := FirstImage.UploadTime.UTC()
+	expectIDs(t, "uploaded day", state.searchIDs(t, Set+" uploaded:"+Uploaded.Format("2006-01-02")), Fourth, Third, Second, First)
+	expectIDs(t, "uploaded month", state.searchIDs(t, Set+" uploaded:"+Uploaded.Format("2006-01")), Fourth, Third, Second, First)
+	expectIDs(t, "uploaded before", state.searchIDs(t, Set+" uploaded:<"+Uploaded.Format("2006")))
+	expectIDs(t, "uploaded after", state.searchIDs(t, Set+" uploaded:>"+Uploaded.AddDate(0, 0, -1).Format("2006-01-02")), Fourth, Third, Second, First)
+	expectIDs(t, "-uploaded", state.searchIDs(t, Set+" -uploaded:"+Uploaded.Format("2006-01-02")))
+	expectIDs(t, "uploaded age", state.searchIDs(t, Set+" uploaded:<1d"), Fourth, Third, Second, First)
+	expectIDs(t, "uploaded older", state.searchIDs(t, Set+" uploaded:>1d"))
+	Counts, err := DB.GetImageUploadCounts(ctx, Uploaded.Truncate(24*time.Hour), time.Time{}, true)
+	if err != nil || len(Counts) == 0 || Counts[0].Period != Uploaded.Format("2006-01-02") || Counts[0].Count < 4 {
+		t.Errorf("GetImageUploadCounts(by day) = %+v, %v, want at least 4 on %s", Counts, err, Uploaded.Format("2006-01-02"))
+	}
+	Counts, err = DB.GetImageUploadCounts(ctx, time.Time{}, time.Time{}, false)
+	if err != nil || len(Counts) == 0 || Counts[len(Counts)-1].Period != Uploaded.Format("2006-01") || Counts[len(Counts)-1].Count < 4 {
+		t.Errorf("GetImageUploadCounts(by month) = %+v, %v, want at least 4 in %s last", Counts, err, Uploaded.Format("2006-01"))
+	}
+	if Counts, err := DB.GetImageUploadCounts(ctx, Uploaded.AddDate(0, 0, 2), time.Time{}, true); err != nil || len(Counts) != 0 {
+		t.Errorf("GetImageUploadCounts(after now) = %+v, %v, want nothing", Counts, err)
+	}
+
+	//Similar compares dHashes, images without one never match
+	Hashes := map[uint64][2]uint64{First: {0, 0}, Second: {1, 0}, Third: {^uint64(0), ^uint64(0)}}
+	for ImageID, Hash := range Hashes {
+		if err := DB.SetImagedHash(ctx, ImageID, Hash[0], Hash[1]); err != nil {
+			t.Fatalf("SetImagedHash failed: %v", err)
+		}
+	}
+	FirstString := strconv.FormatUint(First, 10)
+	expectIDs(t, "similar", state.searchIDs(t, Set+" similar:"+FirstString), Second, First)
+	expectIDs(t, "similar threshold", state.searchIDs(t, Set+" similar:0-"+FirstString), First)
+
+	//Order tags sort instead of filtering, ties are broken by ID in the same direction
+	for ImageID, Score := range map[uint64]int64{First: 3, Third: 5} {
+		if err := DB.UpdateUserVoteScore(ctx, state.userID, ImageID, Score); err != nil {
+			t.Fatalf("UpdateUserVoteScore failed: %v", err)
+		}
+		if err := DB.UpdateScoreOnImage(ctx, ImageID); err != nil {
+			t.Fatalf("UpdateScoreOnImage failed: %v", err)
+		}
+	}
+	expectIDs(t, "order:score", state.searchIDs(t, Set+" order:score"), Third, First, Fourth, Second)
+	expectIDs(t, "order:votes", state.searchIDs(t, Set+" order:votes"), Third, First, Fourth, Second)
+	expectIDs(t, "order:filesize", state.searchIDs(t, Set+" order:filesize"), Second, First, Third, Fourth)
+	expectIDs(t, "order:filesize_asc", state.searchIDs(t, Set+" order:filesize_asc"), Fourth, Third, First, Second)
+	expectIDs(t, "order:tagcount", state.searchIDs(t, Set+" order:tagcount"), Second, Third, First, Fourth)
+	expectIDs(t, "order:uploaded", state.searchIDs(t, Set+" order:uploaded"), Fourth, Third, Second, First)
+	expectIDs(t, "order:uploaded_asc", state.searchIDs(t, Set+" order:uploaded_asc"), First, Second, Third, Fourth)
+	expectIDs(t, "order without tags", state.searchIDs(t, "("+Set+" OR "+state.prefix+"missing) order:tagcount"), Second, Third, First, Fourth)
+	for _, Query := range []string{Set + " (" + A + " order:filesize OR " + B + ")", Set + " (" + A + " OR order:score)"} {
+		if Tags, err := DB.GetQueryTags(ctx, Query, false); errors.Is(err, interfaces.ErrOrderInGroup) == false {
+			t.Errorf("GetQueryTags(%s) = %+v, %v, want ErrOrderInGroup", Query, Tags, err)
+		}
+	}
+	expectIDs(t, "-order:filesize", state.searchIDs(t, Set+" -order:filesize"), Fourth, Third, Second, First)
+	expectIDs(t, "unknown order", state.searchIDs(t, Set+" order:sideways"), Fourth, Third, Second, First)
+	RandomTags := state.queryTags(t, Set+" order:random", false)
+	Random := []uint64{Fourth, Third, Second, First}
+	for _, Tag := range RandomTags {
+		if Order, isOrder := Tag.MetaValue.(interfaces.SearchOrder); isOrder {
+			sort.Slice(Random, func(i, j int) bool { return Order.RandomOrderKey(Random[i]) > Order.RandomOrderKey(Random[j]) })
+		}
+	}
+	expectIDs(t, "order:random", state.searchIDs(t, Set+" order:random"), Random...)
+	expectIDs(t, "pinned order:random", state.searchIDs(t, interfaces.PinRandomOrder(Set+" order:random", RandomTags)), Random...)
+	//A seed picks the shuffle, the last order in a query wins
+	Seeded := []uint64{Fourth, Third, Second, First}
+	SeededOrder := interfaces.SearchOrder{Name: "random", Seed: 12345}
+	sort.Slice(Seeded, func(i, j int) bool {
+		return SeededOrder.RandomOrderKey(Seeded[i]) > SeededOrder.RandomOrderKey(Seeded[j])
+	})
+	expectIDs(t, "order:random:12345", state.searchIDs(t, Set+" order:random:12345"), Seeded...)
+	expectIDs(t, "order:random order:random:12345", state.searchIDs(t, Set+" order:random order:random:12345"), Seeded...)
+	expectIDs(t, "order:score:5", state.searchIDs(t, Set+" order:score:5"), Fourth, Third, Second, First)
+	expectIDs(t, "order:random:x", state.searchIDs(t, Set+" order:random:x"), Fourth, Third, Second, First)
+	for _, Check := range []struct {
+		Query  string
+		Target uint64
+		Want   []uint64
+	}{
+		{Set + " order:filesize", First, []uint64{Second, Third}},
+		{Set + " order:filesize", Second, []uint64{First}},
+		{Set + " order:uploaded_asc", Third, []uint64{Second, Fourth}},
+		{Set + " order:tagcount", Third, []uint64{Second, First}},
+		{Set + " order:tagcount", First, []uint64{Third, Fourth}},
+		{"(" + Set + " OR " + state.prefix + "missing) order:tagcount", First, []uint64{Third, Fourth}},
+		{Set + " order:random", Random[1], []uint64{Random[0], Random[2]}},
+	} {
+		Neighbours, err := DB.GetPrevNexImages(ctx, state.queryTags(t, Check.Query, false), Check.Target)
+		var Got []uint64
+		for _, Neighbour := range Neighbours {
+			Got = append(Got, Neighbour.ID)
+		}
+		if err != nil {
+			t.Errorf("GetPrevNexImages(%s, %d) failed: %v", Check.Query, Check.Target, err)
+		} else {
+			expectIDs(t, "GetPrevNexImages("+Check.Query+")", Got, Check.Want...)
+		}
+	}
+
+	//Paging keeps the count of all matches
+	SetTags := state.queryTags(t, Set, false)
+	Images, Count, err := DB.SearchImages(ctx, SetTags, 1, 2)
+	if err != nil || Count != 4 || len(Images) != 2 || Images[0].ID != Third || Images[1].ID != Second {
+		t.Errorf("SearchImages(set, 1, 2) = %+v, %d, %v, want %d and %d of 4", Images, Count, err, Third, Second)
+	}
+	if Images, Count, err := DB.SearchImages(ctx, SetTags, 4, 2); err != nil || Count != 4 || len(Images) != 0 {
+		t.Errorf("SearchImages(set, 4, 2) = %+v, %d, %v, want nothing of 4", Images, Count, err)
+	}
+
+	//Following the cursor visits every match once, in the same order as a full search
+	for _, Check := range []struct {
+		Query  string
+		Stride uint64
+		Want   []uint64
+	}{
+		{Set, 2, []uint64{Fourth, Third, Second, First}},
+		{Set, 3, []uint64{Fourth, Third, Second, First}},
+		{Set + " order:tagcount", 1, []uint64{Second, Third, First, Fourth}},
+		{Set + " order:uploaded_asc", 3, []uint64{First, Second, Third, Fourth}},
+		{Set + " order:random", 1, Random},
+	} {
+		CheckTags := state.queryTags(t, Check.Query, false)
+		var Got []uint64
+		Cursor := ""
+		for Page := 0; Page < len(Check.Want)+1; Page++ {
+			Images, NextCursor, Count, err := DB.SearchImagesPage(ctx, CheckTags, Cursor, 0, Check.Stride, Page == 0)
+			if err != nil {
+				t.Fatalf("SearchImagesPage(%s, %q) failed: %v", Check.Query, Cursor, err)
+			}
+			if Page == 0 && Count != uint64(len(Check.Want)) {
+				t.Errorf("SearchImagesPage(%s) count = %d, want %d", Check.Query, Count, len(Check.Want))
+			} else if Page > 0 && Count != 0 {
+				t.Errorf("SearchImagesPage(%s) counted %d results without being asked", Check.Query, Count)
+			}
+			for _, Image := range Images {
+				Got = append(Got, Image.ID)
+			}
+			if NextCursor == "" {
+				break
+			}
+			Cursor = NextCursor
+		}
+		expectIDs(t, "SearchImagesPage("+Check.Query+") pages", Got, Check.Want...)
+	}
+	//A cursor keeps the shuffle it was made in, even once the query's seed has changed
+	SeededPage, SeededCursor, _, err := DB.SearchImagesPage(ctx, state.queryTags(t, Set+" order:random:12345", false), "", 0, 1, false)
+	if err != nil || len(SeededPage) != 1 || SeededPage[0].ID != Seeded[0] || SeededCursor == "" {
+		t.Fatalf("SearchImagesPage(order:random:12345) = %+v, %q, %v, want %d and a next page", SeededPage, SeededCursor, err, Seeded[0])
+	}
+	if SeededPage, _, _, err = DB.SearchImagesPage(ctx, state.queryTags(t, Set+" order:random:999", false), SeededCursor, 0, 3, false); err != nil {
+		t.Fatalf("SearchImagesPage(order:random:999, seeded cursor) failed: %v", err)
+	}
+	var SeededRest []uint64
+	for _, Image := range SeededPage {
+		SeededRest = append(SeededRest, Image.ID)
+	}
+	expectIDs(t, "seeded cursor", SeededRest, Seeded[1:]...)
+
+	//A cursor keeps the sort key of its image, so the next page is still found once that image is deleted
+	CursorTag := state.prefix + "cursor_tag"
+	CursorTagID := state.newTag(t, "cursor_tag")
+	CursorFirst := state.newImage(t, "cursorfirst")
+	CursorSecond := state.newImage(t, "cursorsecond")
+	CursorThird := state.newImage(t, "cursorthird")
+	for _, ImageID := range []uint64{CursorSecond, CursorThird} {
+		if err := DB.AddTag(ctx, []uint64{CursorTagID}, ImageID, state.userID); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	for _, Check := range []struct {
+		Query   string
+		Deleted uint64
+		Want    []uint64
+	}{
+		{"name:" + state.prefix + "cursor order:uploaded_asc", CursorFirst, []uint64{CursorSecond, CursorThird}},
+		{CursorTag + " order:score", CursorThird, []uint64{CursorSecond}},
+	} {
+		CheckTags := state.queryTags(t, Check.Query, false)
+		Images, NextCursor, _, err := DB.SearchImagesPage(ctx, CheckTags, "", 0, 1, false)
+		if err != nil || len(Images) != 1 || Images[0].ID != Check.Deleted || NextCursor == "" {
+			t.Fatalf("SearchImagesPage(%s) = %+v, %q, %v, want %d and a next page", Check.Query, Images, NextCursor, err, Check.Deleted)
+		}
+		if err := DB.DeleteImage(ctx, Check.Deleted); err != nil {
+			t.Fatalf("DeleteImage failed: %v", err)
+		}
+		Images, _, _, err = DB.SearchImagesPage(ctx, CheckTags, NextCursor, 0, 3, false)
+		if err != nil {
+			t.Fatalf("SearchImagesPage(%s, deleted image's cursor) failed: %v", Check.Query, err)
+		}
+		var Got []uint64
+		for _, Image := range Images {
+			Got = append(Got, Image.ID)
+		}
+		expectIDs(t, "cursor after deleted image", Got, Check.Want...)
+	}
+	if _, _, _, err := DB.SearchImagesPage(ctx, state.queryTags(t, CursorTag+" order:score", false), interfaces.EncodeSearchCursor(CursorSecond, 0, interfaces.SearchOrder{}), 0, 1, false); errors.Is(err, interfaces.ErrInvalidCursor) == false {
+		t.Errorf("SearchImagesPage(order:score, cursor without sort key) error = %v, want %v", err, interfaces.ErrInvalidCursor)
+	}
+
+	//A cursor replaces the page start
+	Images, NextCursor, _, err := DB.SearchImagesPage(ctx, SetTags, interfaces.EncodeSearchCursor(Third, 0, interfaces.SearchOrder{}), 3, 1, false)
+	if err != nil || len(Images) != 1 || Images[0].ID != Second || NextCursor == "" {
+		t.Errorf("SearchImagesPage(set, after third) = %+v, %q, %v, want %d and a next page", Images, NextCursor, err, Second)
+	}
+	if _, _, _, err := DB.SearchImagesPage(ctx, SetTags, "not a cursor", 0, 2, false); errors.Is(err, interfaces.ErrInvalidCursor) == false {
+		t.Errorf("SearchImagesPage(invalid cursor) error = %v, want %v", err, interfaces.ErrInvalidCursor)
+	}
+
+	//Previous and next, the next (newer) image comes first
+	Neighbours, err := DB.GetPrevNexImages(ctx, SetTags, Second)
+	if err != nil || len(Neighbours) != 2 || Neighbours[0].ID != Third || Neighbours[1].ID != First {
+		t.Errorf("GetPrevNexImages(second) = %+v, %v, want %d then %d", Neighbours, err, Third, First)
+	}
+	Neighbours, err = DB.GetPrevNexImages(ctx, state.queryTags(t, Set+" "+A, false), Third)
+	if err != nil || len(Neighbours) != 1 || Neighbours[0].ID != Second {
+		t.Errorf("GetPrevNexImages(set a, third) = %+v, %v, want only %d", Neighbours, err, Second)
+	}
+
+	Neighbours, err = DB.GetPrevNexImages(ctx, state.queryTags(t, Set+" ("+B+" OR -"+A+" OR rating:explicit)", false), Third)
+	if err != nil || len(Neighbours) != 2 || Neighbours[0].ID != Fourth || Neighbours[1].ID != Second {
+		t.Errorf("GetPrevNexImages(set (b OR -a OR rating), third) = %+v, %v, want %d then %d", Neighbours, err, Fourth, Second)
+	}
+
+	//Random only picks from matches
+	Image, Count, err := DB.GetRandomImage(ctx, state.queryTags(t, Set+" "+A+" -"+B, false))
+	if err != nil || Count != 2 || (Image.ID != First && Image.ID != Third) {
+		t.Errorf("GetRandomImage = %+v, %d, %v, want %d or %d of 2", Image, Count, err, First, Third)
+	}
+	Image, Count, err = DB.GetRandomImage(ctx, state.queryTags(t, Set+" -("+A+" OR "+B+")", false))
+	if err != nil || Count != 1 || Image.ID != Fourth {
+		t.Errorf("GetRandomImage(set -(a OR b)) = %+v, %d, %v, want only %d", Image, Count, err, Fourth)
+	}
+}
@@ -0,0 +1,145 @@
+package dbconformance
+
+import (
+	"testing"
+)
+
+//checkTags covers tag creation, lookup, aliases and tag use on images
+func (state *suiteState) checkTags(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	RedID := state.newTag(t, "red")
+	if _, err := DB.NewTag(ctx, state.prefix+"red", "duplicate", state.userID); err == nil {
+		t.Error("NewTag allowed a duplicate name")
+	}
+	if _, err := DB.NewTag(ctx, "a", "", state.userID); err == nil {
+		t.Error("NewTag allowed a name shorter than 3 characters")
+	}
+
+	//Names are cleaned up before they are stored
+	SpacedID, err := DB.NewTag(ctx, "  "+state.prefix+"Light  Blue ", "", state.userID)
+	if err != nil {
+		t.Fatalf("NewTag failed: %v", err)
+	}
+	if Tag, err := DB.GetTag(ctx, SpacedID, false); err != nil || Tag.Name != state.prefix+"light_blue" {
+		t.Errorf("GetTag = %+v, %v, want name %s", Tag, err, state.prefix+"light_blue")
+	}
+	//Only search meta tag values keep :, / and +
+	for Name, Want := range map[string]string{state.prefix + "a:b:c": state.prefix + "a_b_c", state.prefix + "artist:x/y+z": state.prefix + "artist:x_y_z"} {
+		CleanedID, err := DB.NewTag(ctx, Name, "", state.userID)
+		if err != nil {
+			t.Fatalf("NewTag(%s) failed: %v", Name, err)
+		}
+		if Tag, err := DB.GetTag(ctx, CleanedID, false); err != nil || Tag.Name != Want {
+			t.Errorf("GetTag = %+v, %v, want name %s", Tag, err, Want)
+		}
+		if err := DB.DeleteTag(ctx, CleanedID); err != nil {
+			t.Errorf("DeleteTag failed: %v", err)
+		}
+	}
+
+	Tag, err := DB.GetTagByName(ctx, state.prefix+"red")
+	if err != nil || Tag.ID != RedID || Tag.Exists == false || Tag.UploaderID != state.userID {
+		t.Errorf("GetTagByName = %+v, %v, want ID %d", Tag, err, RedID)
+	}
+	if _, err := DB.GetTag(ctx, RedID+100000, false); err == nil {
+		t.Error("GetTag found a tag that does not exist")
+	}
+
+	//Aliases, set up before the alias is used so plugins that rewrite image tags in the background have nothing to do
+	CrimsonID := state.newTag(t, "crimson")
+	if err := DB.UpdateTag(ctx, CrimsonID, state.prefix+"crimson", "alias of red", RedID, true, state.userID); err != nil {
+		t.Fatalf("UpdateTag failed to make an alias: %v", err)
+	}
+	if Tag, err := DB.GetTag(ctx, CrimsonID, false); err != nil || Tag.IsAlias == false || Tag.AliasedID != RedID {
+		t.Errorf("GetTag = %+v, %v, want an alias of %d", Tag, err, RedID)
+	}
+	ScarletID := state.newTag(t, "scarlet")
+	if err := DB.UpdateTag(ctx, ScarletID, state.prefix+"scarlet", "", CrimsonID, true, state.userID); err == nil {
+		t.Error("UpdateTag allowed an alias of an alias")
+	}
+	if err := DB.UpdateTag(ctx, ScarletID, state.prefix+"red", "", 0, false, state.userID); err == nil {
+		t.Error("UpdateTag allowed renaming to a name already in use")
+	}
+
+	//Querying an alias returns the alias and the tag it points to
+	QueryTags := state.queryTags(t, state.prefix+"crimson", false)
+	if containsTag(QueryTags, CrimsonID) == false || containsTag(QueryTags, RedID) == false {
+		t.Errorf("GetQueryTags(crimson) = %+v, want both the alias and the aliased tag", QueryTags)
+	}
+	//Unknown tags are returned but do not exist
+	QueryTags = state.queryTags(t, state.prefix+"nothing", false)
+	if len(QueryTags) != 1 || QueryTags[0].Exists {
+		t.Errorf("GetQueryTags(nothing) = %+v, want one tag that does not exist", QueryTags)
+	}
+
+	//Adding an alias to an image adds the aliased tag instead
+	ImageID := state.newImage(t, "tagged")
+	if err := DB.AddTag(ctx, []uint64{CrimsonID, SpacedID}, ImageID, state.userID); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	ImageTags, err := DB.GetImageTags(ctx, ImageID)
+	if err != nil || len(ImageTags) != 2 || containsTag(ImageTags, RedID) == false || containsTag(ImageTags, SpacedID) == false {
+		t.Errorf("GetImageTags = %+v, %v, want red and light_blue", ImageTags, err)
+	}
+	if err := DB.AddTag(ctx, []uint64{RedID + 100000}, ImageID, state.userID); err == nil {
+		t.Error("AddTag allowed a tag that does not exist")
+	}
+	if Tag, err := DB.GetTag(ctx, RedID, true); err != nil || Tag.UseCount != 1 {
+		t.Errorf("GetTag with count = %+v, %v, want UseCount 1", Tag, err)
+	}
+
+	if ImageIDs, err := DB.GetTagImageIDs(ctx, SpacedID); err != nil || len(ImageIDs) != 1 || ImageIDs[0] != ImageID {
+		t.Errorf("GetTagImageIDs = %v, %v, want only %d", ImageIDs, err, ImageID)
+	}
+	if ImageIDs, err := DB.GetTagImageIDs(ctx, CrimsonID); err != nil || len(ImageIDs) != 0 {
+		t.Errorf("GetTagImageIDs(alias) = %v, %v, want none as aliases are never linked", ImageIDs, err)
+	}
+
+	//Bulk add puts a tag on every image that has another
+	GreenID := state.newTag(t, "green")
+	if err := DB.BulkAddTag(ctx, GreenID, SpacedID, state.userID); err != nil {
+		t.Errorf("BulkAddTag failed: %v", err)
+	}
+	if ImageTags, err := DB.GetImageTags(ctx, ImageID); err != nil || containsTag(ImageTags, GreenID) == false {
+		t.Errorf("GetImageTags after BulkAddTag = %+v, %v, want green", ImageTags, err)
+	}
+
+	//Replace swaps one tag for another
+	if err := DB.ReplaceImageTags(ctx, SpacedID, ScarletID, state.userID); err != nil {
+		t.Errorf("ReplaceImageTags failed: %v", err)
+	}
+	if ImageTags, err := DB.GetImageTags(ctx, ImageID); err != nil || containsTag(ImageTags, SpacedID) || containsTag(ImageTags, ScarletID) == false {
+		t.Errorf("GetImageTags after ReplaceImageTags = %+v, %v, want scarlet in place of light_blue", ImageTags, err)
+	}
+
+	//Search
+	Tags, Count, err := DB.SearchTags(ctx, state.prefix, 0, 100, true, false)
+	if err != nil || Count != 5 || len(Tags) != 5 {
+		t.Errorf("SearchTags(%s) = %d tags, count %d, %v, want 5", state.prefix, len(Tags), Count, err)
+	}
+	Tags, _, err = DB.SearchTags(ctx, state.prefix+"cr", 0, 100, true, false)
+	if err != nil || len(Tags) != 1 || Tags[0].ID != CrimsonID || Tags[0].IsAlias == false {
+		t.Errorf("SearchTags(crimson) = %+v, %v, want only the crimson alias", Tags, err)
+	}
+	if AllTags, err := DB.GetAllTags(ctx); err != nil || containsTag(AllTags, RedID) == false {
+		t.Errorf("GetAllTags did not return red: %v", err)
+	}
+
+	//Tags in use can not be deleted
+	if err := DB.DeleteTag(ctx, RedID); err == nil {
+		t.Error("DeleteTag removed a tag that is in use")
+	}
+	if err := DB.RemoveTag(ctx, RedID, ImageID); err != nil {
+		t.Errorf("RemoveTag failed: %v", err)
+	}
+	if ImageTags, err := DB.GetImageTags(ctx, ImageID); err != nil || containsTag(ImageTags, RedID) {
+		t.Errorf("GetImageTags after RemoveTag = %+v, %v, want red gone", ImageTags, err)
+	}
+	if err := DB.DeleteTag(ctx, SpacedID); err != nil {
+		t.Errorf("DeleteTag failed: %v", err)
+	}
+	if _, err := DB.GetTag(ctx, SpacedID, false); err == nil {
+		t.Error("GetTag found a deleted tag")
+	}
+}
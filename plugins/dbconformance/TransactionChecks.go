@@ -0,0 +1,94 @@
+package dbconformance
+
+import (
+	"errors"
+	"go-image-board/interfaces"
+	"testing"
+)
+
+//errRollback is returned from transactions the checks want rolled back
+var errRollback = errors.New("conformance rollback")
+
+//checkTransactions covers RunInTransaction committing, rolling back, and joining an outer transaction
+func (state *suiteState) checkTransactions(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+
+	//Committed changes are visible inside the transaction and after it
+	var ImageID uint64
+	err := DB.RunInTransaction(ctx, func(Tx interfaces.DBInterface) error {
+		TagID, err := Tx.NewTag(ctx, state.prefix+"tx_committed", "", state.userID)
+		if err != nil {
+			return err
+		}
+		ImageID, err = Tx.NewImage(ctx, state.prefix+"tx_committed", state.prefix+"tx_committed.png", state.userID, "")
+		if err != nil {
+			return err
+		}
+		if err := Tx.AddTag(ctx, []uint64{TagID}, ImageID, state.userID); err != nil {
+			return err
+		}
+		if Tags, err := Tx.GetImageTags(ctx, ImageID); err != nil || len(Tags) != 1 || Tags[0].ID != TagID {
+			t.Errorf("GetImageTags inside transaction = %+v, %v, want %d", Tags, err, TagID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunInTransaction failed: %v", err)
+	}
+	if Tags, err := DB.GetImageTags(ctx, ImageID); err != nil || len(Tags) != 1 || Tags[0].Name != state.prefix+"tx_committed" {
+		t.Errorf("GetImageTags after commit = %+v, %v, want tx_committed", Tags, err)
+	}
+
+	//A failed transaction leaves nothing behind
+	err = DB.RunInTransaction(ctx, func(Tx interfaces.DBInterface) error {
+		TagID, err := Tx.NewTag(ctx, state.prefix+"tx_rolled_back", "", state.userID)
+		if err != nil {
+			return err
+		}
+		ImageID, err := Tx.NewImage(ctx, state.prefix+"tx_rolled_back", state.prefix+"tx_rolled_back.png", state.userID, "")
+		if err != nil {
+			return err
+		}
+		if err := Tx.AddTag(ctx, []uint64{TagID}, ImageID, state.userID); err != nil {
+			return err
+		}
+		CollectionID, err := Tx.NewCollection(ctx, state.prefix+"tx_rolled_back", "", state.userID)
+		if err != nil {
+			return err
+		}
+		if err := Tx.AddCollectionMember(ctx, CollectionID, []uint64{ImageID}, state.userID); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if err != errRollback {
+		t.Errorf("RunInTransaction = %v, want the error returned by Work", err)
+	}
+	if Image, err := DB.GetImageByFileName(ctx, state.prefix+"tx_rolled_back.png"); err == nil {
+		t.Errorf("GetImageByFileName found %+v from a rolled back transaction", Image)
+	}
+	if Tag, err := DB.GetTagByName(ctx, state.prefix+"tx_rolled_back"); err == nil {
+		t.Errorf("GetTagByName found %+v from a rolled back transaction", Tag)
+	}
+	if Collection, err := DB.GetCollectionByName(ctx, state.prefix+"tx_rolled_back"); err == nil {
+		t.Errorf("GetCollectionByName found %+v from a rolled back transaction", Collection)
+	}
+
+	//A nested transaction is part of the outer one, so its changes roll back with it
+	err = DB.RunInTransaction(ctx, func(Tx interfaces.DBInterface) error {
+		if err := Tx.RunInTransaction(ctx, func(Nested interfaces.DBInterface) error {
+			_, err := Nested.NewTag(ctx, state.prefix+"tx_nested", "", state.userID)
+			return err
+		}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if err != errRollback {
+		t.Errorf("RunInTransaction with a nested transaction = %v, want the error returned by Work", err)
+	}
+	if Tag, err := DB.GetTagByName(ctx, state.prefix+"tx_nested"); err == nil {
+		t.Errorf("GetTagByName found %+v from a rolled back nested transaction", Tag)
+	}
+}
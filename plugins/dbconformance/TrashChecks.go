@@ -0,0 +1,143 @@
+package dbconformance
+
+import (
+	"go-image-board/interfaces"
+	"slices"
+	"testing"
+	"time"
+)
+
+//checkTrash covers the recycle bin, trashed images are hidden from searches and collections until restored
+func (state *suiteState) checkTrash(t *testing.T) {
+	ctx := t.Context()
+	DB := state.DB
+	Tag := state.prefix + "trash_tag"
+	TagID := state.newTag(t, "trash_tag")
+	First := state.newImage(t, "trash_first")
+	Second := state.newImage(t, "trash_second")
+	Third := state.newImage(t, "trash_third")
+	for _, ImageID := range []uint64{First, Second, Third} {
+		if err := DB.AddTag(ctx, []uint64{TagID}, ImageID, state.userID); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	CollectionID, err := DB.NewCollection(ctx, state.prefix+"trash_album", "", state.userID)
+	if err != nil {
+		t.Fatalf("NewCollection failed: %v", err)
+	}
+	if err := DB.AddCollectionMember(ctx, CollectionID, []uint64{First, Second, Third}, state.userID); err != nil {
+		t.Fatalf("AddCollectionMember failed: %v", err)
+	}
+
+	if err := DB.TrashImage(ctx, Second, state.userID); err != nil {
+		t.Fatalf("TrashImage failed: %v", err)
+	}
+	if err := DB.TrashImage(ctx, Second, state.userID); err == nil {
+		t.Error("TrashImage trashed an image that is already in the recycle bin")
+	}
+	if err := DB.TrashImage(ctx, Third+100000, state.userID); err == nil {
+		t.Error("TrashImage trashed an image that does not exist")
+	}
+	//Trashed images can still be looked up directly, so mods can review them
+	if Image, err := DB.GetImage(ctx, Second); err != nil || Image.DeletedTime.IsZero() || Image.DeletedByID != state.userID || Image.DeletedByName != state.userName {
+		t.Errorf("GetImage(trashed) = %+v, %v, want DeletedTime set by %s", Image, err, state.userName)
+	}
+	if Image, err := DB.GetImage(ctx, First); err != nil || Image.DeletedTime.IsZero() == false {
+		t.Errorf("GetImage(not trashed) = %+v, %v, want no DeletedTime", Image, err)
+	}
+	//Checked before serving an image's files
+	if Trashed, err := DB.IsImageFileTrashed(ctx, state.prefix+"trash_second.png"); err != nil || Trashed == false {
+		t.Errorf("IsImageFileTrashed(trashed) = %v, %v, want true", Trashed, err)
+	}
+	if Trashed, err := DB.IsImageFileTrashed(ctx, state.prefix+"trash_first.png"); err != nil || Trashed {
+		t.Errorf("IsImageFileTrashed(not trashed) = %v, %v, want false", Trashed, err)
+	}
+	if Trashed, err := DB.IsImageFileTrashed(ctx, state.prefix+"trash_missing.png"); err != nil || Trashed {
+		t.Errorf("IsImageFileTrashed(missing) = %v, %v, want false", Trashed, err)
+	}
+
+	//Hidden from searches, prev/next and collections
+	expectIDs(t, "search with trashed image", state.searchIDs(t, Tag), Third, First)
+	if Neighbours, err := DB.GetPrevNexImages(ctx, state.queryTags(t, Tag, false), Third); err != nil || len(Neighbours) != 1 || Neighbours[0].ID != First {
+		t.Errorf("GetPrevNexImages(third) = %+v, %v, want only %d", Neighbours, err, First)
+	}
+	state.expectVisibleMembers(t, "with trashed member", CollectionID, First, Third)
+	//Members in the recycle bin can still be restored, so they are not orphaned
+	if Orphans, err := DB.GetOrphanedCollectionMembers(ctx); err != nil || slices.ContainsFunc(Orphans, func(Member interfaces.CollectionMember) bool { return Member.CollectionID == CollectionID }) {
+		t.Errorf("GetOrphanedCollectionMembers = %+v, %v, want no members of collection %d", Orphans, err, CollectionID)
+	}
+	if Collection, err := DB.GetCollection(ctx, CollectionID); err != nil || Collection.Members != 2 {
+		t.Errorf("GetCollection = %+v, %v, want 2 members", Collection, err)
+	}
+	if Collections, err := DB.GetCollectionsWithImage(ctx, First); err != nil || len(Collections) != 1 || Collections[0].NextMemberID != Third || Collections[0].Members != 2 {
+		t.Errorf("GetCollectionsWithImage(first) = %+v, %v, want next %d of 2", Collections, err, Third)
+	}
+
+	//Listed in the recycle bin
+	if Trashed, Count, err := DB.GetTrashedImages(ctx, 0, 1000); err != nil || Count < 1 || trashContains(Trashed, Second) == false {
+		t.Errorf("GetTrashedImages = %d images, %v, want %d listed", Count, err, Second)
+	}
+	//A day either side, so a database in another timezone still agrees
+	if Expired, err := DB.GetImagesTrashedBefore(ctx, time.Now().Add(-24*time.Hour), 1000); err != nil || trashContains(Expired, Second) {
+		t.Errorf("GetImagesTrashedBefore(yesterday) = %+v, %v, want %d left out", Expired, err, Second)
+	}
+	if Expired, err := DB.GetImagesTrashedBefore(ctx, time.Now().Add(24*time.Hour), 1000); err != nil || trashContains(Expired, Second) == false {
+		t.Errorf("GetImagesTrashedBefore(tomorrow) = %+v, %v, want %d listed", Expired, err, Second)
+	}
+
+	//Restoring puts everything back
+	if err := DB.RestoreImage(ctx, Second); err != nil {
+		t.Fatalf("RestoreImage failed: %v", err)
+	}
+	if err := DB.RestoreImage(ctx, Second); err == nil {
+		t.Error("RestoreImage restored an image that is not in the recycle bin")
+	}
+	if Image, err := DB.GetImage(ctx, Second); err != nil || Image.DeletedTime.IsZero() == false {
+		t.Errorf("GetImage(restored) = %+v, %v, want no DeletedTime", Image, err)
+	}
+	if Trashed, err := DB.IsImageFileTrashed(ctx, state.prefix+"trash_second.png"); err != nil || Trashed {
+		t.Errorf("IsImageFileTrashed(restored) = %v, %v, want false", Trashed, err)
+	}
+	expectIDs(t, "search after restore", state.searchIDs(t, Tag), Third, Second, First)
+	state.expectVisibleMembers(t, "after restore", CollectionID, First, Second, Third)
+
+	//Purging a trashed image removes it for good
+	if err := DB.TrashImage(ctx, First, state.userID); err != nil {
+		t.Fatalf("TrashImage failed: %v", err)
+	}
+	if err := DB.DeleteImage(ctx, First); err != nil {
+		t.Fatalf("DeleteImage of a trashed image failed: %v", err)
+	}
+	if _, err := DB.GetImage(ctx, First); err == nil {
+		t.Error("GetImage found a purged image")
+	}
+	state.expectVisibleMembers(t, "after purge", CollectionID, Second, Third)
+}
+
+//expectVisibleMembers checks GetCollectionMembers returns Want in order. Unlike expectMembers it ignores the OrderInCollection gaps trashed members leave
+func (state *suiteState) expectVisibleMembers(t *testing.T, What string, CollectionID uint64, Want ...uint64) {
+	t.Helper()
+	Members, Count, err := state.DB.GetCollectionMembers(t.Context(), CollectionID, 0, 0)
+	if err != nil {
+		t.Errorf("%s: GetCollectionMembers failed: %v", What, err)
+		return
+	}
+	var Got []uint64
+	for _, Member := range Members {
+		Got = append(Got, Member.ID)
+	}
+	expectIDs(t, What, Got, Want...)
+	if Count != uint64(len(Want)) {
+		t.Errorf("%s: GetCollectionMembers count %d, want %d", What, Count, len(Want))
+	}
+}
+
+//trashContains reports whether Images has an image with the given ID
+func trashContains(Images []interfaces.ImageInformation, ImageID uint64) bool {
+	for _, Image := range Images {
+		if Image.ID == ImageID {
+			return true
+		}
+	}
+	return false
+}
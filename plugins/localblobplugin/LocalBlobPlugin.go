@@ -0,0 +1,148 @@
+package localblobplugin
+
+import (
+	"context"
+	"errors"
+	"go-image-board/config"
+	"go-image-board/interfaces"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+//LocalBlobPlugin stores media files as files in ImageDirectory, thumbnails go in its thumbs directory
+type LocalBlobPlugin struct {
+	root string
+}
+
+//Init creates ImageDirectory and its thumbs directory if they do not exist
+func (Store *LocalBlobPlugin) Init(ctx context.Context) error {
+	Store.root = config.Configuration.ImageDirectory
+	return os.MkdirAll(filepath.Join(Store.root, "thumbs"), 0755)
+}
+
+//LocalPath returns the path of the file Name is stored in. Names are cleaned first, so they cannot leave ImageDirectory
+func (Store *LocalBlobPlugin) LocalPath(Name string) string {
+	return filepath.Join(Store.root, filepath.FromSlash(path.Clean("/"+Name)))
+}
+
+//Create stores Content under Name, it fails with an error matching fs.ErrExist if Name is already stored
+func (Store *LocalBlobPlugin) Create(ctx context.Context, Name string, Content io.Reader, Size int64) error {
+	FilePath := Store.LocalPath(Name)
+	if err := os.MkdirAll(filepath.Dir(FilePath), 0755); err != nil {
+		return err
+	}
+	//O_EXCL so that a file is never overwritten, and only the file created here is removed on failure
+	File, err := os.OpenFile(FilePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0660)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(File, Content)
+	if closeErr := File.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(FilePath)
+	}
+	return err
+}
+
+//Put stores Content under Name, replacing what was stored there before.
+//The file is written next to its final path and only renamed into place once complete, so readers never see a partial file
+func (Store *LocalBlobPlugin) Put(ctx context.Context, Name string, Content io.Reader, Size int64) error {
+	FilePath := Store.LocalPath(Name)
+	if err := os.MkdirAll(filepath.Dir(FilePath), 0755); err != nil {
+		return err
+	}
+	File, err := os.CreateTemp(filepath.Dir(FilePath), "."+filepath.Base(FilePath)+".partial-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(File.Name()) //Does nothing once renamed
+	_, err = io.Copy(File, Content)
+	if err == nil {
+		err = File.Chmod(0660)
+	}
+	if closeErr := File.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(File.Name(), FilePath)
+}
+
+//Open returns the file Name is stored in
+func (Store *LocalBlobPlugin) Open(ctx context.Context, Name string) (io.ReadSeekCloser, error) {
+	return os.Open(Store.LocalPath(Name))
+}
+
+//Stat returns information about the file Name is stored in
+func (Store *LocalBlobPlugin) Stat(ctx context.Context, Name string) (interfaces.BlobInfo, error) {
+	info, err := os.Stat(Store.LocalPath(Name))
+	if err != nil {
+		return interfaces.BlobInfo{}, err
+	}
+	if info.IsDir() {
+		return interfaces.BlobInfo{}, &fs.PathError{Op: "stat", Path: Store.LocalPath(Name), Err: fs.ErrNotExist}
+	}
+	return interfaces.BlobInfo{Name: Name, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+//Delete removes the file Name is stored in, if there is one
+func (Store *LocalBlobPlugin) Delete(ctx context.Context, Name string) error {
+	if err := os.Remove(Store.LocalPath(Name)); err != nil && errors.Is(err, fs.ErrNotExist) == false {
+		return err
+	}
+	return nil
+}
+
+//Rename moves the file OldName is stored in to NewName
+func (Store *LocalBlobPlugin) Rename(ctx context.Context, OldName string, NewName string) error {
+	NewPath := Store.LocalPath(NewName)
+	if err := os.MkdirAll(filepath.Dir(NewPath), 0755); err != nil {
+		return err
+	}
+	return os.Rename(Store.LocalPath(OldName), NewPath)
+}
+
+//List calls Visit for every file in ImageDirectory whose name starts with Prefix
+func (Store *LocalBlobPlugin) List(ctx context.Context, Prefix string, Visit func(Blob interfaces.BlobInfo) error) error {
+	return filepath.WalkDir(Store.root, func(FilePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		Relative, err := filepath.Rel(Store.root, FilePath)
+		if err != nil {
+			return err
+		}
+		Name := filepath.ToSlash(Relative)
+		if entry.IsDir() {
+			//Skip directories that cannot hold a match
+			if Name != "." && strings.HasPrefix(Name+"/", Prefix) == false && strings.HasPrefix(Prefix, Name+"/") == false {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(Name, Prefix) == false {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return Visit(interfaces.BlobInfo{Name: Name, Size: info.Size(), ModTime: info.ModTime()})
+	})
+}
+
+//ServeBlob replies to request with the file Name is stored in
+func (Store *LocalBlobPlugin) ServeBlob(responseWriter http.ResponseWriter, request *http.Request, Name string) {
+	http.ServeFile(responseWriter, request, Store.LocalPath(Name))
+}
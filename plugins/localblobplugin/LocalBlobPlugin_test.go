@@ -0,0 +1,27 @@
+package localblobplugin
+
+import (
+	"go-image-board/config"
+	"go-image-board/plugins/blobconformance"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	config.Configuration.ImageDirectory = t.TempDir()
+	Store := &LocalBlobPlugin{}
+	if err := Store.Init(t.Context()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	blobconformance.RunSuite(t, Store)
+}
+
+func TestLocalPathStaysInImageDirectory(t *testing.T) {
+	Store := &LocalBlobPlugin{root: filepath.Join("srv", "images")}
+	for _, Name := range []string{"../secret", "thumbs/../../secret", "/secret"} {
+		if Got := Store.LocalPath(Name); strings.HasPrefix(Got, Store.root+string(filepath.Separator)) == false {
+			t.Errorf("LocalPath(%q) = %q, want a path inside %q", Name, Got, Store.root)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package mariadbplugin
 
 import (
+	"context"
 	"errors"
 	"go-image-board/interfaces"
 	"go-image-board/logging"
@@ -13,10 +14,10 @@ import (
 )
 
 //CreateUser is used to create and add a user to the AuthN database (return nil on success)
-func (DBConnection *MariaDBPlugin) CreateUser(userName string, password []byte, email string, permissions uint64) error {
+func (DBConnection *MariaDBPlugin) CreateUser(ctx context.Context, userName string, password []byte, email string, permissions uint64) error {
 	//Validate User does not exist
 	var userCount int
-	row := DBConnection.DBHandle.QueryRow("SELECT COUNT(*) AS UserCount FROM Users WHERE Name = ? OR EMail = ?", userName, email)
+	row := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT COUNT(*) AS UserCount FROM Users WHERE Name = ? OR EMail = ?", userName, email)
 	if err := row.Scan(&userCount); err != nil {
 		return err
 	}
@@ -30,7 +31,7 @@ func (DBConnection *MariaDBPlugin) CreateUser(userName string, password []byte,
 	if err != nil {
 		return errors.New("Error with user password")
 	}
-	_, err = DBConnection.DBHandle.Exec("INSERT INTO Users (Name, EMail, PasswordHash, Permissions) VALUES (?, ?, ?, ?);", userName, email, string(hash), permissions)
+	_, err = DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO Users (Name, EMail, PasswordHash, Permissions) VALUES (?, ?, ?, ?);", userName, email, string(hash), permissions)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/CreateUser", userName, logging.ResultFailure, []string{"Failed to create new user", err.Error()})
 	}
@@ -39,10 +40,10 @@ func (DBConnection *MariaDBPlugin) CreateUser(userName string, password []byte,
 }
 
 //ValidateUser Validate a user's password (return nil if valid)
-func (DBConnection *MariaDBPlugin) ValidateUser(userName string, password []byte) error {
+func (DBConnection *MariaDBPlugin) ValidateUser(ctx context.Context, userName string, password []byte) error {
 	var userPassword string
 	var userDisabled bool
-	row := DBConnection.DBHandle.QueryRow("SELECT PasswordHash, Disabled FROM Users WHERE Name = ?", userName)
+	row := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT PasswordHash, Disabled FROM Users WHERE Name = ?", userName)
 	err := row.Scan(&userPassword, &userDisabled)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/ValidateUser", userName, logging.ResultFailure, []string{"Username and Password not correct", userName, err.Error()})
@@ -61,9 +62,9 @@ func (DBConnection *MariaDBPlugin) ValidateUser(userName string, password []byte
 }
 
 //GetUserID returns a user's DBID for association with other db elements
-func (DBConnection *MariaDBPlugin) GetUserID(userName string) (uint64, error) {
+func (DBConnection *MariaDBPlugin) GetUserID(ctx context.Context, userName string) (uint64, error) {
 	var userID uint64
-	row := DBConnection.DBHandle.QueryRow("SELECT ID FROM Users WHERE Name = ?", userName)
+	row := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT ID FROM Users WHERE Name = ?", userName)
 	err := row.Scan(&userID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/GetUserID", userName, logging.ResultFailure, []string{"Username does not exist", userName})
@@ -73,9 +74,9 @@ func (DBConnection *MariaDBPlugin) GetUserID(userName string) (uint64, error) {
 }
 
 //GetUserPermissionSet returns a UserPermission object representing a user's intended access
-func (DBConnection *MariaDBPlugin) GetUserPermissionSet(userName string) (interfaces.UserPermission, error) {
+func (DBConnection *MariaDBPlugin) GetUserPermissionSet(ctx context.Context, userName string) (interfaces.UserPermission, error) {
 	var userPermission uint64
-	row := DBConnection.DBHandle.QueryRow("SELECT Permissions FROM Users WHERE Name = ?", userName)
+	row := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT Permissions FROM Users WHERE Name = ?", userName)
 	err := row.Scan(&userPermission)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/GetUserID", userName, logging.ResultFailure, []string{"Username does not exist", userName})
@@ -85,33 +86,33 @@ func (DBConnection *MariaDBPlugin) GetUserPermissionSet(userName string) (interf
 }
 
 //SetUserPermissionSet sets a user's permission in the database
-func (DBConnection *MariaDBPlugin) SetUserPermissionSet(userID uint64, permissions uint64) error {
-	_, err := DBConnection.DBHandle.Exec("UPDATE Users SET Permissions=? WHERE ID=?", permissions, userID)
+func (DBConnection *MariaDBPlugin) SetUserPermissionSet(ctx context.Context, userID uint64, permissions uint64) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Users SET Permissions=? WHERE ID=?", permissions, userID)
 	return err
 }
 
 //SetUserDisableState disables or enables a user account
-func (DBConnection *MariaDBPlugin) SetUserDisableState(userID uint64, isDisabled bool) error {
-	_, err := DBConnection.DBHandle.Exec("UPDATE Users SET Disabled=? WHERE ID=?", isDisabled, userID)
+func (DBConnection *MariaDBPlugin) SetUserDisableState(ctx context.Context, userID uint64, isDisabled bool) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Users SET Disabled=? WHERE ID=?", isDisabled, userID)
 	return err
 }
 
 //SetUserQueryTags sets a user's global filter
-func (DBConnection *MariaDBPlugin) SetUserQueryTags(UserID uint64, Filter string) error {
-	_, err := DBConnection.DBHandle.Exec("UPDATE Users SET SearchFilter=? WHERE ID=?", Filter, UserID)
+func (DBConnection *MariaDBPlugin) SetUserQueryTags(ctx context.Context, UserID uint64, Filter string) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Users SET SearchFilter=? WHERE ID=?", Filter, UserID)
 	return err
 }
 
 //SetUserPassword Update a user's password, validation of user provided by either old password, or security answers. (nil on success)
-func (DBConnection *MariaDBPlugin) SetUserPassword(userName string, password []byte, newPassword []byte, answerOne []byte, answerTwo []byte, answerThree []byte, force bool) error {
+func (DBConnection *MariaDBPlugin) SetUserPassword(ctx context.Context, userName string, password []byte, newPassword []byte, answerOne []byte, answerTwo []byte, answerThree []byte, force bool) error {
 	if !force {
 		//Validate authentication method
 		if password == nil {
-			if err := DBConnection.ValidateSecurityQuestions(userName, answerOne, answerTwo, answerThree); err != nil {
+			if err := DBConnection.ValidateSecurityQuestions(ctx, userName, answerOne, answerTwo, answerThree); err != nil {
 				//Need to use security question method
 				return err
 			}
-		} else if err := DBConnection.ValidateUser(userName, password); err != nil {
+		} else if err := DBConnection.ValidateUser(ctx, userName, password); err != nil {
 			//Otherwise, utilize classic password
 			return err
 		}
@@ -128,13 +129,13 @@ func (DBConnection *MariaDBPlugin) SetUserPassword(userName string, password []b
 		return err
 	}
 
-	_, err = DBConnection.DBHandle.Exec("UPDATE Users SET PasswordHash=? WHERE Name = ?", string(newPasswordHash), userName)
+	_, err = DBConnection.DBHandle.ExecContext(ctx, "UPDATE Users SET PasswordHash=? WHERE Name = ?", string(newPasswordHash), userName)
 	return err
 }
 
 //RemoveUser Removes a user from the database (nil on success)
-func (DBConnection *MariaDBPlugin) RemoveUser(userName string) error {
-	_, err := DBConnection.DBHandle.Exec("DELETE FROM Users WHERE Name = ?", userName)
+func (DBConnection *MariaDBPlugin) RemoveUser(ctx context.Context, userName string) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM Users WHERE Name = ?", userName)
 	if err == nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/RemoveUser", userName, logging.ResultSuccess, []string{"User removed", userName})
 	} else {
@@ -171,9 +172,9 @@ func (DBConnection *MariaDBPlugin) ValidateProposedUsername(UserName string) err
 }
 
 //GetUserFilter returns the raw string of the user's filter
-func (DBConnection *MariaDBPlugin) GetUserFilter(UserID uint64) (string, error) {
+func (DBConnection *MariaDBPlugin) GetUserFilter(ctx context.Context, UserID uint64) (string, error) {
 	var userFilter string
-	err := DBConnection.DBHandle.QueryRow("SELECT SearchFilter FROM Users WHERE ID = ?", UserID).Scan(&userFilter)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT SearchFilter FROM Users WHERE ID = ?", UserID).Scan(&userFilter)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/GetUserQueryTags", "0", logging.ResultFailure, []string{"Failed to get user filter", err.Error()})
 	}
@@ -181,7 +182,7 @@ func (DBConnection *MariaDBPlugin) GetUserFilter(UserID uint64) (string, error)
 }
 
 //SearchUsers performs a search for users (Returns a list of UserInfos, or error)
-func (DBConnection *MariaDBPlugin) SearchUsers(searchString string, PageStart uint64, PageStride uint64) ([]interfaces.UserInformation, uint64, error) {
+func (DBConnection *MariaDBPlugin) SearchUsers(ctx context.Context, searchString string, PageStart uint64, PageStride uint64) ([]interfaces.UserInformation, uint64, error) {
 	var ToReturn []interfaces.UserInformation
 	searchString = strings.TrimSpace(searchString)
 	searchString = strings.Replace(searchString, "%", "", -1)
@@ -199,7 +200,7 @@ func (DBConnection *MariaDBPlugin) SearchUsers(searchString string, PageStart ui
 	//Query Count
 	//Run the count query (Count query does not use start/stride, so run this before we add those)
 	var MaxResults uint64
-	err := DBConnection.DBHandle.QueryRow(sqlCountQuery, queryArray...).Scan(&MaxResults)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlCountQuery, queryArray...).Scan(&MaxResults)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/SearchUsers", "0", logging.ResultFailure, []string{"Error running search query", sqlCountQuery, err.Error()})
 		return nil, 0, err
@@ -212,7 +213,7 @@ func (DBConnection *MariaDBPlugin) SearchUsers(searchString string, PageStart ui
 	}
 
 	//First Query the main information
-	rows, err := DBConnection.DBHandle.Query(sqlQuery, queryArray...)
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, sqlQuery, queryArray...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -242,7 +243,7 @@ func (DBConnection *MariaDBPlugin) SearchUsers(searchString string, PageStart ui
 }
 
 //GetUser returns a UserInformation object for the user with the specified ID
-func (DBConnection *MariaDBPlugin) GetUser(UserID uint64) (interfaces.UserInformation, error) {
+func (DBConnection *MariaDBPlugin) GetUser(ctx context.Context, UserID uint64) (interfaces.UserInformation, error) {
 	queryArray := []interface{}{}
 	sqlQuery := "SELECT Name, CreationTime, Disabled, Permissions FROM Users WHERE ID = ?"
 	queryArray = append(queryArray, UserID)
@@ -253,7 +254,7 @@ func (DBConnection *MariaDBPlugin) GetUser(UserID uint64) (interfaces.UserInform
 	var CreationTime time.Time
 	var Disabled bool
 	var Permissions uint64
-	err := DBConnection.DBHandle.QueryRow(sqlQuery, queryArray...).Scan(&Name, &NCreationTime, &Disabled, &Permissions)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlQuery, queryArray...).Scan(&Name, &NCreationTime, &Disabled, &Permissions)
 	if err != nil {
 		return interfaces.UserInformation{}, err
 	}
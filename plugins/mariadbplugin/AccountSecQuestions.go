@@ -1,6 +1,7 @@
 package mariadbplugin
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"go-image-board/logging"
@@ -9,7 +10,7 @@ import (
 )
 
 //SetSecurityQuestions changes a user's security questions (nil if success)
-func (DBConnection *MariaDBPlugin) SetSecurityQuestions(userName string, questionOne string, questionTwo string, questionThree string, answerOne []byte, answerTwo []byte, answerThree []byte, challengeAnswer []byte) error {
+func (DBConnection *MariaDBPlugin) SetSecurityQuestions(ctx context.Context, userName string, questionOne string, questionTwo string, questionThree string, answerOne []byte, answerTwo []byte, answerThree []byte, challengeAnswer []byte) error {
 	answerOneHash, errA := getPasswordHash(answerOne)
 	answerTwoHash, errB := getPasswordHash(answerTwo)
 	answerThreeHash, errC := getPasswordHash(answerThree)
@@ -22,7 +23,7 @@ func (DBConnection *MariaDBPlugin) SetSecurityQuestions(userName string, questio
 	//Grab pre-existing first quesion, if needed
 	var secQuestionOne sql.NullString
 	var secAnswerOne sql.NullString
-	err := DBConnection.DBHandle.QueryRow("SELECT SecQuestionOne, SecAnswerOne FROM Users WHERE Name = ?", userName).Scan(&secQuestionOne, &secAnswerOne)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT SecQuestionOne, SecAnswerOne FROM Users WHERE Name = ?", userName).Scan(&secQuestionOne, &secAnswerOne)
 	//If question one is set
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/SetSecurityQuestions", userName, logging.ResultFailure, []string{"Security questions failed to update. Challenge could not be loaded SQL Error.", userName, err.Error()})
@@ -37,7 +38,7 @@ func (DBConnection *MariaDBPlugin) SetSecurityQuestions(userName string, questio
 		}
 	}
 
-	_, err = DBConnection.DBHandle.Exec("UPDATE Users SET SecQuestionOne=?, SecQuestionTwo=?, SecQuestionThree=?, SecAnswerOne=?, SecAnswerTwo=?, SecAnswerThree=? WHERE Name = ? AND Disabled = FALSE", questionOne, questionTwo, questionThree, string(answerOneHash), string(answerTwoHash), string(answerThreeHash), userName)
+	_, err = DBConnection.DBHandle.ExecContext(ctx, "UPDATE Users SET SecQuestionOne=?, SecQuestionTwo=?, SecQuestionThree=?, SecAnswerOne=?, SecAnswerTwo=?, SecAnswerThree=? WHERE Name = ? AND Disabled = FALSE", questionOne, questionTwo, questionThree, string(answerOneHash), string(answerTwoHash), string(answerThreeHash), userName)
 	if err == nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/SetSecurityQuestions", userName, logging.ResultSuccess, []string{"Security questions updated!", userName})
 	} else {
@@ -47,7 +48,7 @@ func (DBConnection *MariaDBPlugin) SetSecurityQuestions(userName string, questio
 }
 
 //ValidateSecurityQuestions Validates answers against a user's security questions (nil on success)
-func (DBConnection *MariaDBPlugin) ValidateSecurityQuestions(userName string, answerOne []byte, answerTwo []byte, answerThree []byte) error {
+func (DBConnection *MariaDBPlugin) ValidateSecurityQuestions(ctx context.Context, userName string, answerOne []byte, answerTwo []byte, answerThree []byte) error {
 	//Ensure answers have values
 	if answerOne == nil || answerTwo == nil || answerThree == nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/ValidateSecurityQuestions", userName, logging.ResultFailure, []string{"No answers?", userName})
@@ -55,7 +56,7 @@ func (DBConnection *MariaDBPlugin) ValidateSecurityQuestions(userName string, an
 	}
 
 	//Ensure Questions Exist
-	secQuestionOne, secQuestionTwo, secQuestionThree, err := DBConnection.GetSecurityQuestions(userName)
+	secQuestionOne, secQuestionTwo, secQuestionThree, err := DBConnection.GetSecurityQuestions(ctx, userName)
 	if err != nil || secQuestionOne == "" || secQuestionTwo == "" || secQuestionThree == "" {
 
 		if err != nil {
@@ -70,7 +71,7 @@ func (DBConnection *MariaDBPlugin) ValidateSecurityQuestions(userName string, an
 	var secAnswerTwo sql.NullString
 	var secAnswerThree sql.NullString
 
-	row := DBConnection.DBHandle.QueryRow("SELECT SecAnswerOne, SecAnswerTwo, SecAnswerThree FROM Users WHERE Name = ?", userName)
+	row := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT SecAnswerOne, SecAnswerTwo, SecAnswerThree FROM Users WHERE Name = ?", userName)
 	err = row.Scan(&secAnswerOne, &secAnswerTwo, &secAnswerThree)
 	if err != nil {
 		return err
@@ -99,11 +100,11 @@ func (DBConnection *MariaDBPlugin) ValidateSecurityQuestions(userName string, an
 }
 
 //GetSecurityQuestions returns the three questions, first, second, third, and an error if an issue occured
-func (DBConnection *MariaDBPlugin) GetSecurityQuestions(userName string) (string, string, string, error) {
+func (DBConnection *MariaDBPlugin) GetSecurityQuestions(ctx context.Context, userName string) (string, string, string, error) {
 	var secQuestionOne sql.NullString
 	var secQuestionTwo sql.NullString
 	var secQuestionThree sql.NullString
-	row := DBConnection.DBHandle.QueryRow("SELECT SecQuestionOne, SecQuestionTwo, SecQuestionThree FROM Users WHERE Name = ?", userName)
+	row := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT SecQuestionOne, SecQuestionTwo, SecQuestionThree FROM Users WHERE Name = ?", userName)
 	err := row.Scan(&secQuestionOne, &secQuestionTwo, &secQuestionThree)
 	if err != nil {
 		return "", "", "", err
@@ -2,6 +2,7 @@ package mariadbplugin
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"errors"
 	"go-image-board/logging"
@@ -10,11 +11,11 @@ import (
 )
 
 //ValidateToken Validate a cookie token (true if valid cookie, false otherwise, error for reason or nil)
-func (DBConnection *MariaDBPlugin) ValidateToken(userName string, tokenID string, ip string) error {
+func (DBConnection *MariaDBPlugin) ValidateToken(ctx context.Context, userName string, tokenID string, ip string) error {
 	var validTokenID sql.NullString
 	var validTokenIP sql.NullString
 	var userDisabled bool
-	row := DBConnection.DBHandle.QueryRow("SELECT TokenID, IP, Disabled FROM Users WHERE Name = ?", userName)
+	row := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT TokenID, IP, Disabled FROM Users WHERE Name = ?", userName)
 	err := row.Scan(&validTokenID, &validTokenIP, &userDisabled)
 	if userDisabled {
 		return errors.New("Account disabled")
@@ -48,9 +49,9 @@ func (DBConnection *MariaDBPlugin) ValidateToken(userName string, tokenID string
 }
 
 //GenerateToken Generate a cookie token (string token, or error)
-func (DBConnection *MariaDBPlugin) GenerateToken(userName string, ip string) (string, error) {
+func (DBConnection *MariaDBPlugin) GenerateToken(ctx context.Context, userName string, ip string) (string, error) {
 	newToken := uuid.NewV4()
-	_, err := DBConnection.DBHandle.Exec("UPDATE Users SET TokenID=?, IP=? WHERE Name = ?", newToken.String(), ip, userName)
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Users SET TokenID=?, IP=? WHERE Name = ?", newToken.String(), ip, userName)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/GenerateToken", userName, logging.ResultFailure, []string{"Failed to save token", userName, ip, err.Error()})
 		return "", errors.New("failed to generate a token, check if user exists")
@@ -59,8 +60,8 @@ func (DBConnection *MariaDBPlugin) GenerateToken(userName string, ip string) (st
 }
 
 //RevokeToken Revokes a token (nil on success)
-func (DBConnection *MariaDBPlugin) RevokeToken(userName string) error {
-	_, err := DBConnection.DBHandle.Exec("UPDATE Users SET TokenID=NULL, IP=NULL WHERE Name = ?", userName)
+func (DBConnection *MariaDBPlugin) RevokeToken(ctx context.Context, userName string) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Users SET TokenID=NULL, IP=NULL WHERE Name = ?", userName)
 	if err == nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/RevokeToken", userName, logging.ResultSuccess, []string{"Token revoked!", userName})
 	} else {
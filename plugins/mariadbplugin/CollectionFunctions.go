@@ -1,6 +1,7 @@
 package mariadbplugin
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"go-image-board/interfaces"
@@ -14,13 +15,13 @@ import (
 //--Collections
 
 //NewCollection adds a collection with the provided information
-func (DBConnection *MariaDBPlugin) NewCollection(Name string, Description string, UploaderID uint64) (uint64, error) {
+func (DBConnection *MariaDBPlugin) NewCollection(ctx context.Context, Name string, Description string, UploaderID uint64) (uint64, error) {
 	if len(Name) < 3 || len(Name) > 255 || len(Description) > 255 {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/NewCollection", strconv.FormatUint(UploaderID, 10), logging.ResultFailure, []string{"Failed to add collection due to name/description size", Name, Description})
 		return 0, errors.New("name or description outside size range")
 	}
 
-	resultInfo, err := DBConnection.DBHandle.Exec("INSERT INTO Collections (Name, Description, UploaderID) VALUES (?, ?, ?);", Name, Description, UploaderID)
+	resultInfo, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO Collections (Name, Description, UploaderID) VALUES (?, ?, ?);", Name, Description, UploaderID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/NewCollection", strconv.FormatUint(UploaderID, 10), logging.ResultFailure, []string{"Failed to add collection", err.Error()})
 		return 0, err
@@ -31,16 +32,16 @@ func (DBConnection *MariaDBPlugin) NewCollection(Name string, Description string
 }
 
 //DeleteCollection removes a collection
-func (DBConnection *MariaDBPlugin) DeleteCollection(CollectionID uint64) error {
+func (DBConnection *MariaDBPlugin) DeleteCollection(ctx context.Context, CollectionID uint64) error {
 	//Ensure not in use
-	_, err := DBConnection.DBHandle.Exec("DELETE FROM CollectionMembers WHERE CollectionID=?;", CollectionID)
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM CollectionMembers WHERE CollectionID=?;", CollectionID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/DeleteCollection", "0", logging.ResultFailure, []string{"Colleciton to delete is still in use and members could not be removed", strconv.FormatUint(CollectionID, 10)})
 		return errors.New("could not remove members from collection before deleting collection")
 	}
 
 	//Delete
-	_, err = DBConnection.DBHandle.Exec("DELETE FROM Collections WHERE ID=?;", CollectionID)
+	_, err = DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM Collections WHERE ID=?;", CollectionID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/DeleteCollection", "0", logging.ResultFailure, []string{"Failed to delete collection", err.Error(), strconv.FormatUint(CollectionID, 10)})
 	} else {
@@ -50,14 +51,14 @@ func (DBConnection *MariaDBPlugin) DeleteCollection(CollectionID uint64) error {
 }
 
 //UpdateCollection updates a pre-existing collection
-func (DBConnection *MariaDBPlugin) UpdateCollection(CollectionID uint64, Name string, Description string) error {
+func (DBConnection *MariaDBPlugin) UpdateCollection(ctx context.Context, CollectionID uint64, Name string, Description string) error {
 	//Cleanup name
 	if len(Name) < 3 || len(Name) > 255 || len(Description) > 255 {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateCollection", "0", logging.ResultFailure, []string{"Failed to update collection due to size of name/description", Name, Description})
 		return errors.New("name or description outside of right sizes")
 	}
 
-	_, err := DBConnection.DBHandle.Exec("UPDATE Collections SET Name = ?, Description=? WHERE ID=?;", Name, Description, CollectionID)
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Collections SET Name = ?, Description=? WHERE ID=?;", Name, Description, CollectionID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateCollection", "0", logging.ResultFailure, []string{"Failed to update collection", err.Error()})
 		return err
@@ -67,7 +68,7 @@ func (DBConnection *MariaDBPlugin) UpdateCollection(CollectionID uint64, Name st
 }
 
 //GetCollections returns a list of all collections, but only the ID, Name, Description
-func (DBConnection *MariaDBPlugin) GetCollections(PageStart uint64, PageStride uint64) ([]interfaces.CollectionInformation, uint64, error) {
+func (DBConnection *MariaDBPlugin) GetCollections(ctx context.Context, PageStart uint64, PageStride uint64) ([]interfaces.CollectionInformation, uint64, error) {
 	var ToReturn []interfaces.CollectionInformation
 
 	sqlQuery := `SELECT CL.ID, CL.Name, CL.Description, IFNULL(Location, "") AS Location, IFNULL(Counts.Members,0) as Members
@@ -75,15 +76,15 @@ func (DBConnection *MariaDBPlugin) GetCollections(PageStart uint64, PageStride u
 	-- This part gets the number of members in a collection
 	LEFT JOIN (
 		SELECT CollectionID, Count(*) as Members
-		FROM CollectionMembers
+		FROM VisibleCollectionMembers
 		GROUP BY CollectionID
 	) Counts ON Counts.CollectionID = CL.ID
 	-- This part gets a preview image location
 	LEFT JOIN (
 		SELECT CM.CollectionID as CollectionID, Images.Location as Location
-		FROM CollectionMembers as CM
+		FROM VisibleCollectionMembers as CM
 		INNER JOIN Images on Images.ID = CM.ImageID
-		WHERE OrderWeight = (SELECT MIN(OrderWeight) From CollectionMembers WHERE CollectionMembers.CollectionID = CM.CollectionID)
+		WHERE OrderWeight = (SELECT MIN(OrderWeight) From VisibleCollectionMembers WHERE VisibleCollectionMembers.CollectionID = CM.CollectionID)
 	) Preview ON Preview.CollectionID = CL.ID
 	ORDER BY Name
 	LIMIT ? OFFSET ?;`
@@ -92,14 +93,14 @@ func (DBConnection *MariaDBPlugin) GetCollections(PageStart uint64, PageStride u
 	//Get Count query
 	var MaxResults uint64
 	//Run the count query (Count query does not use start/stride)
-	err := DBConnection.DBHandle.QueryRow(sqlCountQuery).Scan(&MaxResults)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlCountQuery).Scan(&MaxResults)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/GetCollections", "0", logging.ResultFailure, []string{"Error running count query", sqlCountQuery, err.Error()})
 		return nil, 0, err
 	}
 
 	//Pass the sql query to DB
-	rows, err := DBConnection.DBHandle.Query(sqlQuery, PageStride, PageStart)
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, sqlQuery, PageStride, PageStart)
 	if err != nil {
 		return nil, MaxResults, err
 	}
@@ -129,7 +130,7 @@ func (DBConnection *MariaDBPlugin) GetCollections(PageStart uint64, PageStride u
 }
 
 //GetCollection returns detailed information on one collection
-func (DBConnection *MariaDBPlugin) GetCollection(ID uint64) (interfaces.CollectionInformation, error) {
+func (DBConnection *MariaDBPlugin) GetCollection(ctx context.Context, ID uint64) (interfaces.CollectionInformation, error) {
 	sqlQuery := "SELECT Name, Description, UploaderID, UploadTime FROM Collections WHERE ID=?"
 	//Pass the sql query to DB
 	//Placeholders for data returned by each row
@@ -138,12 +139,12 @@ func (DBConnection *MariaDBPlugin) GetCollection(ID uint64) (interfaces.Collecti
 	var UploaderID uint64
 	var NUploadTime mysql.NullTime
 	var UploadTime time.Time
-	if err := DBConnection.DBHandle.QueryRow(sqlQuery, ID).Scan(&Name, &Description, &UploaderID, &NUploadTime); err != nil {
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, sqlQuery, ID).Scan(&Name, &Description, &UploaderID, &NUploadTime); err != nil {
 		return interfaces.CollectionInformation{}, err
 	}
 
 	var MemberCount uint64
-	if err := DBConnection.DBHandle.QueryRow("SELECT COUNT(*) FROM CollectionMembers WHERE CollectionID=?", ID).Scan(&MemberCount); err != nil {
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT COUNT(*) FROM VisibleCollectionMembers WHERE CollectionID=?", ID).Scan(&MemberCount); err != nil {
 		return interfaces.CollectionInformation{}, err
 	}
 
@@ -161,7 +162,7 @@ func (DBConnection *MariaDBPlugin) GetCollection(ID uint64) (interfaces.Collecti
 }
 
 //GetCollectionByName returns detailed information on one collection
-func (DBConnection *MariaDBPlugin) GetCollectionByName(Name string) (interfaces.CollectionInformation, error) {
+func (DBConnection *MariaDBPlugin) GetCollectionByName(ctx context.Context, Name string) (interfaces.CollectionInformation, error) {
 	sqlQuery := "SELECT ID, Name, Description, UploaderID, UploadTime FROM Collections WHERE Name=?"
 	//Pass the sql query to DB
 	//Placeholders for data returned by each row
@@ -170,12 +171,12 @@ func (DBConnection *MariaDBPlugin) GetCollectionByName(Name string) (interfaces.
 	var UploaderID uint64
 	var NUploadTime mysql.NullTime
 	var UploadTime time.Time
-	if err := DBConnection.DBHandle.QueryRow(sqlQuery, Name).Scan(&CollectionID, &Name, &Description, &UploaderID, &NUploadTime); err != nil {
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, sqlQuery, Name).Scan(&CollectionID, &Name, &Description, &UploaderID, &NUploadTime); err != nil {
 		return interfaces.CollectionInformation{}, err
 	}
 
 	var MemberCount uint64
-	if err := DBConnection.DBHandle.QueryRow("SELECT COUNT(*) FROM CollectionMembers WHERE CollectionID=?", CollectionID).Scan(&MemberCount); err != nil {
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT COUNT(*) FROM VisibleCollectionMembers WHERE CollectionID=?", CollectionID).Scan(&MemberCount); err != nil {
 		return interfaces.CollectionInformation{}, err
 	}
 
@@ -195,14 +196,14 @@ func (DBConnection *MariaDBPlugin) GetCollectionByName(Name string) (interfaces.
 //--Collection Members
 
 //AddCollectionMember adds an image to a collection
-func (DBConnection *MariaDBPlugin) AddCollectionMember(CollectionID uint64, ImageIDs []uint64, LinkerID uint64) error {
+func (DBConnection *MariaDBPlugin) AddCollectionMember(ctx context.Context, CollectionID uint64, ImageIDs []uint64, LinkerID uint64) error {
 	if len(ImageIDs) == 0 {
 		return errors.New("ImageIDs required")
 	}
 	//Get last order
 	lastOrder := uint64(0)
 	memberCount := uint64(0)
-	if err := DBConnection.DBHandle.QueryRow("SELECT IFNULL(MAX(OrderWeight),0) AS LastWeight, COUNT(*) AS MemberCount FROM CollectionMembers WHERE CollectionID = ?", CollectionID).Scan(&lastOrder, &memberCount); err != nil {
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT IFNULL(MAX(OrderWeight),0) AS LastWeight, COUNT(*) AS MemberCount FROM CollectionMembers WHERE CollectionID = ?", CollectionID).Scan(&lastOrder, &memberCount); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/AddCollectionMember", strconv.FormatUint(LinkerID, 10), logging.ResultFailure, []string{"Could not get count of members in collection", strconv.FormatUint(CollectionID, 10)})
 		return errors.New("could not get count of members in collection")
 	}
@@ -227,7 +228,7 @@ func (DBConnection *MariaDBPlugin) AddCollectionMember(CollectionID uint64, Imag
 
 	//Add image
 	sqlQuery := "INSERT INTO CollectionMembers (CollectionID, ImageID, LinkerID, OrderWeight) VALUES" + values
-	if _, err := DBConnection.DBHandle.Exec(sqlQuery, queryArray...); err != nil {
+	if _, err := DBConnection.DBHandle.ExecContext(ctx, sqlQuery, queryArray...); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/AddCollectionMember", strconv.FormatUint(LinkerID, 10), logging.ResultFailure, []string{"Image not added to collection", strconv.FormatUint(CollectionID, 10), idString, err.Error()})
 		return err
 	}
@@ -236,32 +237,32 @@ func (DBConnection *MariaDBPlugin) AddCollectionMember(CollectionID uint64, Imag
 }
 
 //RemoveCollectionMember removes an image from collection
-func (DBConnection *MariaDBPlugin) RemoveCollectionMember(CollectionID uint64, ImageID uint64) error {
+func (DBConnection *MariaDBPlugin) RemoveCollectionMember(ctx context.Context, CollectionID uint64, ImageID uint64) error {
 	//Get Order
 	var Order uint64
-	if err := DBConnection.DBHandle.QueryRow("SELECT OrderWeight FROM CollectionMembers WHERE ImageID=? AND CollectionID=?", ImageID, CollectionID).Scan(&Order); err != nil {
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT OrderWeight FROM CollectionMembers WHERE ImageID=? AND CollectionID=?", ImageID, CollectionID).Scan(&Order); err != nil {
 		return err
 	}
 
 	var Members uint64
-	if err := DBConnection.DBHandle.QueryRow("SELECT Count(*) FROM CollectionMembers WHERE CollectionID=?", CollectionID).Scan(&Members); err != nil {
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT Count(*) FROM CollectionMembers WHERE CollectionID=?", CollectionID).Scan(&Members); err != nil {
 		return err
 	}
 
 	//If last member of collection, just delete it instead
 	if Members <= 1 {
-		return DBConnection.DeleteCollection(CollectionID)
+		return DBConnection.DeleteCollection(ctx, CollectionID)
 	}
 
 	//Delete Image
-	if _, err := DBConnection.DBHandle.Exec("DELETE FROM CollectionMembers WHERE CollectionID =? AND ImageID = ?;", CollectionID, ImageID); err != nil {
+	if _, err := DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM CollectionMembers WHERE CollectionID =? AND ImageID = ?;", CollectionID, ImageID); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/RemoveCollectionMember", "0", logging.ResultFailure, []string{"Image not removed from collection", strconv.FormatUint(CollectionID, 10), strconv.FormatUint(ImageID, 10), err.Error()})
 		return err
 	}
 	logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/RemoveCollectionMember", "0", logging.ResultSuccess, []string{"Image removed from collection", strconv.FormatUint(CollectionID, 10), strconv.FormatUint(ImageID, 10)})
 
 	//Decrement Order
-	if _, err := DBConnection.DBHandle.Exec("UPDATE CollectionMembers SET OrderWeight = OrderWeight - 1 WHERE OrderWeight > ? AND CollectionID=?;", Order, CollectionID); err != nil {
+	if _, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE CollectionMembers SET OrderWeight = OrderWeight - 1 WHERE OrderWeight > ? AND CollectionID=?;", Order, CollectionID); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/RemoveCollectionMember", "0", logging.ResultFailure, []string{"Could not update Order after member removed from collection", strconv.FormatUint(CollectionID, 10), strconv.FormatUint(ImageID, 10), err.Error()})
 		return err
 	}
@@ -270,16 +271,16 @@ func (DBConnection *MariaDBPlugin) RemoveCollectionMember(CollectionID uint64, I
 }
 
 //UpdateCollectionMember updates an image's properties in a collection
-func (DBConnection *MariaDBPlugin) UpdateCollectionMember(CollectionID uint64, ImageID uint64, Order uint64) error {
+func (DBConnection *MariaDBPlugin) UpdateCollectionMember(ctx context.Context, CollectionID uint64, ImageID uint64, Order uint64) error {
 	//Get Current Order
 	var BeforeOrder uint64
-	if err := DBConnection.DBHandle.QueryRow("SELECT OrderWeight FROM CollectionMembers WHERE ImageID=? AND CollectionID=?", ImageID, CollectionID).Scan(&BeforeOrder); err != nil {
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT OrderWeight FROM CollectionMembers WHERE ImageID=? AND CollectionID=?", ImageID, CollectionID).Scan(&BeforeOrder); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateCollectionMember", "0", logging.ResultFailure, []string{"Could not get previous order to update collectionmember", strconv.FormatUint(CollectionID, 10), strconv.FormatUint(ImageID, 10), err.Error()})
 		return err
 	}
 
 	var MemberCount uint64
-	if err := DBConnection.DBHandle.QueryRow("SELECT COUNT(*) FROM CollectionMembers WHERE CollectionID=?", CollectionID).Scan(&MemberCount); err != nil {
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT COUNT(*) FROM CollectionMembers WHERE CollectionID=?", CollectionID).Scan(&MemberCount); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateCollectionMember", "0", logging.ResultFailure, []string{"Could not validate order", strconv.FormatUint(CollectionID, 10), strconv.FormatUint(ImageID, 10), err.Error()})
 		return err
 	}
@@ -290,19 +291,19 @@ func (DBConnection *MariaDBPlugin) UpdateCollectionMember(CollectionID uint64, I
 	}
 
 	//Set order for image
-	if _, err := DBConnection.DBHandle.Exec("UPDATE CollectionMembers SET OrderWeight = ? WHERE ImageID=? AND CollectionID=?;", Order, ImageID, CollectionID); err != nil {
+	if _, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE CollectionMembers SET OrderWeight = ? WHERE ImageID=? AND CollectionID=?;", Order, ImageID, CollectionID); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateCollectionMember", "0", logging.ResultFailure, []string{"Could not set Order of member in collection", strconv.FormatUint(CollectionID, 10), strconv.FormatUint(ImageID, 10), err.Error()})
 		return err
 	}
 
 	//Decrement Order
-	if _, err := DBConnection.DBHandle.Exec("UPDATE CollectionMembers SET OrderWeight = OrderWeight - 1 WHERE OrderWeight >= ? AND CollectionID=? AND ImageID<>?;", BeforeOrder, CollectionID, ImageID); err != nil {
+	if _, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE CollectionMembers SET OrderWeight = OrderWeight - 1 WHERE OrderWeight >= ? AND CollectionID=? AND ImageID<>?;", BeforeOrder, CollectionID, ImageID); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateCollectionMember", "0", logging.ResultFailure, []string{"Could not decrement Order of members in collection", strconv.FormatUint(CollectionID, 10), strconv.FormatUint(ImageID, 10), err.Error()})
 		return err
 	}
 
 	//Increment Order
-	if _, err := DBConnection.DBHandle.Exec("UPDATE CollectionMembers SET OrderWeight = OrderWeight + 1 WHERE OrderWeight >= ? AND CollectionID=? AND ImageID<>?;", Order, CollectionID, ImageID); err != nil {
+	if _, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE CollectionMembers SET OrderWeight = OrderWeight + 1 WHERE OrderWeight >= ? AND CollectionID=? AND ImageID<>?;", Order, CollectionID, ImageID); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateCollectionMember", "0", logging.ResultFailure, []string{"Could not increment Order of members in collection", strconv.FormatUint(CollectionID, 10), strconv.FormatUint(ImageID, 10), err.Error()})
 		return err
 	}
@@ -311,7 +312,7 @@ func (DBConnection *MariaDBPlugin) UpdateCollectionMember(CollectionID uint64, I
 }
 
 //GetCollectionMembers gets a list of images in a collection (Returns a list of imageIDs, or error)
-func (DBConnection *MariaDBPlugin) GetCollectionMembers(CollectionID uint64, PageStart uint64, PageStride uint64) ([]interfaces.ImageInformation, uint64, error) {
+func (DBConnection *MariaDBPlugin) GetCollectionMembers(ctx context.Context, CollectionID uint64, PageStart uint64, PageStride uint64) ([]interfaces.ImageInformation, uint64, error) {
 	//Attributes passed to SQL Query
 	queryArray := []interface{}{}
 	queryArray = append(queryArray, CollectionID)
@@ -320,7 +321,7 @@ func (DBConnection *MariaDBPlugin) GetCollectionMembers(CollectionID uint64, Pag
 	sqlQuery := `SELECT ImageID, Name, Location, OrderWeight
 	FROM Images
 	INNER JOIN CollectionMembers ON Images.ID=CollectionMembers.ImageID
-	WHERE CollectionMembers.CollectionID=?
+	WHERE CollectionMembers.CollectionID=? AND Images.DeletedTime IS NULL
 	ORDER BY CollectionMembers.OrderWeight`
 
 	//If we limited the search
@@ -334,21 +335,21 @@ func (DBConnection *MariaDBPlugin) GetCollectionMembers(CollectionID uint64, Pag
 	sqlCountQuery := `SELECT COUNT(ImageID)
 	FROM Images
 	INNER JOIN CollectionMembers ON Images.ID=CollectionMembers.ImageID
-	WHERE CollectionMembers.CollectionID=?;`
+	WHERE CollectionMembers.CollectionID=? AND Images.DeletedTime IS NULL;`
 
 	//Init Output
 	var ToReturn []interfaces.ImageInformation
 	var MaxResults uint64
 
 	//Run the count query (Count query does not use start/stride)
-	err := DBConnection.DBHandle.QueryRow(sqlCountQuery, CollectionID).Scan(&MaxResults)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlCountQuery, CollectionID).Scan(&MaxResults)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/GetCollectionMembers", "0", logging.ResultFailure, []string{"Error running count query", sqlCountQuery, err.Error()})
 		return nil, 0, err
 	}
 
 	//Now for the real query
-	rows, err := DBConnection.DBHandle.Query(sqlQuery, queryArray...)
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, sqlQuery, queryArray...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -372,21 +373,21 @@ func (DBConnection *MariaDBPlugin) GetCollectionMembers(CollectionID uint64, Pag
 }
 
 //GetCollectionsWithImage returns a slice of collections with a specific image
-func (DBConnection *MariaDBPlugin) GetCollectionsWithImage(ImageID uint64) ([]interfaces.CollectionInformation, error) {
+func (DBConnection *MariaDBPlugin) GetCollectionsWithImage(ctx context.Context, ImageID uint64) ([]interfaces.CollectionInformation, error) {
 	var ToReturn []interfaces.CollectionInformation
-	sqlQuery := `SELECT Collections.Name, Collections.Description, CollectionMembers.OrderWeight, Collections.ID, Counts.Members, IFNULL(BeforeMember.ImageID,0) as BeforeMember, IFNULL(AfterMember.ImageID,0) as AfterMember
+	sqlQuery := `SELECT Collections.Name, Collections.Description, CollectionMembers.OrderWeight, Collections.ID, IFNULL(Counts.Members,0) as Members, IFNULL(BeforeMember.ImageID,0) as BeforeMember, IFNULL(AfterMember.ImageID,0) as AfterMember
 	FROM CollectionMembers
 	INNER JOIN Collections ON Collections.ID=CollectionMembers.CollectionID
-	-- This part gets the number of members in a collection
-	INNER JOIN (
+	-- This part gets the number of members in a collection, which leaves out images in the recycle bin
+	LEFT JOIN (
 		SELECT CollectionID, Count(*) as Members
-		FROM CollectionMembers
+		FROM VisibleCollectionMembers
 		GROUP BY CollectionID
 	) Counts ON Counts.CollectionID = Collections.ID
 	-- This part gets the imageid for the previous image in collection or 0
 	LEFT JOIN (
 		SELECT IFNULL(ImageID,0) as ImageID, CollectionID
-		FROM CollectionMembers CM
+		FROM VisibleCollectionMembers CM
 		WHERE OrderWeight < (SELECT OrderWeight FROM CollectionMembers WHERE ImageID = ? AND CollectionID = CM.CollectionID)
 		ORDER BY OrderWeight DESC
 		LIMIT 0,1
@@ -394,7 +395,7 @@ func (DBConnection *MariaDBPlugin) GetCollectionsWithImage(ImageID uint64) ([]in
 	-- This part gets the imageid for the next image in collection or 0
 	LEFT JOIN (
 		SELECT IFNULL(ImageID,0) as ImageID, CollectionID
-		FROM CollectionMembers CM
+		FROM VisibleCollectionMembers CM
 		WHERE OrderWeight > (SELECT OrderWeight FROM CollectionMembers WHERE ImageID = ? AND CollectionID = CM.CollectionID)
 		ORDER BY OrderWeight
 		LIMIT 0,1
@@ -402,7 +403,7 @@ func (DBConnection *MariaDBPlugin) GetCollectionsWithImage(ImageID uint64) ([]in
 	WHERE CollectionMembers.ImageID=?`
 
 	//First Query the main information
-	rows, err := DBConnection.DBHandle.Query(sqlQuery, ImageID, ImageID, ImageID)
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, sqlQuery, ImageID, ImageID, ImageID)
 	if err != nil {
 		return nil, err
 	}
@@ -430,11 +431,11 @@ func (DBConnection *MariaDBPlugin) GetCollectionsWithImage(ImageID uint64) ([]in
 }
 
 //GetCollectionTags returns a list of TagInformation for all tags that apply to the given collection
-func (DBConnection *MariaDBPlugin) GetCollectionTags(CollectionID uint64) ([]interfaces.TagInformation, error) {
+func (DBConnection *MariaDBPlugin) GetCollectionTags(ctx context.Context, CollectionID uint64) ([]interfaces.TagInformation, error) {
 	var ToReturn []interfaces.TagInformation
 	sqlQuery := "SELECT Tags.ID, Tags.Name, Tags.Description FROM CollectionTags INNER JOIN Tags ON Tags.ID = CollectionTags.TagID WHERE CollectionID=?"
 	//Pass the sql query to DB
-	rows, err := DBConnection.DBHandle.Query(sqlQuery, CollectionID)
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, sqlQuery, CollectionID)
 	if err != nil {
 		return nil, err
 	}
@@ -460,3 +461,22 @@ func (DBConnection *MariaDBPlugin) GetCollectionTags(CollectionID uint64) ([]int
 	}
 	return ToReturn, nil
 }
+
+//GetOrphanedCollectionMembers returns collection members whose image no longer exists, sorted by collection then image
+func (DBConnection *MariaDBPlugin) GetOrphanedCollectionMembers(ctx context.Context) ([]interfaces.CollectionMember, error) {
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, "SELECT CollectionID, ImageID FROM CollectionMembers WHERE NOT EXISTS (SELECT 1 FROM Images WHERE Images.ID = CollectionMembers.ImageID) ORDER BY CollectionID, ImageID")
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/GetOrphanedCollectionMembers", "0", logging.ResultFailure, []string{"Failed to query orphaned collection members", err.Error()})
+		return nil, err
+	}
+	defer rows.Close()
+	var ToReturn []interfaces.CollectionMember
+	for rows.Next() {
+		var Member interfaces.CollectionMember
+		if err := rows.Scan(&Member.CollectionID, &Member.ImageID); err != nil {
+			return nil, err
+		}
+		ToReturn = append(ToReturn, Member)
+	}
+	return ToReturn, rows.Err()
+}
@@ -1,20 +1,23 @@
 package mariadbplugin
 
 import (
+	"context"
 	"errors"
 	"go-image-board/interfaces"
 	"go-image-board/logging"
+	"strconv"
 	"strings"
 )
 
 //SearchCollections performs a search for collections (Returns a list of CollectionInformation a result count and an error/nil)
 //If you edit this function, consider SearchImages for a similar change
-func (DBConnection *MariaDBPlugin) SearchCollections(Tags []interfaces.TagInformation, PageStart uint64, PageStride uint64) ([]interfaces.CollectionInformation, uint64, error) {
+func (DBConnection *MariaDBPlugin) SearchCollections(ctx context.Context, Tags []interfaces.TagInformation, PageStart uint64, PageStride uint64) ([]interfaces.CollectionInformation, uint64, error) {
 	//Cleanup input for use in code below
 	//Specifically we separate the include, the exclude and metatags into their own lists
 	var IncludeTags []uint64
 	var ExcludeTags []uint64
 	var MetaTags []interfaces.TagInformation
+	var Order interfaces.SearchOrder
 	for _, tag := range Tags {
 		if tag.Exists && tag.IsAlias == false && tag.IsMeta == false {
 			if tag.Exclude {
@@ -23,7 +26,11 @@ func (DBConnection *MariaDBPlugin) SearchCollections(Tags []interfaces.TagInform
 				IncludeTags = append(IncludeTags, tag.ID)
 			}
 		} else if tag.Exists && tag.IsMeta {
-			MetaTags = append(MetaTags, tag)
+			if tagOrderValue, isOrder := tag.MetaValue.(interfaces.SearchOrder); isOrder {
+				Order = tagOrderValue //Orders sort the results instead of filtering them
+			} else {
+				MetaTags = append(MetaTags, tag)
+			}
 		}
 	}
 
@@ -33,6 +40,13 @@ func (DBConnection *MariaDBPlugin) SearchCollections(Tags []interfaces.TagInform
 
 	//Construct SQL Query
 
+	//Sorting by anything other than ID needs the sort key in the inner statement used when searching with tags
+	sortKey := collectionSortKey(Order)
+	sortKeyColumn := ""
+	if sortKey != "" {
+		sortKeyColumn = ", " + sortKey + " AS SortKey"
+	}
+
 	//This is the start of the query we want
 	sqlQuery := `SELECT ID, Name, IFNULL(Preview.Location,"") as Location, IFNULL(Counts.Members,0) as Members `
 	sqlCountQuery := `SELECT COUNT(*) `
@@ -41,7 +55,7 @@ func (DBConnection *MariaDBPlugin) SearchCollections(Tags []interfaces.TagInform
 		sqlCountQuery = sqlCountQuery + `FROM Collections `
 	} else {
 		sqlQuery = sqlQuery + `FROM (
-			SELECT CollectionID as ID, Name, COUNT(*) as MatchingTags
+			SELECT CollectionID as ID, Name, COUNT(*) as MatchingTags` + sortKeyColumn + `
 			FROM CollectionTags 
 			INNER JOIN Collections ON CollectionTags.CollectionID=Collections.ID `
 		sqlCountQuery = sqlCountQuery + `FROM ( 
@@ -65,37 +79,32 @@ func (DBConnection *MariaDBPlugin) SearchCollections(Tags []interfaces.TagInform
 	}
 
 	//And add any metatags
-	if len(MetaTags) > 0 {
-		for _, tag := range MetaTags {
-			metaTagQuery := "AND "
-			if sqlWhereClause == "" {
-				metaTagQuery = "WHERE "
-			}
-			metaTagQuery = metaTagQuery + "Collections." + tag.Name + " "
-			comparator := tag.Comparator
-			if tag.Exclude {
-				comparator = getInvertedComparator(comparator)
-			}
-			if comparator == "" {
-				return ToReturn, 0, errors.New("Failed to invert query to negate on " + tag.Name)
-			}
-			metaTagQuery = metaTagQuery + comparator + " ? "
-
-			sqlWhereClause = sqlWhereClause + metaTagQuery
+	var metaTagArgs []interface{}
+	for _, tag := range MetaTags {
+		metaTagQuery, tagArgs, err := collectionMetaTagCondition(tag)
+		if err != nil {
+			return ToReturn, 0, err
+		}
+		if sqlWhereClause == "" {
+			sqlWhereClause = "WHERE "
+		} else {
+			sqlWhereClause += "AND "
 		}
+		sqlWhereClause += metaTagQuery + " "
+		metaTagArgs = append(metaTagArgs, tagArgs...)
 	}
 
 	//Special difference here compares to searchImages, this gets Location for a cover of the collection of sorts
 	previewCountPortion := `LEFT JOIN (
 		SELECT CollectionID, Count(*) as Members
-		FROM CollectionMembers
+		FROM VisibleCollectionMembers
 		GROUP BY CollectionID
 	) Counts ON Counts.CollectionID = ID
 	LEFT JOIN (
 		SELECT CM.CollectionID as CollectionID, Images.Location as Location
-		FROM CollectionMembers as CM
+		FROM VisibleCollectionMembers as CM
 		INNER JOIN Images on Images.ID = CM.ImageID
-		WHERE OrderWeight = (SELECT MIN(OrderWeight) From CollectionMembers WHERE CollectionMembers.CollectionID = CM.CollectionID)
+		WHERE OrderWeight = (SELECT MIN(OrderWeight) From VisibleCollectionMembers WHERE VisibleCollectionMembers.CollectionID = CM.CollectionID)
 	) Preview ON Preview.CollectionID = ID `
 
 	if len(IncludeTags) > 0 {
@@ -107,8 +116,18 @@ func (DBConnection *MariaDBPlugin) SearchCollections(Tags []interfaces.TagInform
 	}
 
 	//Add Order
-	sqlQuery = sqlQuery + `ORDER BY ID
-		DESC LIMIT ? OFFSET ?;`
+	direction := " DESC"
+	if Order.Ascending {
+		direction = " ASC"
+	}
+	if sortKey == "" {
+		sqlQuery = sqlQuery + `ORDER BY ID` + direction
+	} else if len(IncludeTags) > 0 {
+		sqlQuery = sqlQuery + `ORDER BY SortKey` + direction + `, ID` + direction
+	} else {
+		sqlQuery = sqlQuery + `ORDER BY ` + sortKey + direction + `, ID` + direction
+	}
+	sqlQuery = sqlQuery + ` LIMIT ? OFFSET ?;`
 
 	//Now construct arguments list. Order must follow query order
 	/*
@@ -129,9 +148,7 @@ func (DBConnection *MariaDBPlugin) SearchCollections(Tags []interfaces.TagInform
 		queryArray = append(queryArray, tag)
 	}
 	//Add values for metatags
-	for _, tag := range MetaTags {
-		queryArray = append(queryArray, tag.MetaValue)
-	}
+	queryArray = append(queryArray, metaTagArgs...)
 
 	//Add inclusive tag count, but only if we have any
 	if len(IncludeTags) > 0 {
@@ -139,7 +156,7 @@ func (DBConnection *MariaDBPlugin) SearchCollections(Tags []interfaces.TagInform
 	}
 
 	//Run the count query (Count query does not use start/stride, so run this before we add those)
-	err := DBConnection.DBHandle.QueryRow(sqlCountQuery, queryArray...).Scan(&MaxResults)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlCountQuery, queryArray...).Scan(&MaxResults)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/SearchCollections", "0", logging.ResultFailure, []string{"Error running search query", sqlCountQuery, err.Error()})
 		return nil, 0, err
@@ -150,7 +167,7 @@ func (DBConnection *MariaDBPlugin) SearchCollections(Tags []interfaces.TagInform
 	queryArray = append(queryArray, PageStart)
 
 	//Now we have query and args, run the query
-	rows, err := DBConnection.DBHandle.Query(sqlQuery, queryArray...)
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, sqlQuery, queryArray...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -172,3 +189,85 @@ func (DBConnection *MariaDBPlugin) SearchCollections(Tags []interfaces.TagInform
 	}
 	return ToReturn, MaxResults, nil
 }
+
+//collectionMetaTagCondition returns the SQL condition for a MetaTag in a collection search, along with the values for its placeholders
+func collectionMetaTagCondition(tag interfaces.TagInformation) (string, []interface{}, error) {
+	comparator := tag.Comparator
+	if tag.Exclude {
+		comparator = getInvertedComparator(comparator)
+	}
+	if comparator == "" {
+		return "", nil, errors.New("Failed to invert query to negate on " + tag.Name)
+	}
+
+	//Handle Complex Tags Here
+	if tagGroupValue, isTagGroup := tag.MetaValue.(interfaces.QueryGroup); isTagGroup { //Groups match when every tag in any one of their alternatives matches
+		var alternativeQueries []string
+		var groupArgs []interface{}
+		for _, alternative := range tagGroupValue.Alternatives {
+			var conditions []string
+			for _, groupTag := range alternative {
+				condition, conditionArgs, err := collectionGroupTagCondition(groupTag)
+				if err != nil {
+					return "", nil, err
+				}
+				if condition != "" {
+					conditions = append(conditions, condition)
+					groupArgs = append(groupArgs, conditionArgs...)
+				}
+			}
+			if len(conditions) == 0 {
+				conditions = append(conditions, "1 = 0") //An alternative of only ignored tags matches nothing, rather than everything
+			}
+			alternativeQueries = append(alternativeQueries, "("+strings.Join(conditions, " AND ")+")")
+		}
+		metaTagQuery := "(" + strings.Join(alternativeQueries, " OR ") + ")"
+		if comparator == "!=" {
+			metaTagQuery = "NOT " + metaTagQuery
+		}
+		return metaTagQuery, groupArgs, nil
+	}
+
+	return "Collections." + tag.Name + " " + comparator + " ?", []interface{}{tag.MetaValue}, nil
+}
+
+//collectionGroupTagCondition returns the SQL condition for one tag of a collection search group. Tags that do not change the result return an empty condition
+func collectionGroupTagCondition(tag interfaces.TagInformation) (string, []interface{}, error) {
+	if tag.IsMeta {
+		if tag.Exists == false {
+			return "", nil, nil
+		}
+		if _, isOrder := tag.MetaValue.(interfaces.SearchOrder); isOrder {
+			return "", nil, interfaces.ErrOrderInGroup //Orders only apply to a whole search
+		}
+		return collectionMetaTagCondition(tag)
+	}
+	if tag.IsAlias {
+		return "", nil, nil //The tag it is an alias of is in the group alongside it
+	}
+	if tag.Exists == false {
+		if tag.Exclude {
+			return "1 = 1", nil, nil //Every collection is without a tag that does not exist
+		}
+		return "1 = 0", nil, nil //No collection can have a tag that does not exist
+	}
+	if tag.Exclude {
+		return "Collections.ID NOT IN (SELECT CollectionID FROM CollectionTags WHERE TagID = ?)", []interface{}{tag.ID}, nil
+	}
+	return "Collections.ID IN (SELECT CollectionID FROM CollectionTags WHERE TagID = ?)", []interface{}{tag.ID}, nil
+}
+
+//collectionSortKey returns the SQL an order sorts collections by, or nothing for the default order by ID
+func collectionSortKey(Order interfaces.SearchOrder) string {
+	switch Order.Name {
+	case "uploaded":
+		return "Collections.UploadTime"
+	case "tagcount":
+		return "(SELECT COUNT(*) FROM CollectionTags AS CountedTags WHERE CountedTags.CollectionID = Collections.ID)"
+	case "random":
+		//Same as SearchOrder.RandomOrderKey
+		scrambled := "((Collections.ID * " + strconv.Itoa(interfaces.RandomOrderMultiplier) + " + " + strconv.FormatInt(Order.Seed, 10) + ") % " + strconv.Itoa(interfaces.RandomOrderModulus) + ")"
+		return "(" + scrambled + " * " + scrambled + " % " + strconv.Itoa(interfaces.RandomOrderModulus) + ")"
+	}
+	return ""
+}
@@ -1,6 +1,7 @@
 package mariadbplugin
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"go-image-board/interfaces"
@@ -13,8 +14,8 @@ import (
 //Image operations
 
 //NewImage adds an image with the provided information
-func (DBConnection *MariaDBPlugin) NewImage(ImageName string, ImageFileName string, OwnerID uint64, Source string) (uint64, error) {
-	resultInfo, err := DBConnection.DBHandle.Exec("INSERT INTO Images (Name, Location, UploaderID, Source) VALUES (?, ?, ?, ?);", ImageName, ImageFileName, OwnerID, Source)
+func (DBConnection *MariaDBPlugin) NewImage(ctx context.Context, ImageName string, ImageFileName string, OwnerID uint64, Source string) (uint64, error) {
+	resultInfo, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO Images (Name, Location, UploaderID, Source) VALUES (?, ?, ?, ?);", ImageName, ImageFileName, OwnerID, Source)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/NewImage", strconv.FormatUint(OwnerID, 10), logging.ResultFailure, []string{"Failed to add image", err.Error()})
 		return 0, err
@@ -25,28 +26,29 @@ func (DBConnection *MariaDBPlugin) NewImage(ImageName string, ImageFileName stri
 }
 
 //DeleteImage removes an image from the db
-func (DBConnection *MariaDBPlugin) DeleteImage(ImageID uint64) error {
+func (DBConnection *MariaDBPlugin) DeleteImage(ctx context.Context, ImageID uint64) error {
 	//First, remove image from any associated collections
-	collectionInfo, err := DBConnection.GetCollectionsWithImage(ImageID)
+	collectionInfo, err := DBConnection.GetCollectionsWithImage(ctx, ImageID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/DeleteImage", "0", logging.ResultFailure, []string{"Failed to get collection data to delete image", err.Error(), strconv.FormatUint(ImageID, 10)})
 		return err
 	}
 
 	for I := 0; I < len(collectionInfo); I++ {
-		err = DBConnection.RemoveCollectionMember(collectionInfo[I].ID, ImageID)
-		logging.WriteLog(logging.LogLevelWarning, "MariaDBPlugin/DeleteImage", "0", logging.ResultFailure, []string{"Failed to remove image from collection", err.Error(), strconv.FormatUint(ImageID, 10)})
+		if err = DBConnection.RemoveCollectionMember(ctx, collectionInfo[I].ID, ImageID); err != nil {
+			logging.WriteLog(logging.LogLevelWarning, "MariaDBPlugin/DeleteImage", "0", logging.ResultFailure, []string{"Failed to remove image from collection", err.Error(), strconv.FormatUint(ImageID, 10)})
+		}
 	}
 
 	//First delete ImageTags
-	_, err = DBConnection.DBHandle.Exec("DELETE FROM ImageTags WHERE ImageID=?;", ImageID)
+	_, err = DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM ImageTags WHERE ImageID=?;", ImageID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/DeleteImage", "0", logging.ResultFailure, []string{"Failed to delete image", err.Error(), strconv.FormatUint(ImageID, 10)})
 		return err
 	}
 	logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/DeleteImage", "0", logging.ResultSuccess, []string{"Image tags deleted", strconv.FormatUint(ImageID, 10)})
 	//Second delete Image from table
-	_, err = DBConnection.DBHandle.Exec("DELETE FROM Images WHERE ID=?;", ImageID)
+	_, err = DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM Images WHERE ID=?;", ImageID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/DeleteImage", "0", logging.ResultFailure, []string{"Failed to delete image", err.Error(), strconv.FormatUint(ImageID, 10)})
 	} else {
@@ -56,13 +58,13 @@ func (DBConnection *MariaDBPlugin) DeleteImage(ImageID uint64) error {
 }
 
 //UpdateImage updates properties of an image
-func (DBConnection *MariaDBPlugin) UpdateImage(ImageID uint64, ImageName interface{}, ImageDescription interface{}, OwnerID interface{}, Rating interface{}, Source interface{}, Location interface{}) error {
+func (DBConnection *MariaDBPlugin) UpdateImage(ctx context.Context, ImageID uint64, ImageName interface{}, ImageDescription interface{}, OwnerID interface{}, Rating interface{}, Source interface{}, Location interface{}) error {
 	if _, correctValue := OwnerID.(uint64); OwnerID != nil && correctValue == false {
 		return errors.New("OwnerID, when provided, must be of uint64 type")
 	}
 
 	//See if image exists
-	_, err := DBConnection.GetImage(ImageID)
+	_, err := DBConnection.GetImage(ctx, ImageID)
 	if err != nil {
 		return err
 	}
@@ -117,15 +119,15 @@ func (DBConnection *MariaDBPlugin) UpdateImage(ImageID uint64, ImageName interfa
 		return nil //No change requested
 	}
 	sqlQuery = "UPDATE Images SET " + sqlQuery + "WHERE ID = ?"
-	_, err = DBConnection.DBHandle.Exec(sqlQuery, queryArray...)
+	_, err = DBConnection.DBHandle.ExecContext(ctx, sqlQuery, queryArray...)
 	return err
 }
 
 //GetImage returns information on a single image (Returns an ImageInformation, or error)
-func (DBConnection *MariaDBPlugin) GetImage(ID uint64) (interfaces.ImageInformation, error) {
+func (DBConnection *MariaDBPlugin) GetImage(ctx context.Context, ID uint64) (interfaces.ImageInformation, error) {
 	ToReturn := interfaces.ImageInformation{ID: ID}
-	var UploadTime mysql.NullTime
-	err := DBConnection.DBHandle.QueryRow("Select Images.Name, IFNULL(Images.Description,'') AS Description, Images.Location, Images.UploaderID, Images.UploadTime, Images.Rating, Users.Name, Images.ScoreAverage, Images.ScoreTotal, Images.ScoreVoters, Images.Source FROM Images LEFT OUTER JOIN Users ON Images.UploaderID = Users.ID WHERE Images.ID=?", ID).Scan(&ToReturn.Name, &ToReturn.Description, &ToReturn.Location, &ToReturn.UploaderID, &UploadTime, &ToReturn.Rating, &ToReturn.UploaderName, &ToReturn.ScoreAverage, &ToReturn.ScoreTotal, &ToReturn.ScoreVoters, &ToReturn.Source)
+	var UploadTime, DeletedTime mysql.NullTime
+	err := DBConnection.DBHandle.QueryRowContext(ctx, "Select Images.Name, IFNULL(Images.Description,'') AS Description, Images.Location, Images.UploaderID, Images.UploadTime, Images.Rating, Users.Name, Images.ScoreAverage, Images.ScoreTotal, Images.ScoreVoters, Images.Source, Images.DeletedTime, Images.DeletedBy, IFNULL(DeletedUsers.Name, ''), Images.Width, Images.Height, Images.FileSize, Images.MIMEType, Images.Duration, Images.Codec FROM Images LEFT OUTER JOIN Users ON Images.UploaderID = Users.ID LEFT OUTER JOIN Users DeletedUsers ON Images.DeletedBy = DeletedUsers.ID WHERE Images.ID=?", ID).Scan(&ToReturn.Name, &ToReturn.Description, &ToReturn.Location, &ToReturn.UploaderID, &UploadTime, &ToReturn.Rating, &ToReturn.UploaderName, &ToReturn.ScoreAverage, &ToReturn.ScoreTotal, &ToReturn.ScoreVoters, &ToReturn.Source, &DeletedTime, &ToReturn.DeletedByID, &ToReturn.DeletedByName, &ToReturn.Width, &ToReturn.Height, &ToReturn.FileSize, &ToReturn.MIMEType, &ToReturn.Duration, &ToReturn.Codec)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/ImageFunctions/GetImage", "0", logging.ResultFailure, []string{"Failed to get image info from database", err.Error()})
 		return ToReturn, err
@@ -133,14 +135,17 @@ func (DBConnection *MariaDBPlugin) GetImage(ID uint64) (interfaces.ImageInformat
 	if UploadTime.Valid {
 		ToReturn.UploadTime = UploadTime.Time
 	}
+	if DeletedTime.Valid {
+		ToReturn.DeletedTime = DeletedTime.Time
+	}
 	return ToReturn, nil
 }
 
 //GetImageByFileName returns an ImageInformation object given a ImageName
-func (DBConnection *MariaDBPlugin) GetImageByFileName(imageName string) (interfaces.ImageInformation, error) {
+func (DBConnection *MariaDBPlugin) GetImageByFileName(ctx context.Context, imageName string) (interfaces.ImageInformation, error) {
 	ToReturn := interfaces.ImageInformation{Location: imageName}
-	var UploadTime mysql.NullTime
-	err := DBConnection.DBHandle.QueryRow("Select Images.Name, IFNULL(Images.Description,'') AS Description, Images.ID, Images.UploaderID, Images.UploadTime, Images.Rating, Users.Name, Images.ScoreAverage, Images.ScoreTotal, Images.ScoreVoters, Images.Source FROM Images LEFT OUTER JOIN Users ON Images.UploaderID = Users.ID WHERE Images.Location=?", imageName).Scan(&ToReturn.Name, &ToReturn.Description, &ToReturn.ID, &ToReturn.UploaderID, &UploadTime, &ToReturn.Rating, &ToReturn.UploaderName, &ToReturn.ScoreAverage, &ToReturn.ScoreTotal, &ToReturn.ScoreVoters, &ToReturn.Source)
+	var UploadTime, DeletedTime mysql.NullTime
+	err := DBConnection.DBHandle.QueryRowContext(ctx, "Select Images.Name, IFNULL(Images.Description,'') AS Description, Images.ID, Images.UploaderID, Images.UploadTime, Images.Rating, Users.Name, Images.ScoreAverage, Images.ScoreTotal, Images.ScoreVoters, Images.Source, Images.DeletedTime, Images.DeletedBy, IFNULL(DeletedUsers.Name, ''), Images.Width, Images.Height, Images.FileSize, Images.MIMEType, Images.Duration, Images.Codec FROM Images LEFT OUTER JOIN Users ON Images.UploaderID = Users.ID LEFT OUTER JOIN Users DeletedUsers ON Images.DeletedBy = DeletedUsers.ID WHERE Images.Location=?", imageName).Scan(&ToReturn.Name, &ToReturn.Description, &ToReturn.ID, &ToReturn.UploaderID, &UploadTime, &ToReturn.Rating, &ToReturn.UploaderName, &ToReturn.ScoreAverage, &ToReturn.ScoreTotal, &ToReturn.ScoreVoters, &ToReturn.Source, &DeletedTime, &ToReturn.DeletedByID, &ToReturn.DeletedByName, &ToReturn.Width, &ToReturn.Height, &ToReturn.FileSize, &ToReturn.MIMEType, &ToReturn.Duration, &ToReturn.Codec)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/ImageFunctions/GetImageByFileName", "0", logging.ResultFailure, []string{"Failed to get image info from database", err.Error()})
 		return ToReturn, err
@@ -148,12 +153,15 @@ func (DBConnection *MariaDBPlugin) GetImageByFileName(imageName string) (interfa
 	if UploadTime.Valid {
 		ToReturn.UploadTime = UploadTime.Time
 	}
+	if DeletedTime.Valid {
+		ToReturn.DeletedTime = DeletedTime.Time
+	}
 	return ToReturn, nil
 }
 
 //SetImageRating changes a given image's rating in the database
-func (DBConnection *MariaDBPlugin) SetImageRating(ID uint64, Rating string) error {
-	_, err := DBConnection.DBHandle.Exec("UPDATE Images SET Rating = ? WHERE ID = ?;", Rating, ID)
+func (DBConnection *MariaDBPlugin) SetImageRating(ctx context.Context, ID uint64, Rating string) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Images SET Rating = ? WHERE ID = ?;", Rating, ID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/ImageFunctions/SetImageRating", "0", logging.ResultFailure, []string{"Failed to set image rating", err.Error()})
 		return err
@@ -162,8 +170,8 @@ func (DBConnection *MariaDBPlugin) SetImageRating(ID uint64, Rating string) erro
 }
 
 //SetImageSource changes a given image's source in the database
-func (DBConnection *MariaDBPlugin) SetImageSource(ID uint64, Source string) error {
-	_, err := DBConnection.DBHandle.Exec("UPDATE Images SET Source = ? WHERE ID = ?;", Source, ID)
+func (DBConnection *MariaDBPlugin) SetImageSource(ctx context.Context, ID uint64, Source string) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Images SET Source = ? WHERE ID = ?;", Source, ID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/ImageFunctions/SetImageSource", "0", logging.ResultFailure, []string{"Failed to set image source", err.Error()})
 		return err
@@ -171,9 +179,19 @@ func (DBConnection *MariaDBPlugin) SetImageSource(ID uint64, Source string) erro
 	return nil
 }
 
+//SetImageMetadata stores what was read from an image's file
+func (DBConnection *MariaDBPlugin) SetImageMetadata(ctx context.Context, ID uint64, Metadata interfaces.ImageMetadata) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Images SET Width = ?, Height = ?, FileSize = ?, MIMEType = ?, Duration = ?, Codec = ? WHERE ID = ?;", Metadata.Width, Metadata.Height, Metadata.FileSize, Metadata.MIMEType, Metadata.Duration, Metadata.Codec, ID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/ImageFunctions/SetImageMetadata", "0", logging.ResultFailure, []string{"Failed to set image metadata", err.Error()})
+		return err
+	}
+	return nil
+}
+
 //SetImagedHash changes a given image's dHash in the database
-func (DBConnection *MariaDBPlugin) SetImagedHash(ID uint64, hHash uint64, vHash uint64) error {
-	_, err := DBConnection.DBHandle.Exec("INSERT INTO ImagedHashes (ImageID, hHash, vHash) VALUES (?,?,?) ON DUPLICATE KEY UPDATE hHash = VALUES(hHash), vHash = VALUES(vHash);", ID, hHash, vHash)
+func (DBConnection *MariaDBPlugin) SetImagedHash(ctx context.Context, ID uint64, hHash uint64, vHash uint64) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO ImagedHashes (ImageID, hHash, vHash) VALUES (?,?,?) ON DUPLICATE KEY UPDATE hHash = VALUES(hHash), vHash = VALUES(vHash);", ID, hHash, vHash)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/ImageFunctions/SetImagedHash", "0", logging.ResultFailure, []string{"Failed to set image dHashes", err.Error()})
 		return err
@@ -182,9 +200,9 @@ func (DBConnection *MariaDBPlugin) SetImagedHash(ID uint64, hHash uint64, vHash
 }
 
 //GetImagedHash changes a given image's dHash in the database
-func (DBConnection *MariaDBPlugin) GetImagedHash(ID uint64) (uint64, uint64, error) {
+func (DBConnection *MariaDBPlugin) GetImagedHash(ctx context.Context, ID uint64) (uint64, uint64, error) {
 	var hHash, vHash uint64
-	err := DBConnection.DBHandle.QueryRow("SELECT hHash, vHash from ImagedHashes WHERE ImageID = ?", ID).Scan(&hHash, &vHash)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT hHash, vHash from ImagedHashes WHERE ImageID = ?", ID).Scan(&hHash, &vHash)
 	if err != nil {
 		return hHash, vHash, err
 	}
@@ -1,6 +1,7 @@
 package mariadbplugin
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"go-image-board/interfaces"
@@ -8,16 +9,27 @@ import (
 	"math/rand"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
 )
 
 //SearchImages performs a search for images (Returns a list of ImageInformations a result count and an error/nil)
+func (DBConnection *MariaDBPlugin) SearchImages(ctx context.Context, Tags []interfaces.TagInformation, PageStart uint64, PageStride uint64) ([]interfaces.ImageInformation, uint64, error) {
+	ToReturn, _, MaxResults, err := DBConnection.SearchImagesPage(ctx, Tags, "", PageStart, PageStride, true)
+	return ToReturn, MaxResults, err
+}
+
+//SearchImagesPage performs a search for a page of images, continuing after Cursor when it is set or starting at PageStart otherwise
+//Returns the images, the cursor for the next page (blank on the last page), the result count if CountResults is set, and an error/nil
 //If you edit this function, consider SearchCollections and GetPrevNexImages for a similar change
-func (DBConnection *MariaDBPlugin) SearchImages(Tags []interfaces.TagInformation, PageStart uint64, PageStride uint64) ([]interfaces.ImageInformation, uint64, error) {
+func (DBConnection *MariaDBPlugin) SearchImagesPage(ctx context.Context, Tags []interfaces.TagInformation, Cursor string, PageStart uint64, PageStride uint64, CountResults bool) ([]interfaces.ImageInformation, string, uint64, error) {
 	//Cleanup input for use in code below
 	//Specifically we separate the include, the exclude and metatags into their own lists
 	var IncludeTags []uint64
 	var ExcludeTags []uint64
 	var MetaTags []interfaces.TagInformation
+	var Order interfaces.SearchOrder
 	for _, tag := range Tags {
 		if tag.Exists && tag.IsAlias == false && tag.IsMeta == false {
 			if tag.Exclude {
@@ -26,9 +38,24 @@ func (DBConnection *MariaDBPlugin) SearchImages(Tags []interfaces.TagInformation
 				IncludeTags = append(IncludeTags, tag.ID)
 			}
 		} else if tag.Exists && tag.IsMeta {
-			MetaTags = append(MetaTags, tag)
+			if tagOrderValue, isOrder := tag.MetaValue.(interfaces.SearchOrder); isOrder {
+				Order = tagOrderValue //Orders sort the results instead of filtering them
+			} else {
+				MetaTags = append(MetaTags, tag)
+			}
 		}
 	}
+	//The cursor also keeps the seed of the random order's shuffle
+	var AfterID uint64
+	var AfterSortKey int64
+	if Cursor != "" {
+		var err error
+		AfterID, AfterSortKey, Order, err = interfaces.DecodeSearchCursor(Cursor, Order)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		PageStart = 0
+	}
 
 	//Initialize output
 	var ToReturn []interfaces.ImageInformation
@@ -36,15 +63,18 @@ func (DBConnection *MariaDBPlugin) SearchImages(Tags []interfaces.TagInformation
 
 	//Construct SQL Query
 
-	//This is the start of the query we want
-	sqlQuery := `SELECT ID, Name, Location `
+	//This is the start of the query we want, the sort key is read for the next page's cursor
+	sqlQuery := `SELECT ID, Name, Location`
 	sqlCountQuery := `SELECT COUNT(*) `
 	if len(IncludeTags) == 0 {
-		sqlQuery = sqlQuery + `FROM Images `
+		sqlQuery = sqlQuery + imageSortKeyColumn(Order) + ` FROM Images `
 		sqlCountQuery = sqlCountQuery + `FROM Images `
 	} else {
-		sqlQuery = sqlQuery + `FROM (
-			SELECT ImageID as ID, Name, Location, COUNT(*) as MatchingTags
+		if imageSortKey(Order) != "" {
+			sqlQuery = sqlQuery + `, SortKey`
+		}
+		sqlQuery = sqlQuery + ` FROM (
+			SELECT ImageID as ID, Name, Location, COUNT(*) as MatchingTags` + imageSortKeyColumn(Order) + `
 			FROM ImageTags 
 			INNER JOIN Images ON ImageTags.ImageID=Images.ID `
 		sqlCountQuery = sqlCountQuery + `FROM ( 
@@ -54,89 +84,56 @@ func (DBConnection *MariaDBPlugin) SearchImages(Tags []interfaces.TagInformation
 	}
 
 	//Now for the variable piece
-	sqlWhereClause := ""
+	//Images in the recycle bin never show up in searches
+	sqlWhereClause := "WHERE Images.DeletedTime IS NULL "
 	if len(IncludeTags) > 0 {
-		sqlWhereClause = sqlWhereClause + "WHERE TagID IN (?" + strings.Repeat(",?", len(IncludeTags)-1) + ") "
+		sqlWhereClause = sqlWhereClause + "AND TagID IN (?" + strings.Repeat(",?", len(IncludeTags)-1) + ") "
 	}
 	if len(ExcludeTags) > 0 {
-		if len(IncludeTags) > 0 {
-			sqlWhereClause += "AND "
-		} else {
-			sqlWhereClause += "WHERE "
-		}
-		sqlWhereClause += "Images.ID NOT IN (SELECT DISTINCT ImageID FROM ImageTags WHERE TagID IN (?" + strings.Repeat(",?", len(ExcludeTags)-1) + ")) "
+		sqlWhereClause += "AND Images.ID NOT IN (SELECT DISTINCT ImageID FROM ImageTags WHERE TagID IN (?" + strings.Repeat(",?", len(ExcludeTags)-1) + ")) "
 	}
 
 	//And add any metatags
-	if len(MetaTags) > 0 {
-		for _, tag := range MetaTags {
-			metaTagQuery := "AND "
-			if sqlWhereClause == "" {
-				metaTagQuery = "WHERE "
-			}
-
-			//Handle Comparator transforms
-			comparator := tag.Comparator
-			if tag.Exclude {
-				comparator = getInvertedComparator(comparator)
-			}
-			if comparator == "" {
-				return ToReturn, 0, errors.New("Failed to invert query to negate on " + tag.Name)
-			}
-
-			//Handle Complex Tags Here
-			if tag.Name == "InCollection" { //Special Exception for InCollection
-				tagBoolValue, isTagValued := tag.MetaValue.(bool)
-				if isTagValued == false {
-					return ToReturn, 0, errors.New("Failed get value of " + tag.Name)
-				}
-				if (comparator == "=" && tagBoolValue == true) || (comparator == "!=" && tagBoolValue == false) {
-					comparator = " IN "
-				} else {
-					comparator = " NOT IN "
-				}
-				metaTagQuery += "Images.ID" + comparator + "(SELECT DISTINCT ImageID FROM CollectionMembers) "
-				sqlWhereClause = sqlWhereClause + metaTagQuery
-				continue //Skip over rest of code for this tag
-			} else if tag.Name == "TagCount" { //Special Exception for TagCount
-				tagStringValue, isTagValued := tag.MetaValue.(string)
-				if isTagValued == false {
-					return ToReturn, 0, errors.New("Failed get value of " + tag.Name)
-				}
-				metaTagQuery += "Images.ID IN (SELECT ImageID FROM (SELECT ImageID, COUNT(*) AS TagCount FROM `ImageTags` GROUP BY ImageID) TagCountTBL WHERE TagCountTBL.TagCount " + comparator + " " + tagStringValue + ") "
-				sqlWhereClause = sqlWhereClause + metaTagQuery
-				continue //Skip over rest of code for this tag
-			} else if tag.Name == "Similar" { //Special Exception for TagCount
-				tagImagedHashValue, isTagValued := tag.MetaValue.(interfaces.ImagedHash)
-				if isTagValued == false {
-					return ToReturn, 0, errors.New("Failed get value of " + tag.Name)
-				}
-				metaTagQuery += "Images.ID IN (SELECT ImageID FROM ImagedHashes WHERE (BIT_COUNT(hHash ^ " + strconv.FormatUint(tagImagedHashValue.ImagehHash, 10) + ")+BIT_COUNT(vHash ^ " + strconv.FormatUint(tagImagedHashValue.ImagevHash, 10) + ")) " + comparator + " " + strconv.FormatUint(tagImagedHashValue.SimilarityThreshold, 10) + ") "
-				sqlWhereClause = sqlWhereClause + metaTagQuery
-				continue //Skip over rest of code for this tag
-			}
+	var metaTagArgs []interface{}
+	for _, tag := range MetaTags {
+		metaTagQuery, tagArgs, err := imageMetaTagCondition(tag)
+		if err != nil {
+			return ToReturn, "", 0, err
+		}
+		sqlWhereClause += "AND " + metaTagQuery + " "
+		metaTagArgs = append(metaTagArgs, tagArgs...)
+	}
 
-			metaTagQuery = metaTagQuery + "Images." + tag.Name + " "
-			metaTagQuery = metaTagQuery + comparator + " ? "
-			sqlWhereClause = sqlWhereClause + metaTagQuery
+	//Continue after the cursor's image, the same way as the previous image link on its page. The count leaves this out
+	sqlPageWhereClause := sqlWhereClause
+	var cursorArgs []interface{}
+	if AfterID != 0 {
+		comparator := "<"
+		if Order.Ascending {
+			comparator = ">"
 		}
+		cursorCondition, keysetArgs := imageCursorCondition(Order, comparator, AfterID, AfterSortKey)
+		sqlPageWhereClause += "AND " + cursorCondition + " "
+		cursorArgs = keysetArgs
 	}
 
 	if len(IncludeTags) > 0 {
-		sqlQuery = sqlQuery + sqlWhereClause + `GROUP BY ImageID) InnerStatement WHERE MatchingTags = ? `
+		sqlQuery = sqlQuery + sqlPageWhereClause + `GROUP BY ImageID) InnerStatement WHERE MatchingTags = ? `
 		sqlCountQuery = sqlCountQuery + sqlWhereClause + `GROUP BY ImageID) InnerStatement WHERE MatchingTags = ? `
 	} else {
-		sqlQuery = sqlQuery + sqlWhereClause
+		sqlQuery = sqlQuery + sqlPageWhereClause
 		sqlCountQuery = sqlCountQuery + sqlWhereClause
 	}
 
 	//Add Order
-	sqlQuery = sqlQuery + `ORDER BY ID DESC LIMIT ? OFFSET ?;`
+	sqlQuery = sqlQuery + `ORDER BY ` + imageOrderBy(Order, len(IncludeTags) > 0, Order.Ascending == false) + ` LIMIT ? OFFSET ?;`
 
 	//Now construct arguments list. Order must follow query order
 	/*
 		Inclusive Tags
 		Exclusive Tags
+		MetaTag values
+		<Cursor values, only in the page query>
 		Inclusive Tag Count
 		<However we pause here to run count query, as that one does not have limits>
 		Max Amount of results to return (Stride)
@@ -152,69 +149,89 @@ func (DBConnection *MariaDBPlugin) SearchImages(Tags []interfaces.TagInformation
 		queryArray = append(queryArray, tag)
 	}
 	//Add values for metatags
-	for _, tag := range MetaTags {
-		//Handle Complex Tags Here
-		if tag.Name == "InCollection" || tag.Name == "TagCount" || tag.Name == "Similar" { //Special Exception for cert MetaTags
-			continue
-		}
-		//Otherwise use default
-		queryArray = append(queryArray, tag.MetaValue)
-	}
+	queryArray = append(queryArray, metaTagArgs...)
 
 	//Add inclusive tag count, but only if we have any
+	var tagCountArgs []interface{}
 	if len(IncludeTags) > 0 {
-		queryArray = append(queryArray, len(IncludeTags))
+		tagCountArgs = append(tagCountArgs, len(IncludeTags))
 	}
 
-	//Run the count query (Count query does not use start/stride, so run this before we add those)
-	err := DBConnection.DBHandle.QueryRow(sqlCountQuery, queryArray...).Scan(&MaxResults)
-	if err != nil {
-		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/SearchImages", "0", logging.ResultFailure, []string{"Error running search query", sqlCountQuery, err.Error()})
-		return nil, 0, err
+	//Run the count query (Count query does not use the cursor or start/stride, so run this before we add those)
+	if CountResults {
+		err := DBConnection.DBHandle.QueryRowContext(ctx, sqlCountQuery, append(queryArray, tagCountArgs...)...).Scan(&MaxResults)
+		if err != nil {
+			logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/SearchImagesPage", "0", logging.ResultFailure, []string{"Error running search query", sqlCountQuery, err.Error()})
+			return nil, "", 0, err
+		}
 	}
 
-	//Add rest of arguments now that we have max result count
-	queryArray = append(queryArray, PageStride)
+	//Add rest of arguments now that we have max result count, one extra image is read to tell if there is a next page
+	queryArray = append(queryArray, cursorArgs...)
+	queryArray = append(queryArray, tagCountArgs...)
+	queryArray = append(queryArray, PageStride+1)
 	queryArray = append(queryArray, PageStart)
 
 	//Now we have query and args, run the query
-	rows, err := DBConnection.DBHandle.Query(sqlQuery, queryArray...)
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, sqlQuery, queryArray...)
 	if err != nil {
-		return nil, 0, err
+		return nil, "", 0, err
 	}
 	defer rows.Close()
 	//Placeholders for data returned by each row
 	var ImageID uint64
 	var Name string
 	var Location string
+	var SortKey int64
+	var SortKeyTime mysql.NullTime
+	var SortKeys []int64
+	scanTargets := []interface{}{&ImageID, &Name, &Location}
+	if Order.Name == "uploaded" {
+		scanTargets = append(scanTargets, &SortKeyTime)
+	} else if imageSortKey(Order) != "" {
+		scanTargets = append(scanTargets, &SortKey)
+	}
 	//For each row
 	for rows.Next() {
 		//Parse out the data
-		err := rows.Scan(&ImageID, &Name, &Location)
+		err := rows.Scan(scanTargets...)
 		if err != nil {
-			return nil, 0, err
+			return nil, "", 0, err
+		}
+		//Search cursors keep upload times as Unix nanoseconds
+		if SortKeyTime.Valid {
+			SortKey = SortKeyTime.Time.UnixNano()
 		}
 		//Add this result to ToReturn
 		ToReturn = append(ToReturn, interfaces.ImageInformation{Name: Name, ID: ImageID, Location: Location})
+		SortKeys = append(SortKeys, SortKey)
+	}
+	//The extra image only shows that there is a next page
+	var NextCursor string
+	if uint64(len(ToReturn)) > PageStride {
+		ToReturn = ToReturn[:PageStride]
+		if PageStride > 0 {
+			NextCursor = interfaces.EncodeSearchCursor(ToReturn[PageStride-1].ID, SortKeys[PageStride-1], Order)
+		}
 	}
-	return ToReturn, MaxResults, nil
+	return ToReturn, NextCursor, MaxResults, nil
 }
 
 //GetPrevNexImages performs a search for images (Returns a list of ImageInformations (Up to 2) and an error/nil)
-func (DBConnection *MariaDBPlugin) GetPrevNexImages(Tags []interfaces.TagInformation, TargetID uint64) ([]interfaces.ImageInformation, error) {
+func (DBConnection *MariaDBPlugin) GetPrevNexImages(ctx context.Context, Tags []interfaces.TagInformation, TargetID uint64) ([]interfaces.ImageInformation, error) {
 	if TargetID == 0 {
 		return nil, errors.New("invalid targetid")
 	}
 
 	var ToReturn []interfaces.ImageInformation
 
-	if imageInfo, err := DBConnection.getPrevNexImage(Tags, TargetID, true); err == nil {
+	if imageInfo, err := DBConnection.getPrevNexImage(ctx, Tags, TargetID, true); err == nil {
 		ToReturn = append(ToReturn, imageInfo)
 	} else if err != sql.ErrNoRows {
 		return ToReturn, err
 	}
 
-	if imageInfo, err := DBConnection.getPrevNexImage(Tags, TargetID, false); err == nil {
+	if imageInfo, err := DBConnection.getPrevNexImage(ctx, Tags, TargetID, false); err == nil {
 		ToReturn = append(ToReturn, imageInfo)
 	} else if err != sql.ErrNoRows {
 		return ToReturn, err
@@ -224,12 +241,13 @@ func (DBConnection *MariaDBPlugin) GetPrevNexImages(Tags []interfaces.TagInforma
 }
 
 //GetPrevNexImages performs a search for images (Returns a ImageInformation and an error/nil)
-func (DBConnection *MariaDBPlugin) getPrevNexImage(Tags []interfaces.TagInformation, TargetID uint64, Next bool) (interfaces.ImageInformation, error) {
+func (DBConnection *MariaDBPlugin) getPrevNexImage(ctx context.Context, Tags []interfaces.TagInformation, TargetID uint64, Next bool) (interfaces.ImageInformation, error) {
 	//Cleanup input for use in code below
 	//Specifically we separate the include, the exclude and metatags into their own lists
 	var IncludeTags []uint64
 	var ExcludeTags []uint64
 	var MetaTags []interfaces.TagInformation
+	var Order interfaces.SearchOrder
 	for _, tag := range Tags {
 		if tag.Exists && tag.IsAlias == false && tag.IsMeta == false {
 			if tag.Exclude {
@@ -238,7 +256,11 @@ func (DBConnection *MariaDBPlugin) getPrevNexImage(Tags []interfaces.TagInformat
 				IncludeTags = append(IncludeTags, tag.ID)
 			}
 		} else if tag.Exists && tag.IsMeta {
-			MetaTags = append(MetaTags, tag)
+			if tagOrderValue, isOrder := tag.MetaValue.(interfaces.SearchOrder); isOrder {
+				Order = tagOrderValue //Orders sort the results instead of filtering them
+			} else {
+				MetaTags = append(MetaTags, tag)
+			}
 		}
 	}
 
@@ -256,7 +278,7 @@ func (DBConnection *MariaDBPlugin) getPrevNexImage(Tags []interfaces.TagInformat
 		sqlCountQuery = sqlCountQuery + `FROM Images `
 	} else {
 		sqlQuery = sqlQuery + `FROM (
-			SELECT ImageID as ID, Name, Location, COUNT(*) as MatchingTags
+			SELECT ImageID as ID, Name, Location, COUNT(*) as MatchingTags` + imageSortKeyColumn(Order) + `
 			FROM ImageTags 
 			INNER JOIN Images ON ImageTags.ImageID=Images.ID `
 		sqlCountQuery = sqlCountQuery + `FROM ( 
@@ -266,85 +288,33 @@ func (DBConnection *MariaDBPlugin) getPrevNexImage(Tags []interfaces.TagInformat
 	}
 
 	//Now for the variable piece
-	sqlWhereClause := ""
+	//Images in the recycle bin never show up in searches
+	sqlWhereClause := "WHERE Images.DeletedTime IS NULL "
 	if len(IncludeTags) > 0 {
-		sqlWhereClause = sqlWhereClause + "WHERE TagID IN (?" + strings.Repeat(",?", len(IncludeTags)-1) + ") "
+		sqlWhereClause = sqlWhereClause + "AND TagID IN (?" + strings.Repeat(",?", len(IncludeTags)-1) + ") "
 	}
 	if len(ExcludeTags) > 0 {
-		if len(IncludeTags) > 0 {
-			sqlWhereClause += "AND "
-		} else {
-			sqlWhereClause += "WHERE "
-		}
-		sqlWhereClause += "Images.ID NOT IN (SELECT DISTINCT ImageID FROM ImageTags WHERE TagID IN (?" + strings.Repeat(",?", len(ExcludeTags)-1) + ")) "
+		sqlWhereClause += "AND Images.ID NOT IN (SELECT DISTINCT ImageID FROM ImageTags WHERE TagID IN (?" + strings.Repeat(",?", len(ExcludeTags)-1) + ")) "
 	}
 
 	//And add any metatags
-	if len(MetaTags) > 0 {
-		for _, tag := range MetaTags {
-			metaTagQuery := "AND "
-			if sqlWhereClause == "" {
-				metaTagQuery = "WHERE "
-			}
-
-			//Handle Comparator transforms
-			comparator := tag.Comparator
-			if tag.Exclude {
-				comparator = getInvertedComparator(comparator)
-			}
-			if comparator == "" {
-				return ToReturn, errors.New("Failed to invert query to negate on " + tag.Name)
-			}
-
-			//Handle Complex Tags Here
-			if tag.Name == "InCollection" { //Special Exception for InCollection
-				tagBoolValue, isTagValued := tag.MetaValue.(bool)
-				if isTagValued == false {
-					return ToReturn, errors.New("Failed get value of " + tag.Name)
-				}
-				if (comparator == "=" && tagBoolValue == true) || (comparator == "!=" && tagBoolValue == false) {
-					comparator = " IN "
-				} else {
-					comparator = " NOT IN "
-				}
-				metaTagQuery += "Images.ID" + comparator + "(SELECT DISTINCT ImageID FROM CollectionMembers) "
-				sqlWhereClause = sqlWhereClause + metaTagQuery
-				continue //Skip over rest of code for this tag
-			} else if tag.Name == "TagCount" { //Special Exception for TagCount
-				tagStringValue, isTagValued := tag.MetaValue.(string)
-				if isTagValued == false {
-					return ToReturn, errors.New("Failed get value of " + tag.Name)
-				}
-				metaTagQuery += "Images.ID IN (SELECT ImageID FROM (SELECT ImageID, COUNT(*) AS TagCount FROM `ImageTags` GROUP BY ImageID) TagCountTBL WHERE TagCountTBL.TagCount " + comparator + " " + tagStringValue + ") "
-				sqlWhereClause = sqlWhereClause + metaTagQuery
-				continue //Skip over rest of code for this tag
-			} else if tag.Name == "Similar" { //Special Exception for TagCount
-				tagImagedHashValue, isTagValued := tag.MetaValue.(interfaces.ImagedHash)
-				if isTagValued == false {
-					return ToReturn, errors.New("Failed get value of " + tag.Name)
-				}
-				metaTagQuery += "Images.ID IN (SELECT ImageID FROM ImagedHashes WHERE (BIT_COUNT(hHash ^ " + strconv.FormatUint(tagImagedHashValue.ImagehHash, 10) + ")+BIT_COUNT(vHash ^ " + strconv.FormatUint(tagImagedHashValue.ImagevHash, 10) + ")) " + comparator + " " + strconv.FormatUint(tagImagedHashValue.SimilarityThreshold, 10) + ") "
-				sqlWhereClause = sqlWhereClause + metaTagQuery
-				continue //Skip over rest of code for this tag
-			}
-
-			metaTagQuery = metaTagQuery + "Images." + tag.Name + " "
-			metaTagQuery = metaTagQuery + comparator + " ? "
-			sqlWhereClause = sqlWhereClause + metaTagQuery
+	var metaTagArgs []interface{}
+	for _, tag := range MetaTags {
+		metaTagQuery, tagArgs, err := imageMetaTagCondition(tag)
+		if err != nil {
+			return ToReturn, err
 		}
+		sqlWhereClause += "AND " + metaTagQuery + " "
+		metaTagArgs = append(metaTagArgs, tagArgs...)
 	}
 
-	//Add changes for next/prev
-	if sqlWhereClause == "" {
-		sqlWhereClause += "WHERE "
-	} else {
-		sqlWhereClause += "AND "
-	}
-	if Next == false {
-		sqlWhereClause += "Images.ID < ? "
-	} else {
-		sqlWhereClause += "Images.ID > ? "
+	//Add changes for next/prev, the next image is the one before the target in the search results
+	comparator := "<"
+	if Next != Order.Ascending {
+		comparator = ">"
 	}
+	keysetCondition, keysetArgs := imageKeysetCondition(Order, comparator, TargetID)
+	sqlWhereClause += "AND " + keysetCondition + " "
 
 	if len(IncludeTags) > 0 {
 		sqlQuery = sqlQuery + sqlWhereClause + `GROUP BY ImageID) InnerStatement WHERE MatchingTags = ? `
@@ -355,11 +325,7 @@ func (DBConnection *MariaDBPlugin) getPrevNexImage(Tags []interfaces.TagInformat
 	}
 
 	//Add Order
-	order := "DESC "
-	if Next {
-		order = ""
-	}
-	sqlQuery = sqlQuery + `ORDER BY ID ` + order + `LIMIT 1;`
+	sqlQuery = sqlQuery + `ORDER BY ` + imageOrderBy(Order, len(IncludeTags) > 0, comparator == "<") + ` LIMIT 1;`
 
 	//Now construct arguments list. Order must follow query order
 	/*
@@ -380,17 +346,10 @@ func (DBConnection *MariaDBPlugin) getPrevNexImage(Tags []interfaces.TagInformat
 		queryArray = append(queryArray, tag)
 	}
 	//Add values for metatags
-	for _, tag := range MetaTags {
-		//Handle Complex Tags Here
-		if tag.Name == "InCollection" || tag.Name == "TagCount" || tag.Name == "Similar" { //Special Exception for cert MetaTags
-			continue
-		}
-		//Otherwise use default
-		queryArray = append(queryArray, tag.MetaValue)
-	}
+	queryArray = append(queryArray, metaTagArgs...)
 
-	//Add ID
-	queryArray = append(queryArray, TargetID)
+	//Add ID, the target's sort key is looked up by ID too
+	queryArray = append(queryArray, keysetArgs...)
 
 	//Add inclusive tag count, but only if we have any
 	if len(IncludeTags) > 0 {
@@ -398,7 +357,7 @@ func (DBConnection *MariaDBPlugin) getPrevNexImage(Tags []interfaces.TagInformat
 	}
 
 	//Run the count query (Count query does not use start/stride, so run this before we add those)
-	/*err := DBConnection.DBHandle.QueryRow(sqlCountQuery, queryArray...).Scan(&MaxResults) //Uneeded
+	/*err := DBConnection.DBHandle.QueryRowContext(ctx, sqlCountQuery, queryArray...).Scan(&MaxResults) //Uneeded
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError,"MariaDBPlugin/SearchImages", "0", logging.ResultFailure, []string{"Error running search query", sqlCountQuery, err.Error()})
 		return nil, 0, err
@@ -414,7 +373,7 @@ func (DBConnection *MariaDBPlugin) getPrevNexImage(Tags []interfaces.TagInformat
 	var Location string
 
 	//Now we have query and args, run the query
-	err := DBConnection.DBHandle.QueryRow(sqlQuery, queryArray...).Scan(&ImageID, &Name, &Location)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlQuery, queryArray...).Scan(&ImageID, &Name, &Location)
 	if err != nil {
 		return ToReturn, err
 	}
@@ -424,8 +383,8 @@ func (DBConnection *MariaDBPlugin) getPrevNexImage(Tags []interfaces.TagInformat
 }
 
 //GetRandomImage returns a random image (Returns a ImageInformation and an error/nil)
-func (DBConnection *MariaDBPlugin) GetRandomImage(Tags []interfaces.TagInformation) (interfaces.ImageInformation, uint64, error) {
-	imageInfo, resultCount, err := DBConnection.SearchImages(Tags, 0, 1)
+func (DBConnection *MariaDBPlugin) GetRandomImage(ctx context.Context, Tags []interfaces.TagInformation) (interfaces.ImageInformation, uint64, error) {
+	imageInfo, resultCount, err := DBConnection.SearchImages(ctx, Tags, 0, 1)
 
 	if err == nil {
 		if resultCount <= 0 {
@@ -437,7 +396,7 @@ func (DBConnection *MariaDBPlugin) GetRandomImage(Tags []interfaces.TagInformati
 
 		rando := rand.Float64()
 		randoID := uint64(rando * float64(resultCount))
-		imageInfo, _, err = DBConnection.SearchImages(Tags, randoID, 1)
+		imageInfo, _, err = DBConnection.SearchImages(ctx, Tags, randoID, 1)
 		if err == nil {
 			return imageInfo[0], resultCount, nil
 		}
@@ -445,3 +404,258 @@ func (DBConnection *MariaDBPlugin) GetRandomImage(Tags []interfaces.TagInformati
 	}
 	return interfaces.ImageInformation{}, resultCount, err
 }
+
+//GetImageUploadCounts returns how many images not in the recycle bin were uploaded in each day (ByDay) or month from Since up to but not including Until, oldest first
+func (DBConnection *MariaDBPlugin) GetImageUploadCounts(ctx context.Context, Since time.Time, Until time.Time, ByDay bool) ([]interfaces.UploadCount, error) {
+	Period := "DATE_FORMAT(UploadTime, '%Y-%m')"
+	if ByDay {
+		Period = "DATE_FORMAT(UploadTime, '%Y-%m-%d')"
+	}
+	Conditions := []string{"DeletedTime IS NULL"}
+	var Args []interface{}
+	if Since.IsZero() == false {
+		Conditions = append(Conditions, "UploadTime >= ?")
+		Args = append(Args, Since.UTC())
+	}
+	if Until.IsZero() == false {
+		Conditions = append(Conditions, "UploadTime < ?")
+		Args = append(Args, Until.UTC())
+	}
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, "SELECT "+Period+" AS Period, COUNT(*) FROM Images WHERE "+strings.Join(Conditions, " AND ")+" GROUP BY Period ORDER BY Period;", Args...)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/GetImageUploadCounts", "0", logging.ResultFailure, []string{"Failed to count uploads", err.Error()})
+		return nil, err
+	}
+	defer rows.Close()
+	var ToReturn []interfaces.UploadCount
+	for rows.Next() {
+		var Count interfaces.UploadCount
+		if err := rows.Scan(&Count.Period, &Count.Count); err != nil {
+			return nil, err
+		}
+		ToReturn = append(ToReturn, Count)
+	}
+	return ToReturn, rows.Err()
+}
+
+//imageMetaTagCondition returns the SQL condition for a MetaTag in an image search, along with the values for its placeholders
+func imageMetaTagCondition(tag interfaces.TagInformation) (string, []interface{}, error) {
+	metaTagQuery := ""
+
+	//Handle Comparator transforms
+	comparator := tag.Comparator
+	if tag.Exclude {
+		comparator = getInvertedComparator(comparator)
+	}
+	if comparator == "" {
+		return "", nil, errors.New("Failed to invert query to negate on " + tag.Name)
+	}
+
+	//Handle Complex Tags Here
+	if tagGroupValue, isTagGroup := tag.MetaValue.(interfaces.QueryGroup); isTagGroup { //Groups match when every tag in any one of their alternatives matches
+		var alternativeQueries []string
+		var groupArgs []interface{}
+		for _, alternative := range tagGroupValue.Alternatives {
+			var conditions []string
+			for _, groupTag := range alternative {
+				condition, conditionArgs, err := imageGroupTagCondition(groupTag)
+				if err != nil {
+					return "", nil, err
+				}
+				if condition != "" {
+					conditions = append(conditions, condition)
+					groupArgs = append(groupArgs, conditionArgs...)
+				}
+			}
+			if len(conditions) == 0 {
+				conditions = append(conditions, "1 = 0") //An alternative of only ignored tags matches nothing, rather than everything
+			}
+			alternativeQueries = append(alternativeQueries, "("+strings.Join(conditions, " AND ")+")")
+		}
+		metaTagQuery += "(" + strings.Join(alternativeQueries, " OR ") + ")"
+		if comparator == "!=" {
+			metaTagQuery = "NOT " + metaTagQuery
+		}
+		return metaTagQuery, groupArgs, nil
+	} else if tag.Name == "InCollection" { //Special Exception for InCollection
+		tagBoolValue, isTagValued := tag.MetaValue.(bool)
+		if isTagValued == false {
+			return "", nil, errors.New("Failed get value of " + tag.Name)
+		}
+		if (comparator == "=" && tagBoolValue == true) || (comparator == "!=" && tagBoolValue == false) {
+			comparator = " IN "
+		} else {
+			comparator = " NOT IN "
+		}
+		metaTagQuery += "Images.ID" + comparator + "(SELECT DISTINCT ImageID FROM CollectionMembers) "
+		return metaTagQuery, nil, nil
+	} else if tag.Name == "TagCount" { //Special Exception for TagCount
+		tagStringValue, isTagValued := tag.MetaValue.(string)
+		if isTagValued == false {
+			return "", nil, errors.New("Failed get value of " + tag.Name)
+		}
+		metaTagQuery += "Images.ID IN (SELECT ImageID FROM (SELECT ImageID, COUNT(*) AS TagCount FROM `ImageTags` GROUP BY ImageID) TagCountTBL WHERE TagCountTBL.TagCount " + comparator + " " + tagStringValue + ") "
+		return metaTagQuery, nil, nil
+	} else if tag.Name == "Similar" { //Special Exception for TagCount
+		tagImagedHashValue, isTagValued := tag.MetaValue.(interfaces.ImagedHash)
+		if isTagValued == false {
+			return "", nil, errors.New("Failed get value of " + tag.Name)
+		}
+		metaTagQuery += "Images.ID IN (SELECT ImageID FROM ImagedHashes WHERE (BIT_COUNT(hHash ^ " + strconv.FormatUint(tagImagedHashValue.ImagehHash, 10) + ")+BIT_COUNT(vHash ^ " + strconv.FormatUint(tagImagedHashValue.ImagevHash, 10) + ")) " + comparator + " " + strconv.FormatUint(tagImagedHashValue.SimilarityThreshold, 10) + ") "
+		return metaTagQuery, nil, nil
+	} else if tag.Name == "Ratio" { //Special Exception for Ratio, compared by cross multiplying to avoid division
+		tagRatioValue, isTagValued := tag.MetaValue.(interfaces.ImageRatio)
+		if isTagValued == false {
+			return "", nil, errors.New("Failed get value of " + tag.Name)
+		}
+		imageSide := "Images.Width * " + strconv.FormatUint(tagRatioValue.Height, 10)
+		ratioSide := "Images.Height * " + strconv.FormatUint(tagRatioValue.Width, 10)
+		//Decimal ratios are rounded, so they match images within the tolerance on either side
+		tolerance := ""
+		if tagRatioValue.Tolerance > 0 {
+			tolerance = " + Images.Height * " + strconv.FormatUint(tagRatioValue.Tolerance, 10)
+		}
+		var ratioQuery string
+		switch comparator {
+		case "!=":
+			ratioQuery = "(" + imageSide + " > " + ratioSide + tolerance + " OR " + imageSide + tolerance + " < " + ratioSide + ")"
+		case "<":
+			ratioQuery = imageSide + tolerance + " < " + ratioSide
+		case "<=":
+			ratioQuery = imageSide + " <= " + ratioSide + tolerance
+		case ">":
+			ratioQuery = imageSide + " > " + ratioSide + tolerance
+		case ">=":
+			ratioQuery = imageSide + tolerance + " >= " + ratioSide
+		default:
+			ratioQuery = imageSide + " <= " + ratioSide + tolerance + " AND " + imageSide + tolerance + " >= " + ratioSide
+		}
+		//Images without read metadata have no dimensions, so they only match when the ratio is excluded
+		if comparator == "!=" {
+			metaTagQuery += "(Images.Height = 0 OR " + ratioQuery + ") "
+		} else {
+			metaTagQuery += "(Images.Height > 0 AND " + ratioQuery + ") "
+		}
+		return metaTagQuery, nil, nil
+	} else if tag.Name == "UploadTime" { //Special Exception for UploadTime, dates cover a span of time
+		tagRangeValue, isTagValued := tag.MetaValue.(interfaces.TimeRange)
+		if isTagValued == false {
+			return "", nil, errors.New("Failed get value of " + tag.Name)
+		}
+		From, To := tagRangeValue.Bounds(comparator)
+		var rangeConditions []string
+		var rangeArgs []interface{}
+		if From.IsZero() == false {
+			rangeConditions = append(rangeConditions, "Images.UploadTime >= ?")
+			rangeArgs = append(rangeArgs, From.UTC())
+		}
+		if To.IsZero() == false {
+			rangeConditions = append(rangeConditions, "Images.UploadTime < ?")
+			rangeArgs = append(rangeArgs, To.UTC())
+		}
+		if comparator == "!=" {
+			metaTagQuery += "NOT (" + strings.Join(rangeConditions, " AND ") + ") "
+		} else {
+			metaTagQuery += "(" + strings.Join(rangeConditions, " AND ") + ") "
+		}
+		return metaTagQuery, rangeArgs, nil
+	}
+
+	metaTagQuery = metaTagQuery + "Images." + tag.Name + " "
+	metaTagQuery = metaTagQuery + comparator + " ?"
+	return metaTagQuery, []interface{}{tag.MetaValue}, nil
+}
+
+//imageGroupTagCondition returns the SQL condition for one tag of a search group. Tags that do not change the result return an empty condition
+func imageGroupTagCondition(tag interfaces.TagInformation) (string, []interface{}, error) {
+	if tag.IsMeta {
+		if tag.Exists == false {
+			return "", nil, nil
+		}
+		if _, isOrder := tag.MetaValue.(interfaces.SearchOrder); isOrder {
+			return "", nil, interfaces.ErrOrderInGroup //Orders only apply to a whole search
+		}
+		return imageMetaTagCondition(tag)
+	}
+	if tag.IsAlias {
+		return "", nil, nil //The tag it is an alias of is in the group alongside it
+	}
+	if tag.Exists == false {
+		if tag.Exclude {
+			return "1 = 1", nil, nil //Every image is without a tag that does not exist
+		}
+		return "1 = 0", nil, nil //No image can have a tag that does not exist
+	}
+	if tag.Exclude {
+		return "Images.ID NOT IN (SELECT ImageID FROM ImageTags WHERE TagID = ?)", []interface{}{tag.ID}, nil
+	}
+	return "Images.ID IN (SELECT ImageID FROM ImageTags WHERE TagID = ?)", []interface{}{tag.ID}, nil
+}
+
+//imageSortKey returns the SQL an order sorts images by, or nothing for the default order by ID
+func imageSortKey(Order interfaces.SearchOrder) string {
+	switch Order.Name {
+	case "score":
+		return "Images.ScoreAverage"
+	case "votes":
+		return "Images.ScoreVoters"
+	case "uploaded":
+		return "Images.UploadTime"
+	case "filesize":
+		return "Images.FileSize"
+	case "tagcount":
+		return "(SELECT COUNT(*) FROM ImageTags AS CountedTags WHERE CountedTags.ImageID = Images.ID)"
+	case "random":
+		//Same as SearchOrder.RandomOrderKey
+		scrambled := "((Images.ID * " + strconv.Itoa(interfaces.RandomOrderMultiplier) + " + " + strconv.FormatInt(Order.Seed, 10) + ") % " + strconv.Itoa(interfaces.RandomOrderModulus) + ")"
+		return "(" + scrambled + " * " + scrambled + " % " + strconv.Itoa(interfaces.RandomOrderModulus) + ")"
+	}
+	return ""
+}
+
+//imageSortKeyColumn returns the sort key of an order as an extra SortKey column, for the inner statement used when searching with tags
+func imageSortKeyColumn(Order interfaces.SearchOrder) string {
+	if sortKey := imageSortKey(Order); sortKey != "" {
+		return ", " + sortKey + " AS SortKey"
+	}
+	return ""
+}
+
+//imageOrderBy returns what to ORDER BY for an order, ties are broken by ID. InnerStatement is set when the sort key is a column of the inner statement
+func imageOrderBy(Order interfaces.SearchOrder, InnerStatement bool, Descending bool) string {
+	direction := " ASC"
+	if Descending {
+		direction = " DESC"
+	}
+	sortKey := imageSortKey(Order)
+	if sortKey == "" {
+		return "ID" + direction
+	}
+	if InnerStatement {
+		sortKey = "SortKey"
+	}
+	return sortKey + direction + ", ID" + direction
+}
+
+//imageKeysetCondition returns the condition for images that sort before (<) or after (>) the target image by an order's sort key and then ID, and the values for its placeholders
+func imageKeysetCondition(Order interfaces.SearchOrder, comparator string, TargetID uint64) (string, []interface{}) {
+	sortKey := imageSortKey(Order)
+	if sortKey == "" {
+		return "Images.ID " + comparator + " ?", []interface{}{TargetID}
+	}
+	targetSortKey := "(SELECT " + sortKey + " FROM Images WHERE Images.ID = ?)"
+	return "(" + sortKey + " " + comparator + " " + targetSortKey + " OR (" + sortKey + " = " + targetSortKey + " AND Images.ID " + comparator + " ?))", []interface{}{TargetID, TargetID, TargetID}
+}
+
+//imageCursorCondition is imageKeysetCondition for a search cursor, which keeps the sort key of the image it continues after so the next page does not depend on that image still existing
+func imageCursorCondition(Order interfaces.SearchOrder, comparator string, TargetID uint64, TargetSortKey int64) (string, []interface{}) {
+	sortKey := imageSortKey(Order)
+	if sortKey == "" {
+		return "Images.ID " + comparator + " ?", []interface{}{TargetID}
+	}
+	var targetSortKey interface{} = TargetSortKey
+	if Order.Name == "uploaded" {
+		targetSortKey = time.Unix(0, TargetSortKey).UTC()
+	}
+	return "(" + sortKey + " " + comparator + " ? OR (" + sortKey + " = ? AND Images.ID " + comparator + " ?))", []interface{}{targetSortKey, targetSortKey, TargetID}
+}
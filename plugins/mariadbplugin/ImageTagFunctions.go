@@ -1,6 +1,7 @@
 package mariadbplugin
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"go-image-board/interfaces"
@@ -9,14 +10,14 @@ import (
 )
 
 //GetImageTags returns a list of TagInformation for all tags that apply to the given image
-func (DBConnection *MariaDBPlugin) GetImageTags(ImageID uint64) ([]interfaces.TagInformation, error) {
+func (DBConnection *MariaDBPlugin) GetImageTags(ctx context.Context, ImageID uint64) ([]interfaces.TagInformation, error) {
 	var ToReturn []interfaces.TagInformation
 
 	//SELECT Tags.ID AS ID, Tags.Name AS Name, Tags.Description AS Description FROM ImageTags INNER JOIN Tags ON Tags.ID = ImageTags.TagID WHERE ImageID=?
 
 	sqlQuery := "SELECT Tags.ID, Tags.Name, Tags.Description FROM ImageTags INNER JOIN Tags ON Tags.ID = ImageTags.TagID WHERE ImageID=?"
 	//Pass the sql query to DB
-	rows, err := DBConnection.DBHandle.Query(sqlQuery, ImageID)
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, sqlQuery, ImageID)
 	if err != nil {
 		return nil, err
 	}
@@ -44,8 +45,8 @@ func (DBConnection *MariaDBPlugin) GetImageTags(ImageID uint64) ([]interfaces.Ta
 }
 
 //RemoveTag remove a tag association
-func (DBConnection *MariaDBPlugin) RemoveTag(TagID uint64, ImageID uint64) error {
-	if _, err := DBConnection.DBHandle.Exec("DELETE FROM ImageTags WHERE TagID=? AND ImageID=?;", TagID, ImageID); err != nil {
+func (DBConnection *MariaDBPlugin) RemoveTag(ctx context.Context, TagID uint64, ImageID uint64) error {
+	if _, err := DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM ImageTags WHERE TagID=? AND ImageID=?;", TagID, ImageID); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/RemoveTag", "0", logging.ResultFailure, []string{"Tag to remove was not on image", strconv.FormatUint(TagID, 10), strconv.FormatUint(ImageID, 10), err.Error()})
 		return err
 	}
@@ -53,41 +54,40 @@ func (DBConnection *MariaDBPlugin) RemoveTag(TagID uint64, ImageID uint64) error
 	return nil
 }
 
-//tagsContainID is a helper function to check if a TagInformation slice contains a specified ID
-func tagsContainID(ID uint64, Tags []interfaces.TagInformation) bool {
-	for _, Tag := range Tags {
-		if Tag.ID == ID {
-			return true
-		}
+//GetTagImageIDs returns the IDs of every image with a tag, including images in the recycle bin
+func (DBConnection *MariaDBPlugin) GetTagImageIDs(ctx context.Context, TagID uint64) ([]uint64, error) {
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, "SELECT ImageID FROM ImageTags WHERE TagID = ? ORDER BY ImageID;", TagID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/GetTagImageIDs", "0", logging.ResultFailure, []string{"Failed to query images with tag", strconv.FormatUint(TagID, 10), err.Error()})
+		return nil, err
 	}
-	return false
-}
-
-//tagsContainName is a helper function to check if a TagInformation slice contains a specified Name
-func tagsContainName(Name string, Tags []interfaces.TagInformation) bool {
-	for _, Tag := range Tags {
-		if Tag.Name == Name {
-			return true
+	defer rows.Close()
+	var ToReturn []uint64
+	for rows.Next() {
+		var ImageID uint64
+		if err := rows.Scan(&ImageID); err != nil {
+			return nil, err
 		}
+		ToReturn = append(ToReturn, ImageID)
 	}
-	return false
+	return ToReturn, rows.Err()
 }
 
 //ReplaceImageTags replaces all instances of ImageTags that have the specified tag with the new tag
-func (DBConnection *MariaDBPlugin) ReplaceImageTags(OldTagID uint64, NewTagID uint64, LinkerID uint64) error {
+func (DBConnection *MariaDBPlugin) ReplaceImageTags(ctx context.Context, OldTagID uint64, NewTagID uint64, LinkerID uint64) error {
 	query := `UPDATE ImageTags
 	SET TagID = ? , LinkerID=?
 	WHERE TagID=? AND ImageID NOT IN
 	(
 		SELECT ImageID from ImageTags WHERE TagID=?
 	);`
-	_, err := DBConnection.DBHandle.Exec(query, NewTagID, LinkerID, OldTagID, NewTagID)
+	_, err := DBConnection.DBHandle.ExecContext(ctx, query, NewTagID, LinkerID, OldTagID, NewTagID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/ReplaceImageTags", strconv.FormatUint(LinkerID, 10), logging.ResultFailure, []string{"Failed to update imagetags", err.Error()})
 		return err
 	}
 	//Remove any instances of old tag, first query replaces the old tag on all images, but does not allow duplicates. This query will remove the old tag that would have been replaced if it would not have lead to a duplicate.
-	_, err = DBConnection.DBHandle.Exec("DELETE FROM ImageTags WHERE TagID=?;", OldTagID)
+	_, err = DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM ImageTags WHERE TagID=?;", OldTagID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/ReplaceImageTags", strconv.FormatUint(LinkerID, 10), logging.ResultFailure, []string{"Failed to remove old instances of tag", err.Error()})
 		return err
@@ -96,10 +96,10 @@ func (DBConnection *MariaDBPlugin) ReplaceImageTags(OldTagID uint64, NewTagID ui
 }
 
 //BulkAddTag adds an association of a tag to image into the association table that already have another tag
-func (DBConnection *MariaDBPlugin) BulkAddTag(TagID uint64, OldTagID uint64, LinkerID uint64) error {
+func (DBConnection *MariaDBPlugin) BulkAddTag(ctx context.Context, TagID uint64, OldTagID uint64, LinkerID uint64) error {
 	//Prevent adding alias
-	tagInfo, err := DBConnection.GetTag(TagID, false)
-	oldTagInfo, err2 := DBConnection.GetTag(OldTagID, false)
+	tagInfo, err := DBConnection.GetTag(ctx, TagID, false)
+	oldTagInfo, err2 := DBConnection.GetTag(ctx, OldTagID, false)
 	if err != nil || err2 != nil {
 		return errors.New("Failed to validate tags")
 	}
@@ -114,7 +114,7 @@ func (DBConnection *MariaDBPlugin) BulkAddTag(TagID uint64, OldTagID uint64, Lin
 		OldTagID = oldTagInfo.AliasedID
 	}
 
-	if _, err := DBConnection.DBHandle.Exec("INSERT INTO ImageTags (TagID, ImageID, LinkerID) SELECT ?, ImageID, ? FROM ImageTags WHERE TagID=? AND ImageID NOT IN (SELECT ImageID FROM ImageTags WHERE TagID=?);", TagID, LinkerID, OldTagID, TagID); err != nil {
+	if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO ImageTags (TagID, ImageID, LinkerID) SELECT ?, ImageID, ? FROM ImageTags WHERE TagID=? AND ImageID NOT IN (SELECT ImageID FROM ImageTags WHERE TagID=?);", TagID, LinkerID, OldTagID, TagID); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/BulkAddTag", strconv.FormatUint(LinkerID, 10), logging.ResultFailure, []string{"Tag not added to image", strconv.FormatUint(OldTagID, 10), strconv.FormatUint(TagID, 10), err.Error()})
 		return err
 	}
@@ -122,16 +122,6 @@ func (DBConnection *MariaDBPlugin) BulkAddTag(TagID uint64, OldTagID uint64, Lin
 	return nil
 }
 
-//sliceContains is a helper function that returns whether a slice contains a specifc string
-func sliceContains(slice []string, item string) bool {
-	for _, sliceItem := range slice {
-		if sliceItem == item {
-			return true
-		}
-	}
-	return false
-}
-
 //inverts a tags comparator
 func getInvertedComparator(comparator string) string {
 	if comparator == "=" {
@@ -0,0 +1,34 @@
+package mariadbplugin
+
+import (
+	"go-image-board/config"
+	"go-image-board/plugins/dbconformance"
+	"os"
+	"testing"
+)
+
+//useTestServer points the configuration at the live server the tests run against, or skips the test if GIB_TEST_MARIADB_HOST is not set
+func useTestServer(t *testing.T) {
+	if os.Getenv("GIB_TEST_MARIADB_HOST") == "" {
+		t.Skip("GIB_TEST_MARIADB_HOST not set")
+	}
+	config.Configuration.DBHost = os.Getenv("GIB_TEST_MARIADB_HOST")
+	config.Configuration.DBPort = os.Getenv("GIB_TEST_MARIADB_PORT")
+	if config.Configuration.DBPort == "" {
+		config.Configuration.DBPort = "3306"
+	}
+	config.Configuration.DBName = os.Getenv("GIB_TEST_MARIADB_NAME")
+	config.Configuration.DBUser = os.Getenv("GIB_TEST_MARIADB_USER")
+	config.Configuration.DBPassword = os.Getenv("GIB_TEST_MARIADB_PASSWORD")
+	dbconformance.PrepareLogging()
+}
+
+//TestConformance runs against a live server, set GIB_TEST_MARIADB_HOST (and _PORT, _NAME, _USER, _PASSWORD as needed) to enable it
+func TestConformance(t *testing.T) {
+	useTestServer(t)
+	DB := &MariaDBPlugin{}
+	if err := DB.InitDatabase(); err != nil {
+		t.Fatalf("InitDatabase failed: %v", err)
+	}
+	dbconformance.RunSuite(t, DB)
+}
@@ -0,0 +1,85 @@
+package mariadbplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/config"
+	"go-image-board/interfaces"
+	"go-image-board/plugins/migrations"
+	"strconv"
+	"time"
+)
+
+//PlanMigrations reports the schema version and the migrations InitDatabase would run, without running them.
+//The schema comparison installs into a scratch database next to the configured one, so it needs CREATE and DROP privileges on the server
+func (DBConnection *MariaDBPlugin) PlanMigrations(ctx context.Context, CompareSchema bool) (interfaces.MigrationPlan, error) {
+	if DBConnection.pool == nil {
+		if err := DBConnection.connect(); err != nil {
+			return interfaces.MigrationPlan{}, err
+		}
+	}
+	version, err := DBConnection.getDatabaseVersion()
+	if err != nil {
+		version = migrations.NotInstalled
+	}
+	Plan := schemaMigrations.Plan(version)
+	if CompareSchema && version != migrations.NotInstalled {
+		Plan.SchemaDifferences, err = DBConnection.compareWithFreshInstall(ctx, version)
+		if err != nil {
+			Plan.SchemaCheckError = err.Error()
+		}
+	}
+	return Plan, nil
+}
+
+//compareWithFreshInstall migrates a scratch database up to version and compares its schema with the configured database
+func (DBConnection *MariaDBPlugin) compareWithFreshInstall(ctx context.Context, version int64) ([]string, error) {
+	scratchName := config.Configuration.DBName + "_schemacheck_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if _, err := DBConnection.pool.ExecContext(ctx, "CREATE DATABASE "+scratchName+";"); err != nil {
+		return nil, err
+	}
+	defer DBConnection.pool.ExecContext(context.Background(), "DROP DATABASE IF EXISTS "+scratchName+";")
+	scratch, err := openDatabase(scratchName)
+	if err != nil {
+		return nil, err
+	}
+	defer scratch.Close()
+	if _, err := schemaMigrations.Apply(ctx, scratch, migrations.NotInstalled, version, "MariaDBPlugin/PlanMigrations"); err != nil {
+		return nil, err
+	}
+
+	current, err := describeSchema(ctx, DBConnection.pool)
+	if err != nil {
+		return nil, err
+	}
+	fresh, err := describeSchema(ctx, scratch)
+	if err != nil {
+		return nil, err
+	}
+	return migrations.Diff(current, fresh), nil
+}
+
+//describeSchema lists the columns, indexes, foreign keys, routines, triggers and events of the database DB is connected to.
+//Column order is left out, as columns added by a migration always come last
+func describeSchema(ctx context.Context, DB *sql.DB) ([]string, error) {
+	queries := []struct {
+		Kind  string
+		Query string
+	}{
+		{"column", "SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE();"},
+		{"index", "SELECT TABLE_NAME, INDEX_NAME, CAST(NON_UNIQUE AS CHAR), GROUP_CONCAT(COLUMN_NAME ORDER BY SEQ_IN_INDEX) FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() GROUP BY TABLE_NAME, INDEX_NAME, NON_UNIQUE;"},
+		{"foreignkey", "SELECT TABLE_NAME, CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL;"},
+		{"routine", "SELECT ROUTINE_TYPE, ROUTINE_NAME, ROUTINE_DEFINITION FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = DATABASE();"},
+		{"trigger", "SELECT TRIGGER_NAME, EVENT_OBJECT_TABLE, ACTION_TIMING, EVENT_MANIPULATION, ACTION_STATEMENT FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA = DATABASE();"},
+		{"event", "SELECT EVENT_NAME, EVENT_DEFINITION, INTERVAL_VALUE, INTERVAL_FIELD FROM information_schema.EVENTS WHERE EVENT_SCHEMA = DATABASE();"},
+	}
+	var Description []string
+	for _, query := range queries {
+		Lines, err := migrations.DescribeRows(ctx, DB, query.Kind, query.Query)
+		if err != nil {
+			return nil, err
+		}
+		Description = append(Description, Lines...)
+	}
+	return Description, nil
+}
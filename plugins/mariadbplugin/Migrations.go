@@ -0,0 +1,406 @@
+package mariadbplugin
+
+import (
+	"go-image-board/plugins/migrations"
+)
+
+//schemaMigrations is the MariaDB schema, InitDatabase applies whichever of these the database has not had yet, a fresh install applies all of them.
+//MariaDB commits implicitly around DDL, so these cannot run in transactions.
+//To change the schema, append a migration with the next version, never edit one that has been released
+var schemaMigrations = migrations.NewSet(
+	migrations.Migration{
+		Version:       0,
+		Description:   "Initial schema",
+		NoTransaction: true,
+		Statements: []string{
+			"CREATE TABLE DBVersion (version BIGINT UNSIGNED NOT NULL);",
+			"INSERT INTO DBVersion (version) VALUES (0);",
+			//Images and tags
+			"CREATE TABLE Tags (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, Name VARCHAR(255) NOT NULL UNIQUE, Description VARCHAR(255), UploaderID BIGINT UNSIGNED NOT NULL, UploadTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, AliasedID BIGINT UNSIGNED NOT NULL DEFAULT 0, IsAlias BOOL NOT NULL DEFAULT FALSE);",
+			"CREATE TABLE Images (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, UploaderID BIGINT UNSIGNED NOT NULL, Name VARCHAR(255) NOT NULL, Location VARCHAR(255) UNIQUE NOT NULL, UploadTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL);",
+			"CREATE TABLE ImageTags (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, ImageID BIGINT UNSIGNED NOT NULL, TagID BIGINT UNSIGNED NOT NULL, LinkerID BIGINT UNSIGNED NOT NULL, LinkTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UNIQUE INDEX ImageTagPair (TagID,ImageID));",
+			//Users
+			"CREATE TABLE Users (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, Name VARCHAR(40) NOT NULL UNIQUE, EMail VARCHAR(255) NOT NULL UNIQUE, PasswordHash VARCHAR(255) NOT NULL, TokenID VARCHAR(255), IP VARCHAR(50), SecQuestionOne VARCHAR(50), SecQuestionTwo VARCHAR(50), SecQuestionThree VARCHAR(50), SecAnswerOne VARCHAR(255), SecAnswerTwo VARCHAR(255), SecAnswerThree VARCHAR(255), CreationTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, Disabled BOOL NOT NULL DEFAULT FALSE, Permissions BIGINT UNSIGNED NOT NULL DEFAULT 0);",
+			//Reserve system for auditing
+			"INSERT INTO Users (ID, Name, EMail, PasswordHash, Disabled) VALUES (0, 'SYSTEM', '', '', TRUE);",
+			//Auditing
+			"CREATE TABLE AuditLogs (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, UserID BIGINT UNSIGNED NOT NULL, Type VARCHAR(40), Info VARCHAR(2048) NOT NULL DEFAULT '');",
+		},
+	},
+	migrations.Migration{
+		Version:       1,
+		Description:   "Image ratings",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE Images ADD COLUMN (Rating VARCHAR(255) DEFAULT 'unrated');",
+			"UPDATE Images SET Rating = 'unrated';",
+		},
+	},
+	migrations.Migration{
+		Version:       2,
+		Description:   "Image scores",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE Images ADD COLUMN (ScoreTotal BIGINT NOT NULL DEFAULT 0, ScoreAverage BIGINT NOT NULL DEFAULT 0, ScoreVoters BIGINT NOT NULL DEFAULT 0);",
+			"CREATE TABLE ImageUserScores (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, UserID BIGINT UNSIGNED NOT NULL, ImageID BIGINT UNSIGNED NOT NULL, Score BIGINT NOT NULL, CreationTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UNIQUE INDEX ImageUserPair (UserID,ImageID));",
+		},
+	},
+	migrations.Migration{
+		Version:       3,
+		Description:   "Image sources",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE Images ADD COLUMN (Source VARCHAR(2000) NOT NULL DEFAULT '');",
+		},
+	},
+	migrations.Migration{
+		Version:       4,
+		Description:   "User search filters",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE Users ADD COLUMN (SearchFilter VARCHAR(255) NOT NULL DEFAULT '');",
+		},
+	},
+	migrations.Migration{
+		Version:       5,
+		Description:   "Collections",
+		NoTransaction: true,
+		Statements: []string{
+			"CREATE TABLE Collections (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, Name VARCHAR(255) NOT NULL UNIQUE, Description VARCHAR(255), UploaderID BIGINT UNSIGNED NOT NULL, UploadTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL);",
+			"CREATE TABLE CollectionMembers (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, ImageID BIGINT UNSIGNED NOT NULL, CollectionID BIGINT UNSIGNED NOT NULL, LinkerID BIGINT UNSIGNED NOT NULL, LinkTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UNIQUE INDEX ImageCollectionPair (CollectionID,ImageID), OrderWeight BIGINT UNSIGNED NOT NULL);",
+		},
+	},
+	migrations.Migration{
+		Version:       6,
+		Description:   "Audit log times",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE AuditLogs ADD COLUMN (LogTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL);",
+			"ALTER TABLE AuditLogs CHANGE COLUMN Info Info VARCHAR(10240) NOT NULL DEFAULT '';",
+		},
+	},
+	migrations.Migration{
+		Version:       7,
+		Description:   "Collection tags, audit cleanup and delete triggers",
+		NoTransaction: true,
+		Statements: []string{
+			"CREATE TABLE CollectionTags (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, CollectionID BIGINT UNSIGNED NOT NULL, TagID BIGINT UNSIGNED NOT NULL, LinkerID BIGINT UNSIGNED NOT NULL, LinkTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UNIQUE INDEX CollectionTagPair (TagID,CollectionID));",
+			`CREATE PROCEDURE LinkCollTags(IN collID BIGINT UNSIGNED)
+	BEGIN
+	-- Insert missing tags
+	INSERT INTO CollectionTags (TagID, CollectionID, LinkerID)
+	SELECT DISTINCT(ImageTags.TagID), collID, ImageTags.LinkerID
+	FROM ImageTags
+	INNER JOIN CollectionMembers on CollectionMembers.ImageID = ImageTags.ImageID
+	WHERE CollectionMembers.CollectionID = collID
+	AND TagID NOT IN (SELECT TagID from CollectionTags WHERE CollectionID = collID);
+	-- Remove extra tags
+	DELETE FROM CollectionTags
+	WHERE TagID NOT IN ( SELECT TagID
+						 FROM ImageTags
+						 INNER JOIN CollectionMembers on CollectionMembers.ImageID = ImageTags.ImageID
+						 WHERE CollectionMembers.CollectionID = collID
+					   )
+	AND CollectionID=collID;
+	END`,
+			`CREATE EVENT auditCleanup
+	ON SCHEDULE EVERY 1 DAY
+	DO
+	DELETE FROM AuditLogs WHERE LogTime < DATE_SUB(current_timestamp(), INTERVAL 30 DAY);`,
+			`CREATE TRIGGER onCollectionDelete BEFORE DELETE ON Collections
+	FOR EACH ROW BEGIN
+		DELETE FROM CollectionMembers WHERE CollectionID=OLD.ID;
+		DELETE FROM CollectionTags WHERE CollectionID=OLD.ID;
+	END`,
+			`CREATE TRIGGER onCollectionMemberAdd AFTER INSERT ON CollectionMembers
+	FOR EACH ROW BEGIN
+		CALL LinkCollTags(NEW.CollectionID);
+	END`,
+			`CREATE TRIGGER onCollectionMemberDelete AFTER DELETE ON CollectionMembers
+	FOR EACH ROW BEGIN
+		CALL LinkCollTags(OLD.CollectionID);
+	END`,
+			`CREATE TRIGGER onImageTagDelete AFTER DELETE ON ImageTags
+	FOR EACH ROW BEGIN
+		DECLARE collID BIGINT UNSIGNED;
+		DECLARE cursorDone BOOL DEFAULT FALSE;
+		DECLARE collCursor CURSOR FOR SELECT CollectionID FROM CollectionMembers WHERE ImageID = OLD.ImageID;
+		DECLARE CONTINUE HANDLER FOR NOT FOUND SET cursorDone = TRUE;
+		OPEN collCursor;
+		collLoop: LOOP
+			FETCH collCursor INTO collID;
+			IF cursorDone THEN
+				LEAVE collLoop;
+			END IF;
+			CALL LinkCollTags(collID);
+		END LOOP;
+	END`,
+			`CREATE TRIGGER onImageDelete BEFORE DELETE ON Images
+	FOR EACH ROW BEGIN
+		DELETE FROM ImageTags WHERE ImageID=OLD.ID;
+		DELETE FROM ImageUserScores WHERE ImageID=OLD.ID;
+		DELETE FROM CollectionMembers WHERE ImageID=OLD.ID;
+	END`,
+			`CREATE TRIGGER onImageTagInsert AFTER INSERT ON ImageTags
+	FOR EACH ROW BEGIN
+		DECLARE collID BIGINT UNSIGNED;
+		DECLARE cursorDone BOOL DEFAULT FALSE;
+		DECLARE collCursor CURSOR FOR SELECT CollectionID FROM CollectionMembers WHERE ImageID = NEW.ImageID;
+		DECLARE CONTINUE HANDLER FOR NOT FOUND SET cursorDone = TRUE;
+		OPEN collCursor;
+		collLoop: LOOP
+			FETCH collCursor INTO collID;
+			IF cursorDone THEN
+				LEAVE collLoop;
+			END IF;
+			CALL LinkCollTags(collID);
+		END LOOP;
+	END`,
+			`CREATE TRIGGER onTagDelete BEFORE DELETE ON Tags
+	FOR EACH ROW BEGIN
+		DELETE FROM ImageTags WHERE TagID=OLD.ID;
+		DELETE FROM CollectionTags WHERE TagID=OLD.ID;
+	END`,
+		},
+	},
+	migrations.Migration{
+		Version:       8,
+		Description:   "Faster LinkCollTags",
+		NoTransaction: true,
+		Statements: []string{
+			"DROP PROCEDURE IF EXISTS LinkCollTags;",
+			`CREATE PROCEDURE LinkCollTags(IN collID BIGINT UNSIGNED)
+	BEGIN
+	-- Insert missing tags
+	INSERT INTO CollectionTags (TagID, CollectionID, LinkerID)
+	SELECT DISTINCT ImageTags.TagID, collID, ImageTags.LinkerID
+	FROM ImageTags
+	INNER JOIN CollectionMembers on CollectionMembers.ImageID = ImageTags.ImageID
+	LEFT JOIN CollectionTags on CollectionTags.CollectionID = CollectionMembers.CollectionID AND CollectionTags.TagID = ImageTags.TagID
+	WHERE CollectionMembers.CollectionID = collID AND CollectionTags.CollectionID IS NULL;
+	-- Remove extra tags
+	DELETE FROM CollectionTags
+	WHERE TagID NOT IN ( SELECT TagID
+							FROM ImageTags
+							INNER JOIN CollectionMembers on CollectionMembers.ImageID = ImageTags.ImageID
+							WHERE CollectionMembers.CollectionID = collID
+						)
+	AND CollectionID=collID;
+	END`,
+		},
+	},
+	migrations.Migration{
+		Version:       9,
+		Description:   "Indexes and incremental collection tag triggers",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE ImageTags ADD INDEX(ImageID);",
+			"ALTER TABLE ImageTags ADD INDEX(LinkerID);",
+			"ALTER TABLE Images ADD INDEX(UploaderID);",
+			"ALTER TABLE Images ADD INDEX(Rating);",
+			"ALTER TABLE Images ADD INDEX(UploadTime);",
+			"ALTER TABLE Images ADD INDEX(ScoreAverage);",
+			`CREATE PROCEDURE AddMissingCollectionImageTags(IN imgID BIGINT UNSIGNED)
+	BEGIN
+		-- Insert missing tags
+		INSERT INTO CollectionTags(TagID, CollectionID, LinkerID)
+	SELECT DISTINCT
+		ImageTags.TagID,
+		CollectionMembers.CollectionID,
+		ImageTags.LinkerID
+	FROM
+		ImageTags
+	INNER JOIN CollectionMembers ON CollectionMembers.ImageID = ImageTags.ImageID
+	LEFT JOIN CollectionTags ON CollectionTags.CollectionID = CollectionMembers.CollectionID AND CollectionTags.TagID = ImageTags.TagID
+	WHERE
+		CollectionTags.CollectionID IS NULL AND ImageTags.ImageID = imgID;
+	END`,
+			`CREATE PROCEDURE RemSurplusCollectionImageTags(IN collID BIGINT UNSIGNED)
+	BEGIN
+		-- Remove extra tags
+		DELETE FROM CollectionTags
+		WHERE TagID NOT IN ( SELECT TagID
+								FROM ImageTags
+								INNER JOIN CollectionMembers on CollectionMembers.ImageID = ImageTags.ImageID
+								WHERE CollectionMembers.CollectionID = collID
+							)
+		AND CollectionID=collID;
+	END`,
+			"DROP TRIGGER IF EXISTS onImageTagInsert;",
+			"DROP TRIGGER IF EXISTS onCollectionMemberAdd;",
+			"DROP TRIGGER IF EXISTS onImageTagDelete;",
+			"DROP TRIGGER IF EXISTS onCollectionMemberDelete;",
+			`CREATE TRIGGER onImageTagDelete AFTER DELETE ON ImageTags
+	FOR EACH ROW BEGIN
+		DECLARE collID BIGINT UNSIGNED;
+		DECLARE cursorDone BOOL DEFAULT FALSE;
+		DECLARE collCursor CURSOR FOR SELECT CollectionID FROM CollectionMembers WHERE ImageID = OLD.ImageID;
+		DECLARE CONTINUE HANDLER FOR NOT FOUND SET cursorDone = TRUE;
+		OPEN collCursor;
+		collLoop: LOOP
+			FETCH collCursor INTO collID;
+			IF cursorDone THEN
+				LEAVE collLoop;
+			END IF;
+			CALL RemSurplusCollectionImageTags(collID);
+		END LOOP;
+	END`,
+			`CREATE TRIGGER onCollectionMemberDelete AFTER DELETE ON CollectionMembers
+	FOR EACH ROW BEGIN
+		CALL RemSurplusCollectionImageTags(OLD.CollectionID);
+	END`,
+			`CREATE TRIGGER onImageTagInsert AFTER INSERT ON ImageTags
+	FOR EACH ROW BEGIN
+		CALL AddMissingCollectionImageTags(NEW.ImageID);
+	END`,
+			`CREATE TRIGGER onCollectionMemberAdd AFTER INSERT ON CollectionMembers
+	FOR EACH ROW BEGIN
+		CALL AddMissingCollectionImageTags(NEW.ImageID);
+	END`,
+		},
+	},
+	migrations.Migration{
+		Version:       10,
+		Description:   "Image descriptions",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE Images ADD COLUMN (Description VARCHAR(1024) DEFAULT '');",
+		},
+	},
+	migrations.Migration{
+		Version:       11,
+		Description:   "Longer image descriptions",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE Images MODIFY Description TEXT NOT NULL DEFAULT '';",
+		},
+	},
+	migrations.Migration{
+		Version:       12,
+		Description:   "Image dHashes",
+		NoTransaction: true,
+		Statements: []string{
+			"CREATE TABLE ImagedHashes (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, ImageID BIGINT UNSIGNED NOT NULL, vHash BIGINT UNSIGNED NOT NULL, hHash BIGINT UNSIGNED NOT NULL, UNIQUE INDEX(ImageID), INDEX(vHash), INDEX(hHash));",
+		},
+	},
+	migrations.Migration{
+		Version:       13,
+		Description:   "Foreign keys",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE ImagedHashes ADD CONSTRAINT fk_ImagedHashesImageID FOREIGN KEY (ImageID) REFERENCES Images(ID);",
+			"ALTER TABLE CollectionMembers ADD CONSTRAINT fk_CollectionMembersImageID FOREIGN KEY (ImageID) REFERENCES Images(ID), ADD CONSTRAINT fk_CollectionMembersCollectionID FOREIGN KEY (CollectionID) REFERENCES Collections(ID);",
+			"ALTER TABLE CollectionTags ADD CONSTRAINT fk_CollectionTagsCollectionID FOREIGN KEY (CollectionID) REFERENCES Collections(ID), ADD CONSTRAINT fk_CollectionTagsTagID FOREIGN KEY (TagID) REFERENCES Tags(ID);",
+			"ALTER TABLE ImageTags ADD CONSTRAINT fk_ImageTagsImageID FOREIGN KEY (ImageID) REFERENCES Images(ID), ADD CONSTRAINT fk_ImageTagsTagID FOREIGN KEY (TagID) REFERENCES Tags(ID);",
+			"DROP TRIGGER onImageDelete;",
+			`CREATE TRIGGER onImageDelete BEFORE DELETE ON Images
+	FOR EACH ROW BEGIN
+		DELETE FROM ImageTags WHERE ImageID=OLD.ID;
+		DELETE FROM ImageUserScores WHERE ImageID=OLD.ID;
+		DELETE FROM CollectionMembers WHERE ImageID=OLD.ID;
+		DELETE FROM ImagedHashes WHERE ImageID=OLD.ID;
+	END`,
+		},
+	},
+	migrations.Migration{
+		Version:       14,
+		Description:   "Image recycle bin",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE Images ADD COLUMN DeletedTime TIMESTAMP NULL DEFAULT NULL, ADD COLUMN DeletedBy BIGINT UNSIGNED NOT NULL DEFAULT 0, ADD INDEX(DeletedTime);",
+			//Collection listings only count and preview images that are not in the recycle bin
+			"CREATE VIEW VisibleCollectionMembers AS SELECT CollectionMembers.* FROM CollectionMembers INNER JOIN Images ON Images.ID = CollectionMembers.ImageID WHERE Images.DeletedTime IS NULL;",
+		},
+	},
+	migrations.Migration{
+		Version:       15,
+		Description:   "Image revision history",
+		NoTransaction: true,
+		Statements: []string{
+			"CREATE TABLE ImageRevisions (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, ImageID BIGINT UNSIGNED NOT NULL, UserID BIGINT UNSIGNED NOT NULL, ChangeTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, Field VARCHAR(40) NOT NULL, TagID BIGINT UNSIGNED NOT NULL DEFAULT 0, OldValue TEXT NOT NULL, NewValue TEXT NOT NULL, INDEX(ImageID), INDEX(UserID, ChangeTime), CONSTRAINT fk_ImageRevisionsImageID FOREIGN KEY (ImageID) REFERENCES Images(ID));",
+			"DROP TRIGGER onImageDelete;",
+			`CREATE TRIGGER onImageDelete BEFORE DELETE ON Images
+	FOR EACH ROW BEGIN
+		DELETE FROM ImageTags WHERE ImageID=OLD.ID;
+		DELETE FROM ImageUserScores WHERE ImageID=OLD.ID;
+		DELETE FROM CollectionMembers WHERE ImageID=OLD.ID;
+		DELETE FROM ImagedHashes WHERE ImageID=OLD.ID;
+		DELETE FROM ImageRevisions WHERE ImageID=OLD.ID;
+	END`,
+		},
+	},
+	migrations.Migration{
+		Version:       16,
+		Description:   "Collection changes in image revisions",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE ImageRevisions ADD COLUMN CollectionID BIGINT UNSIGNED NOT NULL DEFAULT 0 AFTER TagID;",
+		},
+	},
+	migrations.Migration{
+		Version:       17,
+		Description:   "Audit log search indexes",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE AuditLogs ADD INDEX(LogTime), ADD INDEX(UserID, LogTime), ADD INDEX(Type, LogTime);",
+		},
+	},
+	migrations.Migration{
+		Version:       18,
+		Description:   "Typed audit events",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE AuditLogs CHANGE COLUMN Type Action VARCHAR(40), ADD COLUMN TargetType VARCHAR(20) NOT NULL DEFAULT '' AFTER Action, ADD COLUMN TargetID BIGINT UNSIGNED NOT NULL DEFAULT 0 AFTER TargetType, ADD COLUMN Details TEXT NOT NULL AFTER TargetID, ADD INDEX(TargetType, TargetID, LogTime);",
+			"UPDATE AuditLogs SET Details = IF(Info = '', '{}', JSON_OBJECT('Info', Info));",
+			"ALTER TABLE AuditLogs DROP COLUMN Info;",
+		},
+	},
+	migrations.Migration{
+		Version:       19,
+		Description:   "Audit log retention moves out of the database",
+		NoTransaction: true,
+		Statements: []string{
+			"DROP EVENT IF EXISTS auditCleanup;",
+		},
+	},
+	migrations.Migration{
+		Version:       20,
+		Description:   "Background job queue",
+		NoTransaction: true,
+		Statements: []string{
+			"CREATE TABLE Jobs (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, Type VARCHAR(20) NOT NULL, ImageID BIGINT UNSIGNED NOT NULL, Status VARCHAR(20) NOT NULL DEFAULT 'PENDING', Attempts BIGINT UNSIGNED NOT NULL DEFAULT 0, LastError TEXT NOT NULL, RunAfter TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, CreatedTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UpdatedTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UNIQUE INDEX(Type, ImageID), INDEX(Status, RunAfter));",
+		},
+	},
+	migrations.Migration{
+		Version:       21,
+		Description:   "Image metadata",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE Images ADD COLUMN (Width BIGINT UNSIGNED NOT NULL DEFAULT 0, Height BIGINT UNSIGNED NOT NULL DEFAULT 0, FileSize BIGINT UNSIGNED NOT NULL DEFAULT 0, MIMEType VARCHAR(255) NOT NULL DEFAULT '', Duration DOUBLE NOT NULL DEFAULT 0, Codec VARCHAR(255) NOT NULL DEFAULT '');",
+		},
+	},
+	migrations.Migration{
+		Version:       22,
+		Description:   "Image metadata search indexes",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE Images ADD INDEX(Width), ADD INDEX(Height), ADD INDEX(FileSize), ADD INDEX(MIMEType), ADD INDEX(Duration);",
+		},
+	},
+	migrations.Migration{
+		Version:       23,
+		Description:   "Saved searches",
+		NoTransaction: true,
+		Statements: []string{
+			"CREATE TABLE SavedSearches (ID BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE, UserID BIGINT UNSIGNED NOT NULL, Name VARCHAR(40) NOT NULL, Query TEXT NOT NULL, UNIQUE INDEX(UserID, Name), CONSTRAINT fk_SavedSearchesUserID FOREIGN KEY (UserID) REFERENCES Users(ID) ON DELETE CASCADE);",
+		},
+	},
+	migrations.Migration{
+		Version:       24,
+		Description:   "Job reruns",
+		NoTransaction: true,
+		Statements: []string{
+			"ALTER TABLE Jobs ADD COLUMN Rerun BOOLEAN NOT NULL DEFAULT FALSE;",
+		},
+	},
+)
@@ -0,0 +1,50 @@
+package mariadbplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"strconv"
+)
+
+//GetSavedSearches returns a user's saved searches, sorted by name
+func (DBConnection *MariaDBPlugin) GetSavedSearches(ctx context.Context, UserID uint64) ([]interfaces.SavedSearch, error) {
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, "SELECT ID, UserID, Name, Query FROM SavedSearches WHERE UserID = ? ORDER BY Name;", UserID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/GetSavedSearches", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to query saved searches", err.Error()})
+		return nil, err
+	}
+	defer rows.Close()
+	var ToReturn []interfaces.SavedSearch
+	for rows.Next() {
+		var Search interfaces.SavedSearch
+		if err := rows.Scan(&Search.ID, &Search.UserID, &Search.Name, &Search.Query); err != nil {
+			return nil, err
+		}
+		ToReturn = append(ToReturn, Search)
+	}
+	return ToReturn, rows.Err()
+}
+
+//SetSavedSearch saves Query for a user under Name, replacing the query of their saved search by that name if they have one
+func (DBConnection *MariaDBPlugin) SetSavedSearch(ctx context.Context, UserID uint64, Name string, Query string) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO SavedSearches (UserID, Name, Query) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE Query = VALUES(Query);", UserID, Name, Query)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/SetSavedSearch", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to save search", Name, err.Error()})
+	}
+	return err
+}
+
+//DeleteSavedSearch removes a user's saved search, returns sql.ErrNoRows if they have none by that name
+func (DBConnection *MariaDBPlugin) DeleteSavedSearch(ctx context.Context, UserID uint64, Name string) error {
+	result, err := DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM SavedSearches WHERE UserID = ? AND Name = ?;", UserID, Name)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/DeleteSavedSearch", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to delete saved search", Name, err.Error()})
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
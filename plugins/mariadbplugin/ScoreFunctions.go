@@ -1,6 +1,7 @@
 package mariadbplugin
 
 import (
+	"context"
 	"database/sql"
 	"go-image-board/logging"
 	"strconv"
@@ -9,11 +10,11 @@ import (
 //Score operations
 
 //UpdateUserVoteScore Either creates or changes a user's vote on an image
-func (DBConnection *MariaDBPlugin) UpdateUserVoteScore(UserID uint64, ImageID uint64, Score int64) error {
+func (DBConnection *MariaDBPlugin) UpdateUserVoteScore(ctx context.Context, UserID uint64, ImageID uint64, Score int64) error {
 	//Check if user voted before
 	sqlQuery := "SELECT COUNT(*) FROM ImageUserScores WHERE UserID=? AND ImageID=?;"
 	count := 0
-	err := DBConnection.DBHandle.QueryRow(sqlQuery, UserID, ImageID).Scan(&count)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlQuery, UserID, ImageID).Scan(&count)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateUserVoteScore", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to verify score existance", err.Error()})
 		return err
@@ -25,27 +26,32 @@ func (DBConnection *MariaDBPlugin) UpdateUserVoteScore(UserID uint64, ImageID ui
 		//Create if not
 		sqlQuery = "INSERT INTO ImageUserScores (Score, UserID, ImageID) VALUES (?, ?, ?);"
 	}
-	_, err = DBConnection.DBHandle.Exec(sqlQuery, Score, UserID, ImageID)
+	_, err = DBConnection.DBHandle.ExecContext(ctx, sqlQuery, Score, UserID, ImageID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateUserVoteScore", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to update/add score", err.Error()})
 		return err
 	}
 	logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateUserVoteScore", strconv.FormatUint(UserID, 10), logging.ResultSuccess, []string{"Score added/updated"})
-	go DBConnection.UpdateScoreOnImage(ImageID)
+	if DBConnection.inTransaction() {
+		//A background update would not see the uncommitted transaction, so run it as part of it instead
+		DBConnection.UpdateScoreOnImage(ctx, ImageID)
+	} else {
+		go DBConnection.UpdateScoreOnImage(context.Background(), ImageID)
+	}
 	return nil
 }
 
 //UpdateScoreOnImage update ScoreTotal, ScoreAverage, and ScoreVoters on an image
-func (DBConnection *MariaDBPlugin) UpdateScoreOnImage(ImageID uint64) error {
+func (DBConnection *MariaDBPlugin) UpdateScoreOnImage(ctx context.Context, ImageID uint64) error {
 	sqlQuery := "SELECT COUNT(Score), SUM(Score), AVG(Score) FROM ImageUserScores WHERE ImageID=?;"
 	var count, sum, average float64
-	err := DBConnection.DBHandle.QueryRow(sqlQuery, ImageID).Scan(&count, &sum, &average)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlQuery, ImageID).Scan(&count, &sum, &average)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateScoreOnImage", "0", logging.ResultFailure, []string{"Failed to pull score metrics", err.Error()})
 		return err
 	}
 	sqlQuery = "UPDATE Images SET ScoreTotal = ?, ScoreAverage = ?, ScoreVoters = ? WHERE ID=?;"
-	_, err = DBConnection.DBHandle.Exec(sqlQuery, sum, average, count, ImageID)
+	_, err = DBConnection.DBHandle.ExecContext(ctx, sqlQuery, sum, average, count, ImageID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateScoreOnImage", "0", logging.ResultFailure, []string{"Failed to update score for image", err.Error()})
 		return err
@@ -54,11 +60,11 @@ func (DBConnection *MariaDBPlugin) UpdateScoreOnImage(ImageID uint64) error {
 }
 
 //GetUserVoteScore Returns a user's vote on an image
-func (DBConnection *MariaDBPlugin) GetUserVoteScore(UserID uint64, ImageID uint64) (int64, error) {
+func (DBConnection *MariaDBPlugin) GetUserVoteScore(ctx context.Context, UserID uint64, ImageID uint64) (int64, error) {
 	//Check if user voted before
 	sqlQuery := "SELECT Score FROM ImageUserScores WHERE UserID=? AND ImageID=?;"
 	var score int64
-	err := DBConnection.DBHandle.QueryRow(sqlQuery, UserID, ImageID).Scan(&score)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlQuery, UserID, ImageID).Scan(&score)
 	if err != nil {
 		if err != sql.ErrNoRows {
 			logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateUserVoteScore", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to verify score existance", err.Error()})
@@ -1,11 +1,11 @@
 package mariadbplugin
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"go-image-board/interfaces"
 	"go-image-board/logging"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -13,38 +13,17 @@ import (
 	"github.com/go-sql-driver/mysql"
 )
 
-//Tag Operations
-var regexTagName = regexp.MustCompile("[^a-zA-Z0-9_-]") //Used to cleanup tag names
-var regexWhiteSpace = regexp.MustCompile("\\s{2,}")     //Matches 2 or more consecutive whitespace
-var regexTagValue = regexp.MustCompile("[^a-zA-Z0-9_\\-\\.]")
-
-func prepareTagName(Name string) string {
-	//Lowercase Name -> Trimmed front and end of whitespace -> any inner whitespace reduced and underscored
-	Name = regexWhiteSpace.ReplaceAllString(strings.TrimSpace(strings.ToLower(Name)), "_") //Replace all whitespace with _
-	//Case of metatag
-	if strings.Count(Name, ":") == 1 {
-		//Assume a metatag
-		NameValue := strings.Split(Name, ":")
-		value, comparator := getTagComparator(NameValue[1]) //Strip comparator, so it does not get replaced by a _
-		Name = regexTagName.ReplaceAllString(NameValue[0], "_") + ":" + comparator + regexTagValue.ReplaceAllString(value, "_")
-	} else {
-		//Then any special characters replaced with _
-		Name = regexTagName.ReplaceAllString(Name, "_")
-	}
-	return Name
-}
-
 //NewTag adds a tag with the provided information
-func (DBConnection *MariaDBPlugin) NewTag(Name string, Description string, UploaderID uint64) (uint64, error) {
+func (DBConnection *MariaDBPlugin) NewTag(ctx context.Context, Name string, Description string, UploaderID uint64) (uint64, error) {
 	//Cleanup name
-	Name = prepareTagName(Name)
+	Name = interfaces.PrepareTagName(Name)
 
 	if len(Name) < 3 || len(Name) > 255 || len(Description) > 255 {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/NewTag", strconv.FormatUint(UploaderID, 10), logging.ResultFailure, []string{"Failed to add tag dues to size of name/description", Name, Description})
 		return 0, errors.New("name or description outside of right sizes")
 	}
 
-	resultInfo, err := DBConnection.DBHandle.Exec("INSERT INTO Tags (Name, Description, UploaderID) VALUES (?, ?, ?);", Name, Description, UploaderID)
+	resultInfo, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO Tags (Name, Description, UploaderID) VALUES (?, ?, ?);", Name, Description, UploaderID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/NewTag", strconv.FormatUint(UploaderID, 10), logging.ResultFailure, []string{"Failed to add tag", err.Error()})
 		return 0, err
@@ -56,10 +35,10 @@ func (DBConnection *MariaDBPlugin) NewTag(Name string, Description string, Uploa
 }
 
 //DeleteTag removes a tag
-func (DBConnection *MariaDBPlugin) DeleteTag(TagID uint64) error {
+func (DBConnection *MariaDBPlugin) DeleteTag(ctx context.Context, TagID uint64) error {
 	//Ensure not in use
 	var useCount int
-	if err := DBConnection.DBHandle.QueryRow("SELECT COUNT(*) AS UseCount FROM ImageTags WHERE TagID = ?", TagID).Scan(&useCount); err != nil {
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT COUNT(*) AS UseCount FROM ImageTags WHERE TagID = ?", TagID).Scan(&useCount); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/DeleteTag", "0", logging.ResultFailure, []string{"Failed to get tag use information", err.Error()})
 		return errors.New("failed to check tag to delete usage")
 	}
@@ -70,7 +49,7 @@ func (DBConnection *MariaDBPlugin) DeleteTag(TagID uint64) error {
 	}
 
 	//Delete
-	_, err := DBConnection.DBHandle.Exec("DELETE FROM Tags WHERE ID=?;", TagID)
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM Tags WHERE ID=?;", TagID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/DeleteTag", "0", logging.ResultFailure, []string{"Failed to delete tag", err.Error(), strconv.FormatUint(TagID, 10)})
 	} else {
@@ -80,7 +59,7 @@ func (DBConnection *MariaDBPlugin) DeleteTag(TagID uint64) error {
 }
 
 //AddTag adds an association of a tag to image into the association table
-func (DBConnection *MariaDBPlugin) AddTag(TagIDs []uint64, ImageID uint64, LinkerID uint64) error {
+func (DBConnection *MariaDBPlugin) AddTag(ctx context.Context, TagIDs []uint64, ImageID uint64, LinkerID uint64) error {
 	if len(TagIDs) == 0 {
 		return errors.New("No tags provided")
 	}
@@ -90,7 +69,7 @@ func (DBConnection *MariaDBPlugin) AddTag(TagIDs []uint64, ImageID uint64, Linke
 	queryArray := []interface{}{}
 	for i := 0; i < len(TagIDs); i++ {
 		TagID := TagIDs[i]
-		tagInfo, err := DBConnection.GetTag(TagID, false)
+		tagInfo, err := DBConnection.GetTag(ctx, TagID, false)
 		if err != nil {
 			return errors.New("Failed to validate tag " + strconv.FormatUint(TagID, 10))
 		}
@@ -112,7 +91,7 @@ func (DBConnection *MariaDBPlugin) AddTag(TagIDs []uint64, ImageID uint64, Linke
 	values = values[:len(values)-1] + " ON DUPLICATE KEY UPDATE LinkerID=?;" //Strip last comma, add end
 	queryArray = append(queryArray, LinkerID)                                //For duplicate key update
 	sqlQuery := "INSERT INTO ImageTags (TagID, ImageID, LinkerID) VALUES" + values
-	if _, err := DBConnection.DBHandle.Exec(sqlQuery, queryArray...); err != nil {
+	if _, err := DBConnection.DBHandle.ExecContext(ctx, sqlQuery, queryArray...); err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/AddTag", strconv.FormatUint(LinkerID, 10), logging.ResultFailure, []string{"Tags not added to image", strconv.FormatUint(ImageID, 10), sqlQuery, err.Error()})
 		return err
 	}
@@ -121,12 +100,12 @@ func (DBConnection *MariaDBPlugin) AddTag(TagIDs []uint64, ImageID uint64, Linke
 }
 
 //GetAllTags returns a list of all tags, but only the ID, Name, Description, and IsAlias
-func (DBConnection *MariaDBPlugin) GetAllTags() ([]interfaces.TagInformation, error) {
+func (DBConnection *MariaDBPlugin) GetAllTags(ctx context.Context) ([]interfaces.TagInformation, error) {
 	var ToReturn []interfaces.TagInformation
 
 	sqlQuery := "SELECT ID, Name, Description, IsAlias FROM Tags ORDER BY Name"
 	//Pass the sql query to DB
-	rows, err := DBConnection.DBHandle.Query(sqlQuery)
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, sqlQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -155,7 +134,7 @@ func (DBConnection *MariaDBPlugin) GetAllTags() ([]interfaces.TagInformation, er
 }
 
 //GetTag returns detailed information on one tag
-func (DBConnection *MariaDBPlugin) GetTag(ID uint64, IncludeCount bool) (interfaces.TagInformation, error) {
+func (DBConnection *MariaDBPlugin) GetTag(ctx context.Context, ID uint64, IncludeCount bool) (interfaces.TagInformation, error) {
 	sqlQuery := "SELECT Name, Description, UploaderID, UploadTime, AliasedID, IsAlias FROM Tags WHERE ID=?"
 	//Pass the sql query to DB
 	//Placeholders for data returned by each row
@@ -167,7 +146,7 @@ func (DBConnection *MariaDBPlugin) GetTag(ID uint64, IncludeCount bool) (interfa
 	var AliasedID uint64
 	var IsAlias bool
 	var TagCount uint64
-	err := DBConnection.DBHandle.QueryRow(sqlQuery, ID).Scan(&Name, &Description, &UploaderID, &NUploadTime, &AliasedID, &IsAlias)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlQuery, ID).Scan(&Name, &Description, &UploaderID, &NUploadTime, &AliasedID, &IsAlias)
 	if err != nil {
 		return interfaces.TagInformation{ID: ID, Exists: false}, err
 	}
@@ -183,7 +162,7 @@ func (DBConnection *MariaDBPlugin) GetTag(ID uint64, IncludeCount bool) (interfa
 
 	if IncludeCount {
 		sqlQuery := "SELECT COUNT(*) as TagCount FROM ImageTags WHERE TagID=?"
-		err := DBConnection.DBHandle.QueryRow(sqlQuery, ID).Scan(&TagCount)
+		err := DBConnection.DBHandle.QueryRowContext(ctx, sqlQuery, ID).Scan(&TagCount)
 		if err != nil {
 			return interfaces.TagInformation{ID: ID, Exists: false}, err
 		}
@@ -193,7 +172,7 @@ func (DBConnection *MariaDBPlugin) GetTag(ID uint64, IncludeCount bool) (interfa
 }
 
 //GetTagByName returns detailed information on one tag as queried by name
-func (DBConnection *MariaDBPlugin) GetTagByName(Name string) (interfaces.TagInformation, error) {
+func (DBConnection *MariaDBPlugin) GetTagByName(ctx context.Context, Name string) (interfaces.TagInformation, error) {
 	sqlQuery := "SELECT ID, Description, UploaderID, UploadTime, AliasedID, IsAlias FROM Tags WHERE Name=?"
 	//Pass the sql query to DB
 	//Placeholders for data returned by each row
@@ -204,7 +183,7 @@ func (DBConnection *MariaDBPlugin) GetTagByName(Name string) (interfaces.TagInfo
 	var UploadTime time.Time
 	var AliasedID uint64
 	var IsAlias bool
-	err := DBConnection.DBHandle.QueryRow(sqlQuery, Name).Scan(&TagID, &Description, &UploaderID, &NUploadTime, &AliasedID, &IsAlias)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlQuery, Name).Scan(&TagID, &Description, &UploaderID, &NUploadTime, &AliasedID, &IsAlias)
 	if err != nil {
 		return interfaces.TagInformation{Name: Name, Exists: false}, err
 	}
@@ -222,9 +201,9 @@ func (DBConnection *MariaDBPlugin) GetTagByName(Name string) (interfaces.TagInfo
 }
 
 //UpdateTag updates a pre-existing tag
-func (DBConnection *MariaDBPlugin) UpdateTag(TagID uint64, Name string, Description string, AliasedID uint64, IsAlias bool, RequestorID uint64) error {
+func (DBConnection *MariaDBPlugin) UpdateTag(ctx context.Context, TagID uint64, Name string, Description string, AliasedID uint64, IsAlias bool, RequestorID uint64) error {
 	//Cleanup name
-	Name = prepareTagName(Name)
+	Name = interfaces.PrepareTagName(Name)
 	if len(Name) < 3 || len(Name) > 255 || len(Description) > 255 {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateTag", strconv.FormatUint(RequestorID, 10), logging.ResultFailure, []string{"Failed to update tag dues to size", Name, Description})
 		return errors.New("name or description outside of right sizes")
@@ -232,13 +211,13 @@ func (DBConnection *MariaDBPlugin) UpdateTag(TagID uint64, Name string, Descript
 
 	if IsAlias {
 		//Prevent adding alias
-		tagInfo, err := DBConnection.GetTag(AliasedID, false)
+		tagInfo, err := DBConnection.GetTag(ctx, AliasedID, false)
 		if err != nil || tagInfo.IsAlias {
 			return errors.New("Tag to alias could not be found, or is an alias itself")
 		}
 	}
 
-	_, err := DBConnection.DBHandle.Exec("UPDATE Tags SET Name = ?, Description=?, AliasedID=?, IsAlias=? WHERE ID=?;", Name, Description, AliasedID, IsAlias, TagID)
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Tags SET Name = ?, Description=?, AliasedID=?, IsAlias=? WHERE ID=?;", Name, Description, AliasedID, IsAlias, TagID)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateTag", strconv.FormatUint(RequestorID, 10), logging.ResultFailure, []string{"Failed to update tag", err.Error()})
 		return err
@@ -246,14 +225,19 @@ func (DBConnection *MariaDBPlugin) UpdateTag(TagID uint64, Name string, Descript
 	logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/UpdateTag", strconv.FormatUint(RequestorID, 10), logging.ResultSuccess, []string{"Image added"})
 
 	if IsAlias {
-		go DBConnection.ReplaceImageTags(TagID, AliasedID, RequestorID)
+		if DBConnection.inTransaction() {
+			//A background update would not see the uncommitted transaction, so run it as part of it instead
+			DBConnection.ReplaceImageTags(ctx, TagID, AliasedID, RequestorID)
+		} else {
+			go DBConnection.ReplaceImageTags(context.Background(), TagID, AliasedID, RequestorID)
+		}
 	}
 
 	return nil
 }
 
 //SearchTags returns a list of tags like the provided name, but only the ID, Name, Description, and IsAlias
-func (DBConnection *MariaDBPlugin) SearchTags(name string, PageStart uint64, PageStride uint64, WildcardForwardOnly bool, SortByUsage bool) ([]interfaces.TagInformation, uint64, error) {
+func (DBConnection *MariaDBPlugin) SearchTags(ctx context.Context, name string, PageStart uint64, PageStride uint64, WildcardForwardOnly bool, SortByUsage bool) ([]interfaces.TagInformation, uint64, error) {
 	var ToReturn []interfaces.TagInformation
 	queryArray := []interface{}{}
 	sqlQuery := "SELECT ID, Name, Description, IsAlias FROM Tags"
@@ -290,7 +274,7 @@ func (DBConnection *MariaDBPlugin) SearchTags(name string, PageStart uint64, Pag
 	//Query Count
 	//Run the count query (Count query does not use start/stride, so run this before we add those)
 	var MaxResults uint64
-	err := DBConnection.DBHandle.QueryRow(sqlCountQuery, queryArray...).Scan(&MaxResults)
+	err := DBConnection.DBHandle.QueryRowContext(ctx, sqlCountQuery, queryArray...).Scan(&MaxResults)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/SearchTags", "0", logging.ResultFailure, []string{"Error running count query", sqlCountQuery, err.Error()})
 		return nil, 0, err
@@ -301,7 +285,7 @@ func (DBConnection *MariaDBPlugin) SearchTags(name string, PageStart uint64, Pag
 	queryArray = append(queryArray, PageStart)
 
 	//Pass the sql query to DB
-	rows, err := DBConnection.DBHandle.Query(sqlQuery, queryArray...)
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, sqlQuery, queryArray...)
 	if err != nil {
 		return nil, 0, err
 	}
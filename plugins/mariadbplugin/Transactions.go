@@ -0,0 +1,48 @@
+package mariadbplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+)
+
+//sqlHandle is implemented by both *sql.DB and *sql.Tx, so plugin functions run the same way inside and outside of RunInTransaction
+type sqlHandle interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+//RunInTransaction calls Work with a copy of the plugin bound to a single transaction, committing it if Work returns nil and rolling it back otherwise
+func (DBConnection *MariaDBPlugin) RunInTransaction(ctx context.Context, Work func(Tx interfaces.DBInterface) error) error {
+	if DBConnection.inTransaction() {
+		//Join the outer transaction, it decides whether to commit
+		return Work(DBConnection)
+	}
+	tx, err := DBConnection.pool.BeginTx(ctx, nil)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/RunInTransaction", "0", logging.ResultFailure, []string{"Failed to begin transaction", err.Error()})
+		return err
+	}
+	if err := Work(&MariaDBPlugin{DBHandle: tx, pool: DBConnection.pool}); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && rollbackErr != sql.ErrTxDone {
+			logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/RunInTransaction", "0", logging.ResultFailure, []string{"Failed to roll back transaction", rollbackErr.Error()})
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		logging.WriteLog(logging.LogLevelError, "MariaDBPlugin/RunInTransaction", "0", logging.ResultFailure, []string{"Failed to commit transaction", err.Error()})
+		return err
+	}
+	return nil
+}
+
+//inTransaction returns true if this copy of the plugin was made by RunInTransaction
+func (DBConnection *MariaDBPlugin) inTransaction() bool {
+	_, isTx := DBConnection.DBHandle.(*sql.Tx)
+	return isTx
+}
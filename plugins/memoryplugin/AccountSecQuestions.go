@@ -0,0 +1,112 @@
+package memoryplugin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-image-board/logging"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+//SetSecurityQuestions changes a user's security questions (nil if success)
+func (DBConnection *MemoryPlugin) SetSecurityQuestions(ctx context.Context, userName string, questionOne string, questionTwo string, questionThree string, answerOne []byte, answerTwo []byte, answerThree []byte, challengeAnswer []byte) error {
+	answerOneHash, errA := getPasswordHash(answerOne)
+	answerTwoHash, errB := getPasswordHash(answerTwo)
+	answerThreeHash, errC := getPasswordHash(answerThree)
+
+	if errA != nil || errB != nil || errC != nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/RevokeToken", userName, logging.ResultFailure, []string{"Failed to hash security question answers", userName})
+		return errors.New("Failed to set answers")
+	}
+
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	user := DBConnection.getUserByName(userName)
+	if user == nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/SetSecurityQuestions", userName, logging.ResultFailure, []string{"Security questions failed to update. Challenge could not be loaded SQL Error.", userName, sql.ErrNoRows.Error()})
+		return errors.New("sql error occured attempt to load old question")
+	}
+	//If question one is set
+	if user.SecQuestions[0].Valid && user.SecQuestions[0].String != "" {
+		//Challenge needed/Require that the user entered in the answer to q1
+		if bcrypt.CompareHashAndPassword([]byte(user.SecAnswers[0].String), challengeAnswer) != nil {
+			//Challenge failed/If we fail, log it, and quit without setting questions
+			logging.WriteLog(logging.LogLevelError, "MemoryPlugin/SetSecurityQuestions", userName, logging.ResultFailure, []string{"Security questions failed to update. Challenge answer incorrect or SQL error.", userName})
+			return errors.New("provided answer did not pass challenge")
+		}
+	}
+
+	//Disabled accounts are left untouched, same as the SQL plugins
+	if user.Disabled == false {
+		user.SecQuestions = [3]sql.NullString{{String: questionOne, Valid: true}, {String: questionTwo, Valid: true}, {String: questionThree, Valid: true}}
+		user.SecAnswers = [3]sql.NullString{{String: string(answerOneHash), Valid: true}, {String: string(answerTwoHash), Valid: true}, {String: string(answerThreeHash), Valid: true}}
+	}
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/SetSecurityQuestions", userName, logging.ResultSuccess, []string{"Security questions updated!", userName})
+	return nil
+}
+
+//ValidateSecurityQuestions Validates answers against a user's security questions (nil on success)
+func (DBConnection *MemoryPlugin) ValidateSecurityQuestions(ctx context.Context, userName string, answerOne []byte, answerTwo []byte, answerThree []byte) error {
+	//Ensure answers have values
+	if answerOne == nil || answerTwo == nil || answerThree == nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ValidateSecurityQuestions", userName, logging.ResultFailure, []string{"No answers?", userName})
+		return errors.New("Security Question validation failed, provide answers")
+	}
+
+	//Ensure Questions Exist
+	secQuestionOne, secQuestionTwo, secQuestionThree, err := DBConnection.GetSecurityQuestions(ctx, userName)
+	if err != nil || secQuestionOne == "" || secQuestionTwo == "" || secQuestionThree == "" {
+
+		if err != nil {
+			logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ValidateSecurityQuestions", userName, logging.ResultFailure, []string{"User does not exist?", err.Error(), userName})
+			return err
+		}
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ValidateSecurityQuestions", userName, logging.ResultFailure, []string{"Questions do not exist for user", userName})
+		return errors.New("Questions do not exist for user")
+	}
+
+	DBConnection.lock.RLock()
+	user := DBConnection.getUserByName(userName)
+	if user == nil {
+		DBConnection.lock.RUnlock()
+		return sql.ErrNoRows
+	}
+	secAnswers := user.SecAnswers
+	DBConnection.lock.RUnlock()
+
+	if secAnswers[0].Valid && secAnswers[1].Valid && secAnswers[2].Valid != true {
+		return errors.New("Account does not have answers to one or more questions")
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(secAnswers[0].String), answerOne) != nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ValidateSecurityQuestions", userName, logging.ResultFailure, []string{"Answer 1 incorrect", userName})
+		return errors.New("Security Question validation failed")
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(secAnswers[1].String), answerTwo) != nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ValidateSecurityQuestions", userName, logging.ResultFailure, []string{"Answer 2 incorrect", userName})
+		return errors.New("Security Question validation failed")
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(secAnswers[2].String), answerThree) != nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ValidateSecurityQuestions", userName, logging.ResultFailure, []string{"Answer 3 incorrect", userName})
+		return errors.New("Security Question validation failed")
+	}
+
+	return nil
+}
+
+//GetSecurityQuestions returns the three questions, first, second, third, and an error if an issue occured
+func (DBConnection *MemoryPlugin) GetSecurityQuestions(ctx context.Context, userName string) (string, string, string, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	user := DBConnection.getUserByName(userName)
+	if user == nil {
+		return "", "", "", sql.ErrNoRows
+	}
+	if user.SecQuestions[0].Valid && user.SecQuestions[1].Valid && user.SecQuestions[2].Valid {
+		return user.SecQuestions[0].String, user.SecQuestions[1].String, user.SecQuestions[2].String, nil
+	}
+	return "", "", "", errors.New("one or more questions nil")
+}
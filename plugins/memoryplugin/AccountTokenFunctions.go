@@ -0,0 +1,75 @@
+package memoryplugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go-image-board/logging"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+//ValidateToken Validate a cookie token (true if valid cookie, false otherwise, error for reason or nil)
+func (DBConnection *MemoryPlugin) ValidateToken(ctx context.Context, userName string, tokenID string, ip string) error {
+	DBConnection.lock.RLock()
+	user := DBConnection.getUserByName(userName)
+	if user == nil {
+		DBConnection.lock.RUnlock()
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ValidateToken", userName, logging.ResultFailure, []string{"Token Invalid", userName, tokenID, ip})
+		return errors.New("Token invalid")
+	}
+	validTokenID := user.TokenID
+	validTokenIP := user.IP
+	userDisabled := user.Disabled
+	DBConnection.lock.RUnlock()
+	if userDisabled {
+		return errors.New("Account disabled")
+	}
+
+	UUIDBytes := uuid.FromStringOrNil(tokenID)
+	if uuid.Equal(UUIDBytes, uuid.UUID{}) == true {
+		//Token provided is blank
+		return errors.New("Token provided is blank")
+	}
+
+	if validTokenIP.String != ip {
+		//Token is registered for a different IP
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ValidateToken", userName, logging.ResultFailure, []string{"Token for a different IP", userName, tokenID, ip})
+		return errors.New("Token invalid")
+	}
+
+	if bytes.Equal(UUIDBytes.Bytes(), uuid.FromStringOrNil(validTokenID.String).Bytes()) == false {
+		//Tokens do not match
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ValidateToken", userName, logging.ResultFailure, []string{"Tokens don't match", userName, tokenID, ip})
+		return errors.New("Token invalid")
+	}
+
+	return nil
+}
+
+//GenerateToken Generate a cookie token (string token, or error)
+func (DBConnection *MemoryPlugin) GenerateToken(ctx context.Context, userName string, ip string) (string, error) {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	user := DBConnection.getUserByName(userName)
+	if user == nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/GenerateToken", userName, logging.ResultFailure, []string{"Failed to save token", userName, ip, "user does not exist"})
+		return "", errors.New("failed to generate a token, check if user exists")
+	}
+	newToken := uuid.NewV4()
+	user.TokenID.String, user.TokenID.Valid = newToken.String(), true
+	user.IP.String, user.IP.Valid = ip, true
+	return newToken.String(), nil
+}
+
+//RevokeToken Revokes a token (nil on success)
+func (DBConnection *MemoryPlugin) RevokeToken(ctx context.Context, userName string) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if user := DBConnection.getUserByName(userName); user != nil {
+		user.TokenID.String, user.TokenID.Valid = "", false
+		user.IP.String, user.IP.Valid = "", false
+	}
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/RevokeToken", userName, logging.ResultSuccess, []string{"Token revoked!", userName})
+	return nil
+}
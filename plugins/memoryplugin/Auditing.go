@@ -0,0 +1,89 @@
+package memoryplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"strconv"
+	"time"
+)
+
+//AddAuditLog adds an audit event into the audit table, it is logged at the current time
+func (DBConnection *MemoryPlugin) AddAuditLog(ctx context.Context, Log interfaces.AuditLog) error {
+	if len(Log.Action) > 40 || len(Log.TargetType) > 20 {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/AddAuditLog", strconv.FormatUint(Log.UserID, 10), logging.ResultFailure, []string{"either the action, or the target type is too long for the audit log table", string(Log.Action), string(Log.TargetType)})
+		return errors.New("either the action, or the target type is too long for the audit log table")
+	}
+	Details := string(Log.Details)
+	if Details == "" {
+		Details = "{}"
+	}
+
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	DBConnection.lastAuditLogID++
+	DBConnection.auditLogs = append(DBConnection.auditLogs, memoryAuditLog{ID: DBConnection.lastAuditLogID, UserID: Log.UserID, Action: Log.Action, TargetType: Log.TargetType, TargetID: Log.TargetID, Details: Details, LogTime: time.Now()})
+	return nil
+}
+
+//SearchAuditLogs returns the audit log entries matching Filter, newest first, and how many match in total
+func (DBConnection *MemoryPlugin) SearchAuditLogs(ctx context.Context, Filter interfaces.AuditLogFilter, PageStart uint64, PageStride uint64) ([]interfaces.AuditLog, uint64, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.AuditLog
+	for Index := len(DBConnection.auditLogs) - 1; Index >= 0; Index-- {
+		Log := DBConnection.auditLogs[Index]
+		if (Filter.UserID != 0 && Log.UserID != Filter.UserID) ||
+			(Filter.Action != "" && Log.Action != Filter.Action) ||
+			(Filter.TargetType != interfaces.AuditTargetNone && Log.TargetType != Filter.TargetType) ||
+			(Filter.TargetID != 0 && Log.TargetID != Filter.TargetID) ||
+			(Filter.Since.IsZero() == false && Log.LogTime.Before(Filter.Since)) ||
+			(Filter.Until.IsZero() == false && Log.LogTime.Before(Filter.Until) == false) {
+			continue
+		}
+		ToReturn = append(ToReturn, DBConnection.auditLogEntry(Log))
+	}
+	return pageSlice(ToReturn, PageStart, PageStride), uint64(len(ToReturn)), nil
+}
+
+//GetAuditLogsBefore returns up to Limit audit log entries logged before the given time, oldest first
+func (DBConnection *MemoryPlugin) GetAuditLogsBefore(ctx context.Context, Before time.Time, Limit uint64) ([]interfaces.AuditLog, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.AuditLog
+	for _, Log := range DBConnection.auditLogs {
+		if uint64(len(ToReturn)) >= Limit {
+			break
+		}
+		if Log.LogTime.Before(Before) {
+			ToReturn = append(ToReturn, DBConnection.auditLogEntry(Log))
+		}
+	}
+	return ToReturn, nil
+}
+
+//DeleteAuditLogsBefore deletes the audit log entries logged before the given time. If UpToID is not 0, only entries with an ID up to and including it are deleted
+func (DBConnection *MemoryPlugin) DeleteAuditLogsBefore(ctx context.Context, Before time.Time, UpToID uint64) (uint64, error) {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	Kept := DBConnection.auditLogs[:0]
+	for _, Log := range DBConnection.auditLogs {
+		if Log.LogTime.Before(Before) == false || (UpToID != 0 && Log.ID > UpToID) {
+			Kept = append(Kept, Log)
+		}
+	}
+	Deleted := uint64(len(DBConnection.auditLogs) - len(Kept))
+	DBConnection.auditLogs = Kept
+	return Deleted, nil
+}
+
+//auditLogEntry converts a stored audit log entry to the interface type, the lock must be held
+func (DBConnection *MemoryPlugin) auditLogEntry(Log memoryAuditLog) interfaces.AuditLog {
+	Entry := interfaces.AuditLog{ID: Log.ID, UserID: Log.UserID, Action: Log.Action, TargetType: Log.TargetType, TargetID: Log.TargetID, Details: json.RawMessage(Log.Details), LogTime: Log.LogTime}
+	if user, exists := DBConnection.users[Log.UserID]; exists {
+		Entry.UserName = user.Name
+	}
+	return Entry
+}
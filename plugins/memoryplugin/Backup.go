@@ -0,0 +1,144 @@
+package memoryplugin
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go-image-board/interfaces"
+	"maps"
+	"slices"
+)
+
+//ExportBackup copies every user, saved search, tag, image, image revision, collection, vote and audit log.
+//The memory plugin does not keep link or vote times, so those are left zero
+func (DBConnection *MemoryPlugin) ExportBackup(ctx context.Context) (interfaces.BackupData, error) {
+	if err := ctx.Err(); err != nil {
+		return interfaces.BackupData{}, err
+	}
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var Data interfaces.BackupData
+	for _, ID := range slices.Sorted(maps.Keys(DBConnection.users)) {
+		if ID == 0 {
+			continue //Every install has SYSTEM
+		}
+		User := DBConnection.users[ID]
+		Backup := interfaces.BackupUser{ID: User.ID, Name: User.Name, EMail: User.EMail, PasswordHash: User.PasswordHash, CreationTime: User.CreationTime, Disabled: User.Disabled, Permissions: User.Permissions, SearchFilter: User.SearchFilter}
+		for Index := range User.SecQuestions {
+			Backup.SecQuestions[Index] = User.SecQuestions[Index].String
+			Backup.SecAnswers[Index] = User.SecAnswers[Index].String
+		}
+		Data.Users = append(Data.Users, Backup)
+	}
+	for _, ID := range slices.Sorted(maps.Keys(DBConnection.savedSearches)) {
+		Search := DBConnection.savedSearches[ID]
+		Data.SavedSearches = append(Data.SavedSearches, interfaces.BackupSavedSearch{ID: Search.ID, UserID: Search.UserID, Name: Search.Name, Query: Search.Query})
+	}
+	for _, ID := range slices.Sorted(maps.Keys(DBConnection.tags)) {
+		Tag := DBConnection.tags[ID]
+		Data.Tags = append(Data.Tags, interfaces.BackupTag{ID: Tag.ID, Name: Tag.Name, Description: Tag.Description, UploaderID: Tag.UploaderID, UploadTime: Tag.UploadTime, AliasedID: Tag.AliasedID, IsAlias: Tag.IsAlias})
+	}
+	for _, ID := range slices.Sorted(maps.Keys(DBConnection.images)) {
+		Image := DBConnection.images[ID]
+		Data.Images = append(Data.Images, interfaces.BackupImage{ID: Image.ID, UploaderID: Image.UploaderID, Name: Image.Name, Description: Image.Description, Rating: Image.Rating, ScoreTotal: Image.ScoreTotal, ScoreAverage: Image.ScoreAverage, ScoreVoters: Image.ScoreVoters, Location: Image.Location, Source: Image.Source, UploadTime: Image.UploadTime, DeletedTime: Image.DeletedTime, DeletedBy: Image.DeletedBy, ImageMetadata: Image.Metadata})
+		if Hash, found := DBConnection.imagedHashes[ID]; found {
+			Data.ImagedHashes = append(Data.ImagedHashes, interfaces.BackupImagedHash{ImageID: ID, HHash: Hash.ImagehHash, VHash: Hash.ImagevHash})
+		}
+		for _, TagID := range slices.Sorted(maps.Keys(DBConnection.imageTags[ID])) {
+			Data.ImageTags = append(Data.ImageTags, interfaces.BackupImageTag{ImageID: ID, TagID: TagID, LinkerID: DBConnection.imageTags[ID][TagID]})
+		}
+		for _, UserID := range slices.Sorted(maps.Keys(DBConnection.imageScores[ID])) {
+			Data.Votes = append(Data.Votes, interfaces.BackupVote{UserID: UserID, ImageID: ID, Score: DBConnection.imageScores[ID][UserID]})
+		}
+	}
+	for _, ID := range slices.Sorted(maps.Keys(DBConnection.collections)) {
+		Collection := DBConnection.collections[ID]
+		Data.Collections = append(Data.Collections, interfaces.BackupCollection{ID: Collection.ID, Name: Collection.Name, Description: Collection.Description, UploaderID: Collection.UploaderID, UploadTime: Collection.UploadTime})
+		for _, ImageID := range slices.Sorted(maps.Keys(DBConnection.collectionMembers[ID])) {
+			Data.CollectionMembers = append(Data.CollectionMembers, interfaces.BackupCollectionMember{CollectionID: ID, ImageID: ImageID, OrderWeight: DBConnection.collectionMembers[ID][ImageID]})
+		}
+	}
+	for _, Log := range DBConnection.auditLogs {
+		Data.AuditLogs = append(Data.AuditLogs, interfaces.BackupAuditLog{UserID: Log.UserID, Action: Log.Action, TargetType: Log.TargetType, TargetID: Log.TargetID, Details: json.RawMessage(Log.Details), LogTime: Log.LogTime})
+	}
+	for _, Revision := range DBConnection.imageRevisions {
+		Data.ImageRevisions = append(Data.ImageRevisions, interfaces.BackupImageRevision{ID: Revision.ID, ImageID: Revision.ImageID, UserID: Revision.UserID, ChangeTime: Revision.ChangeTime, Field: Revision.Field, TagID: Revision.TagID, CollectionID: Revision.CollectionID, OldValue: Revision.OldValue, NewValue: Revision.NewValue})
+	}
+	return Data, nil
+}
+
+//ImportBackup restores Data into the empty tables InitDatabase made, keeping all IDs
+func (DBConnection *MemoryPlugin) ImportBackup(ctx context.Context, Data interfaces.BackupData) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if len(DBConnection.users) > 1 || len(DBConnection.images) > 0 || len(DBConnection.tags) > 0 || len(DBConnection.collections) > 0 {
+		return errors.New("database is not empty, backups can only be imported into a fresh install")
+	}
+	for _, User := range Data.Users {
+		Row := &memoryUser{ID: User.ID, Name: User.Name, EMail: User.EMail, PasswordHash: User.PasswordHash, CreationTime: User.CreationTime, Disabled: User.Disabled, Permissions: User.Permissions, SearchFilter: User.SearchFilter}
+		for Index := range Row.SecQuestions {
+			Row.SecQuestions[Index] = sql.NullString{String: User.SecQuestions[Index], Valid: User.SecQuestions[Index] != ""}
+			Row.SecAnswers[Index] = sql.NullString{String: User.SecAnswers[Index], Valid: User.SecAnswers[Index] != ""}
+		}
+		DBConnection.users[User.ID] = Row
+		DBConnection.lastUserID = max(DBConnection.lastUserID, User.ID)
+	}
+	for _, Search := range Data.SavedSearches {
+		DBConnection.savedSearches[Search.ID] = &interfaces.SavedSearch{ID: Search.ID, UserID: Search.UserID, Name: Search.Name, Query: Search.Query}
+		DBConnection.lastSavedSearchID = max(DBConnection.lastSavedSearchID, Search.ID)
+	}
+	for _, Tag := range Data.Tags {
+		DBConnection.tags[Tag.ID] = &memoryTag{ID: Tag.ID, Name: Tag.Name, Description: Tag.Description, UploaderID: Tag.UploaderID, UploadTime: Tag.UploadTime, AliasedID: Tag.AliasedID, IsAlias: Tag.IsAlias}
+		DBConnection.lastTagID = max(DBConnection.lastTagID, Tag.ID)
+	}
+	for _, Image := range Data.Images {
+		DBConnection.images[Image.ID] = &memoryImage{ID: Image.ID, UploaderID: Image.UploaderID, Name: Image.Name, Description: Image.Description, Rating: Image.Rating, ScoreTotal: Image.ScoreTotal, ScoreAverage: Image.ScoreAverage, ScoreVoters: Image.ScoreVoters, Location: Image.Location, Source: Image.Source, UploadTime: Image.UploadTime, DeletedTime: Image.DeletedTime, DeletedBy: Image.DeletedBy, Metadata: Image.ImageMetadata}
+		DBConnection.lastImageID = max(DBConnection.lastImageID, Image.ID)
+	}
+	for _, Hash := range Data.ImagedHashes {
+		DBConnection.imagedHashes[Hash.ImageID] = interfaces.ImagedHash{ImagehHash: Hash.HHash, ImagevHash: Hash.VHash}
+	}
+	for _, Link := range Data.ImageTags {
+		if DBConnection.imageTags[Link.ImageID] == nil {
+			DBConnection.imageTags[Link.ImageID] = make(map[uint64]uint64)
+		}
+		DBConnection.imageTags[Link.ImageID][Link.TagID] = Link.LinkerID
+	}
+	for _, Vote := range Data.Votes {
+		if DBConnection.imageScores[Vote.ImageID] == nil {
+			DBConnection.imageScores[Vote.ImageID] = make(map[uint64]int64)
+		}
+		DBConnection.imageScores[Vote.ImageID][Vote.UserID] = Vote.Score
+	}
+	for _, Collection := range Data.Collections {
+		DBConnection.collections[Collection.ID] = &memoryCollection{ID: Collection.ID, Name: Collection.Name, Description: Collection.Description, UploaderID: Collection.UploaderID, UploadTime: Collection.UploadTime}
+		DBConnection.lastCollectionID = max(DBConnection.lastCollectionID, Collection.ID)
+	}
+	for _, Member := range Data.CollectionMembers {
+		if DBConnection.collectionMembers[Member.CollectionID] == nil {
+			DBConnection.collectionMembers[Member.CollectionID] = make(map[uint64]uint64)
+		}
+		DBConnection.collectionMembers[Member.CollectionID][Member.ImageID] = Member.OrderWeight
+	}
+	for _, Log := range Data.AuditLogs {
+		Details := string(Log.Details)
+		if Details == "" || Details == "null" {
+			Details = "{}"
+		}
+		DBConnection.lastAuditLogID++
+		DBConnection.auditLogs = append(DBConnection.auditLogs, memoryAuditLog{ID: DBConnection.lastAuditLogID, UserID: Log.UserID, Action: Log.Action, TargetType: Log.TargetType, TargetID: Log.TargetID, Details: Details, LogTime: Log.LogTime})
+	}
+	for _, Revision := range Data.ImageRevisions {
+		DBConnection.imageRevisions = append(DBConnection.imageRevisions, interfaces.ImageRevision{ID: Revision.ID, ImageID: Revision.ImageID, UserID: Revision.UserID, ChangeTime: Revision.ChangeTime, Field: Revision.Field, TagID: Revision.TagID, CollectionID: Revision.CollectionID, OldValue: Revision.OldValue, NewValue: Revision.NewValue})
+		DBConnection.lastRevisionID = max(DBConnection.lastRevisionID, Revision.ID)
+	}
+	slices.SortFunc(DBConnection.imageRevisions, func(A interfaces.ImageRevision, B interfaces.ImageRevision) int {
+		return cmp.Compare(A.ID, B.ID)
+	})
+	return nil
+}
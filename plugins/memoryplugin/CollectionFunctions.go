@@ -0,0 +1,365 @@
+package memoryplugin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//--Collections
+
+//NewCollection adds a collection with the provided information
+func (DBConnection *MemoryPlugin) NewCollection(ctx context.Context, Name string, Description string, UploaderID uint64) (uint64, error) {
+	if len(Name) < 3 || len(Name) > 255 || len(Description) > 255 {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/NewCollection", strconv.FormatUint(UploaderID, 10), logging.ResultFailure, []string{"Failed to add collection due to name/description size", Name, Description})
+		return 0, errors.New("name or description outside size range")
+	}
+
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if DBConnection.getCollectionByName(Name) != nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/NewCollection", strconv.FormatUint(UploaderID, 10), logging.ResultFailure, []string{"Failed to add collection", "duplicate name"})
+		return 0, errors.New("a collection with this name already exists")
+	}
+	DBConnection.lastCollectionID++
+	DBConnection.collections[DBConnection.lastCollectionID] = &memoryCollection{ID: DBConnection.lastCollectionID, Name: Name, Description: Description, UploaderID: UploaderID, UploadTime: time.Now()}
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/NewCollection", strconv.FormatUint(UploaderID, 10), logging.ResultSuccess, []string{"Collection added"})
+	return DBConnection.lastCollectionID, nil
+}
+
+//DeleteCollection removes a collection
+func (DBConnection *MemoryPlugin) DeleteCollection(ctx context.Context, CollectionID uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	DBConnection.deleteCollection(CollectionID)
+	return nil
+}
+
+//deleteCollection is DeleteCollection for callers that already hold the lock
+func (DBConnection *MemoryPlugin) deleteCollection(CollectionID uint64) {
+	delete(DBConnection.collectionMembers, CollectionID)
+	delete(DBConnection.collections, CollectionID)
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/DeleteCollection", "0", logging.ResultSuccess, []string{"Collection deleted", strconv.FormatUint(CollectionID, 10)})
+}
+
+//UpdateCollection updates a pre-existing collection
+func (DBConnection *MemoryPlugin) UpdateCollection(ctx context.Context, CollectionID uint64, Name string, Description string) error {
+	//Cleanup name
+	if len(Name) < 3 || len(Name) > 255 || len(Description) > 255 {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/UpdateCollection", "0", logging.ResultFailure, []string{"Failed to update collection due to size of name/description", Name, Description})
+		return errors.New("name or description outside of right sizes")
+	}
+
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if existing := DBConnection.getCollectionByName(Name); existing != nil && existing.ID != CollectionID {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/UpdateCollection", "0", logging.ResultFailure, []string{"Failed to update collection", "duplicate name"})
+		return errors.New("a collection with this name already exists")
+	}
+	if collection, exists := DBConnection.collections[CollectionID]; exists {
+		collection.Name = Name
+		collection.Description = Description
+	}
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/UpdateCollection", "0", logging.ResultSuccess, []string{"Collection updated"})
+	return nil
+}
+
+//GetCollections returns a list of all collections, but only the ID, Name, Description
+func (DBConnection *MemoryPlugin) GetCollections(ctx context.Context, PageStart uint64, PageStride uint64) ([]interfaces.CollectionInformation, uint64, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.CollectionInformation
+	var collections []*memoryCollection
+	for _, collection := range DBConnection.collections {
+		collections = append(collections, collection)
+	}
+	sort.Slice(collections, func(i, j int) bool {
+		return strings.ToLower(collections[i].Name) < strings.ToLower(collections[j].Name)
+	})
+	MaxResults := uint64(len(collections))
+	for _, collection := range pageSlice(collections, PageStart, PageStride) {
+		ToReturn = append(ToReturn, interfaces.CollectionInformation{Name: collection.Name, ID: collection.ID, Description: collection.Description, Location: DBConnection.getCollectionPreview(collection.ID), Members: uint64(len(DBConnection.getSortedMembers(collection.ID)))})
+	}
+	return ToReturn, MaxResults, nil
+}
+
+//GetCollection returns detailed information on one collection
+func (DBConnection *MemoryPlugin) GetCollection(ctx context.Context, ID uint64) (interfaces.CollectionInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	collection, exists := DBConnection.collections[ID]
+	if exists == false {
+		return interfaces.CollectionInformation{}, sql.ErrNoRows
+	}
+	return DBConnection.getCollectionInformation(collection), nil
+}
+
+//GetCollectionByName returns detailed information on one collection
+func (DBConnection *MemoryPlugin) GetCollectionByName(ctx context.Context, Name string) (interfaces.CollectionInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	collection := DBConnection.getCollectionByName(Name)
+	if collection == nil {
+		return interfaces.CollectionInformation{}, sql.ErrNoRows
+	}
+	return DBConnection.getCollectionInformation(collection), nil
+}
+
+//--Collection Members
+
+//AddCollectionMember adds an image to a collection
+func (DBConnection *MemoryPlugin) AddCollectionMember(ctx context.Context, CollectionID uint64, ImageIDs []uint64, LinkerID uint64) error {
+	if len(ImageIDs) == 0 {
+		return errors.New("ImageIDs required")
+	}
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	idString := ""
+	//Validate everything first, the SQL plugins insert all members in one statement so either all or none are added
+	if _, exists := DBConnection.collections[CollectionID]; exists == false {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/AddCollectionMember", strconv.FormatUint(LinkerID, 10), logging.ResultFailure, []string{"Image not added to collection", strconv.FormatUint(CollectionID, 10), "collection does not exist"})
+		return errors.New("collection does not exist")
+	}
+	members := DBConnection.collectionMembers[CollectionID]
+	seen := make(map[uint64]bool)
+	for _, ImageID := range ImageIDs {
+		_, exists := DBConnection.images[ImageID]
+		_, isMember := members[ImageID]
+		if exists == false || isMember || seen[ImageID] {
+			logging.WriteLog(logging.LogLevelError, "MemoryPlugin/AddCollectionMember", strconv.FormatUint(LinkerID, 10), logging.ResultFailure, []string{"Image not added to collection", strconv.FormatUint(CollectionID, 10), strconv.FormatUint(ImageID, 10)})
+			return errors.New("image " + strconv.FormatUint(ImageID, 10) + " does not exist or is already in the collection")
+		}
+		seen[ImageID] = true
+	}
+
+	//Get last order
+	//If we are not an empty collection, the next image goes after the current last one
+	//Otherwise first image will have 0 as it's weight
+	lastOrder := uint64(0)
+	for _, Order := range members {
+		if Order+1 > lastOrder {
+			lastOrder = Order + 1
+		}
+	}
+	if members == nil {
+		members = make(map[uint64]uint64)
+		DBConnection.collectionMembers[CollectionID] = members
+	}
+	for _, ImageID := range ImageIDs {
+		members[ImageID] = lastOrder
+		idString += strconv.FormatUint(ImageID, 10) + ", "
+		lastOrder++
+	}
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/AddCollectionMember", strconv.FormatUint(LinkerID, 10), logging.ResultSuccess, []string{"Image added to collection", strconv.FormatUint(CollectionID, 10), idString})
+	return nil
+}
+
+//RemoveCollectionMember removes an image from collection
+func (DBConnection *MemoryPlugin) RemoveCollectionMember(ctx context.Context, CollectionID uint64, ImageID uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	return DBConnection.removeCollectionMember(CollectionID, ImageID)
+}
+
+//removeCollectionMember is RemoveCollectionMember for callers that already hold the lock
+func (DBConnection *MemoryPlugin) removeCollectionMember(CollectionID uint64, ImageID uint64) error {
+	members := DBConnection.collectionMembers[CollectionID]
+	Order, isMember := members[ImageID]
+	if isMember == false {
+		return sql.ErrNoRows
+	}
+
+	//If last member of collection, just delete it instead
+	if len(members) <= 1 {
+		DBConnection.deleteCollection(CollectionID)
+		return nil
+	}
+
+	delete(members, ImageID)
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/RemoveCollectionMember", "0", logging.ResultSuccess, []string{"Image removed from collection", strconv.FormatUint(CollectionID, 10), strconv.FormatUint(ImageID, 10)})
+
+	//Decrement Order
+	for MemberID, MemberOrder := range members {
+		if MemberOrder > Order {
+			members[MemberID] = MemberOrder - 1
+		}
+	}
+	return nil
+}
+
+//UpdateCollectionMember updates an image's properties in a collection
+func (DBConnection *MemoryPlugin) UpdateCollectionMember(ctx context.Context, CollectionID uint64, ImageID uint64, Order uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	//Get Current Order
+	members := DBConnection.collectionMembers[CollectionID]
+	BeforeOrder, isMember := members[ImageID]
+	if isMember == false {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/UpdateCollectionMember", "0", logging.ResultFailure, []string{"Could not get previous order to update collectionmember", strconv.FormatUint(CollectionID, 10), strconv.FormatUint(ImageID, 10), sql.ErrNoRows.Error()})
+		return sql.ErrNoRows
+	}
+
+	//Ensure that we do not try and set this image to say, the 20th position when we have 3 images. Don't error, just silently set order to last image.
+	MemberCount := uint64(len(members))
+	if MemberCount <= Order {
+		Order = MemberCount - 1 //-1 because we are ordering from 0. If we have 20 images, the last spot is actually 19
+	}
+
+	//Set order for image, then close the gap it left and open a gap where it went, same as the SQL plugins
+	members[ImageID] = Order
+	for MemberID, MemberOrder := range members {
+		if MemberID != ImageID && MemberOrder >= BeforeOrder {
+			members[MemberID] = MemberOrder - 1
+		}
+	}
+	for MemberID, MemberOrder := range members {
+		if MemberID != ImageID && MemberOrder >= Order {
+			members[MemberID] = MemberOrder + 1
+		}
+	}
+	return nil
+}
+
+//GetCollectionMembers gets a list of images in a collection (Returns a list of imageIDs, or error)
+func (DBConnection *MemoryPlugin) GetCollectionMembers(ctx context.Context, CollectionID uint64, PageStart uint64, PageStride uint64) ([]interfaces.ImageInformation, uint64, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.ImageInformation
+	members := DBConnection.getSortedMembers(CollectionID)
+	MaxResults := uint64(len(members))
+	//If we limited the search
+	if PageStride > 0 {
+		members = pageSlice(members, PageStart, PageStride)
+	}
+	for _, ImageID := range members {
+		image := DBConnection.images[ImageID]
+		ToReturn = append(ToReturn, interfaces.ImageInformation{Name: image.Name, ID: ImageID, Location: image.Location, OrderInCollection: DBConnection.collectionMembers[CollectionID][ImageID]})
+	}
+	return ToReturn, MaxResults, nil
+}
+
+//GetCollectionsWithImage returns a slice of collections with a specific image
+func (DBConnection *MemoryPlugin) GetCollectionsWithImage(ctx context.Context, ImageID uint64) ([]interfaces.CollectionInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.CollectionInformation
+	for CollectionID, members := range DBConnection.collectionMembers {
+		Order, isMember := members[ImageID]
+		collection, exists := DBConnection.collections[CollectionID]
+		if isMember == false || exists == false {
+			continue
+		}
+		ToAdd := interfaces.CollectionInformation{Name: collection.Name, Description: collection.Description, ID: CollectionID, OrderInCollection: Order, Members: uint64(len(DBConnection.getSortedMembers(CollectionID)))}
+		//Find the closest members before and after this image
+		var BeforeOrder, AfterOrder uint64
+		for MemberID, MemberOrder := range members {
+			if DBConnection.images[MemberID].DeletedTime.IsZero() == false {
+				continue
+			}
+			if MemberOrder < Order && (ToAdd.PreviousMemberID == 0 || MemberOrder > BeforeOrder) {
+				ToAdd.PreviousMemberID, BeforeOrder = MemberID, MemberOrder
+			}
+			if MemberOrder > Order && (ToAdd.NextMemberID == 0 || MemberOrder < AfterOrder) {
+				ToAdd.NextMemberID, AfterOrder = MemberID, MemberOrder
+			}
+		}
+		ToReturn = append(ToReturn, ToAdd)
+	}
+	sort.Slice(ToReturn, func(i, j int) bool { return ToReturn[i].ID < ToReturn[j].ID })
+	return ToReturn, nil
+}
+
+//GetCollectionTags returns a list of TagInformation for all tags that apply to the given collection
+func (DBConnection *MemoryPlugin) GetCollectionTags(ctx context.Context, CollectionID uint64) ([]interfaces.TagInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.TagInformation
+	for TagID := range DBConnection.getCollectionTagIDs(CollectionID) {
+		if tag, exists := DBConnection.tags[TagID]; exists {
+			ToReturn = append(ToReturn, interfaces.TagInformation{Name: tag.Name, ID: tag.ID, Description: tag.Description, Exists: true, Exclude: false})
+		}
+	}
+	sort.Slice(ToReturn, func(i, j int) bool { return ToReturn[i].Name < ToReturn[j].Name })
+	return ToReturn, nil
+}
+
+//getCollectionTagIDs returns the set of tags on any member of a collection.
+//The SQL plugins keep this in CollectionTags with triggers, here it is worked out on demand. Lock must be held
+func (DBConnection *MemoryPlugin) getCollectionTagIDs(CollectionID uint64) map[uint64]bool {
+	ToReturn := make(map[uint64]bool)
+	for ImageID := range DBConnection.collectionMembers[CollectionID] {
+		for TagID := range DBConnection.imageTags[ImageID] {
+			ToReturn[TagID] = true
+		}
+	}
+	return ToReturn
+}
+
+//getSortedMembers returns a collection's image IDs ordered by OrderWeight, leaving out images in the recycle bin. Lock must be held
+func (DBConnection *MemoryPlugin) getSortedMembers(CollectionID uint64) []uint64 {
+	members := DBConnection.collectionMembers[CollectionID]
+	var ToReturn []uint64
+	for ImageID := range members {
+		if image, exists := DBConnection.images[ImageID]; exists && image.DeletedTime.IsZero() {
+			ToReturn = append(ToReturn, ImageID)
+		}
+	}
+	sort.Slice(ToReturn, func(i, j int) bool {
+		if members[ToReturn[i]] == members[ToReturn[j]] {
+			return ToReturn[i] < ToReturn[j]
+		}
+		return members[ToReturn[i]] < members[ToReturn[j]]
+	})
+	return ToReturn
+}
+
+//getCollectionPreview returns the location of the first image in a collection, or "". Lock must be held
+func (DBConnection *MemoryPlugin) getCollectionPreview(CollectionID uint64) string {
+	members := DBConnection.getSortedMembers(CollectionID)
+	if len(members) == 0 {
+		return ""
+	}
+	return DBConnection.images[members[0]].Location
+}
+
+//getCollectionByName returns the collection with the given name or nil. Lock must be held
+func (DBConnection *MemoryPlugin) getCollectionByName(Name string) *memoryCollection {
+	for _, collection := range DBConnection.collections {
+		if strings.EqualFold(collection.Name, Name) {
+			return collection
+		}
+	}
+	return nil
+}
+
+//getCollectionInformation converts a stored collection to the CollectionInformation GetCollection returns. Lock must be held
+func (DBConnection *MemoryPlugin) getCollectionInformation(collection *memoryCollection) interfaces.CollectionInformation {
+	return interfaces.CollectionInformation{Name: collection.Name, ID: collection.ID, Description: collection.Description, UploaderID: collection.UploaderID, UploadTime: collection.UploadTime, Members: uint64(len(DBConnection.getSortedMembers(collection.ID)))}
+}
+
+//GetOrphanedCollectionMembers returns collection members whose image no longer exists, sorted by collection then image
+func (DBConnection *MemoryPlugin) GetOrphanedCollectionMembers(ctx context.Context) ([]interfaces.CollectionMember, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.CollectionMember
+	for CollectionID, members := range DBConnection.collectionMembers {
+		for ImageID := range members {
+			if _, exists := DBConnection.images[ImageID]; exists == false {
+				ToReturn = append(ToReturn, interfaces.CollectionMember{CollectionID: CollectionID, ImageID: ImageID})
+			}
+		}
+	}
+	sort.Slice(ToReturn, func(i, j int) bool {
+		if ToReturn[i].CollectionID != ToReturn[j].CollectionID {
+			return ToReturn[i].CollectionID < ToReturn[j].CollectionID
+		}
+		return ToReturn[i].ImageID < ToReturn[j].ImageID
+	})
+	return ToReturn, nil
+}
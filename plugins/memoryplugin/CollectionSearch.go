@@ -0,0 +1,168 @@
+package memoryplugin
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"go-image-board/interfaces"
+	"sort"
+)
+
+//SearchCollections performs a search for collections (Returns a list of CollectionInformation a result count and an error/nil)
+//If you edit this function, consider SearchImages for a similar change
+func (DBConnection *MemoryPlugin) SearchCollections(ctx context.Context, Tags []interfaces.TagInformation, PageStart uint64, PageStride uint64) ([]interfaces.CollectionInformation, uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	//Cleanup input for use in code below
+	//Specifically we separate the include, the exclude and metatags into their own lists
+	var IncludeTags []uint64
+	var ExcludeTags []uint64
+	var MetaTags []interfaces.TagInformation
+	for _, tag := range Tags {
+		if tag.Exists && tag.IsAlias == false && tag.IsMeta == false {
+			if tag.Exclude {
+				ExcludeTags = append(ExcludeTags, tag.ID)
+			} else {
+				IncludeTags = append(IncludeTags, tag.ID)
+			}
+		} else if tag.Exists && tag.IsMeta {
+			if _, isOrder := tag.MetaValue.(interfaces.SearchOrder); isOrder == false {
+				MetaTags = append(MetaTags, tag) //Orders sort the results instead of filtering them
+			}
+		}
+	}
+
+	//Initialize output
+	var ToReturn []interfaces.CollectionInformation
+
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var Matches []*memoryCollection
+	for _, collection := range DBConnection.collections {
+		collectionTags := DBConnection.getCollectionTagIDs(collection.ID)
+		matches := true
+		for _, TagID := range IncludeTags {
+			if collectionTags[TagID] == false {
+				matches = false
+				break
+			}
+		}
+		for _, TagID := range ExcludeTags {
+			if collectionTags[TagID] {
+				matches = false
+				break
+			}
+		}
+		for _, tag := range MetaTags {
+			if matches == false {
+				break
+			}
+			result, err := collectionMatchesMetaTag(collection, collectionTags, tag)
+			if err != nil {
+				return nil, 0, err
+			}
+			matches = result
+		}
+		if matches {
+			Matches = append(Matches, collection)
+		}
+	}
+
+	//ORDER BY ID DESC, unless an order tag says otherwise
+	Order := getSearchOrder(Tags)
+	sort.Slice(Matches, func(i, j int) bool { return DBConnection.collectionOrderLess(Order, Matches[i], Matches[j]) })
+	MaxResults := uint64(len(Matches))
+	for _, collection := range pageSlice(Matches, PageStart, PageStride) {
+		ToReturn = append(ToReturn, interfaces.CollectionInformation{Name: collection.Name, ID: collection.ID, Location: DBConnection.getCollectionPreview(collection.ID), Members: uint64(len(DBConnection.getSortedMembers(collection.ID)))})
+	}
+	return ToReturn, MaxResults, nil
+}
+
+//collectionMatchesMetaTag evaluates a single meta tag against a collection and the tags of its members. Lock must be held
+func collectionMatchesMetaTag(collection *memoryCollection, collectionTags map[uint64]bool, tag interfaces.TagInformation) (bool, error) {
+	comparator := tag.Comparator
+	if tag.Exclude {
+		comparator = getInvertedComparator(comparator)
+	}
+	if comparator == "" {
+		return false, errors.New("Failed to invert query to negate on " + tag.Name)
+	}
+
+	//Handle Complex Tags Here
+	if tagGroupValue, isTagGroup := tag.MetaValue.(interfaces.QueryGroup); isTagGroup {
+		groupMatches, err := collectionMatchesTagGroup(collection, collectionTags, tagGroupValue)
+		if err != nil {
+			return false, err
+		}
+		return groupMatches != (comparator == "!="), nil
+	}
+
+	var FieldValue interface{}
+	switch tag.Name {
+	case "Name":
+		FieldValue = collection.Name
+	case "UploaderID":
+		FieldValue = collection.UploaderID
+	default:
+		return false, errors.New("Unknown collection field " + tag.Name)
+	}
+	return compareMetaValue(FieldValue, comparator, tag.MetaValue)
+}
+
+//collectionMatchesTagGroup checks whether a collection has every tag in any one of the group's alternatives. Lock must be held
+func collectionMatchesTagGroup(collection *memoryCollection, collectionTags map[uint64]bool, Group interfaces.QueryGroup) (bool, error) {
+	for _, alternative := range Group.Alternatives {
+		matches := true
+		conditions := 0 //An alternative of only ignored tags matches nothing, rather than everything
+		for _, tag := range alternative {
+			if tag.IsMeta {
+				if _, isOrder := tag.MetaValue.(interfaces.SearchOrder); isOrder {
+					return false, interfaces.ErrOrderInGroup //Orders only apply to a whole search
+				}
+				if tag.Exists == false {
+					continue
+				}
+				metaMatches, err := collectionMatchesMetaTag(collection, collectionTags, tag)
+				if err != nil {
+					return false, err
+				}
+				matches = metaMatches
+			} else if tag.IsAlias {
+				continue //The tag it is an alias of is in the group alongside it
+			} else if tag.Exists == false {
+				matches = tag.Exclude //No collection can have a tag that does not exist
+			} else {
+				matches = collectionTags[tag.ID] != tag.Exclude
+			}
+			conditions++
+			if matches == false {
+				break
+			}
+		}
+		if matches && conditions > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+//collectionOrderLess returns whether collection a is listed before collection b in search results sorted by Order, ties are broken by ID. Lock must be held
+func (DBConnection *MemoryPlugin) collectionOrderLess(Order interfaces.SearchOrder, a *memoryCollection, b *memoryCollection) bool {
+	var compare int
+	switch Order.Name {
+	case "uploaded":
+		compare = a.UploadTime.Compare(b.UploadTime)
+	case "tagcount":
+		compare = cmp.Compare(len(DBConnection.getCollectionTagIDs(a.ID)), len(DBConnection.getCollectionTagIDs(b.ID)))
+	case "random":
+		compare = cmp.Compare(Order.RandomOrderKey(a.ID), Order.RandomOrderKey(b.ID))
+	}
+	if compare == 0 {
+		compare = cmp.Compare(a.ID, b.ID)
+	}
+	if Order.Ascending {
+		return compare < 0
+	}
+	return compare > 0
+}
@@ -0,0 +1,209 @@
+package memoryplugin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"slices"
+	"strconv"
+	"time"
+)
+
+//Image operations
+
+//NewImage adds an image with the provided information
+func (DBConnection *MemoryPlugin) NewImage(ctx context.Context, ImageName string, ImageFileName string, OwnerID uint64, Source string) (uint64, error) {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if DBConnection.getImageByLocation(ImageFileName) != nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/NewImage", strconv.FormatUint(OwnerID, 10), logging.ResultFailure, []string{"Failed to add image", "duplicate location"})
+		return 0, errors.New("an image with this location already exists")
+	}
+	DBConnection.lastImageID++
+	DBConnection.images[DBConnection.lastImageID] = &memoryImage{ID: DBConnection.lastImageID, UploaderID: OwnerID, Name: ImageName, Rating: "unrated", Location: ImageFileName, Source: Source, UploadTime: time.Now()}
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/NewImage", strconv.FormatUint(OwnerID, 10), logging.ResultSuccess, []string{"Image added"})
+	return DBConnection.lastImageID, nil
+}
+
+//DeleteImage removes an image from the db
+func (DBConnection *MemoryPlugin) DeleteImage(ctx context.Context, ImageID uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	//First, remove image from any associated collections
+	for CollectionID, members := range DBConnection.collectionMembers {
+		if _, isMember := members[ImageID]; isMember {
+			if err := DBConnection.removeCollectionMember(CollectionID, ImageID); err != nil {
+				logging.WriteLog(logging.LogLevelWarning, "MemoryPlugin/DeleteImage", "0", logging.ResultFailure, []string{"Failed to remove image from collection", err.Error(), strconv.FormatUint(ImageID, 10)})
+			}
+		}
+	}
+	//Then everything the onImageDelete trigger would clean up
+	delete(DBConnection.imageTags, ImageID)
+	delete(DBConnection.imageScores, ImageID)
+	delete(DBConnection.imagedHashes, ImageID)
+	DBConnection.imageRevisions = slices.DeleteFunc(DBConnection.imageRevisions, func(Revision interfaces.ImageRevision) bool {
+		return Revision.ImageID == ImageID
+	})
+	delete(DBConnection.images, ImageID)
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/DeleteImage", "0", logging.ResultSuccess, []string{"Image deleted", strconv.FormatUint(ImageID, 10)})
+	return nil
+}
+
+//UpdateImage updates properties of an image
+func (DBConnection *MemoryPlugin) UpdateImage(ctx context.Context, ImageID uint64, ImageName interface{}, ImageDescription interface{}, OwnerID interface{}, Rating interface{}, Source interface{}, Location interface{}) error {
+	if _, correctValue := OwnerID.(uint64); OwnerID != nil && correctValue == false {
+		return errors.New("OwnerID, when provided, must be of uint64 type")
+	}
+
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	//See if image exists
+	image, exists := DBConnection.images[ImageID]
+	if exists == false {
+		return sql.ErrNoRows
+	}
+	if Location != nil {
+		if existing := DBConnection.getImageByLocation(fmt.Sprintf("%v", Location)); existing != nil && existing.ID != ImageID {
+			return errors.New("an image with this location already exists")
+		}
+		image.Location = fmt.Sprintf("%v", Location)
+	}
+	if ImageName != nil {
+		image.Name = fmt.Sprintf("%v", ImageName)
+	}
+	if ImageDescription != nil {
+		image.Description = fmt.Sprintf("%v", ImageDescription)
+	}
+	if unwrappedOwnerID, correctValue := OwnerID.(uint64); OwnerID != nil && correctValue {
+		image.UploaderID = unwrappedOwnerID
+	}
+	if Rating != nil {
+		image.Rating = fmt.Sprintf("%v", Rating)
+	}
+	if Source != nil {
+		image.Source = fmt.Sprintf("%v", Source)
+	}
+	return nil
+}
+
+//GetImage returns information on a single image (Returns an ImageInformation, or error)
+func (DBConnection *MemoryPlugin) GetImage(ctx context.Context, ID uint64) (interfaces.ImageInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	image, exists := DBConnection.images[ID]
+	if exists == false {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ImageFunctions/GetImage", "0", logging.ResultFailure, []string{"Failed to get image info from database", sql.ErrNoRows.Error()})
+		return interfaces.ImageInformation{ID: ID}, sql.ErrNoRows
+	}
+	return DBConnection.getImageInformation(image), nil
+}
+
+//GetImageByFileName returns an ImageInformation object given a ImageName
+func (DBConnection *MemoryPlugin) GetImageByFileName(ctx context.Context, imageName string) (interfaces.ImageInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	image := DBConnection.getImageByLocation(imageName)
+	if image == nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ImageFunctions/GetImageByFileName", "0", logging.ResultFailure, []string{"Failed to get image info from database", sql.ErrNoRows.Error()})
+		return interfaces.ImageInformation{Location: imageName}, sql.ErrNoRows
+	}
+	return DBConnection.getImageInformation(image), nil
+}
+
+//SetImageRating changes a given image's rating in the database
+func (DBConnection *MemoryPlugin) SetImageRating(ctx context.Context, ID uint64, Rating string) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if image, exists := DBConnection.images[ID]; exists {
+		image.Rating = Rating
+	}
+	return nil
+}
+
+//SetImageMetadata stores what was read from an image's file
+func (DBConnection *MemoryPlugin) SetImageMetadata(ctx context.Context, ID uint64, Metadata interfaces.ImageMetadata) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if image, exists := DBConnection.images[ID]; exists {
+		image.Metadata = Metadata
+	}
+	return nil
+}
+
+//SetImageSource changes a given image's source in the database
+func (DBConnection *MemoryPlugin) SetImageSource(ctx context.Context, ID uint64, Source string) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if image, exists := DBConnection.images[ID]; exists {
+		image.Source = Source
+	}
+	return nil
+}
+
+//SetImagedHash changes a given image's dHash in the database
+func (DBConnection *MemoryPlugin) SetImagedHash(ctx context.Context, ID uint64, hHash uint64, vHash uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if _, exists := DBConnection.images[ID]; exists == false {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/ImageFunctions/SetImagedHash", "0", logging.ResultFailure, []string{"Failed to set image dHashes", "image does not exist"})
+		return errors.New("image does not exist")
+	}
+	DBConnection.imagedHashes[ID] = interfaces.ImagedHash{ImagehHash: hHash, ImagevHash: vHash}
+	return nil
+}
+
+//GetImagedHash changes a given image's dHash in the database
+func (DBConnection *MemoryPlugin) GetImagedHash(ctx context.Context, ID uint64) (uint64, uint64, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	return DBConnection.getImagedHash(ID)
+}
+
+//getImagedHash is GetImagedHash for callers that already hold the lock
+func (DBConnection *MemoryPlugin) getImagedHash(ID uint64) (uint64, uint64, error) {
+	hash, exists := DBConnection.imagedHashes[ID]
+	if exists == false {
+		return 0, 0, sql.ErrNoRows
+	}
+	return hash.ImagehHash, hash.ImagevHash, nil
+}
+
+//getImageByLocation returns the image stored at the given location, or nil. Lock must be held
+func (DBConnection *MemoryPlugin) getImageByLocation(Location string) *memoryImage {
+	for _, image := range DBConnection.images {
+		if image.Location == Location {
+			return image
+		}
+	}
+	return nil
+}
+
+//getImageInformation converts a stored image to the ImageInformation GetImage returns. Lock must be held
+func (DBConnection *MemoryPlugin) getImageInformation(image *memoryImage) interfaces.ImageInformation {
+	ToReturn := interfaces.ImageInformation{
+		ID:            image.ID,
+		Name:          image.Name,
+		Description:   image.Description,
+		Location:      image.Location,
+		UploaderID:    image.UploaderID,
+		UploadTime:    image.UploadTime,
+		Rating:        image.Rating,
+		ScoreAverage:  image.ScoreAverage,
+		ScoreTotal:    image.ScoreTotal,
+		ScoreVoters:   image.ScoreVoters,
+		Source:        image.Source,
+		DeletedTime:   image.DeletedTime,
+		DeletedByID:   image.DeletedBy,
+		ImageMetadata: image.Metadata,
+	}
+	if uploader, exists := DBConnection.users[image.UploaderID]; exists {
+		ToReturn.UploaderName = uploader.Name
+	}
+	if deleter, exists := DBConnection.users[image.DeletedBy]; exists {
+		ToReturn.DeletedByName = deleter.Name
+	}
+	return ToReturn
+}
@@ -0,0 +1,79 @@
+package memoryplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"time"
+)
+
+//AddImageRevision records a change made to an image
+func (DBConnection *MemoryPlugin) AddImageRevision(ctx context.Context, Revision interfaces.ImageRevision) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	DBConnection.lastRevisionID++
+	Revision.ID = DBConnection.lastRevisionID
+	Revision.UserName = ""
+	Revision.ChangeTime = time.Now()
+	DBConnection.imageRevisions = append(DBConnection.imageRevisions, Revision)
+	return nil
+}
+
+//GetImageRevision returns one recorded change to an image
+func (DBConnection *MemoryPlugin) GetImageRevision(ctx context.Context, RevisionID uint64) (interfaces.ImageRevision, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	for _, Revision := range DBConnection.imageRevisions {
+		if Revision.ID == RevisionID {
+			return DBConnection.getImageRevision(Revision), nil
+		}
+	}
+	return interfaces.ImageRevision{}, sql.ErrNoRows
+}
+
+//GetImageRevisions returns the changes made to an image, newest first, and how many there are in total
+func (DBConnection *MemoryPlugin) GetImageRevisions(ctx context.Context, ImageID uint64, PageStart uint64, PageStride uint64) ([]interfaces.ImageRevision, uint64, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.ImageRevision
+	for Index := len(DBConnection.imageRevisions) - 1; Index >= 0; Index-- {
+		if DBConnection.imageRevisions[Index].ImageID == ImageID {
+			ToReturn = append(ToReturn, DBConnection.getImageRevision(DBConnection.imageRevisions[Index]))
+		}
+	}
+	return pageSlice(ToReturn, PageStart, PageStride), uint64(len(ToReturn)), nil
+}
+
+//GetImageRevisionsSince returns the changes made to an image from RevisionID on, including RevisionID itself, oldest first
+func (DBConnection *MemoryPlugin) GetImageRevisionsSince(ctx context.Context, ImageID uint64, RevisionID uint64) ([]interfaces.ImageRevision, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.ImageRevision
+	for _, Revision := range DBConnection.imageRevisions {
+		if Revision.ImageID == ImageID && Revision.ID >= RevisionID {
+			ToReturn = append(ToReturn, DBConnection.getImageRevision(Revision))
+		}
+	}
+	return ToReturn, nil
+}
+
+//GetUserImageRevisions returns the changes a user made to any image from Since up to but not including Until, oldest first
+func (DBConnection *MemoryPlugin) GetUserImageRevisions(ctx context.Context, UserID uint64, Since time.Time, Until time.Time) ([]interfaces.ImageRevision, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.ImageRevision
+	for _, Revision := range DBConnection.imageRevisions {
+		if Revision.UserID == UserID && Revision.ChangeTime.Before(Since) == false && Revision.ChangeTime.Before(Until) {
+			ToReturn = append(ToReturn, DBConnection.getImageRevision(Revision))
+		}
+	}
+	return ToReturn, nil
+}
+
+//getImageRevision fills in the joined UserName of a stored revision. Lock must be held
+func (DBConnection *MemoryPlugin) getImageRevision(Revision interfaces.ImageRevision) interfaces.ImageRevision {
+	if user, exists := DBConnection.users[Revision.UserID]; exists {
+		Revision.UserName = user.Name
+	}
+	return Revision
+}
@@ -0,0 +1,465 @@
+package memoryplugin
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"go-image-board/interfaces"
+	"math/bits"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//SearchImages performs a search for images (Returns a list of ImageInformations a result count and an error/nil)
+func (DBConnection *MemoryPlugin) SearchImages(ctx context.Context, Tags []interfaces.TagInformation, PageStart uint64, PageStride uint64) ([]interfaces.ImageInformation, uint64, error) {
+	ToReturn, _, MaxResults, err := DBConnection.SearchImagesPage(ctx, Tags, "", PageStart, PageStride, true)
+	return ToReturn, MaxResults, err
+}
+
+//SearchImagesPage performs a search for a page of images, continuing after Cursor when it is set or starting at PageStart otherwise
+//Returns the images, the cursor for the next page (blank on the last page), the result count if CountResults is set, and an error/nil
+//If you edit this function, consider SearchCollections and GetPrevNexImages for a similar change
+func (DBConnection *MemoryPlugin) SearchImagesPage(ctx context.Context, Tags []interfaces.TagInformation, Cursor string, PageStart uint64, PageStride uint64, CountResults bool) ([]interfaces.ImageInformation, string, uint64, error) {
+	//Nothing here blocks, so a cancelled request is only noticed before the scan starts
+	if err := ctx.Err(); err != nil {
+		return nil, "", 0, err
+	}
+	//Newest first, same as ORDER BY ID DESC, unless an order tag says otherwise
+	Order := getSearchOrder(Tags)
+	var AfterID uint64
+	var AfterSortKey int64
+	if Cursor != "" {
+		var err error
+		AfterID, AfterSortKey, Order, err = interfaces.DecodeSearchCursor(Cursor, Order)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		PageStart = 0
+	}
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.ImageInformation
+	matches, err := DBConnection.getMatchingImages(Tags)
+	if err != nil {
+		return ToReturn, "", 0, err
+	}
+	sort.Slice(matches, func(i, j int) bool { return DBConnection.imageOrderLess(Order, matches[i], matches[j]) })
+	var MaxResults uint64
+	if CountResults {
+		MaxResults = uint64(len(matches))
+	}
+	//Continue after the cursor's image, the same way as the previous image link on its page
+	if AfterID != 0 {
+		matches = matches[sort.Search(len(matches), func(i int) bool {
+			return sortKeyLess(Order, AfterSortKey, AfterID, DBConnection.imageSortKey(Order, matches[i]), matches[i].ID)
+		}):]
+	}
+	page := pageSlice(matches, PageStart, PageStride)
+	for _, image := range page {
+		ToReturn = append(ToReturn, interfaces.ImageInformation{Name: image.Name, ID: image.ID, Location: image.Location})
+	}
+	var NextCursor string
+	if len(page) > 0 && PageStart+uint64(len(page)) < uint64(len(matches)) {
+		NextCursor = interfaces.EncodeSearchCursor(page[len(page)-1].ID, DBConnection.imageSortKey(Order, page[len(page)-1]), Order)
+	}
+	return ToReturn, NextCursor, MaxResults, nil
+}
+
+//GetPrevNexImages performs a search for images (Returns a list of ImageInformations (Up to 2) and an error/nil)
+func (DBConnection *MemoryPlugin) GetPrevNexImages(ctx context.Context, Tags []interfaces.TagInformation, TargetID uint64) ([]interfaces.ImageInformation, error) {
+	if TargetID == 0 {
+		return nil, errors.New("invalid targetid")
+	}
+
+	var ToReturn []interfaces.ImageInformation
+
+	if imageInfo, err := DBConnection.getPrevNexImage(Tags, TargetID, true); err == nil {
+		ToReturn = append(ToReturn, imageInfo)
+	} else if err != sql.ErrNoRows {
+		return ToReturn, err
+	}
+
+	if imageInfo, err := DBConnection.getPrevNexImage(Tags, TargetID, false); err == nil {
+		ToReturn = append(ToReturn, imageInfo)
+	} else if err != sql.ErrNoRows {
+		return ToReturn, err
+	}
+
+	return ToReturn, nil
+}
+
+//getPrevNexImage returns the closest matching image after (Next) or before the target image
+func (DBConnection *MemoryPlugin) getPrevNexImage(Tags []interfaces.TagInformation, TargetID uint64, Next bool) (interfaces.ImageInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn interfaces.ImageInformation
+	matches, err := DBConnection.getMatchingImages(Tags)
+	if err != nil {
+		return ToReturn, err
+	}
+	//The next image is the one before the target in the search results
+	Order := getSearchOrder(Tags)
+	target, hasTarget := DBConnection.images[TargetID]
+	if hasTarget == false {
+		if Order.Name != "" {
+			return ToReturn, sql.ErrNoRows //There is no sort key to compare against
+		}
+		target = &memoryImage{ID: TargetID}
+	}
+	var closest *memoryImage
+	for _, image := range matches {
+		if Next && DBConnection.imageOrderLess(Order, image, target) && (closest == nil || DBConnection.imageOrderLess(Order, closest, image)) {
+			closest = image
+		} else if Next == false && DBConnection.imageOrderLess(Order, target, image) && (closest == nil || DBConnection.imageOrderLess(Order, image, closest)) {
+			closest = image
+		}
+	}
+	if closest == nil {
+		return ToReturn, sql.ErrNoRows
+	}
+	return interfaces.ImageInformation{Name: closest.Name, ID: closest.ID, Location: closest.Location}, nil
+}
+
+//GetRandomImage returns a random image (Returns a ImageInformation and an error/nil)
+func (DBConnection *MemoryPlugin) GetRandomImage(ctx context.Context, Tags []interfaces.TagInformation) (interfaces.ImageInformation, uint64, error) {
+	imageInfo, resultCount, err := DBConnection.SearchImages(ctx, Tags, 0, 1)
+
+	if err == nil {
+		if resultCount <= 0 {
+			return interfaces.ImageInformation{}, 0, errors.New("no images found with provided tags")
+		}
+		if resultCount == 1 {
+			return imageInfo[0], resultCount, nil //Shortcut for one result
+		}
+
+		rando := rand.Float64()
+		randoID := uint64(rando * float64(resultCount))
+		imageInfo, _, err = DBConnection.SearchImages(ctx, Tags, randoID, 1)
+		if err == nil {
+			return imageInfo[0], resultCount, nil
+		}
+		return interfaces.ImageInformation{}, resultCount, err
+	}
+	return interfaces.ImageInformation{}, resultCount, err
+}
+
+//getMatchingImages returns every image that satisfies the provided tags, in no particular order. Lock must be held
+func (DBConnection *MemoryPlugin) getMatchingImages(Tags []interfaces.TagInformation) ([]*memoryImage, error) {
+	//Cleanup input for use in code below
+	//Specifically we separate the include, the exclude and metatags into their own lists
+	var IncludeTags []uint64
+	var ExcludeTags []uint64
+	var MetaTags []interfaces.TagInformation
+	for _, tag := range Tags {
+		if tag.Exists && tag.IsAlias == false && tag.IsMeta == false {
+			if tag.Exclude {
+				ExcludeTags = append(ExcludeTags, tag.ID)
+			} else {
+				IncludeTags = append(IncludeTags, tag.ID)
+			}
+		} else if tag.Exists && tag.IsMeta {
+			if _, isOrder := tag.MetaValue.(interfaces.SearchOrder); isOrder == false {
+				MetaTags = append(MetaTags, tag) //Orders sort the results instead of filtering them
+			}
+		}
+	}
+
+	//Validate meta tags up front, so a bad tag errors even when there are no images
+	for _, tag := range MetaTags {
+		comparator := tag.Comparator
+		if tag.Exclude {
+			comparator = getInvertedComparator(comparator)
+		}
+		if comparator == "" {
+			return nil, errors.New("Failed to invert query to negate on " + tag.Name)
+		}
+	}
+
+	var ToReturn []*memoryImage
+	for _, image := range DBConnection.images {
+		if image.DeletedTime.IsZero() == false {
+			continue //Images in the recycle bin never show up in searches
+		}
+		imageTags := DBConnection.imageTags[image.ID]
+		matches := true
+		for _, TagID := range IncludeTags {
+			if _, hasTag := imageTags[TagID]; hasTag == false {
+				matches = false
+				break
+			}
+		}
+		for _, TagID := range ExcludeTags {
+			if _, hasTag := imageTags[TagID]; hasTag {
+				matches = false
+				break
+			}
+		}
+		for _, tag := range MetaTags {
+			if matches == false {
+				break
+			}
+			metaMatches, err := DBConnection.imageMatchesMetaTag(image, tag)
+			if err != nil {
+				return nil, err
+			}
+			matches = metaMatches
+		}
+		if matches {
+			ToReturn = append(ToReturn, image)
+		}
+	}
+	return ToReturn, nil
+}
+
+//imageMatchesMetaTag evaluates a single meta tag against an image. Lock must be held
+func (DBConnection *MemoryPlugin) imageMatchesMetaTag(image *memoryImage, tag interfaces.TagInformation) (bool, error) {
+	//Handle Comparator transforms
+	comparator := tag.Comparator
+	if tag.Exclude {
+		comparator = getInvertedComparator(comparator)
+	}
+
+	//Handle Complex Tags Here
+	if tagGroupValue, isTagGroup := tag.MetaValue.(interfaces.QueryGroup); isTagGroup {
+		groupMatches, err := DBConnection.imageMatchesTagGroup(image, tagGroupValue)
+		if err != nil {
+			return false, err
+		}
+		return groupMatches != (comparator == "!="), nil
+	}
+	switch tag.Name {
+	case "InCollection":
+		tagBoolValue, isTagValued := tag.MetaValue.(bool)
+		if isTagValued == false {
+			return false, errors.New("Failed get value of " + tag.Name)
+		}
+		wantInCollection := (comparator == "=" && tagBoolValue == true) || (comparator == "!=" && tagBoolValue == false)
+		return DBConnection.imageIsInCollection(image.ID) == wantInCollection, nil
+	case "TagCount":
+		tagStringValue, isTagValued := tag.MetaValue.(string)
+		if isTagValued == false {
+			return false, errors.New("Failed get value of " + tag.Name)
+		}
+		tagCount := len(DBConnection.imageTags[image.ID])
+		if tagCount == 0 {
+			//The SQL plugins only count images that have at least one tag
+			return false, nil
+		}
+		countValue, err := strconv.ParseInt(tagStringValue, 10, 64)
+		if err != nil {
+			return false, err
+		}
+		return compareMetaValue(int64(tagCount), comparator, countValue)
+	case "Similar":
+		tagImagedHashValue, isTagValued := tag.MetaValue.(interfaces.ImagedHash)
+		if isTagValued == false {
+			return false, errors.New("Failed get value of " + tag.Name)
+		}
+		hash, hasHash := DBConnection.imagedHashes[image.ID]
+		if hasHash == false {
+			return false, nil
+		}
+		distance := bits.OnesCount64(hash.ImagehHash^tagImagedHashValue.ImagehHash) + bits.OnesCount64(hash.ImagevHash^tagImagedHashValue.ImagevHash)
+		return compareMetaValue(int64(distance), comparator, int64(tagImagedHashValue.SimilarityThreshold))
+	case "Ratio":
+		tagRatioValue, isTagValued := tag.MetaValue.(interfaces.ImageRatio)
+		if isTagValued == false {
+			return false, errors.New("Failed get value of " + tag.Name)
+		}
+		if image.Metadata.Height == 0 {
+			//Images without read metadata have no dimensions, so they only match when the ratio is excluded
+			return comparator == "!=", nil
+		}
+		imageSide := int64(image.Metadata.Width * tagRatioValue.Height)
+		ratioSide := int64(image.Metadata.Height * tagRatioValue.Width)
+		//Decimal ratios are rounded, so they match images within the tolerance on either side
+		tolerance := int64(image.Metadata.Height * tagRatioValue.Tolerance)
+		switch comparator {
+		case "!=":
+			return imageSide > ratioSide+tolerance || imageSide+tolerance < ratioSide, nil
+		case "<":
+			return imageSide+tolerance < ratioSide, nil
+		case "<=":
+			return imageSide <= ratioSide+tolerance, nil
+		case ">":
+			return imageSide > ratioSide+tolerance, nil
+		case ">=":
+			return imageSide+tolerance >= ratioSide, nil
+		}
+		return imageSide <= ratioSide+tolerance && imageSide+tolerance >= ratioSide, nil
+	case "UploadTime":
+		tagRangeValue, isTagValued := tag.MetaValue.(interfaces.TimeRange)
+		if isTagValued == false {
+			return false, errors.New("Failed get value of " + tag.Name)
+		}
+		From, To := tagRangeValue.Bounds(comparator)
+		inRange := (From.IsZero() || image.UploadTime.Before(From) == false) && (To.IsZero() || image.UploadTime.Before(To))
+		return inRange != (comparator == "!="), nil
+	}
+
+	//Otherwise a direct property of the image
+	var fieldValue interface{}
+	switch tag.Name {
+	case "UploaderID":
+		fieldValue = image.UploaderID
+	case "Rating":
+		fieldValue = image.Rating
+	case "ScoreAverage":
+		fieldValue = image.ScoreAverage
+	case "ScoreTotal":
+		fieldValue = image.ScoreTotal
+	case "ScoreVoters":
+		fieldValue = image.ScoreVoters
+	case "Name":
+		fieldValue = image.Name
+	case "Location":
+		fieldValue = image.Location
+	case "Description":
+		fieldValue = image.Description
+	case "Source":
+		fieldValue = image.Source
+	case "Width":
+		fieldValue = image.Metadata.Width
+	case "Height":
+		fieldValue = image.Metadata.Height
+	case "FileSize":
+		fieldValue = image.Metadata.FileSize
+	case "MIMEType":
+		fieldValue = image.Metadata.MIMEType
+	case "Duration":
+		fieldValue = image.Metadata.Duration
+	default:
+		return false, errors.New("Unknown column " + tag.Name)
+	}
+	return compareMetaValue(fieldValue, comparator, tag.MetaValue)
+}
+
+//imageMatchesTagGroup checks whether an image has every tag in any one of the group's alternatives. Lock must be held
+func (DBConnection *MemoryPlugin) imageMatchesTagGroup(image *memoryImage, Group interfaces.QueryGroup) (bool, error) {
+	for _, alternative := range Group.Alternatives {
+		matches := true
+		conditions := 0 //An alternative of only ignored tags matches nothing, rather than everything
+		for _, tag := range alternative {
+			if tag.IsMeta {
+				if _, isOrder := tag.MetaValue.(interfaces.SearchOrder); isOrder {
+					return false, interfaces.ErrOrderInGroup //Orders only apply to a whole search
+				}
+				if tag.Exists == false {
+					continue
+				}
+				if tag.Exclude && getInvertedComparator(tag.Comparator) == "" {
+					return false, errors.New("Failed to invert query to negate on " + tag.Name)
+				}
+				metaMatches, err := DBConnection.imageMatchesMetaTag(image, tag)
+				if err != nil {
+					return false, err
+				}
+				matches = metaMatches
+			} else if tag.IsAlias {
+				continue //The tag it is an alias of is in the group alongside it
+			} else if tag.Exists == false {
+				matches = tag.Exclude //No image can have a tag that does not exist
+			} else {
+				_, hasTag := DBConnection.imageTags[image.ID][tag.ID]
+				matches = hasTag != tag.Exclude
+			}
+			conditions++
+			if matches == false {
+				break
+			}
+		}
+		if matches && conditions > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+//getSearchOrder returns the order from a search's tags, the last one wins when there are several
+func getSearchOrder(Tags []interfaces.TagInformation) interfaces.SearchOrder {
+	var Order interfaces.SearchOrder
+	for _, tag := range Tags {
+		if tagOrderValue, isOrder := tag.MetaValue.(interfaces.SearchOrder); isOrder && tag.Exists {
+			Order = tagOrderValue
+		}
+	}
+	return Order
+}
+
+//imageSortKey returns what Order sorts an image by, upload times are Unix nanoseconds the same as in search cursors. Lock must be held
+func (DBConnection *MemoryPlugin) imageSortKey(Order interfaces.SearchOrder, image *memoryImage) int64 {
+	switch Order.Name {
+	case "score":
+		return image.ScoreAverage
+	case "votes":
+		return image.ScoreVoters
+	case "uploaded":
+		return image.UploadTime.UnixNano()
+	case "filesize":
+		return int64(image.Metadata.FileSize)
+	case "tagcount":
+		return int64(len(DBConnection.imageTags[image.ID]))
+	case "random":
+		return Order.RandomOrderKey(image.ID)
+	}
+	return 0
+}
+
+//imageOrderLess returns whether image a is listed before image b in search results sorted by Order, ties are broken by ID. Lock must be held
+func (DBConnection *MemoryPlugin) imageOrderLess(Order interfaces.SearchOrder, a *memoryImage, b *memoryImage) bool {
+	return sortKeyLess(Order, DBConnection.imageSortKey(Order, a), a.ID, DBConnection.imageSortKey(Order, b), b.ID)
+}
+
+//sortKeyLess returns whether the image with aSortKey and aID is listed before the image with bSortKey and bID in search results sorted by Order
+func sortKeyLess(Order interfaces.SearchOrder, aSortKey int64, aID uint64, bSortKey int64, bID uint64) bool {
+	compare := cmp.Compare(aSortKey, bSortKey)
+	if compare == 0 {
+		compare = cmp.Compare(aID, bID)
+	}
+	if Order.Ascending {
+		return compare < 0
+	}
+	return compare > 0
+}
+
+//imageIsInCollection returns whether an image is a member of any collection. Lock must be held
+func (DBConnection *MemoryPlugin) imageIsInCollection(ImageID uint64) bool {
+	for _, members := range DBConnection.collectionMembers {
+		if _, isMember := members[ImageID]; isMember {
+			return true
+		}
+	}
+	return false
+}
+
+//GetImageUploadCounts returns how many images not in the recycle bin were uploaded in each day (ByDay) or month from Since up to but not including Until, oldest first
+func (DBConnection *MemoryPlugin) GetImageUploadCounts(ctx context.Context, Since time.Time, Until time.Time, ByDay bool) ([]interfaces.UploadCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	Layout := "2006-01"
+	if ByDay {
+		Layout = "2006-01-02"
+	}
+	Counts := make(map[string]uint64)
+	for _, image := range DBConnection.images {
+		if image.DeletedTime.IsZero() == false {
+			continue
+		}
+		if (Since.IsZero() == false && image.UploadTime.Before(Since)) || (Until.IsZero() == false && image.UploadTime.Before(Until) == false) {
+			continue
+		}
+		Counts[image.UploadTime.UTC().Format(Layout)]++
+	}
+	var ToReturn []interfaces.UploadCount
+	for Period, Count := range Counts {
+		ToReturn = append(ToReturn, interfaces.UploadCount{Period: Period, Count: Count})
+	}
+	sort.Slice(ToReturn, func(i, j int) bool { return ToReturn[i].Period < ToReturn[j].Period })
+	return ToReturn, nil
+}
@@ -0,0 +1,132 @@
+package memoryplugin
+
+import (
+	"context"
+	"errors"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"sort"
+	"strconv"
+)
+
+//GetImageTags returns a list of TagInformation for all tags that apply to the given image
+func (DBConnection *MemoryPlugin) GetImageTags(ctx context.Context, ImageID uint64) ([]interfaces.TagInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.TagInformation
+	for TagID := range DBConnection.imageTags[ImageID] {
+		if tag, exists := DBConnection.tags[TagID]; exists {
+			ToReturn = append(ToReturn, interfaces.TagInformation{Name: tag.Name, ID: tag.ID, Description: tag.Description, Exists: true, Exclude: false})
+		}
+	}
+	sort.Slice(ToReturn, func(i, j int) bool { return ToReturn[i].Name < ToReturn[j].Name })
+	return ToReturn, nil
+}
+
+//RemoveTag remove a tag association
+func (DBConnection *MemoryPlugin) RemoveTag(ctx context.Context, TagID uint64, ImageID uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	delete(DBConnection.imageTags[ImageID], TagID)
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/RemoveTag", "0", logging.ResultSuccess, []string{"Tag removed", strconv.FormatUint(TagID, 10), strconv.FormatUint(ImageID, 10)})
+	return nil
+}
+
+//GetTagImageIDs returns the IDs of every image with a tag, including images in the recycle bin
+func (DBConnection *MemoryPlugin) GetTagImageIDs(ctx context.Context, TagID uint64) ([]uint64, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []uint64
+	for ImageID, imageTags := range DBConnection.imageTags {
+		if _, hasTag := imageTags[TagID]; hasTag {
+			ToReturn = append(ToReturn, ImageID)
+		}
+	}
+	sort.Slice(ToReturn, func(i, j int) bool { return ToReturn[i] < ToReturn[j] })
+	return ToReturn, nil
+}
+
+//ReplaceImageTags replaces all instances of ImageTags that have the specified tag with the new tag
+func (DBConnection *MemoryPlugin) ReplaceImageTags(ctx context.Context, OldTagID uint64, NewTagID uint64, LinkerID uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	DBConnection.replaceImageTags(OldTagID, NewTagID, LinkerID)
+	return nil
+}
+
+//replaceImageTags is ReplaceImageTags for callers that already hold the lock
+func (DBConnection *MemoryPlugin) replaceImageTags(OldTagID uint64, NewTagID uint64, LinkerID uint64) {
+	for ImageID, imageTags := range DBConnection.imageTags {
+		if _, hasOldTag := imageTags[OldTagID]; hasOldTag == false {
+			continue
+		}
+		//Images that already have the new tag keep their original link
+		if _, hasNewTag := imageTags[NewTagID]; hasNewTag == false {
+			DBConnection.linkImageTag(NewTagID, ImageID, LinkerID)
+		}
+		delete(imageTags, OldTagID)
+	}
+}
+
+//BulkAddTag adds an association of a tag to image into the association table that already have another tag
+func (DBConnection *MemoryPlugin) BulkAddTag(ctx context.Context, TagID uint64, OldTagID uint64, LinkerID uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	//Prevent adding alias
+	tagInfo, exists := DBConnection.tags[TagID]
+	oldTagInfo, oldExists := DBConnection.tags[OldTagID]
+	if exists == false || oldExists == false {
+		return errors.New("Failed to validate tags")
+	}
+
+	//If this is an alias, then add aliasedid instead
+	if tagInfo.IsAlias {
+		TagID = tagInfo.AliasedID
+	}
+
+	//Similiarly convert oldTag if it is an alias
+	if oldTagInfo.IsAlias {
+		OldTagID = oldTagInfo.AliasedID
+	}
+
+	for ImageID, imageTags := range DBConnection.imageTags {
+		_, hasOldTag := imageTags[OldTagID]
+		_, hasNewTag := imageTags[TagID]
+		if hasOldTag && hasNewTag == false {
+			DBConnection.linkImageTag(TagID, ImageID, LinkerID)
+		}
+	}
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/BulkAddTag", strconv.FormatUint(LinkerID, 10), logging.ResultSuccess, []string{"Tags added", strconv.FormatUint(OldTagID, 10), strconv.FormatUint(TagID, 10)})
+	return nil
+}
+
+//linkImageTag adds or updates a single ImageTags row. Lock must be held
+func (DBConnection *MemoryPlugin) linkImageTag(TagID uint64, ImageID uint64, LinkerID uint64) {
+	if DBConnection.imageTags[ImageID] == nil {
+		DBConnection.imageTags[ImageID] = make(map[uint64]uint64)
+	}
+	DBConnection.imageTags[ImageID][TagID] = LinkerID
+}
+
+//inverts a tags comparator
+func getInvertedComparator(comparator string) string {
+	if comparator == "=" {
+		return "!="
+	}
+	if comparator == ">" {
+		return "<="
+	}
+	if comparator == "<" {
+		return ">="
+	}
+	if comparator == ">=" {
+		return "<"
+	}
+	if comparator == "<=" {
+		return ">"
+	}
+	if comparator == "LIKE" {
+		return "NOT LIKE"
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package memoryplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"sort"
+	"time"
+)
+
+//TrashImage moves an image to the recycle bin, the image is kept until it is restored or purged
+func (DBConnection *MemoryPlugin) TrashImage(ctx context.Context, ImageID uint64, DeletedBy uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	image, exists := DBConnection.images[ImageID]
+	if exists == false || image.DeletedTime.IsZero() == false {
+		return sql.ErrNoRows
+	}
+	image.DeletedTime = time.Now()
+	image.DeletedBy = DeletedBy
+	return nil
+}
+
+//RestoreImage takes an image back out of the recycle bin
+func (DBConnection *MemoryPlugin) RestoreImage(ctx context.Context, ImageID uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	image, exists := DBConnection.images[ImageID]
+	if exists == false || image.DeletedTime.IsZero() {
+		return sql.ErrNoRows
+	}
+	image.DeletedTime = time.Time{}
+	image.DeletedBy = 0
+	return nil
+}
+
+//IsImageFileTrashed returns whether the image stored under Location is in the recycle bin, false if no image is stored there
+func (DBConnection *MemoryPlugin) IsImageFileTrashed(ctx context.Context, Location string) (bool, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	image := DBConnection.getImageByLocation(Location)
+	return image != nil && image.DeletedTime.IsZero() == false, nil
+}
+
+//GetTrashedImages returns images in the recycle bin, most recently deleted first, and how many there are in total
+func (DBConnection *MemoryPlugin) GetTrashedImages(ctx context.Context, PageStart uint64, PageStride uint64) ([]interfaces.ImageInformation, uint64, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	trashed := DBConnection.getTrashedImages()
+	//Newest first, getTrashedImages sorts oldest first
+	for i, j := 0, len(trashed)-1; i < j; i, j = i+1, j-1 {
+		trashed[i], trashed[j] = trashed[j], trashed[i]
+	}
+	return pageSlice(trashed, PageStart, PageStride), uint64(len(trashed)), nil
+}
+
+//GetImagesTrashedBefore returns up to Limit images that were moved to the recycle bin before the given time, oldest first
+func (DBConnection *MemoryPlugin) GetImagesTrashedBefore(ctx context.Context, Before time.Time, Limit uint64) ([]interfaces.ImageInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.ImageInformation
+	for _, image := range DBConnection.getTrashedImages() {
+		if image.DeletedTime.Before(Before) {
+			ToReturn = append(ToReturn, image)
+		}
+	}
+	return pageSlice(ToReturn, 0, Limit), nil
+}
+
+//getTrashedImages returns every image in the recycle bin, oldest deletion first. Lock must be held
+func (DBConnection *MemoryPlugin) getTrashedImages() []interfaces.ImageInformation {
+	var ToReturn []interfaces.ImageInformation
+	for _, image := range DBConnection.images {
+		if image.DeletedTime.IsZero() == false {
+			ToReturn = append(ToReturn, DBConnection.getImageInformation(image))
+		}
+	}
+	sort.Slice(ToReturn, func(i, j int) bool {
+		if ToReturn[i].DeletedTime.Equal(ToReturn[j].DeletedTime) {
+			return ToReturn[i].ID < ToReturn[j].ID
+		}
+		return ToReturn[i].DeletedTime.Before(ToReturn[j].DeletedTime)
+	})
+	return ToReturn
+}
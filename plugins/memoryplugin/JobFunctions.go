@@ -0,0 +1,162 @@
+package memoryplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"sort"
+	"time"
+)
+
+//EnqueueJob queues a job to run at RunAfter. A pending or failed job of the same type for the same image is reset to pending with no attempts instead, a running one is marked to rerun
+func (DBConnection *MemoryPlugin) EnqueueJob(ctx context.Context, Type interfaces.JobType, ImageID uint64, RunAfter time.Time) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	for _, Job := range DBConnection.jobs {
+		if Job.Type == Type && Job.ImageID == ImageID {
+			Job.RunAfter = RunAfter
+			if Job.Status == interfaces.JobRunning {
+				Job.Rerun = true
+			} else {
+				Job.Status = interfaces.JobPending
+				Job.Attempts = 0
+				Job.UpdatedTime = time.Now()
+			}
+			return nil
+		}
+	}
+	DBConnection.lastJobID++
+	DBConnection.jobs[DBConnection.lastJobID] = &interfaces.Job{ID: DBConnection.lastJobID, Type: Type, ImageID: ImageID, Status: interfaces.JobPending, RunAfter: RunAfter, CreatedTime: time.Now(), UpdatedTime: time.Now()}
+	return nil
+}
+
+//ClaimJob marks the pending job that has been due the longest as running and counts the attempt, returns sql.ErrNoRows if no job is due at Now
+func (DBConnection *MemoryPlugin) ClaimJob(ctx context.Context, Now time.Time) (interfaces.Job, error) {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	var Claimed *interfaces.Job
+	for _, Job := range DBConnection.jobs {
+		if Job.Status != interfaces.JobPending || Job.RunAfter.After(Now) {
+			continue
+		}
+		if Claimed == nil || Job.RunAfter.Before(Claimed.RunAfter) || (Job.RunAfter.Equal(Claimed.RunAfter) && Job.ID < Claimed.ID) {
+			Claimed = Job
+		}
+	}
+	if Claimed == nil {
+		return interfaces.Job{}, sql.ErrNoRows
+	}
+	Claimed.Status = interfaces.JobRunning
+	Claimed.Attempts++
+	Claimed.UpdatedTime = time.Now()
+	return *Claimed, nil
+}
+
+//FinishJob removes a job that succeeded, or sets it back to pending with no attempts if it was marked to rerun
+func (DBConnection *MemoryPlugin) FinishJob(ctx context.Context, JobID uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	Job, exists := DBConnection.jobs[JobID]
+	if exists == false {
+		return nil
+	}
+	if Job.Rerun == false {
+		delete(DBConnection.jobs, JobID)
+		return nil
+	}
+	if Job.Status == interfaces.JobRunning {
+		Job.Status = interfaces.JobPending
+		Job.Attempts = 0
+		Job.LastError = ""
+		Job.UpdatedTime = time.Now()
+	}
+	Job.Rerun = false
+	return nil
+}
+
+//FailJob records why an attempt failed. The job is set back to pending to retry at RetryAt, or marked failed if RetryAt is zero. Jobs marked to rerun are set back to pending with no attempts instead
+func (DBConnection *MemoryPlugin) FailJob(ctx context.Context, JobID uint64, Error string, RetryAt time.Time) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	Job, exists := DBConnection.jobs[JobID]
+	if exists == false {
+		return sql.ErrNoRows
+	}
+	Job.LastError = Error
+	Job.UpdatedTime = time.Now()
+	if Job.Rerun {
+		Job.Status = interfaces.JobPending
+		Job.Attempts = 0
+		Job.Rerun = false
+	} else if RetryAt.IsZero() {
+		Job.Status = interfaces.JobFailed
+	} else {
+		Job.Status = interfaces.JobPending
+		Job.RunAfter = RetryAt
+	}
+	return nil
+}
+
+//RetryFailedJobs sets a failed job back to pending with no attempts, to run at RunAfter. JobID 0 retries every failed job. Returns how many were retried
+func (DBConnection *MemoryPlugin) RetryFailedJobs(ctx context.Context, JobID uint64, RunAfter time.Time) (uint64, error) {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	var Retried uint64
+	for _, Job := range DBConnection.jobs {
+		if Job.Status == interfaces.JobFailed && (JobID == 0 || Job.ID == JobID) {
+			Job.Status = interfaces.JobPending
+			Job.Attempts = 0
+			Job.RunAfter = RunAfter
+			Job.UpdatedTime = time.Now()
+			Retried++
+		}
+	}
+	return Retried, nil
+}
+
+//RequeueRunningJobs sets running jobs that have not been updated for Lease back to pending to run at RunAfter, for jobs whose worker stopped before finishing them. Returns how many were requeued
+func (DBConnection *MemoryPlugin) RequeueRunningJobs(ctx context.Context, RunAfter time.Time, Lease time.Duration) (uint64, error) {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	var Requeued uint64
+	for _, Job := range DBConnection.jobs {
+		if Job.Status == interfaces.JobRunning && time.Since(Job.UpdatedTime) >= Lease {
+			Job.Status = interfaces.JobPending
+			Job.RunAfter = RunAfter
+			Job.Rerun = false
+			Job.UpdatedTime = time.Now()
+			Requeued++
+		}
+	}
+	return Requeued, nil
+}
+
+//GetJobs returns jobs with the given status, most recently updated first, and the total number of them
+func (DBConnection *MemoryPlugin) GetJobs(ctx context.Context, Status interfaces.JobStatus, PageStart uint64, PageStride uint64) ([]interfaces.Job, uint64, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var Matching []interfaces.Job
+	for _, Job := range DBConnection.jobs {
+		if Job.Status == Status {
+			Matching = append(Matching, *Job)
+		}
+	}
+	sort.Slice(Matching, func(i, j int) bool {
+		if Matching[i].UpdatedTime.Equal(Matching[j].UpdatedTime) == false {
+			return Matching[i].UpdatedTime.After(Matching[j].UpdatedTime)
+		}
+		return Matching[i].ID > Matching[j].ID
+	})
+	return pageSlice(Matching, PageStart, PageStride), uint64(len(Matching)), nil
+}
+
+//GetJobCounts returns how many jobs have each status, statuses without jobs are left out
+func (DBConnection *MemoryPlugin) GetJobCounts(ctx context.Context) (map[interfaces.JobStatus]uint64, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	Counts := make(map[interfaces.JobStatus]uint64)
+	for _, Job := range DBConnection.jobs {
+		Counts[Job.Status]++
+	}
+	return Counts, nil
+}
@@ -0,0 +1,257 @@
+package memoryplugin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-image-board/interfaces"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//MemoryPlugin is a DBInterface that keeps everything in process memory.
+//Nothing is persisted, it exists so that routers and the backend conformance suite can run without a database server.
+type MemoryPlugin struct {
+	lock sync.RWMutex
+	//txLock makes transactions run one at a time, so there is only ever one snapshot to roll back to
+	txLock sync.Mutex
+	memoryTables
+}
+
+//memoryTables holds all data, it is what a transaction snapshots and restores
+type memoryTables struct {
+	users       map[uint64]*memoryUser
+	images      map[uint64]*memoryImage
+	tags        map[uint64]*memoryTag
+	collections map[uint64]*memoryCollection
+	//imageTags maps ImageID -> TagID -> LinkerID
+	imageTags map[uint64]map[uint64]uint64
+	//collectionMembers maps CollectionID -> ImageID -> OrderWeight
+	collectionMembers map[uint64]map[uint64]uint64
+	//imageScores maps ImageID -> UserID -> Score
+	imageScores  map[uint64]map[uint64]int64
+	imagedHashes map[uint64]interfaces.ImagedHash
+	auditLogs    []memoryAuditLog
+	//imageRevisions is kept in ID order
+	imageRevisions []interfaces.ImageRevision
+	jobs           map[uint64]*interfaces.Job
+	savedSearches  map[uint64]*interfaces.SavedSearch
+
+	lastUserID        uint64
+	lastImageID       uint64
+	lastTagID         uint64
+	lastCollectionID  uint64
+	lastRevisionID    uint64
+	lastAuditLogID    uint64
+	lastJobID         uint64
+	lastSavedSearchID uint64
+}
+
+type memoryUser struct {
+	ID           uint64
+	Name         string
+	EMail        string
+	PasswordHash string
+	TokenID      sql.NullString
+	IP           sql.NullString
+	SecQuestions [3]sql.NullString
+	SecAnswers   [3]sql.NullString
+	CreationTime time.Time
+	Disabled     bool
+	Permissions  uint64
+	SearchFilter string
+}
+
+type memoryImage struct {
+	ID           uint64
+	UploaderID   uint64
+	Name         string
+	Description  string
+	Rating       string
+	ScoreTotal   int64
+	ScoreAverage int64
+	ScoreVoters  int64
+	Location     string
+	Source       string
+	UploadTime   time.Time
+	//DeletedTime is zero unless the image is in the recycle bin
+	DeletedTime time.Time
+	DeletedBy   uint64
+	Metadata    interfaces.ImageMetadata
+}
+
+type memoryTag struct {
+	ID          uint64
+	Name        string
+	Description string
+	UploaderID  uint64
+	UploadTime  time.Time
+	AliasedID   uint64
+	IsAlias     bool
+}
+
+type memoryCollection struct {
+	ID          uint64
+	Name        string
+	Description string
+	UploaderID  uint64
+	UploadTime  time.Time
+}
+
+type memoryAuditLog struct {
+	ID         uint64
+	UserID     uint64
+	Action     interfaces.AuditAction
+	TargetType interfaces.AuditTargetType
+	TargetID   uint64
+	Details    string
+	LogTime    time.Time
+}
+
+//InitDatabase prepares the in memory tables, calling it again wipes all data
+func (DBConnection *MemoryPlugin) InitDatabase() error {
+	rand.Seed(time.Now().UnixNano())
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	DBConnection.users = make(map[uint64]*memoryUser)
+	DBConnection.images = make(map[uint64]*memoryImage)
+	DBConnection.tags = make(map[uint64]*memoryTag)
+	DBConnection.collections = make(map[uint64]*memoryCollection)
+	DBConnection.imageTags = make(map[uint64]map[uint64]uint64)
+	DBConnection.collectionMembers = make(map[uint64]map[uint64]uint64)
+	DBConnection.imageScores = make(map[uint64]map[uint64]int64)
+	DBConnection.imagedHashes = make(map[uint64]interfaces.ImagedHash)
+	DBConnection.auditLogs = nil
+	DBConnection.imageRevisions = nil
+	DBConnection.jobs = make(map[uint64]*interfaces.Job)
+	DBConnection.savedSearches = make(map[uint64]*interfaces.SavedSearch)
+	DBConnection.lastUserID = 0
+	DBConnection.lastImageID = 0
+	DBConnection.lastTagID = 0
+	DBConnection.lastCollectionID = 0
+	DBConnection.lastRevisionID = 0
+	DBConnection.lastAuditLogID = 0
+	DBConnection.lastJobID = 0
+	DBConnection.lastSavedSearchID = 0
+	//Reserve system for auditing, same as the SQL plugins
+	DBConnection.users[0] = &memoryUser{ID: 0, Name: "SYSTEM", CreationTime: time.Now(), Disabled: true}
+	return nil
+}
+
+//PlanMigrations always reports an empty plan, the memory plugin has no schema and starts empty every run
+func (DBConnection *MemoryPlugin) PlanMigrations(ctx context.Context, CompareSchema bool) (interfaces.MigrationPlan, error) {
+	return interfaces.MigrationPlan{}, nil
+}
+
+//Support Functions
+
+//pageSlice applies LIMIT PageStride OFFSET PageStart to an already sorted slice
+func pageSlice[T any](items []T, PageStart uint64, PageStride uint64) []T {
+	if PageStart >= uint64(len(items)) {
+		return nil
+	}
+	items = items[PageStart:]
+	if PageStride < uint64(len(items)) {
+		items = items[:PageStride]
+	}
+	return items
+}
+
+//likeMatch reports whether value matches a SQL LIKE pattern, case insensitive like the default MariaDB collation
+func likeMatch(pattern string, value string) bool {
+	expression := "(?is)^"
+	escaped := false
+	for _, character := range pattern {
+		switch {
+		case escaped:
+			expression += regexp.QuoteMeta(string(character))
+			escaped = false
+		case character == '\\':
+			escaped = true
+		case character == '%':
+			expression += ".*"
+		case character == '_':
+			expression += "."
+		default:
+			expression += regexp.QuoteMeta(string(character))
+		}
+	}
+	matched, _ := regexp.MatchString(expression+"$", value)
+	return matched
+}
+
+//toInt64 converts the integer types used for meta tag values to an int64
+func toInt64(value interface{}) (int64, bool) {
+	switch typedValue := value.(type) {
+	case int64:
+		return typedValue, true
+	case uint64:
+		return int64(typedValue), true
+	case int:
+		return int64(typedValue), true
+	case string:
+		parsedValue, err := strconv.ParseInt(typedValue, 10, 64)
+		return parsedValue, err == nil
+	}
+	return 0, false
+}
+
+//toFloat64 converts a floating point meta value, so durations can be compared without truncating them
+func toFloat64(value interface{}) (float64, bool) {
+	switch typedValue := value.(type) {
+	case float64:
+		return typedValue, true
+	case float32:
+		return float64(typedValue), true
+	}
+	integerValue, isInteger := toInt64(value)
+	return float64(integerValue), isInteger
+}
+
+//compareMetaValue evaluates "FieldValue Comparator Value" the way the SQL plugins' WHERE clauses would
+func compareMetaValue(FieldValue interface{}, Comparator string, Value interface{}) (bool, error) {
+	if Comparator == "LIKE" || Comparator == "NOT LIKE" {
+		return likeMatch(fmt.Sprintf("%v", Value), fmt.Sprintf("%v", FieldValue)) == (Comparator == "LIKE"), nil
+	}
+	//Compare as numbers when both sides are numbers, otherwise as case insensitive strings
+	var order int
+	fieldNumber, fieldIsNumber := toInt64(FieldValue)
+	valueNumber, valueIsNumber := toInt64(Value)
+	fieldFloat, fieldIsFloat := toFloat64(FieldValue)
+	valueFloat, valueIsFloat := toFloat64(Value)
+	if fieldIsNumber && valueIsNumber {
+		if fieldNumber < valueNumber {
+			order = -1
+		} else if fieldNumber > valueNumber {
+			order = 1
+		}
+	} else if fieldIsFloat && valueIsFloat {
+		if fieldFloat < valueFloat {
+			order = -1
+		} else if fieldFloat > valueFloat {
+			order = 1
+		}
+	} else {
+		order = strings.Compare(strings.ToLower(fmt.Sprintf("%v", FieldValue)), strings.ToLower(fmt.Sprintf("%v", Value)))
+	}
+	switch Comparator {
+	case "=":
+		return order == 0, nil
+	case "!=":
+		return order != 0, nil
+	case ">":
+		return order > 0, nil
+	case "<":
+		return order < 0, nil
+	case ">=":
+		return order >= 0, nil
+	case "<=":
+		return order <= 0, nil
+	}
+	return false, errors.New("Unsupported comparator " + Comparator)
+}
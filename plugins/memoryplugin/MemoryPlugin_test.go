@@ -0,0 +1,26 @@
+package memoryplugin
+
+import (
+	"go-image-board/plugins/dbconformance"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	dbconformance.PrepareLogging()
+	DB := &MemoryPlugin{}
+	if err := DB.InitDatabase(); err != nil {
+		t.Fatalf("InitDatabase failed: %v", err)
+	}
+	dbconformance.RunSuite(t, DB)
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	dbconformance.PrepareLogging()
+	Source, Fresh := &MemoryPlugin{}, &MemoryPlugin{}
+	for _, DB := range []*MemoryPlugin{Source, Fresh} {
+		if err := DB.InitDatabase(); err != nil {
+			t.Fatalf("InitDatabase failed: %v", err)
+		}
+	}
+	dbconformance.RunBackupRoundTrip(t, Source, Fresh)
+}
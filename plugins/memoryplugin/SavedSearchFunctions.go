@@ -0,0 +1,50 @@
+package memoryplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"sort"
+)
+
+//GetSavedSearches returns a user's saved searches, sorted by name
+func (DBConnection *MemoryPlugin) GetSavedSearches(ctx context.Context, UserID uint64) ([]interfaces.SavedSearch, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.SavedSearch
+	for _, Search := range DBConnection.savedSearches {
+		if Search.UserID == UserID {
+			ToReturn = append(ToReturn, *Search)
+		}
+	}
+	sort.Slice(ToReturn, func(i, j int) bool { return ToReturn[i].Name < ToReturn[j].Name })
+	return ToReturn, nil
+}
+
+//SetSavedSearch saves Query for a user under Name, replacing the query of their saved search by that name if they have one
+func (DBConnection *MemoryPlugin) SetSavedSearch(ctx context.Context, UserID uint64, Name string, Query string) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	for _, Search := range DBConnection.savedSearches {
+		if Search.UserID == UserID && Search.Name == Name {
+			Search.Query = Query
+			return nil
+		}
+	}
+	DBConnection.lastSavedSearchID++
+	DBConnection.savedSearches[DBConnection.lastSavedSearchID] = &interfaces.SavedSearch{ID: DBConnection.lastSavedSearchID, UserID: UserID, Name: Name, Query: Query}
+	return nil
+}
+
+//DeleteSavedSearch removes a user's saved search, returns sql.ErrNoRows if they have none by that name
+func (DBConnection *MemoryPlugin) DeleteSavedSearch(ctx context.Context, UserID uint64, Name string) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	for ID, Search := range DBConnection.savedSearches {
+		if Search.UserID == UserID && Search.Name == Name {
+			delete(DBConnection.savedSearches, ID)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
@@ -0,0 +1,59 @@
+package memoryplugin
+
+import (
+	"context"
+	"go-image-board/logging"
+	"math"
+	"strconv"
+)
+
+//Score operations
+
+//UpdateUserVoteScore Either creates or changes a user's vote on an image
+func (DBConnection *MemoryPlugin) UpdateUserVoteScore(ctx context.Context, UserID uint64, ImageID uint64, Score int64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if DBConnection.imageScores[ImageID] == nil {
+		DBConnection.imageScores[ImageID] = make(map[uint64]int64)
+	}
+	DBConnection.imageScores[ImageID][UserID] = Score
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/UpdateUserVoteScore", strconv.FormatUint(UserID, 10), logging.ResultSuccess, []string{"Score added/updated"})
+	//The SQL plugins do this in the background, here it is cheap enough to do right away
+	DBConnection.updateScoreOnImage(ImageID)
+	return nil
+}
+
+//UpdateScoreOnImage update ScoreTotal, ScoreAverage, and ScoreVoters on an image
+func (DBConnection *MemoryPlugin) UpdateScoreOnImage(ctx context.Context, ImageID uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	DBConnection.updateScoreOnImage(ImageID)
+	return nil
+}
+
+//updateScoreOnImage is UpdateScoreOnImage for callers that already hold the lock
+func (DBConnection *MemoryPlugin) updateScoreOnImage(ImageID uint64) {
+	image, exists := DBConnection.images[ImageID]
+	if exists == false {
+		return
+	}
+	var sum int64
+	for _, score := range DBConnection.imageScores[ImageID] {
+		sum += score
+	}
+	count := int64(len(DBConnection.imageScores[ImageID]))
+	image.ScoreTotal = sum
+	image.ScoreVoters = count
+	image.ScoreAverage = 0
+	if count > 0 {
+		//Storing AVG() into a BIGINT rounds, so match that
+		image.ScoreAverage = int64(math.Round(float64(sum) / float64(count)))
+	}
+}
+
+//GetUserVoteScore Returns a user's vote on an image
+func (DBConnection *MemoryPlugin) GetUserVoteScore(ctx context.Context, UserID uint64, ImageID uint64) (int64, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	return DBConnection.imageScores[ImageID][UserID], nil
+}
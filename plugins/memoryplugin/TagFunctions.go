@@ -0,0 +1,241 @@
+package memoryplugin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//NewTag adds a tag with the provided information
+func (DBConnection *MemoryPlugin) NewTag(ctx context.Context, Name string, Description string, UploaderID uint64) (uint64, error) {
+	//Cleanup name
+	Name = interfaces.PrepareTagName(Name)
+
+	if len(Name) < 3 || len(Name) > 255 || len(Description) > 255 {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/NewTag", strconv.FormatUint(UploaderID, 10), logging.ResultFailure, []string{"Failed to add tag dues to size of name/description", Name, Description})
+		return 0, errors.New("name or description outside of right sizes")
+	}
+
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if DBConnection.getTagByName(Name) != nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/NewTag", strconv.FormatUint(UploaderID, 10), logging.ResultFailure, []string{"Failed to add tag", "duplicate name"})
+		return 0, errors.New("a tag with this name already exists")
+	}
+	DBConnection.lastTagID++
+	DBConnection.tags[DBConnection.lastTagID] = &memoryTag{ID: DBConnection.lastTagID, Name: Name, Description: Description, UploaderID: UploaderID, UploadTime: time.Now()}
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/NewTag", strconv.FormatUint(UploaderID, 10), logging.ResultSuccess, []string{"Tag added", strconv.FormatUint(DBConnection.lastTagID, 10)})
+	return DBConnection.lastTagID, nil
+}
+
+//DeleteTag removes a tag
+func (DBConnection *MemoryPlugin) DeleteTag(ctx context.Context, TagID uint64) error {
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	//Ensure not in use
+	if useCount := DBConnection.getTagUseCount(TagID); useCount > 0 {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/DeleteTag", "0", logging.ResultFailure, []string{"Tag to delete is still in use", strconv.FormatUint(TagID, 10), "in use", strconv.FormatUint(useCount, 10)})
+		return errors.New("tag to delete is still in use")
+	}
+	delete(DBConnection.tags, TagID)
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/DeleteTag", "0", logging.ResultSuccess, []string{"Tag deleted", strconv.FormatUint(TagID, 10)})
+	return nil
+}
+
+//AddTag adds an association of a tag to image into the association table
+func (DBConnection *MemoryPlugin) AddTag(ctx context.Context, TagIDs []uint64, ImageID uint64, LinkerID uint64) error {
+	if len(TagIDs) == 0 {
+		return errors.New("No tags provided")
+	}
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	//Validate tags, if some are alias, add alias instead, if a tag does not exist, error out
+	var validatedTagIDs []uint64
+	for i := 0; i < len(TagIDs); i++ {
+		TagID := TagIDs[i]
+		tagInfo, exists := DBConnection.tags[TagID]
+		if exists == false {
+			return errors.New("Failed to validate tag " + strconv.FormatUint(TagID, 10))
+		}
+		//If this is an alias, then add aliasedid instead
+		if tagInfo.IsAlias {
+			validatedTagIDs = append(validatedTagIDs, tagInfo.AliasedID)
+		} else {
+			validatedTagIDs = append(validatedTagIDs, TagID)
+		}
+	}
+	if _, exists := DBConnection.images[ImageID]; exists == false {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/AddTag", strconv.FormatUint(LinkerID, 10), logging.ResultFailure, []string{"Tags not added to image", strconv.FormatUint(ImageID, 10), "image does not exist"})
+		return errors.New("image does not exist")
+	}
+	for _, TagID := range validatedTagIDs {
+		DBConnection.linkImageTag(TagID, ImageID, LinkerID)
+	}
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/AddTag", strconv.FormatUint(LinkerID, 10), logging.ResultSuccess, []string{"Tags added", strconv.FormatUint(ImageID, 10)})
+	return nil
+}
+
+//GetAllTags returns a list of all tags, but only the ID, Name, Description, and IsAlias
+func (DBConnection *MemoryPlugin) GetAllTags(ctx context.Context) ([]interfaces.TagInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.TagInformation
+	for _, tag := range DBConnection.getSortedTags() {
+		ToReturn = append(ToReturn, interfaces.TagInformation{Name: tag.Name, ID: tag.ID, Description: tag.Description, Exists: true, Exclude: false, IsAlias: tag.IsAlias})
+	}
+	return ToReturn, nil
+}
+
+//GetTag returns detailed information on one tag
+func (DBConnection *MemoryPlugin) GetTag(ctx context.Context, ID uint64, IncludeCount bool) (interfaces.TagInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	tag, exists := DBConnection.tags[ID]
+	if exists == false {
+		return interfaces.TagInformation{ID: ID, Exists: false}, sql.ErrNoRows
+	}
+	ToReturn := getTagInformation(tag, false)
+	if IncludeCount {
+		ToReturn.UseCount = DBConnection.getTagUseCount(ID)
+	}
+	return ToReturn, nil
+}
+
+//GetTagByName returns detailed information on one tag as queried by name
+func (DBConnection *MemoryPlugin) GetTagByName(ctx context.Context, Name string) (interfaces.TagInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	tag := DBConnection.getTagByName(Name)
+	if tag == nil {
+		return interfaces.TagInformation{Name: Name, Exists: false}, sql.ErrNoRows
+	}
+	return getTagInformation(tag, false), nil
+}
+
+//UpdateTag updates a pre-existing tag
+func (DBConnection *MemoryPlugin) UpdateTag(ctx context.Context, TagID uint64, Name string, Description string, AliasedID uint64, IsAlias bool, RequestorID uint64) error {
+	//Cleanup name
+	Name = interfaces.PrepareTagName(Name)
+	if len(Name) < 3 || len(Name) > 255 || len(Description) > 255 {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/UpdateTag", strconv.FormatUint(RequestorID, 10), logging.ResultFailure, []string{"Failed to update tag dues to size", Name, Description})
+		return errors.New("name or description outside of right sizes")
+	}
+
+	DBConnection.lock.Lock()
+	defer DBConnection.lock.Unlock()
+	if IsAlias {
+		//Prevent adding alias
+		tagInfo, exists := DBConnection.tags[AliasedID]
+		if exists == false || tagInfo.IsAlias {
+			return errors.New("Tag to alias could not be found, or is an alias itself")
+		}
+	}
+
+	if existing := DBConnection.getTagByName(Name); existing != nil && existing.ID != TagID {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/UpdateTag", strconv.FormatUint(RequestorID, 10), logging.ResultFailure, []string{"Failed to update tag", "duplicate name"})
+		return errors.New("a tag with this name already exists")
+	}
+	if tag, exists := DBConnection.tags[TagID]; exists {
+		tag.Name = Name
+		tag.Description = Description
+		tag.AliasedID = AliasedID
+		tag.IsAlias = IsAlias
+	}
+	logging.WriteLog(logging.LogLevelError, "MemoryPlugin/UpdateTag", strconv.FormatUint(RequestorID, 10), logging.ResultSuccess, []string{"Image added"})
+
+	//The SQL plugins do this in the background, here it is cheap enough to do right away
+	if IsAlias {
+		DBConnection.replaceImageTags(TagID, AliasedID, RequestorID)
+	}
+
+	return nil
+}
+
+//SearchTags returns a list of tags like the provided name, but only the ID, Name, Description, and IsAlias
+func (DBConnection *MemoryPlugin) SearchTags(ctx context.Context, name string, PageStart uint64, PageStride uint64, WildcardForwardOnly bool, SortByUsage bool) ([]interfaces.TagInformation, uint64, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	var ToReturn []interfaces.TagInformation
+
+	//Cleanup Query and alter if we were provided a name
+	name = strings.TrimSpace(name)
+	name = strings.Replace(name, "%", "", -1)
+	if name != "" {
+		if WildcardForwardOnly {
+			name = name + "%"
+		} else {
+			name = "%" + name + "%"
+		}
+	}
+
+	var matches []*memoryTag
+	for _, tag := range DBConnection.getSortedTags() {
+		if name == "" || likeMatch(name, tag.Name) {
+			matches = append(matches, tag)
+		}
+	}
+	//Count query does not care about usage
+	MaxResults := uint64(len(matches))
+
+	//Add the sorting
+	if SortByUsage {
+		//Sorting by usage joins against ImageTags, so unused tags drop out of the results
+		var usedMatches []*memoryTag
+		for _, tag := range matches {
+			if DBConnection.getTagUseCount(tag.ID) > 0 {
+				usedMatches = append(usedMatches, tag)
+			}
+		}
+		matches = usedMatches
+		sort.SliceStable(matches, func(i, j int) bool {
+			return DBConnection.getTagUseCount(matches[i].ID) > DBConnection.getTagUseCount(matches[j].ID)
+		})
+	}
+
+	for _, tag := range pageSlice(matches, PageStart, PageStride) {
+		ToReturn = append(ToReturn, interfaces.TagInformation{Name: tag.Name, ID: tag.ID, Description: tag.Description, Exists: true, Exclude: false, IsAlias: tag.IsAlias})
+	}
+	return ToReturn, MaxResults, nil
+}
+
+//getTagByName returns the tag with the given name or nil. Lock must be held
+func (DBConnection *MemoryPlugin) getTagByName(Name string) *memoryTag {
+	for _, tag := range DBConnection.tags {
+		if strings.EqualFold(tag.Name, Name) {
+			return tag
+		}
+	}
+	return nil
+}
+
+//getTagUseCount returns how many images use a tag. Lock must be held
+func (DBConnection *MemoryPlugin) getTagUseCount(TagID uint64) uint64 {
+	var useCount uint64
+	for _, imageTags := range DBConnection.imageTags {
+		if _, hasTag := imageTags[TagID]; hasTag {
+			useCount++
+		}
+	}
+	return useCount
+}
+
+//getSortedTags returns all tags ordered by name. Lock must be held
+func (DBConnection *MemoryPlugin) getSortedTags() []*memoryTag {
+	var ToReturn []*memoryTag
+	for _, tag := range DBConnection.tags {
+		ToReturn = append(ToReturn, tag)
+	}
+	sort.Slice(ToReturn, func(i, j int) bool { return ToReturn[i].Name < ToReturn[j].Name })
+	return ToReturn
+}
+
+//getTagInformation converts a stored tag to a TagInformation
+func getTagInformation(tag *memoryTag, Exclude bool) interfaces.TagInformation {
+	return interfaces.TagInformation{Name: tag.Name, ID: tag.ID, Description: tag.Description, Exists: true, Exclude: Exclude, UploaderID: tag.UploaderID, UploadTime: tag.UploadTime, AliasedID: tag.AliasedID, IsAlias: tag.IsAlias}
+}
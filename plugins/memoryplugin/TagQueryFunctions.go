@@ -0,0 +1,96 @@
+package memoryplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"strconv"
+	"strings"
+)
+
+//GetUserFilterTags returns a slice of tags based on a user's custom filter
+func (DBConnection *MemoryPlugin) GetUserFilterTags(ctx context.Context, UserID uint64, CollectionContext bool) ([]interfaces.TagInformation, error) {
+	var userFilter string
+	var err error
+	DBConnection.lock.RLock()
+	user, exists := DBConnection.users[UserID]
+	if exists {
+		userFilter = user.SearchFilter
+	} else {
+		err = sql.ErrNoRows
+	}
+	DBConnection.lock.RUnlock()
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/GetUserQueryTags", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to get user filter", err.Error()})
+		return nil, err
+	}
+	tags, err := DBConnection.GetQueryTags(ctx, userFilter, CollectionContext)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "MemoryPlugin/GetUserQueryTags", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to get tags from user filter", err.Error()})
+		return nil, err
+	}
+	//Loop through the tags and ensure we have them set as FromUserFilter
+	for i := 0; i < len(tags); i++ {
+		tags[i].FromUserFilter = true
+	}
+	return tags, nil
+}
+
+//GetQueryTags returns a slice of tags based on a query string, CollectionContext should be true if these tags are being parsed for a collection
+func (DBConnection *MemoryPlugin) GetQueryTags(ctx context.Context, UserQuery string, CollectionContext bool) ([]interfaces.TagInformation, error) {
+	DBConnection.lock.RLock()
+	defer DBConnection.lock.RUnlock()
+	return interfaces.GetQueryTags(ctx, lockedQueryTagSource{DBConnection}, UserQuery, CollectionContext)
+}
+
+//lockedQueryTagSource looks up the tags of a query while the caller holds the lock
+type lockedQueryTagSource struct {
+	DB *MemoryPlugin
+}
+
+//GetTagsByName returns the tags with the given names, names without a tag are left out. Lock must be held
+func (Source lockedQueryTagSource) GetTagsByName(ctx context.Context, Names []string) ([]interfaces.TagInformation, error) {
+	var ToReturn []interfaces.TagInformation
+	for _, Name := range Names {
+		if tag := Source.DB.getTagByName(Name); tag != nil {
+			ToReturn = append(ToReturn, getTagInformation(tag, false))
+		}
+	}
+	return ToReturn, nil
+}
+
+//GetTagsByID returns the tags with the given IDs, IDs without a tag are left out. Lock must be held
+func (Source lockedQueryTagSource) GetTagsByID(ctx context.Context, IDs []uint64) ([]interfaces.TagInformation, error) {
+	var ToReturn []interfaces.TagInformation
+	for _, ID := range IDs {
+		if tag, exists := Source.DB.tags[ID]; exists {
+			ToReturn = append(ToReturn, getTagInformation(tag, false))
+		}
+	}
+	return ToReturn, nil
+}
+
+//GetTagNamesMatching returns the names of up to Limit tags that match a wildcard pattern. Lock must be held
+func (Source lockedQueryTagSource) GetTagNamesMatching(ctx context.Context, Pattern string, Limit int) ([]string, error) {
+	var Names []string
+	for _, tag := range Source.DB.tags {
+		if len(Names) >= Limit {
+			break
+		}
+		if interfaces.WildcardMatch(Pattern, strings.ToLower(tag.Name)) {
+			Names = append(Names, tag.Name)
+		}
+	}
+	return Names, nil
+}
+
+//GetUserID returns the ID of a user. Lock must be held
+func (Source lockedQueryTagSource) GetUserID(ctx context.Context, userName string) (uint64, error) {
+	return Source.DB.getUserID(userName)
+}
+
+//GetImagedHash returns the perceptual hashes of an image. Lock must be held
+func (Source lockedQueryTagSource) GetImagedHash(ctx context.Context, ID uint64) (uint64, uint64, error) {
+	return Source.DB.getImagedHash(ID)
+}
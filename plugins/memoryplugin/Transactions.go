@@ -0,0 +1,80 @@
+package memoryplugin
+
+import (
+	"context"
+	"go-image-board/interfaces"
+	"maps"
+	"slices"
+)
+
+//memoryTransaction is what RunInTransaction hands to Work, so that nested calls join the running transaction instead of waiting on txLock
+type memoryTransaction struct {
+	*MemoryPlugin
+}
+
+//RunInTransaction snapshots all tables, calls Work and restores the snapshot if Work fails or ctx is cancelled.
+//Transactions are serialized with each other, but other writes are not isolated from one and are lost if it rolls back
+func (DBConnection *MemoryPlugin) RunInTransaction(ctx context.Context, Work func(Tx interfaces.DBInterface) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	DBConnection.txLock.Lock()
+	defer DBConnection.txLock.Unlock()
+	DBConnection.lock.RLock()
+	snapshot := DBConnection.memoryTables.clone()
+	DBConnection.lock.RUnlock()
+
+	err := Work(&memoryTransaction{MemoryPlugin: DBConnection})
+	if err == nil {
+		//Same as a SQL transaction, a cancelled context fails the commit
+		err = ctx.Err()
+	}
+	if err != nil {
+		DBConnection.lock.Lock()
+		DBConnection.memoryTables = snapshot
+		DBConnection.lock.Unlock()
+	}
+	return err
+}
+
+//RunInTransaction joins the transaction that is already running
+func (DBConnection *memoryTransaction) RunInTransaction(ctx context.Context, Work func(Tx interfaces.DBInterface) error) error {
+	return Work(DBConnection)
+}
+
+//clone returns a copy of the tables that shares nothing with the original
+func (tables *memoryTables) clone() memoryTables {
+	copied := *tables
+	copied.users = clonePointerMap(tables.users)
+	copied.images = clonePointerMap(tables.images)
+	copied.tags = clonePointerMap(tables.tags)
+	copied.collections = clonePointerMap(tables.collections)
+	copied.imageTags = cloneNestedMap(tables.imageTags)
+	copied.collectionMembers = cloneNestedMap(tables.collectionMembers)
+	copied.imageScores = cloneNestedMap(tables.imageScores)
+	copied.imagedHashes = maps.Clone(tables.imagedHashes)
+	copied.auditLogs = slices.Clone(tables.auditLogs)
+	copied.imageRevisions = slices.Clone(tables.imageRevisions)
+	copied.jobs = clonePointerMap(tables.jobs)
+	copied.savedSearches = clonePointerMap(tables.savedSearches)
+	return copied
+}
+
+//clonePointerMap copies a table of rows, including the rows themselves
+func clonePointerMap[T any](source map[uint64]*T) map[uint64]*T {
+	copied := make(map[uint64]*T, len(source))
+	for key, value := range source {
+		row := *value
+		copied[key] = &row
+	}
+	return copied
+}
+
+//cloneNestedMap copies a link table such as imageTags
+func cloneNestedMap[T any](source map[uint64]map[uint64]T) map[uint64]map[uint64]T {
+	copied := make(map[uint64]map[uint64]T, len(source))
+	for key, value := range source {
+		copied[key] = maps.Clone(value)
+	}
+	return copied
+}
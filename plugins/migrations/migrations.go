@@ -0,0 +1,178 @@
+//Package migrations runs the numbered schema migrations that the SQL plugins register.
+//A fresh install is the same as upgrading from nothing, so each plugin's schema is only ever written down once, as its list of migrations.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//NotInstalled is the version of a database that has no schema yet
+const NotInstalled int64 = -1
+
+//Migration is one numbered change to a plugin's schema
+type Migration struct {
+	//Version is the schema version once this migration has run
+	Version     int64
+	Description string
+	Statements  []string
+	//NoTransaction is set on migrations that cannot be rolled back, such as MariaDB DDL which commits implicitly
+	NoTransaction bool
+}
+
+//Set is a plugin's ordered list of migrations
+type Set struct {
+	migrations []Migration
+}
+
+//NewSet returns a Set of the given migrations, which must have consecutive versions in ascending order.
+//Migrations are declared in package variables, so a mistake panics at startup rather than being discovered on an upgrade
+func NewSet(Migrations ...Migration) *Set {
+	for Index, Migration := range Migrations {
+		if Index > 0 && Migration.Version != Migrations[Index-1].Version+1 {
+			panic("migrations: version " + strconv.FormatInt(Migration.Version, 10) + " does not follow version " + strconv.FormatInt(Migrations[Index-1].Version, 10))
+		}
+		if len(Migration.Statements) == 0 {
+			panic("migrations: version " + strconv.FormatInt(Migration.Version, 10) + " has no statements")
+		}
+	}
+	return &Set{migrations: Migrations}
+}
+
+//Latest returns the version the last migration brings the schema to
+func (set *Set) Latest() int64 {
+	if len(set.migrations) == 0 {
+		return NotInstalled
+	}
+	return set.migrations[len(set.migrations)-1].Version
+}
+
+//Pending returns the migrations that bring a database at Version up to date
+func (set *Set) Pending(Version int64) []Migration {
+	Index := sort.Search(len(set.migrations), func(i int) bool { return set.migrations[i].Version > Version })
+	return set.migrations[Index:]
+}
+
+//Plan describes what Apply would do to a database at Version
+func (set *Set) Plan(Version int64) interfaces.MigrationPlan {
+	Plan := interfaces.MigrationPlan{CurrentVersion: Version, TargetVersion: set.Latest()}
+	for _, Migration := range set.Pending(Version) {
+		Plan.Pending = append(Plan.Pending, interfaces.PendingMigration{
+			Version:       Migration.Version,
+			Description:   Migration.Description,
+			Transactional: Migration.NoTransaction == false,
+			Statements:    Migration.Statements,
+		})
+	}
+	return Plan
+}
+
+//Apply runs the migrations that bring a database at Version up to date, or up to UpTo if it is not NotInstalled, and returns the version reached.
+//Each migration is recorded in DBVersion as it completes, so a failed upgrade can be resumed from where it stopped.
+//Statements are passed to the driver as is, they must not contain placeholders
+func (set *Set) Apply(ctx context.Context, DB *sql.DB, Version int64, UpTo int64, Source string) (int64, error) {
+	for _, Migration := range set.Pending(Version) {
+		if UpTo != NotInstalled && Migration.Version > UpTo {
+			break
+		}
+		if err := apply(ctx, DB, Migration); err != nil {
+			logging.WriteLog(logging.LogLevelError, Source, "0", logging.ResultFailure, []string{"Failed to migrate database to version " + strconv.FormatInt(Migration.Version, 10), Migration.Description, err.Error()})
+			return Version, err
+		}
+		Version = Migration.Version
+		logging.WriteLog(logging.LogLevelError, Source, "0", logging.ResultInfo, []string{"Database schema updated to version", strconv.FormatInt(Version, 10), Migration.Description})
+	}
+	return Version, nil
+}
+
+//apply runs a single migration and records its version
+func apply(ctx context.Context, DB *sql.DB, Migration Migration) error {
+	//The first migration creates DBVersion and inserts its row, every later one updates it
+	Statements := append(Migration.Statements[:len(Migration.Statements):len(Migration.Statements)], "UPDATE DBVersion SET version = "+strconv.FormatInt(Migration.Version, 10)+";")
+	if Migration.NoTransaction {
+		for _, Statement := range Statements {
+			if _, err := DB.ExecContext(ctx, Statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, Statement := range Statements {
+		if _, err := tx.ExecContext(ctx, Statement); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//Diff compares two schema descriptions, one line per column, index, constraint or trigger, and lists the lines only one of them has
+func Diff(Database []string, FreshInstall []string) []string {
+	inFresh := make(map[string]bool, len(FreshInstall))
+	for _, Line := range FreshInstall {
+		inFresh[Line] = true
+	}
+	inDatabase := make(map[string]bool, len(Database))
+	var Differences []string
+	for _, Line := range Database {
+		inDatabase[Line] = true
+		if inFresh[Line] == false {
+			Differences = append(Differences, "only in database: "+Line)
+		}
+	}
+	for _, Line := range FreshInstall {
+		if inDatabase[Line] == false {
+			Differences = append(Differences, "only in fresh install: "+Line)
+		}
+	}
+	sort.Strings(Differences)
+	return Differences
+}
+
+//NormalizeSQL collapses whitespace, so that routine and trigger bodies created from differently indented source compare equal
+func NormalizeSQL(Body string) string {
+	return strings.Join(strings.Fields(Body), " ")
+}
+
+//DescribeRows runs a query whose columns are all strings and joins each row into one description line, prefixed by Kind
+func DescribeRows(ctx context.Context, DB *sql.DB, Kind string, Query string, Args ...interface{}) ([]string, error) {
+	rows, err := DB.QueryContext(ctx, Query, Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var Lines []string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for Index := range values {
+			pointers[Index] = &values[Index]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		Line := Kind
+		for Index, value := range values {
+			text := "NULL"
+			if value.Valid {
+				text = NormalizeSQL(value.String)
+			}
+			Line += " " + columns[Index] + "=" + text
+		}
+		Lines = append(Lines, Line)
+	}
+	return Lines, rows.Err()
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewSetRejectsGaps(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewSet accepted versions 1 and 3")
+		}
+	}()
+	NewSet(Migration{Version: 1, Statements: []string{"SELECT 1;"}}, Migration{Version: 3, Statements: []string{"SELECT 1;"}})
+}
+
+func TestNewSetRejectsEmptyMigrations(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewSet accepted a migration without statements")
+		}
+	}()
+	NewSet(Migration{Version: 1})
+}
+
+func TestPending(t *testing.T) {
+	set := NewSet(
+		Migration{Version: 1, Statements: []string{"SELECT 1;"}},
+		Migration{Version: 2, Statements: []string{"SELECT 2;"}},
+		Migration{Version: 3, Statements: []string{"SELECT 3;"}, NoTransaction: true},
+	)
+	if Latest := set.Latest(); Latest != 3 {
+		t.Errorf("Latest = %d, want 3", Latest)
+	}
+	for Version, Want := range map[int64]int{NotInstalled: 3, 1: 2, 3: 0, 4: 0} {
+		if Got := len(set.Pending(Version)); Got != Want {
+			t.Errorf("Pending(%d) returned %d migrations, want %d", Version, Got, Want)
+		}
+	}
+	Plan := set.Plan(1)
+	if Plan.CurrentVersion != 1 || Plan.TargetVersion != 3 || len(Plan.Pending) != 2 || Plan.Pending[0].Version != 2 || Plan.Pending[0].Transactional == false || Plan.Pending[1].Transactional {
+		t.Errorf("Plan(1) = %+v", Plan)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	Got := Diff([]string{"column a", "column b", "index c"}, []string{"column  b", "index c", "column a"})
+	Want := []string{"only in database: column b", "only in fresh install: column  b"}
+	if slices.Equal(Got, Want) == false {
+		t.Errorf("Diff = %q, want %q", Got, Want)
+	}
+	if Got := Diff([]string{"a", "b"}, []string{"b", "a"}); len(Got) != 0 {
+		t.Errorf("Diff of the same lines = %q, want none", Got)
+	}
+	if Got := NormalizeSQL("BEGIN\n\tDELETE  FROM x;\nEND"); Got != "BEGIN DELETE FROM x; END" {
+		t.Errorf("NormalizeSQL = %q", Got)
+	}
+}
@@ -0,0 +1,250 @@
+package postgresplugin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"time"
+)
+
+//ExportBackup reads every user, saved search, tag, image, image revision, collection, vote and audit log from one consistent snapshot of the database
+func (DBConnection *PostgresPlugin) ExportBackup(ctx context.Context) (interfaces.BackupData, error) {
+	if DBConnection.inTransaction() {
+		return DBConnection.exportBackup(ctx)
+	}
+	//Postgres defaults to read committed, where each query sees a new snapshot, so ask for repeatable read
+	tx, err := DBConnection.DBHandle.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/ExportBackup", "0", logging.ResultFailure, []string{"Failed to begin transaction", err.Error()})
+		return interfaces.BackupData{}, err
+	}
+	defer tx.Rollback()
+	Data, err := (&PostgresPlugin{DBHandle: &PostgresHandle{DB: DBConnection.DBHandle.DB, Tx: tx}}).exportBackup(ctx)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/ExportBackup", "0", logging.ResultFailure, []string{"Failed to export backup", err.Error()})
+		return interfaces.BackupData{}, err
+	}
+	return Data, nil
+}
+
+func (DBConnection *PostgresPlugin) exportBackup(ctx context.Context) (interfaces.BackupData, error) {
+	var Data interfaces.BackupData
+	err := DBConnection.exportRows(ctx, "SELECT ID, Name, EMail, PasswordHash, COALESCE(SecQuestionOne, ''), COALESCE(SecQuestionTwo, ''), COALESCE(SecQuestionThree, ''), COALESCE(SecAnswerOne, ''), COALESCE(SecAnswerTwo, ''), COALESCE(SecAnswerThree, ''), CreationTime, Disabled, Permissions, SearchFilter FROM Users WHERE ID <> 0 ORDER BY ID;", func(rows *sql.Rows) error {
+		var User interfaces.BackupUser
+		err := rows.Scan(&User.ID, &User.Name, &User.EMail, &User.PasswordHash, &User.SecQuestions[0], &User.SecQuestions[1], &User.SecQuestions[2], &User.SecAnswers[0], &User.SecAnswers[1], &User.SecAnswers[2], &User.CreationTime, &User.Disabled, &User.Permissions, &User.SearchFilter)
+		Data.Users = append(Data.Users, User)
+		return err
+	})
+	if err == nil {
+		err = DBConnection.exportRows(ctx, "SELECT ID, UserID, Name, Query FROM SavedSearches ORDER BY ID;", func(rows *sql.Rows) error {
+			var Search interfaces.BackupSavedSearch
+			err := rows.Scan(&Search.ID, &Search.UserID, &Search.Name, &Search.Query)
+			Data.SavedSearches = append(Data.SavedSearches, Search)
+			return err
+		})
+	}
+	if err == nil {
+		err = DBConnection.exportRows(ctx, "SELECT ID, Name, COALESCE(Description, ''), UploaderID, UploadTime, AliasedID, IsAlias FROM Tags ORDER BY ID;", func(rows *sql.Rows) error {
+			var Tag interfaces.BackupTag
+			err := rows.Scan(&Tag.ID, &Tag.Name, &Tag.Description, &Tag.UploaderID, &Tag.UploadTime, &Tag.AliasedID, &Tag.IsAlias)
+			Data.Tags = append(Data.Tags, Tag)
+			return err
+		})
+	}
+	if err == nil {
+		err = DBConnection.exportRows(ctx, "SELECT ID, UploaderID, Name, Description, COALESCE(Rating, ''), ScoreTotal, ScoreAverage, ScoreVoters, Location, Source, UploadTime, DeletedTime, DeletedBy, Width, Height, FileSize, MIMEType, Duration, Codec FROM Images ORDER BY ID;", func(rows *sql.Rows) error {
+			var Image interfaces.BackupImage
+			var DeletedTime sql.NullTime
+			err := rows.Scan(&Image.ID, &Image.UploaderID, &Image.Name, &Image.Description, &Image.Rating, &Image.ScoreTotal, &Image.ScoreAverage, &Image.ScoreVoters, &Image.Location, &Image.Source, &Image.UploadTime, &DeletedTime, &Image.DeletedBy, &Image.Width, &Image.Height, &Image.FileSize, &Image.MIMEType, &Image.Duration, &Image.Codec)
+			Image.DeletedTime = DeletedTime.Time
+			Data.Images = append(Data.Images, Image)
+			return err
+		})
+	}
+	if err == nil {
+		err = DBConnection.exportRows(ctx, "SELECT ImageID, hHash, vHash FROM ImagedHashes ORDER BY ImageID;", func(rows *sql.Rows) error {
+			var ImageID uint64
+			var hHash, vHash int64
+			err := rows.Scan(&ImageID, &hHash, &vHash)
+			//Postgres has no unsigned integers, so hashes are stored with their bits reinterpreted as int64
+			Data.ImagedHashes = append(Data.ImagedHashes, interfaces.BackupImagedHash{ImageID: ImageID, HHash: uint64(hHash), VHash: uint64(vHash)})
+			return err
+		})
+	}
+	if err == nil {
+		err = DBConnection.exportRows(ctx, "SELECT ImageID, TagID, LinkerID, LinkTime FROM ImageTags ORDER BY ID;", func(rows *sql.Rows) error {
+			var Link interfaces.BackupImageTag
+			err := rows.Scan(&Link.ImageID, &Link.TagID, &Link.LinkerID, &Link.LinkTime)
+			Data.ImageTags = append(Data.ImageTags, Link)
+			return err
+		})
+	}
+	if err == nil {
+		err = DBConnection.exportRows(ctx, "SELECT UserID, ImageID, Score, CreationTime FROM ImageUserScores ORDER BY ID;", func(rows *sql.Rows) error {
+			var Vote interfaces.BackupVote
+			err := rows.Scan(&Vote.UserID, &Vote.ImageID, &Vote.Score, &Vote.CreationTime)
+			Data.Votes = append(Data.Votes, Vote)
+			return err
+		})
+	}
+	if err == nil {
+		err = DBConnection.exportRows(ctx, "SELECT ID, Name, COALESCE(Description, ''), UploaderID, UploadTime FROM Collections ORDER BY ID;", func(rows *sql.Rows) error {
+			var Collection interfaces.BackupCollection
+			err := rows.Scan(&Collection.ID, &Collection.Name, &Collection.Description, &Collection.UploaderID, &Collection.UploadTime)
+			Data.Collections = append(Data.Collections, Collection)
+			return err
+		})
+	}
+	if err == nil {
+		err = DBConnection.exportRows(ctx, "SELECT CollectionID, ImageID, LinkerID, LinkTime, OrderWeight FROM CollectionMembers ORDER BY ID;", func(rows *sql.Rows) error {
+			var Member interfaces.BackupCollectionMember
+			err := rows.Scan(&Member.CollectionID, &Member.ImageID, &Member.LinkerID, &Member.LinkTime, &Member.OrderWeight)
+			Data.CollectionMembers = append(Data.CollectionMembers, Member)
+			return err
+		})
+	}
+	if err == nil {
+		err = DBConnection.exportRows(ctx, "SELECT UserID, COALESCE(Action, ''), TargetType, TargetID, Details, LogTime FROM AuditLogs ORDER BY ID;", func(rows *sql.Rows) error {
+			var Log interfaces.BackupAuditLog
+			var Details string
+			err := rows.Scan(&Log.UserID, &Log.Action, &Log.TargetType, &Log.TargetID, &Details, &Log.LogTime)
+			Log.Details = json.RawMessage(Details)
+			Data.AuditLogs = append(Data.AuditLogs, Log)
+			return err
+		})
+	}
+	if err == nil {
+		err = DBConnection.exportRows(ctx, "SELECT ID, ImageID, UserID, ChangeTime, Field, TagID, CollectionID, OldValue, NewValue FROM ImageRevisions ORDER BY ID;", func(rows *sql.Rows) error {
+			var Revision interfaces.BackupImageRevision
+			err := rows.Scan(&Revision.ID, &Revision.ImageID, &Revision.UserID, &Revision.ChangeTime, &Revision.Field, &Revision.TagID, &Revision.CollectionID, &Revision.OldValue, &Revision.NewValue)
+			Data.ImageRevisions = append(Data.ImageRevisions, Revision)
+			return err
+		})
+	}
+	return Data, err
+}
+
+//exportRows runs Query and calls Scan for each row
+func (DBConnection *PostgresPlugin) exportRows(ctx context.Context, Query string, Scan func(rows *sql.Rows) error) error {
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, Query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := Scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+//ImportBackup restores Data into a freshly installed database, keeping all IDs
+func (DBConnection *PostgresPlugin) ImportBackup(ctx context.Context, Data interfaces.BackupData) error {
+	err := DBConnection.RunInTransaction(ctx, func(Tx interfaces.DBInterface) error {
+		return Tx.(*PostgresPlugin).importBackup(ctx, Data)
+	})
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/ImportBackup", "0", logging.ResultFailure, []string{"Failed to import backup", err.Error()})
+		return err
+	}
+	return nil
+}
+
+func (DBConnection *PostgresPlugin) importBackup(ctx context.Context, Data interfaces.BackupData) error {
+	var Existing uint64
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT (SELECT COUNT(*) FROM Users WHERE ID <> 0) + (SELECT COUNT(*) FROM Images) + (SELECT COUNT(*) FROM Tags) + (SELECT COUNT(*) FROM Collections);").Scan(&Existing); err != nil {
+		return err
+	}
+	if Existing > 0 {
+		return errors.New("database is not empty, backups can only be imported into a fresh install")
+	}
+	for _, User := range Data.Users {
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO Users (ID, Name, EMail, PasswordHash, SecQuestionOne, SecQuestionTwo, SecQuestionThree, SecAnswerOne, SecAnswerTwo, SecAnswerThree, CreationTime, Disabled, Permissions, SearchFilter) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?);", User.ID, User.Name, User.EMail, User.PasswordHash, nullIfEmpty(User.SecQuestions[0]), nullIfEmpty(User.SecQuestions[1]), nullIfEmpty(User.SecQuestions[2]), nullIfEmpty(User.SecAnswers[0]), nullIfEmpty(User.SecAnswers[1]), nullIfEmpty(User.SecAnswers[2]), timestamp(User.CreationTime), User.Disabled, User.Permissions, User.SearchFilter); err != nil {
+			return errors.New("failed to import user " + User.Name + ": " + err.Error())
+		}
+	}
+	for _, Search := range Data.SavedSearches {
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO SavedSearches (ID, UserID, Name, Query) VALUES (?,?,?,?);", Search.ID, Search.UserID, Search.Name, Search.Query); err != nil {
+			return errors.New("failed to import saved search " + Search.Name + ": " + err.Error())
+		}
+	}
+	for _, Tag := range Data.Tags {
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO Tags (ID, Name, Description, UploaderID, UploadTime, AliasedID, IsAlias) VALUES (?,?,?,?,?,?,?);", Tag.ID, Tag.Name, Tag.Description, Tag.UploaderID, timestamp(Tag.UploadTime), Tag.AliasedID, Tag.IsAlias); err != nil {
+			return errors.New("failed to import tag " + Tag.Name + ": " + err.Error())
+		}
+	}
+	for _, Image := range Data.Images {
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO Images (ID, UploaderID, Name, Description, Rating, ScoreTotal, ScoreAverage, ScoreVoters, Location, Source, UploadTime, DeletedTime, DeletedBy, Width, Height, FileSize, MIMEType, Duration, Codec) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?);", Image.ID, Image.UploaderID, Image.Name, Image.Description, Image.Rating, Image.ScoreTotal, Image.ScoreAverage, Image.ScoreVoters, Image.Location, Image.Source, timestamp(Image.UploadTime), nullTimestamp(Image.DeletedTime), Image.DeletedBy, Image.Width, Image.Height, Image.FileSize, Image.MIMEType, Image.Duration, Image.Codec); err != nil {
+			return errors.New("failed to import image " + Image.Location + ": " + err.Error())
+		}
+	}
+	for _, Hash := range Data.ImagedHashes {
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO ImagedHashes (ImageID, hHash, vHash) VALUES (?,?,?);", Hash.ImageID, int64(Hash.HHash), int64(Hash.VHash)); err != nil {
+			return errors.New("failed to import image dHash: " + err.Error())
+		}
+	}
+	for _, Link := range Data.ImageTags {
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO ImageTags (ImageID, TagID, LinkerID, LinkTime) VALUES (?,?,?,?);", Link.ImageID, Link.TagID, Link.LinkerID, timestamp(Link.LinkTime)); err != nil {
+			return errors.New("failed to import image tag: " + err.Error())
+		}
+	}
+	for _, Vote := range Data.Votes {
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO ImageUserScores (UserID, ImageID, Score, CreationTime) VALUES (?,?,?,?);", Vote.UserID, Vote.ImageID, Vote.Score, timestamp(Vote.CreationTime)); err != nil {
+			return errors.New("failed to import vote: " + err.Error())
+		}
+	}
+	for _, Collection := range Data.Collections {
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO Collections (ID, Name, Description, UploaderID, UploadTime) VALUES (?,?,?,?,?);", Collection.ID, Collection.Name, Collection.Description, Collection.UploaderID, timestamp(Collection.UploadTime)); err != nil {
+			return errors.New("failed to import collection " + Collection.Name + ": " + err.Error())
+		}
+	}
+	//CollectionTags are filled in by the onCollectionMemberAdd trigger
+	for _, Member := range Data.CollectionMembers {
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO CollectionMembers (CollectionID, ImageID, LinkerID, LinkTime, OrderWeight) VALUES (?,?,?,?,?);", Member.CollectionID, Member.ImageID, Member.LinkerID, timestamp(Member.LinkTime), Member.OrderWeight); err != nil {
+			return errors.New("failed to import collection member: " + err.Error())
+		}
+	}
+	for _, Revision := range Data.ImageRevisions {
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO ImageRevisions (ID, ImageID, UserID, ChangeTime, Field, TagID, CollectionID, OldValue, NewValue) VALUES (?,?,?,?,?,?,?,?,?);", Revision.ID, Revision.ImageID, Revision.UserID, timestamp(Revision.ChangeTime), Revision.Field, Revision.TagID, Revision.CollectionID, Revision.OldValue, Revision.NewValue); err != nil {
+			return errors.New("failed to import image revision: " + err.Error())
+		}
+	}
+	//Rows were inserted with their IDs, so move the sequences past them
+	for _, Table := range []string{"Users", "SavedSearches", "Tags", "Images", "Collections", "ImageRevisions"} {
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "SELECT setval(pg_get_serial_sequence('"+Table+"', 'id'), COALESCE((SELECT MAX(ID) FROM "+Table+"), 0) + 1, false);"); err != nil {
+			return errors.New("failed to reset the " + Table + " ID sequence: " + err.Error())
+		}
+	}
+	for _, Log := range Data.AuditLogs {
+		Details := string(Log.Details)
+		if Details == "" || Details == "null" {
+			Details = "{}"
+		}
+		if _, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO AuditLogs (UserID, Action, TargetType, TargetID, Details, LogTime) VALUES (?,?,?,?,?,?);", Log.UserID, Log.Action, Log.TargetType, Log.TargetID, Details, timestamp(Log.LogTime)); err != nil {
+			return errors.New("failed to import audit log: " + err.Error())
+		}
+	}
+	return nil
+}
+
+//timestamp returns Time in UTC, as TIMESTAMP columns have no time zone, times missing from a backup become the current time
+func timestamp(Time time.Time) time.Time {
+	if Time.IsZero() {
+		Time = time.Now()
+	}
+	return Time.UTC()
+}
+
+//nullTimestamp is timestamp for optional columns, a zero time is stored as NULL
+func nullTimestamp(Time time.Time) sql.NullTime {
+	return sql.NullTime{Time: Time, Valid: Time.IsZero() == false}
+}
+
+//nullIfEmpty stores empty strings as NULL, for optional columns such as security questions
+func nullIfEmpty(Value string) sql.NullString {
+	return sql.NullString{String: Value, Valid: Value != ""}
+}
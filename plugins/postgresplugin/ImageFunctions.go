@@ -0,0 +1,253 @@
+package postgresplugin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"strconv"
+)
+
+//Image operations
+
+//NewImage adds an image with the provided information
+func (DBConnection *PostgresPlugin) NewImage(ctx context.Context, ImageName string, ImageFileName string, OwnerID uint64, Source string) (uint64, error) {
+	//lib/pq does not support LastInsertId, so ask for the new ID back instead
+	var id uint64
+	err := DBConnection.DBHandle.QueryRowContext(ctx, "INSERT INTO Images (Name, Location, UploaderID, Source) VALUES (?, ?, ?, ?) RETURNING ID;", ImageName, ImageFileName, OwnerID, Source).Scan(&id)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/NewImage", strconv.FormatUint(OwnerID, 10), logging.ResultFailure, []string{"Failed to add image", err.Error()})
+		return 0, err
+	}
+	logging.WriteLog(logging.LogLevelError, "PostgresPlugin/NewImage", strconv.FormatUint(OwnerID, 10), logging.ResultSuccess, []string{"Image added"})
+	return id, err
+}
+
+//DeleteImage removes an image from the db
+func (DBConnection *PostgresPlugin) DeleteImage(ctx context.Context, ImageID uint64) error {
+	//First, remove image from any associated collections
+	collectionInfo, err := DBConnection.GetCollectionsWithImage(ctx, ImageID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/DeleteImage", "0", logging.ResultFailure, []string{"Failed to get collection data to delete image", err.Error(), strconv.FormatUint(ImageID, 10)})
+		return err
+	}
+
+	for I := 0; I < len(collectionInfo); I++ {
+		if err = DBConnection.RemoveCollectionMember(ctx, collectionInfo[I].ID, ImageID); err != nil {
+			logging.WriteLog(logging.LogLevelWarning, "PostgresPlugin/DeleteImage", "0", logging.ResultFailure, []string{"Failed to remove image from collection", err.Error(), strconv.FormatUint(ImageID, 10)})
+		}
+	}
+
+	//First delete ImageTags
+	_, err = DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM ImageTags WHERE ImageID=?;", ImageID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/DeleteImage", "0", logging.ResultFailure, []string{"Failed to delete image", err.Error(), strconv.FormatUint(ImageID, 10)})
+		return err
+	}
+	logging.WriteLog(logging.LogLevelError, "PostgresPlugin/DeleteImage", "0", logging.ResultSuccess, []string{"Image tags deleted", strconv.FormatUint(ImageID, 10)})
+	//Second delete Image from table
+	_, err = DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM Images WHERE ID=?;", ImageID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/DeleteImage", "0", logging.ResultFailure, []string{"Failed to delete image", err.Error(), strconv.FormatUint(ImageID, 10)})
+	} else {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/DeleteImage", "0", logging.ResultSuccess, []string{"Image deleted", strconv.FormatUint(ImageID, 10)})
+	}
+	return err
+}
+
+//UpdateImage updates properties of an image
+func (DBConnection *PostgresPlugin) UpdateImage(ctx context.Context, ImageID uint64, ImageName interface{}, ImageDescription interface{}, OwnerID interface{}, Rating interface{}, Source interface{}, Location interface{}) error {
+	if _, correctValue := OwnerID.(uint64); OwnerID != nil && correctValue == false {
+		return errors.New("OwnerID, when provided, must be of uint64 type")
+	}
+
+	//See if image exists
+	_, err := DBConnection.GetImage(ctx, ImageID)
+	if err != nil {
+		return err
+	}
+
+	queryArray := []interface{}{}
+	sqlQuery := ""
+
+	if ImageName != nil {
+		queryArray = append(queryArray, fmt.Sprintf("%v", ImageName))
+		if sqlQuery != "" {
+			sqlQuery += ", "
+		}
+		sqlQuery += "Name = ? "
+	}
+	if ImageDescription != nil {
+		queryArray = append(queryArray, fmt.Sprintf("%v", ImageDescription))
+		if sqlQuery != "" {
+			sqlQuery += ", "
+		}
+		sqlQuery += "Description = ? "
+	}
+	if unwrappedOwnerID, correctValue := OwnerID.(uint64); OwnerID != nil && correctValue {
+		queryArray = append(queryArray, unwrappedOwnerID)
+		if sqlQuery != "" {
+			sqlQuery += ", "
+		}
+		sqlQuery += "UploaderID = ? "
+	}
+	if Rating != nil {
+		queryArray = append(queryArray, fmt.Sprintf("%v", Rating))
+		if sqlQuery != "" {
+			sqlQuery += ", "
+		}
+		sqlQuery += "Rating = ? "
+	}
+	if Source != nil {
+		queryArray = append(queryArray, fmt.Sprintf("%v", Source))
+		if sqlQuery != "" {
+			sqlQuery += ", "
+		}
+		sqlQuery += "Source = ? "
+	}
+	if Location != nil {
+		queryArray = append(queryArray, fmt.Sprintf("%v", Location))
+		if sqlQuery != "" {
+			sqlQuery += ", "
+		}
+		sqlQuery += "Location = ? "
+	}
+	queryArray = append(queryArray, ImageID)
+	if sqlQuery == "" {
+		return nil //No change requested
+	}
+	sqlQuery = "UPDATE Images SET " + sqlQuery + "WHERE ID = ?"
+	_, err = DBConnection.DBHandle.ExecContext(ctx, sqlQuery, queryArray...)
+	return err
+}
+
+//GetImage returns information on a single image (Returns an ImageInformation, or error)
+func (DBConnection *PostgresPlugin) GetImage(ctx context.Context, ID uint64) (interfaces.ImageInformation, error) {
+	ToReturn := interfaces.ImageInformation{ID: ID}
+	var UploadTime, DeletedTime sql.NullTime
+	err := DBConnection.DBHandle.QueryRowContext(ctx, "Select Images.Name, COALESCE(Images.Description,'') AS Description, Images.Location, Images.UploaderID, Images.UploadTime, Images.Rating, Users.Name, Images.ScoreAverage, Images.ScoreTotal, Images.ScoreVoters, Images.Source, Images.DeletedTime, Images.DeletedBy, COALESCE(DeletedUsers.Name, ''), Images.Width, Images.Height, Images.FileSize, Images.MIMEType, Images.Duration, Images.Codec FROM Images LEFT OUTER JOIN Users ON Images.UploaderID = Users.ID LEFT OUTER JOIN Users DeletedUsers ON Images.DeletedBy = DeletedUsers.ID WHERE Images.ID=?", ID).Scan(&ToReturn.Name, &ToReturn.Description, &ToReturn.Location, &ToReturn.UploaderID, &UploadTime, &ToReturn.Rating, &ToReturn.UploaderName, &ToReturn.ScoreAverage, &ToReturn.ScoreTotal, &ToReturn.ScoreVoters, &ToReturn.Source, &DeletedTime, &ToReturn.DeletedByID, &ToReturn.DeletedByName, &ToReturn.Width, &ToReturn.Height, &ToReturn.FileSize, &ToReturn.MIMEType, &ToReturn.Duration, &ToReturn.Codec)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/ImageFunctions/GetImage", "0", logging.ResultFailure, []string{"Failed to get image info from database", err.Error()})
+		return ToReturn, err
+	}
+	if UploadTime.Valid {
+		ToReturn.UploadTime = UploadTime.Time
+	}
+	if DeletedTime.Valid {
+		ToReturn.DeletedTime = DeletedTime.Time
+	}
+	return ToReturn, nil
+}
+
+//GetImageByFileName returns an ImageInformation object given a ImageName
+func (DBConnection *PostgresPlugin) GetImageByFileName(ctx context.Context, imageName string) (interfaces.ImageInformation, error) {
+	ToReturn := interfaces.ImageInformation{Location: imageName}
+	var UploadTime, DeletedTime sql.NullTime
+	err := DBConnection.DBHandle.QueryRowContext(ctx, "Select Images.Name, COALESCE(Images.Description,'') AS Description, Images.ID, Images.UploaderID, Images.UploadTime, Images.Rating, Users.Name, Images.ScoreAverage, Images.ScoreTotal, Images.ScoreVoters, Images.Source, Images.DeletedTime, Images.DeletedBy, COALESCE(DeletedUsers.Name, ''), Images.Width, Images.Height, Images.FileSize, Images.MIMEType, Images.Duration, Images.Codec FROM Images LEFT OUTER JOIN Users ON Images.UploaderID = Users.ID LEFT OUTER JOIN Users DeletedUsers ON Images.DeletedBy = DeletedUsers.ID WHERE Images.Location=?", imageName).Scan(&ToReturn.Name, &ToReturn.Description, &ToReturn.ID, &ToReturn.UploaderID, &UploadTime, &ToReturn.Rating, &ToReturn.UploaderName, &ToReturn.ScoreAverage, &ToReturn.ScoreTotal, &ToReturn.ScoreVoters, &ToReturn.Source, &DeletedTime, &ToReturn.DeletedByID, &ToReturn.DeletedByName, &ToReturn.Width, &ToReturn.Height, &ToReturn.FileSize, &ToReturn.MIMEType, &ToReturn.Duration, &ToReturn.Codec)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/ImageFunctions/GetImageByFileName", "0", logging.ResultFailure, []string{"Failed to get image info from database", err.Error()})
+		return ToReturn, err
+	}
+	if UploadTime.Valid {
+		ToReturn.UploadTime = UploadTime.Time
+	}
+	if DeletedTime.Valid {
+		ToReturn.DeletedTime = DeletedTime.Time
+	}
+	return ToReturn, nil
+}
+
+//SetImageRating changes a given image's rating in the database
+func (DBConnection *PostgresPlugin) SetImageRating(ctx context.Context, ID uint64, Rating string) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Images SET Rating = ? WHERE ID = ?;", Rating, ID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/ImageFunctions/SetImageRating", "0", logging.ResultFailure, []string{"Failed to set image rating", err.Error()})
+		return err
+	}
+	return nil
+}
+
+//SetImageSource changes a given image's source in the database
+func (DBConnection *PostgresPlugin) SetImageSource(ctx context.Context, ID uint64, Source string) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Images SET Source = ? WHERE ID = ?;", Source, ID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/ImageFunctions/SetImageSource", "0", logging.ResultFailure, []string{"Failed to set image source", err.Error()})
+		return err
+	}
+	return nil
+}
+
+//SetImageMetadata stores what was read from an image's file
+func (DBConnection *PostgresPlugin) SetImageMetadata(ctx context.Context, ID uint64, Metadata interfaces.ImageMetadata) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Images SET Width = ?, Height = ?, FileSize = ?, MIMEType = ?, Duration = ?, Codec = ? WHERE ID = ?;", Metadata.Width, Metadata.Height, Metadata.FileSize, Metadata.MIMEType, Metadata.Duration, Metadata.Codec, ID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/ImageFunctions/SetImageMetadata", "0", logging.ResultFailure, []string{"Failed to set image metadata", err.Error()})
+		return err
+	}
+	return nil
+}
+
+//SetImagedHash changes a given image's dHash in the database
+func (DBConnection *PostgresPlugin) SetImagedHash(ctx context.Context, ID uint64, hHash uint64, vHash uint64) error {
+	//Postgres integers are signed, so hashes are stored with their bits reinterpreted as int64
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO ImagedHashes (ImageID, hHash, vHash) VALUES (?,?,?) ON CONFLICT (ImageID) DO UPDATE SET hHash = excluded.hHash, vHash = excluded.vHash;", ID, int64(hHash), int64(vHash))
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/ImageFunctions/SetImagedHash", "0", logging.ResultFailure, []string{"Failed to set image dHashes", err.Error()})
+		return err
+	}
+	return nil
+}
+
+//GetImagedHash changes a given image's dHash in the database
+func (DBConnection *PostgresPlugin) GetImagedHash(ctx context.Context, ID uint64) (uint64, uint64, error) {
+	var hHash, vHash int64
+	err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT hHash, vHash from ImagedHashes WHERE ImageID = ?", ID).Scan(&hHash, &vHash)
+	if err != nil {
+		return uint64(hHash), uint64(vHash), err
+	}
+	return uint64(hHash), uint64(vHash), nil
+}
+
+/*
+//Our select query, if inclusive
+SELECT ImageID, Name, Location FROM (
+	SELECT ImageID, Name, Location, Count(*) as MatchingTags
+	FROM ImageTags
+	INNER JOIN Images ON ImageTags.ImageID=Images.ID
+	[WHERE ][TagID IN (1, 2, 3)]
+		[AND ][ImageID NOT IN (
+								SELECT DISTINCT ImageID FROM ImageTags WHERE TagID IN (4)
+							)]
+	GROUP BY ImageID
+) InnerStatement
+WHERE MatchingTags = 3
+ORDER BY ImageID DESC LIMIT 30 OFFSET 0;
+
+//Our Count Query
+SELECT COUNT(ImageID) FROM (
+	SELECT ImageID, Name, Location, Count(*) as MatchingTags
+	FROM ImageTags
+	INNER JOIN Images ON ImageTags.ImageID=Images.ID
+	WHERE TagID IN (1, 2, 3)
+		AND ImageID NOT IN (
+								SELECT DISTINCT ImageID FROM ImageTags WHERE TagID IN (4)
+							)
+	GROUP BY ImageID
+) InnerStatement
+WHERE MatchingTags = 3
+*/
+
+/*
+//Our select query, if blank or exlusive
+SELECT ImageID, Name, Location FROM Images [WHERE ][ImageID NOT IN (
+		SELECT DISTINCT ImageID FROM ImageTags WHERE TagID IN (4, 5, 6)
+	)]
+ORDER BY ImageID DESC LIMIT 30 OFFSET 0;
+
+//Our Count Query
+SELECT COUNT(*) FROM Images [WHERE ][ImageID NOT IN (
+		SELECT DISTINCT ImageID FROM ImageTags WHERE TagID IN (4, 5, 6)
+	)]
+*/
@@ -0,0 +1,68 @@
+package postgresplugin
+
+import (
+	"context"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"strconv"
+	"time"
+)
+
+//AddImageRevision records a change made to an image
+func (DBConnection *PostgresPlugin) AddImageRevision(ctx context.Context, Revision interfaces.ImageRevision) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO ImageRevisions (ImageID, UserID, Field, TagID, CollectionID, OldValue, NewValue) VALUES (?, ?, ?, ?, ?, ?, ?);", Revision.ImageID, Revision.UserID, Revision.Field, Revision.TagID, Revision.CollectionID, Revision.OldValue, Revision.NewValue)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/AddImageRevision", strconv.FormatUint(Revision.UserID, 10), logging.ResultFailure, []string{"Failed to record image revision", strconv.FormatUint(Revision.ImageID, 10), Revision.Field, err.Error()})
+	}
+	return err
+}
+
+//GetImageRevision returns one recorded change to an image
+func (DBConnection *PostgresPlugin) GetImageRevision(ctx context.Context, RevisionID uint64) (interfaces.ImageRevision, error) {
+	var ToReturn interfaces.ImageRevision
+	err := DBConnection.DBHandle.QueryRowContext(ctx, imageRevisionQuery+"WHERE ImageRevisions.ID = ?;", RevisionID).Scan(&ToReturn.ID, &ToReturn.ImageID, &ToReturn.UserID, &ToReturn.UserName, &ToReturn.ChangeTime, &ToReturn.Field, &ToReturn.TagID, &ToReturn.CollectionID, &ToReturn.OldValue, &ToReturn.NewValue)
+	return ToReturn, err
+}
+
+//GetImageRevisions returns the changes made to an image, newest first, and how many there are in total
+func (DBConnection *PostgresPlugin) GetImageRevisions(ctx context.Context, ImageID uint64, PageStart uint64, PageStride uint64) ([]interfaces.ImageRevision, uint64, error) {
+	var MaxResults uint64
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT COUNT(*) FROM ImageRevisions WHERE ImageID = ?;", ImageID).Scan(&MaxResults); err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/GetImageRevisions", "0", logging.ResultFailure, []string{"Error running count query", err.Error()})
+		return nil, 0, err
+	}
+	ToReturn, err := DBConnection.queryImageRevisions(ctx, "WHERE ImageRevisions.ImageID = ? ORDER BY ImageRevisions.ID DESC LIMIT ? OFFSET ?;", ImageID, PageStride, PageStart)
+	return ToReturn, MaxResults, err
+}
+
+//GetImageRevisionsSince returns the changes made to an image from RevisionID on, including RevisionID itself, oldest first
+func (DBConnection *PostgresPlugin) GetImageRevisionsSince(ctx context.Context, ImageID uint64, RevisionID uint64) ([]interfaces.ImageRevision, error) {
+	return DBConnection.queryImageRevisions(ctx, "WHERE ImageRevisions.ImageID = ? AND ImageRevisions.ID >= ? ORDER BY ImageRevisions.ID;", ImageID, RevisionID)
+}
+
+//GetUserImageRevisions returns the changes a user made to any image from Since up to but not including Until, oldest first
+func (DBConnection *PostgresPlugin) GetUserImageRevisions(ctx context.Context, UserID uint64, Since time.Time, Until time.Time) ([]interfaces.ImageRevision, error) {
+	return DBConnection.queryImageRevisions(ctx, "WHERE ImageRevisions.UserID = ? AND ImageRevisions.ChangeTime >= ? AND ImageRevisions.ChangeTime < ? ORDER BY ImageRevisions.ID;", UserID, Since, Until)
+}
+
+//imageRevisionQuery selects the columns of an ImageRevision, in the order queryImageRevisions scans them
+const imageRevisionQuery = "SELECT ImageRevisions.ID, ImageRevisions.ImageID, ImageRevisions.UserID, COALESCE(Users.Name, ''), ImageRevisions.ChangeTime, ImageRevisions.Field, ImageRevisions.TagID, ImageRevisions.CollectionID, ImageRevisions.OldValue, ImageRevisions.NewValue FROM ImageRevisions LEFT OUTER JOIN Users ON ImageRevisions.UserID = Users.ID "
+
+//queryImageRevisions runs imageRevisionQuery, Clause continues the query after its joins
+func (DBConnection *PostgresPlugin) queryImageRevisions(ctx context.Context, Clause string, Args ...interface{}) ([]interfaces.ImageRevision, error) {
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, imageRevisionQuery+Clause, Args...)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/queryImageRevisions", "0", logging.ResultFailure, []string{"Failed to query image revisions", err.Error()})
+		return nil, err
+	}
+	defer rows.Close()
+	var ToReturn []interfaces.ImageRevision
+	for rows.Next() {
+		var Revision interfaces.ImageRevision
+		if err := rows.Scan(&Revision.ID, &Revision.ImageID, &Revision.UserID, &Revision.UserName, &Revision.ChangeTime, &Revision.Field, &Revision.TagID, &Revision.CollectionID, &Revision.OldValue, &Revision.NewValue); err != nil {
+			return nil, err
+		}
+		ToReturn = append(ToReturn, Revision)
+	}
+	return ToReturn, rows.Err()
+}
@@ -0,0 +1,137 @@
+package postgresplugin
+
+import (
+	"context"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"strconv"
+	"time"
+)
+
+//jobColumns are the columns scanJob reads, in order
+const jobColumns = "ID, Type, ImageID, Status, Attempts, LastError, RunAfter, Rerun, CreatedTime, UpdatedTime"
+
+//jobScanner is implemented by both *sql.Row and *sql.Rows
+type jobScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//scanJob reads a row of jobColumns
+func scanJob(Row jobScanner) (interfaces.Job, error) {
+	var Job interfaces.Job
+	err := Row.Scan(&Job.ID, &Job.Type, &Job.ImageID, &Job.Status, &Job.Attempts, &Job.LastError, &Job.RunAfter, &Job.Rerun, &Job.CreatedTime, &Job.UpdatedTime)
+	return Job, err
+}
+
+//EnqueueJob queues a job to run at RunAfter. A pending or failed job of the same type for the same image is reset to pending with no attempts instead, a running one is marked to rerun
+func (DBConnection *PostgresPlugin) EnqueueJob(ctx context.Context, Type interfaces.JobType, ImageID uint64, RunAfter time.Time) error {
+	_, err := DBConnection.DBHandle.ExecContext(ctx, `INSERT INTO Jobs (Type, ImageID, Status, RunAfter, LastError) VALUES (?, ?, 'PENDING', ?, '')
+	ON CONFLICT (Type, ImageID) DO UPDATE SET Status = CASE WHEN Jobs.Status = 'RUNNING' THEN Jobs.Status ELSE 'PENDING' END, Attempts = CASE WHEN Jobs.Status = 'RUNNING' THEN Jobs.Attempts ELSE 0 END, RunAfter = excluded.RunAfter, Rerun = (Jobs.Status = 'RUNNING'), UpdatedTime = CASE WHEN Jobs.Status = 'RUNNING' THEN Jobs.UpdatedTime ELSE CURRENT_TIMESTAMP END;`, Type, ImageID, RunAfter.UTC())
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/EnqueueJob", "0", logging.ResultFailure, []string{"Failed to queue job", string(Type), strconv.FormatUint(ImageID, 10), err.Error()})
+	}
+	return err
+}
+
+//ClaimJob marks the pending job that has been due the longest as running and counts the attempt, returns sql.ErrNoRows if no job is due at Now
+func (DBConnection *PostgresPlugin) ClaimJob(ctx context.Context, Now time.Time) (interfaces.Job, error) {
+	//SKIP LOCKED lets workers claim different jobs at the same time instead of waiting on each other
+	return scanJob(DBConnection.DBHandle.QueryRowContext(ctx, `UPDATE Jobs SET Status = 'RUNNING', Attempts = Attempts + 1, UpdatedTime = CURRENT_TIMESTAMP
+	WHERE ID = (SELECT ID FROM Jobs WHERE Status = 'PENDING' AND RunAfter <= ? ORDER BY RunAfter, ID LIMIT 1 FOR UPDATE SKIP LOCKED) RETURNING `+jobColumns+`;`, Now.UTC()))
+}
+
+//FinishJob removes a job that succeeded, or sets it back to pending with no attempts if it was marked to rerun
+func (DBConnection *PostgresPlugin) FinishJob(ctx context.Context, JobID uint64) error {
+	//The job is only reset if the delete left it, so a rerun queued in between is not lost
+	if _, err := DBConnection.DBHandle.ExecContext(ctx, "DELETE FROM Jobs WHERE ID = ? AND Rerun = FALSE;", JobID); err != nil {
+		return err
+	}
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Jobs SET Status = 'PENDING', Attempts = 0, LastError = '', UpdatedTime = CURRENT_TIMESTAMP, Rerun = FALSE WHERE ID = ? AND Status = 'RUNNING';", JobID)
+	return err
+}
+
+//FailJob records why an attempt failed. The job is set back to pending to retry at RetryAt, or marked failed if RetryAt is zero. Jobs marked to rerun are set back to pending with no attempts instead
+func (DBConnection *PostgresPlugin) FailJob(ctx context.Context, JobID uint64, Error string, RetryAt time.Time) error {
+	var err error
+	if RetryAt.IsZero() {
+		_, err = DBConnection.DBHandle.ExecContext(ctx, "UPDATE Jobs SET Status = CASE WHEN Rerun THEN 'PENDING' ELSE 'FAILED' END, Attempts = CASE WHEN Rerun THEN 0 ELSE Attempts END, LastError = ?, UpdatedTime = CURRENT_TIMESTAMP, Rerun = FALSE WHERE ID = ?;", Error, JobID)
+	} else {
+		_, err = DBConnection.DBHandle.ExecContext(ctx, "UPDATE Jobs SET Status = 'PENDING', Attempts = CASE WHEN Rerun THEN 0 ELSE Attempts END, LastError = ?, RunAfter = CASE WHEN Rerun THEN RunAfter ELSE ? END, UpdatedTime = CURRENT_TIMESTAMP, Rerun = FALSE WHERE ID = ?;", Error, RetryAt.UTC(), JobID)
+	}
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/FailJob", "0", logging.ResultFailure, []string{"Failed to record failed job", strconv.FormatUint(JobID, 10), err.Error()})
+	}
+	return err
+}
+
+//RetryFailedJobs sets a failed job back to pending with no attempts, to run at RunAfter. JobID 0 retries every failed job. Returns how many were retried
+func (DBConnection *PostgresPlugin) RetryFailedJobs(ctx context.Context, JobID uint64, RunAfter time.Time) (uint64, error) {
+	Query := "UPDATE Jobs SET Status = 'PENDING', Attempts = 0, RunAfter = ?, UpdatedTime = CURRENT_TIMESTAMP WHERE Status = 'FAILED'"
+	Args := []interface{}{RunAfter.UTC()}
+	if JobID != 0 {
+		Query += " AND ID = ?"
+		Args = append(Args, JobID)
+	}
+	result, err := DBConnection.DBHandle.ExecContext(ctx, Query+";", Args...)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/RetryFailedJobs", "0", logging.ResultFailure, []string{"Failed to retry jobs", err.Error()})
+		return 0, err
+	}
+	Retried, err := result.RowsAffected()
+	return uint64(Retried), err
+}
+
+//RequeueRunningJobs sets running jobs that have not been updated for Lease back to pending to run at RunAfter, for jobs whose worker stopped before finishing them. Returns how many were requeued
+func (DBConnection *PostgresPlugin) RequeueRunningJobs(ctx context.Context, RunAfter time.Time, Lease time.Duration) (uint64, error) {
+	result, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Jobs SET Status = 'PENDING', RunAfter = ?, UpdatedTime = CURRENT_TIMESTAMP, Rerun = FALSE WHERE Status = 'RUNNING' AND UpdatedTime <= CURRENT_TIMESTAMP - make_interval(secs => ?);", RunAfter.UTC(), Lease.Seconds())
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/RequeueRunningJobs", "0", logging.ResultFailure, []string{"Failed to requeue jobs", err.Error()})
+		return 0, err
+	}
+	Requeued, err := result.RowsAffected()
+	return uint64(Requeued), err
+}
+
+//GetJobs returns jobs with the given status, most recently updated first, and the total number of them
+func (DBConnection *PostgresPlugin) GetJobs(ctx context.Context, Status interfaces.JobStatus, PageStart uint64, PageStride uint64) ([]interfaces.Job, uint64, error) {
+	var MaxResults uint64
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT COUNT(*) FROM Jobs WHERE Status = ?;", Status).Scan(&MaxResults); err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/GetJobs", "0", logging.ResultFailure, []string{"Error running count query", err.Error()})
+		return nil, 0, err
+	}
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, "SELECT "+jobColumns+" FROM Jobs WHERE Status = ? ORDER BY UpdatedTime DESC, ID DESC LIMIT ? OFFSET ?;", Status, PageStride, PageStart)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/GetJobs", "0", logging.ResultFailure, []string{"Failed to query jobs", err.Error()})
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var ToReturn []interfaces.Job
+	for rows.Next() {
+		Job, err := scanJob(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		ToReturn = append(ToReturn, Job)
+	}
+	return ToReturn, MaxResults, rows.Err()
+}
+
+//GetJobCounts returns how many jobs have each status, statuses without jobs are left out
+func (DBConnection *PostgresPlugin) GetJobCounts(ctx context.Context) (map[interfaces.JobStatus]uint64, error) {
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, "SELECT Status, COUNT(*) FROM Jobs GROUP BY Status;")
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/GetJobCounts", "0", logging.ResultFailure, []string{"Failed to count jobs", err.Error()})
+		return nil, err
+	}
+	defer rows.Close()
+	Counts := make(map[interfaces.JobStatus]uint64)
+	for rows.Next() {
+		var Status interfaces.JobStatus
+		var Count uint64
+		if err := rows.Scan(&Status, &Count); err != nil {
+			return nil, err
+		}
+		Counts[Status] = Count
+	}
+	return Counts, rows.Err()
+}
@@ -0,0 +1,85 @@
+package postgresplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"go-image-board/plugins/migrations"
+	"strconv"
+	"time"
+)
+
+//PlanMigrations reports the schema version and the migrations InitDatabase would run, without running them.
+//The schema comparison installs into a scratch schema in the configured database, so it needs the CREATE privilege on that database
+func (DBConnection *PostgresPlugin) PlanMigrations(ctx context.Context, CompareSchema bool) (interfaces.MigrationPlan, error) {
+	if DBConnection.DBHandle == nil {
+		if err := DBConnection.connect(); err != nil {
+			return interfaces.MigrationPlan{}, err
+		}
+	}
+	version, err := DBConnection.getDatabaseVersion()
+	if err != nil {
+		version = migrations.NotInstalled
+	}
+	Plan := schemaMigrations.Plan(version)
+	if CompareSchema && version != migrations.NotInstalled {
+		Plan.SchemaDifferences, err = DBConnection.compareWithFreshInstall(ctx, version)
+		if err != nil {
+			Plan.SchemaCheckError = err.Error()
+		}
+	}
+	return Plan, nil
+}
+
+//compareWithFreshInstall migrates a scratch schema up to version and compares it with the configured database
+func (DBConnection *PostgresPlugin) compareWithFreshInstall(ctx context.Context, version int64) ([]string, error) {
+	scratchName := "gib_schemacheck_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if _, err := DBConnection.DBHandle.ExecContext(ctx, "CREATE SCHEMA "+scratchName+";"); err != nil {
+		return nil, err
+	}
+	defer DBConnection.DBHandle.ExecContext(context.Background(), "DROP SCHEMA IF EXISTS "+scratchName+" CASCADE;")
+	//public stays on the path so that extensions such as citext resolve as they do in the configured database
+	scratch, err := openDatabase(scratchName + ",public")
+	if err != nil {
+		return nil, err
+	}
+	defer scratch.Close()
+	if _, err := schemaMigrations.Apply(ctx, scratch, migrations.NotInstalled, version, "PostgresPlugin/PlanMigrations"); err != nil {
+		return nil, err
+	}
+
+	current, err := describeSchema(ctx, DBConnection.DBHandle.DB)
+	if err != nil {
+		return nil, err
+	}
+	fresh, err := describeSchema(ctx, scratch)
+	if err != nil {
+		return nil, err
+	}
+	return migrations.Diff(current, fresh), nil
+}
+
+//describeSchema lists the columns, indexes, constraints, triggers and functions in the current schema of the database DB is connected to.
+//Column order is left out, as columns added by a migration always come last
+func describeSchema(ctx context.Context, DB *sql.DB) ([]string, error) {
+	queries := []struct {
+		Kind  string
+		Query string
+	}{
+		{"column", "SELECT table_name, column_name, data_type, is_nullable, column_default FROM information_schema.columns WHERE table_schema = current_schema();"},
+		//Index definitions name the schema, which differs between the database and the scratch copy
+		{"index", "SELECT tablename, indexname, replace(indexdef, schemaname || '.', '') AS indexdef FROM pg_indexes WHERE schemaname = current_schema();"},
+		{"constraint", "SELECT c.conrelid::regclass::text AS tablename, c.conname, pg_get_constraintdef(c.oid) AS definition FROM pg_constraint c JOIN pg_namespace n ON n.oid = c.connamespace WHERE n.nspname = current_schema();"},
+		{"trigger", "SELECT trigger_name, event_object_table, action_timing, event_manipulation, action_statement FROM information_schema.triggers WHERE trigger_schema = current_schema();"},
+		{"function", "SELECT p.proname, p.prosrc FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace WHERE n.nspname = current_schema();"},
+	}
+	var Description []string
+	for _, query := range queries {
+		Lines, err := migrations.DescribeRows(ctx, DB, query.Kind, query.Query)
+		if err != nil {
+			return nil, err
+		}
+		Description = append(Description, Lines...)
+	}
+	return Description, nil
+}
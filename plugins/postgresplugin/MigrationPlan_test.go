@@ -0,0 +1,66 @@
+package postgresplugin
+
+import (
+	"go-image-board/config"
+	"go-image-board/plugins/migrations"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//TestUpgradeMatchesFreshInstall migrates a database to each released version, upgrades it with InitDatabase and checks it matches a fresh install.
+//Each version gets its own database next to GIB_TEST_POSTGRES_NAME, so the user needs the CREATEDB privilege
+func TestUpgradeMatchesFreshInstall(t *testing.T) {
+	useTestServer(t)
+	TestDBName := config.Configuration.DBName
+	defer func() { config.Configuration.DBName = TestDBName }()
+	admin, err := openDatabase("")
+	if err != nil {
+		t.Fatalf("openDatabase failed: %v", err)
+	}
+	defer admin.Close()
+	for Version := migrations.NotInstalled; Version < schemaMigrations.Latest(); Version++ {
+		//Unquoted names are folded to lower case
+		UpgradeDBName := strings.ToLower(TestDBName + "_upgradetest_" + strconv.FormatInt(Version+1, 10))
+		if _, err := admin.ExecContext(t.Context(), "CREATE DATABASE "+UpgradeDBName+";"); err != nil {
+			t.Fatalf("failed to create %s: %v", UpgradeDBName, err)
+		}
+		config.Configuration.DBName = UpgradeDBName
+		t.Run(strconv.FormatInt(Version, 10), func(t *testing.T) {
+			checkUpgradeFrom(t, Version)
+		})
+		if _, err := admin.ExecContext(t.Context(), "DROP DATABASE IF EXISTS "+UpgradeDBName+" WITH (FORCE);"); err != nil {
+			t.Errorf("failed to drop %s: %v", UpgradeDBName, err)
+		}
+	}
+}
+
+//checkUpgradeFrom migrates the configured database to Version, upgrades it with InitDatabase and checks it matches a fresh install
+func checkUpgradeFrom(t *testing.T, Version int64) {
+	if Version != migrations.NotInstalled {
+		pool, err := openDatabase("")
+		if err != nil {
+			t.Fatalf("openDatabase failed: %v", err)
+		}
+		_, err = schemaMigrations.Apply(t.Context(), pool, migrations.NotInstalled, Version, "PostgresPlugin/Test")
+		pool.Close()
+		if err != nil {
+			t.Fatalf("Apply up to %d failed: %v", Version, err)
+		}
+	}
+	DB := &PostgresPlugin{}
+	if err := DB.InitDatabase(); err != nil {
+		t.Fatalf("InitDatabase from version %d failed: %v", Version, err)
+	}
+	defer DB.DBHandle.Close()
+	Plan, err := DB.PlanMigrations(t.Context(), true)
+	if err != nil || Plan.SchemaCheckError != "" {
+		t.Fatalf("PlanMigrations failed: %v %s", err, Plan.SchemaCheckError)
+	}
+	if Plan.CurrentVersion != schemaMigrations.Latest() || len(Plan.Pending) != 0 {
+		t.Errorf("after upgrading from %d, PlanMigrations = %+v, want no pending migrations", Version, Plan)
+	}
+	if len(Plan.SchemaDifferences) != 0 {
+		t.Errorf("after upgrading from %d, schema differs from a fresh install: %q", Version, Plan.SchemaDifferences)
+	}
+}
@@ -0,0 +1,241 @@
+package postgresplugin
+
+import (
+	"go-image-board/plugins/migrations"
+)
+
+//schemaMigrations is the PostgreSQL schema, InitDatabase applies whichever of these the database has not had yet, a fresh install applies all of them.
+//DDL is transactional in postgres, so each migration runs in a transaction and a failed one leaves no partial changes behind.
+//To change the schema, append a migration with the next version, never edit one that has been released
+var schemaMigrations = migrations.NewSet(
+	migrations.Migration{
+		Version:     1,
+		Description: "Initial schema",
+		Statements: []string{
+			//Names are compared case insensitively in MariaDB, citext keeps that behaviour
+			"CREATE EXTENSION IF NOT EXISTS citext;",
+			//DBVersion
+			"CREATE TABLE DBVersion (version BIGINT NOT NULL);",
+			"INSERT INTO DBVersion (version) VALUES (1);",
+			//Images and tags
+			"CREATE TABLE Tags (ID BIGSERIAL PRIMARY KEY, Name CITEXT NOT NULL UNIQUE, Description VARCHAR(255), UploaderID BIGINT NOT NULL, UploadTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, AliasedID BIGINT NOT NULL DEFAULT 0, IsAlias BOOL NOT NULL DEFAULT FALSE);",
+			"CREATE TABLE Images (ID BIGSERIAL PRIMARY KEY, UploaderID BIGINT NOT NULL, Name CITEXT NOT NULL, Rating VARCHAR(255) DEFAULT 'unrated', ScoreTotal BIGINT NOT NULL DEFAULT 0, ScoreAverage BIGINT NOT NULL DEFAULT 0, ScoreVoters BIGINT NOT NULL DEFAULT 0, Location VARCHAR(255) UNIQUE NOT NULL, Source VARCHAR(2000) NOT NULL DEFAULT '', UploadTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, Description TEXT NOT NULL DEFAULT '');",
+			"CREATE INDEX ImagesUploaderID ON Images (UploaderID);",
+			"CREATE INDEX ImagesRating ON Images (Rating);",
+			"CREATE INDEX ImagesUploadTime ON Images (UploadTime);",
+			"CREATE INDEX ImagesScoreAverage ON Images (ScoreAverage);",
+			"CREATE TABLE ImageTags (ID BIGSERIAL PRIMARY KEY, ImageID BIGINT NOT NULL, TagID BIGINT NOT NULL, LinkerID BIGINT NOT NULL, LinkTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, CONSTRAINT ImageTagPair UNIQUE (TagID, ImageID), CONSTRAINT fk_ImageTagsImageID FOREIGN KEY (ImageID) REFERENCES Images(ID), CONSTRAINT fk_ImageTagsTagID FOREIGN KEY (TagID) REFERENCES Tags(ID));",
+			"CREATE INDEX ImageTagsImageID ON ImageTags (ImageID);",
+			"CREATE INDEX ImageTagsLinkerID ON ImageTags (LinkerID);",
+			"CREATE TABLE ImagedHashes (ID BIGSERIAL PRIMARY KEY, ImageID BIGINT NOT NULL UNIQUE, vHash BIGINT NOT NULL, hHash BIGINT NOT NULL, CONSTRAINT fk_ImagedHashesImageID FOREIGN KEY (ImageID) REFERENCES Images(ID));",
+			"CREATE INDEX ImagedHashesvHash ON ImagedHashes (vHash);",
+			"CREATE INDEX ImagedHasheshHash ON ImagedHashes (hHash);",
+			"CREATE TABLE ImageUserScores (ID BIGSERIAL PRIMARY KEY, UserID BIGINT NOT NULL, ImageID BIGINT NOT NULL, Score BIGINT NOT NULL, CreationTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, CONSTRAINT ImageUserPair UNIQUE (UserID, ImageID));",
+			//Users
+			"CREATE TABLE Users (ID BIGSERIAL PRIMARY KEY, Name CITEXT NOT NULL UNIQUE, EMail CITEXT NOT NULL UNIQUE, PasswordHash VARCHAR(255) NOT NULL, TokenID VARCHAR(255), IP VARCHAR(50), SecQuestionOne VARCHAR(50), SecQuestionTwo VARCHAR(50), SecQuestionThree VARCHAR(50), SecAnswerOne VARCHAR(255), SecAnswerTwo VARCHAR(255), SecAnswerThree VARCHAR(255), CreationTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, Disabled BOOL NOT NULL DEFAULT FALSE, Permissions BIGINT NOT NULL DEFAULT 0, SearchFilter VARCHAR(255) NOT NULL DEFAULT '');",
+			//Reserve system for auditing
+			"INSERT INTO Users (ID, Name, EMail, PasswordHash, Disabled) VALUES (0, 'SYSTEM', '', '', TRUE);",
+			//Auditing
+			"CREATE TABLE AuditLogs (ID BIGSERIAL PRIMARY KEY, UserID BIGINT NOT NULL, Type VARCHAR(40), Info VARCHAR(10240) NOT NULL DEFAULT '', LogTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL);",
+			"CREATE INDEX AuditLogsLogTime ON AuditLogs (LogTime);",
+			//Collections
+			"CREATE TABLE Collections (ID BIGSERIAL PRIMARY KEY, Name CITEXT NOT NULL UNIQUE, Description VARCHAR(255), UploaderID BIGINT NOT NULL, UploadTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL);",
+			"CREATE TABLE CollectionMembers (ID BIGSERIAL PRIMARY KEY, ImageID BIGINT NOT NULL, CollectionID BIGINT NOT NULL, LinkerID BIGINT NOT NULL, LinkTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, OrderWeight BIGINT NOT NULL, CONSTRAINT ImageCollectionPair UNIQUE (CollectionID, ImageID), CONSTRAINT fk_CollectionMembersImageID FOREIGN KEY (ImageID) REFERENCES Images(ID), CONSTRAINT fk_CollectionMembersCollectionID FOREIGN KEY (CollectionID) REFERENCES Collections(ID));",
+			"CREATE TABLE CollectionTags (ID BIGSERIAL PRIMARY KEY, CollectionID BIGINT NOT NULL, TagID BIGINT NOT NULL, LinkerID BIGINT NOT NULL, LinkTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, CONSTRAINT CollectionTagPair UNIQUE (TagID, CollectionID), CONSTRAINT fk_CollectionTagsCollectionID FOREIGN KEY (CollectionID) REFERENCES Collections(ID), CONSTRAINT fk_CollectionTagsTagID FOREIGN KEY (TagID) REFERENCES Tags(ID));",
+			//Functions, Triggers
+			`CREATE FUNCTION LinkCollTags(collID BIGINT) RETURNS void AS $$
+		-- Insert missing tags
+		INSERT INTO CollectionTags (TagID, CollectionID, LinkerID)
+		SELECT DISTINCT ON (ImageTags.TagID) ImageTags.TagID, collID, ImageTags.LinkerID
+		FROM ImageTags
+		INNER JOIN CollectionMembers on CollectionMembers.ImageID = ImageTags.ImageID
+		LEFT JOIN CollectionTags on CollectionTags.CollectionID = CollectionMembers.CollectionID AND CollectionTags.TagID = ImageTags.TagID
+		WHERE CollectionMembers.CollectionID = collID AND CollectionTags.CollectionID IS NULL
+		ON CONFLICT DO NOTHING;
+		-- Remove extra tags
+		DELETE FROM CollectionTags
+		WHERE TagID NOT IN ( SELECT TagID
+								FROM ImageTags
+								INNER JOIN CollectionMembers on CollectionMembers.ImageID = ImageTags.ImageID
+								WHERE CollectionMembers.CollectionID = collID
+							)
+		AND CollectionID=collID;
+		$$ LANGUAGE SQL;`,
+			`CREATE FUNCTION AddMissingCollectionImageTags(imgID BIGINT) RETURNS void AS $$
+		-- Insert missing tags
+		INSERT INTO CollectionTags(TagID, CollectionID, LinkerID)
+		SELECT DISTINCT ON (ImageTags.TagID, CollectionMembers.CollectionID)
+			ImageTags.TagID,
+			CollectionMembers.CollectionID,
+			ImageTags.LinkerID
+		FROM
+			ImageTags
+		INNER JOIN CollectionMembers ON CollectionMembers.ImageID = ImageTags.ImageID
+		LEFT JOIN CollectionTags ON CollectionTags.CollectionID = CollectionMembers.CollectionID AND CollectionTags.TagID = ImageTags.TagID
+		WHERE
+			CollectionTags.CollectionID IS NULL AND ImageTags.ImageID = imgID
+		ON CONFLICT DO NOTHING;
+		$$ LANGUAGE SQL;`,
+			`CREATE FUNCTION RemSurplusCollectionImageTags(collID BIGINT) RETURNS void AS $$
+		-- Remove extra tags
+		DELETE FROM CollectionTags
+		WHERE TagID NOT IN ( SELECT TagID
+								FROM ImageTags
+								INNER JOIN CollectionMembers on CollectionMembers.ImageID = ImageTags.ImageID
+								WHERE CollectionMembers.CollectionID = collID
+							)
+		AND CollectionID=collID;
+		$$ LANGUAGE SQL;`,
+			`CREATE FUNCTION onCollectionDelete() RETURNS trigger AS $$
+		BEGIN
+			DELETE FROM CollectionMembers WHERE CollectionID=OLD.ID;
+			DELETE FROM CollectionTags WHERE CollectionID=OLD.ID;
+			RETURN OLD;
+		END;
+		$$ LANGUAGE plpgsql;`,
+			"CREATE TRIGGER onCollectionDelete BEFORE DELETE ON Collections FOR EACH ROW EXECUTE PROCEDURE onCollectionDelete();",
+			`CREATE FUNCTION onCollectionMemberAdd() RETURNS trigger AS $$
+		BEGIN
+			PERFORM AddMissingCollectionImageTags(NEW.ImageID);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;`,
+			"CREATE TRIGGER onCollectionMemberAdd AFTER INSERT ON CollectionMembers FOR EACH ROW EXECUTE PROCEDURE onCollectionMemberAdd();",
+			`CREATE FUNCTION onCollectionMemberDelete() RETURNS trigger AS $$
+		BEGIN
+			PERFORM RemSurplusCollectionImageTags(OLD.CollectionID);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;`,
+			"CREATE TRIGGER onCollectionMemberDelete AFTER DELETE ON CollectionMembers FOR EACH ROW EXECUTE PROCEDURE onCollectionMemberDelete();",
+			`CREATE FUNCTION onImageTagDelete() RETURNS trigger AS $$
+		BEGIN
+			PERFORM RemSurplusCollectionImageTags(CollectionID) FROM CollectionMembers WHERE ImageID = OLD.ImageID;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;`,
+			"CREATE TRIGGER onImageTagDelete AFTER DELETE ON ImageTags FOR EACH ROW EXECUTE PROCEDURE onImageTagDelete();",
+			`CREATE FUNCTION onImageDelete() RETURNS trigger AS $$
+		BEGIN
+			DELETE FROM ImageTags WHERE ImageID=OLD.ID;
+			DELETE FROM ImageUserScores WHERE ImageID=OLD.ID;
+			DELETE FROM CollectionMembers WHERE ImageID=OLD.ID;
+			DELETE FROM ImagedHashes WHERE ImageID=OLD.ID;
+			RETURN OLD;
+		END;
+		$$ LANGUAGE plpgsql;`,
+			"CREATE TRIGGER onImageDelete BEFORE DELETE ON Images FOR EACH ROW EXECUTE PROCEDURE onImageDelete();",
+			`CREATE FUNCTION onImageTagInsert() RETURNS trigger AS $$
+		BEGIN
+			PERFORM AddMissingCollectionImageTags(NEW.ImageID);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;`,
+			"CREATE TRIGGER onImageTagInsert AFTER INSERT ON ImageTags FOR EACH ROW EXECUTE PROCEDURE onImageTagInsert();",
+			`CREATE FUNCTION onTagDelete() RETURNS trigger AS $$
+		BEGIN
+			DELETE FROM ImageTags WHERE TagID=OLD.ID;
+			DELETE FROM CollectionTags WHERE TagID=OLD.ID;
+			RETURN OLD;
+		END;
+		$$ LANGUAGE plpgsql;`,
+			"CREATE TRIGGER onTagDelete BEFORE DELETE ON Tags FOR EACH ROW EXECUTE PROCEDURE onTagDelete();",
+		},
+	},
+	migrations.Migration{
+		Version:     2,
+		Description: "Image recycle bin",
+		Statements: []string{
+			"ALTER TABLE Images ADD COLUMN DeletedTime TIMESTAMP NULL, ADD COLUMN DeletedBy BIGINT NOT NULL DEFAULT 0;",
+			"CREATE INDEX ImagesDeletedTime ON Images (DeletedTime);",
+			//Collection listings only count and preview images that are not in the recycle bin
+			"CREATE VIEW VisibleCollectionMembers AS SELECT CollectionMembers.* FROM CollectionMembers INNER JOIN Images ON Images.ID = CollectionMembers.ImageID WHERE Images.DeletedTime IS NULL;",
+		},
+	},
+	migrations.Migration{
+		Version:     3,
+		Description: "Image revision history",
+		Statements: []string{
+			"CREATE TABLE ImageRevisions (ID BIGSERIAL PRIMARY KEY, ImageID BIGINT NOT NULL, UserID BIGINT NOT NULL, ChangeTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, Field VARCHAR(40) NOT NULL, TagID BIGINT NOT NULL DEFAULT 0, OldValue TEXT NOT NULL, NewValue TEXT NOT NULL, CONSTRAINT fk_ImageRevisionsImageID FOREIGN KEY (ImageID) REFERENCES Images(ID));",
+			"CREATE INDEX ImageRevisionsImageID ON ImageRevisions (ImageID);",
+			"CREATE INDEX ImageRevisionsUserID ON ImageRevisions (UserID, ChangeTime);",
+			`CREATE OR REPLACE FUNCTION onImageDelete() RETURNS trigger AS $$
+		BEGIN
+			DELETE FROM ImageTags WHERE ImageID=OLD.ID;
+			DELETE FROM ImageUserScores WHERE ImageID=OLD.ID;
+			DELETE FROM CollectionMembers WHERE ImageID=OLD.ID;
+			DELETE FROM ImagedHashes WHERE ImageID=OLD.ID;
+			DELETE FROM ImageRevisions WHERE ImageID=OLD.ID;
+			RETURN OLD;
+		END;
+		$$ LANGUAGE plpgsql;`,
+		},
+	},
+	migrations.Migration{
+		Version:     4,
+		Description: "Collection changes in image revisions",
+		Statements: []string{
+			"ALTER TABLE ImageRevisions ADD COLUMN CollectionID BIGINT NOT NULL DEFAULT 0;",
+		},
+	},
+	migrations.Migration{
+		Version:     5,
+		Description: "Audit log search indexes",
+		Statements: []string{
+			"CREATE INDEX AuditLogsUserID ON AuditLogs (UserID, LogTime);",
+			"CREATE INDEX AuditLogsType ON AuditLogs (Type, LogTime);",
+		},
+	},
+	migrations.Migration{
+		Version:     6,
+		Description: "Typed audit events",
+		Statements: []string{
+			"ALTER TABLE AuditLogs RENAME COLUMN Type TO Action;",
+			"ALTER INDEX AuditLogsType RENAME TO AuditLogsAction;",
+			"ALTER TABLE AuditLogs ADD COLUMN TargetType VARCHAR(20) NOT NULL DEFAULT '', ADD COLUMN TargetID BIGINT NOT NULL DEFAULT 0, ADD COLUMN Details TEXT NOT NULL DEFAULT '{}';",
+			"UPDATE AuditLogs SET Details = json_build_object('Info', Info)::text WHERE Info <> '';",
+			"ALTER TABLE AuditLogs DROP COLUMN Info;",
+			"CREATE INDEX AuditLogsTarget ON AuditLogs (TargetType, TargetID, LogTime);",
+		},
+	},
+	migrations.Migration{
+		Version:     7,
+		Description: "Background job queue",
+		Statements: []string{
+			"CREATE TABLE Jobs (ID BIGSERIAL PRIMARY KEY, Type VARCHAR(20) NOT NULL, ImageID BIGINT NOT NULL, Status VARCHAR(20) NOT NULL DEFAULT 'PENDING', Attempts BIGINT NOT NULL DEFAULT 0, LastError TEXT NOT NULL DEFAULT '', RunAfter TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, CreatedTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UpdatedTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, CONSTRAINT JobsTypeImage UNIQUE (Type, ImageID));",
+			"CREATE INDEX JobsStatus ON Jobs (Status, RunAfter);",
+		},
+	},
+	migrations.Migration{
+		Version:     8,
+		Description: "Image metadata",
+		Statements: []string{
+			"ALTER TABLE Images ADD COLUMN Width BIGINT NOT NULL DEFAULT 0, ADD COLUMN Height BIGINT NOT NULL DEFAULT 0, ADD COLUMN FileSize BIGINT NOT NULL DEFAULT 0, ADD COLUMN MIMEType VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN Duration DOUBLE PRECISION NOT NULL DEFAULT 0, ADD COLUMN Codec VARCHAR(255) NOT NULL DEFAULT '';",
+		},
+	},
+	migrations.Migration{
+		Version:     9,
+		Description: "Image metadata search indexes",
+		Statements: []string{
+			"CREATE INDEX ImagesWidth ON Images (Width);",
+			"CREATE INDEX ImagesHeight ON Images (Height);",
+			"CREATE INDEX ImagesFileSize ON Images (FileSize);",
+			"CREATE INDEX ImagesMIMEType ON Images (MIMEType);",
+			"CREATE INDEX ImagesDuration ON Images (Duration);",
+		},
+	},
+	migrations.Migration{
+		Version:     10,
+		Description: "Saved searches",
+		Statements: []string{
+			"CREATE TABLE SavedSearches (ID BIGSERIAL PRIMARY KEY, UserID BIGINT NOT NULL, Name VARCHAR(40) NOT NULL, Query TEXT NOT NULL, CONSTRAINT SavedSearchesUserName UNIQUE (UserID, Name), CONSTRAINT fk_SavedSearchesUserID FOREIGN KEY (UserID) REFERENCES Users(ID) ON DELETE CASCADE);",
+		},
+	},
+	migrations.Migration{
+		Version:     11,
+		Description: "Job reruns",
+		Statements: []string{
+			"ALTER TABLE Jobs ADD COLUMN Rerun BOOLEAN NOT NULL DEFAULT FALSE;",
+		},
+	},
+)
@@ -0,0 +1,174 @@
+package postgresplugin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-image-board/config"
+	"go-image-board/logging"
+	"go-image-board/plugins/migrations"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"math/rand"
+	"time"
+
+	//I mean, where else would this go?
+	_ "github.com/lib/pq"
+)
+
+//TODO: Increment this when we alter the db schema and don't add a migration to compensate
+var minSupportedDBVersion int64 // 0 by default
+
+//PostgresPlugin acts as plugin between gib and a PostgreSQL DB
+type PostgresPlugin struct {
+	DBHandle *PostgresHandle
+}
+
+//PostgresHandle wraps a sql.DB and rebinds the ? placeholders used by gib's queries to postgres' $1, $2... style
+type PostgresHandle struct {
+	*sql.DB
+	//Tx is set on the handles made by RunInTransaction, queries then run on it instead of the pool
+	Tx *sql.Tx
+}
+
+//queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+//target returns the transaction if there is one, otherwise the pool
+func (Handle *PostgresHandle) target() queryer {
+	if Handle.Tx != nil {
+		return Handle.Tx
+	}
+	return Handle.DB
+}
+
+//Exec rebinds and executes a query without returning any rows
+func (Handle *PostgresHandle) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return Handle.target().Exec(rebind(query), args...)
+}
+
+//Query rebinds and executes a query that returns rows
+func (Handle *PostgresHandle) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return Handle.target().Query(rebind(query), args...)
+}
+
+//QueryRow rebinds and executes a query that is expected to return at most one row
+func (Handle *PostgresHandle) QueryRow(query string, args ...interface{}) *sql.Row {
+	return Handle.target().QueryRow(rebind(query), args...)
+}
+
+//ExecContext rebinds and executes a query without returning any rows, stopping if ctx is cancelled
+func (Handle *PostgresHandle) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return Handle.target().ExecContext(ctx, rebind(query), args...)
+}
+
+//QueryContext rebinds and executes a query that returns rows, stopping if ctx is cancelled
+func (Handle *PostgresHandle) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return Handle.target().QueryContext(ctx, rebind(query), args...)
+}
+
+//QueryRowContext rebinds and executes a query that is expected to return at most one row, stopping if ctx is cancelled
+func (Handle *PostgresHandle) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return Handle.target().QueryRowContext(ctx, rebind(query), args...)
+}
+
+//rebind replaces each ? placeholder outside of a quoted string with $N
+func rebind(query string) string {
+	var builder strings.Builder
+	builder.Grow(len(query) + 16)
+	argument := 0
+	inQuote := false
+	for _, character := range query {
+		if character == '\'' {
+			inQuote = !inQuote
+		}
+		if character == '?' && inQuote == false {
+			argument++
+			builder.WriteString("$" + strconv.Itoa(argument))
+			continue
+		}
+		builder.WriteRune(character)
+	}
+	return builder.String()
+}
+
+//InitDatabase connects to a database, and if needed, creates and or updates tables
+func (DBConnection *PostgresPlugin) InitDatabase() error {
+	rand.Seed(time.Now().UnixNano())
+	if err := DBConnection.connect(); err != nil {
+		return err
+	}
+	version, err := DBConnection.getDatabaseVersion()
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/InitDatabase", "0", logging.ResultFailure, []string{"Failed to get database version, assuming not installed. Will attempt to perform install.", err.Error()})
+		//Assume no database installed. Perform fresh install
+		version = migrations.NotInstalled
+	} else {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/InitDatabase", "0", logging.ResultInfo, []string{"DBVersion is " + strconv.FormatInt(version, 10)})
+		if version < minSupportedDBVersion {
+			return errors.New("database version is not supported and no update code was found to bring database up to current version")
+		}
+	}
+	if _, err := schemaMigrations.Apply(context.Background(), DBConnection.DBHandle.DB, version, migrations.NotInstalled, "PostgresPlugin/InitDatabase"); err != nil {
+		return err
+	}
+	return nil
+}
+
+//connect opens the connection pool to the configured database
+func (DBConnection *PostgresPlugin) connect() error {
+	sqlDB, err := openDatabase("")
+	if err != nil {
+		return err
+	}
+	DBConnection.DBHandle = &PostgresHandle{DB: sqlDB}
+	return nil
+}
+
+//openDatabase opens and pings a connection pool to the configured database, with SearchPath as the schema search path if it is not empty
+func openDatabase(SearchPath string) (*sql.DB, error) {
+	//https://pkg.go.dev/github.com/lib/pq#hdr-Connection_String_Parameters
+	host := config.Configuration.DBHost
+	if config.Configuration.DBPort != "" {
+		host = host + ":" + config.Configuration.DBPort
+	}
+	parameters := url.Values{}
+	parameters.Set("sslmode", config.Configuration.DBSSLMode)
+	if SearchPath != "" {
+		//lib/pq sends parameters it does not know to the server as run-time settings
+		parameters.Set("search_path", SearchPath)
+	}
+	connectionURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(config.Configuration.DBUser, config.Configuration.DBPassword),
+		Host:     host,
+		Path:     "/" + config.Configuration.DBName,
+		RawQuery: parameters.Encode(),
+	}
+	sqlDB, err := sql.Open("postgres", connectionURL.String())
+	if err != nil {
+		return nil, err
+	}
+	//Ping actually validates we can query database
+	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return sqlDB, nil
+}
+
+func (DBConnection *PostgresPlugin) getDatabaseVersion() (int64, error) {
+	var version int64
+	row := DBConnection.DBHandle.QueryRow("SELECT version FROM DBVersion")
+	err := row.Scan(&version)
+	return version, err
+}
@@ -0,0 +1,35 @@
+package postgresplugin
+
+import (
+	"go-image-board/config"
+	"go-image-board/plugins/dbconformance"
+	"os"
+	"testing"
+)
+
+//useTestServer points the configuration at the live server the tests run against, or skips the test if GIB_TEST_POSTGRES_HOST is not set
+func useTestServer(t *testing.T) {
+	if os.Getenv("GIB_TEST_POSTGRES_HOST") == "" {
+		t.Skip("GIB_TEST_POSTGRES_HOST not set")
+	}
+	config.Configuration.DBHost = os.Getenv("GIB_TEST_POSTGRES_HOST")
+	config.Configuration.DBPort = os.Getenv("GIB_TEST_POSTGRES_PORT")
+	config.Configuration.DBName = os.Getenv("GIB_TEST_POSTGRES_NAME")
+	config.Configuration.DBUser = os.Getenv("GIB_TEST_POSTGRES_USER")
+	config.Configuration.DBPassword = os.Getenv("GIB_TEST_POSTGRES_PASSWORD")
+	config.Configuration.DBSSLMode = os.Getenv("GIB_TEST_POSTGRES_SSLMODE")
+	if config.Configuration.DBSSLMode == "" {
+		config.Configuration.DBSSLMode = "disable"
+	}
+	dbconformance.PrepareLogging()
+}
+
+//TestConformance runs against a live server, set GIB_TEST_POSTGRES_HOST (and _PORT, _NAME, _USER, _PASSWORD, _SSLMODE as needed) to enable it
+func TestConformance(t *testing.T) {
+	useTestServer(t)
+	DB := &PostgresPlugin{}
+	if err := DB.InitDatabase(); err != nil {
+		t.Fatalf("InitDatabase failed: %v", err)
+	}
+	dbconformance.RunSuite(t, DB)
+}
@@ -0,0 +1,106 @@
+package postgresplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"strconv"
+	"strings"
+)
+
+//GetUserFilterTags returns a slice of tags based on a user's custom filter
+func (DBConnection *PostgresPlugin) GetUserFilterTags(ctx context.Context, UserID uint64, CollectionContext bool) ([]interfaces.TagInformation, error) {
+	var userFilter string
+	err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT SearchFilter FROM Users WHERE ID = ?", UserID).Scan(&userFilter)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/GetUserQueryTags", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to get user filter", err.Error()})
+		return nil, err
+	}
+	tags, err := DBConnection.GetQueryTags(ctx, userFilter, CollectionContext)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/GetUserQueryTags", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to get tags from user filter", err.Error()})
+		return nil, err
+	}
+	//Loop through the tags and ensure we have them set as FromUserFilter
+	for i := 0; i < len(tags); i++ {
+		tags[i].FromUserFilter = true
+	}
+	return tags, nil
+}
+
+//GetQueryTags returns a slice of tags based on a query string, CollectionContext should be true if these tags are being parsed for a collection
+func (DBConnection *PostgresPlugin) GetQueryTags(ctx context.Context, UserQuery string, CollectionContext bool) ([]interfaces.TagInformation, error) {
+	return interfaces.GetQueryTags(ctx, DBConnection, UserQuery, CollectionContext)
+}
+
+//GetTagsByName returns the tags with the given names, names without a tag are left out
+func (DBConnection *PostgresPlugin) GetTagsByName(ctx context.Context, Names []string) ([]interfaces.TagInformation, error) {
+	if len(Names) == 0 {
+		return nil, nil
+	}
+	//Add all the tags into a generic interface to pass to DBQuery
+	queryArray := []interface{}{}
+	for _, Name := range Names {
+		queryArray = append(queryArray, Name)
+	}
+	//This is safe from SQL injection as we are just dynamically adjusting the placeholder "?s"
+	return DBConnection.queryTags(ctx, "SELECT Description, ID, Name, UploaderID, UploadTime, AliasedID, IsAlias FROM Tags WHERE Name IN (?"+strings.Repeat(",?", len(Names)-1)+")", queryArray...)
+}
+
+//GetTagsByID returns the tags with the given IDs, IDs without a tag are left out
+func (DBConnection *PostgresPlugin) GetTagsByID(ctx context.Context, IDs []uint64) ([]interfaces.TagInformation, error) {
+	if len(IDs) == 0 {
+		return nil, nil
+	}
+	queryArray := []interface{}{}
+	for _, ID := range IDs {
+		queryArray = append(queryArray, ID)
+	}
+	return DBConnection.queryTags(ctx, "SELECT Description, ID, Name, UploaderID, UploadTime, AliasedID, IsAlias FROM Tags WHERE ID IN (?"+strings.Repeat(",?", len(IDs)-1)+")", queryArray...)
+}
+
+//queryTags runs a query for the Description, ID, Name, UploaderID, UploadTime, AliasedID and IsAlias of tags
+func (DBConnection *PostgresPlugin) queryTags(ctx context.Context, sqlQuery string, queryArray ...interface{}) ([]interfaces.TagInformation, error) {
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, sqlQuery, queryArray...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ToReturn []interfaces.TagInformation
+	for rows.Next() {
+		var Tag interfaces.TagInformation
+		var Description sql.NullString
+		var UploadTime sql.NullTime
+		if err := rows.Scan(&Description, &Tag.ID, &Tag.Name, &Tag.UploaderID, &UploadTime, &Tag.AliasedID, &Tag.IsAlias); err != nil {
+			return nil, err
+		}
+		//If description is a valid non-null value, use it, else, use ""
+		if Description.Valid {
+			Tag.Description = Description.String
+		}
+		if UploadTime.Valid {
+			Tag.UploadTime = UploadTime.Time
+		}
+		ToReturn = append(ToReturn, Tag)
+	}
+	return ToReturn, rows.Err()
+}
+
+//GetTagNamesMatching returns the names of up to Limit tags that match a wildcard pattern, see interfaces.WildcardMatch
+func (DBConnection *PostgresPlugin) GetTagNamesMatching(ctx context.Context, Pattern string, Limit int) ([]string, error) {
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, "SELECT Name FROM Tags WHERE Name LIKE ? ESCAPE '!' LIMIT ?", interfaces.WildcardLikePattern(Pattern), Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var Names []string
+	for rows.Next() {
+		var Name string
+		if err := rows.Scan(&Name); err != nil {
+			return nil, err
+		}
+		Names = append(Names, Name)
+	}
+	return Names, rows.Err()
+}
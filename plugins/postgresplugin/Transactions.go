@@ -0,0 +1,37 @@
+package postgresplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+)
+
+//RunInTransaction calls Work with a copy of the plugin bound to a single transaction, committing it if Work returns nil and rolling it back otherwise
+func (DBConnection *PostgresPlugin) RunInTransaction(ctx context.Context, Work func(Tx interfaces.DBInterface) error) error {
+	if DBConnection.inTransaction() {
+		//Join the outer transaction, it decides whether to commit
+		return Work(DBConnection)
+	}
+	tx, err := DBConnection.DBHandle.BeginTx(ctx, nil)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/RunInTransaction", "0", logging.ResultFailure, []string{"Failed to begin transaction", err.Error()})
+		return err
+	}
+	if err := Work(&PostgresPlugin{DBHandle: &PostgresHandle{DB: DBConnection.DBHandle.DB, Tx: tx}}); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && rollbackErr != sql.ErrTxDone {
+			logging.WriteLog(logging.LogLevelError, "PostgresPlugin/RunInTransaction", "0", logging.ResultFailure, []string{"Failed to roll back transaction", rollbackErr.Error()})
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		logging.WriteLog(logging.LogLevelError, "PostgresPlugin/RunInTransaction", "0", logging.ResultFailure, []string{"Failed to commit transaction", err.Error()})
+		return err
+	}
+	return nil
+}
+
+//inTransaction returns true if this copy of the plugin was made by RunInTransaction
+func (DBConnection *PostgresPlugin) inTransaction() bool {
+	return DBConnection.DBHandle.Tx != nil
+}
@@ -0,0 +1,202 @@
+package s3blobplugin
+
+import (
+	"context"
+	"errors"
+	"go-image-board/config"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+//streamPartSize is the part size used for uploads whose size is not known, which are sent as multipart uploads
+const streamPartSize = 16 << 20
+
+//S3BlobPlugin stores media files as objects in an S3 compatible bucket
+type S3BlobPlugin struct {
+	client *minio.Client
+	bucket string
+	prefix string
+	//presignExpiry is how long the URLs ServeBlob redirects to are valid, 0 to stream blobs through gib instead
+	presignExpiry time.Duration
+}
+
+//Init connects to the configured service and checks that the bucket exists
+func (Store *S3BlobPlugin) Init(ctx context.Context) error {
+	Endpoint, err := url.Parse(config.Configuration.S3Endpoint)
+	if err != nil || Endpoint.Host == "" {
+		return errors.New("S3Endpoint must be a URL such as https://s3.us-east-1.amazonaws.com")
+	}
+	if config.Configuration.S3Bucket == "" {
+		return errors.New("missing S3Bucket")
+	}
+	Region := config.Configuration.S3Region
+	if Region == "" {
+		Region = "us-east-1"
+	}
+	Options := &minio.Options{
+		Creds:  credentials.NewStaticV4(config.Configuration.S3AccessKey, config.Configuration.S3SecretKey, ""),
+		Secure: Endpoint.Scheme == "https",
+		Region: Region,
+	}
+	if config.Configuration.S3PathStyle {
+		Options.BucketLookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(Endpoint.Host, Options)
+	if err != nil {
+		return err
+	}
+	Store.client = client
+	Store.bucket = config.Configuration.S3Bucket
+	Store.prefix = config.Configuration.S3Prefix
+	Store.presignExpiry = time.Duration(config.Configuration.S3PresignSeconds) * time.Second
+
+	Exists, err := client.BucketExists(ctx, Store.bucket)
+	if err != nil {
+		return err
+	}
+	if Exists == false {
+		return errors.New("bucket " + Store.bucket + " does not exist")
+	}
+	return nil
+}
+
+//key returns the object key Name is stored under
+func (Store *S3BlobPlugin) key(Name string) string {
+	return Store.prefix + Name
+}
+
+//translateError converts the S3 errors for missing objects and failed conditions into their fs equivalents
+func translateError(Op string, Name string, err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case minio.NoSuchKey:
+		return &fs.PathError{Op: Op, Path: Name, Err: fs.ErrNotExist}
+	case minio.PreconditionFailed:
+		return &fs.PathError{Op: Op, Path: Name, Err: fs.ErrExist}
+	}
+	return err
+}
+
+//Create stores Content under Name, it fails with an error matching fs.ErrExist if Name is already stored.
+//The object is only written if the service still has no object of that name when the upload completes
+func (Store *S3BlobPlugin) Create(ctx context.Context, Name string, Content io.Reader, Size int64) error {
+	//Checked first as well, so a duplicate is not uploaded only to be refused
+	if _, err := Store.Stat(ctx, Name); err == nil {
+		return &fs.PathError{Op: "create", Path: Name, Err: fs.ErrExist}
+	} else if errors.Is(err, fs.ErrNotExist) == false {
+		return err
+	}
+	Options := Store.putOptions(Name)
+	Options.SetMatchETagExcept("*")
+	_, err := Store.client.PutObject(ctx, Store.bucket, Store.key(Name), Content, Size, Options)
+	return translateError("create", Name, err)
+}
+
+//Put stores Content under Name, replacing what was stored there before
+func (Store *S3BlobPlugin) Put(ctx context.Context, Name string, Content io.Reader, Size int64) error {
+	_, err := Store.client.PutObject(ctx, Store.bucket, Store.key(Name), Content, Size, Store.putOptions(Name))
+	return translateError("put", Name, err)
+}
+
+//putOptions sets the content type from Name's extension, so redirects to presigned URLs are served with the right type
+func (Store *S3BlobPlugin) putOptions(Name string) minio.PutObjectOptions {
+	return minio.PutObjectOptions{ContentType: mime.TypeByExtension(path.Ext(Name)), PartSize: streamPartSize}
+}
+
+//Open returns the content stored under Name
+func (Store *S3BlobPlugin) Open(ctx context.Context, Name string) (io.ReadSeekCloser, error) {
+	Object, err := Store.client.GetObject(ctx, Store.bucket, Store.key(Name), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, translateError("open", Name, err)
+	}
+	//Objects are only requested once used, so check it exists before handing it out
+	if _, err := Object.Stat(); err != nil {
+		Object.Close()
+		return nil, translateError("open", Name, err)
+	}
+	return Object, nil
+}
+
+//Stat returns information about the object Name is stored in
+func (Store *S3BlobPlugin) Stat(ctx context.Context, Name string) (interfaces.BlobInfo, error) {
+	info, err := Store.client.StatObject(ctx, Store.bucket, Store.key(Name), minio.StatObjectOptions{})
+	if err != nil {
+		return interfaces.BlobInfo{}, translateError("stat", Name, err)
+	}
+	return interfaces.BlobInfo{Name: Name, Size: info.Size, ModTime: info.LastModified}, nil
+}
+
+//Delete removes the object Name is stored in, if there is one
+func (Store *S3BlobPlugin) Delete(ctx context.Context, Name string) error {
+	err := translateError("delete", Name, Store.client.RemoveObject(ctx, Store.bucket, Store.key(Name), minio.RemoveObjectOptions{}))
+	if err != nil && errors.Is(err, fs.ErrNotExist) == false {
+		return err
+	}
+	return nil
+}
+
+//Rename copies the object OldName is stored in to NewName, then removes the original
+func (Store *S3BlobPlugin) Rename(ctx context.Context, OldName string, NewName string) error {
+	_, err := Store.client.CopyObject(ctx, minio.CopyDestOptions{Bucket: Store.bucket, Object: Store.key(NewName)}, minio.CopySrcOptions{Bucket: Store.bucket, Object: Store.key(OldName)})
+	if err != nil {
+		return translateError("rename", OldName, err)
+	}
+	return Store.Delete(ctx, OldName)
+}
+
+//List calls Visit for every object in the bucket whose name starts with Prefix
+func (Store *S3BlobPlugin) List(ctx context.Context, Prefix string, Visit func(Blob interfaces.BlobInfo) error) error {
+	//Cancelling stops the listing when Visit fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for Object := range Store.client.ListObjects(ctx, Store.bucket, minio.ListObjectsOptions{Prefix: Store.key(Prefix), Recursive: true}) {
+		if Object.Err != nil {
+			return Object.Err
+		}
+		if err := Visit(interfaces.BlobInfo{Name: Object.Key[len(Store.prefix):], Size: Object.Size, ModTime: Object.LastModified}); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+//ServeBlob redirects to a presigned URL for Name if S3PresignSeconds is set, otherwise it streams the object through gib
+func (Store *S3BlobPlugin) ServeBlob(responseWriter http.ResponseWriter, request *http.Request, Name string) {
+	if Store.presignExpiry > 0 {
+		Presigned, err := Store.client.PresignedGetObject(request.Context(), Store.bucket, Store.key(Name), Store.presignExpiry, nil)
+		if err != nil {
+			logging.WriteLog(logging.LogLevelError, "S3BlobPlugin/ServeBlob", "0", logging.ResultFailure, []string{"Failed to presign URL", Name, err.Error()})
+			http.Error(responseWriter, "Failed to load file", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(responseWriter, request, Presigned.String(), http.StatusFound)
+		return
+	}
+	Object, err := Store.client.GetObject(request.Context(), Store.bucket, Store.key(Name), minio.GetObjectOptions{})
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "S3BlobPlugin/ServeBlob", "0", logging.ResultFailure, []string{"Failed to get object", Name, err.Error()})
+		http.Error(responseWriter, "Failed to load file", http.StatusInternalServerError)
+		return
+	}
+	defer Object.Close()
+	info, err := Object.Stat()
+	if err != nil {
+		if errors.Is(translateError("serve", Name, err), fs.ErrNotExist) {
+			http.NotFound(responseWriter, request)
+			return
+		}
+		logging.WriteLog(logging.LogLevelError, "S3BlobPlugin/ServeBlob", "0", logging.ResultFailure, []string{"Failed to get object", Name, err.Error()})
+		http.Error(responseWriter, "Failed to load file", http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(responseWriter, request, path.Base(Name), info.LastModified, Object)
+}
@@ -0,0 +1,255 @@
+package s3blobplugin
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"go-image-board/config"
+	"go-image-board/plugins/blobconformance"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//TestConformance runs against a stand-in for S3 served by the test itself.
+//Set GIB_TEST_S3_ENDPOINT (and _BUCKET, _REGION, _ACCESS_KEY, _SECRET_KEY as needed) to run it against a real service instead
+func TestConformance(t *testing.T) {
+	if os.Getenv("GIB_TEST_S3_ENDPOINT") != "" {
+		config.Configuration.S3Endpoint = os.Getenv("GIB_TEST_S3_ENDPOINT")
+		config.Configuration.S3Bucket = os.Getenv("GIB_TEST_S3_BUCKET")
+		config.Configuration.S3Region = os.Getenv("GIB_TEST_S3_REGION")
+		config.Configuration.S3AccessKey = os.Getenv("GIB_TEST_S3_ACCESS_KEY")
+		config.Configuration.S3SecretKey = os.Getenv("GIB_TEST_S3_SECRET_KEY")
+		config.Configuration.S3PathStyle = true
+	} else {
+		Server := httptest.NewServer(newFakeS3("gib-test"))
+		defer Server.Close()
+		config.Configuration.S3Endpoint = Server.URL
+		config.Configuration.S3Bucket = "gib-test"
+		config.Configuration.S3AccessKey = "test"
+		config.Configuration.S3SecretKey = "test-secret"
+		config.Configuration.S3PathStyle = true
+	}
+	for _, Presign := range []int64{0, 60} {
+		config.Configuration.S3PresignSeconds = Presign
+		config.Configuration.S3Prefix = "gib/"
+		t.Run("Presign"+strconv.FormatInt(Presign, 10), func(t *testing.T) {
+			Store := &S3BlobPlugin{}
+			if err := Store.Init(t.Context()); err != nil {
+				t.Fatalf("Init failed: %v", err)
+			}
+			blobconformance.RunSuite(t, Store)
+		})
+	}
+}
+
+func TestInitFailsForMissingBucket(t *testing.T) {
+	Server := httptest.NewServer(newFakeS3("gib-test"))
+	defer Server.Close()
+	config.Configuration.S3Endpoint = Server.URL
+	config.Configuration.S3Bucket = "other-bucket"
+	config.Configuration.S3PathStyle = true
+	if err := (&S3BlobPlugin{}).Init(t.Context()); err == nil {
+		t.Error("Init succeeded for a bucket that does not exist")
+	}
+}
+
+//fakeObject is an object stored by fakeS3
+type fakeObject struct {
+	Content []byte
+	ModTime time.Time
+}
+
+//fakeS3 is a stand-in for one bucket of an S3 compatible service, addressed path style.
+//It covers the requests S3BlobPlugin makes and does not check signatures
+type fakeS3 struct {
+	bucket  string
+	lock    sync.Mutex
+	objects map[string]fakeObject
+	//uploads holds the parts of multipart uploads in progress, by upload ID and part number
+	uploads map[string]map[int][]byte
+}
+
+func newFakeS3(Bucket string) *fakeS3 {
+	return &fakeS3{bucket: Bucket, objects: make(map[string]fakeObject), uploads: make(map[string]map[int][]byte)}
+}
+
+func (S3 *fakeS3) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	Bucket, Key, _ := strings.Cut(strings.TrimPrefix(request.URL.Path, "/"), "/")
+	if Bucket != S3.bucket {
+		S3.replyError(responseWriter, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	S3.lock.Lock()
+	defer S3.lock.Unlock()
+	switch {
+	case Key == "" && request.Method == "HEAD":
+		responseWriter.WriteHeader(http.StatusOK)
+	case Key == "" && request.Method == "GET" && request.URL.Query().Get("list-type") == "2":
+		S3.list(responseWriter, request.URL.Query().Get("prefix"))
+	case Key == "":
+		S3.replyError(responseWriter, http.StatusNotImplemented, "NotImplemented")
+	case request.Method == "POST" && request.URL.Query().Has("uploads"):
+		UploadID := strconv.Itoa(len(S3.uploads) + 1)
+		S3.uploads[UploadID] = make(map[int][]byte)
+		xml.NewEncoder(responseWriter).Encode(struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadId string
+		}{Bucket: S3.bucket, Key: Key, UploadId: UploadID})
+	case request.Method == "PUT" && request.URL.Query().Has("uploadId"):
+		Parts, found := S3.uploads[request.URL.Query().Get("uploadId")]
+		PartNumber, err := strconv.Atoi(request.URL.Query().Get("partNumber"))
+		if found == false || err != nil {
+			S3.replyError(responseWriter, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		if Parts[PartNumber], err = readPayload(request); err != nil {
+			S3.replyError(responseWriter, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		responseWriter.Header().Set("ETag", etag(Parts[PartNumber]))
+		responseWriter.WriteHeader(http.StatusOK)
+	case request.Method == "POST" && request.URL.Query().Has("uploadId"):
+		Parts, found := S3.uploads[request.URL.Query().Get("uploadId")]
+		if found == false {
+			S3.replyError(responseWriter, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		delete(S3.uploads, request.URL.Query().Get("uploadId"))
+		var Content []byte
+		for _, PartNumber := range slices.Sorted(maps.Keys(Parts)) {
+			Content = append(Content, Parts[PartNumber]...)
+		}
+		S3.objects[Key] = fakeObject{Content: Content, ModTime: time.Now()}
+		xml.NewEncoder(responseWriter).Encode(struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Bucket  string
+			Key     string
+			ETag    string
+		}{Bucket: S3.bucket, Key: Key, ETag: etag(Content)})
+	case request.Method == "PUT" && request.Header.Get("X-Amz-Copy-Source") != "":
+		Source, err := url.PathUnescape(request.Header.Get("X-Amz-Copy-Source"))
+		Object, found := S3.objects[strings.TrimPrefix(strings.TrimPrefix(Source, "/"), S3.bucket+"/")]
+		if err != nil || found == false {
+			S3.replyError(responseWriter, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		Object.ModTime = time.Now()
+		S3.objects[Key] = Object
+		xml.NewEncoder(responseWriter).Encode(struct {
+			XMLName      xml.Name `xml:"CopyObjectResult"`
+			ETag         string
+			LastModified string
+		}{ETag: etag(Object.Content), LastModified: Object.ModTime.UTC().Format(time.RFC3339)})
+	case request.Method == "PUT":
+		if _, found := S3.objects[Key]; found && request.Header.Get("If-None-Match") == "*" {
+			S3.replyError(responseWriter, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		Content, err := readPayload(request)
+		if err != nil {
+			S3.replyError(responseWriter, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		S3.objects[Key] = fakeObject{Content: Content, ModTime: time.Now()}
+		responseWriter.Header().Set("ETag", etag(Content))
+		responseWriter.WriteHeader(http.StatusOK)
+	case request.Method == "GET" || request.Method == "HEAD":
+		Object, found := S3.objects[Key]
+		if found == false {
+			S3.replyError(responseWriter, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		responseWriter.Header().Set("ETag", etag(Object.Content))
+		http.ServeContent(responseWriter, request, Key, Object.ModTime, bytes.NewReader(Object.Content))
+	case request.Method == "DELETE":
+		delete(S3.objects, Key)
+		responseWriter.WriteHeader(http.StatusNoContent)
+	default:
+		S3.replyError(responseWriter, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+//list replies to a ListObjectsV2 request, every object fits on one page
+func (S3 *fakeS3) list(responseWriter http.ResponseWriter, Prefix string) {
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+	}
+	Result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []content
+	}{Name: S3.bucket, Prefix: Prefix, MaxKeys: 1000}
+	for Key, Object := range S3.objects {
+		if strings.HasPrefix(Key, Prefix) {
+			Result.Contents = append(Result.Contents, content{Key: Key, LastModified: Object.ModTime.UTC().Format(time.RFC3339), ETag: etag(Object.Content), Size: len(Object.Content)})
+		}
+	}
+	slices.SortFunc(Result.Contents, func(A content, B content) int { return strings.Compare(A.Key, B.Key) })
+	Result.KeyCount = len(Result.Contents)
+	responseWriter.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(responseWriter).Encode(Result)
+}
+
+func (S3 *fakeS3) replyError(responseWriter http.ResponseWriter, Status int, Code string) {
+	responseWriter.Header().Set("Content-Type", "application/xml")
+	responseWriter.WriteHeader(Status)
+	xml.NewEncoder(responseWriter).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: Code, Message: Code})
+}
+
+//readPayload reads the body of a PUT, decoding the aws-chunked encoding used when the payload is signed or checksummed as it is streamed
+func readPayload(request *http.Request) ([]byte, error) {
+	if strings.HasPrefix(request.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") == false {
+		return io.ReadAll(request.Body)
+	}
+	var Content []byte
+	Body := bufio.NewReader(request.Body)
+	for {
+		Line, err := Body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		SizeField, _, _ := strings.Cut(strings.TrimSpace(Line), ";")
+		Size, err := strconv.ParseInt(SizeField, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if Size == 0 {
+			return Content, nil //Any trailing checksums are ignored
+		}
+		Chunk := make([]byte, Size+2)
+		if _, err := io.ReadFull(Body, Chunk); err != nil {
+			return nil, err
+		}
+		Content = append(Content, Chunk[:Size]...)
+	}
+}
+
+func etag(Content []byte) string {
+	Sum := md5.Sum(Content)
+	return "\"" + hex.EncodeToString(Sum[:]) + "\""
+}
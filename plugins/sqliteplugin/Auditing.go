@@ -0,0 +1,112 @@
+package sqliteplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//AddAuditLog adds an audit event into the audit table, it is logged at the current time
+func (DBConnection *SQLitePlugin) AddAuditLog(ctx context.Context, Log interfaces.AuditLog) error {
+	if len(Log.Action) > 40 || len(Log.TargetType) > 20 {
+		logging.WriteLog(logging.LogLevelError, "SQLitePlugin/AddAuditLog", strconv.FormatUint(Log.UserID, 10), logging.ResultFailure, []string{"either the action, or the target type is too long for the audit log table", string(Log.Action), string(Log.TargetType)})
+		return errors.New("either the action, or the target type is too long for the audit log table")
+	}
+	Details := string(Log.Details)
+	if Details == "" {
+		Details = "{}"
+	}
+
+	_, err := DBConnection.DBHandle.ExecContext(ctx, "INSERT INTO AuditLogs (UserID, Action, TargetType, TargetID, Details) VALUES (?, ?, ?, ?, ?);", Log.UserID, Log.Action, Log.TargetType, Log.TargetID, Details)
+	return err
+}
+
+//SearchAuditLogs returns the audit log entries matching Filter, newest first, and how many match in total
+func (DBConnection *SQLitePlugin) SearchAuditLogs(ctx context.Context, Filter interfaces.AuditLogFilter, PageStart uint64, PageStride uint64) ([]interfaces.AuditLog, uint64, error) {
+	var Conditions []string
+	var Args []interface{}
+	if Filter.UserID != 0 {
+		Conditions = append(Conditions, "AuditLogs.UserID = ?")
+		Args = append(Args, Filter.UserID)
+	}
+	if Filter.Action != "" {
+		Conditions = append(Conditions, "AuditLogs.Action = ?")
+		Args = append(Args, Filter.Action)
+	}
+	if Filter.TargetType != interfaces.AuditTargetNone {
+		Conditions = append(Conditions, "AuditLogs.TargetType = ?")
+		Args = append(Args, Filter.TargetType)
+	}
+	if Filter.TargetID != 0 {
+		Conditions = append(Conditions, "AuditLogs.TargetID = ?")
+		Args = append(Args, Filter.TargetID)
+	}
+	if Filter.Since.IsZero() == false {
+		Conditions = append(Conditions, "AuditLogs.LogTime >= ?")
+		Args = append(Args, Filter.Since.UTC().Format(timestampFormat))
+	}
+	if Filter.Until.IsZero() == false {
+		Conditions = append(Conditions, "AuditLogs.LogTime < ?")
+		Args = append(Args, Filter.Until.UTC().Format(timestampFormat))
+	}
+	Where := ""
+	if len(Conditions) > 0 {
+		Where = "WHERE " + strings.Join(Conditions, " AND ") + " "
+	}
+
+	var MaxResults uint64
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT COUNT(*) FROM AuditLogs "+Where+";", Args...).Scan(&MaxResults); err != nil {
+		logging.WriteLog(logging.LogLevelError, "SQLitePlugin/SearchAuditLogs", "0", logging.ResultFailure, []string{"Error running count query", err.Error()})
+		return nil, 0, err
+	}
+	ToReturn, err := DBConnection.queryAuditLogs(ctx, Where+"ORDER BY AuditLogs.ID DESC LIMIT ? OFFSET ?;", append(Args, PageStride, PageStart)...)
+	return ToReturn, MaxResults, err
+}
+
+//GetAuditLogsBefore returns up to Limit audit log entries logged before the given time, oldest first
+func (DBConnection *SQLitePlugin) GetAuditLogsBefore(ctx context.Context, Before time.Time, Limit uint64) ([]interfaces.AuditLog, error) {
+	return DBConnection.queryAuditLogs(ctx, "WHERE AuditLogs.LogTime < ? ORDER BY AuditLogs.ID LIMIT ?;", Before.UTC().Format(timestampFormat), Limit)
+}
+
+//DeleteAuditLogsBefore deletes the audit log entries logged before the given time. If UpToID is not 0, only entries with an ID up to and including it are deleted
+func (DBConnection *SQLitePlugin) DeleteAuditLogsBefore(ctx context.Context, Before time.Time, UpToID uint64) (uint64, error) {
+	Query := "DELETE FROM AuditLogs WHERE LogTime < ?"
+	Args := []interface{}{Before.UTC().Format(timestampFormat)}
+	if UpToID != 0 {
+		Query += " AND ID <= ?"
+		Args = append(Args, UpToID)
+	}
+	result, err := DBConnection.DBHandle.ExecContext(ctx, Query+";", Args...)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "SQLitePlugin/DeleteAuditLogsBefore", "0", logging.ResultFailure, []string{"Failed to delete audit logs", err.Error()})
+		return 0, err
+	}
+	Deleted, err := result.RowsAffected()
+	return uint64(Deleted), err
+}
+
+//queryAuditLogs runs a query for audit log entries, Clause continues the query after its joins
+func (DBConnection *SQLitePlugin) queryAuditLogs(ctx context.Context, Clause string, Args ...interface{}) ([]interfaces.AuditLog, error) {
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, "SELECT AuditLogs.ID, AuditLogs.UserID, IFNULL(Users.Name, ''), IFNULL(AuditLogs.Action, ''), AuditLogs.TargetType, AuditLogs.TargetID, AuditLogs.Details, AuditLogs.LogTime FROM AuditLogs LEFT OUTER JOIN Users ON AuditLogs.UserID = Users.ID "+Clause, Args...)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "SQLitePlugin/queryAuditLogs", "0", logging.ResultFailure, []string{"Failed to query audit logs", err.Error()})
+		return nil, err
+	}
+	defer rows.Close()
+	var ToReturn []interfaces.AuditLog
+	for rows.Next() {
+		var Log interfaces.AuditLog
+		var Details string
+		if err := rows.Scan(&Log.ID, &Log.UserID, &Log.UserName, &Log.Action, &Log.TargetType, &Log.TargetID, &Details, &Log.LogTime); err != nil {
+			return nil, err
+		}
+		Log.Details = json.RawMessage(Details)
+		ToReturn = append(ToReturn, Log)
+	}
+	return ToReturn, rows.Err()
+}
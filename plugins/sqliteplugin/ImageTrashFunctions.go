@@ -0,0 +1,85 @@
+package sqliteplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"strconv"
+	"time"
+)
+
+//TrashImage moves an image to the recycle bin, the row and its files are kept until it is restored or purged
+func (DBConnection *SQLitePlugin) TrashImage(ctx context.Context, ImageID uint64, DeletedBy uint64) error {
+	result, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Images SET DeletedTime = CURRENT_TIMESTAMP, DeletedBy = ? WHERE ID = ? AND DeletedTime IS NULL;", DeletedBy, ImageID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "SQLitePlugin/TrashImage", strconv.FormatUint(DeletedBy, 10), logging.ResultFailure, []string{"Failed to move image to recycle bin", strconv.FormatUint(ImageID, 10), err.Error()})
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+//RestoreImage takes an image back out of the recycle bin
+func (DBConnection *SQLitePlugin) RestoreImage(ctx context.Context, ImageID uint64) error {
+	result, err := DBConnection.DBHandle.ExecContext(ctx, "UPDATE Images SET DeletedTime = NULL, DeletedBy = 0 WHERE ID = ? AND DeletedTime IS NOT NULL;", ImageID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "SQLitePlugin/RestoreImage", "0", logging.ResultFailure, []string{"Failed to restore image from recycle bin", strconv.FormatUint(ImageID, 10), err.Error()})
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+//IsImageFileTrashed returns whether the image stored under Location is in the recycle bin, false if no image is stored there
+func (DBConnection *SQLitePlugin) IsImageFileTrashed(ctx context.Context, Location string) (bool, error) {
+	var Trashed uint64
+	err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT COUNT(*) FROM Images WHERE Location = ? AND DeletedTime IS NOT NULL;", Location).Scan(&Trashed)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "SQLitePlugin/IsImageFileTrashed", "0", logging.ResultFailure, []string{"Failed to check if image is in recycle bin", Location, err.Error()})
+		return false, err
+	}
+	return Trashed > 0, nil
+}
+
+//GetTrashedImages returns images in the recycle bin, most recently deleted first, and how many there are in total
+func (DBConnection *SQLitePlugin) GetTrashedImages(ctx context.Context, PageStart uint64, PageStride uint64) ([]interfaces.ImageInformation, uint64, error) {
+	var MaxResults uint64
+	if err := DBConnection.DBHandle.QueryRowContext(ctx, "SELECT COUNT(*) FROM Images WHERE DeletedTime IS NOT NULL;").Scan(&MaxResults); err != nil {
+		logging.WriteLog(logging.LogLevelError, "SQLitePlugin/GetTrashedImages", "0", logging.ResultFailure, []string{"Error running count query", err.Error()})
+		return nil, 0, err
+	}
+	ToReturn, err := DBConnection.queryTrashedImages(ctx, "WHERE Images.DeletedTime IS NOT NULL ORDER BY Images.DeletedTime DESC, Images.ID DESC LIMIT ? OFFSET ?;", PageStride, PageStart)
+	return ToReturn, MaxResults, err
+}
+
+//GetImagesTrashedBefore returns up to Limit images that were moved to the recycle bin before the given time, oldest first
+func (DBConnection *SQLitePlugin) GetImagesTrashedBefore(ctx context.Context, Before time.Time, Limit uint64) ([]interfaces.ImageInformation, error) {
+	//DeletedTime is stored as CURRENT_TIMESTAMP text, so compare against the same format
+	return DBConnection.queryTrashedImages(ctx, "WHERE Images.DeletedTime IS NOT NULL AND Images.DeletedTime < ? ORDER BY Images.DeletedTime, Images.ID LIMIT ?;", Before.UTC().Format(timestampFormat), Limit)
+}
+
+//queryTrashedImages runs a query for trashed images, Clause continues the query after its joins
+func (DBConnection *SQLitePlugin) queryTrashedImages(ctx context.Context, Clause string, Args ...interface{}) ([]interfaces.ImageInformation, error) {
+	rows, err := DBConnection.DBHandle.QueryContext(ctx, "SELECT Images.ID, Images.Name, Images.Location, Images.UploaderID, IFNULL(Users.Name, ''), Images.DeletedTime, Images.DeletedBy, IFNULL(DeletedUsers.Name, '') FROM Images LEFT OUTER JOIN Users ON Images.UploaderID = Users.ID LEFT OUTER JOIN Users DeletedUsers ON Images.DeletedBy = DeletedUsers.ID "+Clause, Args...)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "SQLitePlugin/queryTrashedImages", "0", logging.ResultFailure, []string{"Failed to query recycle bin", err.Error()})
+		return nil, err
+	}
+	defer rows.Close()
+	var ToReturn []interfaces.ImageInformation
+	for rows.Next() {
+		var Image interfaces.ImageInformation
+		var DeletedTime sql.NullTime
+		if err := rows.Scan(&Image.ID, &Image.Name, &Image.Location, &Image.UploaderID, &Image.UploaderName, &DeletedTime, &Image.DeletedByID, &Image.DeletedByName); err != nil {
+			return nil, err
+		}
+		Image.DeletedTime = DeletedTime.Time
+		ToReturn = append(ToReturn, Image)
+	}
+	return ToReturn, rows.Err()
+}
@@ -0,0 +1,82 @@
+package sqliteplugin
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/interfaces"
+	"go-image-board/plugins/migrations"
+	"os"
+	"path/filepath"
+)
+
+//PlanMigrations reports the schema version and the migrations InitDatabase would run, without running them.
+//The schema comparison installs into a temporary database file, which is removed afterwards
+func (DBConnection *SQLitePlugin) PlanMigrations(ctx context.Context, CompareSchema bool) (interfaces.MigrationPlan, error) {
+	if DBConnection.pool == nil {
+		if err := DBConnection.connect(); err != nil {
+			return interfaces.MigrationPlan{}, err
+		}
+	}
+	version, err := DBConnection.getDatabaseVersion()
+	if err != nil {
+		version = migrations.NotInstalled
+	}
+	Plan := schemaMigrations.Plan(version)
+	if CompareSchema && version != migrations.NotInstalled {
+		Plan.SchemaDifferences, err = DBConnection.compareWithFreshInstall(ctx, version)
+		if err != nil {
+			Plan.SchemaCheckError = err.Error()
+		}
+	}
+	return Plan, nil
+}
+
+//compareWithFreshInstall migrates a scratch database up to version and compares its schema with the configured database
+func (DBConnection *SQLitePlugin) compareWithFreshInstall(ctx context.Context, version int64) ([]string, error) {
+	scratchDirectory, err := os.MkdirTemp("", "gib-schemacheck-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratchDirectory)
+	scratch, err := openDatabase(filepath.Join(scratchDirectory, "gib.db"))
+	if err != nil {
+		return nil, err
+	}
+	defer scratch.Close()
+	if _, err := schemaMigrations.Apply(ctx, scratch, migrations.NotInstalled, version, "SQLitePlugin/PlanMigrations"); err != nil {
+		return nil, err
+	}
+
+	current, err := describeSchema(ctx, DBConnection.pool)
+	if err != nil {
+		return nil, err
+	}
+	fresh, err := describeSchema(ctx, scratch)
+	if err != nil {
+		return nil, err
+	}
+	return migrations.Diff(current, fresh), nil
+}
+
+//describeSchema lists the columns, indexes, foreign keys and triggers of the database DB is connected to.
+//Column order is left out, as columns added by a migration always come last
+func describeSchema(ctx context.Context, DB *sql.DB) ([]string, error) {
+	queries := []struct {
+		Kind  string
+		Query string
+	}{
+		{"column", "SELECT m.name AS TableName, p.name AS ColumnName, p.type AS Type, p.\"notnull\" AS Required, p.dflt_value AS DefaultValue, p.pk AS PrimaryKey FROM sqlite_master m, pragma_table_info(m.name) p WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%';"},
+		{"index", "SELECT m.name AS TableName, l.name AS IndexName, l.\"unique\" AS IsUnique, l.origin AS Origin, group_concat(i.name) AS Columns FROM sqlite_master m, pragma_index_list(m.name) l, pragma_index_info(l.name) i WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%' GROUP BY m.name, l.name, l.\"unique\", l.origin;"},
+		{"foreignkey", "SELECT m.name AS TableName, f.\"from\" AS ColumnName, f.\"table\" AS ReferencedTable, f.\"to\" AS ReferencedColumn, f.on_update AS OnUpdate, f.on_delete AS OnDelete FROM sqlite_master m, pragma_foreign_key_list(m.name) f WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%';"},
+		{"trigger", "SELECT name AS TriggerName, tbl_name AS TableName, sql AS Definition FROM sqlite_master WHERE type = 'trigger';"},
+	}
+	var Description []string
+	for _, query := range queries {
+		Lines, err := migrations.DescribeRows(ctx, DB, query.Kind, query.Query)
+		if err != nil {
+			return nil, err
+		}
+		Description = append(Description, Lines...)
+	}
+	return Description, nil
+}
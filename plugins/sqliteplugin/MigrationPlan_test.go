@@ -0,0 +1,62 @@
+package sqliteplugin
+
+import (
+	"go-image-board/config"
+	"go-image-board/plugins/dbconformance"
+	"go-image-board/plugins/migrations"
+	"path/filepath"
+	"testing"
+)
+
+//TestUpgradeMatchesFreshInstall migrates a database to each released version, upgrades it with InitDatabase and checks it matches a fresh install
+func TestUpgradeMatchesFreshInstall(t *testing.T) {
+	dbconformance.PrepareLogging()
+	for Version := migrations.NotInstalled; Version < schemaMigrations.Latest(); Version++ {
+		config.Configuration.DBFile = filepath.Join(t.TempDir(), "gib.db")
+		if Version != migrations.NotInstalled {
+			pool, err := openDatabase(config.Configuration.DBFile)
+			if err != nil {
+				t.Fatalf("openDatabase failed: %v", err)
+			}
+			if _, err := schemaMigrations.Apply(t.Context(), pool, migrations.NotInstalled, Version, "SQLitePlugin/Test"); err != nil {
+				t.Fatalf("Apply up to %d failed: %v", Version, err)
+			}
+			pool.Close()
+		}
+		DB := &SQLitePlugin{}
+		if err := DB.InitDatabase(); err != nil {
+			t.Fatalf("InitDatabase from version %d failed: %v", Version, err)
+		}
+		Plan, err := DB.PlanMigrations(t.Context(), true)
+		DB.pool.Close()
+		if err != nil || Plan.SchemaCheckError != "" {
+			t.Fatalf("PlanMigrations failed: %v %s", err, Plan.SchemaCheckError)
+		}
+		if Plan.CurrentVersion != schemaMigrations.Latest() || len(Plan.Pending) != 0 {
+			t.Errorf("after upgrading from %d, PlanMigrations = %+v, want no pending migrations", Version, Plan)
+		}
+		if len(Plan.SchemaDifferences) != 0 {
+			t.Errorf("after upgrading from %d, schema differs from a fresh install: %q", Version, Plan.SchemaDifferences)
+		}
+	}
+}
+
+func TestPlanMigrationsFindsSchemaDrift(t *testing.T) {
+	config.Configuration.DBFile = filepath.Join(t.TempDir(), "gib.db")
+	dbconformance.PrepareLogging()
+	DB := &SQLitePlugin{}
+	if err := DB.InitDatabase(); err != nil {
+		t.Fatalf("InitDatabase failed: %v", err)
+	}
+	defer DB.pool.Close()
+	if _, err := DB.pool.Exec("ALTER TABLE Images ADD COLUMN Stray BIGINT;"); err != nil {
+		t.Fatalf("failed to alter Images: %v", err)
+	}
+	Plan, err := DB.PlanMigrations(t.Context(), true)
+	if err != nil || Plan.SchemaCheckError != "" {
+		t.Fatalf("PlanMigrations failed: %v %s", err, Plan.SchemaCheckError)
+	}
+	if len(Plan.SchemaDifferences) != 1 {
+		t.Errorf("SchemaDifferences = %q, want the Stray column", Plan.SchemaDifferences)
+	}
+}
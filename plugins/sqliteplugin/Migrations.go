@@ -0,0 +1,217 @@
+package sqliteplugin
+
+import (
+	"go-image-board/plugins/migrations"
+)
+
+//schemaMigrations is the SQLite schema, InitDatabase applies whichever of these the database has not had yet, a fresh install applies all of them.
+//DDL is transactional in SQLite, so each migration runs in a transaction and a failed one leaves no partial changes behind.
+//To change the schema, append a migration with the next version, never edit one that has been released
+var schemaMigrations = migrations.NewSet(
+	migrations.Migration{
+		Version:     1,
+		Description: "Initial schema",
+		Statements: []string{
+			//DBVersion
+			"CREATE TABLE DBVersion (version BIGINT NOT NULL);",
+			"INSERT INTO DBVersion (version) VALUES (1);",
+			//Images and tags
+			"CREATE TABLE Tags (ID INTEGER PRIMARY KEY AUTOINCREMENT, Name VARCHAR(255) NOT NULL UNIQUE COLLATE NOCASE, Description VARCHAR(255), UploaderID BIGINT NOT NULL, UploadTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, AliasedID BIGINT NOT NULL DEFAULT 0, IsAlias BOOL NOT NULL DEFAULT FALSE);",
+			"CREATE TABLE Images (ID INTEGER PRIMARY KEY AUTOINCREMENT, UploaderID BIGINT NOT NULL, Name VARCHAR(255) NOT NULL, Rating VARCHAR(255) DEFAULT 'unrated', ScoreTotal BIGINT NOT NULL DEFAULT 0, ScoreAverage BIGINT NOT NULL DEFAULT 0, ScoreVoters BIGINT NOT NULL DEFAULT 0, Location VARCHAR(255) UNIQUE NOT NULL, Source VARCHAR(2000) NOT NULL DEFAULT '', UploadTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, Description TEXT NOT NULL DEFAULT '');",
+			"CREATE INDEX ImagesUploaderID ON Images (UploaderID);",
+			"CREATE INDEX ImagesRating ON Images (Rating);",
+			"CREATE INDEX ImagesUploadTime ON Images (UploadTime);",
+			"CREATE INDEX ImagesScoreAverage ON Images (ScoreAverage);",
+			"CREATE TABLE ImageTags (ID INTEGER PRIMARY KEY AUTOINCREMENT, ImageID BIGINT NOT NULL REFERENCES Images(ID), TagID BIGINT NOT NULL REFERENCES Tags(ID), LinkerID BIGINT NOT NULL, LinkTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UNIQUE (TagID, ImageID));",
+			"CREATE INDEX ImageTagsImageID ON ImageTags (ImageID);",
+			"CREATE INDEX ImageTagsLinkerID ON ImageTags (LinkerID);",
+			"CREATE TABLE ImagedHashes (ID INTEGER PRIMARY KEY AUTOINCREMENT, ImageID BIGINT NOT NULL UNIQUE REFERENCES Images(ID), vHash BIGINT NOT NULL, hHash BIGINT NOT NULL);",
+			"CREATE INDEX ImagedHashesvHash ON ImagedHashes (vHash);",
+			"CREATE INDEX ImagedHasheshHash ON ImagedHashes (hHash);",
+			"CREATE TABLE ImageUserScores (ID INTEGER PRIMARY KEY AUTOINCREMENT, UserID BIGINT NOT NULL, ImageID BIGINT NOT NULL, Score BIGINT NOT NULL, CreationTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UNIQUE (UserID, ImageID));",
+			//Users
+			"CREATE TABLE Users (ID INTEGER PRIMARY KEY AUTOINCREMENT, Name VARCHAR(40) NOT NULL UNIQUE COLLATE NOCASE, EMail VARCHAR(255) NOT NULL UNIQUE COLLATE NOCASE, PasswordHash VARCHAR(255) NOT NULL, TokenID VARCHAR(255), IP VARCHAR(50), SecQuestionOne VARCHAR(50), SecQuestionTwo VARCHAR(50), SecQuestionThree VARCHAR(50), SecAnswerOne VARCHAR(255), SecAnswerTwo VARCHAR(255), SecAnswerThree VARCHAR(255), CreationTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, Disabled BOOL NOT NULL DEFAULT FALSE, Permissions BIGINT NOT NULL DEFAULT 0, SearchFilter VARCHAR(255) NOT NULL DEFAULT '');",
+			//Reserve system for auditing
+			"INSERT INTO Users (ID, Name, EMail, PasswordHash, Disabled) VALUES (0, 'SYSTEM', '', '', TRUE);",
+			//Auditing
+			"CREATE TABLE AuditLogs (ID INTEGER PRIMARY KEY AUTOINCREMENT, UserID BIGINT NOT NULL, Type VARCHAR(40), Info VARCHAR(10240) NOT NULL DEFAULT '', LogTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL);",
+			"CREATE INDEX AuditLogsLogTime ON AuditLogs (LogTime);",
+			//Collections
+			"CREATE TABLE Collections (ID INTEGER PRIMARY KEY AUTOINCREMENT, Name VARCHAR(255) NOT NULL UNIQUE COLLATE NOCASE, Description VARCHAR(255), UploaderID BIGINT NOT NULL, UploadTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL);",
+			"CREATE TABLE CollectionMembers (ID INTEGER PRIMARY KEY AUTOINCREMENT, ImageID BIGINT NOT NULL REFERENCES Images(ID), CollectionID BIGINT NOT NULL REFERENCES Collections(ID), LinkerID BIGINT NOT NULL, LinkTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, OrderWeight BIGINT NOT NULL, UNIQUE (CollectionID, ImageID));",
+			"CREATE TABLE CollectionTags (ID INTEGER PRIMARY KEY AUTOINCREMENT, CollectionID BIGINT NOT NULL REFERENCES Collections(ID), TagID BIGINT NOT NULL REFERENCES Tags(ID), LinkerID BIGINT NOT NULL, LinkTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UNIQUE (TagID, CollectionID));",
+			//Triggers
+			//SQLite has no stored procedures, so the bodies of AddMissingCollectionImageTags and RemSurplusCollectionImageTags
+			//(which together do the work of LinkCollTags) are inlined into the triggers that call them in MariaDB
+			`CREATE TRIGGER onCollectionDelete BEFORE DELETE ON Collections
+		FOR EACH ROW BEGIN
+			DELETE FROM CollectionMembers WHERE CollectionID=OLD.ID;
+			DELETE FROM CollectionTags WHERE CollectionID=OLD.ID;
+		END;`,
+			`CREATE TRIGGER onCollectionMemberAdd AFTER INSERT ON CollectionMembers
+		FOR EACH ROW BEGIN
+			-- AddMissingCollectionImageTags(NEW.ImageID)
+			INSERT INTO CollectionTags (TagID, CollectionID, LinkerID)
+			SELECT DISTINCT ImageTags.TagID, CollectionMembers.CollectionID, ImageTags.LinkerID
+			FROM ImageTags
+			INNER JOIN CollectionMembers ON CollectionMembers.ImageID = ImageTags.ImageID
+			LEFT JOIN CollectionTags ON CollectionTags.CollectionID = CollectionMembers.CollectionID AND CollectionTags.TagID = ImageTags.TagID
+			WHERE CollectionTags.CollectionID IS NULL AND ImageTags.ImageID = NEW.ImageID
+			ON CONFLICT DO NOTHING;
+		END;`,
+			`CREATE TRIGGER onCollectionMemberDelete AFTER DELETE ON CollectionMembers
+		FOR EACH ROW BEGIN
+			-- RemSurplusCollectionImageTags(OLD.CollectionID)
+			DELETE FROM CollectionTags
+			WHERE TagID NOT IN ( SELECT TagID
+									FROM ImageTags
+									INNER JOIN CollectionMembers on CollectionMembers.ImageID = ImageTags.ImageID
+									WHERE CollectionMembers.CollectionID = OLD.CollectionID
+								)
+			AND CollectionID=OLD.CollectionID;
+		END;`,
+			`CREATE TRIGGER onImageTagDelete AFTER DELETE ON ImageTags
+		FOR EACH ROW BEGIN
+			-- RemSurplusCollectionImageTags for every collection containing OLD.ImageID
+			DELETE FROM CollectionTags
+			WHERE CollectionID IN (SELECT CollectionID FROM CollectionMembers WHERE ImageID = OLD.ImageID)
+			AND TagID NOT IN ( SELECT ImageTags.TagID
+									FROM ImageTags
+									INNER JOIN CollectionMembers on CollectionMembers.ImageID = ImageTags.ImageID
+									WHERE CollectionMembers.CollectionID = CollectionTags.CollectionID
+								);
+		END;`,
+			`CREATE TRIGGER onImageDelete BEFORE DELETE ON Images
+		FOR EACH ROW BEGIN
+			DELETE FROM ImageTags WHERE ImageID=OLD.ID;
+			DELETE FROM ImageUserScores WHERE ImageID=OLD.ID;
+			DELETE FROM CollectionMembers WHERE ImageID=OLD.ID;
+			DELETE FROM ImagedHashes WHERE ImageID=OLD.ID;
+		END;`,
+			`CREATE TRIGGER onImageTagInsert AFTER INSERT ON ImageTags
+		FOR EACH ROW BEGIN
+			-- AddMissingCollectionImageTags(NEW.ImageID)
+			INSERT INTO CollectionTags (TagID, CollectionID, LinkerID)
+			SELECT DISTINCT ImageTags.TagID, CollectionMembers.CollectionID, ImageTags.LinkerID
+			FROM ImageTags
+			INNER JOIN CollectionMembers ON CollectionMembers.ImageID = ImageTags.ImageID
+			LEFT JOIN CollectionTags ON CollectionTags.CollectionID = CollectionMembers.CollectionID AND CollectionTags.TagID = ImageTags.TagID
+			WHERE CollectionTags.CollectionID IS NULL AND ImageTags.ImageID = NEW.ImageID
+			ON CONFLICT DO NOTHING;
+		END;`,
+			`CREATE TRIGGER onTagDelete BEFORE DELETE ON Tags
+		FOR EACH ROW BEGIN
+			DELETE FROM ImageTags WHERE TagID=OLD.ID;
+			DELETE FROM CollectionTags WHERE TagID=OLD.ID;
+		END;`,
+		},
+	},
+	migrations.Migration{
+		Version:     2,
+		Description: "Image recycle bin",
+		Statements: []string{
+			"ALTER TABLE Images ADD COLUMN DeletedTime TIMESTAMP NULL;",
+			"ALTER TABLE Images ADD COLUMN DeletedBy BIGINT NOT NULL DEFAULT 0;",
+			"CREATE INDEX ImagesDeletedTime ON Images (DeletedTime);",
+			//Collection listings only count and preview images that are not in the recycle bin
+			"CREATE VIEW VisibleCollectionMembers AS SELECT CollectionMembers.* FROM CollectionMembers INNER JOIN Images ON Images.ID = CollectionMembers.ImageID WHERE Images.DeletedTime IS NULL;",
+		},
+	},
+	migrations.Migration{
+		Version:     3,
+		Description: "Image revision history",
+		Statements: []string{
+			"CREATE TABLE ImageRevisions (ID INTEGER PRIMARY KEY AUTOINCREMENT, ImageID BIGINT NOT NULL REFERENCES Images(ID), UserID BIGINT NOT NULL, ChangeTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, Field VARCHAR(40) NOT NULL, TagID BIGINT NOT NULL DEFAULT 0, OldValue TEXT NOT NULL, NewValue TEXT NOT NULL);",
+			"CREATE INDEX ImageRevisionsImageID ON ImageRevisions (ImageID);",
+			"CREATE INDEX ImageRevisionsUserID ON ImageRevisions (UserID, ChangeTime);",
+			"DROP TRIGGER onImageDelete;",
+			`CREATE TRIGGER onImageDelete BEFORE DELETE ON Images
+		FOR EACH ROW BEGIN
+			DELETE FROM ImageTags WHERE ImageID=OLD.ID;
+			DELETE FROM ImageUserScores WHERE ImageID=OLD.ID;
+			DELETE FROM CollectionMembers WHERE ImageID=OLD.ID;
+			DELETE FROM ImagedHashes WHERE ImageID=OLD.ID;
+			DELETE FROM ImageRevisions WHERE ImageID=OLD.ID;
+		END;`,
+		},
+	},
+	migrations.Migration{
+		Version:     4,
+		Description: "Collection changes in image revisions",
+		Statements: []string{
+			"ALTER TABLE ImageRevisions ADD COLUMN CollectionID BIGINT NOT NULL DEFAULT 0;",
+		},
+	},
+	migrations.Migration{
+		Version:     5,
+		Description: "Audit log search indexes",
+		Statements: []string{
+			"CREATE INDEX AuditLogsUserID ON AuditLogs (UserID, LogTime);",
+			"CREATE INDEX AuditLogsType ON AuditLogs (Type, LogTime);",
+		},
+	},
+	migrations.Migration{
+		Version:     6,
+		Description: "Typed audit events",
+		Statements: []string{
+			"ALTER TABLE AuditLogs RENAME COLUMN Type TO Action;",
+			"ALTER TABLE AuditLogs ADD COLUMN TargetType VARCHAR(20) NOT NULL DEFAULT '';",
+			"ALTER TABLE AuditLogs ADD COLUMN TargetID BIGINT NOT NULL DEFAULT 0;",
+			"ALTER TABLE AuditLogs ADD COLUMN Details TEXT NOT NULL DEFAULT '{}';",
+			"UPDATE AuditLogs SET Details = json_object('Info', Info) WHERE Info <> '';",
+			"ALTER TABLE AuditLogs DROP COLUMN Info;",
+			"DROP INDEX AuditLogsType;",
+			"CREATE INDEX AuditLogsAction ON AuditLogs (Action, LogTime);",
+			"CREATE INDEX AuditLogsTarget ON AuditLogs (TargetType, TargetID, LogTime);",
+		},
+	},
+	migrations.Migration{
+		Version:     7,
+		Description: "Background job queue",
+		Statements: []string{
+			"CREATE TABLE Jobs (ID INTEGER PRIMARY KEY AUTOINCREMENT, Type VARCHAR(20) NOT NULL, ImageID BIGINT NOT NULL, Status VARCHAR(20) NOT NULL DEFAULT 'PENDING', Attempts BIGINT NOT NULL DEFAULT 0, LastError TEXT NOT NULL DEFAULT '', RunAfter TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, CreatedTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UpdatedTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, UNIQUE (Type, ImageID));",
+			"CREATE INDEX JobsStatus ON Jobs (Status, RunAfter);",
+		},
+	},
+	migrations.Migration{
+		Version:     8,
+		Description: "Image metadata",
+		Statements: []string{
+			"ALTER TABLE Images ADD COLUMN Width BIGINT NOT NULL DEFAULT 0;",
+			"ALTER TABLE Images ADD COLUMN Height BIGINT NOT NULL DEFAULT 0;",
+			"ALTER TABLE Images ADD COLUMN FileSize BIGINT NOT NULL DEFAULT 0;",
+			"ALTER TABLE Images ADD COLUMN MIMEType VARCHAR(255) NOT NULL DEFAULT '';",
+			"ALTER TABLE Images ADD COLUMN Duration REAL NOT NULL DEFAULT 0;",
+			"ALTER TABLE Images ADD COLUMN Codec VARCHAR(255) NOT NULL DEFAULT '';",
+		},
+	},
+	migrations.Migration{
+		Version:     9,
+		Description: "Image metadata search indexes",
+		Statements: []string{
+			"CREATE INDEX ImagesWidth ON Images (Width);",
+			"CREATE INDEX ImagesHeight ON Images (Height);",
+			"CREATE INDEX ImagesFileSize ON Images (FileSize);",
+			"CREATE INDEX ImagesMIMEType ON Images (MIMEType);",
+			"CREATE INDEX ImagesDuration ON Images (Duration);",
+		},
+	},
+	migrations.Migration{
+		Version:     10,
+		Description: "Saved searches",
+		Statements: []string{
+			"CREATE TABLE SavedSearches (ID INTEGER PRIMARY KEY AUTOINCREMENT, UserID BIGINT NOT NULL REFERENCES Users(ID), Name VARCHAR(40) NOT NULL, Query TEXT NOT NULL, UNIQUE (UserID, Name));",
+			`CREATE TRIGGER onUserDelete BEFORE DELETE ON Users
+		FOR EACH ROW BEGIN
+			DELETE FROM SavedSearches WHERE UserID=OLD.ID;
+		END;`,
+		},
+	},
+	migrations.Migration{
+		Version:     11,
+		Description: "Job reruns",
+		Statements: []string{
+			"ALTER TABLE Jobs ADD COLUMN Rerun BOOLEAN NOT NULL DEFAULT FALSE;",
+		},
+	},
+)
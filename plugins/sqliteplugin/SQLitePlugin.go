@@ -0,0 +1,99 @@
+package sqliteplugin
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"go-image-board/config"
+	"go-image-board/logging"
+	"go-image-board/plugins/migrations"
+	"math/bits"
+	"net/url"
+	"strconv"
+
+	"math/rand"
+	"time"
+
+	//Pure go driver, so no cgo is required to build gib
+	"modernc.org/sqlite"
+)
+
+//TODO: Increment this when we alter the db schema and don't add a migration to compensate
+var minSupportedDBVersion int64 // 0 by default
+
+//SQLitePlugin acts as plugin between gib and a SQLite database file
+type SQLitePlugin struct {
+	DBHandle sqlHandle
+	//pool is the connection pool, DBHandle is either pool or a transaction on it
+	pool *sql.DB
+}
+
+func init() {
+	//SQLite has no BIT_COUNT, which is needed for the similar: tag, so provide one
+	sqlite.MustRegisterDeterministicScalarFunction("BIT_COUNT", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		switch value := args[0].(type) {
+		case int64:
+			return int64(bits.OnesCount64(uint64(value))), nil
+		case nil:
+			return nil, nil
+		}
+		return nil, errors.New("BIT_COUNT requires an integer argument")
+	})
+}
+
+//InitDatabase opens the database file, and if needed, creates and or updates tables
+func (DBConnection *SQLitePlugin) InitDatabase() error {
+	rand.Seed(time.Now().UnixNano())
+	if err := DBConnection.connect(); err != nil {
+		return err
+	}
+	version, err := DBConnection.getDatabaseVersion()
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "SQLitePlugin/InitDatabase", "0", logging.ResultFailure, []string{"Failed to get database version, assuming not installed. Will attempt to perform install.", err.Error()})
+		//Assume no database installed. Perform fresh install
+		version = migrations.NotInstalled
+	} else {
+		logging.WriteLog(logging.LogLevelError, "SQLitePlugin/InitDatabase", "0", logging.ResultInfo, []string{"DBVersion is " + strconv.FormatInt(version, 10)})
+		if version < minSupportedDBVersion {
+			return errors.New("database version is not supported and no update code was found to bring database up to current version")
+		}
+	}
+	if _, err := schemaMigrations.Apply(context.Background(), DBConnection.pool, version, migrations.NotInstalled, "SQLitePlugin/InitDatabase"); err != nil {
+		return err
+	}
+	return nil
+}
+
+//connect opens the connection pool to the configured database file
+func (DBConnection *SQLitePlugin) connect() error {
+	pool, err := openDatabase(config.Configuration.DBFile)
+	if err != nil {
+		return err
+	}
+	DBConnection.pool = pool
+	DBConnection.DBHandle = pool
+	return nil
+}
+
+//openDatabase opens and pings a connection pool to the database file at Path
+func openDatabase(Path string) (*sql.DB, error) {
+	//https://pkg.go.dev/modernc.org/sqlite#Driver.Open
+	pool, err := sql.Open("sqlite", "file:"+Path+"?_pragma="+url.QueryEscape("busy_timeout(10000)")+"&_pragma="+url.QueryEscape("journal_mode(WAL)"))
+	if err != nil {
+		return nil, err
+	}
+	//Ping actually validates we can query database
+	if err := pool.Ping(); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (DBConnection *SQLitePlugin) getDatabaseVersion() (int64, error) {
+	var version int64
+	row := DBConnection.DBHandle.QueryRow("SELECT version FROM DBVersion")
+	err := row.Scan(&version)
+	return version, err
+}
@@ -0,0 +1,71 @@
+package sqliteplugin
+
+import (
+	"go-image-board/config"
+	"go-image-board/interfaces"
+	"go-image-board/plugins/dbconformance"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	config.Configuration.DBFile = filepath.Join(t.TempDir(), "gib.db")
+	dbconformance.PrepareLogging()
+	DB := &SQLitePlugin{}
+	if err := DB.InitDatabase(); err != nil {
+		t.Fatalf("InitDatabase failed: %v", err)
+	}
+	defer DB.pool.Close()
+	dbconformance.RunSuite(t, DB)
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	dbconformance.PrepareLogging()
+	Source, Fresh := &SQLitePlugin{}, &SQLitePlugin{}
+	for Index, DB := range []*SQLitePlugin{Source, Fresh} {
+		config.Configuration.DBFile = filepath.Join(t.TempDir(), "gib"+strconv.Itoa(Index)+".db")
+		if err := DB.InitDatabase(); err != nil {
+			t.Fatalf("InitDatabase failed: %v", err)
+		}
+		defer DB.pool.Close()
+	}
+	dbconformance.RunBackupRoundTrip(t, Source, Fresh)
+}
+
+//TestOrphanedCollectionMembers covers members left behind by a deleted image, SQLite does not enforce foreign keys so they can be made directly
+func TestOrphanedCollectionMembers(t *testing.T) {
+	ctx := t.Context()
+	config.Configuration.DBFile = filepath.Join(t.TempDir(), "gib.db")
+	dbconformance.PrepareLogging()
+	DB := &SQLitePlugin{}
+	if err := DB.InitDatabase(); err != nil {
+		t.Fatalf("InitDatabase failed: %v", err)
+	}
+	defer DB.pool.Close()
+	ImageID, err := DB.NewImage(ctx, "orphan", "orphan.png", 1, "")
+	if err != nil {
+		t.Fatalf("NewImage failed: %v", err)
+	}
+	CollectionID, err := DB.NewCollection(ctx, "orphans", "", 1)
+	if err != nil {
+		t.Fatalf("NewCollection failed: %v", err)
+	}
+	if err := DB.AddCollectionMember(ctx, CollectionID, []uint64{ImageID}, 1); err != nil {
+		t.Fatalf("AddCollectionMember failed: %v", err)
+	}
+	if _, err := DB.DBHandle.ExecContext(ctx, "INSERT INTO CollectionMembers (ImageID, CollectionID, LinkerID, OrderWeight) VALUES (?, ?, 1, 1);", ImageID+1000, CollectionID); err != nil {
+		t.Fatalf("Failed to add orphaned member: %v", err)
+	}
+	Want := []interfaces.CollectionMember{{CollectionID: CollectionID, ImageID: ImageID + 1000}}
+	if Orphans, err := DB.GetOrphanedCollectionMembers(ctx); err != nil || slices.Equal(Orphans, Want) == false {
+		t.Fatalf("GetOrphanedCollectionMembers = %+v, %v, want %+v", Orphans, err, Want)
+	}
+	if err := DB.RemoveCollectionMember(ctx, CollectionID, ImageID+1000); err != nil {
+		t.Fatalf("RemoveCollectionMember failed: %v", err)
+	}
+	if Orphans, err := DB.GetOrphanedCollectionMembers(ctx); err != nil || len(Orphans) != 0 {
+		t.Errorf("GetOrphanedCollectionMembers after removal = %+v, %v, want none", Orphans, err)
+	}
+}
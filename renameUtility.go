@@ -1,18 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"go-image-board/config"
 	"go-image-board/database"
+	"go-image-board/interfaces"
 	"go-image-board/logging"
 	"go-image-board/routers"
-	"os"
-	"path"
-	"path/filepath"
+	"go-image-board/storage"
+	"io/fs"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 func renameAllImages() {
-	_, maxCount, err := database.DBInterface.SearchImages(nil, 0, config.Configuration.PageStride)
+	_, maxCount, err := database.DBInterface.SearchImages(context.Background(), nil, 0, config.Configuration.PageStride)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "renameUtility/renameAllImages", "0", logging.ResultFailure, []string{"Failed to query for images", err.Error()})
 		return
@@ -21,7 +25,7 @@ func renameAllImages() {
 	//Loop through the images one page at a time
 	for count := uint64(0); count < maxCount; count += config.Configuration.PageStride {
 		logging.WriteLog(logging.LogLevelInfo, "renameUtility/renameAllImages", "0", logging.ResultInfo, []string{"Processing at", strconv.FormatUint(count, 10)})
-		images, _, err := database.DBInterface.SearchImages(nil, count, config.Configuration.PageStride)
+		images, _, err := database.DBInterface.SearchImages(context.Background(), nil, count, config.Configuration.PageStride)
 		if err != nil {
 			logging.WriteLog(logging.LogLevelCritical, "renameUtility/renameAllImages", "0", logging.ResultFailure, []string{"Failed to query for images", err.Error()})
 			return
@@ -29,7 +33,7 @@ func renameAllImages() {
 		//Loop through the images in this page
 		for _, imageInfo := range images {
 			//Open file for reading
-			fileStream, err := os.Open(path.Join(config.Configuration.ImageDirectory, imageInfo.Location))
+			fileStream, err := storage.BlobStore.Open(context.Background(), imageInfo.Location)
 			if err != nil {
 				logging.WriteLog(logging.LogLevelCritical, "renameUtility/renameAllImages", "0", logging.ResultFailure, []string{"Failed to open file", err.Error()})
 				return
@@ -47,24 +51,24 @@ func renameAllImages() {
 				continue //Skip if same name
 			}
 			//Rename image
-			if err := os.Rename(path.Join(config.Configuration.ImageDirectory, imageInfo.Location), path.Join(config.Configuration.ImageDirectory, newName)); err != nil {
+			if err := storage.BlobStore.Rename(context.Background(), imageInfo.Location, newName); err != nil {
 				logging.WriteLog(logging.LogLevelCritical, "renameUtility/renameAllImages", "0", logging.ResultFailure, []string{"Error renaming file", err.Error()})
 				return //On error cancel out to keep db and image in sync
 			}
 			//Rename thumbnail
-			if err := os.Rename(path.Join(config.Configuration.ImageDirectory, "thumbs"+string(filepath.Separator)+imageInfo.Location+".png"), path.Join(config.Configuration.ImageDirectory, "thumbs"+string(filepath.Separator)+newName+".png")); err != nil {
+			if err := storage.BlobStore.Rename(context.Background(), routers.ThumbnailName(imageInfo.Location), routers.ThumbnailName(newName)); err != nil {
 				logging.WriteLog(logging.LogLevelError, "renameUtility/renameAllImages", "0", logging.ResultFailure, []string{"Error renaming file", err.Error()})
 			}
 			//Update database
-			if err := database.DBInterface.UpdateImage(imageInfo.ID, nil, nil, nil, nil, nil, newName); err != nil {
+			if err := database.DBInterface.UpdateImage(context.Background(), imageInfo.ID, nil, nil, nil, nil, nil, newName); err != nil {
 				//Rollback and cancel on error
 				logging.WriteLog(logging.LogLevelError, "renameUtility/renameAllImages", "0", logging.ResultFailure, []string{"Error adding renamed image to db, cancelling", err.Error()})
 				//Rename thumbnail
-				if err := os.Rename(path.Join(config.Configuration.ImageDirectory, "thumbs"+string(filepath.Separator)+newName+".png"), path.Join(config.Configuration.ImageDirectory, "thumbs"+string(filepath.Separator)+imageInfo.Location+".png")); err != nil {
+				if err := storage.BlobStore.Rename(context.Background(), routers.ThumbnailName(newName), routers.ThumbnailName(imageInfo.Location)); err != nil {
 					logging.WriteLog(logging.LogLevelError, "renameUtility/renameAllImages", "0", logging.ResultFailure, []string{"Error renaming file", err.Error()})
 				}
 				//Rename image
-				if err := os.Rename(path.Join(config.Configuration.ImageDirectory, newName), path.Join(config.Configuration.ImageDirectory, imageInfo.Location)); err != nil {
+				if err := storage.BlobStore.Rename(context.Background(), newName, imageInfo.Location); err != nil {
 					logging.WriteLog(logging.LogLevelError, "renameUtility/renameAllImages", "0", logging.ResultFailure, []string{"Error renaming file", err.Error()})
 				}
 				return
@@ -73,3 +77,103 @@ func renameAllImages() {
 		}
 	}
 }
+
+//getAllImages returns every image, including those in the recycle bin
+func getAllImages(ctx context.Context) ([]interfaces.ImageInformation, error) {
+	var ToReturn []interfaces.ImageInformation
+	for _, getPage := range []func(PageStart uint64) ([]interfaces.ImageInformation, uint64, error){
+		func(PageStart uint64) ([]interfaces.ImageInformation, uint64, error) {
+			return database.DBInterface.SearchImages(ctx, nil, PageStart, config.Configuration.PageStride)
+		},
+		func(PageStart uint64) ([]interfaces.ImageInformation, uint64, error) {
+			return database.DBInterface.GetTrashedImages(ctx, PageStart, config.Configuration.PageStride)
+		},
+	} {
+		for count, maxCount := uint64(0), uint64(1); count < maxCount; count += config.Configuration.PageStride {
+			images, total, err := getPage(count)
+			if err != nil {
+				return nil, err
+			}
+			maxCount = total
+			ToReturn = append(ToReturn, images...)
+		}
+	}
+	return ToReturn, nil
+}
+
+//flatHashName matches files named after their hash that are still in the flat layout used before files were put in subdirectories
+var flatHashName = regexp.MustCompile(`^[0-9a-f]{64}(\.[^/]*)?$`)
+
+//shardAllImages moves every image still in the flat layout, along with its thumbnail, into the subdirectories given by routers.ShardedImageName and corrects its location in the database.
+//Images in the recycle bin are moved as well. It is safe to run again after being interrupted
+func shardAllImages() {
+	ctx := context.Background()
+	//Gather every image first, so that changing locations cannot move images between pages
+	images, err := getAllImages(ctx)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelCritical, "renameUtility/shardAllImages", "0", logging.ResultFailure, []string{"Failed to query for images", err.Error()})
+		return
+	}
+	var toMove []interfaces.ImageInformation
+	for _, imageInfo := range images {
+		if strings.Contains(imageInfo.Location, "/") == false {
+			toMove = append(toMove, imageInfo)
+		}
+	}
+	logging.WriteLog(logging.LogLevelInfo, "renameUtility/shardAllImages", "0", logging.ResultInfo, []string{"Images to move", strconv.Itoa(len(toMove))})
+
+	moved := 0
+	for _, imageInfo := range toMove {
+		//Files named by an older naming convention are hashed to get their new name
+		var newName string
+		if flatHashName.MatchString(imageInfo.Location) {
+			newName = routers.ShardedImageName(imageInfo.Location)
+		} else {
+			fileStream, err := storage.BlobStore.Open(ctx, imageInfo.Location)
+			if err == nil {
+				newName, err = routers.GetNewImageName(imageInfo.Location, fileStream)
+				fileStream.Close()
+			}
+			if err != nil {
+				logging.WriteLog(logging.LogLevelError, "renameUtility/shardAllImages", "0", logging.ResultFailure, []string{"Failed to hash file, it will be skipped", imageInfo.Location, err.Error()})
+				continue
+			}
+		}
+
+		//An interrupted run may have moved the files without updating the database
+		_, err := storage.BlobStore.Stat(ctx, imageInfo.Location)
+		if errors.Is(err, fs.ErrNotExist) {
+			if _, err := storage.BlobStore.Stat(ctx, newName); err != nil {
+				logging.WriteLog(logging.LogLevelError, "renameUtility/shardAllImages", "0", logging.ResultFailure, []string{"File is missing, it will be skipped", imageInfo.Location})
+				continue
+			}
+		} else if err != nil {
+			logging.WriteLog(logging.LogLevelCritical, "renameUtility/shardAllImages", "0", logging.ResultFailure, []string{"Failed to stat file", imageInfo.Location, err.Error()})
+			return
+		} else {
+			//Move image
+			if err := storage.BlobStore.Rename(ctx, imageInfo.Location, newName); err != nil {
+				logging.WriteLog(logging.LogLevelCritical, "renameUtility/shardAllImages", "0", logging.ResultFailure, []string{"Error moving file", imageInfo.Location, err.Error()})
+				return //On error cancel out to keep db and image in sync
+			}
+		}
+		//Move thumbnail, a missing one can be made again with -thumbsonly -missingonly
+		if err := storage.BlobStore.Rename(ctx, routers.ThumbnailName(imageInfo.Location), routers.ThumbnailName(newName)); err != nil && errors.Is(err, fs.ErrNotExist) == false {
+			logging.WriteLog(logging.LogLevelError, "renameUtility/shardAllImages", "0", logging.ResultFailure, []string{"Error moving thumbnail", imageInfo.Location, err.Error()})
+		}
+		//Update database
+		if err := database.DBInterface.UpdateImage(ctx, imageInfo.ID, nil, nil, nil, nil, nil, newName); err != nil {
+			//Rollback and cancel on error
+			logging.WriteLog(logging.LogLevelCritical, "renameUtility/shardAllImages", "0", logging.ResultFailure, []string{"Error updating location in db, cancelling", imageInfo.Location, err.Error()})
+			if err := storage.BlobStore.Rename(ctx, routers.ThumbnailName(newName), routers.ThumbnailName(imageInfo.Location)); err != nil && errors.Is(err, fs.ErrNotExist) == false {
+				logging.WriteLog(logging.LogLevelError, "renameUtility/shardAllImages", "0", logging.ResultFailure, []string{"Error moving thumbnail back", err.Error()})
+			}
+			if err := storage.BlobStore.Rename(ctx, newName, imageInfo.Location); err != nil {
+				logging.WriteLog(logging.LogLevelError, "renameUtility/shardAllImages", "0", logging.ResultFailure, []string{"Error moving file back", err.Error()})
+			}
+			return
+		}
+		moved++
+	}
+	logging.WriteLog(logging.LogLevelInfo, "renameUtility/shardAllImages", "0", logging.ResultSuccess, []string{"Finished moving " + strconv.Itoa(moved) + " images."})
+}
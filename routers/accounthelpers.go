@@ -28,7 +28,7 @@ func getSessionInformation(request *http.Request) (string, string, *sessions.Ses
 	userName = strings.ToLower(userName)
 	tokenID, _ := session.Values["TokenID"].(string)
 	ip, _, _ := net.SplitHostPort(request.RemoteAddr)
-	if err := database.DBInterface.ValidateToken(userName, tokenID, ip); err != nil {
+	if err := database.DBInterface.ValidateToken(request.Context(), userName, tokenID, ip); err != nil {
 		return "", "", session
 	}
 	return userName, tokenID, session
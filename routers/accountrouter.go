@@ -3,6 +3,7 @@ package routers
 import (
 	"go-image-board/config"
 	"go-image-board/database"
+	"go-image-board/interfaces"
 	"go-image-board/logging"
 	"html/template"
 	"net"
@@ -17,12 +18,12 @@ func LogonGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	//If logged in
 	if TemplateInput.IsLoggedOn() {
 		//Get question for challenge
-		oldQOne, _, _, err := database.DBInterface.GetSecurityQuestions(TemplateInput.UserInformation.Name)
+		oldQOne, _, _, err := database.DBInterface.GetSecurityQuestions(request.Context(), TemplateInput.UserInformation.Name)
 		if err == nil && oldQOne != "" {
 			TemplateInput.QuestionOne = oldQOne
 		}
 		//Get user filter
-		TemplateInput.UserFilter, _ = database.DBInterface.GetUserFilter(TemplateInput.UserInformation.ID)
+		TemplateInput.UserFilter, _ = database.DBInterface.GetUserFilter(request.Context(), TemplateInput.UserInformation.ID)
 	}
 
 	//Grab user query information
@@ -38,13 +39,13 @@ func LogonGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
 		//But only wipe token from DB if session info was correct
 		//The idea is to prevent a DOS in the event someone constructs an invalid session
 		if tokenID != "" {
-			err := database.DBInterface.RevokeToken(userName)
+			err := database.DBInterface.RevokeToken(request.Context(), userName)
 			if err != nil {
 				logging.WriteLog(logging.LogLevelError, "accountrouter/LogonRouter", userName, logging.ResultFailure, []string{"Account logout requested but error occured during token removal", err.Error()})
 			}
 		}
 
-		go WriteAuditLogByName(userName, "LOGOUT", userName+" manually logged out.")
+		go WriteAuditLogByName(request.Context(), userName, interfaces.AuditLogout, interfaces.AuditTargetNone, 0, nil)
 		TemplateInput.HTMLMessage += template.HTML("Successfully logged out.<br>")
 		TemplateInput.UserInformation.ID = 0
 		TemplateInput.UserInformation.Name = ""
@@ -64,7 +65,7 @@ func LogonGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
 		}
 
 		//We are in a "GET" only method, so return questions and let them POST answers
-		questionOne, questionTwo, questionThree, err := database.DBInterface.GetSecurityQuestions(userName)
+		questionOne, questionTwo, questionThree, err := database.DBInterface.GetSecurityQuestions(request.Context(), userName)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Could not load security questions. Please contact site owner.<br>")
 			logging.WriteLog(logging.LogLevelError, "accountrouter/LogonRouter", userName, logging.ResultFailure, []string{"Security Questions not found for user", userName})
@@ -93,13 +94,13 @@ func LogonPostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		username := strings.ToLower(request.FormValue("userName"))
 		ip, _, _ := net.SplitHostPort(request.RemoteAddr)
 		if username != "" && request.FormValue("password") != "" {
-			err := database.DBInterface.ValidateUser(username, []byte(request.FormValue("password")))
+			err := database.DBInterface.ValidateUser(request.Context(), username, []byte(request.FormValue("password")))
 			if err == nil {
 				//Get Session
 				_, _, session := getSessionInformation(request)
 
 				// Set some session values.
-				Token, err := database.DBInterface.GenerateToken(username, ip)
+				Token, err := database.DBInterface.GenerateToken(request.Context(), username, ip)
 				if err != nil {
 					logging.WriteLog(logging.LogLevelError, "accountrouter/LogonRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Account Validation", err.Error()})
 					TemplateInput.HTMLMessage += template.HTML("Token Failure.<br>")
@@ -110,12 +111,12 @@ func LogonPostRouter(responseWriter http.ResponseWriter, request *http.Request)
 				session.Values["UserName"] = username
 				// Save it before we write to the response/return from the handler.
 				session.Save(request, responseWriter)
-				go WriteAuditLogByName(username, "LOGON", username+" successfully logged on.")
+				go WriteAuditLogByName(request.Context(), username, interfaces.AuditLogon, interfaces.AuditTargetNone, 0, nil)
 				logging.WriteLog(logging.LogLevelInfo, "accountrouter/LogonRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultSuccess, []string{"Account Validation"})
 				redirectWithFlash(responseWriter, request, "/images", TemplateInput.HTMLMessage, "LogonSucceeded")
 				return
 			}
-			go WriteAuditLogByName(username, "LOGON", username+" failed to log in. "+err.Error())
+			go WriteAuditLogByName(request.Context(), username, interfaces.AuditLogon, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"UserName": username, "Error": err.Error()})
 			TemplateInput.HTMLMessage += template.HTML("Wrong username or password.<br>")
 			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "LogonFailed")
 			return
@@ -154,9 +155,9 @@ func LogonPostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "AccountFailed")
 			return
 		}
-		err := database.DBInterface.CreateUser(username, []byte(request.FormValue("password")), strings.ToLower(request.FormValue("eMail")), config.Configuration.DefaultPermissions)
+		err := database.DBInterface.CreateUser(request.Context(), username, []byte(request.FormValue("password")), strings.ToLower(request.FormValue("eMail")), config.Configuration.DefaultPermissions)
 		if err == nil {
-			go WriteAuditLogByName(username, "ACCOUNT-CREATED", username+" successfully created an account.")
+			go WriteAuditLogByName(request.Context(), username, interfaces.AuditAccountCreate, interfaces.AuditTargetNone, 0, nil)
 			TemplateInput.HTMLMessage += template.HTML("Your account has been created. Please sign in.<br>")
 			TemplateInput.UserInformation.ID = 0
 			TemplateInput.UserInformation.Name = ""
@@ -178,13 +179,13 @@ func LogonPostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		//But only wipe token from DB if session info was correct
 		//The idea is to prevent a DOS in the event someone constructs an invalid session
 		if tokenID != "" {
-			err := database.DBInterface.RevokeToken(userName)
+			err := database.DBInterface.RevokeToken(request.Context(), userName)
 			if err != nil {
 				logging.WriteLog(logging.LogLevelError, "accountrouter/LogonRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Account logout requested but error occured during token removal", err.Error()})
 			}
 		}
 
-		go WriteAuditLogByName(userName, "LOGOUT", userName+" manually logged out.")
+		go WriteAuditLogByName(request.Context(), userName, interfaces.AuditLogout, interfaces.AuditTargetNone, 0, nil)
 		TemplateInput.HTMLMessage += template.HTML("Successfully logged out.<br>")
 		TemplateInput.UserInformation.ID = 0
 		TemplateInput.UserInformation.Name = ""
@@ -232,23 +233,23 @@ func LogonPostRouter(responseWriter http.ResponseWriter, request *http.Request)
 
 		//Validate questions/answers in DB
 		//Call verify in DB
-		err := database.DBInterface.ValidateSecurityQuestions(userName, []byte(answerOne), []byte(answerTwo), []byte(answerThree))
+		err := database.DBInterface.ValidateSecurityQuestions(request.Context(), userName, []byte(answerOne), []byte(answerTwo), []byte(answerThree))
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to validate answers.<br>")
-			go WriteAuditLogByName(userName, "PASSWORD-RESET", userName+" failed to reset password, security answers incorrect.")
+			go WriteAuditLogByName(request.Context(), userName, interfaces.AuditPasswordReset, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"UserName": userName, "Error": "security answers incorrect"})
 			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "PasswordFailed")
 			return
 		}
 
 		//Call change pw
-		err = database.DBInterface.SetUserPassword(userName, nil, []byte(request.FormValue("newpassword")), []byte(answerOne), []byte(answerTwo), []byte(answerThree), false)
+		err = database.DBInterface.SetUserPassword(request.Context(), userName, nil, []byte(request.FormValue("newpassword")), []byte(answerOne), []byte(answerTwo), []byte(answerThree), false)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to change password.<br>")
-			go WriteAuditLogByName(userName, "PASSWORD-RESET", userName+" failed to reset password. "+err.Error())
+			go WriteAuditLogByName(request.Context(), userName, interfaces.AuditPasswordReset, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "PasswordFailed")
 			return
 		}
-		go WriteAuditLogByName(userName, "PASSWORD-RESET", userName+" reset password successfully by security question challenge.")
+		go WriteAuditLogByName(request.Context(), userName, interfaces.AuditPasswordReset, interfaces.AuditTargetNone, 0, nil)
 		TemplateInput.HTMLMessage += template.HTML("Successfully set password.<br>")
 		redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "PasswordSucceeded")
 		return
@@ -265,25 +266,25 @@ func LogonPostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		}
 		//Validate user is who they are by running validation like logging in
 		if username != "" && request.FormValue("oldpassword") != "" && database.DBInterface.ValidateProposedUsername(username) == nil {
-			err := database.DBInterface.ValidateUser(username, []byte(request.FormValue("oldpassword")))
+			err := database.DBInterface.ValidateUser(request.Context(), username, []byte(request.FormValue("oldpassword")))
 			if err != nil {
-				go WriteAuditLogByName(username, "PASSWORD-SET", username+" failed to set password. "+err.Error())
+				go WriteAuditLogByName(request.Context(), username, interfaces.AuditPasswordSet, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"UserName": username, "Error": err.Error()})
 				TemplateInput.HTMLMessage += template.HTML("Either username or password incorrect.<br>")
 				redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "PasswordFailed")
 				return
 			}
 		}
 
-		err := database.DBInterface.SetUserPassword(username, []byte(request.FormValue("oldpassword")), []byte(request.FormValue("newpassword")), nil, nil, nil, false)
+		err := database.DBInterface.SetUserPassword(request.Context(), username, []byte(request.FormValue("oldpassword")), []byte(request.FormValue("newpassword")), nil, nil, nil, false)
 		if err != nil {
-			go WriteAuditLogByName(username, "PASSWORD-SET", username+" failed to set password. "+err.Error())
+			go WriteAuditLogByName(request.Context(), username, interfaces.AuditPasswordSet, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": err.Error()})
 			TemplateInput.HTMLMessage += template.HTML("Failed to update password: " + err.Error() + ".<br>")
 			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "PasswordFailed")
 			return
 		}
 		//Success if we hit this point
 		//Clear session info to force login
-		err = database.DBInterface.RevokeToken(username)
+		err = database.DBInterface.RevokeToken(request.Context(), username)
 		if err != nil {
 			logging.WriteLog(logging.LogLevelError, "accountrouter/LogonRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Account logout after password change attempted but error occured during token removal", err.Error()})
 		}
@@ -292,7 +293,7 @@ func LogonPostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		session.Values["TokenID"] = ""
 		session.Values["UserName"] = ""
 		session.Save(request, responseWriter)
-		go WriteAuditLogByName(username, "PASSWORD-SET", username+" successfully set password by old password challenge. ")
+		go WriteAuditLogByName(request.Context(), username, interfaces.AuditPasswordSet, interfaces.AuditTargetNone, 0, nil)
 		TemplateInput.HTMLMessage += template.HTML("Your password was changed successfully. Please log in again.<br>")
 		redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "PasswordSucceeded")
 		return
@@ -301,17 +302,17 @@ func LogonPostRouter(responseWriter http.ResponseWriter, request *http.Request)
 
 		if !TemplateInput.IsLoggedOn() {
 			TemplateInput.HTMLMessage += template.HTML("You must be logged in to perform this action.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "QUESTION-SET", TemplateInput.UserInformation.Name+" failed to set questions. Not logged in.")
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditQuestionSet, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": "not logged in"})
 			logging.WriteLog(logging.LogLevelError, "accountrouter/LogonRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"User not logged in"})
 			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "QuestionFailed")
 			return
 		}
 
 		//Verify password confirmation
-		err := database.DBInterface.ValidateUser(TemplateInput.UserInformation.Name, []byte(request.FormValue("confirmpassword")))
+		err := database.DBInterface.ValidateUser(request.Context(), TemplateInput.UserInformation.Name, []byte(request.FormValue("confirmpassword")))
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Password confirmation failed, please try again.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "QUESTION-SET", TemplateInput.UserInformation.Name+" failed to set questions. "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditQuestionSet, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "QuestionFailed")
 			return
 		}
@@ -335,7 +336,7 @@ func LogonPostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		}
 
 		//Verify Challenge
-		oldQOne, _, _, err := database.DBInterface.GetSecurityQuestions(TemplateInput.UserInformation.Name)
+		oldQOne, _, _, err := database.DBInterface.GetSecurityQuestions(request.Context(), TemplateInput.UserInformation.Name)
 		if err == nil && oldQOne != "" && answerChallenge == "" {
 			TemplateInput.HTMLMessage += template.HTML("You must answer the challenge question.<br>")
 			logging.WriteLog(logging.LogLevelError, "accountrouter/LogonRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Security Question not set as user did not answer challenge"})
@@ -345,14 +346,14 @@ func LogonPostRouter(responseWriter http.ResponseWriter, request *http.Request)
 
 		//Set questions in db
 		//Call change in DB once implemented
-		err = database.DBInterface.SetSecurityQuestions(TemplateInput.UserInformation.Name, questionOne, questionTwo, questionThree, []byte(answerOne), []byte(answerTwo), []byte(answerThree), []byte(answerChallenge))
+		err = database.DBInterface.SetSecurityQuestions(request.Context(), TemplateInput.UserInformation.Name, questionOne, questionTwo, questionThree, []byte(answerOne), []byte(answerTwo), []byte(answerThree), []byte(answerChallenge))
 		if err != nil {
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "QUESTION-SET", TemplateInput.UserInformation.Name+" failed to set questions. "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditQuestionSet, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": err.Error()})
 			TemplateInput.HTMLMessage += template.HTML("Failed to set questions.<br>")
 			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "QuestionFailed")
 			return
 		}
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "QUESTION-SET", TemplateInput.UserInformation.Name+" successfully set questions with password challenge. ")
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditQuestionSet, interfaces.AuditTargetNone, 0, nil)
 		TemplateInput.HTMLMessage += template.HTML("Successfully set questions.<br>")
 		redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "QuestionSucceed")
 		return
@@ -364,18 +365,60 @@ func LogonPostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "LogonRequired")
 			return
 		}
-		err := database.DBInterface.SetUserQueryTags(TemplateInput.UserInformation.ID, request.FormValue("filter"))
+		err := database.DBInterface.SetUserQueryTags(request.Context(), TemplateInput.UserInformation.ID, request.FormValue("filter"))
 		if err != nil {
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "FILTER-SET", TemplateInput.UserInformation.Name+" failed to set filter. "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditFilterSet, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Filter": request.FormValue("filter"), "Error": err.Error()})
 			TemplateInput.HTMLMessage += template.HTML("Failed to update filter.<br>")
 			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "AccountFail")
 			return
 		}
 		//Success
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "FILTER-SET", TemplateInput.UserInformation.Name+" successfully set filter. ")
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditFilterSet, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Filter": request.FormValue("filter")})
 		TemplateInput.HTMLMessage += template.HTML("Your filter was changed successfully.<br>")
 		redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "FilterSucceeded")
 		return
+	case "savesearch":
+		//Ensure signed in
+		if !TemplateInput.IsLoggedOn() {
+			TemplateInput.HTMLMessage += template.HTML("You must be logged in to perform this action.<br>")
+			logging.WriteLog(logging.LogLevelError, "accountrouter/LogonRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"User not logged in"})
+			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "LogonRequired")
+			return
+		}
+		searchName := strings.ToLower(strings.TrimSpace(request.FormValue("Name")))
+		err := SaveSearch(request.Context(), TemplateInput.UserInformation.ID, searchName, request.FormValue("Query"))
+		if err != nil {
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditSavedSearchSet, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Name": searchName, "Query": request.FormValue("Query"), "Error": err.Error()})
+			TemplateInput.HTMLMessage += template.HTML("Failed to save search, " + template.HTMLEscapeString(err.Error()) + ".<br>")
+			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "AccountFail")
+			return
+		}
+		//Success
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditSavedSearchSet, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Name": searchName, "Query": request.FormValue("Query")})
+		TemplateInput.HTMLMessage += template.HTML("Saved search " + template.HTMLEscapeString(searchName) + ", use it in a query as saved:" + template.HTMLEscapeString(searchName) + ".<br>")
+		redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "SavedSearchSucceeded")
+		return
+	case "deletesavedsearch":
+		//Ensure signed in
+		if !TemplateInput.IsLoggedOn() {
+			TemplateInput.HTMLMessage += template.HTML("You must be logged in to perform this action.<br>")
+			logging.WriteLog(logging.LogLevelError, "accountrouter/LogonRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"User not logged in"})
+			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "LogonRequired")
+			return
+		}
+		searchName := strings.ToLower(strings.TrimSpace(request.FormValue("Name")))
+		err := database.DBInterface.DeleteSavedSearch(request.Context(), TemplateInput.UserInformation.ID, searchName)
+		if err != nil {
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditSavedSearchDelete, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Name": searchName, "Error": err.Error()})
+			TemplateInput.HTMLMessage += template.HTML("Failed to delete saved search.<br>")
+			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "AccountFail")
+			return
+		}
+		//Success
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditSavedSearchDelete, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Name": searchName})
+		TemplateInput.HTMLMessage += template.HTML("Deleted saved search " + template.HTMLEscapeString(searchName) + ".<br>")
+		redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "SavedSearchSucceeded")
+		return
 	}
 	TemplateInput.HTMLMessage += template.HTML("Command not recognized or form submitted incorrectly.<br>")
 	redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "AccountFail")
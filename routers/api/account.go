@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"go-image-board/config"
 	"go-image-board/database"
+	"go-image-board/interfaces"
 	"go-image-board/logging"
 	"go-image-board/routers"
 	"net"
@@ -69,13 +70,13 @@ func LogonAPIRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	logonData.Username = strings.ToLower(logonData.Username)
 	ip, _, _ := net.SplitHostPort(request.RemoteAddr)
 	if logonData.Username != "" && logonData.Password != "" {
-		err := database.DBInterface.ValidateUser(logonData.Username, []byte(logonData.Password))
+		err := database.DBInterface.ValidateUser(request.Context(), logonData.Username, []byte(logonData.Password))
 		if err == nil {
 			//Get Session
 			session, _ := config.SessionStore.Get(request, config.SessionVariableName)
 
 			// Set some session values.
-			Token, err := database.DBInterface.GenerateToken(logonData.Username, ip)
+			Token, err := database.DBInterface.GenerateToken(request.Context(), logonData.Username, ip)
 			if err != nil {
 				logging.WriteLog(logging.LogLevelError, "account/LogonAPIRouter", logonData.Username, logging.ResultFailure, []string{"Account Validation", err.Error()})
 				ReplyWithJSONError(responseWriter, request, "failed to generate token", logonData.Username, http.StatusInternalServerError)
@@ -85,12 +86,12 @@ func LogonAPIRouter(responseWriter http.ResponseWriter, request *http.Request) {
 			session.Values["UserName"] = logonData.Username
 			// Save it before we write to the response/return from the handler.
 			session.Save(request, responseWriter)
-			go routers.WriteAuditLogByName(logonData.Username, "LOGON", logonData.Username+" successfully logged on with API.")
+			go routers.WriteAuditLogByName(request.Context(), logonData.Username, interfaces.AuditLogon, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"API": true})
 			logging.WriteLog(logging.LogLevelError, "account/LogonAPIRouter", logonData.Username, logging.ResultSuccess, []string{"Account Validation"})
 			ReplyWithJSON(responseWriter, request, GenericResponse{Result: "Successfully signed in"}, logonData.Username)
 			return
 		}
-		go routers.WriteAuditLogByName(logonData.Username, "LOGON", logonData.Username+" failed to log in. "+err.Error())
+		go routers.WriteAuditLogByName(request.Context(), logonData.Username, interfaces.AuditLogon, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"API": true, "UserName": logonData.Username, "Error": err.Error()})
 		responseWriter.Header().Add("WWW-Authenticate", "Newauth realm=\"gib-api\"")
 		ReplyWithJSONError(responseWriter, request, "wrong username or password", "", http.StatusUnauthorized)
 		return
@@ -113,11 +114,11 @@ func LogoutAPIRouter(responseWriter http.ResponseWriter, request *http.Request)
 	//But only wipe token from DB if session info was correct
 	//The idea is to prevent a DOS in the event someone constructs an invalid session
 	if TokenID != "" {
-		err := database.DBInterface.RevokeToken(UserName)
+		err := database.DBInterface.RevokeToken(request.Context(), UserName)
 		if err != nil {
 			logging.WriteLog(logging.LogLevelError, "account/LogoutAPIRouter", UserName, logging.ResultFailure, []string{"Account logout was requested but an error occured during token removal", err.Error()})
 		}
 	}
-	go routers.WriteAuditLogByName(UserName, "LOGOUT", UserName+" manually logged out of API.")
+	go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditLogout, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"API": true})
 	ReplyWithJSON(responseWriter, request, GenericResponse{Result: "you have been logged out"}, UserName)
 }
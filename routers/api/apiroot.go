@@ -76,9 +76,9 @@ func ValidateAPIUser(responseWriter http.ResponseWriter, request *http.Request)
 				userName := authPieces[0]
 				tokenID := authPieces[1]
 				ip, _, _ := net.SplitHostPort(request.RemoteAddr)
-				userID, err := database.DBInterface.GetUserID(userName)
+				userID, err := database.DBInterface.GetUserID(request.Context(), userName)
 				if err == nil {
-					if err := database.DBInterface.ValidateToken(userName, tokenID, ip); err != nil {
+					if err := database.DBInterface.ValidateToken(request.Context(), userName, tokenID, ip); err != nil {
 						logging.WriteLog(logging.LogLevelError, "apiroot/ValidateAPIUser", userName, logging.ResultSuccess, []string{"Validated by header"})
 						return true, userID, userName //Valid auth header
 					}
@@ -129,7 +129,7 @@ func ValidateAndThrottleAPIUser(responseWriter http.ResponseWriter, request *htt
 //ValidateAPIUserWriteAccess Validates the given user has permission to use advanced API functions, and if not repsonds to user. Returns ShouldContinue, and UserPermissions
 func ValidateAPIUserWriteAccess(responseWriter http.ResponseWriter, request *http.Request, UserName string) (bool, interfaces.UserPermission) {
 	//Get user permission info
-	permissions, err := database.DBInterface.GetUserPermissionSet(UserName)
+	permissions, err := database.DBInterface.GetUserPermissionSet(request.Context(), UserName)
 	if err != nil {
 		ReplyWithJSONError(responseWriter, request, "Could not validate your permission, internal database error", UserName, http.StatusForbidden)
 		return false, permissions
@@ -137,7 +137,7 @@ func ValidateAPIUserWriteAccess(responseWriter http.ResponseWriter, request *htt
 	//Validate Permission to delete using api
 	if interfaces.UserPermission(permissions).HasPermission(interfaces.APIWriteAccess) != true {
 		ReplyWithJSONError(responseWriter, request, "You do not have API write access", UserName, http.StatusForbidden)
-		go routers.WriteAuditLogByName(UserName, "API", UserName+" failed query API. Insufficient permissions. ")
+		go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditAPIAccess, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 		return false, permissions
 	}
 	return true, permissions
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"go-image-board/config"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"go-image-board/routers"
+	"net/http"
+	"strconv"
+)
+
+//AuditLogSearchResult response format for an audit log search
+type AuditLogSearchResult struct {
+	AuditLogs    []interfaces.AuditLog
+	ResultCount  uint64
+	ServerStride uint64
+}
+
+//AuditLogsGetAPIRouter serves get requests to /api/AuditLogs
+func AuditLogsGetAPIRouter(responseWriter http.ResponseWriter, request *http.Request) {
+	//Validate Logon
+	UserAPIValidated, _, UserName := ValidateAndThrottleAPIUser(responseWriter, request)
+	if !UserAPIValidated {
+		return //User either not logged in, or hit by throttle. Either way, already handled.
+	}
+
+	//Validate Permission
+	UserPerms, err := database.DBInterface.GetUserPermissionSet(request.Context(), UserName)
+	if err != nil || UserPerms.HasPermission(interfaces.ViewAuditLogs) == false {
+		ReplyWithJSONError(responseWriter, request, "Authenticated, but insufficient permissions to perform request", UserName, http.StatusForbidden)
+		return
+	}
+
+	pageStart, _ := strconv.ParseUint(request.FormValue("PageStart"), 10, 32) //Either parses fine, or is 0, both works
+	pageStride := config.Configuration.PageStride
+	Filter, err := routers.ParseAuditLogSearch(request).Filter(request.Context())
+	if err != nil {
+		ReplyWithJSONError(responseWriter, request, "Invalid search, "+err.Error(), UserName, http.StatusBadRequest)
+		return
+	}
+
+	//Perform Query
+	AuditLogs, count, err := database.DBInterface.SearchAuditLogs(request.Context(), Filter, pageStart, pageStride)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "auditlogsapi/AuditLogsGetAPIRouter", UserName, logging.ResultFailure, []string{"Failed to search audit logs", err.Error()})
+		ReplyWithJSONError(responseWriter, request, "Internal Database Error Occured", UserName, http.StatusInternalServerError)
+		return
+	}
+
+	ReplyWithJSON(responseWriter, request, AuditLogSearchResult{AuditLogs: AuditLogs, ResultCount: count, ServerStride: pageStride}, UserName)
+}
@@ -33,7 +33,7 @@ func CollectionGetAPIRouter(responseWriter http.ResponseWriter, request *http.Re
 			ReplyWithJSONError(responseWriter, request, "CollectionID could not be parsed into a number", UserName, http.StatusBadRequest)
 			return
 		}
-		collection, err := database.DBInterface.GetCollection(parsedID)
+		collection, err := database.DBInterface.GetCollection(request.Context(), parsedID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				ReplyWithJSONError(responseWriter, request, "No collection by that ID", UserName, http.StatusNotFound)
@@ -73,7 +73,7 @@ func CollectionDeleteAPIRouter(responseWriter http.ResponseWriter, request *http
 			ReplyWithJSONError(responseWriter, request, "CollectionID could not be parsed into a number", UserName, http.StatusBadRequest)
 			return
 		}
-		collection, err := database.DBInterface.GetCollection(parsedID)
+		collection, err := database.DBInterface.GetCollection(request.Context(), parsedID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				ReplyWithJSONError(responseWriter, request, "No collection by that ID", UserName, http.StatusNotFound)
@@ -85,7 +85,7 @@ func CollectionDeleteAPIRouter(responseWriter http.ResponseWriter, request *http
 		//Verify delete permissions
 		if interfaces.UserPermission(permissions).HasPermission(interfaces.RemoveCollections) != true && (config.Configuration.UsersControlOwnObjects != true || collection.UploaderID != UserID) {
 			ReplyWithJSONError(responseWriter, request, "You do not have permission to delete that", UserName, http.StatusForbidden)
-			go routers.WriteAuditLogByName(UserName, "DELETE-IMAGE", UserName+" failed to delete collection with API. Insufficient permissions. "+requestedID)
+			go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, parsedID, interfaces.AuditDetails{"API": true, "Error": interfaces.AuditDenied})
 			return
 		}
 		//Check if we are to delete members as well
@@ -93,10 +93,10 @@ func CollectionDeleteAPIRouter(responseWriter http.ResponseWriter, request *http
 		additionalMessages := ""
 		if strings.ToLower(deleteMembers) == "true" {
 			//Grab list of images
-			CollectionMembers, _, err := database.DBInterface.GetCollectionMembers(parsedID, 0, 0)
+			CollectionMembers, _, err := database.DBInterface.GetCollectionMembers(request.Context(), parsedID, 0, 0)
 			if err != nil {
 				ReplyWithJSONError(responseWriter, request, "Failed to delete collection. SQL Error getting collection memebers.", UserName, http.StatusInternalServerError)
-				go routers.WriteAuditLogByName(UserName, "DELETE-COLLECTION", UserName+" failed to delete collection. "+requestedID+", "+err.Error())
+				go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, parsedID, interfaces.AuditDetails{"API": true, "DeleteMembers": true, "Error": err.Error()})
 				return
 			}
 
@@ -105,25 +105,28 @@ func CollectionDeleteAPIRouter(responseWriter http.ResponseWriter, request *http
 				//Validate Permission to delete
 				if permissions.HasPermission(interfaces.RemoveImage) != true && (config.Configuration.UsersControlOwnObjects != true || ImageInfo.UploaderID != UserID) {
 					ReplyWithJSONError(responseWriter, request, "You do not have permission to delete all members. "+strconv.FormatUint(ImageInfo.ID, 10), UserName, http.StatusForbidden)
-					go routers.WriteAuditLogByName(UserName, "DELETE-COLLECTION", UserName+" failed to delete image. Insufficient permissions. "+requestedID)
+					go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditImageDelete, interfaces.AuditTargetImage, ImageInfo.ID, interfaces.AuditDetails{"API": true, "CollectionID": parsedID, "Error": interfaces.AuditDenied})
 					return
 				}
 			}
-			//Permission validated for all members, delete them
+			//Permission validated for all members, move them to the recycle bin
 			for _, ImageInfo := range CollectionMembers {
-				err = database.DBInterface.DeleteImage(ImageInfo.ID)
+				err = database.DBInterface.TrashImage(request.Context(), ImageInfo.ID, UserID)
 				if err != nil {
 					additionalMessages += "Failed to delete collection member " + strconv.FormatUint(ImageInfo.ID, 10) + ". "
-					go routers.WriteAuditLogByName(UserName, "DELETE-COLLECTION", UserName+" failed to delete image "+strconv.FormatUint(ImageInfo.ID, 10))
+					go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditImageDelete, interfaces.AuditTargetImage, ImageInfo.ID, interfaces.AuditDetails{"API": true, "CollectionID": parsedID, "Error": err.Error()})
+				} else {
+					go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditImageDelete, interfaces.AuditTargetImage, ImageInfo.ID, interfaces.AuditDetails{"API": true, "CollectionID": parsedID, "Name": ImageInfo.Name, "Location": ImageInfo.Location})
 				}
 			}
 		}
 		//Permission validated, delete collection
-		if err := database.DBInterface.DeleteCollection(parsedID); err != nil {
+		if err := database.DBInterface.DeleteCollection(request.Context(), parsedID); err != nil {
 			ReplyWithJSONError(responseWriter, request, "Interal Database Error", UserName, http.StatusInternalServerError)
-			go routers.WriteAuditLogByName(UserName, "DELETE-COLLECTION", UserName+" failed to delete collection with API. "+requestedID+", "+err.Error())
+			go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, parsedID, interfaces.AuditDetails{"API": true, "Error": err.Error()})
 			return //Cancel delete
 		}
+		go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, parsedID, interfaces.AuditDetails{"API": true, "Name": collection.Name, "DeleteMembers": strings.ToLower(deleteMembers) == "true"})
 		ReplyWithJSON(responseWriter, request, GenericResponse{Result: "Successfully deleted collection " + requestedID + ". " + additionalMessages}, UserName)
 		return
 	}
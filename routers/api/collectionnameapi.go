@@ -18,7 +18,7 @@ func CollectionNameAPIRouter(responseWriter http.ResponseWriter, request *http.R
 	requestedName := request.FormValue("CollectionName")
 
 	if requestedName != "" {
-		collection, err := database.DBInterface.GetCollectionByName(requestedName)
+		collection, err := database.DBInterface.GetCollectionByName(request.Context(), requestedName)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				ReplyWithJSONError(responseWriter, request, "No collection found by that Name", UserName, http.StatusNotFound)
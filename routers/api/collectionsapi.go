@@ -5,6 +5,7 @@ import (
 	"go-image-board/database"
 	"go-image-board/interfaces"
 	"go-image-board/logging"
+	"go-image-board/routers"
 	"net/http"
 	"strconv"
 )
@@ -25,14 +26,14 @@ func CollectionsGetAPIRouter(responseWriter http.ResponseWriter, request *http.R
 	}
 
 	//Query for a collection's information, will return CollectionInformation
-	userQuery := request.FormValue("SearchQuery")
+	userQuery := routers.ExpandSavedSearches(request.Context(), UserID, request.FormValue("SearchQuery"))
 	pageStart, _ := strconv.ParseUint(request.FormValue("PageStart"), 10, 32) //Either parses fine, or is 0, both works
 	pageStride := config.Configuration.PageStride
 
-	userQTags, err := database.DBInterface.GetQueryTags(userQuery, true)
+	userQTags, err := database.DBInterface.GetQueryTags(request.Context(), userQuery, true)
 	if err == nil {
 		//add user's global filters to query
-		userFilterTags, err := database.DBInterface.GetUserFilterTags(UserID, true)
+		userFilterTags, err := database.DBInterface.GetUserFilterTags(request.Context(), UserID, true)
 		if err != nil {
 			logging.WriteLog(logging.LogLevelError, "collectionqueries/CollectionsAPIRouter", UserName, logging.ResultFailure, []string{"Failed to load user's filter", err.Error()})
 		} else {
@@ -40,7 +41,7 @@ func CollectionsGetAPIRouter(responseWriter http.ResponseWriter, request *http.R
 		}
 
 		//Perform Query
-		collectionInfo, MaxCount, err := database.DBInterface.SearchCollections(userQTags, pageStart, pageStride)
+		collectionInfo, MaxCount, err := database.DBInterface.SearchCollections(request.Context(), userQTags, pageStart, pageStride)
 		ReplyWithJSON(responseWriter, request, CollectionSearchResult{Collections: collectionInfo, ResultCount: MaxCount, ServerStride: pageStride}, UserName)
 		return
 	}
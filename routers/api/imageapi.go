@@ -8,9 +8,6 @@ import (
 	"go-image-board/interfaces"
 	"go-image-board/routers"
 	"net/http"
-	"os"
-	"path"
-	"path/filepath"
 	"strconv"
 
 	"github.com/gorilla/mux"
@@ -35,7 +32,7 @@ func ImageGetAPIRouter(responseWriter http.ResponseWriter, request *http.Request
 			ReplyWithJSONError(responseWriter, request, "ImageID could not be parsed into a number", UserName, http.StatusBadRequest)
 			return
 		}
-		image, err := database.DBInterface.GetImage(parsedID)
+		image, err := database.DBInterface.GetImage(request.Context(), parsedID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				ReplyWithJSONError(responseWriter, request, "No image by that ID", UserName, http.StatusNotFound)
@@ -44,6 +41,11 @@ func ImageGetAPIRouter(responseWriter http.ResponseWriter, request *http.Request
 			ReplyWithJSONError(responseWriter, request, "Interal Database Error", UserName, http.StatusInternalServerError)
 			return
 		}
+		//Images in the recycle bin are treated as gone
+		if image.DeletedTime.IsZero() == false {
+			ReplyWithJSONError(responseWriter, request, "No image by that ID", UserName, http.StatusNotFound)
+			return
+		}
 		ReplyWithJSON(responseWriter, request, image, UserName)
 		return
 	}
@@ -75,7 +77,7 @@ func ImageDeleteAPIRouter(responseWriter http.ResponseWriter, request *http.Requ
 			return
 		}
 		//Get Image info
-		imageInfo, err := database.DBInterface.GetImage(parsedID)
+		imageInfo, err := database.DBInterface.GetImage(request.Context(), parsedID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				ReplyWithJSONError(responseWriter, request, "No image by that ID", UserName, http.StatusNotFound)
@@ -84,26 +86,30 @@ func ImageDeleteAPIRouter(responseWriter http.ResponseWriter, request *http.Requ
 			ReplyWithJSONError(responseWriter, request, "Interal Database Error", UserName, http.StatusInternalServerError)
 			return
 		}
+		if imageInfo.DeletedTime.IsZero() == false {
+			ReplyWithJSONError(responseWriter, request, "No image by that ID", UserName, http.StatusNotFound)
+			return
+		}
 
 		//Validate delete permissions
 		if interfaces.UserPermission(permissions).HasPermission(interfaces.RemoveImage) != true && (config.Configuration.UsersControlOwnObjects != true || imageInfo.UploaderID != UserID) {
 			ReplyWithJSONError(responseWriter, request, "You do not have permission to delete that", UserName, http.StatusForbidden)
-			go routers.WriteAuditLogByName(UserName, "DELETE-IMAGE", UserName+" failed to delete image with API. Insufficient permissions. "+requestedID)
+			go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditImageDelete, interfaces.AuditTargetImage, parsedID, interfaces.AuditDetails{"API": true, "Error": interfaces.AuditDenied})
 			return
 		}
 
 		//Delete
-		//Permission validated, now delete (ImageTags and Images)
-		if err := database.DBInterface.DeleteImage(parsedID); err != nil {
+		//Permission validated, now move it to the recycle bin. Files stay until the image is purged
+		if err := database.DBInterface.TrashImage(request.Context(), parsedID, UserID); err != nil {
+			if err == sql.ErrNoRows {
+				ReplyWithJSONError(responseWriter, request, "No image by that ID", UserName, http.StatusNotFound)
+				return
+			}
 			ReplyWithJSONError(responseWriter, request, "Interal Database Error", UserName, http.StatusInternalServerError)
-			go routers.WriteAuditLogByName(UserName, "DELETE-IMAGE", UserName+" failed to delete image with API. "+requestedID+", "+err.Error())
+			go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditImageDelete, interfaces.AuditTargetImage, parsedID, interfaces.AuditDetails{"API": true, "Error": err.Error()})
 			return //Cancel delete
 		}
-		go routers.WriteAuditLogByName(UserName, "DELETE-IMAGE", UserName+" deleted image with API. "+requestedID+", "+imageInfo.Name+", "+imageInfo.Location)
-		//Third, delete Image from Disk
-		go os.Remove(path.Join(config.Configuration.ImageDirectory, imageInfo.Location))
-		//Last delete thumbnail from disk
-		go os.Remove(path.Join(config.Configuration.ImageDirectory, "thumbs"+string(filepath.Separator)+imageInfo.Location+".png"))
+		go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditImageDelete, interfaces.AuditTargetImage, parsedID, interfaces.AuditDetails{"API": true, "Name": imageInfo.Name, "Location": imageInfo.Location})
 		//Reply Success
 		ReplyWithJSON(responseWriter, request, GenericResponse{Result: "Successfully deleted image " + requestedID}, UserName)
 		return
@@ -138,7 +144,7 @@ func ImagePostAPIRouter(responseWriter http.ResponseWriter, request *http.Reques
 
 	//Verify user can upload an image
 	if interfaces.UserPermission(permissions).HasPermission(interfaces.UploadImage) != true {
-		go routers.WriteAuditLog(UserID, "IMAGE-UPLOAD", UserName+" failed to upload image. No permissions.")
+		go routers.WriteAuditLog(request.Context(), UserID, interfaces.AuditImageUpload, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"API": true, "Error": interfaces.AuditDenied})
 		ReplyWithJSONError(responseWriter, request, "Insufficient permissions to upload", UserName, http.StatusForbidden)
 		return
 	}
@@ -1,10 +1,12 @@
 package api
 
 import (
+	"errors"
 	"go-image-board/config"
 	"go-image-board/database"
 	"go-image-board/interfaces"
 	"go-image-board/logging"
+	"go-image-board/routers"
 	"net/http"
 	"strconv"
 	"strings"
@@ -15,6 +17,8 @@ type ImageSearchResult struct {
 	Images       []interfaces.ImageInformation
 	ResultCount  uint64
 	ServerStride uint64
+	//NextCursor is passed as Cursor to get the next page, blank on the last page
+	NextCursor string
 }
 
 //ImagesGetAPIRouter serves requests to /api/Images
@@ -26,14 +30,15 @@ func ImagesGetAPIRouter(responseWriter http.ResponseWriter, request *http.Reques
 	}
 
 	//Query for a images's information, will return ImageInformation
-	userQuery := request.FormValue("SearchQuery")
+	userQuery := routers.ExpandSavedSearches(request.Context(), UserID, request.FormValue("SearchQuery"))
 	pageStart, _ := strconv.ParseUint(request.FormValue("PageStart"), 10, 32) //Either parses fine, or is 0, both works
 	pageStride := config.Configuration.PageStride
+	cursor := request.FormValue("Cursor") //Continues after the previous page when set, in place of PageStart
 
-	userQTags, err := database.DBInterface.GetQueryTags(userQuery, false)
+	userQTags, err := database.DBInterface.GetQueryTags(request.Context(), userQuery, false)
 	if err == nil {
 		//add user's global filters to query
-		userFilterTags, err := database.DBInterface.GetUserFilterTags(UserID, false)
+		userFilterTags, err := database.DBInterface.GetUserFilterTags(request.Context(), UserID, false)
 		if err != nil {
 			logging.WriteLog(logging.LogLevelError, "imagequeries/ImagesAPIRouter", UserName, logging.ResultFailure, []string{"Failed to load user's filter", err.Error()})
 		} else {
@@ -42,7 +47,7 @@ func ImagesGetAPIRouter(responseWriter http.ResponseWriter, request *http.Reques
 
 		//Return random image if requested
 		if strings.ToLower(request.FormValue("SearchType")) == "random" {
-			imageInfo, resultCount, err := database.DBInterface.GetRandomImage(userQTags)
+			imageInfo, resultCount, err := database.DBInterface.GetRandomImage(request.Context(), userQTags)
 			if err == nil {
 				ReplyWithJSON(responseWriter, request, ImageSearchResult{Images: []interfaces.ImageInformation{imageInfo}, ResultCount: resultCount, ServerStride: pageStride}, UserName)
 				return
@@ -52,9 +57,21 @@ func ImagesGetAPIRouter(responseWriter http.ResponseWriter, request *http.Reques
 			return
 		}
 
-		//Perform Query
-		imageInfo, MaxCount, err := database.DBInterface.SearchImages(userQTags, pageStart, pageStride)
-		ReplyWithJSON(responseWriter, request, ImageSearchResult{Images: imageInfo, ResultCount: MaxCount, ServerStride: pageStride}, UserName)
+		//Perform Query, counting every match only when the count is not cached
+		cachedCount, hasCount := routers.SearchCounts.GetValue(UserID, userQuery)
+		imageInfo, nextCursor, MaxCount, err := database.DBInterface.SearchImagesPage(request.Context(), userQTags, cursor, pageStart, pageStride, hasCount == false)
+		if errors.Is(err, interfaces.ErrInvalidCursor) {
+			ReplyWithJSONError(responseWriter, request, "invalid cursor", UserName, http.StatusBadRequest)
+			return
+		}
+		if err == nil {
+			if hasCount {
+				MaxCount = cachedCount
+			} else {
+				routers.SearchCounts.SetValue(UserID, userQuery, MaxCount)
+			}
+		}
+		ReplyWithJSON(responseWriter, request, ImageSearchResult{Images: imageInfo, ResultCount: MaxCount, ServerStride: pageStride, NextCursor: nextCursor}, UserName)
 		return
 	}
 	logging.WriteLog(logging.LogLevelError, "imagequeries/ImagesAPIRouter", UserName, logging.ResultFailure, []string{"Failed to parse user query", err.Error()})
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"database/sql"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/routers"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+//SavedSearchesGetAPIRouter serves get requests to /api/SavedSearches
+func SavedSearchesGetAPIRouter(responseWriter http.ResponseWriter, request *http.Request) {
+	//Validate Logon
+	UserAPIValidated, UserID, UserName := ValidateAndThrottleAPIUser(responseWriter, request)
+	if !UserAPIValidated {
+		return //User not logged in and was already handled
+	}
+
+	//Will return the user's SavedSearches, sorted by name
+	Saved, err := database.DBInterface.GetSavedSearches(request.Context(), UserID)
+	if err != nil {
+		ReplyWithJSONError(responseWriter, request, "Internal database error", UserName, http.StatusInternalServerError)
+		return
+	}
+	if Saved == nil {
+		Saved = []interfaces.SavedSearch{}
+	}
+	ReplyWithJSON(responseWriter, request, Saved, UserName)
+}
+
+//SavedSearchesPostAPIRouter serves post requests to /api/SavedSearches, saving Query under Name or replacing the query of the saved search by that name
+func SavedSearchesPostAPIRouter(responseWriter http.ResponseWriter, request *http.Request) {
+	//Validate Logon
+	UserAPIValidated, UserID, UserName := ValidateAndThrottleAPIUser(responseWriter, request)
+	if !UserAPIValidated {
+		return //User not logged in and was already handled
+	}
+	//Validate Permission to use api
+	UserAPIWriteValidated, _ := ValidateAPIUserWriteAccess(responseWriter, request, UserName)
+	if !UserAPIWriteValidated {
+		return //User does not have API access and was already told
+	}
+
+	searchName := strings.ToLower(strings.TrimSpace(request.FormValue("Name")))
+	if err := routers.SaveSearch(request.Context(), UserID, searchName, request.FormValue("Query")); err != nil {
+		go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditSavedSearchSet, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"API": true, "Name": searchName, "Query": request.FormValue("Query"), "Error": err.Error()})
+		ReplyWithJSONError(responseWriter, request, err.Error(), UserName, http.StatusBadRequest)
+		return
+	}
+	go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditSavedSearchSet, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"API": true, "Name": searchName, "Query": request.FormValue("Query")})
+	//Reply Success
+	ReplyWithJSON(responseWriter, request, GenericResponse{Result: "Successfully saved search " + searchName}, UserName)
+}
+
+//SavedSearchDeleteAPIRouter serves delete requests to /api/SavedSearch/{Name}
+func SavedSearchDeleteAPIRouter(responseWriter http.ResponseWriter, request *http.Request) {
+	//Validate Logon
+	UserAPIValidated, UserID, UserName := ValidateAndThrottleAPIUser(responseWriter, request)
+	if !UserAPIValidated {
+		return //User not logged in and was already handled
+	}
+	//Validate Permission to use api
+	UserAPIWriteValidated, _ := ValidateAPIUserWriteAccess(responseWriter, request, UserName)
+	if !UserAPIWriteValidated {
+		return //User does not have API access and was already told
+	}
+
+	//Get variables for URL mux from Gorilla
+	searchName := strings.ToLower(mux.Vars(request)["Name"])
+	if searchName == "" {
+		ReplyWithJSONError(responseWriter, request, "Please specify Name", UserName, http.StatusBadRequest)
+		return
+	}
+	if err := database.DBInterface.DeleteSavedSearch(request.Context(), UserID, searchName); err != nil {
+		if err == sql.ErrNoRows {
+			ReplyWithJSONError(responseWriter, request, "No saved search by that name", UserName, http.StatusNotFound)
+			return
+		}
+		go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditSavedSearchDelete, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"API": true, "Name": searchName, "Error": err.Error()})
+		ReplyWithJSONError(responseWriter, request, "Internal database error", UserName, http.StatusInternalServerError)
+		return
+	}
+	go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditSavedSearchDelete, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"API": true, "Name": searchName})
+	//Reply Success
+	ReplyWithJSON(responseWriter, request, GenericResponse{Result: "Successfully deleted saved search " + searchName}, UserName)
+}
@@ -33,7 +33,7 @@ func TagGetAPIRouter(responseWriter http.ResponseWriter, request *http.Request)
 			ReplyWithJSONError(responseWriter, request, "TagID could not be parsed into a number", UserName, http.StatusBadRequest)
 			return
 		}
-		tag, err := database.DBInterface.GetTag(parsedID, true)
+		tag, err := database.DBInterface.GetTag(request.Context(), parsedID, true)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				ReplyWithJSONError(responseWriter, request, "No tag by that ID", UserName, http.StatusNotFound)
@@ -73,7 +73,7 @@ func TagDeleteAPIRouter(responseWriter http.ResponseWriter, request *http.Reques
 			ReplyWithJSONError(responseWriter, request, "TagID could not be parsed into a number", UserName, http.StatusBadRequest)
 			return
 		}
-		tag, err := database.DBInterface.GetTag(parsedID, true)
+		tag, err := database.DBInterface.GetTag(request.Context(), parsedID, true)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				ReplyWithJSONError(responseWriter, request, "No tag by that ID", UserName, http.StatusNotFound)
@@ -86,16 +86,17 @@ func TagDeleteAPIRouter(responseWriter http.ResponseWriter, request *http.Reques
 		//Validate delete permissions
 		if interfaces.UserPermission(permissions).HasPermission(interfaces.RemoveTags) != true && (config.Configuration.UsersControlOwnObjects != true || tag.UploaderID != UserID) {
 			ReplyWithJSONError(responseWriter, request, "You do not have permission to delete that", UserName, http.StatusForbidden)
-			go routers.WriteAuditLogByName(UserName, "DELETE-TAG", UserName+" failed to delete tag with API. Insufficient permissions. "+requestedID)
+			go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditTagDelete, interfaces.AuditTargetTag, parsedID, interfaces.AuditDetails{"API": true, "Error": interfaces.AuditDenied})
 			return
 		}
 
 		//Permission validated, now delete
-		if err := database.DBInterface.DeleteTag(parsedID); err != nil {
+		if err := database.DBInterface.DeleteTag(request.Context(), parsedID); err != nil {
 			ReplyWithJSONError(responseWriter, request, "Interal Database Error", UserName, http.StatusInternalServerError)
-			go routers.WriteAuditLogByName(UserName, "DELETE-TAG", UserName+" failed to delete tag with API. "+requestedID+", "+err.Error())
+			go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditTagDelete, interfaces.AuditTargetTag, parsedID, interfaces.AuditDetails{"API": true, "Error": err.Error()})
 			return //Cancel delete
 		}
+		go routers.WriteAuditLogByName(request.Context(), UserName, interfaces.AuditTagDelete, interfaces.AuditTargetTag, parsedID, interfaces.AuditDetails{"API": true, "Name": tag.Name})
 		//Reply Success
 		ReplyWithJSON(responseWriter, request, GenericResponse{Result: "Successfully deleted tag " + requestedID}, UserName)
 		return
@@ -19,7 +19,7 @@ func TagNameAPIRouter(responseWriter http.ResponseWriter, request *http.Request)
 	requestedName := strings.TrimSpace(request.FormValue("tagNameQuery"))
 
 	//Perform Query
-	tagInfo, count, err := database.DBInterface.SearchTags(requestedName, 0, 5, true, true)
+	tagInfo, count, err := database.DBInterface.SearchTags(request.Context(), requestedName, 0, 5, true, true)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "tagqueries/TagNameAPIRouter", UserName, logging.ResultFailure, []string{"Failed to query tags", err.Error()})
 		ReplyWithJSONError(responseWriter, request, "Internal Database Error Occured", UserName, http.StatusInternalServerError)
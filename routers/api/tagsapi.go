@@ -31,7 +31,7 @@ func TagsGetAPIRouter(responseWriter http.ResponseWriter, request *http.Request)
 	pageStride := config.Configuration.PageStride
 
 	//Perform Query
-	tagInfo, count, err := database.DBInterface.SearchTags(requestedName, pageStart, pageStride, false, false)
+	tagInfo, count, err := database.DBInterface.SearchTags(request.Context(), requestedName, pageStart, pageStride, false, false)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "tagqueries/TagsAPIRouter", UserName, logging.ResultFailure, []string{"Failed to query tags", err.Error()})
 		ReplyWithJSONError(responseWriter, request, "Internal Database Error Occured", UserName, http.StatusInternalServerError)
@@ -33,14 +33,14 @@ func UsersAPIRouter(responseWriter http.ResponseWriter, request *http.Request) {
 
 	//Validate Permission
 	////Get User Info
-	UserPerms, err := database.DBInterface.GetUserPermissionSet(UserName)
+	UserPerms, err := database.DBInterface.GetUserPermissionSet(request.Context(), UserName)
 	if UserPerms.HasPermission(interfaces.EditUserPermissions) == false && UserPerms.HasPermission(interfaces.DisableUser) == false {
 		ReplyWithJSONError(responseWriter, request, "Authenticated, but insufficient permissions to perform request", UserName, http.StatusForbidden)
 		return
 	}
 
 	//Perform Query
-	userInfo, count, err := database.DBInterface.SearchUsers(requestedName, pageStart, pageStride)
+	userInfo, count, err := database.DBInterface.SearchUsers(request.Context(), requestedName, pageStart, pageStride)
 	if err != nil {
 		logging.WriteLog(logging.LogLevelError, "userqueries/UserAPIRouter", UserName, logging.ResultFailure, []string{"Failed to query users", err.Error()})
 		ReplyWithJSONError(responseWriter, request, "Internal Database Error Occured", UserName, http.StatusInternalServerError)
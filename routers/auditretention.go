@@ -0,0 +1,94 @@
+package routers
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"go-image-board/config"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+//auditRetentionInterval how often audit log entries past the retention period are expired
+var auditRetentionInterval = 24 * time.Hour
+
+//auditArchiveBatch how many audit log entries are written to each archive file
+const auditArchiveBatch = 10000
+
+//ExpireAuditLogs deletes every audit log entry older than AuditRetentionDays, returning how many were deleted.
+//If AuditArchiveDirectory is set, entries are only deleted once they have been written to an archive file there
+func ExpireAuditLogs(ctx context.Context) (uint64, error) {
+	if config.Configuration.AuditRetentionDays < 0 {
+		return 0, nil //Kept forever
+	}
+	Before := time.Now().AddDate(0, 0, -int(config.Configuration.AuditRetentionDays))
+	if config.Configuration.AuditArchiveDirectory == "" {
+		return database.DBInterface.DeleteAuditLogsBefore(ctx, Before, 0)
+	}
+	if err := os.MkdirAll(config.Configuration.AuditArchiveDirectory, 0750); err != nil {
+		return 0, err
+	}
+	var Expired uint64
+	for {
+		Logs, err := database.DBInterface.GetAuditLogsBefore(ctx, Before, auditArchiveBatch)
+		if err != nil || len(Logs) == 0 {
+			return Expired, err
+		}
+		FirstID, LastID := strconv.FormatUint(Logs[0].ID, 10), strconv.FormatUint(Logs[len(Logs)-1].ID, 10)
+		if err := writeAuditArchive(filepath.Join(config.Configuration.AuditArchiveDirectory, "auditlog-"+FirstID+"-"+LastID+".jsonl.gz"), Logs); err != nil {
+			return Expired, err
+		}
+		Deleted, err := database.DBInterface.DeleteAuditLogsBefore(ctx, Before, Logs[len(Logs)-1].ID)
+		Expired += Deleted
+		if err != nil {
+			return Expired, err
+		}
+	}
+}
+
+//writeAuditArchive writes audit log entries to a gzip compressed file at ArchivePath, one JSON object per line.
+//The file is written next to ArchivePath and only renamed into place once it is complete and synced to disk
+func writeAuditArchive(ArchivePath string, Logs []interfaces.AuditLog) error {
+	archiveFile, err := os.CreateTemp(filepath.Dir(ArchivePath), filepath.Base(ArchivePath)+".partial-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archiveFile.Name()) //Does nothing once renamed
+	defer archiveFile.Close()
+	compressor := gzip.NewWriter(archiveFile)
+	encoder := json.NewEncoder(compressor)
+	for _, Log := range Logs {
+		if err := encoder.Encode(Log); err != nil {
+			return err
+		}
+	}
+	if err := compressor.Close(); err != nil {
+		return err
+	}
+	if err := archiveFile.Sync(); err != nil {
+		return err
+	}
+	if err := archiveFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(archiveFile.Name(), ArchivePath)
+}
+
+//StartAuditRetention expires old audit log entries now, and again every auditRetentionInterval
+func StartAuditRetention() {
+	go func() {
+		for {
+			if Expired, err := ExpireAuditLogs(context.Background()); err != nil {
+				logging.WriteLog(logging.LogLevelError, "auditretention/StartAuditRetention", "0", logging.ResultFailure, []string{"Failed to expire audit logs", err.Error()})
+			} else if Expired > 0 {
+				logging.WriteLog(logging.LogLevelInfo, "auditretention/StartAuditRetention", "0", logging.ResultSuccess, []string{"Expired", strconv.FormatUint(Expired, 10), "audit log entries"})
+			}
+			time.Sleep(auditRetentionInterval)
+		}
+	}()
+}
@@ -0,0 +1,124 @@
+package routers
+
+import (
+	"go-image-board/database"
+	"go-image-board/logging"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//UploadCalendarPeriod is a day or month of the upload calendar. Period is blank for the cells padding out a month's first and last week
+type UploadCalendarPeriod struct {
+	Label string
+	//Period is the day (2006-01-02) or month (2006-01) as used in an uploaded search
+	Period string
+	Count  uint64
+}
+
+//UploadCalendarYear is a year of the upload timeline, with all twelve of its months
+type UploadCalendarYear struct {
+	Year   string
+	Count  uint64
+	Months []UploadCalendarPeriod
+}
+
+//UploadCalendar is either the days of one month, when Month is set, or a timeline of every year with uploads, newest first
+type UploadCalendar struct {
+	Month         string
+	MonthName     string
+	PreviousMonth string
+	NextMonth     string
+	Weeks         [][]UploadCalendarPeriod
+	Years         []UploadCalendarYear
+}
+
+//CalendarRouter serves requests to /calendar, which links each day or month to the images uploaded in it
+func CalendarRouter(responseWriter http.ResponseWriter, request *http.Request) {
+	TemplateInput := getTemplateInputFromRequest(responseWriter, request)
+
+	if Month, err := time.ParseInLocation("2006-01", request.FormValue("Month"), time.UTC); err == nil {
+		TemplateInput.Calendar, err = uploadCalendarMonth(request, Month)
+		if err != nil {
+			TemplateInput.HTMLMessage += template.HTML("Failed to load the upload calendar.<br>")
+			logging.WriteLog(logging.LogLevelError, "calendarrouter/CalendarRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to count uploads", err.Error()})
+		}
+	} else {
+		TemplateInput.Calendar, err = uploadCalendarTimeline(request)
+		if err != nil {
+			TemplateInput.HTMLMessage += template.HTML("Failed to load the upload timeline.<br>")
+			logging.WriteLog(logging.LogLevelError, "calendarrouter/CalendarRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to count uploads", err.Error()})
+		}
+	}
+
+	replyWithTemplate("calendar.html", TemplateInput, responseWriter, request)
+}
+
+//uploadCalendarMonth lays out the days of a month in weeks starting on Sunday, with how many images were uploaded each day
+func uploadCalendarMonth(request *http.Request, Month time.Time) (UploadCalendar, error) {
+	ToReturn := UploadCalendar{
+		Month:         Month.Format("2006-01"),
+		MonthName:     Month.Format("January 2006"),
+		PreviousMonth: Month.AddDate(0, -1, 0).Format("2006-01"),
+		NextMonth:     Month.AddDate(0, 1, 0).Format("2006-01"),
+	}
+	Counts, err := database.DBInterface.GetImageUploadCounts(request.Context(), Month, Month.AddDate(0, 1, 0), true)
+	if err != nil {
+		return ToReturn, err
+	}
+	DayCounts := make(map[string]uint64)
+	for _, Count := range Counts {
+		DayCounts[Count.Period] = Count.Count
+	}
+
+	Week := make([]UploadCalendarPeriod, int(Month.Weekday()))
+	for Day := Month; Day.Month() == Month.Month(); Day = Day.AddDate(0, 0, 1) {
+		Period := Day.Format("2006-01-02")
+		Week = append(Week, UploadCalendarPeriod{Label: strconv.Itoa(Day.Day()), Period: Period, Count: DayCounts[Period]})
+		if len(Week) == 7 {
+			ToReturn.Weeks = append(ToReturn.Weeks, Week)
+			Week = nil
+		}
+	}
+	if len(Week) > 0 {
+		ToReturn.Weeks = append(ToReturn.Weeks, append(Week, make([]UploadCalendarPeriod, 7-len(Week))...))
+	}
+	return ToReturn, nil
+}
+
+//uploadCalendarTimeline lists every year that has uploads, newest first, with how many images were uploaded each month
+func uploadCalendarTimeline(request *http.Request) (UploadCalendar, error) {
+	var ToReturn UploadCalendar
+	Counts, err := database.DBInterface.GetImageUploadCounts(request.Context(), time.Time{}, time.Time{}, false)
+	if err != nil {
+		return ToReturn, err
+	}
+	if len(Counts) == 0 {
+		return ToReturn, nil
+	}
+	MonthCounts := make(map[string]uint64)
+	for _, Count := range Counts {
+		MonthCounts[Count.Period] = Count.Count
+	}
+
+	//Counts are oldest first, so the first and last give the range of years to show
+	FirstYear, err := time.ParseInLocation("2006-01", Counts[0].Period, time.UTC)
+	if err != nil {
+		return ToReturn, err
+	}
+	LastYear, err := time.ParseInLocation("2006-01", Counts[len(Counts)-1].Period, time.UTC)
+	if err != nil {
+		return ToReturn, err
+	}
+	for Year := LastYear.Year(); Year >= FirstYear.Year(); Year-- {
+		ToAdd := UploadCalendarYear{Year: strconv.Itoa(Year)}
+		for Month := time.Date(Year, time.January, 1, 0, 0, 0, 0, time.UTC); Month.Year() == Year; Month = Month.AddDate(0, 1, 0) {
+			Period := Month.Format("2006-01")
+			ToAdd.Months = append(ToAdd.Months, UploadCalendarPeriod{Label: Month.Format("January"), Period: Period, Count: MonthCounts[Period]})
+			ToAdd.Count += MonthCounts[Period]
+		}
+		ToReturn.Years = append(ToReturn.Years, ToAdd)
+	}
+	return ToReturn, nil
+}
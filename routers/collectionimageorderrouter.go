@@ -33,7 +33,7 @@ func CollectionImageOrderGetRouter(responseWriter http.ResponseWriter, request *
 	}
 
 	//Get collection data
-	CollectionInfo, err := database.DBInterface.GetCollection(collectionID)
+	CollectionInfo, err := database.DBInterface.GetCollection(request.Context(), collectionID)
 	if err != nil {
 		TemplateInput.HTMLMessage += template.HTML("Failed to get collection. SQL Error.<br>")
 		redirectWithFlash(responseWriter, request, "/collections?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "OrderFail")
@@ -50,7 +50,7 @@ func CollectionImageOrderGetRouter(responseWriter http.ResponseWriter, request *
 	//Fill in TemplateInput
 	TemplateInput.CollectionInfo = CollectionInfo
 	//Parse tag results for next query
-	imageInfo, MaxCount, err := database.DBInterface.GetCollectionMembers(collectionID, 0, 0)
+	imageInfo, MaxCount, err := database.DBInterface.GetCollectionMembers(request.Context(), collectionID, 0, 0)
 	if err == nil {
 		TemplateInput.ImageInfo = imageInfo
 		TemplateInput.TotalResults = MaxCount
@@ -83,10 +83,10 @@ func CollectionImageOrderPostRouter(responseWriter http.ResponseWriter, request
 	}
 
 	//Get collection data
-	CollectionInfo, err := database.DBInterface.GetCollection(collectionID)
+	CollectionInfo, err := database.DBInterface.GetCollection(request.Context(), collectionID)
 	if err != nil {
 		TemplateInput.HTMLMessage += template.HTML("Failed to get collection. SQL Error.<br>")
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "MODIFY-COLLECTIONMEMBER", TemplateInput.UserInformation.Name+" failed to modify collection. "+request.FormValue("ID")+", "+err.Error())
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionOrder, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Error": err.Error()})
 		redirectWithFlash(responseWriter, request, "/collections?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "OrderFail")
 		return
 	}
@@ -94,7 +94,7 @@ func CollectionImageOrderPostRouter(responseWriter http.ResponseWriter, request
 	//Validate Permission to Modify
 	if TemplateInput.UserPermissions.HasPermission(interfaces.ModifyCollectionMembers) != true && (config.Configuration.UsersControlOwnObjects != true || CollectionInfo.UploaderID != TemplateInput.UserInformation.ID) {
 		TemplateInput.HTMLMessage += template.HTML("You do not have edit member permission for collection.<br>")
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "MODIFY-COLLECTIONMEMBER", TemplateInput.UserInformation.Name+" failed to modify collection order. Insufficient permissions. "+request.FormValue("ID"))
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionOrder, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 		redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "OrderFail")
 		return
 	}
@@ -118,7 +118,7 @@ func CollectionImageOrderPostRouter(responseWriter http.ResponseWriter, request
 		//Loop through and send reorder commands
 		if len(idList) > 0 {
 			for i, v := range idList {
-				if err := database.DBInterface.UpdateCollectionMember(collectionID, v, uint64(i)); err != nil {
+				if err := database.DBInterface.UpdateCollectionMember(request.Context(), collectionID, v, uint64(i)); err != nil {
 					TemplateInput.HTMLMessage += template.HTML("Failed to reorder imageID " + strconv.FormatUint(v, 10) + " to " + strconv.Itoa(i) + ".<br>")
 				}
 			}
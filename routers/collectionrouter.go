@@ -9,9 +9,6 @@ import (
 	"html/template"
 	"net/http"
 	"net/url"
-	"os"
-	"path"
-	"path/filepath"
 	"strconv"
 	"strings"
 )
@@ -55,7 +52,7 @@ func CollectionGetRouter(responseWriter http.ResponseWriter, request *http.Reque
 	}
 
 	//getCollectionInfo
-	collectionInfo, err := database.DBInterface.GetCollection(collectionID)
+	collectionInfo, err := database.DBInterface.GetCollection(request.Context(), collectionID)
 	if err != nil {
 		TemplateInput.HTMLMessage += template.HTML("Failed to get the requested collection.<br>")
 		logging.WriteLog(logging.LogLevelError, "collectionimagerouter/CollectionImageRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to get collection", strconv.FormatUint(collectionID, 10), err.Error()})
@@ -65,12 +62,12 @@ func CollectionGetRouter(responseWriter http.ResponseWriter, request *http.Reque
 
 	TemplateInput.CollectionInfo = collectionInfo
 	//Parse tag results for next query
-	imageInfo, MaxCount, err := database.DBInterface.GetCollectionMembers(collectionID, pageStart, pageStride)
+	imageInfo, MaxCount, err := database.DBInterface.GetCollectionMembers(request.Context(), collectionID, pageStart, pageStride)
 	if err == nil {
 		TemplateInput.ImageInfo = imageInfo
 		TemplateInput.TotalResults = MaxCount
 		TemplateInput.PageMenu, err = generatePageMenu(int64(pageStart), int64(pageStride), int64(TemplateInput.TotalResults), "SearchTerms="+url.QueryEscape(userQuery)+"&ID="+strconv.FormatUint(collectionInfo.ID, 10), "/collection")
-		TemplateInput.Tags, err = database.DBInterface.GetCollectionTags(collectionInfo.ID)
+		TemplateInput.Tags, err = database.DBInterface.GetCollectionTags(request.Context(), collectionInfo.ID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to get collection tags.<br>")
 			logging.WriteLog(logging.LogLevelError, "collectionimagerouter/CollectionImageRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to get collection tags", strconv.FormatUint(collectionID, 10), err.Error()})
@@ -114,10 +111,10 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 		}
 
 		//Cache collection data
-		CollectionInfo, err := database.DBInterface.GetCollection(collectionID)
+		CollectionInfo, err := database.DBInterface.GetCollection(request.Context(), collectionID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to edit collection. SQL Error.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "REMOVE-COLLECTIONMEMBER", TemplateInput.UserInformation.Name+" failed to delete collection. "+request.FormValue("ID")+", "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionMemberRemove, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"ImageID": parsedImageID, "Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/collections?SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 			return
 		}
@@ -125,32 +122,36 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 		//Validate Permission to delete
 		if TemplateInput.UserPermissions.HasPermission(interfaces.ModifyCollectionMembers) != true && (config.Configuration.UsersControlOwnObjects != true || CollectionInfo.UploaderID != TemplateInput.UserInformation.ID) {
 			TemplateInput.HTMLMessage += template.HTML("You do not have edit member permission for collection.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "REMOVE-COLLECTIONMEMBER", TemplateInput.UserInformation.Name+" failed to delete collection. Insufficient permissions. "+request.FormValue("ID"))
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionMemberRemove, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"ImageID": parsedImageID, "Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 			return
 		}
 
 		//Permission validated, now delete (CollectionMember)
 		if CollectionInfo.Members <= 1 {
-			if err := database.DBInterface.DeleteCollection(collectionID); err != nil {
+			if err := changeImage(request.Context(), parsedImageID, TemplateInput.UserInformation.ID, func(Tx interfaces.DBInterface) error {
+				return Tx.DeleteCollection(request.Context(), collectionID)
+			}); err != nil {
 				TemplateInput.HTMLMessage += template.HTML("Failed to delete collection. SQL Error.<br>")
-				go WriteAuditLogByName(TemplateInput.UserInformation.Name, "REMOVE-COLLECTIONMEMBER", TemplateInput.UserInformation.Name+" failed to remove member from collection. "+request.FormValue("ImageID")+" from "+request.FormValue("ID")+", "+err.Error())
+				go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionMemberRemove, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"ImageID": parsedImageID, "Error": err.Error()})
 				redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 				return
 			}
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "REMOVE-COLLECTIONMEMBER", TemplateInput.UserInformation.Name+" removed image from collection. "+request.FormValue("ImageID")+" from "+request.FormValue("ID")+", "+CollectionInfo.Name)
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionMemberRemove, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"ImageID": parsedImageID, "Name": CollectionInfo.Name, "CollectionDeleted": true})
 			TemplateInput.HTMLMessage += template.HTML("Successfully remove image from collection. Collection empty, so collection was also removed.<br>")
 			//Redirect since we deleted collection
 			redirectWithFlash(responseWriter, request, "/collections?SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "DeleteSuccess")
 			return
 		}
-		if err := database.DBInterface.RemoveCollectionMember(collectionID, parsedImageID); err != nil {
+		if err := changeImage(request.Context(), parsedImageID, TemplateInput.UserInformation.ID, func(Tx interfaces.DBInterface) error {
+			return Tx.RemoveCollectionMember(request.Context(), collectionID, parsedImageID)
+		}); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to delete collection member. SQL Error.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "REMOVE-COLLECTIONMEMBER", TemplateInput.UserInformation.Name+" failed to remove member from collection. "+request.FormValue("ImageID")+" from "+request.FormValue("ID")+", "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionMemberRemove, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"ImageID": parsedImageID, "Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 			return
 		}
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "REMOVE-COLLECTIONMEMBER", TemplateInput.UserInformation.Name+" removed image from collection. "+request.FormValue("ImageID")+" from "+request.FormValue("ID")+", "+CollectionInfo.Name)
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionMemberRemove, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"ImageID": parsedImageID, "Name": CollectionInfo.Name})
 		TemplateInput.HTMLMessage += template.HTML("Successfully removed image from collection.<br>")
 		redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionSuccess")
 		return
@@ -163,13 +164,13 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 			return
 		}
 
-		collection, err := database.DBInterface.GetCollectionByName(request.FormValue("CollectionName"))
+		collection, err := database.DBInterface.GetCollectionByName(request.Context(), request.FormValue("CollectionName"))
 		if err != nil && err == sql.ErrNoRows {
 			//Does not exist, so check if we can create
 			//Validate Permission
 			if TemplateInput.UserPermissions.HasPermission(interfaces.AddCollections) != true {
 				TemplateInput.HTMLMessage += template.HTML("You do not have create collection permissions.<br>")
-				go WriteAuditLogByName(TemplateInput.UserInformation.Name, "ADD-COLLECTIONMEMBER", TemplateInput.UserInformation.Name+" failed to create a collection. Insufficient permissions. "+request.FormValue("CollectionName"))
+				go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionCreate, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Name": request.FormValue("CollectionName"), "ImageID": parsedImageID, "Error": interfaces.AuditDenied})
 				redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(parsedImageID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 				return
 			}
@@ -180,7 +181,7 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 				return
 			}
 
-			collectionID, err = database.DBInterface.NewCollection(request.FormValue("CollectionName"), "", TemplateInput.UserInformation.ID)
+			collectionID, err = database.DBInterface.NewCollection(request.Context(), request.FormValue("CollectionName"), "", TemplateInput.UserInformation.ID)
 			if err != nil {
 				logging.WriteLog(logging.LogLevelError, "collectionimagerouter/CollectionImageRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"failed to create collection", err.Error()})
 				TemplateInput.HTMLMessage += template.HTML("Failed to create new collection. SQL Error.<br>")
@@ -188,15 +189,20 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 				return
 			}
 
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionCreate, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Name": request.FormValue("CollectionName")})
 			TemplateInput.HTMLMessage += template.HTML("New collection created successfully.<br>")
 
-			if err := database.DBInterface.AddCollectionMember(collectionID, append([]uint64{}, parsedImageID), TemplateInput.UserInformation.ID); err != nil {
+			if err := changeImage(request.Context(), parsedImageID, TemplateInput.UserInformation.ID, func(Tx interfaces.DBInterface) error {
+				return Tx.AddCollectionMember(request.Context(), collectionID, append([]uint64{}, parsedImageID), TemplateInput.UserInformation.ID)
+			}); err != nil {
 				logging.WriteLog(logging.LogLevelError, "collectionimagerouter/CollectionImageRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"failed to add image to new collection", err.Error()})
 				TemplateInput.HTMLMessage += template.HTML("Failed to add image to new collection. SQL Error.<br>")
+				go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionMemberAdd, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"ImageID": parsedImageID, "Error": err.Error()})
 				redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(parsedImageID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 				return
 			}
 
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionMemberAdd, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"ImageID": parsedImageID})
 			TemplateInput.HTMLMessage += template.HTML("Image added to new collection.<br>")
 
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(parsedImageID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionSucceeded")
@@ -212,14 +218,19 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 		//Validate Permission
 		if TemplateInput.UserPermissions.HasPermission(interfaces.ModifyCollectionMembers) != true && (config.Configuration.UsersControlOwnObjects != true || collection.UploaderID != TemplateInput.UserInformation.ID) {
 			TemplateInput.HTMLMessage += template.HTML("You do not have edit member permission for this collection.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "ADD-COLLECTIONMEMBER", TemplateInput.UserInformation.Name+" failed to add a collection member. Insufficient permissions. "+collection.Name)
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionMemberAdd, interfaces.AuditTargetCollection, collection.ID, interfaces.AuditDetails{"ImageID": parsedImageID, "Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(parsedImageID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 			return
 		}
 
 		//Add image to collection
-		if err := database.DBInterface.AddCollectionMember(collection.ID, append([]uint64{}, parsedImageID), TemplateInput.UserInformation.ID); err != nil {
+		if err := changeImage(request.Context(), parsedImageID, TemplateInput.UserInformation.ID, func(Tx interfaces.DBInterface) error {
+			return Tx.AddCollectionMember(request.Context(), collection.ID, append([]uint64{}, parsedImageID), TemplateInput.UserInformation.ID)
+		}); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to add image to collection. SQL error. Check if image is already part of the collection.<br>")
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionMemberAdd, interfaces.AuditTargetCollection, collection.ID, interfaces.AuditDetails{"ImageID": parsedImageID, "Error": err.Error()})
+		} else {
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionMemberAdd, interfaces.AuditTargetCollection, collection.ID, interfaces.AuditDetails{"ImageID": parsedImageID})
 		}
 		TemplateInput.HTMLMessage += template.HTML("Image added to collection.<br>")
 		redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(parsedImageID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "CollectionSuccess")
@@ -255,16 +266,16 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 		}
 
 		//Cache collection data
-		CollectionInfo, err := database.DBInterface.GetCollection(collectionID)
+		CollectionInfo, err := database.DBInterface.GetCollection(request.Context(), collectionID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to edit collection. SQL Error.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "MODIFY-COLLECTION", TemplateInput.UserInformation.Name+" failed to modify collection. "+request.FormValue("ID")+", "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionModify, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/collections?SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 			return
 		}
 
 		//Validate unique name
-		newNameCollection, err := database.DBInterface.GetCollectionByName(newName)
+		newNameCollection, err := database.DBInterface.GetCollectionByName(request.Context(), newName)
 		if err == nil && newNameCollection.ID != CollectionInfo.ID {
 			TemplateInput.HTMLMessage += template.HTML(template.HTMLEscapeString(newName) + " is already in use, plese select a different name.<br>")
 			redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
@@ -274,19 +285,19 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 		//Validate Permission to delete
 		if TemplateInput.UserPermissions.HasPermission(interfaces.ModifyCollections) != true && (config.Configuration.UsersControlOwnObjects != true || CollectionInfo.UploaderID != TemplateInput.UserInformation.ID) {
 			TemplateInput.HTMLMessage += template.HTML("You do not have edit member permission for collection.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "MODIFY-COLLECTION", TemplateInput.UserInformation.Name+" failed to modify collection. Insufficient permissions. "+request.FormValue("ID"))
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionModify, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 			return
 		}
 
 		//Permission validated, now modify
-		if err := database.DBInterface.UpdateCollection(collectionID, newName, newDesc); err != nil {
+		if err := database.DBInterface.UpdateCollection(request.Context(), collectionID, newName, newDesc); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to modify collection. SQL Error.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "MODIFY-COLLECTION", TemplateInput.UserInformation.Name+" failed to modify collection. "+request.FormValue("ID")+", "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionModify, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 			return
 		}
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "MODIFY-COLLECTION", TemplateInput.UserInformation.Name+" modified collection ("+request.FormValue("ID")+")")
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionModify, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Name": newName, "Description": newDesc})
 		TemplateInput.HTMLMessage += template.HTML("Successfully modified collection.<br>")
 		redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionSuccess")
 		return
@@ -306,10 +317,10 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 		}
 
 		//Cache collection data
-		CollectionInfo, err := database.DBInterface.GetCollection(collectionID)
+		CollectionInfo, err := database.DBInterface.GetCollection(request.Context(), collectionID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to delete collection. SQL Error.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-COLLECTION", TemplateInput.UserInformation.Name+" failed to delete collection. "+request.FormValue("ID")+", "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/collections?SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 			return
 		}
@@ -317,19 +328,19 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 		//Validate Permission to delete
 		if TemplateInput.UserPermissions.HasPermission(interfaces.RemoveCollections) != true && (config.Configuration.UsersControlOwnObjects != true || CollectionInfo.UploaderID != TemplateInput.UserInformation.ID) {
 			TemplateInput.HTMLMessage += template.HTML("User does not have delete permission for collection.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-COLLECTION", TemplateInput.UserInformation.Name+" failed to delete collection. Insufficient permissions. "+request.FormValue("ID"))
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 			return
 		}
 
 		//Permission validated, now delete (Collection)
-		if err := database.DBInterface.DeleteCollection(collectionID); err != nil {
+		if err := database.DBInterface.DeleteCollection(request.Context(), collectionID); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to delete collection. SQL Error.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-COLLECTION", TemplateInput.UserInformation.Name+" failed to delete collection. "+request.FormValue("ID")+", "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(userQuery), TemplateInput.HTMLMessage, "CollectionFailed")
 			return
 		}
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-COLLECTION", TemplateInput.UserInformation.Name+" deleted collection. "+request.FormValue("ID")+", "+CollectionInfo.Name)
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Name": CollectionInfo.Name})
 		TemplateInput.HTMLMessage += template.HTML("Successfully deleted collection " + template.HTMLEscapeString(CollectionInfo.Name) + ".<br>")
 		redirectWithFlash(responseWriter, request, "/collections?"+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "Success")
 		return
@@ -349,10 +360,10 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 		}
 
 		//Cache collection data
-		CollectionInfo, err := database.DBInterface.GetCollection(collectionID)
+		CollectionInfo, err := database.DBInterface.GetCollection(request.Context(), collectionID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to delete collection. SQL Error.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-COLLECTION", TemplateInput.UserInformation.Name+" failed to delete collection. "+request.FormValue("ID")+", "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"DeleteMembers": true, "Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/collections?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "DeleteFailed")
 			return
 		}
@@ -360,16 +371,16 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 		//Validate Permission to delete
 		if TemplateInput.UserPermissions.HasPermission(interfaces.RemoveCollections) != true && (config.Configuration.UsersControlOwnObjects != true || CollectionInfo.UploaderID != TemplateInput.UserInformation.ID) {
 			TemplateInput.HTMLMessage += template.HTML("User does not have delete permission for collection.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-COLLECTION", TemplateInput.UserInformation.Name+" failed to delete collection. Insufficient permissions. "+request.FormValue("ID"))
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"DeleteMembers": true, "Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "DeleteFailed")
 			return
 		}
 
 		//Grab list of images
-		CollectionMembers, _, err := database.DBInterface.GetCollectionMembers(collectionID, 0, 0)
+		CollectionMembers, _, err := database.DBInterface.GetCollectionMembers(request.Context(), collectionID, 0, 0)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to delete collection. SQL Error getting collection memebers.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-COLLECTION", TemplateInput.UserInformation.Name+" failed to delete collection. "+request.FormValue("ID")+", "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"DeleteMembers": true, "Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "DeleteFailed")
 			return
 		}
@@ -380,7 +391,7 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 			//Validate Permission to delete
 			if TemplateInput.UserPermissions.HasPermission(interfaces.RemoveImage) != true && (config.Configuration.UsersControlOwnObjects != true || ImageInfo.UploaderID != TemplateInput.UserInformation.ID) {
 				TemplateInput.HTMLMessage += template.HTML("User does not have delete permission for image.<br>")
-				go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-IMAGE", TemplateInput.UserInformation.Name+" failed to delete image. Insufficient permissions. "+request.FormValue("ID"))
+				go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditImageDelete, interfaces.AuditTargetImage, ImageInfo.ID, interfaces.AuditDetails{"CollectionID": collectionID, "Error": interfaces.AuditDenied})
 				canDelete = false
 				break
 			}
@@ -392,28 +403,25 @@ func CollectionPostRouter(responseWriter http.ResponseWriter, request *http.Requ
 		}
 
 		//Permission validated, now delete (Collection)
-		if err := database.DBInterface.DeleteCollection(collectionID); err != nil {
+		if err := database.DBInterface.DeleteCollection(request.Context(), collectionID); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to delete collection. SQL Error.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-COLLECTION", TemplateInput.UserInformation.Name+" failed to delete collection. "+request.FormValue("ID")+", "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"DeleteMembers": true, "Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/collection?ID="+strconv.FormatUint(collectionID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "DeleteFailed")
 			return
 		}
 
-		//Delete images
+		//Move images to the recycle bin
 		for _, ImageInfo := range CollectionMembers {
-			err = database.DBInterface.DeleteImage(ImageInfo.ID)
+			err = database.DBInterface.TrashImage(request.Context(), ImageInfo.ID, TemplateInput.UserInformation.ID)
 			if err != nil {
 				TemplateInput.HTMLMessage += template.HTML("Failed to delete image " + strconv.FormatUint(ImageInfo.ID, 10) + ".<br>")
-				go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-IMAGE", TemplateInput.UserInformation.Name+" failed to delete image.")
+				go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditImageDelete, interfaces.AuditTargetImage, ImageInfo.ID, interfaces.AuditDetails{"CollectionID": collectionID, "Error": err.Error()})
 			} else {
-				//Delete Image from Disk
-				go os.Remove(path.Join(config.Configuration.ImageDirectory, ImageInfo.Location))
-				//Delete thumbnail from disk
-				go os.Remove(path.Join(config.Configuration.ImageDirectory, "thumbs"+string(filepath.Separator)+ImageInfo.Location+".png"))
+				go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditImageDelete, interfaces.AuditTargetImage, ImageInfo.ID, interfaces.AuditDetails{"CollectionID": collectionID, "Name": ImageInfo.Name, "Location": ImageInfo.Location})
 			}
 		}
 
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-COLLECTION", TemplateInput.UserInformation.Name+" deleted collection. "+request.FormValue("ID")+", "+CollectionInfo.Name)
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditCollectionDelete, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Name": CollectionInfo.Name, "DeleteMembers": true})
 		TemplateInput.HTMLMessage += template.HTML("Successfully deleted collection " + template.HTMLEscapeString(CollectionInfo.Name) + ".<br>")
 		redirectWithFlash(responseWriter, request, "/collections?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "DeleteSuccess")
 		return
@@ -1,6 +1,7 @@
 package routers
 
 import (
+	"errors"
 	"go-image-board/config"
 	"go-image-board/database"
 	"go-image-board/interfaces"
@@ -26,11 +27,13 @@ func CollectionsRouter(responseWriter http.ResponseWriter, request *http.Request
 		pageStart = upageStart
 	}
 
-	userQTags, err := database.DBInterface.GetQueryTags(TemplateInput.OldQuery, true)
+	userQTags, err := database.DBInterface.GetQueryTags(request.Context(), ExpandSavedSearches(request.Context(), TemplateInput.UserInformation.ID, TemplateInput.OldQuery), true)
 	if err == nil {
+		//Page links are built from the query, keep them in this random order's shuffle
+		TemplateInput.OldQuery = interfaces.PinRandomOrder(TemplateInput.OldQuery, userQTags)
 		//if signed in, add user's global filters to query
 		if TemplateInput.IsLoggedOn() {
-			userFilterTags, err := database.DBInterface.GetUserFilterTags(TemplateInput.UserInformation.ID, true)
+			userFilterTags, err := database.DBInterface.GetUserFilterTags(request.Context(), TemplateInput.UserInformation.ID, true)
 			if err != nil {
 				logging.WriteLog(logging.LogLevelError, "collectionqueryrouter/CollectionsRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to load user's filter", err.Error()})
 				TemplateInput.HTMLMessage += template.HTML("Failed to add your global filter to this query. Internal error.<br>")
@@ -40,7 +43,7 @@ func CollectionsRouter(responseWriter http.ResponseWriter, request *http.Request
 		}
 
 		//Perform Query
-		collectionInfo, MaxCount, err := database.DBInterface.SearchCollections(userQTags, pageStart, pageStride)
+		collectionInfo, MaxCount, err := database.DBInterface.SearchCollections(request.Context(), userQTags, pageStart, pageStride)
 		if err == nil {
 			TemplateInput.CollectionInfoList = collectionInfo
 			TemplateInput.TotalResults = MaxCount
@@ -59,6 +62,11 @@ func CollectionsRouter(responseWriter http.ResponseWriter, request *http.Request
 		}
 	} else {
 		logging.WriteLog(logging.LogLevelError, "CollectionQueryRouter/CollectionsRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to validate tags", TemplateInput.OldQuery, err.Error()})
+		if errors.Is(err, interfaces.ErrWildcardTooBroad) {
+			TemplateInput.HTMLMessage += template.HTML("A wildcard in your search matches too many tags, try making it more specific.<br>")
+		} else if errors.Is(err, interfaces.ErrOrderInGroup) {
+			TemplateInput.HTMLMessage += template.HTML("Orders sort the whole search, move order: out of the OR group.<br>")
+		}
 	}
 
 	TemplateInput.Tags = userQTags
@@ -51,13 +51,21 @@ func ImageQueryRouter(responseWriter http.ResponseWriter, request *http.Request)
 		//default to 0 on err
 		pageStart = upageStart
 	}
+	//The cursor continues after the previous page's last image, which stays fast deep into the results. PageStart is still used for the page menu
+	cursor := request.FormValue("Cursor")
+
+	//Replace saved:name with the user's saved searches, the expanded query is also what result counts are cached by so editing a saved search is seen straight away
+	expandedQuery := ExpandSavedSearches(request.Context(), TemplateInput.UserInformation.ID, userQuery)
 
 	//Cleanup and format tags for use with SearchImages
-	userQTags, err := database.DBInterface.GetQueryTags(userQuery, false)
+	userQTags, err := database.DBInterface.GetQueryTags(request.Context(), expandedQuery, false)
 	if err == nil {
+		//Links to more results are built from the query, keep them in this random order's shuffle
+		userQuery = interfaces.PinRandomOrder(userQuery, userQTags)
+		TemplateInput.OldQuery = userQuery
 		//if signed in, add user's global filters to query
 		if TemplateInput.UserInformation.Name != "" {
-			userFilterTags, err := database.DBInterface.GetUserFilterTags(TemplateInput.UserInformation.ID, false)
+			userFilterTags, err := database.DBInterface.GetUserFilterTags(request.Context(), TemplateInput.UserInformation.ID, false)
 			if err != nil {
 				logging.WriteLog(logging.LogLevelError, "imagequeryrouter/ImageQueryRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to load user's filter", err.Error()})
 				TemplateInput.HTMLMessage += template.HTML("Failed to add your global filter to this query. Internal error.<br>")
@@ -67,7 +75,7 @@ func ImageQueryRouter(responseWriter http.ResponseWriter, request *http.Request)
 		}
 		//Return random image if requested
 		if request.FormValue("SearchType") == "Random" || TemplateInput.ViewMode == "slideshow" {
-			imageInfo, _, err := database.DBInterface.GetRandomImage(userQTags)
+			imageInfo, _, err := database.DBInterface.GetRandomImage(request.Context(), userQTags)
 			if err == nil {
 				//redirect user to randomly selected image
 				http.Redirect(responseWriter, request, "/image?ID="+strconv.FormatUint(imageInfo.ID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), 302)
@@ -76,11 +84,22 @@ func ImageQueryRouter(responseWriter http.ResponseWriter, request *http.Request)
 			logging.WriteLog(logging.LogLevelError, "imagequeryrouter/ImageQueryRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to search random image", userQuery, err.Error()})
 			TemplateInput.HTMLMessage += template.HTML("Failed to search for a random image.<br>") //Just fall through to the normal search
 		}
-		//Parse tag results for next query
-		imageInfo, MaxCount, err := database.DBInterface.SearchImages(userQTags, pageStart, pageStride)
+		//Parse tag results for next query, counting every match only when the count is not cached
+		cachedCount, hasCount := SearchCounts.GetValue(TemplateInput.UserInformation.ID, expandedQuery)
+		imageInfo, nextCursor, MaxCount, err := database.DBInterface.SearchImagesPage(request.Context(), userQTags, cursor, pageStart, pageStride, hasCount == false)
 		if err == nil {
+			if hasCount {
+				MaxCount = cachedCount
+			} else {
+				SearchCounts.SetValue(TemplateInput.UserInformation.ID, expandedQuery, MaxCount)
+			}
 			TemplateInput.ImageInfo = imageInfo
 			TemplateInput.TotalResults = MaxCount
+			if nextCursor != "" {
+				TemplateInput.NextPageLink = "/images?SearchTerms=" + url.QueryEscape(userQuery) + "&PageStart=" + strconv.FormatUint(pageStart+pageStride, 10) + "&Cursor=" + url.QueryEscape(nextCursor)
+			}
+		} else if errors.Is(err, interfaces.ErrInvalidCursor) {
+			TemplateInput.HTMLMessage += template.HTML("This page link is no longer valid, try going back to the first page.<br>")
 		} else {
 			parsed := ""
 			for _, tag := range userQTags {
@@ -96,6 +115,11 @@ func ImageQueryRouter(responseWriter http.ResponseWriter, request *http.Request)
 		}
 	} else {
 		logging.WriteLog(logging.LogLevelError, "imagequeryrouter/ImageQueryRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to validate tags", userQuery, err.Error()})
+		if errors.Is(err, interfaces.ErrWildcardTooBroad) {
+			TemplateInput.HTMLMessage += template.HTML("A wildcard in your search matches too many tags, try making it more specific.<br>")
+		} else if errors.Is(err, interfaces.ErrOrderInGroup) {
+			TemplateInput.HTMLMessage += template.HTML("Orders sort the whole search, move order: out of the OR group.<br>")
+		}
 	}
 
 	TemplateInput.Tags = userQTags
@@ -0,0 +1,268 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"maps"
+	"slices"
+)
+
+//imageState is the part of an image that revisions track
+type imageState struct {
+	Fields map[string]string
+	//Tags maps TagID -> tag name
+	Tags map[uint64]string
+	//Collections maps CollectionID -> collection name
+	Collections map[uint64]string
+}
+
+//revisionFields are the image fields revisions track, in the order their changes are recorded
+var revisionFields = []string{interfaces.RevisionName, interfaces.RevisionDescription, interfaces.RevisionSource, interfaces.RevisionRating}
+
+//getImageState reads the tracked fields, tags and collections of an image
+func getImageState(ctx context.Context, DB interfaces.DBInterface, ImageID uint64) (imageState, error) {
+	ImageInfo, err := DB.GetImage(ctx, ImageID)
+	if err != nil {
+		return imageState{}, err
+	}
+	State := imageState{
+		Fields: map[string]string{
+			interfaces.RevisionName:        ImageInfo.Name,
+			interfaces.RevisionDescription: ImageInfo.Description,
+			interfaces.RevisionSource:      ImageInfo.Source,
+			interfaces.RevisionRating:      ImageInfo.Rating,
+		},
+		Tags:        make(map[uint64]string),
+		Collections: make(map[uint64]string),
+	}
+	Tags, err := DB.GetImageTags(ctx, ImageID)
+	if err != nil {
+		return imageState{}, err
+	}
+	for _, Tag := range Tags {
+		State.Tags[Tag.ID] = Tag.Name
+	}
+	Collections, err := DB.GetCollectionsWithImage(ctx, ImageID)
+	if err != nil {
+		return imageState{}, err
+	}
+	for _, Collection := range Collections {
+		State.Collections[Collection.ID] = Collection.Name
+	}
+	return State, nil
+}
+
+//value returns what the state holds for the field, tag or collection a revision changed
+func (State imageState) value(Revision interfaces.ImageRevision) string {
+	switch Revision.Field {
+	case interfaces.RevisionTag:
+		return State.Tags[Revision.TagID]
+	case interfaces.RevisionCollection:
+		return State.Collections[Revision.CollectionID]
+	}
+	return State.Fields[Revision.Field]
+}
+
+//setValue changes the field, tag or collection a revision changed to Value
+func (State imageState) setValue(Revision interfaces.ImageRevision, Value string) {
+	switch Revision.Field {
+	case interfaces.RevisionTag:
+		State.Tags[Revision.TagID] = Value
+	case interfaces.RevisionCollection:
+		State.Collections[Revision.CollectionID] = Value
+	default:
+		State.Fields[Revision.Field] = Value
+	}
+}
+
+//revisionKey identifies the field, tag or collection a revision changed, so changes to the same one can be matched up
+type revisionKey struct {
+	Field string
+	ID    uint64
+}
+
+//keyOf returns the revisionKey of a revision
+func keyOf(Revision interfaces.ImageRevision) revisionKey {
+	switch Revision.Field {
+	case interfaces.RevisionTag:
+		return revisionKey{Field: Revision.Field, ID: Revision.TagID}
+	case interfaces.RevisionCollection:
+		return revisionKey{Field: Revision.Field, ID: Revision.CollectionID}
+	}
+	return revisionKey{Field: Revision.Field}
+}
+
+//diffImageState returns a revision for every field, tag link and collection membership that differs between Before and After
+func diffImageState(Before imageState, After imageState) []interfaces.ImageRevision {
+	var Revisions []interfaces.ImageRevision
+	for _, Field := range revisionFields {
+		if Before.Fields[Field] != After.Fields[Field] {
+			Revisions = append(Revisions, interfaces.ImageRevision{Field: Field, OldValue: Before.Fields[Field], NewValue: After.Fields[Field]})
+		}
+	}
+	for _, TagID := range changedLinks(Before.Tags, After.Tags) {
+		Revisions = append(Revisions, interfaces.ImageRevision{Field: interfaces.RevisionTag, TagID: TagID, OldValue: Before.Tags[TagID], NewValue: After.Tags[TagID]})
+	}
+	for _, CollectionID := range changedLinks(Before.Collections, After.Collections) {
+		Revisions = append(Revisions, interfaces.ImageRevision{Field: interfaces.RevisionCollection, CollectionID: CollectionID, OldValue: Before.Collections[CollectionID], NewValue: After.Collections[CollectionID]})
+	}
+	return Revisions
+}
+
+//changedLinks returns the sorted IDs whose name differs between Before and After, including IDs only one of them has
+func changedLinks(Before map[uint64]string, After map[uint64]string) []uint64 {
+	var IDs []uint64
+	for ID, Name := range Before {
+		if After[ID] != Name {
+			IDs = append(IDs, ID)
+		}
+	}
+	for ID := range After {
+		if _, found := Before[ID]; found == false {
+			IDs = append(IDs, ID)
+		}
+	}
+	slices.Sort(IDs)
+	return IDs
+}
+
+//changeImage runs Change in a transaction, then records every change it made to the image as a revision made by UserID
+func changeImage(ctx context.Context, ImageID uint64, UserID uint64, Change func(Tx interfaces.DBInterface) error) error {
+	return changeImages(ctx, database.DBInterface, UserID, func(Tx interfaces.DBInterface) ([]uint64, error) {
+		return []uint64{ImageID}, nil
+	}, Change)
+}
+
+//changeTaggedImages is changeImage for changes to every image with TagID, such as BulkAddTag and ReplaceImageTags
+func changeTaggedImages(ctx context.Context, TagID uint64, UserID uint64, Change func(Tx interfaces.DBInterface) error) error {
+	return changeImages(ctx, database.DBInterface, UserID, func(Tx interfaces.DBInterface) ([]uint64, error) {
+		ImageIDs, err := Tx.GetTagImageIDs(ctx, TagID)
+		if err != nil {
+			return nil, err
+		}
+		//Bulk operations link the tag an alias points to
+		if Tag, err := Tx.GetTag(ctx, TagID, false); err == nil && Tag.IsAlias {
+			AliasedImageIDs, err := Tx.GetTagImageIDs(ctx, Tag.AliasedID)
+			if err != nil {
+				return nil, err
+			}
+			ImageIDs = append(ImageIDs, AliasedImageIDs...)
+		}
+		return ImageIDs, nil
+	}, Change)
+}
+
+//changeImages runs Change in a transaction on DB, then records every change it made to the images listed by ImageIDs as revisions made by UserID.
+//ImageIDs is called inside the transaction, before Change
+func changeImages(ctx context.Context, DB interfaces.DBInterface, UserID uint64, ImageIDs func(Tx interfaces.DBInterface) ([]uint64, error), Change func(Tx interfaces.DBInterface) error) error {
+	return DB.RunInTransaction(ctx, func(Tx interfaces.DBInterface) error {
+		IDs, err := ImageIDs(Tx)
+		if err != nil {
+			return err
+		}
+		Before := make(map[uint64]imageState)
+		for _, ImageID := range IDs {
+			if Before[ImageID], err = getImageState(ctx, Tx, ImageID); err != nil {
+				return err
+			}
+		}
+		if err := Change(Tx); err != nil {
+			return err
+		}
+		for _, ImageID := range slices.Sorted(maps.Keys(Before)) {
+			After, err := getImageState(ctx, Tx, ImageID)
+			if err != nil {
+				return err
+			}
+			for _, Revision := range diffImageState(Before[ImageID], After) {
+				Revision.ImageID = ImageID
+				Revision.UserID = UserID
+				if err := Tx.AddImageRevision(ctx, Revision); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+//revertImage puts an image back the way it was before RevisionID, undoing that change and every later one.
+//The revert is itself recorded as revisions made by UserID. Tags and collections that have since been deleted are not added back
+func revertImage(ctx context.Context, ImageID uint64, RevisionID uint64, UserID uint64) error {
+	return changeImage(ctx, ImageID, UserID, func(Tx interfaces.DBInterface) error {
+		Revisions, err := Tx.GetImageRevisionsSince(ctx, ImageID, RevisionID)
+		if err != nil {
+			return err
+		}
+		if len(Revisions) == 0 || Revisions[0].ID != RevisionID {
+			return errors.New("revision does not belong to this image")
+		}
+		//The first change to each field, tag or collection after RevisionID holds the value it had before
+		Target, err := getImageState(ctx, Tx, ImageID)
+		if err != nil {
+			return err
+		}
+		seen := make(map[revisionKey]bool)
+		for _, Revision := range Revisions {
+			if seen[keyOf(Revision)] == false {
+				seen[keyOf(Revision)] = true
+				Target.setValue(Revision, Revision.OldValue)
+			}
+		}
+		return applyImageState(ctx, Tx, ImageID, Target, UserID)
+	})
+}
+
+//applyImageState changes an image's tracked fields, tags and collections to match Target, tags and collections with an empty name are removed
+func applyImageState(ctx context.Context, Tx interfaces.DBInterface, ImageID uint64, Target imageState, UserID uint64) error {
+	if err := Tx.UpdateImage(ctx, ImageID, Target.Fields[interfaces.RevisionName], Target.Fields[interfaces.RevisionDescription], nil, Target.Fields[interfaces.RevisionRating], Target.Fields[interfaces.RevisionSource], nil); err != nil {
+		return err
+	}
+	Current, err := getImageState(ctx, Tx, ImageID)
+	if err != nil {
+		return err
+	}
+	for TagID := range Current.Tags {
+		if Target.Tags[TagID] == "" {
+			if err := Tx.RemoveTag(ctx, TagID, ImageID); err != nil {
+				return err
+			}
+		}
+	}
+	var AddTagIDs []uint64
+	for _, TagID := range slices.Sorted(maps.Keys(Target.Tags)) {
+		if _, found := Current.Tags[TagID]; found || Target.Tags[TagID] == "" {
+			continue
+		}
+		if _, err := Tx.GetTag(ctx, TagID, false); err != nil {
+			continue //Deleted since
+		}
+		AddTagIDs = append(AddTagIDs, TagID)
+	}
+	if len(AddTagIDs) > 0 {
+		if err := Tx.AddTag(ctx, AddTagIDs, ImageID, UserID); err != nil {
+			return err
+		}
+	}
+	for CollectionID := range Current.Collections {
+		if Target.Collections[CollectionID] == "" {
+			if err := Tx.RemoveCollectionMember(ctx, CollectionID, ImageID); err != nil {
+				return err
+			}
+		}
+	}
+	for _, CollectionID := range slices.Sorted(maps.Keys(Target.Collections)) {
+		if _, found := Current.Collections[CollectionID]; found || Target.Collections[CollectionID] == "" {
+			continue
+		}
+		if _, err := Tx.GetCollection(ctx, CollectionID); err != nil {
+			continue //Deleted since
+		}
+		if err := Tx.AddCollectionMember(ctx, CollectionID, []uint64{ImageID}, UserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -10,9 +10,6 @@ import (
 	"html/template"
 	"net/http"
 	"net/url"
-	"os"
-	"path"
-	"path/filepath"
 	"strconv"
 	"strings"
 )
@@ -33,16 +30,22 @@ func ImageGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	}
 
 	//Get Imageinformation
-	imageInfo, err := database.DBInterface.GetImage(requestedID)
+	imageInfo, err := database.DBInterface.GetImage(request.Context(), requestedID)
 	if err != nil {
 		TemplateInput.HTMLMessage += template.HTML("Failed to get image information.<br>")
 		logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to get image info for", strconv.FormatUint(requestedID, 10), err.Error()})
 		redirectWithFlash(responseWriter, request, "/images?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "ImageFail")
 		return
 	}
+	//Only mods can see what is in the recycle bin
+	if imageInfo.DeletedTime.IsZero() == false && TemplateInput.UserPermissions.HasPermission(interfaces.RemoveImage) != true {
+		TemplateInput.HTMLMessage += template.HTML("No image selected or image not found.<br>")
+		redirectWithFlash(responseWriter, request, "/images?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "ImageFail")
+		return
+	}
 
 	//Get Collection Info
-	imageInfo.MemberCollections, err = database.DBInterface.GetCollectionsWithImage(requestedID)
+	imageInfo.MemberCollections, err = database.DBInterface.GetCollectionsWithImage(request.Context(), requestedID)
 	if err != nil {
 		//log err but no need to inform user
 		logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to get collection info for", strconv.FormatUint(requestedID, 10), err.Error()})
@@ -51,11 +54,13 @@ func ImageGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	if TemplateInput.OldQuery != "" {
 		//Get next and previous image based on query
 
-		userQTags, err := database.DBInterface.GetQueryTags(TemplateInput.OldQuery, false)
+		userQTags, err := database.DBInterface.GetQueryTags(request.Context(), ExpandSavedSearches(request.Context(), TemplateInput.UserInformation.ID, TemplateInput.OldQuery), false)
 		if err == nil {
+			//The previous and next links keep this random order's shuffle
+			TemplateInput.OldQuery = interfaces.PinRandomOrder(TemplateInput.OldQuery, userQTags)
 			//if signed in, add user's global filters to query
 			if TemplateInput.UserInformation.Name != "" {
-				userFilterTags, err := database.DBInterface.GetUserFilterTags(TemplateInput.UserInformation.ID, false)
+				userFilterTags, err := database.DBInterface.GetUserFilterTags(request.Context(), TemplateInput.UserInformation.ID, false)
 				if err != nil {
 					logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to load user's filter", err.Error()})
 					TemplateInput.HTMLMessage += template.HTML("Failed to add your global filter to this query. Internal error.<br>")
@@ -63,7 +68,7 @@ func ImageGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
 					userQTags = interfaces.RemoveDuplicateTags(append(userQTags, userFilterTags...))
 				}
 			}
-			prevNextImage, err := database.DBInterface.GetPrevNexImages(userQTags, requestedID)
+			prevNextImage, err := database.DBInterface.GetPrevNexImages(request.Context(), userQTags, requestedID)
 			if err == nil {
 				if len(prevNextImage) == 2 {
 					TemplateInput.NextMemberID = prevNextImage[1].ID
@@ -85,9 +90,9 @@ func ImageGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	TemplateInput.ImageContentInfo = imageInfo
 
 	if config.Configuration.ShowSimilarOnImages {
-		similarTag, err := database.DBInterface.GetQueryTags("similar:"+strconv.FormatUint(imageInfo.ID, 10), false)
+		similarTag, err := database.DBInterface.GetQueryTags(request.Context(), "similar:"+strconv.FormatUint(imageInfo.ID, 10), false)
 		if err == nil {
-			_, similarCount, _ := database.DBInterface.SearchImages(similarTag, 0, config.Configuration.PageStride)
+			_, similarCount, _ := database.DBInterface.SearchImages(request.Context(), similarTag, 0, config.Configuration.PageStride)
 			if similarCount > 1 {
 				TemplateInput.SimilarCount = similarCount - 1 //Remove the current image from count
 			}
@@ -102,18 +107,32 @@ func ImageGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
 
 	//Get vote information if logged in
 	if TemplateInput.IsLoggedOn() {
-		TemplateInput.ImageContentInfo.UsersVotedScore, err = database.DBInterface.GetUserVoteScore(TemplateInput.UserInformation.ID, requestedID)
+		TemplateInput.ImageContentInfo.UsersVotedScore, err = database.DBInterface.GetUserVoteScore(request.Context(), TemplateInput.UserInformation.ID, requestedID)
 	}
 
 	//Get the image content information based on type (Img, vs video vs...)
 	TemplateInput.ImageContent = templatecache.GetEmbedForContent(imageInfo.Location)
 
-	TemplateInput.Tags, err = database.DBInterface.GetImageTags(imageInfo.ID)
+	TemplateInput.Tags, err = database.DBInterface.GetImageTags(request.Context(), imageInfo.ID)
 	if err != nil {
 		TemplateInput.HTMLMessage += template.HTML("Failed to load tags.<br>")
 		logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to load tags", err.Error()})
 	}
 
+	//Get a page of the image's history, defaulting to the newest changes
+	var historyStart uint64
+	if parsedHistoryStart, err := strconv.ParseUint(request.FormValue("PageStart"), 10, 32); err == nil {
+		historyStart = parsedHistoryStart
+	}
+	var historyCount uint64
+	TemplateInput.ImageRevisions, historyCount, err = database.DBInterface.GetImageRevisions(request.Context(), imageInfo.ID, historyStart, imageHistoryStride)
+	if err != nil {
+		TemplateInput.HTMLMessage += template.HTML("Failed to load image history.<br>")
+		logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to load image history", err.Error()})
+	} else if historyCount > imageHistoryStride {
+		TemplateInput.PageMenu, _ = generatePageMenu(int64(historyStart), imageHistoryStride, int64(historyCount), "ID="+strconv.FormatUint(imageInfo.ID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), "/image")
+	}
+
 	if TemplateInput.ViewMode == "slideshow" {
 		replyWithTemplate("image-slideshow-js.html", TemplateInput, responseWriter, request)
 		return
@@ -122,12 +141,25 @@ func ImageGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	replyWithTemplate("image.html", TemplateInput, responseWriter, request)
 }
 
+//imageHistoryStride how many changes are shown per page of an image's history
+const imageHistoryStride = 10
+
 //ImagePostRouter serves post requests to /image
 func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	TemplateInput := getTemplateInputFromRequest(responseWriter, request)
 	var requestedID uint64
 	var err error
 	var duplicateIDs map[string]uint64
+	//Only mods can change what is in the recycle bin, to everyone else it is not there
+	if request.FormValue("command") != "uploadFile" && TemplateInput.UserPermissions.HasPermission(interfaces.RemoveImage) != true {
+		if ImageID, err := strconv.ParseUint(request.FormValue("ID"), 10, 64); err == nil {
+			if imageInfo, err := database.DBInterface.GetImage(request.Context(), ImageID); err == nil && imageInfo.DeletedTime.IsZero() == false {
+				TemplateInput.HTMLMessage += template.HTML("No image selected or image not found.<br>")
+				redirectWithFlash(responseWriter, request, "/images?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "ImageFail")
+				return
+			}
+		}
+	}
 	//If we are just now uploading the file, then we need to get ID from upload function
 	switch request.FormValue("command") {
 	case "uploadFile":
@@ -154,7 +186,9 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 				break
 			}
 		}
-		TemplateInput.HTMLMessage += template.HTML("Upload complete. ")
+		if requestedID != 0 {
+			TemplateInput.HTMLMessage += template.HTML("Upload complete. ")
+		}
 		redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UploadFinished")
 		return
 	case "ChangeVote":
@@ -174,7 +208,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			return
 		}
 		//Validate permission to vote
-		imageInfo, err := database.DBInterface.GetImage(requestedID)
+		imageInfo, err := database.DBInterface.GetImage(request.Context(), requestedID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to get image information.<br>")
 			redirectWithFlash(responseWriter, request, "/images?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
@@ -182,7 +216,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		}
 
 		if !(TemplateInput.UserPermissions.HasPermission(interfaces.ScoreImage) || (imageInfo.UploaderID == TemplateInput.UserInformation.ID && config.Configuration.UsersControlOwnObjects)) {
-			go WriteAuditLog(TemplateInput.UserInformation.ID, "IMAGE-SCORE", TemplateInput.UserInformation.Name+" failed to score image. No permissions.")
+			go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditImageScore, interfaces.AuditTargetImage, requestedID, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 			TemplateInput.HTMLMessage += template.HTML("You do not have permissions to vote on this image.<br>")
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
 			return
@@ -201,7 +235,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
 			return
 		}
-		if err := database.DBInterface.UpdateUserVoteScore(TemplateInput.UserInformation.ID, requestedID, Score); err != nil {
+		if err := database.DBInterface.UpdateUserVoteScore(request.Context(), TemplateInput.UserInformation.ID, requestedID, Score); err != nil {
 			logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter/ChangeVota", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to set vote in database", err.Error()})
 			TemplateInput.HTMLMessage += template.HTML("Failed to set vote in database, internal error.<br>")
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
@@ -227,7 +261,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			return
 		}
 		//Validate permission to vote
-		imageInfo, err := database.DBInterface.GetImage(requestedID)
+		imageInfo, err := database.DBInterface.GetImage(request.Context(), requestedID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to get image information.<br>")
 			redirectWithFlash(responseWriter, request, "/images?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
@@ -235,7 +269,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		}
 
 		if !(TemplateInput.UserPermissions.HasPermission(interfaces.SourceImage) || (imageInfo.UploaderID == TemplateInput.UserInformation.ID && config.Configuration.UsersControlOwnObjects)) {
-			go WriteAuditLog(TemplateInput.UserInformation.ID, "IMAGE-SOURCE", TemplateInput.UserInformation.Name+" failed to source image. No permissions.")
+			go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditImageSource, interfaces.AuditTargetImage, requestedID, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 			TemplateInput.HTMLMessage += template.HTML("You do not have permissions to change the source of this image.<br>")
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
 			return
@@ -245,7 +279,9 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		//At this point, user is validated
 		Source := request.FormValue("NewSource")
 
-		if err := database.DBInterface.SetImageSource(requestedID, Source); err != nil {
+		if err := changeImage(request.Context(), requestedID, TemplateInput.UserInformation.ID, func(Tx interfaces.DBInterface) error {
+			return Tx.SetImageSource(request.Context(), requestedID, Source)
+		}); err != nil {
 			logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter/ChangeSource", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to set source in database", err.Error()})
 			TemplateInput.HTMLMessage += template.HTML("Failed to set source in database, internal error.<br>")
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
@@ -271,7 +307,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			return
 		}
 		//Validate permission to vote
-		imageInfo, err := database.DBInterface.GetImage(requestedID)
+		imageInfo, err := database.DBInterface.GetImage(request.Context(), requestedID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to get image information.<br>")
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
@@ -279,7 +315,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		}
 
 		if !(TemplateInput.UserPermissions.HasPermission(interfaces.SourceImage) || (imageInfo.UploaderID == TemplateInput.UserInformation.ID && config.Configuration.UsersControlOwnObjects)) {
-			go WriteAuditLog(TemplateInput.UserInformation.ID, "IMAGE-NAME", TemplateInput.UserInformation.Name+" failed to name image. No permissions.")
+			go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditImageName, interfaces.AuditTargetImage, requestedID, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 			TemplateInput.HTMLMessage += template.HTML("You do not have permissions to change the name/description of this image.<br>")
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
 			return
@@ -290,7 +326,9 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		Name := request.FormValue("NewName")
 		Description := request.FormValue("NewDescription")
 
-		if err := database.DBInterface.UpdateImage(requestedID, Name, Description, nil, nil, nil, nil); err != nil {
+		if err := changeImage(request.Context(), requestedID, TemplateInput.UserInformation.ID, func(Tx interfaces.DBInterface) error {
+			return Tx.UpdateImage(request.Context(), requestedID, Name, Description, nil, nil, nil, nil)
+		}); err != nil {
 			logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter/ChangeName", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to set name in database", err.Error()})
 			TemplateInput.HTMLMessage += template.HTML("Failed to set name/description in database, internal error.<br>")
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
@@ -315,7 +353,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			return
 		}
 
-		imageInfo, err := database.DBInterface.GetImage(requestedID)
+		imageInfo, err := database.DBInterface.GetImage(request.Context(), requestedID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Error parsing image id.<br>")
 			logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter/RemoveTag", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to parse image id ", err.Error()})
@@ -326,7 +364,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		//Validate permission to manage tags
 		if TemplateInput.UserPermissions.HasPermission(interfaces.ModifyImageTags) != true && (config.Configuration.UsersControlOwnObjects != true || TemplateInput.UserInformation.ID != imageInfo.UploaderID) {
 			TemplateInput.HTMLMessage += template.HTML("User does not have modify permission for tags on images.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "REMOVE-IMAGETAG", TemplateInput.UserInformation.Name+" failed to remove tag from image "+strconv.FormatUint(requestedID, 10)+". Insufficient permissions. "+TagID)
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditImageTagRemove, interfaces.AuditTargetImage, requestedID, interfaces.AuditDetails{"TagID": TagID, "Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
 			return
 		}
@@ -339,13 +377,15 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			return
 		}
 		//Remove tag
-		if err := database.DBInterface.RemoveTag(requestedTagID, requestedID); err != nil {
+		if err := changeImage(request.Context(), requestedID, TemplateInput.UserInformation.ID, func(Tx interfaces.DBInterface) error {
+			return Tx.RemoveTag(request.Context(), requestedTagID, requestedID)
+		}); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to remove tag. Was it attached in the first place?<br>")
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
 			return
 		}
 		TemplateInput.HTMLMessage += template.HTML("Tag removed successfully.<br>")
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "REMOVE-IMAGETAG", TemplateInput.UserInformation.Name+" removed tag from image "+strconv.FormatUint(requestedID, 10)+". tag "+TagID)
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditImageTagRemove, interfaces.AuditTargetImage, requestedID, interfaces.AuditDetails{"TagID": requestedTagID})
 		redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateSucceeded")
 		return
 	case "AddTags":
@@ -363,7 +403,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			redirectWithFlash(responseWriter, request, "/images?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
 			return
 		}
-		imageInfo, err := database.DBInterface.GetImage(requestedID)
+		imageInfo, err := database.DBInterface.GetImage(request.Context(), requestedID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Error parsing image id.<br>")
 			logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter/AddTags", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to parse image id ", err.Error()})
@@ -373,7 +413,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		//Validate permission to modify tags
 		if TemplateInput.UserPermissions.HasPermission(interfaces.ModifyImageTags) != true && (config.Configuration.UsersControlOwnObjects != true || TemplateInput.UserInformation.ID != imageInfo.UploaderID) {
 			TemplateInput.HTMLMessage += template.HTML("User does not have modify permission for tags on images.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "ADD-IMAGETAG", TemplateInput.UserInformation.Name+" failed to add tag to image "+strconv.FormatUint(requestedID, 10)+". Insufficient permissions. "+userQuery)
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditImageTagAdd, interfaces.AuditTargetImage, requestedID, interfaces.AuditDetails{"Tags": userQuery, "Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
 			return
 		}
@@ -383,7 +423,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		//Get tags
 		var validatedUserTags []uint64 //Will contain tags the user is allowed to use
 		tagIDString := ""
-		userQTags, err := database.DBInterface.GetQueryTags(request.FormValue("NewTags"), false)
+		userQTags, err := database.DBInterface.GetQueryTags(request.Context(), request.FormValue("NewTags"), false)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to get tags from input.<br>")
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
@@ -403,12 +443,12 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 					TemplateInput.HTMLMessage += template.HTML("Unable to use tag " + template.HTMLEscapeString(tag.Name) + " due to insufficient permissions of user to create tags.<br>")
 					// /ValidatePermission
 				} else {
-					tagID, err := database.DBInterface.NewTag(tag.Name, tag.Description, TemplateInput.UserInformation.ID)
+					tagID, err := database.DBInterface.NewTag(request.Context(), tag.Name, tag.Description, TemplateInput.UserInformation.ID)
 					if err != nil {
 						logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter/AddTags", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"error attempting to create tag", err.Error(), tag.Name})
 						TemplateInput.HTMLMessage += template.HTML("Unable to use tag " + template.HTMLEscapeString(tag.Name) + " due to a database error.<br>")
 					} else {
-						go WriteAuditLog(TemplateInput.UserInformation.ID, "CREATE-TAG", TemplateInput.UserInformation.Name+" created a new tag. "+tag.Name)
+						go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditTagCreate, interfaces.AuditTargetTag, tagID, interfaces.AuditDetails{"Name": tag.Name})
 						validatedUserTags = append(validatedUserTags, tagID)
 						tagIDString = tagIDString + ", " + strconv.FormatUint(tagID, 10)
 					}
@@ -416,12 +456,15 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			}
 		}
 		///////////////////
-		if err := database.DBInterface.AddTag(validatedUserTags, requestedID, TemplateInput.UserInformation.ID); err != nil {
+		if err := changeImage(request.Context(), requestedID, TemplateInput.UserInformation.ID, func(Tx interfaces.DBInterface) error {
+			return Tx.AddTag(request.Context(), validatedUserTags, requestedID, TemplateInput.UserInformation.ID)
+		}); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to add tag due to database error.<br>")
 			logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter/AddTags", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"error attempting to add tags to file", err.Error(), strconv.FormatUint(requestedID, 10), tagIDString})
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
 			return
 		}
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditImageTagAdd, interfaces.AuditTargetImage, requestedID, interfaces.AuditDetails{"TagIDs": validatedUserTags})
 		TemplateInput.HTMLMessage += template.HTML("Tag(s) added.<br>")
 		redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateSucceeded")
 		return
@@ -440,7 +483,7 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			redirectWithFlash(responseWriter, request, "/images?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
 			return
 		}
-		imageInfo, err := database.DBInterface.GetImage(requestedID)
+		imageInfo, err := database.DBInterface.GetImage(request.Context(), requestedID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Error parsing image id.<br>")
 			logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter/ChangeRating", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to parse image id ", err.Error()})
@@ -450,14 +493,16 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		//Validate permission to modify tags
 		if TemplateInput.UserPermissions.HasPermission(interfaces.ModifyImageTags) != true && (config.Configuration.UsersControlOwnObjects != true || TemplateInput.UserInformation.ID != imageInfo.UploaderID) {
 			TemplateInput.HTMLMessage += template.HTML("User does not have modify permission for tags on images.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "ADD-IMAGERATING", TemplateInput.UserInformation.Name+" failed to edit rating for image "+strconv.FormatUint(requestedID, 10)+". Insufficient permissions. "+newRating)
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditImageRating, interfaces.AuditTargetImage, requestedID, interfaces.AuditDetails{"Rating": newRating, "Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
 			return
 		}
 
 		// /ValidatePermission
 		//Change Rating
-		if err = database.DBInterface.SetImageRating(requestedID, newRating); err != nil {
+		if err = changeImage(request.Context(), requestedID, TemplateInput.UserInformation.ID, func(Tx interfaces.DBInterface) error {
+			return Tx.SetImageRating(request.Context(), requestedID, newRating)
+		}); err != nil {
 			logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter/ChangeRating", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to change image rating ", err.Error()})
 			TemplateInput.HTMLMessage += template.HTML("Failed to change image rating, internal error ocurred.<br>")
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
@@ -480,10 +525,10 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 			return
 		}
 		//Cache image data
-		ImageInfo, err := database.DBInterface.GetImage(parsedImageID)
+		ImageInfo, err := database.DBInterface.GetImage(request.Context(), parsedImageID)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to delete image. SQL Error.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-IMAGE", TemplateInput.UserInformation.Name+" failed to delete image. "+request.FormValue("ID")+", "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditImageDelete, interfaces.AuditTargetImage, parsedImageID, interfaces.AuditDetails{"Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/images?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "DeleteFailed")
 			return
 		}
@@ -491,26 +536,59 @@ func ImagePostRouter(responseWriter http.ResponseWriter, request *http.Request)
 		//Validate Permission to delete
 		if TemplateInput.UserPermissions.HasPermission(interfaces.RemoveImage) != true && (config.Configuration.UsersControlOwnObjects != true || ImageInfo.UploaderID != TemplateInput.UserInformation.ID) {
 			TemplateInput.HTMLMessage += template.HTML("You do not have delete permission for this image.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-IMAGE", TemplateInput.UserInformation.Name+" failed to delete image. Insufficient permissions. "+request.FormValue("ID"))
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditImageDelete, interfaces.AuditTargetImage, parsedImageID, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(parsedImageID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "DeleteFailed")
 			return
 		}
 
-		//Permission validated, now delete (ImageTags and Images)
-		if err := database.DBInterface.DeleteImage(parsedImageID); err != nil {
+		//Permission validated, now move it to the recycle bin. Files stay until the image is purged
+		if err := database.DBInterface.TrashImage(request.Context(), parsedImageID, TemplateInput.UserInformation.ID); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to delete image. SQL Error.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-IMAGE", TemplateInput.UserInformation.Name+" failed to delete image. "+request.FormValue("ID")+", "+err.Error())
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditImageDelete, interfaces.AuditTargetImage, parsedImageID, interfaces.AuditDetails{"Error": err.Error()})
 			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(parsedImageID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "DeleteFailed")
 			return
 		}
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-IMAGE", TemplateInput.UserInformation.Name+" deleted image. "+request.FormValue("ID")+", "+ImageInfo.Name+", "+ImageInfo.Location)
-		//Third, delete Image from Disk
-		go os.Remove(path.Join(config.Configuration.ImageDirectory, ImageInfo.Location))
-		//Last delete thumbnail from disk
-		go os.Remove(path.Join(config.Configuration.ImageDirectory, "thumbs"+string(filepath.Separator)+ImageInfo.Location+".png"))
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditImageDelete, interfaces.AuditTargetImage, parsedImageID, interfaces.AuditDetails{"Name": ImageInfo.Name, "Location": ImageInfo.Location})
 		TemplateInput.HTMLMessage += template.HTML("Deletion success.<br>")
 		redirectWithFlash(responseWriter, request, "/images?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "DeleteSuccess")
 		return
+	case "RevertImage":
+		if !TemplateInput.IsLoggedOn() {
+			TemplateInput.HTMLMessage += template.HTML("You must be logged in to perform that action.<br>")
+			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "LogonRequired")
+			return
+		}
+
+		requestedID, err := strconv.ParseUint(request.FormValue("ID"), 10, 32)
+		if err != nil {
+			TemplateInput.HTMLMessage += template.HTML("Error parsing image id.<br>")
+			redirectWithFlash(responseWriter, request, "/images?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
+			return
+		}
+		//Reverting can touch every tracked field, so it needs the permissions for all of them
+		if TemplateInput.UserPermissions.HasPermission(interfaces.ModifyImageTags) != true || TemplateInput.UserPermissions.HasPermission(interfaces.SourceImage) != true {
+			TemplateInput.HTMLMessage += template.HTML("You do not have permission to revert images.<br>")
+			go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditImageRevert, interfaces.AuditTargetImage, requestedID, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
+			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
+			return
+		}
+		// /ValidatePermission
+		RevisionID, err := strconv.ParseUint(request.FormValue("RevisionID"), 10, 64)
+		if err != nil {
+			TemplateInput.HTMLMessage += template.HTML("Error parsing revision id.<br>")
+			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
+			return
+		}
+		if err := revertImage(request.Context(), requestedID, RevisionID, TemplateInput.UserInformation.ID); err != nil {
+			logging.WriteLog(logging.LogLevelError, "imagerouter/ImageRouter/RevertImage", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to revert image", strconv.FormatUint(requestedID, 10), strconv.FormatUint(RevisionID, 10), err.Error()})
+			TemplateInput.HTMLMessage += template.HTML("Failed to revert image, internal error ocurred.<br>")
+			redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateFailed")
+			return
+		}
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditImageRevert, interfaces.AuditTargetImage, requestedID, interfaces.AuditDetails{"RevisionID": RevisionID})
+		TemplateInput.HTMLMessage += template.HTML("Image reverted.<br>")
+		redirectWithFlash(responseWriter, request, "/image?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "UpdateSucceeded")
+		return
 	}
 	TemplateInput.HTMLMessage += template.HTML("Command not recognized or form submitted incorrectly.<br>")
 	redirectWithFlash(responseWriter, request, "/images?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "ImageFail")
@@ -2,6 +2,7 @@ package routers
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"errors"
 	"fmt"
@@ -9,10 +10,10 @@ import (
 	"go-image-board/database"
 	"go-image-board/interfaces"
 	"go-image-board/logging"
+	"go-image-board/storage"
 	"io"
+	"io/fs"
 	"net/http"
-	"os"
-	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -26,218 +27,62 @@ type uploadData struct {
 
 func handleImageUpload(request *http.Request, userName string) (uint64, map[string]uint64, error) {
 	//Translate UserID
-	userID, err := database.DBInterface.GetUserID(userName)
+	userID, err := database.DBInterface.GetUserID(request.Context(), userName)
 	if err != nil {
-		go WriteAuditLog(userID, "IMAGE-UPLOAD", userName+" failed to upload image. "+err.Error())
+		go WriteAuditLog(request.Context(), userID, interfaces.AuditImageUpload, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": err.Error()})
 		return 0, nil, errors.New("user not valid")
 	}
 
 	//Validate permission to upload
-	userPermission, err := database.DBInterface.GetUserPermissionSet(userName)
+	userPermission, err := database.DBInterface.GetUserPermissionSet(request.Context(), userName)
 	if err != nil {
-		go WriteAuditLog(userID, "IMAGE-UPLOAD", userName+" failed to upload image. "+err.Error())
+		go WriteAuditLog(request.Context(), userID, interfaces.AuditImageUpload, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": err.Error()})
 		return 0, nil, errors.New("Could not validate permission (SQL Error)")
 	}
 
 	//ParseCollection
 	collectionName := strings.TrimSpace(request.FormValue("CollectionName"))
 	//CacheCollectionInfo
-	collectionInfo, err := database.DBInterface.GetCollectionByName(collectionName)
+	collectionInfo, err := database.DBInterface.GetCollectionByName(request.Context(), collectionName)
 	if collectionName != "" && err != nil {
 		//Want to add to collection, but the collection does not exist
 		if interfaces.UserPermission(userPermission).HasPermission(interfaces.AddCollections) != true {
-			go WriteAuditLog(userID, "IMAGE-UPLOAD", userName+" failed to upload image. No permissions to create collection.")
+			go WriteAuditLog(request.Context(), userID, interfaces.AuditImageUpload, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"CollectionName": collectionName, "Error": "no permission to create collections"})
 			return 0, nil, errors.New("User does not have create permission for collections")
 		}
 	} else if collectionName != "" && err == nil {
 		//Want to add to a pre-existing collection
 		if interfaces.UserPermission(userPermission).HasPermission(interfaces.ModifyCollections) != true &&
 			(config.Configuration.UsersControlOwnObjects && collectionInfo.UploaderID != userID) {
-			go WriteAuditLog(userID, "IMAGE-UPLOAD", userName+" failed to upload image. No permissions to add members to collection.")
+			go WriteAuditLog(request.Context(), userID, interfaces.AuditImageUpload, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"CollectionID": collectionInfo.ID, "Error": "no permission to add members to the collection"})
 			return 0, nil, errors.New("User does not have permission to update requested collection")
 		}
 	}
 
 	if interfaces.UserPermission(userPermission).HasPermission(interfaces.UploadImage) != true {
-		go WriteAuditLog(userID, "IMAGE-UPLOAD", userName+" failed to upload image. No permissions.")
+		go WriteAuditLog(request.Context(), userID, interfaces.AuditImageUpload, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 		return 0, nil, errors.New("User does not have upload permission for images")
 	}
 	// /ValidatePermission
 
-	errorCompilation := ""
-	duplicateIDs := make(map[string]uint64)
-
-	//Cache tags first, improves speed to calculate this once than for each image
-	//Get tags
-	var validatedUserTags []uint64 //Will contain tags the user is allowed to use
-	tagIDString := ""
-	userQTags, err := database.DBInterface.GetQueryTags(request.FormValue("SearchTags"), false)
-	if err != nil {
-		errorCompilation += "Failed to get tags from input"
-	}
-	for _, tag := range userQTags {
-		if tag.Exists && tag.IsMeta == false {
-			//Assign pre-existing tag
-			//Validate permission to modify tags
-			if interfaces.UserPermission(userPermission).HasPermission(interfaces.ModifyImageTags) != true && (config.Configuration.UsersControlOwnObjects != true) {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"Does not have modify tag permission"})
-				errorCompilation += "Unable to use tag " + tag.Name + " due to insufficient permissions of user to tag images. "
-				// /ValidatePermission
-			} else {
-				validatedUserTags = append(validatedUserTags, tag.ID)
-				tagIDString = tagIDString + ", " + strconv.FormatUint(tag.ID, 10)
-			}
-		} else if tag.IsMeta == false {
-			//Create Tag
-			//Validate permissions to create tags
-			if interfaces.UserPermission(userPermission).HasPermission(interfaces.AddTags) != true {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"Does not have create tag permission"})
-				errorCompilation += "Unable to use tag " + tag.Name + " due to insufficient permissions of user to create tags. "
-				// /ValidatePermission
-			} else {
-				tagID, err := database.DBInterface.NewTag(tag.Name, tag.Description, userID)
-				if err != nil {
-					logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"error attempting to create tag", err.Error(), tag.Name})
-					errorCompilation += "Unable to use tag " + tag.Name + " due to a database error. "
-				} else {
-					go WriteAuditLog(userID, "CREATE-TAG", userName+" created a new tag. "+tag.Name)
-					validatedUserTags = append(validatedUserTags, tagID)
-					tagIDString = tagIDString + ", " + strconv.FormatUint(tagID, 10)
-				}
-			}
-		}
-	}
-
-	var lastID uint64
-	var uploadedIDs []uploadData
 	request.ParseMultipartForm(config.Configuration.MaxUploadBytes)
-	fileHeaders := request.MultipartForm.File["fileToUpload"]
-	source := request.FormValue("Source")
-	for _, fileHeader := range fileHeaders {
-		switch ext := strings.ToLower(filepath.Ext(fileHeader.Filename)); ext {
-		case ".jpg", ".jpeg", ".jfif", ".bmp", ".gif", ".png", ".svg", ".mpg", ".mov", ".webm", ".avi", ".mp4", ".mp3", ".ogg", ".wav", ".webp", ".tiff", ".tif":
-			//Passes filter
-		default:
-			logging.WriteLog(logging.LogLevelVerbose, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"Attempted to upload a file which did not pass filter", ext})
-			errorCompilation += fileHeader.Filename + " is not a recognized file. "
-			continue
-		}
-		fileStream, err := fileHeader.Open()
-		if err != nil {
-			logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"Upload image, could not open stream to save", err.Error()})
-			errorCompilation += fileHeader.Filename + " could not be opened. "
-		} else {
-			originalName := fileHeader.Filename
-			//Hash Image
-			hashName, err := GetNewImageName(originalName, fileStream)
-			if err != nil {
-				errorCompilation += err.Error()
-				fileStream.Close()
-				continue
-			}
-
-			filePath := path.Join(config.Configuration.ImageDirectory, hashName)
-			//Check if file exists, if so, skip
-			if _, err := os.Stat(filePath); err == nil {
-				var duplicateID uint64
-				dupInfo, ierr := database.DBInterface.GetImageByFileName(hashName)
-				if ierr == nil {
-					duplicateID = dupInfo.ID
-				}
-				logging.WriteLog(logging.LogLevelInfo, "imagerouter/handleImageUpload", userName, logging.ResultInfo, []string{"Skipping as file is already uploaded", fileHeader.Filename, filePath, strconv.FormatUint(duplicateID, 10)})
-				if ierr == nil {
-					//errorCompilation += fileHeader.Filename + " has already been uploaded as ID " + strconv.FormatUint(duplicateID, 10) + ". "
-					duplicateIDs[fileHeader.Filename] = duplicateID
-				} else {
-					errorCompilation += fileHeader.Filename + " has already been uploaded. "
-				}
-				fileStream.Close()
-				continue
-			}
-
-			saveStream, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE, 0660)
-			if err != nil {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"Upload image, failed to open new file", err.Error()})
-				errorCompilation += fileHeader.Filename + " could not be saved, internal error. "
-				saveStream.Close()
-				fileStream.Close()
-				continue
-			}
-			//Save Image
-			_, err = fileStream.Seek(0, 0)
-			if err != nil {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"Upload image, failed to seek stream", err.Error()})
-				errorCompilation += fileHeader.Filename + " could not be saved, internal error. "
-				saveStream.Close()
-				fileStream.Close()
-				continue
-			}
-			io.Copy(saveStream, fileStream)
-			saveStream.Close()
-			//Add image to Database
-
-			lastID, err = database.DBInterface.NewImage(hashName, hashName, userID, source)
-			if err != nil {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"error attempting to add file to database", err.Error(), filePath})
-				errorCompilation += fileHeader.Filename + " could not be added to database, internal error. "
-				//Attempt to cleanup file
-				if err := os.Remove(filePath); err != nil {
-					logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"error attempting to remove orphaned file", err.Error(), filePath})
-				}
-				continue
-			}
-
-			uploadedIDs = append(uploadedIDs, uploadData{Name: originalName, ID: lastID})
-
-			//Add tags
-			if err := database.DBInterface.AddTag(validatedUserTags, lastID, userID); err != nil {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"failed to add tags", err.Error(), strconv.FormatUint(lastID, 10)})
-				errorCompilation += "Failed to add tags to " + fileHeader.Filename + ". "
-			} else {
-
-				go WriteAuditLog(userID, "IMAGE-UPLOAD", userName+" tagged image "+strconv.FormatUint(lastID, 10)+" with "+tagIDString)
-			}
-
-			//Log success
-			go WriteAuditLog(userID, "IMAGE-UPLOAD", userName+" successfully uploaded an image. "+strconv.FormatUint(lastID, 10))
-			//Start go routine to generate thumbnail
-			go GenerateThumbnail(hashName)
-			go GeneratedHash(hashName, lastID)
-		}
-		fileStream.Close()
+	if request.MultipartForm == nil {
+		return 0, nil, errors.New("No files were uploaded")
 	}
-	//Now handle collection if requested
-
-	if collectionName != "" {
-		if collectionInfo.ID == 0 {
-			collectionInfo.ID, err = database.DBInterface.NewCollection(collectionName, "", userID)
-			if err != nil {
-				errorCompilation += "Failed to create the collection requested, SQL error. "
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"error attempting to create collection", err.Error()})
-			}
-		}
-		//If we had an error creating collection, this would still be 0, otherwise would have value or if collection already existed, would still have value other than 0
-		if collectionInfo.ID != 0 {
-			//Sort uploads by name
-			sort.Slice(uploadedIDs, func(i, j int) bool {
-				return uploadedIDs[i].Name < uploadedIDs[j].Name
-			})
-			var ids []uint64
-			for _, v := range uploadedIDs {
-				ids = append(ids, v.ID)
-			}
-			err = database.DBInterface.AddCollectionMember(collectionInfo.ID, ids, userID)
-			if err != nil {
-				errorCompilation += "Failed to add images to collection. "
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userName, logging.ResultFailure, []string{"error adding image to collection", err.Error()})
-			}
-		}
+	var files []uploadFile
+	for _, fileHeader := range request.MultipartForm.File["fileToUpload"] {
+		files = append(files, uploadFile{Name: fileHeader.Filename, Open: func() (io.ReadSeekCloser, error) { return fileHeader.Open() }})
 	}
 
-	if errorCompilation != "" {
-		return lastID, duplicateIDs, errors.New(errorCompilation)
-	}
-	return lastID, duplicateIDs, nil
+	return storeUpload(request.Context(), pendingUpload{
+		User:           interfaces.UserInformation{Name: userName, ID: userID},
+		Permissions:    interfaces.UserPermission(userPermission),
+		CollectionName: collectionName,
+		CollectionID:   collectionInfo.ID,
+		Tags:           request.FormValue("SearchTags"),
+		Source:         request.FormValue("Source"),
+		Files:          files,
+	})
 }
 
 //UploadingFile contains information on the Name and Data of a file to be uploaded
@@ -251,208 +96,297 @@ func HandleImageUploadRequest(request *http.Request, userInformation interfaces.
 	var err error
 	//Validate permission to upload
 	//Get the user's permissions
-	userPermission, err := database.DBInterface.GetUserPermissionSet(userInformation.Name)
+	userPermission, err := database.DBInterface.GetUserPermissionSet(request.Context(), userInformation.Name)
 	if err != nil {
-		go WriteAuditLog(userInformation.ID, "IMAGE-UPLOAD", userInformation.Name+" failed to upload image. "+err.Error())
+		go WriteAuditLog(request.Context(), userInformation.ID, interfaces.AuditImageUpload, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": err.Error()})
 		return 0, nil, errors.New("Could not validate permission (SQL Error)")
 	}
 
 	//Verify user can upload an image
 	if interfaces.UserPermission(userPermission).HasPermission(interfaces.UploadImage) != true {
-		go WriteAuditLog(userInformation.ID, "IMAGE-UPLOAD", userInformation.Name+" failed to upload image. No permissions.")
+		go WriteAuditLog(request.Context(), userInformation.ID, interfaces.AuditImageUpload, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 		return 0, nil, errors.New("User does not have upload permission for images")
 	}
 
 	//CacheCollectionInfo if needed and verify permissions to create or update the collection
 	var collectionInfo interfaces.CollectionInformation
 	if collectionName != "" {
-		collectionInfo, err = database.DBInterface.GetCollectionByName(collectionName)
+		collectionInfo, err = database.DBInterface.GetCollectionByName(request.Context(), collectionName)
 		if err != nil {
 			//Want to add to collection, but the collection does not exist, so validate permissions to create collections
 			if interfaces.UserPermission(userPermission).HasPermission(interfaces.AddCollections) != true {
-				go WriteAuditLog(userInformation.ID, "IMAGE-UPLOAD", userInformation.Name+" failed to upload image. No permissions to create collection.")
+				go WriteAuditLog(request.Context(), userInformation.ID, interfaces.AuditImageUpload, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"CollectionName": collectionName, "Error": "no permission to create collections"})
 				return 0, nil, errors.New("User does not have create permission for collections")
 			}
 		} else {
 			//Want to add to a pre-existing collection, validate permissions on the pre-existing collection
 			if interfaces.UserPermission(userPermission).HasPermission(interfaces.ModifyCollections) != true &&
 				(config.Configuration.UsersControlOwnObjects && collectionInfo.UploaderID != userInformation.ID) {
-				go WriteAuditLog(userInformation.ID, "IMAGE-UPLOAD", userInformation.Name+" failed to upload image. No permissions to add members to collection.")
+				go WriteAuditLog(request.Context(), userInformation.ID, interfaces.AuditImageUpload, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"CollectionID": collectionInfo.ID, "Error": "no permission to add members to the collection"})
 				return 0, nil, errors.New("User does not have permission to update requested collection")
 			}
 		}
 	}
 	// /ValidatePermission
 
-	errorCompilation := ""                  //To store non-critical errors such as file already uploaded
-	duplicateIDs := make(map[string]uint64) //Stores id's for files that already exist
+	var uploadFiles []uploadFile
+	for _, file := range files {
+		data := file.Data
+		uploadFiles = append(uploadFiles, uploadFile{Name: file.Name, Open: func() (io.ReadSeekCloser, error) { return nopSeekCloser{bytes.NewReader(data)}, nil }})
+	}
+
+	return storeUpload(request.Context(), pendingUpload{
+		User:           userInformation,
+		Permissions:    interfaces.UserPermission(userPermission),
+		CollectionName: collectionName,
+		CollectionID:   collectionInfo.ID,
+		Tags:           imageTags,
+		Source:         source,
+		Files:          uploadFiles,
+	})
+}
+
+//uploadFile is one file of an upload, Open is called once when the file is stored
+type uploadFile struct {
+	Name string
+	Open func() (io.ReadSeekCloser, error)
+}
+
+//nopSeekCloser lets in memory API uploads be opened the same way as multipart files
+type nopSeekCloser struct {
+	*bytes.Reader
+}
+
+//Close does nothing, there is nothing to release
+func (nopSeekCloser) Close() error {
+	return nil
+}
+
+//pendingUpload is an upload whose permissions have been validated, and that is ready to be stored
+type pendingUpload struct {
+	User           interfaces.UserInformation
+	Permissions    interfaces.UserPermission
+	CollectionName string
+	//CollectionID is 0 when CollectionName does not exist yet and has to be created
+	CollectionID uint64
+	Tags         string
+	Source       string
+	Files        []uploadFile
+}
+
+//uploadState tracks what storeUpload did, so it can be undone or followed up once the transaction finishes
+type uploadState struct {
+	lastID       uint64
+	uploadedIDs  []uploadData
+	duplicateIDs map[string]uint64
+	//warnings are files or tags that were skipped, they do not stop the rest of the upload
+	warnings string
+	//writtenFiles are the blobs removed again if the transaction fails
+	writtenFiles []string
+	//afterCommit runs once the transaction is committed, for audit logs and thumbnail generation
+	afterCommit []func()
+}
 
+//storeUpload saves the files of an upload, then adds the images, their tags and their collection in one transaction.
+//Unrecognized and already uploaded files are skipped with a warning, any other failure rolls back the database and removes the files written so far
+func storeUpload(ctx context.Context, Upload pendingUpload) (uint64, map[string]uint64, error) {
+	state := &uploadState{duplicateIDs: make(map[string]uint64)}
+	err := database.DBInterface.RunInTransaction(ctx, func(Tx interfaces.DBInterface) error {
+		return state.store(ctx, Tx, Upload)
+	})
+	if err != nil {
+		for _, fileName := range state.writtenFiles {
+			if err := storage.BlobStore.Delete(context.WithoutCancel(ctx), fileName); err != nil {
+				logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"error attempting to remove file of a failed upload", err.Error(), fileName})
+			}
+		}
+		go WriteAuditLog(ctx, Upload.User.ID, interfaces.AuditImageUpload, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": err.Error()})
+		return 0, nil, errors.New(state.warnings + "Upload failed and nothing was saved. " + err.Error())
+	}
+	for _, followUp := range state.afterCommit {
+		followUp()
+	}
+	if state.warnings != "" {
+		return state.lastID, state.duplicateIDs, errors.New(state.warnings)
+	}
+	return state.lastID, state.duplicateIDs, nil
+}
+
+//store does the work of storeUpload inside of the transaction Tx
+func (state *uploadState) store(ctx context.Context, Tx interfaces.DBInterface, Upload pendingUpload) error {
 	//Cache tags first, improves speed to calculate this once than for each image
 	//Get tags
 	var validatedUserTags []uint64 //Will contain tags the user is allowed to use
-	tagIDString := ""
-	userQTags, err := database.DBInterface.GetQueryTags(imageTags, false)
+	userQTags, err := Tx.GetQueryTags(ctx, Upload.Tags, false)
 	if err != nil {
-		errorCompilation += "Failed to get tags from input. "
+		logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"Failed to get tags from input", err.Error()})
+		return errors.New("Failed to get tags from input. ")
 	}
 	for _, tag := range userQTags {
 		if tag.Exists && tag.IsMeta == false {
 			//Assign pre-existing tag
 			//Validate permission to modify tags
-			if interfaces.UserPermission(userPermission).HasPermission(interfaces.ModifyImageTags) != true && (config.Configuration.UsersControlOwnObjects != true) {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"Does not have modify tag permission"})
-				errorCompilation += "Unable to use tag " + tag.Name + " due to insufficient permissions of user to tag images. "
+			if Upload.Permissions.HasPermission(interfaces.ModifyImageTags) != true && (config.Configuration.UsersControlOwnObjects != true) {
+				logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"Does not have modify tag permission"})
+				state.warnings += "Unable to use tag " + tag.Name + " due to insufficient permissions of user to tag images. "
 				// /ValidatePermission
 			} else {
 				validatedUserTags = append(validatedUserTags, tag.ID)
-				tagIDString = tagIDString + ", " + strconv.FormatUint(tag.ID, 10)
 			}
 		} else if tag.IsMeta == false {
 			//Create Tag
 			//Validate permissions to create tags
-			if interfaces.UserPermission(userPermission).HasPermission(interfaces.AddTags) != true {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"Does not have create tag permission"})
-				errorCompilation += "Unable to use tag " + tag.Name + " due to insufficient permissions of user to create tags. "
+			if Upload.Permissions.HasPermission(interfaces.AddTags) != true {
+				logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"Does not have create tag permission"})
+				state.warnings += "Unable to use tag " + tag.Name + " due to insufficient permissions of user to create tags. "
 				// /ValidatePermission
 			} else {
-				tagID, err := database.DBInterface.NewTag(tag.Name, tag.Description, userInformation.ID)
+				tagID, err := Tx.NewTag(ctx, tag.Name, tag.Description, Upload.User.ID)
 				if err != nil {
-					logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"error attempting to create tag", err.Error(), tag.Name})
-					errorCompilation += "Unable to use tag " + tag.Name + " due to a database error. "
-				} else {
-					go WriteAuditLog(userInformation.ID, "CREATE-TAG", userInformation.Name+" created a new tag. "+tag.Name)
-					validatedUserTags = append(validatedUserTags, tagID)
-					tagIDString = tagIDString + ", " + strconv.FormatUint(tagID, 10)
+					logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"error attempting to create tag", err.Error(), tag.Name})
+					return errors.New("Unable to create tag " + tag.Name + " due to a database error. ")
 				}
+				tagName := tag.Name
+				state.afterCommit = append(state.afterCommit, func() {
+					go WriteAuditLog(ctx, Upload.User.ID, interfaces.AuditTagCreate, interfaces.AuditTargetTag, tagID, interfaces.AuditDetails{"Name": tagName})
+				})
+				validatedUserTags = append(validatedUserTags, tagID)
 			}
 		}
 	}
 
-	var lastID uint64
-	var uploadedIDs []uploadData
-	for _, toUpload := range files {
-		switch ext := strings.ToLower(filepath.Ext(toUpload.Name)); ext {
+	for _, file := range Upload.Files {
+		switch ext := strings.ToLower(filepath.Ext(file.Name)); ext {
 		case ".jpg", ".jpeg", ".jfif", ".bmp", ".gif", ".png", ".svg", ".mpg", ".mov", ".webm", ".avi", ".mp4", ".mp3", ".ogg", ".wav", ".webp", ".tiff", ".tif":
 			//Passes filter
 		default:
-			logging.WriteLog(logging.LogLevelVerbose, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"Attempted to upload a file which did not pass filter", ext})
-			errorCompilation += toUpload.Name + " is not a recognized file. "
+			logging.WriteLog(logging.LogLevelVerbose, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"Attempted to upload a file which did not pass filter", ext})
+			state.warnings += file.Name + " is not a recognized file. "
 			continue
 		}
-		fileStream := bytes.NewReader(toUpload.Data)
+		hashName, err := state.saveFile(ctx, Tx, Upload, file)
 		if err != nil {
-			logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"Upload image, could not open stream to save", err.Error()})
-			errorCompilation += toUpload.Name + " could not be opened. "
-		} else {
-			originalName := toUpload.Name
-			//Hash Image
-			hashName, err := GetNewImageName(originalName, fileStream)
-			if err != nil {
-				errorCompilation += err.Error()
-				continue
-			}
+			return err
+		}
+		if hashName == "" {
+			//Already uploaded
+			continue
+		}
 
-			filePath := path.Join(config.Configuration.ImageDirectory, hashName)
-			//Check if file exists, if so, skip
-			if _, err := os.Stat(filePath); err == nil {
-				var duplicateID uint64
-				dupInfo, ierr := database.DBInterface.GetImageByFileName(hashName)
-				if ierr == nil {
-					duplicateID = dupInfo.ID
-				}
-				logging.WriteLog(logging.LogLevelInfo, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultInfo, []string{"Skipping as file is already uploaded", toUpload.Name, filePath, strconv.FormatUint(duplicateID, 10)})
-				if ierr == nil {
-					//errorCompilation += fileHeader.Filename + " has already been uploaded as ID " + strconv.FormatUint(duplicateID, 10) + ". "
-					duplicateIDs[toUpload.Name] = duplicateID
-				} else {
-					errorCompilation += toUpload.Name + " has already been uploaded. "
-				}
-				continue
+		//Add image to Database
+		imageID, err := Tx.NewImage(ctx, hashName, hashName, Upload.User.ID, Upload.Source)
+		if err != nil {
+			logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"error attempting to add file to database", err.Error(), hashName})
+			return errors.New(file.Name + " could not be added to database, internal error. ")
+		}
+		state.lastID = imageID
+		state.uploadedIDs = append(state.uploadedIDs, uploadData{Name: file.Name, ID: imageID})
+
+		//Add tags
+		if len(validatedUserTags) > 0 {
+			if err := Tx.AddTag(ctx, validatedUserTags, imageID, Upload.User.ID); err != nil {
+				logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"failed to add tags", err.Error(), strconv.FormatUint(imageID, 10)})
+				return errors.New("Failed to add tags to " + file.Name + ". ")
 			}
+		}
 
-			saveStream, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE, 0660)
-			if err != nil {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"Upload image, failed to open new file", err.Error()})
-				errorCompilation += toUpload.Name + " could not be saved, internal error. "
-				saveStream.Close()
-				continue
-			}
-			//Save Image
-			_, err = fileStream.Seek(0, 0)
-			if err != nil {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"Upload image, failed to seek stream", err.Error()})
-				errorCompilation += toUpload.Name + " could not be saved, internal error. "
-				saveStream.Close()
-				continue
-			}
-			io.Copy(saveStream, fileStream)
-			saveStream.Close()
-			//Add image to Database
-
-			lastID, err = database.DBInterface.NewImage(hashName, hashName, userInformation.ID, source)
-			if err != nil {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"error attempting to add file to database", err.Error(), filePath})
-				errorCompilation += toUpload.Name + " could not be added to database, internal error. "
-				//Attempt to cleanup file
-				if err := os.Remove(filePath); err != nil {
-					logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"error attempting to remove orphaned file", err.Error(), filePath})
-				}
-				continue
-			}
+		//Queue reading the metadata and making the thumbnail and dHash with the image, so they are done even if the server restarts first
+		if err := queueImageJobs(ctx, Tx, imageID, hashName); err != nil {
+			logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"failed to queue jobs", err.Error(), strconv.FormatUint(imageID, 10)})
+			return errors.New("Failed to add " + file.Name + ". ")
+		}
 
-			uploadedIDs = append(uploadedIDs, uploadData{Name: originalName, ID: lastID})
+		fileName := file.Name
+		state.afterCommit = append(state.afterCommit, func() {
+			//Log success
+			go WriteAuditLog(ctx, Upload.User.ID, interfaces.AuditImageUpload, interfaces.AuditTargetImage, imageID, interfaces.AuditDetails{"Name": fileName, "Location": hashName, "TagIDs": validatedUserTags})
+			WakeJobWorkers()
+		})
+	}
 
-			//Add tags
-			if err := database.DBInterface.AddTag(validatedUserTags, lastID, userInformation.ID); err != nil {
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"failed to add tags", err.Error(), strconv.FormatUint(lastID, 10)})
-				errorCompilation += "Failed to add tags to " + toUpload.Name + ". "
-			} else {
+	//Now handle collection if requested, there is nothing to add if every file was skipped
+	if Upload.CollectionName == "" || len(state.uploadedIDs) == 0 {
+		return nil
+	}
+	collectionID := Upload.CollectionID
+	if collectionID == 0 {
+		collectionID, err = Tx.NewCollection(ctx, Upload.CollectionName, "", Upload.User.ID)
+		if err != nil {
+			logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"error attempting to create collection", err.Error()})
+			return errors.New("Failed to create the collection requested, SQL error. ")
+		}
+		state.afterCommit = append(state.afterCommit, func() {
+			go WriteAuditLog(ctx, Upload.User.ID, interfaces.AuditCollectionCreate, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"Name": Upload.CollectionName})
+		})
+	}
+	//Sort uploads by name
+	sort.Slice(state.uploadedIDs, func(i, j int) bool {
+		return state.uploadedIDs[i].Name < state.uploadedIDs[j].Name
+	})
+	var ids []uint64
+	for _, v := range state.uploadedIDs {
+		ids = append(ids, v.ID)
+	}
+	if err := Tx.AddCollectionMember(ctx, collectionID, ids, Upload.User.ID); err != nil {
+		logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"error adding image to collection", err.Error()})
+		return errors.New("Failed to add images to collection. ")
+	}
+	state.afterCommit = append(state.afterCommit, func() {
+		go WriteAuditLog(ctx, Upload.User.ID, interfaces.AuditCollectionMemberAdd, interfaces.AuditTargetCollection, collectionID, interfaces.AuditDetails{"ImageIDs": ids})
+	})
+	return nil
+}
 
-				go WriteAuditLog(userInformation.ID, "IMAGE-UPLOAD", userInformation.Name+" tagged image "+strconv.FormatUint(lastID, 10)+" with "+tagIDString)
-			}
+//saveFile writes one file to the blob store under its hashed name, and returns that name.
+//An empty name and nil error means the file was already uploaded and has been skipped
+func (state *uploadState) saveFile(ctx context.Context, Tx interfaces.DBInterface, Upload pendingUpload, file uploadFile) (string, error) {
+	fileStream, err := file.Open()
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"Upload image, could not open stream to save", err.Error()})
+		return "", errors.New(file.Name + " could not be opened. ")
+	}
+	defer fileStream.Close()
 
-			//Log success
-			go WriteAuditLog(userInformation.ID, "IMAGE-UPLOAD", userInformation.Name+" successfully uploaded an image. "+strconv.FormatUint(lastID, 10))
-			//Start go routine to generate thumbnail
-			go GenerateThumbnail(hashName)
-			go GeneratedHash(hashName, lastID)
-		}
+	//Hash Image
+	hashName, err := GetNewImageName(file.Name, fileStream)
+	if err != nil {
+		return "", err
 	}
-	//Now handle collection if requested
 
-	if collectionName != "" {
-		if collectionInfo.ID == 0 {
-			collectionInfo.ID, err = database.DBInterface.NewCollection(collectionName, "", userInformation.ID)
-			if err != nil {
-				errorCompilation += "Failed to create the collection requested, SQL error. "
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"error attempting to create collection", err.Error()})
-			}
+	//Save Image, Create refuses to replace a stored file so that only files this upload created are removed on rollback
+	fileSize, err := fileStream.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = fileStream.Seek(0, io.SeekStart)
+	}
+	if err == nil {
+		err = storage.BlobStore.Create(ctx, hashName, fileStream, fileSize)
+	}
+	if errors.Is(err, fs.ErrExist) {
+		//Skip files that are already uploaded, including duplicates within this upload
+		var duplicateID uint64
+		dupInfo, ierr := Tx.GetImageByFileName(ctx, hashName)
+		if ierr == nil {
+			duplicateID = dupInfo.ID
 		}
-		//If we had an error creating collection, this would still be 0, otherwise would have value or if collection already existed, would still have value other than 0
-		if collectionInfo.ID != 0 {
-			//Sort uploads by name
-			sort.Slice(uploadedIDs, func(i, j int) bool {
-				return uploadedIDs[i].Name < uploadedIDs[j].Name
-			})
-			var ids []uint64
-			for _, v := range uploadedIDs {
-				ids = append(ids, v.ID)
-			}
-			err = database.DBInterface.AddCollectionMember(collectionInfo.ID, ids, userInformation.ID)
-			if err != nil {
-				errorCompilation += "Failed to add images to collection. "
-				logging.WriteLog(logging.LogLevelError, "imagerouter/handleImageUpload", userInformation.Name, logging.ResultFailure, []string{"error adding image to collection", err.Error()})
-			}
+		logging.WriteLog(logging.LogLevelInfo, "imagerouter/storeUpload", Upload.User.Name, logging.ResultInfo, []string{"Skipping as file is already uploaded", file.Name, hashName, strconv.FormatUint(duplicateID, 10)})
+		if ierr == nil && dupInfo.DeletedTime.IsZero() == false {
+			//The duplicate cannot be linked to, as only mods can see the recycle bin
+			state.warnings += file.Name + " has already been uploaded and is in the recycle bin, ask a moderator to restore it. "
+		} else if ierr == nil {
+			state.duplicateIDs[file.Name] = duplicateID
+		} else {
+			state.warnings += file.Name + " has already been uploaded. "
 		}
+		return "", nil
+	} else if err != nil {
+		logging.WriteLog(logging.LogLevelError, "imagerouter/storeUpload", Upload.User.Name, logging.ResultFailure, []string{"Upload image, failed to save file", err.Error(), hashName})
+		return "", errors.New(file.Name + " could not be saved, internal error. ")
 	}
-
-	if errorCompilation != "" {
-		return lastID, duplicateIDs, errors.New(errorCompilation)
-	}
-	return lastID, duplicateIDs, nil
+	state.writtenFiles = append(state.writtenFiles, hashName)
+	return hashName, nil
 }
 
-//GetNewImageName uses the original filename and file contents to create a new name
+//GetNewImageName uses the original filename and file contents to create a new name, in the subdirectories given by ShardedImageName
 func GetNewImageName(originalName string, fileStream io.Reader) (string, error) {
 	hasher := sha256.New()
 	if _, err := io.Copy(hasher, fileStream); err != nil {
@@ -460,7 +394,13 @@ func GetNewImageName(originalName string, fileStream io.Reader) (string, error)
 		return "", errors.New(originalName + " could not be hashed. Internal error.")
 	}
 
-	return (fmt.Sprintf("%x", hasher.Sum(nil)) + filepath.Ext(originalName)), nil
+	return ShardedImageName(fmt.Sprintf("%x", hasher.Sum(nil)) + filepath.Ext(originalName)), nil
+}
+
+//ShardedImageName returns where a file named after its hash is stored, under subdirectories named after the first two pairs of characters of the hash, such as ab/cd/abcd….png.
+//This keeps directories small enough to list quickly however many images are uploaded
+func ShardedImageName(HashName string) string {
+	return HashName[0:2] + "/" + HashName[2:4] + "/" + HashName
 }
 
 //UploadFormRouter shows the upload form upon request
@@ -0,0 +1,182 @@
+package routers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-image-board/config"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//jobPollInterval how often idle workers look for jobs that have become due
+var jobPollInterval = 5 * time.Second
+
+//jobRetryDelay how long a failed job waits before its first retry, the wait doubles with each attempt up to jobMaxRetryDelay
+var jobRetryDelay = 30 * time.Second
+
+//jobMaxRetryDelay the longest a failed job waits before it is retried
+var jobMaxRetryDelay = time.Hour
+
+//jobLease how long a job may run before it is taken as interrupted and queued again. Jobs are expected to finish well within it
+var jobLease = 30 * time.Minute
+
+//jobWake wakes an idle worker when a job is queued, so new uploads do not wait for jobPollInterval
+var jobWake = make(chan struct{}, 1)
+
+//QueueJob queues a job to run now. DB may be a transaction, call WakeJobWorkers once it is committed
+func QueueJob(ctx context.Context, DB interfaces.DBInterface, Type interfaces.JobType, ImageID uint64) error {
+	return DB.EnqueueJob(ctx, Type, ImageID, time.Now())
+}
+
+//queueImageJobs queues reading the metadata of an image, and generating its thumbnail and dHash for file types that have them
+func queueImageJobs(ctx context.Context, DB interfaces.DBInterface, ImageID uint64, Location string) error {
+	if err := QueueJob(ctx, DB, interfaces.JobMetadata, ImageID); err != nil {
+		return err
+	}
+	if CanGenerateThumbnail(Location) {
+		if err := QueueJob(ctx, DB, interfaces.JobThumbnail, ImageID); err != nil {
+			return err
+		}
+	}
+	if CanGeneratedHash(Location) {
+		if err := QueueJob(ctx, DB, interfaces.JobdHash, ImageID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//WakeJobWorkers tells an idle worker that a job has been queued
+func WakeJobWorkers() {
+	select {
+	case jobWake <- struct{}{}:
+	default:
+	}
+}
+
+//jobRetryAt returns when a job whose attempt number Attempts failed should be retried, or zero once it has used up JobMaxAttempts
+func jobRetryAt(Attempts uint64) time.Time {
+	if Attempts >= config.Configuration.JobMaxAttempts {
+		return time.Time{}
+	}
+	Delay := jobRetryDelay
+	for Attempt := uint64(1); Attempt < Attempts && Delay < jobMaxRetryDelay; Attempt++ {
+		Delay *= 2
+	}
+	return time.Now().Add(min(Delay, jobMaxRetryDelay))
+}
+
+//runJob does the work of one job. Jobs for images that have since been purged succeed without doing anything
+func runJob(ctx context.Context, Job interfaces.Job) error {
+	Image, err := database.DBInterface.GetImage(ctx, Job.ImageID)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	switch Job.Type {
+	case interfaces.JobThumbnail:
+		return GenerateThumbnail(Image.Location)
+	case interfaces.JobdHash:
+		return GeneratedHash(Image.Location, Image.ID)
+	case interfaces.JobMetadata:
+		Metadata, err := ReadImageMetadata(ctx, Image.Location)
+		if err != nil {
+			return err
+		}
+		return database.DBInterface.SetImageMetadata(ctx, Image.ID, Metadata)
+	}
+	return errors.New("unknown job type " + string(Job.Type))
+}
+
+//workJob claims and runs one due job, returns false if no job was due
+func workJob(ctx context.Context) (bool, error) {
+	Job, err := database.DBInterface.ClaimJob(ctx, time.Now())
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if err := runJob(ctx, Job); err != nil {
+		RetryAt := jobRetryAt(Job.Attempts)
+		if RetryAt.IsZero() {
+			logging.WriteLog(logging.LogLevelError, "jobqueue/workJob", "0", logging.ResultFailure, []string{"Job failed, giving up", string(Job.Type), strconv.FormatUint(Job.ImageID, 10), strconv.FormatUint(Job.Attempts, 10), err.Error()})
+		} else {
+			logging.WriteLog(logging.LogLevelWarning, "jobqueue/workJob", "0", logging.ResultFailure, []string{"Job failed, will retry", string(Job.Type), strconv.FormatUint(Job.ImageID, 10), strconv.FormatUint(Job.Attempts, 10), err.Error()})
+		}
+		return true, database.DBInterface.FailJob(ctx, Job.ID, err.Error(), RetryAt)
+	}
+	return true, database.DBInterface.FinishJob(ctx, Job.ID)
+}
+
+//jobWorker runs jobs one at a time. Whenever no job is due it returns if Done does, otherwise it waits for jobPollInterval or a queued job
+func jobWorker(ctx context.Context, Done func() bool) {
+	for {
+		Worked, err := workJob(ctx)
+		if err != nil {
+			logging.WriteLog(logging.LogLevelError, "jobqueue/jobWorker", "0", logging.ResultFailure, []string{"Failed to work job queue", err.Error()})
+		} else if Worked {
+			continue
+		}
+		if Done() {
+			return
+		}
+		select {
+		case <-jobWake:
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+//requeueInterruptedJobs queues jobs again that have been running for longer than jobLease.
+//Another server sharing the database may still be running newer jobs, so those are left alone
+func requeueInterruptedJobs(ctx context.Context) {
+	if Requeued, err := database.DBInterface.RequeueRunningJobs(ctx, time.Now(), jobLease); err != nil {
+		logging.WriteLog(logging.LogLevelError, "jobqueue/requeueInterruptedJobs", "0", logging.ResultFailure, []string{"Failed to requeue interrupted jobs", err.Error()})
+	} else if Requeued > 0 {
+		logging.WriteLog(logging.LogLevelInfo, "jobqueue/requeueInterruptedJobs", "0", logging.ResultInfo, []string{"Requeued", strconv.FormatUint(Requeued, 10), "interrupted jobs"})
+	}
+}
+
+//StartJobWorkers starts JobWorkers workers that run queued jobs for as long as the server runs.
+//Jobs that were interrupted, here or on another server, are queued again once their lease runs out
+func StartJobWorkers() {
+	go func() {
+		for {
+			requeueInterruptedJobs(context.Background())
+			time.Sleep(jobLease / 2)
+		}
+	}()
+	for Worker := uint64(0); Worker < config.Configuration.JobWorkers; Worker++ {
+		go jobWorker(context.Background(), func() bool { return false })
+	}
+}
+
+//RunQueuedJobs runs queued jobs with JobWorkers workers until none are pending or running, for the command line modes. Returns how many jobs have failed
+func RunQueuedJobs(ctx context.Context) (uint64, error) {
+	Done := func() bool {
+		requeueInterruptedJobs(ctx)
+		Counts, err := database.DBInterface.GetJobCounts(ctx)
+		if err != nil {
+			logging.WriteLog(logging.LogLevelError, "jobqueue/RunQueuedJobs", "0", logging.ResultFailure, []string{"Failed to count jobs", err.Error()})
+			return true
+		}
+		return Counts[interfaces.JobPending]+Counts[interfaces.JobRunning] == 0
+	}
+	var wg sync.WaitGroup
+	for Worker := uint64(0); Worker < config.Configuration.JobWorkers; Worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jobWorker(ctx, Done)
+		}()
+	}
+	wg.Wait()
+	Counts, err := database.DBInterface.GetJobCounts(ctx)
+	return Counts[interfaces.JobFailed], err
+}
@@ -0,0 +1,133 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"go-image-board/config"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//AuditLogSearch is the filter form of the audit log page and API, as entered
+type AuditLogSearch struct {
+	//UserName only shows entries written by this user
+	UserName string
+	//Action only shows entries of this exact action, such as DELETE-IMAGE
+	Action string
+	//TargetType only shows entries that acted on this kind of object, Image, Tag, Collection or User
+	TargetType string
+	//TargetID only shows entries that acted on this object ID
+	TargetID string
+	//Since and Until are a time window in UTC, either RFC 3339 or datetime-local format. Until is exclusive
+	Since string
+	Until string
+}
+
+//ParseAuditLogSearch reads an AuditLogSearch from the form values of a request
+func ParseAuditLogSearch(request *http.Request) AuditLogSearch {
+	return AuditLogSearch{
+		UserName:   strings.TrimSpace(request.FormValue("UserName")),
+		Action:     strings.TrimSpace(request.FormValue("Action")),
+		TargetType: strings.TrimSpace(request.FormValue("TargetType")),
+		TargetID:   strings.TrimSpace(request.FormValue("TargetID")),
+		Since:      strings.TrimSpace(request.FormValue("Since")),
+		Until:      strings.TrimSpace(request.FormValue("Until")),
+	}
+}
+
+//auditTargetTypes are the target types that can be searched on
+var auditTargetTypes = []interfaces.AuditTargetType{interfaces.AuditTargetImage, interfaces.AuditTargetTag, interfaces.AuditTargetCollection, interfaces.AuditTargetUser}
+
+//parseAuditLogTime parses an RFC 3339 or datetime-local time, the latter is taken as UTC
+func parseAuditLogTime(Value string) (time.Time, error) {
+	if Parsed, err := time.Parse(time.RFC3339, Value); err == nil {
+		return Parsed, nil
+	}
+	return time.ParseInLocation(datetimeLocalFormat, Value, time.UTC)
+}
+
+//Filter converts the search into a filter for SearchAuditLogs. Empty values are not filtered on
+func (Search AuditLogSearch) Filter(ctx context.Context) (interfaces.AuditLogFilter, error) {
+	var Filter interfaces.AuditLogFilter
+	var err error
+	if Search.UserName != "" {
+		if Filter.UserID, err = database.DBInterface.GetUserID(ctx, Search.UserName); err != nil {
+			return Filter, errors.New("no user named " + Search.UserName)
+		}
+	}
+	Filter.Action = interfaces.AuditAction(strings.ToUpper(Search.Action))
+	if Search.TargetType != "" {
+		Found := false
+		for _, TargetType := range auditTargetTypes {
+			if strings.EqualFold(Search.TargetType, string(TargetType)) {
+				Filter.TargetType = TargetType
+				Found = true
+			}
+		}
+		if Found == false {
+			return Filter, errors.New("target type must be one of Image, Tag, Collection or User")
+		}
+	}
+	if Search.TargetID != "" {
+		if Filter.TargetID, err = strconv.ParseUint(Search.TargetID, 10, 64); err != nil || Filter.TargetID == 0 {
+			return Filter, errors.New("target ID must be a positive number")
+		}
+	}
+	if Search.Since != "" {
+		if Filter.Since, err = parseAuditLogTime(Search.Since); err != nil {
+			return Filter, errors.New("could not parse the since time")
+		}
+	}
+	if Search.Until != "" {
+		if Filter.Until, err = parseAuditLogTime(Search.Until); err != nil {
+			return Filter, errors.New("could not parse the until time")
+		}
+	}
+	return Filter, nil
+}
+
+//Query returns the search as URL query values, for links to other pages of results
+func (Search AuditLogSearch) Query() string {
+	return "UserName=" + url.QueryEscape(Search.UserName) + "&Action=" + url.QueryEscape(Search.Action) + "&TargetType=" + url.QueryEscape(Search.TargetType) + "&TargetID=" + url.QueryEscape(Search.TargetID) + "&Since=" + url.QueryEscape(Search.Since) + "&Until=" + url.QueryEscape(Search.Until)
+}
+
+//ModAuditGetRouter serves get requests to /mod/audit
+func ModAuditGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
+	TemplateInput := getTemplateInputFromRequest(responseWriter, request)
+	if TemplateInput.UserPermissions.HasPermission(interfaces.ViewAuditLogs) != true {
+		TemplateInput.HTMLMessage += template.HTML("You do not have permission to view the audit log.<br>")
+		redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFail")
+		return
+	}
+
+	//Get the page offset, defaulting to 0 on err
+	var pageStart uint64
+	if parsedPageStart, err := strconv.ParseUint(request.FormValue("PageStart"), 10, 32); err == nil {
+		pageStart = parsedPageStart
+	}
+	pageStride := config.Configuration.PageStride
+
+	TemplateInput.AuditLogSearch = ParseAuditLogSearch(request)
+	Filter, err := TemplateInput.AuditLogSearch.Filter(request.Context())
+	if err != nil {
+		TemplateInput.HTMLMessage += template.HTML(template.HTMLEscapeString("Invalid search, "+err.Error()) + ".<br>")
+		replyWithTemplate("modAudit.html", TemplateInput, responseWriter, request)
+		return
+	}
+
+	TemplateInput.AuditLogs, TemplateInput.TotalResults, err = database.DBInterface.SearchAuditLogs(request.Context(), Filter, pageStart, pageStride)
+	if err != nil {
+		TemplateInput.HTMLMessage += template.HTML("Failed to load the audit log.<br>")
+		logging.WriteLog(logging.LogLevelError, "modauditrouter/ModAuditGetRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to search audit logs", err.Error()})
+	}
+	TemplateInput.PageMenu, err = generatePageMenu(int64(pageStart), int64(pageStride), int64(TemplateInput.TotalResults), TemplateInput.AuditLogSearch.Query(), "/mod/audit")
+
+	replyWithTemplate("modAudit.html", TemplateInput, responseWriter, request)
+}
@@ -0,0 +1,97 @@
+package routers
+
+import (
+	"go-image-board/config"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+//ModJobsGetRouter serves get requests to /mod/jobs
+func ModJobsGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
+	TemplateInput := getTemplateInputFromRequest(responseWriter, request)
+	if TemplateInput.UserPermissions.HasPermission(interfaces.ManageJobs) != true {
+		TemplateInput.HTMLMessage += template.HTML("You do not have permission to view background jobs.<br>")
+		redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFail")
+		return
+	}
+
+	//Failed jobs are the ones that need attention, so show them unless asked otherwise
+	TemplateInput.JobStatus = interfaces.JobFailed
+	switch Status := interfaces.JobStatus(request.FormValue("Status")); Status {
+	case interfaces.JobPending, interfaces.JobRunning:
+		TemplateInput.JobStatus = Status
+	}
+
+	//Get the page offset, defaulting to 0 on err
+	var pageStart uint64
+	if parsedPageStart, err := strconv.ParseUint(request.FormValue("PageStart"), 10, 32); err == nil {
+		pageStart = parsedPageStart
+	}
+	pageStride := config.Configuration.PageStride
+
+	var err error
+	TemplateInput.Jobs, TemplateInput.TotalResults, err = database.DBInterface.GetJobs(request.Context(), TemplateInput.JobStatus, pageStart, pageStride)
+	if err != nil {
+		TemplateInput.HTMLMessage += template.HTML("Failed to load background jobs.<br>")
+		logging.WriteLog(logging.LogLevelError, "modjobsrouter/ModJobsGetRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to get jobs", err.Error()})
+	}
+	JobCounts, err := database.DBInterface.GetJobCounts(request.Context())
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "modjobsrouter/ModJobsGetRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to count jobs", err.Error()})
+	}
+	TemplateInput.JobCounts = make(map[string]uint64)
+	for _, Status := range []interfaces.JobStatus{interfaces.JobFailed, interfaces.JobPending, interfaces.JobRunning} {
+		TemplateInput.JobCounts[string(Status)] = JobCounts[Status]
+	}
+	TemplateInput.PageMenu, err = generatePageMenu(int64(pageStart), int64(pageStride), int64(TemplateInput.TotalResults), "Status="+url.QueryEscape(string(TemplateInput.JobStatus)), "/mod/jobs")
+
+	replyWithTemplate("modJobs.html", TemplateInput, responseWriter, request)
+}
+
+//ModJobsPostRouter serves post requests to /mod/jobs
+func ModJobsPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
+	TemplateInput := getTemplateInputFromRequest(responseWriter, request)
+	if TemplateInput.UserPermissions.HasPermission(interfaces.ManageJobs) != true {
+		TemplateInput.HTMLMessage += template.HTML("You do not have permission to manage background jobs.<br>")
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditJobRetry, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
+		redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFail")
+		return
+	}
+
+	//A JobID of 0 retries every failed job
+	var JobID uint64
+	switch request.FormValue("command") {
+	case "retry":
+		var err error
+		JobID, err = strconv.ParseUint(request.FormValue("ID"), 10, 64)
+		if err != nil || JobID == 0 {
+			TemplateInput.HTMLMessage += template.HTML("Failed to get job with that ID.<br>")
+			redirectWithFlash(responseWriter, request, "/mod/jobs", TemplateInput.HTMLMessage, "ModFail")
+			return
+		}
+	case "retryall":
+	default:
+		TemplateInput.HTMLMessage += template.HTML("Command not recognized or form submitted incorrectly.<br>")
+		redirectWithFlash(responseWriter, request, "/mod/jobs", TemplateInput.HTMLMessage, "ModFail")
+		return
+	}
+
+	Retried, err := database.DBInterface.RetryFailedJobs(request.Context(), JobID, time.Now())
+	if err != nil {
+		TemplateInput.HTMLMessage += template.HTML("Failed to retry jobs. SQL Error.<br>")
+		logging.WriteLog(logging.LogLevelError, "modjobsrouter/ModJobsPostRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to retry jobs", err.Error()})
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditJobRetry, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"JobID": JobID, "Error": err.Error()})
+		redirectWithFlash(responseWriter, request, "/mod/jobs", TemplateInput.HTMLMessage, "ModFail")
+		return
+	}
+	WakeJobWorkers()
+	go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditJobRetry, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"JobID": JobID, "Retried": Retried})
+	TemplateInput.HTMLMessage += template.HTML(strconv.FormatUint(Retried, 10) + " jobs queued to retry.<br>")
+	redirectWithFlash(responseWriter, request, "/mod/jobs", TemplateInput.HTMLMessage, "ModSucceeded")
+}
@@ -0,0 +1,155 @@
+package routers
+
+import (
+	"context"
+	"database/sql"
+	"go-image-board/config"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"go-image-board/storage"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//trashPurgeInterval how often images past the retention period are purged from the recycle bin
+var trashPurgeInterval = time.Hour
+
+//trashPurgeBatch how many images are purged per query, so a large recycle bin is worked through in pieces
+const trashPurgeBatch = 100
+
+//ModTrashGetRouter serves get requests to /mod/trash
+func ModTrashGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
+	TemplateInput := getTemplateInputFromRequest(responseWriter, request)
+	if TemplateInput.UserPermissions.HasPermission(interfaces.RemoveImage) != true {
+		TemplateInput.HTMLMessage += template.HTML("You do not have permission to view the recycle bin.<br>")
+		redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFail")
+		return
+	}
+
+	//Get the page offset, defaulting to 0 on err
+	var pageStart uint64
+	if parsedPageStart, err := strconv.ParseUint(request.FormValue("PageStart"), 10, 32); err == nil {
+		pageStart = parsedPageStart
+	}
+	pageStride := config.Configuration.PageStride
+
+	var err error
+	TemplateInput.ImageInfo, TemplateInput.TotalResults, err = database.DBInterface.GetTrashedImages(request.Context(), pageStart, pageStride)
+	if err != nil {
+		TemplateInput.HTMLMessage += template.HTML("Failed to load the recycle bin.<br>")
+		logging.WriteLog(logging.LogLevelError, "modtrashrouter/ModTrashGetRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to get trashed images", err.Error()})
+	}
+	TemplateInput.PageMenu, err = generatePageMenu(int64(pageStart), int64(pageStride), int64(TemplateInput.TotalResults), "", "/mod/trash")
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "modtrashrouter/ModTrashGetRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to generate page menu", err.Error()})
+	}
+
+	replyWithTemplate("modTrash.html", TemplateInput, responseWriter, request)
+}
+
+//ModTrashPostRouter serves post requests to /mod/trash
+func ModTrashPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
+	TemplateInput := getTemplateInputFromRequest(responseWriter, request)
+	if TemplateInput.UserPermissions.HasPermission(interfaces.RemoveImage) != true {
+		TemplateInput.HTMLMessage += template.HTML("You do not have permission to manage the recycle bin.<br>")
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditImageRestore, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
+		redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFail")
+		return
+	}
+
+	ImageID, err := strconv.ParseUint(request.FormValue("ID"), 10, 32)
+	if err != nil {
+		TemplateInput.HTMLMessage += template.HTML("Failed to get image with that ID.<br>")
+		redirectWithFlash(responseWriter, request, "/mod/trash", TemplateInput.HTMLMessage, "ModFail")
+		return
+	}
+
+	switch request.FormValue("command") {
+	case "restore":
+		if err := database.DBInterface.RestoreImage(request.Context(), ImageID); err != nil {
+			if err == sql.ErrNoRows {
+				TemplateInput.HTMLMessage += template.HTML("That image is not in the recycle bin.<br>")
+			} else {
+				TemplateInput.HTMLMessage += template.HTML("Failed to restore image. SQL Error.<br>")
+			}
+			go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditImageRestore, interfaces.AuditTargetImage, ImageID, interfaces.AuditDetails{"Error": err.Error()})
+			redirectWithFlash(responseWriter, request, "/mod/trash", TemplateInput.HTMLMessage, "ModFail")
+			return
+		}
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditImageRestore, interfaces.AuditTargetImage, ImageID, nil)
+		TemplateInput.HTMLMessage += template.HTML("Image restored.<br>")
+		redirectWithFlash(responseWriter, request, "/image?ID="+request.FormValue("ID"), TemplateInput.HTMLMessage, "ModSucceeded")
+		return
+	case "purge":
+		ImageInfo, err := database.DBInterface.GetImage(request.Context(), ImageID)
+		if err != nil || ImageInfo.DeletedTime.IsZero() {
+			TemplateInput.HTMLMessage += template.HTML("That image is not in the recycle bin.<br>")
+			redirectWithFlash(responseWriter, request, "/mod/trash", TemplateInput.HTMLMessage, "ModFail")
+			return
+		}
+		if err := purgeImage(request.Context(), ImageInfo); err != nil {
+			TemplateInput.HTMLMessage += template.HTML("Failed to purge image. SQL Error.<br>")
+			go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditImagePurge, interfaces.AuditTargetImage, ImageID, interfaces.AuditDetails{"Error": err.Error()})
+			redirectWithFlash(responseWriter, request, "/mod/trash", TemplateInput.HTMLMessage, "ModFail")
+			return
+		}
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditImagePurge, interfaces.AuditTargetImage, ImageID, interfaces.AuditDetails{"Name": ImageInfo.Name, "Location": ImageInfo.Location})
+		TemplateInput.HTMLMessage += template.HTML("Image purged.<br>")
+		redirectWithFlash(responseWriter, request, "/mod/trash", TemplateInput.HTMLMessage, "ModSucceeded")
+		return
+	}
+	TemplateInput.HTMLMessage += template.HTML("Command not recognized or form submitted incorrectly.<br>")
+	redirectWithFlash(responseWriter, request, "/mod/trash", TemplateInput.HTMLMessage, "ModFail")
+}
+
+//purgeImage permanently deletes an image, then its file and thumbnail
+func purgeImage(ctx context.Context, ImageInfo interfaces.ImageInformation) error {
+	if err := database.DBInterface.DeleteImage(ctx, ImageInfo.ID); err != nil {
+		return err
+	}
+	for _, FileName := range []string{ImageInfo.Location, ThumbnailName(ImageInfo.Location)} {
+		if err := storage.BlobStore.Delete(ctx, FileName); err != nil {
+			logging.WriteLog(logging.LogLevelWarning, "modtrashrouter/purgeImage", "0", logging.ResultFailure, []string{"Failed to remove file of purged image", FileName, err.Error()})
+		}
+	}
+	return nil
+}
+
+//PurgeExpiredTrash purges every image that has been in the recycle bin longer than TrashRetentionDays, returning how many were purged
+func PurgeExpiredTrash(ctx context.Context) (int, error) {
+	if config.Configuration.TrashRetentionDays < 0 {
+		return 0, nil //Kept forever
+	}
+	Before := time.Now().AddDate(0, 0, -int(config.Configuration.TrashRetentionDays))
+	Purged := 0
+	for {
+		Expired, err := database.DBInterface.GetImagesTrashedBefore(ctx, Before, trashPurgeBatch)
+		if err != nil || len(Expired) == 0 {
+			return Purged, err
+		}
+		for _, ImageInfo := range Expired {
+			if err := purgeImage(ctx, ImageInfo); err != nil {
+				return Purged, err
+			}
+			Purged++
+			go WriteAuditLog(ctx, 0, interfaces.AuditImagePurge, interfaces.AuditTargetImage, ImageInfo.ID, interfaces.AuditDetails{"Name": ImageInfo.Name, "Location": ImageInfo.Location, "Reason": "retention period"})
+		}
+	}
+}
+
+//StartTrashPurge purges expired images from the recycle bin now, and again every trashPurgeInterval
+func StartTrashPurge() {
+	go func() {
+		for {
+			if Purged, err := PurgeExpiredTrash(context.Background()); err != nil {
+				logging.WriteLog(logging.LogLevelError, "modtrashrouter/StartTrashPurge", "0", logging.ResultFailure, []string{"Failed to purge recycle bin", err.Error()})
+			} else if Purged > 0 {
+				logging.WriteLog(logging.LogLevelInfo, "modtrashrouter/StartTrashPurge", "0", logging.ResultSuccess, []string{"Purged", strconv.Itoa(Purged), "images from the recycle bin"})
+			}
+			time.Sleep(trashPurgeInterval)
+		}
+	}()
+}
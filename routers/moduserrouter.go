@@ -6,6 +6,7 @@ import (
 	"go-image-board/logging"
 	"html/template"
 	"net/http"
+	"net/url"
 	"strconv"
 )
 
@@ -19,8 +20,8 @@ func ModUserGetRouter(responseWriter http.ResponseWriter, request *http.Request)
 		return
 	}
 
-	if modUserID, err := database.DBInterface.GetUserID(request.FormValue("userName")); err == nil {
-		if TemplateInput.ModUserData, err = database.DBInterface.GetUser(modUserID); err != nil {
+	if modUserID, err := database.DBInterface.GetUserID(request.Context(), request.FormValue("userName")); err == nil {
+		if TemplateInput.ModUserData, err = database.DBInterface.GetUser(request.Context(), modUserID); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Could not get userdata.<br>")
 			logging.WriteLog(logging.LogLevelError, "moduserrouter/ModUserRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"SQL error occured getting userdata ", err.Error()})
 			redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFail")
@@ -32,6 +33,39 @@ func ModUserGetRouter(responseWriter http.ResponseWriter, request *http.Request)
 		return
 	}
 
+	//Preview undoing the user's changes to images in a time window
+	if request.FormValue("RevertSince") != "" && TemplateInput.UserPermissions.HasPermission(interfaces.DisableUser) {
+		since, until, err := parseRevertWindow(request)
+		if err != nil {
+			TemplateInput.HTMLMessage += template.HTML("Failed to parse the time window.<br>")
+			replyWithTemplate("modUser.html", TemplateInput, responseWriter, request)
+			return
+		}
+		plan, err := planUserRevert(request.Context(), database.DBInterface, TemplateInput.ModUserData.ID, since, until)
+		if err != nil {
+			TemplateInput.HTMLMessage += template.HTML("Failed to load the user's changes.<br>")
+			logging.WriteLog(logging.LogLevelError, "moduserrouter/ModUserRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to plan reverting user changes", err.Error()})
+			replyWithTemplate("modUser.html", TemplateInput, responseWriter, request)
+			return
+		}
+		TemplateInput.RevertSince = since.Format(datetimeLocalFormat)
+		TemplateInput.RevertUntil = until.Format(datetimeLocalFormat)
+		for _, change := range plan {
+			if change.ChangedSince == false {
+				TemplateInput.RevertCount++
+			}
+		}
+		var pageStart uint64
+		if parsedPageStart, err := strconv.ParseUint(request.FormValue("PageStart"), 10, 32); err == nil && parsedPageStart < uint64(len(plan)) {
+			pageStart = parsedPageStart
+		}
+		TemplateInput.UserRevertChanges = plan[pageStart:min(pageStart+userRevertStride, uint64(len(plan)))]
+		TemplateInput.TotalResults = uint64(len(plan))
+		if len(plan) > userRevertStride {
+			TemplateInput.PageMenu, _ = generatePageMenu(int64(pageStart), userRevertStride, int64(len(plan)), "userName="+url.QueryEscape(TemplateInput.ModUserData.Name)+"&RevertSince="+url.QueryEscape(TemplateInput.RevertSince)+"&RevertUntil="+url.QueryEscape(TemplateInput.RevertUntil), "/mod/user")
+		}
+	}
+
 	replyWithTemplate("modUser.html", TemplateInput, responseWriter, request)
 }
 
@@ -58,7 +92,7 @@ func ModUserPostRouter(responseWriter http.ResponseWriter, request *http.Request
 		if TemplateInput.UserPermissions.HasPermission(interfaces.EditUserPermissions) != true {
 			TemplateInput.HTMLMessage += template.HTML("User does not have modify permission for user permissions.<br>")
 
-			go WriteAuditLog(TemplateInput.UserInformation.ID, "EDIT-USERPERMISSIONS", TemplateInput.UserInformation.Name+" failed to edit user permissions, insufficient permissions.")
+			go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditUserPermissions, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"UserName": request.FormValue("userName"), "Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFailed")
 			return
 		}
@@ -70,17 +104,18 @@ func ModUserPostRouter(responseWriter http.ResponseWriter, request *http.Request
 			return
 		}
 		sUserName := request.FormValue("userName")
-		iUserID, err := database.DBInterface.GetUserID(sUserName)
+		iUserID, err := database.DBInterface.GetUserID(request.Context(), sUserName)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to find user.<br>")
 			redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFailed")
 			return
 		}
-		if err := database.DBInterface.SetUserPermissionSet(iUserID, iPerms); err != nil {
+		if err := database.DBInterface.SetUserPermissionSet(request.Context(), iUserID, iPerms); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to update user in database.<br>")
 			redirectWithFlash(responseWriter, request, "/mod/user?userName="+request.FormValue("userName"), TemplateInput.HTMLMessage, "ModFailed")
 			return
 		}
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditUserPermissions, interfaces.AuditTargetUser, iUserID, interfaces.AuditDetails{"Permissions": iPerms})
 		TemplateInput.HTMLMessage += template.HTML("Successfully set the user's permissions.<br>")
 		redirectWithFlash(responseWriter, request, "/mod/user?userName="+request.FormValue("userName"), TemplateInput.HTMLMessage, "ModSucceeded")
 		return
@@ -94,7 +129,7 @@ func ModUserPostRouter(responseWriter http.ResponseWriter, request *http.Request
 		//Check if has permissions
 		if TemplateInput.UserPermissions.HasPermission(interfaces.DisableUser) != true {
 			TemplateInput.HTMLMessage += template.HTML("User does not have disable permission for users.<br>")
-			go WriteAuditLog(TemplateInput.UserInformation.ID, "DISABLE-USER", TemplateInput.UserInformation.Name+" failed to edit user permissions, insufficient permissions.")
+			go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditUserDisable, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"UserName": request.FormValue("userName"), "Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFailed")
 			return
 		}
@@ -107,20 +142,60 @@ func ModUserPostRouter(responseWriter http.ResponseWriter, request *http.Request
 			return
 		}
 		sUserName := request.FormValue("userName")
-		iUserID, err := database.DBInterface.GetUserID(sUserName)
+		iUserID, err := database.DBInterface.GetUserID(request.Context(), sUserName)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to find user<br>")
 			redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFailed")
 			return
 		}
-		if err := database.DBInterface.SetUserDisableState(iUserID, bDisableState); err != nil {
+		if err := database.DBInterface.SetUserDisableState(request.Context(), iUserID, bDisableState); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to set user disable state in database.<br>")
 			redirectWithFlash(responseWriter, request, "/mod/user?userName="+request.FormValue("userName"), TemplateInput.HTMLMessage, "ModFailed")
 			return
 		}
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditUserDisable, interfaces.AuditTargetUser, iUserID, interfaces.AuditDetails{"Disabled": bDisableState})
 		TemplateInput.HTMLMessage += template.HTML("Successfully set the user's disable state.<br>")
 		redirectWithFlash(responseWriter, request, "/mod/user?userName="+request.FormValue("userName"), TemplateInput.HTMLMessage, "ModSucceeded")
 		return
+	case "revertUserEdits":
+		//Check if logged in
+		if TemplateInput.UserInformation.ID == 0 {
+			TemplateInput.HTMLMessage += template.HTML("You must be logged in to perform that action.<br>")
+			redirectWithFlash(responseWriter, request, "/logon", TemplateInput.HTMLMessage, "LogonRequired")
+			return
+		}
+		//Check if has permissions
+		if TemplateInput.UserPermissions.HasPermission(interfaces.DisableUser) != true {
+			TemplateInput.HTMLMessage += template.HTML("User does not have permission to revert other users' changes.<br>")
+			go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditUserRevert, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"UserName": request.FormValue("userName"), "Error": interfaces.AuditDenied})
+			redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFailed")
+			return
+		}
+		//Do the thing
+		since, until, err := parseRevertWindow(request)
+		if err != nil {
+			TemplateInput.HTMLMessage += template.HTML("Failed to parse the time window.<br>")
+			redirectWithFlash(responseWriter, request, "/mod/user?userName="+request.FormValue("userName"), TemplateInput.HTMLMessage, "ModFailed")
+			return
+		}
+		sUserName := request.FormValue("userName")
+		iUserID, err := database.DBInterface.GetUserID(request.Context(), sUserName)
+		if err != nil {
+			TemplateInput.HTMLMessage += template.HTML("Failed to find user.<br>")
+			redirectWithFlash(responseWriter, request, "/mod", TemplateInput.HTMLMessage, "ModFailed")
+			return
+		}
+		reverted, err := revertUserChanges(request.Context(), iUserID, since, until, TemplateInput.UserInformation.ID)
+		if err != nil {
+			TemplateInput.HTMLMessage += template.HTML("Failed to revert the user's changes, nothing was changed.<br>")
+			logging.WriteLog(logging.LogLevelError, "moduserrouter/ModUserPostRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to revert user changes", sUserName, err.Error()})
+			redirectWithFlash(responseWriter, request, "/mod/user?userName="+request.FormValue("userName"), TemplateInput.HTMLMessage, "ModFailed")
+			return
+		}
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditUserRevert, interfaces.AuditTargetUser, iUserID, interfaces.AuditDetails{"Reverted": reverted, "Since": since, "Until": until})
+		TemplateInput.HTMLMessage += template.HTML("Successfully reverted " + strconv.Itoa(reverted) + " of the user's changes.<br>")
+		redirectWithFlash(responseWriter, request, "/mod/user?userName="+request.FormValue("userName"), TemplateInput.HTMLMessage, "ModSucceeded")
+		return
 	}
 
 	TemplateInput.HTMLMessage += template.HTML("Command not recognized or provided.<br>")
@@ -1,10 +1,16 @@
 package routers
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"go-image-board/config"
 	"go-image-board/database"
+	"go-image-board/interfaces"
 	"go-image-board/logging"
+	"go-image-board/storage"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
@@ -43,33 +49,112 @@ func RedirectRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	replyWithTemplate("redirect.html", TemplateInput, responseWriter, request)
 }
 
-//ResourceImageRouter handles requests to /images/{file}
+//canServeImageFile reports whether the file stored under Name may be sent to the user.
+//Only mods can see the files of images in the recycle bin, including their thumbnails, files that are not an image's are left to the blob store
+func canServeImageFile(responseWriter http.ResponseWriter, request *http.Request, Name string) bool {
+	Trashed, err := database.DBInterface.IsImageFileTrashed(request.Context(), imageFileName(Name))
+	if err != nil {
+		http.Error(responseWriter, "Failed to get image information", http.StatusInternalServerError)
+		return false
+	}
+	if Trashed == false {
+		return true
+	}
+	TemplateInput := getTemplateInputFromRequest(responseWriter, request)
+	if TemplateInput.UserPermissions.HasPermission(interfaces.RemoveImage) {
+		return true
+	}
+	http.NotFound(responseWriter, request)
+	return false
+}
+
+//imageFileName returns the name of the image file the file stored under Name belongs to, thumbnails map back to the image they were made from
+func imageFileName(Name string) string {
+	Name = path.Clean(Name)
+	for strings.HasPrefix(Name, "thumbs/") && strings.HasSuffix(Name, ".png") {
+		Name = strings.TrimSuffix(strings.TrimPrefix(Name, "thumbs/"), ".png")
+	}
+	return Name
+}
+
+//ResourceImageRouter handles requests to /images/{file}, file may include subdirectories
 func ResourceImageRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	urlVariables := mux.Vars(request)
-	http.ServeFile(responseWriter, request, path.Join(config.Configuration.ImageDirectory, urlVariables["file"]))
+	if canServeImageFile(responseWriter, request, urlVariables["file"]) == false {
+		return
+	}
+	storage.BlobStore.ServeBlob(responseWriter, request, urlVariables["file"])
 }
 
 //ThumbnailRouter handls requests to /thumbs
 func ThumbnailRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	urlVariables := mux.Vars(request)
-	thumbnailPath := path.Join(config.Configuration.ImageDirectory, "thumbs"+string(filepath.Separator)+urlVariables["file"]+".png")
+	if canServeImageFile(responseWriter, request, urlVariables["file"]) == false {
+		return
+	}
+	thumbnailName := ThumbnailName(urlVariables["file"])
 	//Check if file does not exist
-	if _, err := os.Stat(thumbnailPath); err != nil {
+	if _, err := storage.BlobStore.Stat(request.Context(), thumbnailName); err != nil {
 		switch ext := filepath.Ext(strings.ToLower(urlVariables["file"])); ext {
 		//If it does not, and it is an image, return the original image, more bandwidth but better looking site
 		case ".jpg", ".jpeg", ".bmp", ".gif", ".png", ".svg", ".webp", ".tiff", ".tif", ".jfif":
-			thumbnailPath = path.Join(config.Configuration.ImageDirectory, string(filepath.Separator)+urlVariables["file"])
+			if _, err := storage.BlobStore.Stat(request.Context(), urlVariables["file"]); err == nil {
+				storage.BlobStore.ServeBlob(responseWriter, request, urlVariables["file"])
+				return
+			}
 		//If a video or music file, pull up a play icon
 		case ".mpg", ".mov", ".webm", ".avi", ".mp4", ".mp3", ".ogg", ".wav":
-			thumbnailPath = path.Join(config.Configuration.HTTPRoot, "resources"+string(filepath.Separator)+"playicon.svg")
+			http.ServeFile(responseWriter, request, path.Join(config.Configuration.HTTPRoot, "resources"+string(filepath.Separator)+"playicon.svg"))
+			return
 		}
+		//Final fallback, just return a no image type icon
+		http.ServeFile(responseWriter, request, path.Join(config.Configuration.HTTPRoot, "resources"+string(filepath.Separator)+"noicon.svg"))
+		return
+	}
+	storage.BlobStore.ServeBlob(responseWriter, request, thumbnailName)
+}
+
+//ThumbnailName returns the name of the thumbnail of the image stored under Name
+func ThumbnailName(Name string) string {
+	return "thumbs/" + Name + ".png"
+}
+
+//localMediaPath returns a path to a file holding the content of the image stored under Name, for tools like FFMPEG that can only read files.
+//Stores that keep files locally give their own path, otherwise the content is copied to a temporary file. Call the returned function once done with the path
+func localMediaPath(ctx context.Context, Name string) (string, func(), error) {
+	if Store, isLocal := storage.BlobStore.(interfaces.LocalBlobStore); isLocal {
+		return Store.LocalPath(Name), func() {}, nil
+	}
+	Reader, err := storage.BlobStore.Open(ctx, Name)
+	if err != nil {
+		return "", nil, err
+	}
+	defer Reader.Close()
+	File, err := os.CreateTemp("", "gib-*"+filepath.Ext(Name))
+	if err != nil {
+		return "", nil, err
 	}
-	//Final fallback, just return a no image type icon
-	if _, err := os.Stat(thumbnailPath); err != nil {
-		thumbnailPath = path.Join(config.Configuration.HTTPRoot, "resources"+string(filepath.Separator)+"noicon.svg")
+	Remove := func() { os.Remove(File.Name()) }
+	_, err = io.Copy(File, Reader)
+	if closeErr := File.Close(); err == nil {
+		err = closeErr
 	}
+	if err != nil {
+		Remove()
+		return "", nil, err
+	}
+	return File.Name(), Remove, nil
+}
 
-	http.ServeFile(responseWriter, request, thumbnailPath)
+//CanGenerateThumbnail reports whether GenerateThumbnail has a method for the file's type, videos also need UseFFMPEG
+func CanGenerateThumbnail(Name string) bool {
+	switch filepath.Ext(strings.ToLower(Name)) {
+	case ".jpg", ".jpeg", ".bmp", ".gif", ".png", ".webp", ".tiff", ".tif", ".jfif":
+		return true
+	case ".mpg", ".mov", ".webm", ".avi", ".mp4":
+		return config.Configuration.UseFFMPEG
+	}
+	return false
 }
 
 //GenerateThumbnail will attempt to generate a thumbnail for the specified resource
@@ -78,11 +163,11 @@ func GenerateThumbnail(Name string) error {
 	//Each case will contain generators for that file type
 	switch ext := filepath.Ext(strings.ToLower(Name)); ext {
 	case ".jpg", ".jpeg", ".bmp", ".gif", ".png", ".webp", ".tiff", ".tif", ".jfif":
-		File, err := os.Open(path.Join(config.Configuration.ImageDirectory, Name))
-		defer File.Close()
+		File, err := storage.BlobStore.Open(context.Background(), Name)
 		if err != nil {
 			return err
 		}
+		defer File.Close()
 		originalImage, _, err := imageorient.Decode(File)
 		if err != nil {
 			return err
@@ -100,14 +185,12 @@ func GenerateThumbnail(Name string) error {
 			newWidth = uint(float64(newWidth) * scale)
 			newHeight = uint(float64(newHeight) * scale)
 		}
-		//Open the specified file at Path
-		NewFile, err := os.OpenFile(path.Join(config.Configuration.ImageDirectory, "thumbs"+string(filepath.Separator)+Name+".png"), os.O_CREATE|os.O_RDWR, 0660)
-		defer NewFile.Close()
-		if err != nil {
+		thumbnailImage := resize.Resize(uint(newWidth), uint(newHeight), originalImage, resize.Lanczos3)
+		var thumbnail bytes.Buffer
+		if err := png.Encode(&thumbnail, thumbnailImage); err != nil {
 			return err
 		}
-		thumbnailImage := resize.Resize(uint(newWidth), uint(newHeight), originalImage, resize.Lanczos3)
-		return png.Encode(NewFile, thumbnailImage)
+		return storage.BlobStore.Put(context.Background(), ThumbnailName(Name), &thumbnail, int64(thumbnail.Len()))
 	case ".mpg", ".mov", ".webm", ".avi", ".mp4":
 		logging.WriteLog(logging.LogLevelDebug, "resourcesrouters/GenerateThumbnail", "0", logging.ResultInfo, []string{"Video detected", Name})
 
@@ -119,12 +202,35 @@ func GenerateThumbnail(Name string) error {
 		//ffmpeg -i input.mp4 -vf  "thumbnail,scale=640:360" -frames:v 1 thumb.png
 		//Fire forget
 		sizeParam := "thumbnail,scale=" + strconv.FormatUint(uint64(config.Configuration.MaxThumbnailWidth), 10) + ":" + strconv.FormatUint(uint64(config.Configuration.MaxThumbnailHeight), 10)
-		ffmpegCMD := exec.Command(config.Configuration.FFMPEGPath, "-i", path.Join(config.Configuration.ImageDirectory, Name), "-vf", sizeParam, "-frames:v", "1", path.Join(config.Configuration.ImageDirectory, "thumbs"+string(filepath.Separator)+Name+".png"))
-		_, err := ffmpegCMD.Output()
+		inputPath, removeInput, err := localMediaPath(context.Background(), Name)
+		if err != nil {
+			return err
+		}
+		defer removeInput()
+		//FFMPEG writes the thumbnail to a temporary directory, it is then put in the blob store
+		outputDirectory, err := os.MkdirTemp("", "gib-thumb-*")
 		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(outputDirectory)
+		outputPath := filepath.Join(outputDirectory, "thumb.png")
+		ffmpegCMD := exec.Command(config.Configuration.FFMPEGPath, "-i", inputPath, "-vf", sizeParam, "-frames:v", "1", outputPath)
+		if _, err := ffmpegCMD.Output(); err != nil {
 			logging.WriteLog(logging.LogLevelError, "resourcesrouters/GenerateThumbnail", "0", logging.ResultFailure, []string{"Failed to use FFMPEG", Name, err.Error()})
 			return err
 		}
+		thumbnail, err := os.Open(outputPath)
+		if err != nil {
+			return err
+		}
+		defer thumbnail.Close()
+		info, err := thumbnail.Stat()
+		if err != nil {
+			return err
+		}
+		if err := storage.BlobStore.Put(context.Background(), ThumbnailName(Name), thumbnail, info.Size()); err != nil {
+			return err
+		}
 		logging.WriteLog(logging.LogLevelInfo, "resourcesrouters/GenerateThumbnail", "0", logging.ResultInfo, []string{"FFMPEG output success", Name})
 		return nil
 	default:
@@ -132,17 +238,26 @@ func GenerateThumbnail(Name string) error {
 	}
 }
 
+//CanGeneratedHash reports whether GeneratedHash can process the file's type
+func CanGeneratedHash(Name string) bool {
+	switch filepath.Ext(strings.ToLower(Name)) {
+	case ".jpg", ".jpeg", ".bmp", ".gif", ".png", ".webp", ".tiff", ".tif", ".jfif":
+		return true
+	}
+	return false
+}
+
 //GeneratedHash will attempt to generate a dHash for the given image
 func GeneratedHash(Name string, ImageID uint64) error {
 	//Switch on extension
 	switch ext := filepath.Ext(strings.ToLower(Name)); ext {
 	case ".jpg", ".jpeg", ".bmp", ".gif", ".png", ".webp", ".tiff", ".tif", ".jfif":
 		//Load image
-		File, err := os.Open(path.Join(config.Configuration.ImageDirectory, Name))
-		defer File.Close()
+		File, err := storage.BlobStore.Open(context.Background(), Name)
 		if err != nil {
 			return err
 		}
+		defer File.Close()
 		originalImage, _, err := imageorient.Decode(File)
 		if err != nil {
 			return err
@@ -184,8 +299,124 @@ func GeneratedHash(Name string, ImageID uint64) error {
 			}
 		}
 
-		return database.DBInterface.SetImagedHash(ImageID, hHash, vHash)
+		return database.DBInterface.SetImagedHash(context.Background(), ImageID, hHash, vHash)
 	default:
 		return errors.New("Cannot process image of this type")
 	}
 }
+
+//mediaTypes are the MIME types of the file types uploads accept
+var mediaTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".jfif": "image/jpeg",
+	".bmp":  "image/bmp",
+	".gif":  "image/gif",
+	".png":  "image/png",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+	".tiff": "image/tiff",
+	".tif":  "image/tiff",
+	".mpg":  "video/mpeg",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".avi":  "video/x-msvideo",
+	".mp4":  "video/mp4",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+}
+
+//ffprobeOutput is the part of ffprobe's JSON output that ReadImageMetadata uses
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType   string `json:"codec_type"`
+		CodecName   string `json:"codec_name"`
+		Width       uint64 `json:"width"`
+		Height      uint64 `json:"height"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+//ReadImageMetadata reads the metadata of the image stored under Name. Stills are decoded, audio and video are read with ffprobe if UseFFMPEG is set,
+//otherwise only their size and MIME type are known
+func ReadImageMetadata(ctx context.Context, Name string) (interfaces.ImageMetadata, error) {
+	var ToReturn interfaces.ImageMetadata
+	Info, err := storage.BlobStore.Stat(ctx, Name)
+	if err != nil {
+		return ToReturn, err
+	}
+	ToReturn.FileSize = uint64(Info.Size)
+	ToReturn.MIMEType = mediaTypes[filepath.Ext(strings.ToLower(Name))]
+
+	switch ext := filepath.Ext(strings.ToLower(Name)); ext {
+	case ".jpg", ".jpeg", ".bmp", ".gif", ".png", ".webp", ".tiff", ".tif", ".jfif":
+		File, err := storage.BlobStore.Open(ctx, Name)
+		if err != nil {
+			return ToReturn, err
+		}
+		defer File.Close()
+		Config, Format, err := imageorient.DecodeConfig(File)
+		if err != nil {
+			return ToReturn, err
+		}
+		ToReturn.Width = uint64(Config.Width)
+		ToReturn.Height = uint64(Config.Height)
+		ToReturn.Codec = Format
+	case ".mpg", ".mov", ".webm", ".avi", ".mp4", ".mp3", ".ogg", ".wav":
+		if !config.Configuration.UseFFMPEG {
+			break
+		}
+		inputPath, removeInput, err := localMediaPath(ctx, Name)
+		if err != nil {
+			return ToReturn, err
+		}
+		defer removeInput()
+		ffprobeCMD := exec.CommandContext(ctx, config.Configuration.FFProbePath, "-v", "error", "-print_format", "json", "-show_format", "-show_streams", inputPath)
+		Output, err := ffprobeCMD.Output()
+		if err != nil {
+			logging.WriteLog(logging.LogLevelError, "resourcesrouters/ReadImageMetadata", "0", logging.ResultFailure, []string{"Failed to use ffprobe", Name, err.Error()})
+			return ToReturn, err
+		}
+		var Probe ffprobeOutput
+		if err := json.Unmarshal(Output, &Probe); err != nil {
+			return ToReturn, err
+		}
+		for _, Stream := range Probe.Streams {
+			//Cover art of audio files is reported as a video stream
+			if Stream.CodecType == "video" && Stream.Disposition.AttachedPic == 0 {
+				ToReturn.Width = Stream.Width
+				ToReturn.Height = Stream.Height
+				ToReturn.Codec = Stream.CodecName
+				break
+			}
+			if Stream.CodecType == "audio" && ToReturn.Codec == "" {
+				ToReturn.Codec = Stream.CodecName
+			}
+		}
+		if Duration, err := strconv.ParseFloat(Probe.Format.Duration, 64); err == nil {
+			ToReturn.Duration = Duration
+		}
+	}
+
+	//Fall back to sniffing the content for types not in mediaTypes
+	if ToReturn.MIMEType == "" {
+		File, err := storage.BlobStore.Open(ctx, Name)
+		if err != nil {
+			return ToReturn, err
+		}
+		defer File.Close()
+		Header := make([]byte, 512)
+		Read, err := io.ReadFull(File, Header)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return ToReturn, err
+		}
+		ToReturn.MIMEType = http.DetectContentType(Header[:Read])
+	}
+	return ToReturn, nil
+}
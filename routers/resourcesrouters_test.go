@@ -0,0 +1,84 @@
+package routers
+
+import (
+	"bytes"
+	"context"
+	"go-image-board/config"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/plugins/dbconformance"
+	"go-image-board/plugins/localblobplugin"
+	"go-image-board/plugins/memoryplugin"
+	"go-image-board/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestTrashedImageFilesAreHidden(t *testing.T) {
+	dbconformance.PrepareLogging()
+	ctx := t.Context()
+	DB := &memoryplugin.MemoryPlugin{}
+	if err := DB.InitDatabase(); err != nil {
+		t.Fatalf("InitDatabase failed: %v", err)
+	}
+	database.DBInterface = DB
+	config.Configuration.ImageDirectory = t.TempDir()
+	Store := &localblobplugin.LocalBlobPlugin{}
+	if err := Store.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	storage.BlobStore = Store
+
+	for _, Name := range []string{"ab/cd/trashed.jpg", "ab/cd/kept.jpg"} {
+		ImageID, err := DB.NewImage(ctx, Name, Name, 1, "")
+		if err != nil {
+			t.Fatalf("NewImage failed: %v", err)
+		}
+		for _, FileName := range []string{Name, ThumbnailName(Name)} {
+			if err := Store.Put(ctx, FileName, bytes.NewReader([]byte(FileName)), int64(len(FileName))); err != nil {
+				t.Fatalf("Put(%s) failed: %v", FileName, err)
+			}
+		}
+		if Name == "ab/cd/trashed.jpg" {
+			if err := DB.TrashImage(ctx, ImageID, 1); err != nil {
+				t.Fatalf("TrashImage failed: %v", err)
+			}
+		}
+	}
+
+	Router := mux.NewRouter()
+	Router.HandleFunc("/images/{file:.+}", ResourceImageRouter)
+	Router.HandleFunc("/thumbs/{file:.+}", ThumbnailRouter)
+	for _, Test := range []struct {
+		Path       string
+		IsMod      bool
+		WantStatus int
+	}{
+		{"/images/ab/cd/kept.jpg", false, http.StatusOK},
+		{"/images/thumbs/ab/cd/kept.jpg.png", false, http.StatusOK},
+		{"/thumbs/ab/cd/kept.jpg", false, http.StatusOK},
+		{"/images/ab/cd/trashed.jpg", false, http.StatusNotFound},
+		{"/images/thumbs/ab/cd/trashed.jpg.png", false, http.StatusNotFound},
+		{"/images/thumbs/thumbs/ab/cd/trashed.jpg.png.png", false, http.StatusNotFound},
+		{"/thumbs/ab/cd/trashed.jpg", false, http.StatusNotFound},
+		{"/thumbs/thumbs/ab/cd/trashed.jpg.png", false, http.StatusNotFound},
+		{"/images/ab/cd/trashed.jpg", true, http.StatusOK},
+		{"/images/thumbs/ab/cd/trashed.jpg.png", true, http.StatusOK},
+		{"/thumbs/ab/cd/trashed.jpg", true, http.StatusOK},
+	} {
+		TemplateInput := templateInput{}
+		if Test.IsMod {
+			TemplateInput.UserPermissions = interfaces.RemoveImage
+		}
+		Request := httptest.NewRequest(http.MethodGet, Test.Path, nil)
+		Request = Request.WithContext(context.WithValue(Request.Context(), TemplateInputKeyID, TemplateInput))
+		Recorder := httptest.NewRecorder()
+		Router.ServeHTTP(Recorder, Request)
+		if Recorder.Code != Test.WantStatus {
+			t.Errorf("GET %s as mod %v = %d, want %d", Test.Path, Test.IsMod, Recorder.Code, Test.WantStatus)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package routers
 
 import (
+	"context"
+	"encoding/json"
 	"go-image-board/config"
 	"go-image-board/database"
+	"go-image-board/interfaces"
 	"go-image-board/logging"
 	"net/http"
 	"path"
@@ -26,22 +29,31 @@ func BadConfigRouter(responseWriter http.ResponseWriter, request *http.Request)
 	http.ServeFile(responseWriter, request, path.Join(config.Configuration.HTTPRoot, "resources"+string(filepath.Separator)+"updateconfig.html"))
 }
 
-//WriteAuditLog Writes to the DB audit log, requires userID
-func WriteAuditLog(UserID uint64, Type string, Info string) error {
-
-	if err := database.DBInterface.AddAuditLog(UserID, Type, Info); err != nil {
+//WriteAuditLog Writes an event to the DB audit log. UserID is who acted, TargetType and TargetID what they acted on, Details may be nil
+//The entry is still written if ctx is cancelled, a client hanging up after a change should not drop the record of it
+func WriteAuditLog(ctx context.Context, UserID uint64, Action interfaces.AuditAction, TargetType interfaces.AuditTargetType, TargetID uint64, Details interfaces.AuditDetails) error {
+	Log := interfaces.AuditLog{UserID: UserID, Action: Action, TargetType: TargetType, TargetID: TargetID}
+	if Details != nil {
+		DetailsJSON, err := json.Marshal(Details)
+		if err != nil {
+			logging.WriteLog(logging.LogLevelError, "rootrouter/writeAuditLog", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to encode audit details.", err.Error(), string(Action)})
+			return err
+		}
+		Log.Details = DetailsJSON
+	}
+	if err := database.DBInterface.AddAuditLog(context.WithoutCancel(ctx), Log); err != nil {
 		sid := strconv.FormatUint(UserID, 10)
-		logging.WriteLog(logging.LogLevelError, "rootrouter/writeAuditLog", sid, logging.ResultFailure, []string{"Failed to write audit entry.", err.Error(), Type, Info})
+		logging.WriteLog(logging.LogLevelError, "rootrouter/writeAuditLog", sid, logging.ResultFailure, []string{"Failed to write audit entry.", err.Error(), string(Action), string(TargetType), strconv.FormatUint(TargetID, 10), string(Log.Details)})
 		return err
 	}
 	return nil
 }
 
-//WriteAuditLogByName Writes to the DB audit log, requires UserName
-func WriteAuditLogByName(UserName string, Type string, Info string) error {
-	userID, err := database.DBInterface.GetUserID(UserName)
+//WriteAuditLogByName Writes an event to the DB audit log, requires UserName
+func WriteAuditLogByName(ctx context.Context, UserName string, Action interfaces.AuditAction, TargetType interfaces.AuditTargetType, TargetID uint64, Details interfaces.AuditDetails) error {
+	userID, err := database.DBInterface.GetUserID(context.WithoutCancel(ctx), UserName)
 	if err != nil {
-		logging.WriteLog(logging.LogLevelError, "rootrouter/writeAuditLog", UserName, logging.ResultFailure, []string{"Could not get user id for audit log.", err.Error(), Type, Info})
+		logging.WriteLog(logging.LogLevelError, "rootrouter/writeAuditLog", UserName, logging.ResultFailure, []string{"Could not get user id for audit log.", err.Error(), string(Action)})
 	}
-	return WriteAuditLog(userID, Type, Info)
+	return WriteAuditLog(ctx, userID, Action, TargetType, TargetID, Details)
 }
@@ -49,6 +49,10 @@ type templateInput struct {
 	PreviousMemberID uint64
 	//NextMemberID When in a single image view, this should be set to the ID of the next image in the search (For next button)
 	NextMemberID uint64
+	//NextPageLink links to the next page of image search results by cursor, blank on the last page
+	NextPageLink string
+	//SavedSearches are the signed in user's saved searches, listed in the head menu and on the account page
+	SavedSearches []interfaces.SavedSearch
 	//ViewMode changes view mode, can be either "" for normal, stream, to view full images in stream, or slideshow for a auto-playing slideshow
 	ViewMode string
 	//SlideShowSpeed controls speed of slideshow in seconds
@@ -59,6 +63,26 @@ type templateInput struct {
 	RequestTime int64
 	//ModUserData contains information for the modUser page
 	ModUserData interfaces.UserInformation
+	//ImageRevisions is a page of an image's history, newest first
+	ImageRevisions []interfaces.ImageRevision
+	//UserRevertChanges is a page of what undoing a user's changes on the modUser page would put back
+	UserRevertChanges []UserRevertChange
+	//RevertSince and RevertUntil are the time window of the user's changes to undo, in datetime-local format
+	RevertSince string
+	RevertUntil string
+	//RevertCount is how many changes undoing a user's changes would put back, leaving out ones changed again since
+	RevertCount int
+	//AuditLogs is a page of audit log search results, newest first
+	AuditLogs []interfaces.AuditLog
+	//AuditLogSearch is the filter the audit log page was searched with
+	AuditLogSearch AuditLogSearch
+	//Jobs is a page of background jobs with the status JobStatus, most recently updated first
+	Jobs      []interfaces.Job
+	JobStatus interfaces.JobStatus
+	//JobCounts is how many background jobs there are of each status, keyed by the status as a string so templates can index it
+	JobCounts map[string]uint64
+	//Calendar is the upload calendar or timeline
+	Calendar UploadCalendar
 }
 
 func (ti templateInput) IsLoggedOn() bool {
@@ -90,7 +114,7 @@ func ValidateUserLogon(request *http.Request) (uint64, string, string) {
 	userNameT, tokenIDT, _ := getSessionInformation(request) //This bit actually validates, returns "","" otherwise
 	if tokenIDT != "" && userNameT != "" {
 		//Translate UserID
-		userID, err := database.DBInterface.GetUserID(userNameT)
+		userID, err := database.DBInterface.GetUserID(request.Context(), userNameT)
 		if err == nil {
 			return userID, userNameT, tokenIDT
 		}
@@ -113,13 +137,17 @@ func getNewTemplateInput(responseWriter http.ResponseWriter, request *http.Reque
 	//Verify user is logged in by validating token
 	userNameT, tokenIDT, session := getSessionInformation(request)
 	if tokenIDT != "" && userNameT != "" {
-		permissions, _ := database.DBInterface.GetUserPermissionSet(userNameT)
+		permissions, _ := database.DBInterface.GetUserPermissionSet(request.Context(), userNameT)
 		TemplateInput.UserPermissions = interfaces.UserPermission(permissions)
 		//Translate UserID
-		userID, err := database.DBInterface.GetUserID(userNameT)
+		userID, err := database.DBInterface.GetUserID(request.Context(), userNameT)
 		if err == nil {
 			TemplateInput.UserInformation.ID = userID
 			TemplateInput.UserInformation.Name = userNameT
+			TemplateInput.SavedSearches, err = database.DBInterface.GetSavedSearches(request.Context(), userID)
+			if err != nil {
+				logging.WriteLog(logging.LogLevelError, "routertemplate/getNewTemplateInput", userNameT, logging.ResultFailure, []string{"Failed to get saved searches: ", err.Error()})
+			}
 		} else {
 			logging.WriteLog(logging.LogLevelError, "routertemplate/getNewTemplateInput", userNameT, logging.ResultFailure, []string{"Failed to get UserID: ", err.Error()})
 		}
@@ -157,7 +185,7 @@ func getNewTemplateInput(responseWriter http.ResponseWriter, request *http.Reque
 	if time.Since(totalCacheTime).Hours() > 1 {
 		//Get new total
 		var err error
-		_, totalImages, err = database.DBInterface.SearchImages(make([]interfaces.TagInformation, 0), 0, 1)
+		_, totalImages, err = database.DBInterface.SearchImages(request.Context(), make([]interfaces.TagInformation, 0), 0, 1)
 		//Don't really care if this has a transient issue
 		if err != nil {
 			logging.WriteLog(logging.LogLevelWarning, "routertemplate/getNewTemplateInput", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"failed to update count cache", err.Error()})
@@ -0,0 +1,55 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"go-image-board/logging"
+	"strconv"
+	"strings"
+)
+
+//ExpandSavedSearches replaces saved:name in a user's query with their saved search of that name, see interfaces.ExpandSavedSearches.
+//Signed out users have no saved searches, and the query is searched as it is if they cannot be loaded
+func ExpandSavedSearches(ctx context.Context, UserID uint64, Query string) string {
+	if UserID == 0 || strings.Contains(strings.ToLower(Query), "saved:") == false {
+		return Query
+	}
+	Saved, err := database.DBInterface.GetSavedSearches(ctx, UserID)
+	if err != nil {
+		logging.WriteLog(logging.LogLevelError, "savedsearches/ExpandSavedSearches", strconv.FormatUint(UserID, 10), logging.ResultFailure, []string{"Failed to load saved searches", err.Error()})
+		return Query
+	}
+	return interfaces.ExpandSavedSearches(Query, Saved)
+}
+
+//SaveSearch validates and saves a user's search under Name, the returned error can be shown to the user
+func SaveSearch(ctx context.Context, UserID uint64, Name string, Query string) error {
+	Name = strings.ToLower(strings.TrimSpace(Name))
+	Query = strings.TrimSpace(Query)
+	if err := interfaces.ValidateSavedSearchName(Name); err != nil {
+		return err
+	}
+	if Query == "" {
+		return errors.New("the query to save is blank")
+	}
+	if len(Query) > interfaces.MaxSavedSearchQueryLength {
+		return errors.New("the query is longer than " + strconv.Itoa(interfaces.MaxSavedSearchQueryLength) + " characters")
+	}
+	Saved, err := database.DBInterface.GetSavedSearches(ctx, UserID)
+	if err != nil {
+		return errors.New("failed to load your saved searches")
+	}
+	Replacing := false
+	for _, Search := range Saved {
+		Replacing = Replacing || Search.Name == Name
+	}
+	if Replacing == false && len(Saved) >= interfaces.MaxSavedSearches {
+		return errors.New("you already have " + strconv.Itoa(interfaces.MaxSavedSearches) + " saved searches, delete one first")
+	}
+	if err := database.DBInterface.SetSavedSearch(ctx, UserID, Name, Query); err != nil {
+		return errors.New("failed to save search")
+	}
+	return nil
+}
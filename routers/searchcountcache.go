@@ -0,0 +1,64 @@
+package routers
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+//searchCountLifetime is how long a cached result count is used before the search is counted again
+const searchCountLifetime = 5 * time.Minute
+
+//searchCountLimit is the most counts kept, the cache is emptied of expired counts (or entirely) when it fills up
+const searchCountLimit = 1000
+
+//searchCount is a cached result count and when it stops being used
+type searchCount struct {
+	Count   uint64
+	Expires time.Time
+}
+
+//SearchCountMap is an in-memory cache of image search result counts, so paging through a search does not count every match each page
+type SearchCountMap struct {
+	countMap   map[string]searchCount
+	countMutex sync.Mutex
+}
+
+//SearchCounts is a thread-safe cache of image search result counts
+var SearchCounts SearchCountMap
+
+//searchCountKey returns the cache key for a user's query, users are kept apart as their filters change the results
+func searchCountKey(UserID uint64, Query string) string {
+	return strconv.FormatUint(UserID, 10) + " " + Query
+}
+
+//GetValue returns the cached result count for a user's query, and whether there was one
+func (searchCounts *SearchCountMap) GetValue(UserID uint64, Query string) (uint64, bool) {
+	searchCounts.countMutex.Lock()
+	defer searchCounts.countMutex.Unlock()
+	if value, ok := searchCounts.countMap[searchCountKey(UserID, Query)]; ok && value.Expires.After(time.Now()) {
+		return value.Count, true
+	}
+	return 0, false
+}
+
+//SetValue caches the result count for a user's query
+func (searchCounts *SearchCountMap) SetValue(UserID uint64, Query string, Count uint64) {
+	searchCounts.countMutex.Lock()
+	defer searchCounts.countMutex.Unlock()
+	now := time.Now()
+	if searchCounts.countMap == nil {
+		searchCounts.countMap = make(map[string]searchCount)
+	}
+	if len(searchCounts.countMap) >= searchCountLimit {
+		for key, value := range searchCounts.countMap {
+			if value.Expires.After(now) == false {
+				delete(searchCounts.countMap, key)
+			}
+		}
+		if len(searchCounts.countMap) >= searchCountLimit {
+			clear(searchCounts.countMap)
+		}
+	}
+	searchCounts.countMap[searchCountKey(UserID, Query)] = searchCount{Count: Count, Expires: now.Add(searchCountLifetime)}
+}
@@ -24,7 +24,7 @@ func TagGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	}
 
 	//Populate Tag
-	tag, err := database.DBInterface.GetTag(requestedID, true)
+	tag, err := database.DBInterface.GetTag(request.Context(), requestedID, true)
 	if err != nil {
 		TemplateInput.HTMLMessage += template.HTML("Error pulling tag.<br>")
 		logging.WriteLog(logging.LogLevelError, "tagrouter/TagRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to pull tags ", err.Error()})
@@ -33,7 +33,7 @@ func TagGetRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	}
 
 	if tag.IsAlias {
-		aliasInfo, err := database.DBInterface.GetTag(tag.AliasedID, true)
+		aliasInfo, err := database.DBInterface.GetTag(request.Context(), tag.AliasedID, true)
 		TemplateInput.AliasTagInfo = aliasInfo
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Tag is an alias, error pulling parent tag's information.<br>")
@@ -65,7 +65,7 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 			redirectWithFlash(responseWriter, request, "/tags?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagFail")
 			return
 		}
-		tagInfo, err := database.DBInterface.GetTag(requestedID, false)
+		tagInfo, err := database.DBInterface.GetTag(request.Context(), requestedID, false)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Error getting tag info.<br>")
 			logging.WriteLog(logging.LogLevelError, "tagrouter/TagRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to get tag info ", err.Error()})
@@ -76,7 +76,7 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 		//Validate permission to upload
 		if TemplateInput.UserPermissions.HasPermission(interfaces.ModifyTags) != true && (config.Configuration.UsersControlOwnObjects != true || TemplateInput.UserInformation.ID != tagInfo.UploaderID) {
 			TemplateInput.HTMLMessage += template.HTML("User does not have modify permission for tags.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "MODIFY-TAG", TemplateInput.UserInformation.Name+" failed to update tag. Insufficient permissions. "+strconv.FormatUint(requestedID, 10))
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditTagModify, interfaces.AuditTargetTag, requestedID, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/tag?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagFail")
 			return
 		}
@@ -86,7 +86,7 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 		var aliasID uint64
 		//Get alias information if needed
 		if request.FormValue("aliasedTagName") != "" {
-			aliasedTags, err = database.DBInterface.GetQueryTags(request.FormValue("aliasedTagName"), false)
+			aliasedTags, err = database.DBInterface.GetQueryTags(request.Context(), request.FormValue("aliasedTagName"), false)
 			if err != nil || len(aliasedTags) != 1 {
 				TemplateInput.HTMLMessage += template.HTML("Error parsing alias information. Ensure you are not putting in multiple tags to alias, and that you are not pointing the alias to an alias.<br>")
 				logging.WriteLog(logging.LogLevelError, "tagrouter/TagRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to parse alias id "})
@@ -95,13 +95,13 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 			aliasID = aliasedTags[0].ID
 		}
 		//Update tag
-		if err := database.DBInterface.UpdateTag(requestedID, request.FormValue("tagName"), request.FormValue("tagDescription"), aliasID, len(aliasedTags) == 1, TemplateInput.UserInformation.ID); err != nil {
+		if err := database.DBInterface.UpdateTag(request.Context(), requestedID, request.FormValue("tagName"), request.FormValue("tagDescription"), aliasID, len(aliasedTags) == 1, TemplateInput.UserInformation.ID); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to update tag. Is your name too short? Did it exist in the first place?<br>")
 			redirectWithFlash(responseWriter, request, "/tag?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagFail")
 			return
 		}
 		TemplateInput.HTMLMessage += template.HTML("Tag updated successfully.<br>")
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "MODIFY-TAG", TemplateInput.UserInformation.Name+" successfully updated tag. "+strconv.FormatUint(requestedID, 10)+" to alias "+request.FormValue("aliasedTagName")+" with name "+request.FormValue("tagName")+" and description "+request.FormValue("tagDescription"))
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditTagModify, interfaces.AuditTargetTag, requestedID, interfaces.AuditDetails{"Name": request.FormValue("tagName"), "Description": request.FormValue("tagDescription"), "AliasID": aliasID})
 		redirectWithFlash(responseWriter, request, "/tag?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagSucceeded")
 		return
 	case "bulkAddTag":
@@ -120,13 +120,13 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 		//Validate permission to bulk modify tags
 		if TemplateInput.UserPermissions.HasPermission(interfaces.ModifyImageTags) != true || TemplateInput.UserPermissions.HasPermission(interfaces.BulkTagOperations) != true {
 			TemplateInput.HTMLMessage += template.HTML("User does not have modify permission for bulk tagging on images.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "ADD-BULKIMAGETAG", TemplateInput.UserInformation.Name+" failed to add tag to images. Insufficient permissions. "+oldTagQuery+"->"+newTagQuery)
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditTagBulkAdd, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"OldTag": oldTagQuery, "NewTag": newTagQuery, "Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/tags?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagFail")
 			return
 		}
 		//Parse out tag arguments
-		userOldQTags, err := database.DBInterface.GetQueryTags(oldTagQuery, false)
-		userNewQTags, err2 := database.DBInterface.GetQueryTags(newTagQuery, false)
+		userOldQTags, err := database.DBInterface.GetQueryTags(request.Context(), oldTagQuery, false)
+		userNewQTags, err2 := database.DBInterface.GetQueryTags(request.Context(), newTagQuery, false)
 		if err != nil || err2 != nil || len(userOldQTags) != 1 || len(userNewQTags) != 1 || userOldQTags[0].Exists == false || userNewQTags[0].Exists == false || userOldQTags[0].ID == userNewQTags[0].ID {
 			TemplateInput.HTMLMessage += template.HTML("Failed to get tags from user input. Ensure the tags you entered exist and that you did not enter more than one per field. And that the new and old tags are not the same tag or alias to the same tag.<br>")
 			redirectWithFlash(responseWriter, request, "/tags?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagFail")
@@ -134,7 +134,9 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 		}
 
 		//Confirmed tags exist and are valid
-		err = database.DBInterface.BulkAddTag(userNewQTags[0].ID, userOldQTags[0].ID, TemplateInput.UserInformation.ID)
+		err = changeTaggedImages(request.Context(), userOldQTags[0].ID, TemplateInput.UserInformation.ID, func(Tx interfaces.DBInterface) error {
+			return Tx.BulkAddTag(request.Context(), userNewQTags[0].ID, userOldQTags[0].ID, TemplateInput.UserInformation.ID)
+		})
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Error adding tags (SQL).<br>")
 			logging.WriteLog(logging.LogLevelError, "tagrouter/TagRouter/bulkAddTag", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to bulk add tags due to a SQL error", err.Error(), newTagQuery, oldTagQuery})
@@ -142,7 +144,7 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 			return
 		}
 		TemplateInput.HTMLMessage += template.HTML("Tags added successfully.<br>")
-		go WriteAuditLog(TemplateInput.UserInformation.ID, "ADD-BULKIMAGETAG", TemplateInput.UserInformation.Name+" bulk added tags to images. "+oldTagQuery+"->"+newTagQuery)
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditTagBulkAdd, interfaces.AuditTargetTag, userOldQTags[0].ID, interfaces.AuditDetails{"NewTagID": userNewQTags[0].ID})
 		redirectWithFlash(responseWriter, request, "/tag?ID="+strconv.FormatUint(userNewQTags[0].ID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagSucceeded")
 		return
 	case "replaceTag":
@@ -162,13 +164,13 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 		//Validate permission to bulk modify tags
 		if TemplateInput.UserPermissions.HasPermission(interfaces.ModifyImageTags) != true || TemplateInput.UserPermissions.HasPermission(interfaces.BulkTagOperations) != true {
 			TemplateInput.HTMLMessage += template.HTML("User does not have modify permission for bulk tagging on images.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "REPLACE-BULKIMAGETAG", TemplateInput.UserInformation.Name+" failed to add tag to images. Insufficient permissions. "+oldTagQuery+"->"+newTagQuery)
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditTagBulkReplace, interfaces.AuditTargetNone, 0, interfaces.AuditDetails{"OldTag": oldTagQuery, "NewTag": newTagQuery, "Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/tags?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagFail")
 			return
 		}
 		//Parse out tag arguments
-		userOldQTags, err := database.DBInterface.GetQueryTags(oldTagQuery, false)
-		userNewQTags, err2 := database.DBInterface.GetQueryTags(newTagQuery, false)
+		userOldQTags, err := database.DBInterface.GetQueryTags(request.Context(), oldTagQuery, false)
+		userNewQTags, err2 := database.DBInterface.GetQueryTags(request.Context(), newTagQuery, false)
 		if err != nil || err2 != nil || len(userOldQTags) != 1 || len(userNewQTags) != 1 || userOldQTags[0].Exists == false || userNewQTags[0].Exists == false || userOldQTags[0].ID == userNewQTags[0].ID {
 			TemplateInput.HTMLMessage += template.HTML("Failed to get tags from user input. Ensure the tags you entered exist and that you did not enter more than one per field. And that the new and old tags are not the same tag or alias to the same tag.<br>")
 			redirectWithFlash(responseWriter, request, "/tags?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagFail")
@@ -176,7 +178,9 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 		}
 
 		//Confirmed tags exist and are valid, now replace
-		err = database.DBInterface.ReplaceImageTags(userOldQTags[0].ID, userNewQTags[0].ID, TemplateInput.UserInformation.ID)
+		err = changeTaggedImages(request.Context(), userOldQTags[0].ID, TemplateInput.UserInformation.ID, func(Tx interfaces.DBInterface) error {
+			return Tx.ReplaceImageTags(request.Context(), userOldQTags[0].ID, userNewQTags[0].ID, TemplateInput.UserInformation.ID)
+		})
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Error adding tags (SQL).<br>")
 			logging.WriteLog(logging.LogLevelError, "tagrouter/TagRouter/replaceTag", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to bulk replace tags due to a SQL error", err.Error(), newTagQuery, oldTagQuery})
@@ -185,7 +189,7 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 		}
 
 		TemplateInput.HTMLMessage += template.HTML("Tags replaced successfully.<br>")
-		go WriteAuditLog(TemplateInput.UserInformation.ID, "REPLACE-BULKIMAGETAG", TemplateInput.UserInformation.Name+" bulk added tags to images. "+oldTagQuery+"->"+newTagQuery)
+		go WriteAuditLog(request.Context(), TemplateInput.UserInformation.ID, interfaces.AuditTagBulkReplace, interfaces.AuditTargetTag, userOldQTags[0].ID, interfaces.AuditDetails{"NewTagID": userNewQTags[0].ID})
 		redirectWithFlash(responseWriter, request, "/tag?ID="+strconv.FormatUint(userNewQTags[0].ID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagSucceeded")
 		return
 	case "delete":
@@ -202,7 +206,7 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 			redirectWithFlash(responseWriter, request, "/tags?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagFail")
 			return
 		}
-		tagInfo, err := database.DBInterface.GetTag(requestedID, false)
+		tagInfo, err := database.DBInterface.GetTag(request.Context(), requestedID, false)
 		if err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Error getting tag info.<br>")
 			logging.WriteLog(logging.LogLevelError, "tagrouter/TagRouter/delete", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to get tag info ", err.Error()})
@@ -213,21 +217,21 @@ func TagPostRouter(responseWriter http.ResponseWriter, request *http.Request) {
 		//Validate permission to delete
 		if TemplateInput.UserPermissions.HasPermission(interfaces.RemoveTags) != true && (config.Configuration.UsersControlOwnObjects != true || TemplateInput.UserInformation.ID != tagInfo.UploaderID) {
 			TemplateInput.HTMLMessage += template.HTML("User does not have modify permission for tags.<br>")
-			go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-TAG", TemplateInput.UserInformation.Name+" failed to delete tag. Insufficient permissions. "+strconv.FormatUint(requestedID, 10))
+			go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditTagDelete, interfaces.AuditTargetTag, requestedID, interfaces.AuditDetails{"Error": interfaces.AuditDenied})
 			redirectWithFlash(responseWriter, request, "/tag?ID="+strconv.FormatUint(requestedID, 10)+"&SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagFail")
 			return
 		}
 		// /ValidatePermission
 
 		//Update tag
-		if err := database.DBInterface.DeleteTag(requestedID); err != nil {
+		if err := database.DBInterface.DeleteTag(request.Context(), requestedID); err != nil {
 			TemplateInput.HTMLMessage += template.HTML("Failed to delete tag. Ensure the tag is not currently in use.<br>")
 			redirectWithFlash(responseWriter, request, "/tags?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "TagFail")
 			return
 		}
 
 		TemplateInput.HTMLMessage += template.HTML("Tag deleted successfully.<br>")
-		go WriteAuditLogByName(TemplateInput.UserInformation.Name, "DELETE-TAG", TemplateInput.UserInformation.Name+" successfully deleted tag. "+strconv.FormatUint(requestedID, 10))
+		go WriteAuditLogByName(request.Context(), TemplateInput.UserInformation.Name, interfaces.AuditTagDelete, interfaces.AuditTargetTag, requestedID, interfaces.AuditDetails{"Name": tagInfo.Name})
 		//redirect user to tags since we just deleted this one
 		redirectWithFlash(responseWriter, request, "/tags?SearchTerms="+url.QueryEscape(TemplateInput.OldQuery), TemplateInput.HTMLMessage, "DeleteSuccess")
 		return
@@ -24,7 +24,7 @@ func TagsRouter(responseWriter http.ResponseWriter, request *http.Request) {
 	pageStride := config.Configuration.PageStride
 
 	//Populate Tags
-	tag, totalResults, err := database.DBInterface.SearchTags(TagSearch, pageStart, pageStride, false, false)
+	tag, totalResults, err := database.DBInterface.SearchTags(request.Context(), TagSearch, pageStart, pageStride, false, false)
 	if err != nil {
 		TemplateInput.HTMLMessage += template.HTML("Error pulling tags.<br>")
 		logging.WriteLog(logging.LogLevelError, "tagrouter/TagsRouter", TemplateInput.UserInformation.GetCompositeID(), logging.ResultFailure, []string{"Failed to pull tags ", err.Error()})
@@ -76,11 +76,32 @@ func CacheTemplates() error {
 	getEmbed := func(value interface{}) template.HTML {
 		return GetEmbedForContent(fmt.Sprintf("%v", value))
 	}
+	formatFileSize := func(value uint64) string {
+		if value < 1024 {
+			return strconv.FormatUint(value, 10) + " B"
+		}
+		size := float64(value) / 1024
+		unit := 0
+		for size >= 1024 && unit < 3 {
+			size /= 1024
+			unit++
+		}
+		return strconv.FormatFloat(size, 'f', 1, 64) + " " + []string{"KiB", "MiB", "GiB", "TiB"}[unit]
+	}
+	formatDuration := func(value float64) string {
+		seconds := int64(value + 0.5)
+		if seconds >= 3600 {
+			return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+		}
+		return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+	}
 	templates := template.New("")
 	templates = templates.Funcs(template.FuncMap{"getimagetype": getImageType})
 	templates = templates.Funcs(template.FuncMap{"inc": increment})
 	templates = templates.Funcs(template.FuncMap{"dec": decrement})
 	templates = templates.Funcs(template.FuncMap{"getEmbed": getEmbed})
+	templates = templates.Funcs(template.FuncMap{"formatFileSize": formatFileSize})
+	templates = templates.Funcs(template.FuncMap{"formatDuration": formatDuration})
 
 	templates, err = templates.ParseFiles(allFiles...)
 	if err != nil {
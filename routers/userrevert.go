@@ -0,0 +1,132 @@
+package routers
+
+import (
+	"context"
+	"go-image-board/database"
+	"go-image-board/interfaces"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+)
+
+//UserRevertChange is one field, tag or collection of an image that undoing a user's changes puts back
+type UserRevertChange struct {
+	//Revision is the user's first change to it in the time window, its OldValue is what it is put back to
+	Revision interfaces.ImageRevision
+	//Current is what it holds now
+	Current string
+	//ChangedSince is set when someone else changed it after the user did, those are left alone
+	ChangedSince bool
+}
+
+//datetimeLocalFormat is the format of datetime-local inputs, the board treats them as UTC
+const datetimeLocalFormat = "2006-01-02T15:04"
+
+//userRevertStride how many changes are shown per page of a mass revert preview
+const userRevertStride = 50
+
+//parseRevertWindow reads the RevertSince and RevertUntil form values. An empty RevertUntil means up to the end of the current minute
+func parseRevertWindow(request *http.Request) (time.Time, time.Time, error) {
+	Since, err := time.ParseInLocation(datetimeLocalFormat, request.FormValue("RevertSince"), time.UTC)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if request.FormValue("RevertUntil") == "" {
+		return Since, time.Now().UTC().Truncate(time.Minute).Add(time.Minute), nil
+	}
+	Until, err := time.ParseInLocation(datetimeLocalFormat, request.FormValue("RevertUntil"), time.UTC)
+	return Since, Until, err
+}
+
+//sameRevisionValue reports whether two values of the field, tag or collection a revision changed are the same.
+//Tags and collections only compare whether the image has them, so renaming one does not count as a change
+func sameRevisionValue(Revision interfaces.ImageRevision, A string, B string) bool {
+	if Revision.Field == interfaces.RevisionTag || Revision.Field == interfaces.RevisionCollection {
+		return (A == "") == (B == "")
+	}
+	return A == B
+}
+
+//planUserRevert works out what undoing every change UserID made to images from Since up to Until would put back, in the order the user first made them.
+//Changes that have already been undone are left out
+func planUserRevert(ctx context.Context, DB interfaces.DBInterface, UserID uint64, Since time.Time, Until time.Time) ([]UserRevertChange, error) {
+	Revisions, err := DB.GetUserImageRevisions(ctx, UserID, Since, Until)
+	if err != nil {
+		return nil, err
+	}
+	type planKey struct {
+		ImageID uint64
+		Key     revisionKey
+	}
+	//The first change the user made to each field, tag or collection holds its value from before, the last one what the user left it at
+	var Firsts []interfaces.ImageRevision
+	LastValues := make(map[planKey]string)
+	for _, Revision := range Revisions {
+		Key := planKey{ImageID: Revision.ImageID, Key: keyOf(Revision)}
+		if _, found := LastValues[Key]; found == false {
+			Firsts = append(Firsts, Revision)
+		}
+		LastValues[Key] = Revision.NewValue
+	}
+
+	States := make(map[uint64]imageState)
+	var ToReturn []UserRevertChange
+	for _, Revision := range Firsts {
+		State, found := States[Revision.ImageID]
+		if found == false {
+			if State, err = getImageState(ctx, DB, Revision.ImageID); err != nil {
+				return nil, err
+			}
+			States[Revision.ImageID] = State
+		}
+		Change := UserRevertChange{Revision: Revision, Current: State.value(Revision)}
+		if sameRevisionValue(Revision, Change.Current, Revision.OldValue) {
+			continue
+		}
+		Change.ChangedSince = sameRevisionValue(Revision, Change.Current, LastValues[planKey{ImageID: Revision.ImageID, Key: keyOf(Revision)}]) == false
+		ToReturn = append(ToReturn, Change)
+	}
+	return ToReturn, nil
+}
+
+//revertUserChanges undoes every change UserID made to images from Since up to Until, in one transaction, and returns how many were undone.
+//Changes someone else has changed again since are left alone. The reverts are recorded as revisions made by ModeratorID
+func revertUserChanges(ctx context.Context, UserID uint64, Since time.Time, Until time.Time, ModeratorID uint64) (int, error) {
+	var Reverted int
+	err := database.DBInterface.RunInTransaction(ctx, func(Tx interfaces.DBInterface) error {
+		Plan, err := planUserRevert(ctx, Tx, UserID, Since, Until)
+		if err != nil {
+			return err
+		}
+		ByImage := make(map[uint64][]UserRevertChange)
+		for _, Change := range Plan {
+			if Change.ChangedSince == false {
+				ByImage[Change.Revision.ImageID] = append(ByImage[Change.Revision.ImageID], Change)
+			}
+		}
+		for _, ImageID := range slices.Sorted(maps.Keys(ByImage)) {
+			err := changeImages(ctx, Tx, ModeratorID, func(Tx interfaces.DBInterface) ([]uint64, error) {
+				return []uint64{ImageID}, nil
+			}, func(Tx interfaces.DBInterface) error {
+				Target, err := getImageState(ctx, Tx, ImageID)
+				if err != nil {
+					return err
+				}
+				for _, Change := range ByImage[ImageID] {
+					Target.setValue(Change.Revision, Change.Revision.OldValue)
+				}
+				return applyImageState(ctx, Tx, ImageID, Target, ModeratorID)
+			})
+			if err != nil {
+				return err
+			}
+			Reverted += len(ByImage[ImageID])
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return Reverted, nil
+}
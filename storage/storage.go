@@ -0,0 +1,8 @@
+package storage
+
+import (
+	"go-image-board/interfaces"
+)
+
+//BlobStore is a global variable for access to media files
+var BlobStore interfaces.BlobStore